@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,17 +11,28 @@ import (
 	"syscall"
 	"time"
 
-	_ "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/docs" // This is generated by swag
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/docs" // This is generated by swag
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/swaggo/swag"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
 	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/auditsink"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/encryption"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/requestid"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
 )
 
 // @title HelpChat API
@@ -44,8 +56,32 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	migrateTo := flag.Int("migrate-to", -1, "run or roll back the database migrations to this version and exit; 0 rolls back every migration")
+	resetDemo := flag.Bool("reset-demo", false, "wipe the database, reseed it with the demo data and exit; requires DEMO_MODE=true")
+	flag.Parse()
+
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.LoadFile(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	if err := services.LoadRolePolicy(cfg); err != nil {
+		log.Fatal("Failed to load role policy:", err)
+	}
+
+	// Load encryption keys before any sensitive column is read or written
+	secretsProvider, err := secrets.NewProvider(cfg.Secrets.Provider, cfg.Secrets.Dir)
+	if err != nil {
+		log.Fatal("Failed to configure secrets provider:", err)
+	}
+	keyring, err := encryption.LoadKeyring(context.Background(), secretsProvider, cfg.Encryption.KeysSecret, cfg.Encryption.PrimaryKeyID)
+	if err != nil {
+		log.Fatal("Failed to load encryption keys:", err)
+	}
+	if keyring == nil {
+		log.Println("Warning: no encryption keys configured, sensitive columns are stored as plaintext")
+	}
+	encryption.SetKeyring(keyring)
 
 	// Initialize database
 	db, err := database.NewDatabase(cfg)
@@ -54,6 +90,14 @@ func main() {
 	}
 	defer db.Close()
 
+	// Migrate to a given version from the command line instead of serving
+	if *migrateTo >= 0 {
+		if err := database.MigrateTo(db, *migrateTo); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		return
+	}
+
 	// Run database migrations
 	if err := database.RunMigrations(db); err != nil {
 		log.Fatal("Failed to run migrations:", err)
@@ -69,6 +113,15 @@ func main() {
 		log.Fatal("Failed to seed database:", err)
 	}
 
+	// Reset a demo environment from the command line instead of serving
+	if *resetDemo {
+		demoService := services.NewDemoService(repository.NewDemoRepository(db), storage.NewLocalStorage(cfg.Storage.LocalPath), cfg)
+		if _, err := demoService.Reset(context.Background()); err != nil {
+			log.Fatal("Failed to reset demo data:", err)
+		}
+		return
+	}
+
 	// Initialize Echo instance
 	e := echo.New()
 
@@ -77,6 +130,13 @@ func main() {
 	e.HideBanner = true
 	e.HidePort = true
 
+	// Error responses are sent as problem+json to clients that ask for it
+	errorFormats := authMiddleware.NewProblemJSON()
+	e.JSONSerializer = authMiddleware.NewLocalTimeJSON(errorFormats)
+
+	// Unknown enum values such as a ticket status are rejected with the allowed values listed
+	e.Binder = authMiddleware.NewBinder()
+
 	// Setup middleware
 	setupMiddleware(e, cfg)
 
@@ -86,21 +146,264 @@ func main() {
 	categoryRepo := repository.NewCategoryRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
 	attachmentRepo := repository.NewAttachmentRepository(db)
+	draftRepo := repository.NewDraftAttachmentRepository(db)
+	quarantineRepo := repository.NewQuarantineRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	encryptedColumnRepo := repository.NewEncryptedColumnRepository(db)
+	loginEventRepo := repository.NewLoginEventRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	outOfOfficeRepo := repository.NewOutOfOfficeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	problemRepo := repository.NewProblemRepository(db)
+	statusBannerRepo := repository.NewStatusBannerRepository(db)
+	knowledgeRepo := repository.NewKnowledgeRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+	chatTriggerRepo := repository.NewChatTriggerRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+	emailTemplateRepo := repository.NewEmailTemplateRepository(db)
+	emailDeliveryRepo := repository.NewEmailDeliveryRepository(db)
+	notificationQueueRepo := repository.NewNotificationQueueRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	featureUsageRepo := repository.NewFeatureUsageRepository(db)
+	failedTaskRepo := repository.NewFailedTaskRepository(db)
+	accountTokenRepo := repository.NewAccountTokenRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
+	offboardingRepo := repository.NewOffboardingRepository(db)
+	sandboxRepo := repository.NewSandboxRepository(db)
+	demoRepo := repository.NewDemoRepository(db)
+	inboundIntegrationRepo := repository.NewInboundIntegrationRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg)
-	ticketService := services.NewTicketService(ticketRepo, categoryRepo, commentRepo, attachmentRepo, userRepo)
+	auditForwarder, err := auditsink.New(context.Background(), cfg.Audit, secretsProvider)
+	if err != nil {
+		log.Fatal("Failed to configure audit sinks:", err)
+	}
+	auditService := services.NewAuditService(auditLogRepo, auditForwarder)
+	failedTaskService := services.NewFailedTaskService(failedTaskRepo, auditService)
+	redactionService, err := services.NewRedactionService(cfg, auditService)
+	if err != nil {
+		log.Fatal("Failed to configure redaction:", err)
+	}
+	encryptionService := services.NewEncryptionService(encryptedColumnRepo, auditService, keyring)
+	emailLayout, err := email.NewLayout(cfg.Email)
+	if err != nil {
+		log.Fatal("Invalid email branding:", err)
+	}
+	providerSender, err := email.NewSender(context.Background(), cfg.Email, secretsProvider)
+	if err != nil {
+		log.Fatal("Failed to configure email provider:", err)
+	}
+	emailSender := email.NewLayoutSender(providerSender, emailLayout)
+	emailDeliveryService := services.NewEmailDeliveryService(emailSender, emailDeliveryRepo, userRepo, failedTaskService, cfg)
+	failedTaskService.Register(models.FailedTaskEmail, emailDeliveryService)
+	loginHistoryService := services.NewLoginHistoryService(loginEventRepo, emailDeliveryService, cfg)
+	fileStorage := storage.NewLocalStorage(cfg.Storage.LocalPath)
+	userService := services.NewUserService(userRepo)
+	emailTemplateService := services.NewEmailTemplateService(emailTemplateRepo, cfg)
+	accountService := services.NewAccountService(userRepo, accountTokenRepo, emailDeliveryService, emailTemplateService, cfg)
+	quietHoursService := services.NewQuietHoursService(notificationQueueRepo, emailDeliveryService, cfg)
+	notificationService := services.NewNotificationService(notificationRepo)
+	emailChannelService := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, emailDeliveryService, fileStorage, redactionService, emailTemplateService, quietHoursService, notificationService, cfg)
+	attachmentService := services.NewAttachmentService(attachmentRepo, draftRepo, ticketRepo, commentRepo, fileStorage, cfg)
+	ticketTrashService := services.NewTicketTrashService(ticketRepo, fileStorage, cfg)
+	offboardingService := services.NewOffboardingService(offboardingRepo, userRepo, teamRepo, auditService)
+	sandboxService := services.NewSandboxService(sandboxRepo, fileStorage, cfg)
+	demoService := services.NewDemoService(demoRepo, fileStorage, cfg)
+	retentionService := services.NewRetentionService(retentionRepo, attachmentRepo, fileStorage, auditService, cfg)
+	delegationService := services.NewDelegationService(outOfOfficeRepo, ticketRepo, userRepo)
+	hub := realtime.NewHub()
+	ticketEventService := services.NewTicketEventService(hub, teamRepo)
+	webhookService := services.NewWebhookService(webhookRepo)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, commentRepo, userRepo, teamRepo, cfg, services.TicketServiceDeps{
+		AttachmentRepo: attachmentRepo,
+		DraftRepo:      draftRepo,
+		Delegation:     delegationService,
+		Notifier:       webhookService.Notifier(notificationService.Notifier(emailChannelService)),
+		Events:         ticketEventService,
+		Redaction:      redactionService,
+	})
+	shareService := services.NewShareService(ticketRepo, commentRepo, cfg)
+	inboundService := services.NewInboundService(inboundIntegrationRepo, userRepo, categoryRepo, teamRepo, ticketService)
+	teamService := services.NewTeamService(teamRepo, userRepo)
+	categoryService := services.NewCategoryService(categoryRepo)
+	assetService := services.NewAssetService(assetRepo, ticketRepo, userRepo)
+	problemService := services.NewProblemService(problemRepo, ticketRepo, ticketService)
+	statusBannerService := services.NewStatusBannerService(statusBannerRepo, categoryRepo, problemRepo, emailDeliveryService)
+	appCache := cache.NewMemory()
+	presenceService := services.NewPresenceService(appCache, userRepo, cfg)
+	ticketLockService := services.NewTicketLockService(appCache, ticketRepo, cfg)
+	widgetService, err := services.NewWidgetService(userRepo, chatTriggerRepo, presenceService, cfg)
+	if err != nil {
+		log.Fatal("Failed to configure widget:", err)
+	}
+	calendarService, err := services.NewBusinessCalendarService(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure business calendar:", err)
+	}
+	spamFilter := services.NewSpamFilter(cfg)
+	intakeService := services.NewIntakeService(ticketRepo, categoryRepo, userRepo, quarantineRepo, spamFilter, emailChannelService, ticketService)
+	knowledgeService := services.NewKnowledgeService(knowledgeRepo)
+	reportService := services.NewReportService(reportRepo, categoryRepo, chatRepo, presenceService, appCache, cfg)
+	searchService := services.NewSearchService(searchRepo, appCache)
+	usageService := services.NewUsageService(cfg)
+	featureUsageService := services.NewFeatureUsageService(featureUsageRepo, cfg)
+	customDomainService, err := services.NewCustomDomainService(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure custom domain:", err)
+	}
+	exportStorage, err := newExportStorage(cfg, secretsProvider)
+	if err != nil {
+		log.Fatal("Failed to configure analytics export:", err)
+	}
+	exportService := services.NewExportService(exportRepo, exportStorage, cfg)
+	chatService := services.NewChatService(chatRepo, userRepo, teamRepo, knowledgeService, widgetService, intakeService, presenceService, hub, emailDeliveryService, cfg)
+	reloadService := services.NewReloadService(auditService, cfg)
+	reloadService.Register("daily_quota", usageService)
+	reloadService.Register("spam_filter", spamFilter)
+	reloadService.Register("office_hours", widgetService)
+	reloadService.Register("business_calendar", calendarService)
+	reloadService.Register("email_branding", emailSender)
+	integrationHealthService := services.NewIntegrationHealthService()
+	if cfg.Email.Provider != "" || cfg.Email.SMTPHost != "" {
+		integrationHealthService.Register("email", emailDeliveryService)
+	}
+	if cfg.Export.Enabled {
+		integrationHealthService.Register("export_storage", exportService)
+	}
+	for _, sink := range auditForwarder.Sinks() {
+		integrationHealthService.Register("audit_"+sink.Name, sink)
+	}
 
 	// Initialize middleware
-	authMiddlewareInstance := authMiddleware.NewAuthMiddleware(authService)
+	authMiddlewareInstance := authMiddleware.NewAuthMiddleware(authService, usageService)
+	responseCache := authMiddleware.NewResponseCache(appCache, cfg)
+	botProtection, err := authMiddleware.NewBotProtection(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure bot protection:", err)
+	}
+	tokenLockout := authMiddleware.NewTokenLockout(auditService, cfg)
+	if cfg.FeatureUsage.Enabled {
+		e.Use(authMiddleware.FeatureUsage(featureUsageService))
+	}
 
 	// Initialize handlers
 	pingHandler := handlers.NewPingHandler(db)
-	authHandler := handlers.NewAuthHandler(authService)
+	metricsHandler := handlers.NewMetricsHandler(db, errorFormats, cfg)
+	slowQueryHandler := handlers.NewSlowQueryHandler(db)
+	profilingHandler := handlers.NewProfilingHandler(cfg)
+	featureUsageHandler := handlers.NewFeatureUsageHandler(featureUsageService)
+	customDomainHandler := handlers.NewCustomDomainHandler(customDomainService)
+	integrationHealthHandler := handlers.NewIntegrationHealthHandler(integrationHealthService)
+	failedTaskHandler := handlers.NewFailedTaskHandler(failedTaskService)
+	authHandler := handlers.NewAuthHandler(authService, accountService, loginHistoryService, botProtection, tokenLockout)
 	ticketHandler := handlers.NewTicketHandler(ticketService)
+	ticketTrashHandler := handlers.NewTicketTrashHandler(ticketTrashService)
+	retentionHandler := handlers.NewRetentionHandler(retentionService)
+	offboardingHandler := handlers.NewOffboardingHandler(offboardingService)
+	sandboxHandler := handlers.NewSandboxHandler(sandboxService)
+	demoHandler := handlers.NewDemoHandler(demoService, cfg)
+	shareHandler := handlers.NewShareHandler(shareService, tokenLockout)
+	subscriberHandler := handlers.NewTicketSubscriberHandler(ticketService, emailChannelService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	ticketLockHandler := handlers.NewTicketLockHandler(ticketLockService)
+	inboundEmailHandler := handlers.NewInboundEmailHandler(intakeService, cfg)
+	inboundIntegrationHandler := handlers.NewInboundIntegrationHandler(inboundService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	intakeHandler := handlers.NewIntakeHandler(intakeService, botProtection, responseCache)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	encryptionHandler := handlers.NewEncryptionHandler(encryptionService)
+	userHandler := handlers.NewUserHandler(userService, loginHistoryService, delegationService, usageService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	teamHandler := handlers.NewTeamHandler(teamService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	assetHandler := handlers.NewAssetHandler(assetService, ticketService)
+	problemHandler := handlers.NewProblemHandler(problemService)
+	statusBannerHandler := handlers.NewStatusBannerHandler(statusBannerService, botProtection)
+	widgetHandler := handlers.NewWidgetHandler(widgetService)
+	knowledgeHandler := handlers.NewKnowledgeHandler(knowledgeService, responseCache)
+	chatHandler := handlers.NewChatHandler(chatService, botProtection, tokenLockout, cfg)
+	presenceHandler := handlers.NewPresenceHandler(presenceService, cfg)
+	ticketEventHandler := handlers.NewTicketEventHandler(ticketEventService, cfg)
+	reportHandler := handlers.NewReportHandler(reportService, ticketService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	searchHandler := handlers.NewSearchHandler(searchService, ticketService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	reloadHandler := handlers.NewReloadHandler(reloadService)
+	emailTemplateHandler := handlers.NewEmailTemplateHandler(emailTemplateService)
+	emailDeliveryHandler := handlers.NewEmailDeliveryHandler(emailDeliveryService, cfg)
 
 	// Setup routes
-	setupRoutes(e, pingHandler, authHandler, ticketHandler, authMiddlewareInstance)
+	setupRoutes(e, &handlers.Handlers{
+		Ping:          pingHandler,
+		Metrics:       metricsHandler,
+		Auth:          authHandler,
+		Ticket:        ticketHandler,
+		TicketTrash:   ticketTrashHandler,
+		Share:         shareHandler,
+		Subscriber:    subscriberHandler,
+		Attachment:    attachmentHandler,
+		TicketLock:    ticketLockHandler,
+		InboundEmail:  inboundEmailHandler,
+		Inbound:       inboundIntegrationHandler,
+		Webhook:       webhookHandler,
+		Intake:        intakeHandler,
+		Audit:         auditHandler,
+		Encryption:    encryptionHandler,
+		Retention:     retentionHandler,
+		Offboarding:   offboardingHandler,
+		Sandbox:       sandboxHandler,
+		Demo:          demoHandler,
+		User:          userHandler,
+		Notification:  notificationHandler,
+		Team:          teamHandler,
+		Category:      categoryHandler,
+		Asset:         assetHandler,
+		Problem:       problemHandler,
+		StatusBanner:  statusBannerHandler,
+		Widget:        widgetHandler,
+		Knowledge:     knowledgeHandler,
+		Chat:          chatHandler,
+		Presence:      presenceHandler,
+		TicketEvents:  ticketEventHandler,
+		Report:        reportHandler,
+		Export:        exportHandler,
+		Search:        searchHandler,
+		Calendar:      calendarHandler,
+		Reload:        reloadHandler,
+		EmailTemplate: emailTemplateHandler,
+		EmailDelivery: emailDeliveryHandler,
+		SlowQuery:     slowQueryHandler,
+		Profiling:     profilingHandler,
+		FeatureUsage:  featureUsageHandler,
+		CustomDomain:  customDomainHandler,
+		Integrations:  integrationHealthHandler,
+		FailedTasks:   failedTaskHandler,
+	}, authMiddlewareInstance)
+
+	// Start background jobs
+	jobs := scheduler.New()
+	failedTaskService.WatchJobs(jobs)
+	if cfg.Export.Enabled {
+		if err := exportService.Schedule(jobs, cfg.Export.Time); err != nil {
+			log.Fatal("Failed to schedule analytics export:", err)
+		}
+	}
+	quietHoursService.Schedule(jobs)
+	attachmentService.Schedule(jobs)
+	ticketTrashService.Schedule(jobs)
+	offboardingService.Schedule(jobs)
+	sandboxService.Schedule(jobs)
+	demoService.Schedule(jobs)
+	if err := retentionService.Schedule(jobs); err != nil {
+		log.Fatal("Failed to schedule retention rules:", err)
+	}
+	featureUsageService.Schedule(jobs)
+	webhookService.Schedule(jobs)
+	jobs.Start()
 
 	// Start server
 	go func() {
@@ -124,11 +427,23 @@ func main() {
 	if err := e.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	jobs.Stop()
+	featureUsageService.Close(ctx)
+	if err := auditForwarder.Close(ctx); err != nil {
+		log.Println("Failed to forward remaining audit events:", err)
+	}
 
 	log.Println("Server exited")
 }
 
 func setupMiddleware(e *echo.Echo, cfg *config.Config) {
+	// Client IPs drive rate limiting, so forwarded headers are only trusted when configured
+	if cfg.Server.TrustProxyHeaders {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	// Convert config to Echo CORS format
 	allowMethods := make([]string, len(cfg.CORS.AllowedMethods))
 	for i, method := range cfg.CORS.AllowedMethods {
@@ -145,7 +460,7 @@ func setupMiddleware(e *echo.Echo, cfg *config.Config) {
 		AllowMethods:     allowMethods,
 		AllowHeaders:     allowHeaders,
 		AllowCredentials: cfg.CORS.AllowCredentials,
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		MaxAge:           86400, // 24 hours
 	}
 
@@ -154,14 +469,19 @@ func setupMiddleware(e *echo.Echo, cfg *config.Config) {
 
 	// Request logging middleware
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "method=${method}, uri=${uri}, status=${status}, latency=${latency}, latency_human=${latency_human}\n",
+		Format: "id=${id}, method=${method}, uri=${uri}, status=${status}, latency=${latency}, latency_human=${latency_human}\n",
 	}))
 
 	// Recover middleware
 	e.Use(middleware.Recover())
 
-	// Request ID middleware
-	e.Use(middleware.RequestID())
+	// Request ID middleware; the ID is also put on the request context so slow
+	// query logs can name the request that ran them
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, id string) {
+			c.SetRequest(c.Request().WithContext(requestid.NewContext(c.Request().Context(), id)))
+		},
+	}))
 
 	// Validation middleware
 	e.Use(authMiddleware.ValidationMiddleware())
@@ -170,12 +490,36 @@ func setupMiddleware(e *echo.Echo, cfg *config.Config) {
 	e.Use(authMiddleware.ErrorHandlerMiddleware())
 }
 
-func setupRoutes(e *echo.Echo, pingHandler *handlers.PingHandler, authHandler *handlers.AuthHandler, ticketHandler *handlers.TicketHandler, authMiddlewareInstance *authMiddleware.AuthMiddleware) {
-	// Swagger documentation
-	e.GET("/swagger/*", echoSwagger.WrapHandler)
+// documentedSpecName is the swag instance serving the API documentation
+const documentedSpecName = "helpchat"
 
+func setupRoutes(e *echo.Echo, routes *handlers.Handlers, authMiddlewareInstance *authMiddleware.AuthMiddleware) {
 	// Register routes from handlers
-	pingHandler.RegisterRoutes(e)
-	authHandler.RegisterRoutes(e, authMiddlewareInstance)
-	ticketHandler.RegisterRoutes(e, authMiddlewareInstance)
+	authorizations := routes.RegisterRoutes(e, authMiddlewareInstance)
+
+	// Swagger documentation, with the authorization each route enforces
+	doc, err := handlers.DocumentAuthorization(docs.SwaggerInfo.ReadDoc(), authorizations)
+	if err != nil {
+		log.Fatal("Failed to document route authorization:", err)
+	}
+	swag.Register(documentedSpecName, handlers.DocumentedSpec(doc))
+	e.GET("/swagger/*", echoSwagger.EchoWrapHandler(echoSwagger.InstanceName(documentedSpecName)))
+}
+
+// newExportStorage creates the destination analytics exports are written to
+func newExportStorage(cfg *config.Config, secretsProvider secrets.Provider) (storage.Storage, error) {
+	switch cfg.Export.Destination {
+	case "", "local":
+		return storage.NewLocalStorage(cfg.Export.LocalPath), nil
+	case "s3":
+		if cfg.Export.S3Bucket == "" {
+			return nil, fmt.Errorf("EXPORT_S3_BUCKET is required")
+		}
+		secretKey, err := secretsProvider.Get(context.Background(), cfg.Export.S3SecretKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewS3Storage(cfg.Export.S3Endpoint, cfg.Export.S3Region, cfg.Export.S3Bucket, cfg.Export.S3AccessKey, secretKey), nil
+	}
+	return nil, fmt.Errorf("unknown export destination %q", cfg.Export.Destination)
 }
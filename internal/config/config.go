@@ -1,27 +1,73 @@
 package config
 
 import (
+	"cmp"
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	CORS       CORSConfig
+	Share      ShareConfig
+	Email      EmailConfig
+	Storage    StorageConfig
+	Spam       SpamConfig
+	Captcha    CaptchaConfig
+	RateLimit  RateLimitConfig
+	Redaction  RedactionConfig
+	Secrets    SecretsConfig
+	Encryption EncryptionConfig
+	Security   SecurityConfig
+	Widget     WidgetConfig
+	Chat       ChatConfig
+	Presence   PresenceConfig
+	Reports    ReportsConfig
+	Tickets    TicketsConfig
+	Export     ExportConfig
+	Metrics    MetricsConfig
+	HTTPCache  HTTPCacheConfig
+	Audit      AuditConfig
+	// FeatureUsage controls recording which API routes are used
+	FeatureUsage FeatureUsageConfig
+	// CustomDomain serves the portal and its emails under the organization's own domain
+	CustomDomain CustomDomainConfig
+	// Retention anonymizes and purges the data of long closed tickets
+	Retention RetentionConfig
+	// Demo allows wiping the database and reseeding it with demo data
+	Demo DemoConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port string
 	Host string
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For; enable only behind a trusted proxy
+	TrustProxyHeaders bool
+	// ConfigFile holds KEY=VALUE settings that override the environment and
+	// are read again when an administrator reloads the configuration
+	ConfigFile string
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
 	FilePath string
+	// ReplicaFilePath is an optional read-only copy of the database that serves reports
+	ReplicaFilePath string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime string
+	// BusyTimeout is how long a connection waits for another's write lock before failing
+	BusyTimeout string
+	// SlowQueryThreshold is how long a statement may run before it is logged as slow; 0 turns it off
+	SlowQueryThreshold string
 }
 
 // JWTConfig holds JWT-related configuration
@@ -44,45 +90,631 @@ type CORSConfig struct {
 	AllowCredentials bool
 }
 
+// ShareConfig holds configuration for public ticket share links
+type ShareConfig struct {
+	BaseURL    string
+	SecretKey  string
+	DefaultTTL string
+	MaxTTL     string
+}
+
+// EmailConfig holds configuration for the outbound and inbound email channel
+type EmailConfig struct {
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	FromAddress  string
+	FromName     string
+	// ReplyDomain is the domain receiving reply+<token>@ addresses; replies are disabled when empty
+	ReplyDomain       string
+	ReplySecretKey    string
+	ReplyTokenTTL     string
+	InboundSecret     string
+	MaxAttachmentSize int64
+	// DefaultLanguage is the language of notifications to users without a preferred language
+	DefaultLanguage string
+	// BrandName, LogoURL, PrimaryColor and Footer brand the header and footer of HTML emails
+	BrandName    string
+	LogoURL      string
+	PrimaryColor string
+	Footer       string
+	// FailureThreshold is how many emails in a row must fail before a user is flagged
+	FailureThreshold int
+	// Provider is "smtp", "ses" or "sendgrid"; when empty SMTP is used if
+	// SMTPHost is set, else email is logged instead of sent
+	Provider string
+	// ReturnPath is the SMTP envelope sender that receives bounces; its domain's
+	// SPF record must cover the SMTP server. Defaults to FromAddress.
+	ReturnPath   string
+	SESRegion    string
+	SESEndpoint  string
+	SESAccessKey string
+	// SESSecretKeySecret names the secret holding the SES secret access key
+	SESSecretKeySecret string
+	// SESConfigurationSet is applied to every email, for example to publish its delivery events
+	SESConfigurationSet string
+	SendGridEndpoint    string
+	// SendGridAPIKeySecret names the secret holding the SendGrid API key
+	SendGridAPIKeySecret string
+	// RateLimit is how many recipients per second are handed to the provider;
+	// 0 uses the provider's default and a negative value removes the limit
+	RateLimit float64
+	// Sandbox delivers email only to SandboxRecipients and logs the rest, for non-production environments
+	Sandbox bool
+	// SandboxRecipients are the addresses, or @domains, that receive email in sandbox mode
+	SandboxRecipients []string
+	// BreakthroughPriorities are the ticket priorities whose notifications are
+	// sent during the recipient's quiet hours instead of waiting for them to end
+	BreakthroughPriorities []string
+	// BreakthroughOverdue sends notifications about tickets past their due date during quiet hours
+	BreakthroughOverdue bool
+}
+
+// StorageConfig holds configuration for file storage
+type StorageConfig struct {
+	LocalPath string
+	// MaxUploadSize is the largest attachment, in bytes, users can upload
+	MaxUploadSize int64
+	// AllowedTypes lists the MIME types users can upload; "image/*" allows every image type
+	AllowedTypes []string
+	// DraftAttachmentTTL is how long an image uploaded for a ticket description is
+	// kept waiting for the ticket to be saved
+	DraftAttachmentTTL string
+}
+
+// SpamConfig holds configuration for spam scoring of guest and email submissions
+type SpamConfig struct {
+	Enabled   bool
+	Threshold float64
+	Keywords  []string
+	// ExternalURL is an optional scoring service called in addition to the keyword rules
+	ExternalURL     string
+	ExternalAPIKey  string
+	ExternalTimeout string
+}
+
+// CaptchaConfig holds configuration for CAPTCHA verification on public endpoints
+type CaptchaConfig struct {
+	// Provider is "hcaptcha", "recaptcha" or empty to disable verification
+	Provider  string
+	SecretKey string
+	// MinScore is the minimum reCAPTCHA v3 score accepted
+	MinScore float64
+}
+
+// RateLimitConfig holds configuration for per-IP rate limiting of public endpoints
+// and the daily request quota of each signed-in user
+type RateLimitConfig struct {
+	PublicRequestsPerMinute float64
+	PublicBurst             int
+	UserDailyQuota          int64
+}
+
+// RedactionConfig holds configuration for masking PII and blocked words in user content
+type RedactionConfig struct {
+	Enabled bool
+	// Rules lists the built-in rules to apply, in order: credit_card, ssn, phone
+	Rules []string
+	// Dictionary lists words masked wherever they appear as whole words
+	Dictionary []string
+}
+
+// SecretsConfig holds configuration for the secrets provider
+type SecretsConfig struct {
+	// Provider is "env" to read environment variables or "file" to read one file per secret from Dir
+	Provider string
+	Dir      string
+}
+
+// EncryptionConfig holds configuration for encryption of sensitive columns at rest
+type EncryptionConfig struct {
+	// KeysSecret names the secret holding comma-separated id:base64key pairs; encryption is disabled when it is missing
+	KeysSecret string
+	// PrimaryKeyID selects the key used for new values, defaulting to the first key
+	PrimaryKeyID string
+}
+
+// SecurityConfig holds configuration for account security notifications
+type SecurityConfig struct {
+	// LoginAlertsEnabled emails users when they sign in from a new device or location
+	LoginAlertsEnabled bool
+	// TokenMaxFailures is how many bad attempts on a public token endpoint block
+	// the client IP or token; 0 turns the lockout off
+	TokenMaxFailures     int
+	TokenFailureWindow   string
+	TokenLockoutDuration string
+	// RBACPolicyFile is an optional JSON object mapping roles to the
+	// permissions that replace their defaults
+	RBACPolicyFile string
+	// PasswordResetTTL and EmailVerificationTTL are how long the links emailed
+	// to reset a password and to verify an address remain valid
+	PasswordResetTTL     string
+	EmailVerificationTTL string
+}
+
+// WidgetConfig holds branding and availability settings for the embeddable chat widget
+type WidgetConfig struct {
+	Title        string
+	Greeting     string
+	PrimaryColor string
+	LogoURL      string
+	// OfficeHours is a weekly schedule such as "mon-fri 09:00-17:00; sat 10:00-14:00"; empty means always open
+	OfficeHours string
+	// Timezone is the IANA time zone the office hours are given in
+	Timezone string
+	// Holidays is a comma-separated list of YYYY-MM-DD dates support is closed,
+	// which also are not business days
+	Holidays string
+}
+
+// ChatConfig holds configuration for live chat
+type ChatConfig struct {
+	// SecretKey signs the tokens visitors use to continue their chat
+	SecretKey       string
+	VisitorTokenTTL string
+	// MaxConcurrentChats is the default number of chats routed to an agent at once
+	MaxConcurrentChats int
+}
+
+// PresenceConfig holds configuration for tracking which agents are online
+type PresenceConfig struct {
+	// TTL is how long an agent stays online after their last heartbeat
+	TTL string
+}
+
+// ReportsConfig holds configuration for reports and wallboards
+type ReportsConfig struct {
+	// StreamInterval is how often live queue numbers are pushed to wallboards
+	StreamInterval string
+	// SLARiskWindow is how close to its due date an unresolved ticket counts as at risk
+	SLARiskWindow string
+}
+
+// TicketsConfig holds configuration for ticket handling
+type TicketsConfig struct {
+	// ReassignmentAlertThreshold alerts managers once a ticket has been
+	// assigned to more than this many agents; 0 turns the alert off
+	ReassignmentAlertThreshold int
+	// LockTTL is how long an edit lock lasts after its holder's last heartbeat
+	LockTTL string
+	// RootCauses are the root cause codes agents choose from when resolving
+	RootCauses []string
+	// MaxOpenPerRequester is how many open or in-progress tickets an end user
+	// may have before they are asked to update one instead; 0 turns it off
+	MaxOpenPerRequester int
+	// ResponseTargets is the SLA policy quoted to requesters, as PRIORITY=duration
+	// pairs such as "HIGH=4h"; priorities without a target get no promise
+	ResponseTargets []string
+	// TrashRetentionDays is how long deleted tickets stay in the trash before
+	// they are purged for good; 0 keeps them until restored
+	TrashRetentionDays int
+	// SandboxTTL is how long an agent's training sandbox lasts before its demo
+	// data is cleaned up
+	SandboxTTL string
+}
+
+// ExportConfig holds configuration for the nightly analytics export
+type ExportConfig struct {
+	Enabled bool
+	// Time is the office-time clock time the previous day is exported at
+	Time string
+	// Destination is "local" to write under LocalPath or "s3" for an S3 compatible bucket
+	Destination string
+	LocalPath   string
+	// Prefix is prepended to every exported file's key
+	Prefix      string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	// S3SecretKeySecret names the secret holding the S3 secret access key
+	S3SecretKeySecret string
+}
+
+// MetricsConfig holds configuration for the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Token is a bearer token required to scrape /metrics; the endpoint is open when empty
+	Token string
+	// Profiling serves the Go runtime profiles under /debug/pprof to administrators
+	Profiling bool
+}
+
+// FeatureUsageConfig holds configuration for API usage analytics
+type FeatureUsageConfig struct {
+	// Enabled records every API request, rolled up per hour, route and user
+	Enabled bool
+	// FlushInterval is how often the counted requests are written to the database
+	FlushInterval string
+}
+
+// RetentionConfig holds the data retention policy for closed tickets
+type RetentionConfig struct {
+	// Rules are action=days pairs such as "purge_attachments=365", applied to
+	// tickets closed longer than that many days ago; none turns retention off
+	Rules []string
+	// Time is the office-time clock time the rules are applied at each day
+	Time string
+}
+
+// DemoConfig holds configuration for demo and ephemeral environments
+type DemoConfig struct {
+	// Enabled allows administrators to reset the database to the demo data;
+	// never enable it where the data matters
+	Enabled bool
+	// ResetInterval resets the database to the demo data on a schedule; empty
+	// only resets on demand
+	ResetInterval string
+}
+
+// CustomDomainConfig holds the white-label domain of the portal and its emails.
+// When Domain is set it is the default for the cookie domain, share link base
+// URL and sender address, and is added to the allowed CORS origins.
+type CustomDomainConfig struct {
+	// Domain is the host the portal is served from, such as support.example.com
+	Domain string
+	// EmailDomain is the domain of the sender address; defaults to Domain
+	EmailDomain string
+}
+
+// AuditConfig holds settings for forwarding audit events to a SIEM
+type AuditConfig struct {
+	// Sinks lists where audit events are forwarded besides the database:
+	// "syslog", "http" and "eventhub"
+	Sinks []string
+	// BufferSize is how many events may wait to be forwarded before new ones are dropped
+	BufferSize int
+	// FlushInterval is how often waiting events are forwarded
+	FlushInterval string
+	// SyslogNetwork is "udp", "tcp" or "tls"
+	SyslogNetwork string
+	SyslogAddress string
+	// HTTPURL is the collector events are posted to, such as a Splunk HTTP Event Collector
+	HTTPURL string
+	// HTTPFormat is "json" for a JSON array of events or "splunk" for HEC events
+	HTTPFormat string
+	// HTTPTokenSecret names the secret holding the collector's token
+	HTTPTokenSecret string
+	// EventHubEndpoint is the namespace URL, such as https://contoso.servicebus.windows.net
+	EventHubEndpoint string
+	EventHubName     string
+	EventHubKeyName  string
+	// EventHubKeySecret names the secret holding the shared access key
+	EventHubKeySecret string
+}
+
+// HTTPCacheConfig holds caching settings for rarely changing public reads
+type HTTPCacheConfig struct {
+	// TTL is how long responses are kept server side; 0 turns the server-side cache off
+	TTL string
+	// MaxAge is how many seconds clients may reuse a response before revalidating it
+	MaxAge int
+}
+
 // Load loads configuration from environment variables
 func Load() *Config {
+	customDomain := strings.ToLower(strings.TrimSpace(getEnv("CUSTOM_DOMAIN", "")))
+	customEmailDomain := strings.ToLower(strings.TrimSpace(getEnv("CUSTOM_EMAIL_DOMAIN", customDomain)))
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Host: getEnv("HOST", "0.0.0.0"),
+			Port:              getEnv("PORT", "8080"),
+			Host:              getEnv("HOST", "0.0.0.0"),
+			TrustProxyHeaders: getEnv("TRUST_PROXY_HEADERS", "false") == "true",
+			ConfigFile:        os.Getenv("CONFIG_FILE"),
 		},
 		Database: DatabaseConfig{
-			FilePath: getEnv("DB_FILE", "helpchat.db"),
+			FilePath:           getEnv("DB_FILE", "helpchat.db"),
+			ReplicaFilePath:    getEnv("DB_REPLICA_FILE", ""),
+			MaxOpenConns:       int(getEnvInt64("DB_MAX_OPEN_CONNS", 4)),
+			MaxIdleConns:       int(getEnvInt64("DB_MAX_IDLE_CONNS", 4)),
+			ConnMaxLifetime:    getEnv("DB_CONN_MAX_LIFETIME", "1h"),
+			BusyTimeout:        getEnv("DB_BUSY_TIMEOUT", "5s"),
+			SlowQueryThreshold: getEnv("DB_SLOW_QUERY_THRESHOLD", "200ms"),
 		},
 		JWT: JWTConfig{
 			SecretKey:       getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production"),
 			AccessTokenTTL:  getEnv("JWT_ACCESS_TOKEN_TTL", "15m"),
 			RefreshTokenTTL: getEnv("JWT_REFRESH_TOKEN_TTL", "7d"),
 			Issuer:          getEnv("JWT_ISSUER", "helpchat"),
-			CookieDomain:    getEnv("JWT_COOKIE_DOMAIN", ""),
-			CookieSecure:    getEnv("JWT_COOKIE_SECURE", "false") == "true",
+			CookieDomain:    getEnv("JWT_COOKIE_DOMAIN", customDomain),
+			CookieSecure:    getEnv("JWT_COOKIE_SECURE", strconv.FormatBool(customDomain != "")) == "true",
 			CookieSameSite:  getEnv("JWT_COOKIE_SAME_SITE", "Lax"),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins:   getCORSOrigins(),
+			AllowedOrigins:   getCORSOrigins(customDomain),
 			AllowedMethods:   []string{"GET", "HEAD", "PUT", "PATCH", "POST", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "content-type"},
+			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "content-type", "X-Captcha-Token", "X-Chat-Token"},
 			AllowCredentials: true,
 		},
+		Share: ShareConfig{
+			BaseURL:    getEnv("SHARE_BASE_URL", customDomainURL(customDomain, "http://localhost:8080")),
+			SecretKey:  getEnv("SHARE_SECRET_KEY", getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production")),
+			DefaultTTL: getEnv("SHARE_DEFAULT_TTL", "72h"),
+			MaxTTL:     getEnv("SHARE_MAX_TTL", "720h"),
+		},
+		Email: EmailConfig{
+			SMTPHost:             getEnv("SMTP_HOST", ""),
+			SMTPPort:             getEnv("SMTP_PORT", "587"),
+			SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+			FromAddress:          getEnv("EMAIL_FROM_ADDRESS", "support@"+cmp.Or(customEmailDomain, "helpchat.com")),
+			FromName:             getEnv("EMAIL_FROM_NAME", "HelpChat Support"),
+			ReplyDomain:          getEnv("EMAIL_REPLY_DOMAIN", ""),
+			ReplySecretKey:       getEnv("EMAIL_REPLY_SECRET_KEY", getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production")),
+			ReplyTokenTTL:        getEnv("EMAIL_REPLY_TOKEN_TTL", "2160h"),
+			InboundSecret:        getEnv("EMAIL_INBOUND_SECRET", ""),
+			MaxAttachmentSize:    getEnvInt64("EMAIL_MAX_ATTACHMENT_SIZE", 10<<20),
+			DefaultLanguage:      getEnv("DEFAULT_LANGUAGE", "en"),
+			BrandName:            getEnv("EMAIL_BRAND_NAME", getEnv("EMAIL_FROM_NAME", "HelpChat Support")),
+			LogoURL:              getEnv("EMAIL_LOGO_URL", getEnv("WIDGET_LOGO_URL", "")),
+			PrimaryColor:         getEnv("EMAIL_PRIMARY_COLOR", getEnv("WIDGET_PRIMARY_COLOR", "#2563eb")),
+			Footer:               getEnv("EMAIL_FOOTER", ""),
+			FailureThreshold:     int(getEnvInt64("EMAIL_FAILURE_THRESHOLD", 3)),
+			Provider:             getEnv("EMAIL_PROVIDER", ""),
+			ReturnPath:           getEnv("EMAIL_RETURN_PATH", ""),
+			SESRegion:            getEnv("EMAIL_SES_REGION", "us-east-1"),
+			SESEndpoint:          getEnv("EMAIL_SES_ENDPOINT", ""),
+			SESAccessKey:         getEnv("EMAIL_SES_ACCESS_KEY", ""),
+			SESSecretKeySecret:   getEnv("EMAIL_SES_SECRET_KEY_SECRET", "EMAIL_SES_SECRET_KEY"),
+			SESConfigurationSet:  getEnv("EMAIL_SES_CONFIGURATION_SET", ""),
+			SendGridEndpoint:     getEnv("EMAIL_SENDGRID_ENDPOINT", ""),
+			SendGridAPIKeySecret: getEnv("EMAIL_SENDGRID_API_KEY_SECRET", "SENDGRID_API_KEY"),
+			RateLimit:            getEnvFloat("EMAIL_RATE_LIMIT", 0),
+			Sandbox:              getEnv("EMAIL_SANDBOX", "false") == "true",
+			SandboxRecipients:    getEnvList("EMAIL_SANDBOX_RECIPIENTS", nil),
+		},
+		Storage: StorageConfig{
+			LocalPath:     getEnv("STORAGE_LOCAL_PATH", "uploads"),
+			MaxUploadSize: getEnvInt64("ATTACHMENT_MAX_SIZE", 10<<20),
+			AllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp", "text/plain", "text/csv", "application/pdf", "application/zip",
+				"application/msword", "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				"application/vnd.ms-excel", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			}),
+			DraftAttachmentTTL: getEnv("DRAFT_ATTACHMENT_TTL", "24h"),
+		},
+		Spam: SpamConfig{
+			Enabled:   getEnv("SPAM_FILTER_ENABLED", "true") == "true",
+			Threshold: getEnvFloat("SPAM_THRESHOLD", 5),
+			Keywords: getEnvList("SPAM_KEYWORDS", []string{
+				"viagra", "casino", "crypto giveaway", "bitcoin doubler", "work from home",
+				"seo services", "backlinks", "loan approval", "click here", "act now",
+			}),
+			ExternalURL:     getEnv("SPAM_EXTERNAL_URL", ""),
+			ExternalAPIKey:  getEnv("SPAM_EXTERNAL_API_KEY", ""),
+			ExternalTimeout: getEnv("SPAM_EXTERNAL_TIMEOUT", "3s"),
+		},
+		Captcha: CaptchaConfig{
+			Provider:  getEnv("CAPTCHA_PROVIDER", ""),
+			SecretKey: getEnv("CAPTCHA_SECRET_KEY", ""),
+			MinScore:  getEnvFloat("CAPTCHA_MIN_SCORE", 0.5),
+		},
+		RateLimit: RateLimitConfig{
+			PublicRequestsPerMinute: getEnvFloat("RATE_LIMIT_PUBLIC_RPM", 5),
+			PublicBurst:             int(getEnvInt64("RATE_LIMIT_PUBLIC_BURST", 5)),
+			UserDailyQuota:          getEnvInt64("RATE_LIMIT_USER_DAILY_QUOTA", 10000),
+		},
+		Redaction: RedactionConfig{
+			Enabled:    getEnv("REDACTION_ENABLED", "true") == "true",
+			Rules:      getEnvList("REDACTION_RULES", []string{"credit_card", "ssn", "phone"}),
+			Dictionary: getEnvList("REDACTION_DICTIONARY", nil),
+		},
+		Secrets: SecretsConfig{
+			Provider: getEnv("SECRETS_PROVIDER", "env"),
+			Dir:      getEnv("SECRETS_DIR", "/run/secrets"),
+		},
+		Encryption: EncryptionConfig{
+			KeysSecret:   getEnv("ENCRYPTION_KEYS_SECRET", "ENCRYPTION_KEYS"),
+			PrimaryKeyID: getEnv("ENCRYPTION_PRIMARY_KEY_ID", ""),
+		},
+		Security: SecurityConfig{
+			LoginAlertsEnabled:   getEnv("LOGIN_ALERTS_ENABLED", "true") == "true",
+			TokenMaxFailures:     int(getEnvInt64("TOKEN_MAX_FAILURES", 10)),
+			TokenFailureWindow:   getEnv("TOKEN_FAILURE_WINDOW", "15m"),
+			TokenLockoutDuration: getEnv("TOKEN_LOCKOUT_DURATION", "15m"),
+			RBACPolicyFile:       getEnv("RBAC_POLICY_FILE", ""),
+			PasswordResetTTL:     getEnv("PASSWORD_RESET_TTL", "1h"),
+			EmailVerificationTTL: getEnv("EMAIL_VERIFICATION_TTL", "72h"),
+		},
+		Widget: WidgetConfig{
+			Title:        getEnv("WIDGET_TITLE", "HelpChat Support"),
+			Greeting:     getEnv("WIDGET_GREETING", "Hi! How can we help?"),
+			PrimaryColor: getEnv("WIDGET_PRIMARY_COLOR", "#2563eb"),
+			LogoURL:      getEnv("WIDGET_LOGO_URL", ""),
+			OfficeHours:  getEnv("OFFICE_HOURS", ""),
+			Timezone:     getEnv("OFFICE_HOURS_TIMEZONE", "UTC"),
+			Holidays:     getEnv("OFFICE_HOLIDAYS", ""),
+		},
+		Chat: ChatConfig{
+			SecretKey:          getEnv("CHAT_SECRET_KEY", getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production")),
+			VisitorTokenTTL:    getEnv("CHAT_VISITOR_TOKEN_TTL", "24h"),
+			MaxConcurrentChats: int(getEnvInt64("CHAT_MAX_CONCURRENT_CHATS", 3)),
+		},
+		Presence: PresenceConfig{
+			TTL: getEnv("PRESENCE_TTL", "90s"),
+		},
+		Reports: ReportsConfig{
+			StreamInterval: getEnv("REPORT_STREAM_INTERVAL", "5s"),
+			SLARiskWindow:  getEnv("SLA_RISK_WINDOW", "1h"),
+		},
+		Tickets: TicketsConfig{
+			ReassignmentAlertThreshold: int(getEnvInt64("TICKET_REASSIGNMENT_ALERT_THRESHOLD", 0)),
+			LockTTL:                    getEnv("TICKET_LOCK_TTL", "2m"),
+			RootCauses: getEnvList("ROOT_CAUSE_CODES", []string{
+				"HARDWARE_FAILURE", "SOFTWARE_DEFECT", "CONFIGURATION_ERROR", "USER_ERROR", "NETWORK", "THIRD_PARTY", "UNKNOWN",
+			}),
+			MaxOpenPerRequester: int(getEnvInt64("MAX_OPEN_TICKETS_PER_REQUESTER", 0)),
+			ResponseTargets:     getEnvList("TICKET_RESPONSE_TARGETS", []string{"CRITICAL=1h", "HIGH=4h", "MEDIUM=8h", "LOW=24h"}),
+			TrashRetentionDays:  int(getEnvInt64("TICKET_TRASH_RETENTION_DAYS", 30)),
+			SandboxTTL:          getEnv("SANDBOX_TTL", "8h"),
+		},
+		Export: ExportConfig{
+			Enabled:           getEnv("EXPORT_ENABLED", "false") == "true",
+			Time:              getEnv("EXPORT_TIME", "02:00"),
+			Destination:       getEnv("EXPORT_DESTINATION", "local"),
+			LocalPath:         getEnv("EXPORT_LOCAL_PATH", "exports"),
+			Prefix:            getEnv("EXPORT_PREFIX", "helpchat"),
+			S3Endpoint:        getEnv("EXPORT_S3_ENDPOINT", ""),
+			S3Region:          getEnv("EXPORT_S3_REGION", "us-east-1"),
+			S3Bucket:          getEnv("EXPORT_S3_BUCKET", ""),
+			S3AccessKey:       getEnv("EXPORT_S3_ACCESS_KEY", ""),
+			S3SecretKeySecret: getEnv("EXPORT_S3_SECRET_KEY_SECRET", "EXPORT_S3_SECRET_KEY"),
+		},
+		Metrics: MetricsConfig{
+			Token:     getEnv("METRICS_TOKEN", ""),
+			Profiling: getEnv("PROFILING_ENABLED", "false") == "true",
+		},
+		HTTPCache: HTTPCacheConfig{
+			TTL:    getEnv("HTTP_CACHE_TTL", "5m"),
+			MaxAge: int(getEnvInt64("HTTP_CACHE_MAX_AGE", 60)),
+		},
+		Audit: AuditConfig{
+			Sinks:             getEnvList("AUDIT_SINKS", nil),
+			BufferSize:        int(getEnvInt64("AUDIT_SINK_BUFFER_SIZE", 1000)),
+			FlushInterval:     getEnv("AUDIT_SINK_FLUSH_INTERVAL", "1s"),
+			SyslogNetwork:     getEnv("AUDIT_SYSLOG_NETWORK", "udp"),
+			SyslogAddress:     getEnv("AUDIT_SYSLOG_ADDRESS", ""),
+			HTTPURL:           getEnv("AUDIT_HTTP_URL", ""),
+			HTTPFormat:        getEnv("AUDIT_HTTP_FORMAT", "json"),
+			HTTPTokenSecret:   getEnv("AUDIT_HTTP_TOKEN_SECRET", "AUDIT_HTTP_TOKEN"),
+			EventHubEndpoint:  getEnv("AUDIT_EVENTHUB_ENDPOINT", ""),
+			EventHubName:      getEnv("AUDIT_EVENTHUB_NAME", ""),
+			EventHubKeyName:   getEnv("AUDIT_EVENTHUB_KEY_NAME", "RootManageSharedAccessKey"),
+			EventHubKeySecret: getEnv("AUDIT_EVENTHUB_KEY_SECRET", "AUDIT_EVENTHUB_KEY"),
+		},
+		FeatureUsage: FeatureUsageConfig{
+			Enabled:       getEnv("FEATURE_USAGE_ENABLED", "true") == "true",
+			FlushInterval: getEnv("FEATURE_USAGE_FLUSH_INTERVAL", "1m"),
+		},
+		CustomDomain: CustomDomainConfig{
+			Domain:      customDomain,
+			EmailDomain: customEmailDomain,
+		},
+		Retention: RetentionConfig{
+			Rules: getEnvList("RETENTION_RULES", nil),
+			Time:  getEnv("RETENTION_TIME", "03:00"),
+		},
+		Demo: DemoConfig{
+			Enabled:       getEnv("DEMO_MODE", "false") == "true",
+			ResetInterval: getEnv("DEMO_RESET_INTERVAL", ""),
+		},
 	}
 }
 
+// LoadFile reads the KEY=VALUE lines of path, which take precedence over the
+// environment, then loads the configuration. Blank lines and lines starting
+// with # are ignored. An empty path loads from the environment alone.
+func LoadFile(path string) (*Config, error) {
+	values := make(map[string]string)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("config file line %d: expected KEY=VALUE", i+1)
+			}
+			values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+
+	overridesMu.Lock()
+	overrides = values
+	overridesMu.Unlock()
+
+	cfg := Load()
+	cfg.Server.ConfigFile = path
+	return cfg, nil
+}
+
+var (
+	overridesMu sync.RWMutex
+	// overrides holds the values read by LoadFile
+	overrides map[string]string
+)
+
+// lookupEnv returns a value from the config file, falling back to the environment
+func lookupEnv(key string) string {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+	if value, ok := overrides[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-// getCORSOrigins gets CORS origins from environment variable or returns default values
-func getCORSOrigins() []string {
-	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+// getEnvInt64 gets an integer environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := lookupEnv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat gets a floating point environment variable or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList gets a comma-separated environment variable or returns a default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// customDomainURL returns the HTTPS URL of the custom domain, or fallback when there is none
+func customDomainURL(customDomain, fallback string) string {
+	if customDomain == "" {
+		return fallback
+	}
+	return "https://" + customDomain
+}
+
+// getCORSOrigins gets CORS origins from environment variable or returns default
+// values, adding the custom domain's origin when one is set
+func getCORSOrigins(customDomain string) []string {
+	origins := configuredCORSOrigins()
+	if origin := customDomainURL(customDomain, ""); origin != "" && !slices.Contains(origins, origin) {
+		origins = append(origins, origin)
+	}
+	return origins
+}
+
+// configuredCORSOrigins gets CORS origins from environment variable or returns default values
+func configuredCORSOrigins() []string {
+	if origins := lookupEnv("CORS_ALLOWED_ORIGINS"); origins != "" {
 		// Split by comma and trim whitespace
 		originList := strings.Split(origins, ",")
 		for i, origin := range originList {
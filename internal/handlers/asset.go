@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// AssetHandler handles asset HTTP requests and ticket-asset links
+type AssetHandler struct {
+	assetService  *services.AssetService
+	ticketService *services.TicketService
+}
+
+// NewAssetHandler creates a new asset handler
+func NewAssetHandler(assetService *services.AssetService, ticketService *services.TicketService) *AssetHandler {
+	return &AssetHandler{
+		assetService:  assetService,
+		ticketService: ticketService,
+	}
+}
+
+// RegisterRoutes registers the asset routes
+func (h *AssetHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	assets := e.Group("/api/v1/assets")
+	assets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "asset"))
+	assets.GET("", h.ListAssets, ami.RequirePermission(models.PermTicketRead))
+	assets.POST("", h.CreateAsset, ami.RequireAgent())
+	assets.GET("/report", h.GetAssetReport, ami.RequirePermission(models.PermReportRead))
+	assets.GET("/:id", h.GetAsset, ami.RequirePermission(models.PermTicketRead))
+	assets.PUT("/:id", h.UpdateAsset, ami.RequireAgent())
+	assets.DELETE("/:id", h.DeleteAsset, ami.RequireAdmin())
+	assets.GET("/:id/tickets", h.GetAssetTickets, ami.RequirePermission(models.PermTicketRead))
+
+	ticketAssets := e.Group("/api/v1/tickets/:id/assets")
+	ticketAssets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "ticket"), authMiddleware.UUIDParam("asset_id", "asset"))
+	ticketAssets.GET("", h.GetTicketAssets, ami.RequirePermission(models.PermTicketRead))
+	ticketAssets.POST("", h.LinkTicketAsset, ami.RequireAgent())
+	ticketAssets.DELETE("/:asset_id", h.UnlinkTicketAsset, ami.RequireAgent())
+}
+
+// ListAssets handles listing assets
+// @Summary List assets
+// @Description List assets ordered by name, optionally filtered by type, owner or a name/serial search
+// @Tags assets
+// @Produce json
+// @Param type query string false "Asset type"
+// @Param owner_id query string false "Owner user ID"
+// @Param search query string false "Search name or serial number"
+// @Success 200 {array} models.Asset
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets [get]
+// @Security ApiKeyAuth
+func (h *AssetHandler) ListAssets(c echo.Context) error {
+	var filter models.AssetFilter
+	if err := c.Bind(&filter); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse(bindErrorMessage(err, "Invalid query parameters")))
+	}
+
+	assets, err := h.assetService.ListAssets(c.Request().Context(), &filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list assets"))
+	}
+	return c.JSON(http.StatusOK, assets)
+}
+
+// CreateAsset handles registering an asset
+// @Summary Create an asset
+// @Description Register a device or configuration item that tickets can be linked to
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param asset body models.CreateAssetRequest true "Asset data"
+// @Success 201 {object} models.Asset
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets [post]
+// @Security ApiKeyAuth
+func (h *AssetHandler) CreateAsset(c echo.Context) error {
+	var req models.CreateAssetRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	asset, err := h.assetService.CreateAsset(c.Request().Context(), &req)
+	if err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, asset)
+}
+
+// GetAssetReport handles reporting the assets with the most tickets
+// @Summary Asset ticket report
+// @Description List the assets with the most linked tickets, with how many are still open
+// @Tags assets
+// @Produce json
+// @Param limit query int false "Maximum number of assets (default 20, max 100)"
+// @Success 200 {array} models.AssetTicketCount
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets/report [get]
+// @Security ApiKeyAuth
+func (h *AssetHandler) GetAssetReport(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	report, err := h.assetService.TicketReport(ctx, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to build asset report"))
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetAsset handles retrieving an asset
+// @Summary Get an asset
+// @Description Get an asset and its owner
+// @Tags assets
+// @Produce json
+// @Param id path string true "Asset ID"
+// @Success 200 {object} models.Asset
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets/{id} [get]
+// @Security ApiKeyAuth
+func (h *AssetHandler) GetAsset(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	asset, err := h.assetService.GetAsset(c.Request().Context(), id)
+	if err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, asset)
+}
+
+// UpdateAsset handles updating an asset
+// @Summary Update an asset
+// @Description Update an asset's details or owner
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path string true "Asset ID"
+// @Param asset body models.UpdateAssetRequest true "Asset changes"
+// @Success 200 {object} models.Asset
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets/{id} [put]
+// @Security ApiKeyAuth
+func (h *AssetHandler) UpdateAsset(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.UpdateAssetRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	asset, err := h.assetService.UpdateAsset(c.Request().Context(), id, &req)
+	if err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, asset)
+}
+
+// DeleteAsset handles deleting an asset
+// @Summary Delete an asset
+// @Description Delete an asset and unlink it from its tickets
+// @Tags assets
+// @Param id path string true "Asset ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets/{id} [delete]
+// @Security ApiKeyAuth
+func (h *AssetHandler) DeleteAsset(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	if err := h.assetService.DeleteAsset(c.Request().Context(), id); err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetAssetTickets handles listing the tickets linked to an asset
+// @Summary List asset tickets
+// @Description List the tickets raised against an asset, newest first
+// @Tags assets
+// @Produce json
+// @Param id path string true "Asset ID"
+// @Success 200 {array} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assets/{id}/tickets [get]
+// @Security ApiKeyAuth
+func (h *AssetHandler) GetAssetTickets(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	tickets, err := h.assetService.GetAssetTickets(c.Request().Context(), id)
+	if err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, tickets)
+}
+
+// GetTicketAssets handles listing the assets linked to a ticket
+// @Summary List ticket assets
+// @Description List the assets a ticket is linked to
+// @Tags assets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {array} models.Asset
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/assets [get]
+// @Security ApiKeyAuth
+func (h *AssetHandler) GetTicketAssets(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	assets, err := h.assetService.GetTicketAssets(c.Request().Context(), ticketID)
+	if err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, assets)
+}
+
+// LinkTicketAsset handles linking a ticket to an asset
+// @Summary Link a ticket to an asset
+// @Description Link a ticket to the asset it concerns; linking twice has no effect
+// @Tags assets
+// @Accept json
+// @Param id path string true "Ticket ID"
+// @Param link body models.LinkAssetRequest true "Asset to link"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/assets [post]
+// @Security ApiKeyAuth
+func (h *AssetHandler) LinkTicketAsset(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.LinkAssetRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	if err := h.assetService.LinkTicket(c.Request().Context(), ticketID, req.AssetID); err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnlinkTicketAsset handles removing the link between a ticket and an asset
+// @Summary Unlink a ticket from an asset
+// @Description Remove the link between a ticket and an asset
+// @Tags assets
+// @Param id path string true "Ticket ID"
+// @Param asset_id path string true "Asset ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/assets/{asset_id} [delete]
+// @Security ApiKeyAuth
+func (h *AssetHandler) UnlinkTicketAsset(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+	assetID := authMiddleware.ParamUUID(c, "asset_id")
+
+	if err := h.assetService.UnlinkTicket(c.Request().Context(), ticketID, assetID); err != nil {
+		return assetErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// assetErrorResponse maps asset service errors to HTTP responses
+func assetErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process asset request"))
+	}
+}
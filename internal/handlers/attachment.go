@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// attachmentFormOverhead allows for the multipart headers around an uploaded file
+const attachmentFormOverhead = 64 << 10
+
+// AttachmentHandler handles uploading and downloading ticket attachments
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// RegisterRoutes registers the attachment routes
+func (h *AttachmentHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Access is checked against the ticket in the service
+	tickets := e.Group("/api/v1/tickets")
+	tickets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "ticket"))
+	tickets.POST("/:id/attachments", h.UploadAttachment)
+	tickets.POST("/draft-attachments", h.UploadDraftAttachment, ami.RequirePermission(models.PermTicketCreate))
+	tickets.POST("/inline-images", h.UploadInlineImage, ami.RequireAnyPermission(models.PermTicketCreate, models.PermTicketUpdate, models.PermTicketUpdateOwn))
+	tickets.GET("/:id/attachments/:attachmentId", h.DownloadAttachment, authMiddleware.UUIDParam("attachmentId", "attachment"))
+	tickets.DELETE("/:id/attachments/:attachmentId", h.DeleteAttachment, authMiddleware.UUIDParam("attachmentId", "attachment"))
+	tickets.GET("/:id/attachments/:attachmentId/thumbnails/:size", h.GetThumbnail, authMiddleware.UUIDParam("attachmentId", "attachment"))
+}
+
+// UploadAttachment handles attaching a file to a ticket
+// @Summary Attach a file to a ticket
+// @Description Upload a file to a ticket as the multipart form field "file". The requester or an agent can attach files. Files larger than ATTACHMENT_MAX_SIZE are rejected with 413, and files whose declared type, or the type their extension implies when none is declared, is not in ATTACHMENT_ALLOWED_TYPES with 400.
+// @Tags tickets
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/attachments [post]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) UploadAttachment(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	fileHeader, file, err := h.openFormFile(c)
+	if file == nil {
+		return err
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(c.Request().Context(), ticketID, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), file, user)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, attachment)
+}
+
+// UploadDraftAttachment handles uploading a file for a ticket being written
+// @Summary Upload a file for a new ticket
+// @Description Upload a file as the multipart form field "file" while writing a ticket, before it exists. Reference the returned ID in attachment_ids when creating the ticket, which attaches the file to it with the same ID in the same step: the ticket is not created if a file cannot be attached. Only the uploader can attach the file, and files no ticket is created with are removed after DRAFT_ATTACHMENT_TTL. Size and type limits are those of ticket attachments.
+// @Tags tickets
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.DraftAttachment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/draft-attachments [post]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) UploadDraftAttachment(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	fileHeader, file, err := h.openFormFile(c)
+	if file == nil {
+		return err
+	}
+	defer file.Close()
+
+	draft, err := h.attachmentService.UploadDraft(c.Request().Context(), fileHeader.Filename, fileHeader.Header.Get("Content-Type"), file, user)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, draft)
+}
+
+// UploadInlineImage handles uploading an image to embed in a ticket description
+// @Summary Upload an image for a ticket description
+// @Description Upload a PNG, JPEG or GIF image as the multipart form field "file", to embed in the description of a ticket being written. Reference the returned ID in inline_image_ids when creating or updating the ticket, which attaches the image to it as an inline attachment with the same ID. Only the uploader can attach the image, and images no ticket is saved with are removed after DRAFT_ATTACHMENT_TTL. Size and type limits are those of ticket attachments.
+// @Tags tickets
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image to embed"
+// @Success 201 {object} models.DraftAttachment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/inline-images [post]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) UploadInlineImage(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	fileHeader, file, err := h.openFormFile(c)
+	if file == nil {
+		return err
+	}
+	defer file.Close()
+
+	image, err := h.attachmentService.UploadInlineImage(c.Request().Context(), fileHeader.Filename, fileHeader.Header.Get("Content-Type"), file, user)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, image)
+}
+
+// openFormFile opens the file uploaded in the form field "file". When there
+// is none, or it is too large, it writes the error response and returns a nil
+// file with the result of writing it.
+func (h *AttachmentHandler) openFormFile(c echo.Context) (*multipart.FileHeader, multipart.File, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.attachmentService.MaxUploadSize()+attachmentFormOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, nil, c.JSON(http.StatusRequestEntityTooLarge, models.NewErrorResponse("The file is too large"))
+		}
+		return nil, nil, c.JSON(http.StatusBadRequest, models.NewErrorResponse("A file is required in the form field \"file\""))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, nil, c.JSON(http.StatusBadRequest, models.NewErrorResponse("Failed to read the uploaded file"))
+	}
+	return fileHeader, file, nil
+}
+
+// DownloadAttachment handles downloading a ticket attachment
+// @Summary Download a ticket attachment
+// @Description Stream the content of a file attached to a ticket. The requester and staff who can read tickets can download it; files of internal notes are only available to agents. The file is always served as a download, never displayed inline.
+// @Tags tickets
+// @Produce octet-stream
+// @Param id path string true "Ticket ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} binary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/attachments/{attachmentId} [get]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) DownloadAttachment(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+	attachmentID := authMiddleware.ParamUUID(c, "attachmentId")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	attachment, content, err := h.attachmentService.Open(c.Request().Context(), ticketID, attachmentID, user)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	defer content.Close()
+
+	// Uploaded content must never run as part of the site, whatever its type
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set(echo.HeaderContentLength, strconv.FormatInt(attachment.FileSize, 10))
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(echo.HeaderContentSecurityPolicy, "sandbox")
+	return c.Stream(http.StatusOK, attachment.MimeType, content)
+}
+
+// GetThumbnail handles downloading a thumbnail of an image attachment
+// @Summary Get an image attachment thumbnail
+// @Description Get a scaled-down copy of a PNG, JPEG or GIF attachment, for galleries and inline previews. Thumbnails are made in the background shortly after upload; until then, or when the image could not be read, this returns 404. The attachment's previews list the URL and size of each thumbnail once they are ready. Access is the same as for downloading the attachment.
+// @Tags tickets
+// @Produce image/png
+// @Produce image/jpeg
+// @Param id path string true "Ticket ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Param size path string true "Thumbnail size" Enums(small, medium, large)
+// @Success 200 {file} binary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/attachments/{attachmentId}/thumbnails/{size} [get]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) GetThumbnail(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+	attachmentID := authMiddleware.ParamUUID(c, "attachmentId")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	contentType, content, err := h.attachmentService.OpenThumbnail(c.Request().Context(), ticketID, attachmentID, c.Param("size"), user)
+	if err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	defer content.Close()
+
+	// Thumbnails are re-encoded by the server, so they can be shown inline
+	header := c.Response().Header()
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(echo.HeaderCacheControl, "private, max-age=86400")
+	return c.Stream(http.StatusOK, contentType, content)
+}
+
+// DeleteAttachment handles deleting a ticket attachment
+// @Summary Delete a ticket attachment
+// @Description Delete a file attached to a ticket. The uploader or an agent can delete it.
+// @Tags tickets
+// @Param id path string true "Ticket ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/attachments/{attachmentId} [delete]
+// @Security ApiKeyAuth
+func (h *AttachmentHandler) DeleteAttachment(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+	attachmentID := authMiddleware.ParamUUID(c, "attachmentId")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.attachmentService.Delete(c.Request().Context(), ticketID, attachmentID, user); err != nil {
+		return attachmentErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// attachmentErrorResponse maps attachment service errors to HTTP responses
+func attachmentErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process attachment request"))
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// RegisterRoutes registers the audit log routes
+func (h *AuditHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	audit := e.Group("/api/v1/admin/audit-logs")
+	audit.Use(ami.Authenticate, ami.RequireAdmin())
+	audit.GET("", h.ListAuditLogs)
+}
+
+// ListAuditLogs handles listing audit log entries
+// @Summary List audit log entries
+// @Description List audit log entries, newest first
+// @Tags admin
+// @Produce json
+// @Param action query string false "Filter by action"
+// @Param entity_type query string false "Filter by entity type"
+// @Param entity_id query string false "Filter by entity ID"
+// @Param actor_id query string false "Filter by actor ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.AuditLogListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/audit-logs [get]
+// @Security ApiKeyAuth
+func (h *AuditHandler) ListAuditLogs(c echo.Context) error {
+	query := &models.AuditLogQuery{
+		Action:     c.QueryParam("action"),
+		EntityType: c.QueryParam("entity_type"),
+	}
+
+	for param, target := range map[string]**uuid.UUID{"entity_id": &query.EntityID, "actor_id": &query.ActorID} {
+		if value := c.QueryParam(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid "+param))
+			}
+			*target = &id
+		}
+	}
+
+	query.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	query.PageSize, _ = strconv.Atoi(c.QueryParam("page_size"))
+	if query.PageSize > 100 {
+		query.PageSize = 100
+	}
+
+	entries, err := h.auditService.List(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
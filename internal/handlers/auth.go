@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
@@ -13,13 +15,21 @@ import (
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authService *services.AuthService
+	authService         *services.AuthService
+	accountService      *services.AccountService
+	loginHistoryService *services.LoginHistoryService
+	botProtection       *authMiddleware.BotProtection
+	tokenLockout        *authMiddleware.TokenLockout
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, accountService *services.AccountService, loginHistoryService *services.LoginHistoryService, botProtection *authMiddleware.BotProtection, tokenLockout *authMiddleware.TokenLockout) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:         authService,
+		accountService:      accountService,
+		loginHistoryService: loginHistoryService,
+		botProtection:       botProtection,
+		tokenLockout:        tokenLockout,
 	}
 }
 
@@ -30,31 +40,35 @@ func (h *AuthHandler) RegisterRoutes(e *echo.Echo, authMiddlewareInstance *authM
 
 	// Authentication routes
 	auth := api.Group("/auth")
-	auth.POST("/register", h.Register)
-	auth.POST("/login", h.Login)
+	// Unauthenticated endpoints are rate limited per IP; registration also requires a CAPTCHA,
+	// and guessing at account link tokens is locked out
+	auth.POST("/register", h.Register, h.botProtection.RateLimit(), h.botProtection.RequireCaptcha())
+	auth.POST("/login", h.Login, h.botProtection.RateLimit())
 	auth.POST("/refresh", h.RefreshToken)
 	auth.POST("/logout", h.Logout, authMiddlewareInstance.Authenticate)
-	auth.POST("/forgot-password", h.ForgotPassword)
-	auth.POST("/reset-password", h.ResetPassword)
-	auth.POST("/verify-email", h.VerifyEmail)
+	auth.POST("/forgot-password", h.ForgotPassword, h.botProtection.RateLimit())
+	auth.POST("/reset-password", h.ResetPassword, h.botProtection.RateLimit(), h.tokenLockout.GuardBody(models.AuditEntityPasswordReset, "token"))
+	auth.POST("/verify-email", h.VerifyEmail, h.botProtection.RateLimit(), h.tokenLockout.GuardBody(models.AuditEntityEmailVerification, "token"))
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user account with the specified role
+// @Description Register a new user account with the specified role. A welcome email with a link to verify the address is sent to the new user.
 // @Tags authentication
 // @Accept json
 // @Produce json
 // @Param request body models.RegisterRequest true "Registration request"
+// @Param X-Captcha-Token header string false "CAPTCHA response token (required when CAPTCHA is enabled)"
 // @Success 201 {object} models.AuthResponse "User registered successfully"
 // @Failure 400 {object} models.ErrorResponse "Invalid request data"
 // @Failure 409 {object} models.ErrorResponse "User already exists"
+// @Failure 429 {object} models.ErrorResponse "Too many requests"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c echo.Context) error {
 	var req models.RegisterRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return echo.NewHTTPError(http.StatusBadRequest, bindErrorMessage(err, "invalid request body"))
 	}
 
 	// Validate request
@@ -68,6 +82,11 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	// The welcome email is best effort; the user can ask for another verification link later
+	if err := h.accountService.SendWelcome(c.Request().Context(), response.User); err != nil {
+		c.Logger().Errorf("failed to send welcome email to user %s: %v", response.User.ID, err)
+	}
+
 	// Set JWT tokens as HTTP-only cookies
 	h.setAuthCookies(c, tokenResponse.AccessToken, tokenResponse.RefreshToken)
 
@@ -90,7 +109,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 func (h *AuthHandler) Login(c echo.Context) error {
 	var req models.LoginRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return echo.NewHTTPError(http.StatusBadRequest, bindErrorMessage(err, "invalid request body"))
 	}
 
 	// Validate request
@@ -104,6 +123,15 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
 	}
 
+	// Record the login; history is best effort and never blocks signing in
+	if _, err := h.loginHistoryService.RecordLogin(c.Request().Context(), response.User, &models.LoginMetadata{
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Location:  h.geoHint(c.Request()),
+	}); err != nil {
+		c.Logger().Errorf("failed to record login for user %s: %v", response.User.ID, err)
+	}
+
 	// Set JWT tokens as HTTP-only cookies
 	h.setAuthCookies(c, tokenResponse.AccessToken, tokenResponse.RefreshToken)
 
@@ -224,7 +252,7 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 
 // ForgotPassword godoc
 // @Summary Request password reset
-// @Description Send password reset email to user
+// @Description Email a single-use password reset link to the account with the given address. The response is the same whether or not an account exists.
 // @Tags authentication
 // @Accept json
 // @Produce json
@@ -236,7 +264,7 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 func (h *AuthHandler) ForgotPassword(c echo.Context) error {
 	var req models.ForgotPasswordRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return echo.NewHTTPError(http.StatusBadRequest, bindErrorMessage(err, "invalid request body"))
 	}
 
 	// Validate request
@@ -244,8 +272,10 @@ func (h *AuthHandler) ForgotPassword(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// TODO: Implement password reset email functionality
-	// For now, we'll just return success
+	if err := h.accountService.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send password reset email")
+	}
+
 	return c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "If the email exists, a password reset link has been sent",
 	})
@@ -253,7 +283,7 @@ func (h *AuthHandler) ForgotPassword(c echo.Context) error {
 
 // ResetPassword godoc
 // @Summary Reset password
-// @Description Reset password using reset token
+// @Description Set a new password using the token from a password reset link. Each token can be used once, until it expires. Clients and tokens with repeated bad attempts are blocked for a while.
 // @Tags authentication
 // @Accept json
 // @Produce json
@@ -261,12 +291,13 @@ func (h *AuthHandler) ForgotPassword(c echo.Context) error {
 // @Success 200 {object} models.SuccessResponse "Password reset successful"
 // @Failure 400 {object} models.ErrorResponse "Invalid request data"
 // @Failure 401 {object} models.ErrorResponse "Invalid or expired token"
+// @Failure 429 {object} models.ErrorResponse "Too many requests or bad tokens from this client"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /api/v1/auth/reset-password [post]
 func (h *AuthHandler) ResetPassword(c echo.Context) error {
 	var req models.ResetPasswordRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return echo.NewHTTPError(http.StatusBadRequest, bindErrorMessage(err, "invalid request body"))
 	}
 
 	// Validate request
@@ -274,8 +305,10 @@ func (h *AuthHandler) ResetPassword(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// TODO: Implement password reset functionality
-	// For now, we'll just return success
+	if err := h.accountService.ResetPassword(c.Request().Context(), req.Token, req.Password); err != nil {
+		return accountTokenErrorResponse(err)
+	}
+
 	return c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Password reset successful",
 	})
@@ -283,7 +316,7 @@ func (h *AuthHandler) ResetPassword(c echo.Context) error {
 
 // VerifyEmail godoc
 // @Summary Verify email address
-// @Description Verify user email address using verification token
+// @Description Verify the user's email address using the token from the link in their welcome email. Each token can be used once, until it expires. Clients and tokens with repeated bad attempts are blocked for a while.
 // @Tags authentication
 // @Accept json
 // @Produce json
@@ -291,12 +324,13 @@ func (h *AuthHandler) ResetPassword(c echo.Context) error {
 // @Success 200 {object} models.SuccessResponse "Email verified successfully"
 // @Failure 400 {object} models.ErrorResponse "Invalid request data"
 // @Failure 401 {object} models.ErrorResponse "Invalid or expired token"
+// @Failure 429 {object} models.ErrorResponse "Too many requests or bad tokens from this client"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /api/v1/auth/verify-email [post]
 func (h *AuthHandler) VerifyEmail(c echo.Context) error {
 	var req models.VerifyEmailRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return echo.NewHTTPError(http.StatusBadRequest, bindErrorMessage(err, "invalid request body"))
 	}
 
 	// Validate request
@@ -304,13 +338,23 @@ func (h *AuthHandler) VerifyEmail(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// TODO: Implement email verification functionality
-	// For now, we'll just return success
+	if err := h.accountService.VerifyEmail(c.Request().Context(), req.Token); err != nil {
+		return accountTokenErrorResponse(err)
+	}
+
 	return c.JSON(http.StatusOK, models.SuccessResponse{
 		Message: "Email verified successfully",
 	})
 }
 
+// accountTokenErrorResponse maps password reset and email verification errors to HTTP errors
+func accountTokenErrorResponse(err error) error {
+	if errors.Is(err, services.ErrAccountTokenInvalid) {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+}
+
 func (h *AuthHandler) setAuthCookies(c echo.Context, accessToken, refreshToken string) {
 	// Parse access token TTL for cookie expiration
 	accessTokenTTL, err := time.ParseDuration(h.authService.GetConfig().JWT.AccessTokenTTL)
@@ -361,3 +405,21 @@ func (h *AuthHandler) setAuthCookies(c echo.Context, accessToken, refreshToken s
 		SameSite: sameSite,
 	})
 }
+
+// geoHintHeaders are country headers set by common CDNs and load balancers
+var geoHintHeaders = []string{"CF-IPCountry", "CloudFront-Viewer-Country", "X-Country-Code"}
+
+// geoHint returns the client's country as reported by a trusted proxy, or an
+// empty string when the server is not behind one
+func (h *AuthHandler) geoHint(r *http.Request) string {
+	if !h.authService.GetConfig().Server.TrustProxyHeaders {
+		return ""
+	}
+	for _, header := range geoHintHeaders {
+		// Cloudflare reports XX for unknown and T1 for Tor exit nodes
+		if value := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); value != "" && value != "XX" {
+			return value
+		}
+	}
+	return ""
+}
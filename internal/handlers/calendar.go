@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// CalendarHandler handles business calendar requests
+type CalendarHandler struct {
+	calendarService *services.BusinessCalendarService
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(calendarService *services.BusinessCalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// RegisterRoutes registers the calendar routes
+func (h *CalendarHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	calendar := e.Group("/api/v1/calendar")
+	calendar.Use(ami.Authenticate)
+	calendar.GET("/business-days", h.AddBusinessDays)
+}
+
+// AddBusinessDays handles computing a due date a number of business days ahead
+// @Summary Add business days
+// @Description Return the close of business on the given number of business days after a time, such as the due date of a ticket due in 3 business days. Days without office hours (OFFICE_HOURS) and holidays (OFFICE_HOLIDAYS) are skipped and days are counted in OFFICE_HOURS_TIMEZONE. When support is always open, business days close at the end of the day.
+// @Tags calendar
+// @Produce json
+// @Param days query int true "Number of business days" minimum(1) maximum(365)
+// @Param from query string false "RFC 3339 time to count from (default: now)"
+// @Success 200 {object} models.BusinessDaysResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /api/v1/calendar/business-days [get]
+// @Security ApiKeyAuth
+func (h *CalendarHandler) AddBusinessDays(c echo.Context) error {
+	days, err := strconv.Atoi(c.QueryParam("days"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("days must be a number"))
+	}
+	from := time.Now()
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("from must be an RFC 3339 timestamp"))
+		}
+	}
+
+	response, err := h.calendarService.AddBusinessDays(from, days)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to count business days"))
+	}
+	return c.JSON(http.StatusOK, response)
+}
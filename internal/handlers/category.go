@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// CategoryHandler handles category administration HTTP requests
+type CategoryHandler struct {
+	categoryService *services.CategoryService
+}
+
+// NewCategoryHandler creates a new category handler
+func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+// RegisterRoutes registers the category administration routes
+func (h *CategoryHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	categories := e.Group("/api/v1/admin/categories")
+	categories.Use(ami.Authenticate, ami.RequirePermission(models.PermSystemAdmin), authMiddleware.UUIDParam("id", "category"))
+	categories.GET("", h.ListCategories)
+	categories.PUT("/:id/resolution-requirements", h.SetResolutionRequirements)
+}
+
+// ListCategories handles listing every category with its settings
+// @Summary List categories
+// @Description List all categories, including inactive ones, with the resolution details each requires
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Category
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/categories [get]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) ListCategories(c echo.Context) error {
+	categories, err := h.categoryService.ListCategories(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list categories"))
+	}
+	return c.JSON(http.StatusOK, categories)
+}
+
+// SetResolutionRequirements handles setting what a category's tickets need to be resolved
+// @Summary Set resolution requirements
+// @Description Set the details (resolution_code, root_cause, root_cause_code, knowledge_article_id) that must be recorded before tickets in the category can be resolved or closed. An empty list lifts the requirements.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param requirements body models.ResolutionRequirementsRequest true "Required fields"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/categories/{id}/resolution-requirements [put]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) SetResolutionRequirements(c echo.Context) error {
+	var req models.ResolutionRequirementsRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	category, err := h.categoryService.SetResolutionRequirements(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), &req)
+	if err != nil {
+		return categoryErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+// categoryErrorResponse maps category service errors to HTTP responses
+func categoryErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process category request"))
+	}
+}
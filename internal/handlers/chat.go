@@ -0,0 +1,621 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ChatTokenHeader carries the token a visitor received when starting a chat
+const ChatTokenHeader = "X-Chat-Token"
+
+// ChatHandler handles live chat HTTP requests from visitors and agents
+type ChatHandler struct {
+	chatService    *services.ChatService
+	botProtection  *authMiddleware.BotProtection
+	tokenLockout   *authMiddleware.TokenLockout
+	allowedOrigins []string
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(chatService *services.ChatService, botProtection *authMiddleware.BotProtection, tokenLockout *authMiddleware.TokenLockout, config *config.Config) *ChatHandler {
+	return &ChatHandler{
+		chatService:    chatService,
+		botProtection:  botProtection,
+		tokenLockout:   tokenLockout,
+		allowedOrigins: config.CORS.AllowedOrigins,
+	}
+}
+
+// RegisterRoutes registers the chat routes
+func (h *ChatHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Visitor routes (no authentication); later calls carry the chat token,
+	// and repeated bad tokens for a chat or from a client are locked out
+	public := e.Group("/api/v1/public/chats")
+	public.Use(authMiddleware.UUIDParam("id", "chat"))
+	public.POST("", h.StartChat, h.botProtection.RateLimit())
+	guard := h.tokenLockout.Guard(models.AuditEntityChat, "id")
+	public.GET("/:id", h.GetVisitorChat, guard)
+	public.POST("/:id/messages", h.SendVisitorMessage, guard)
+	public.POST("/:id/end", h.EndVisitorChat, guard)
+	public.GET("/:id/queue", h.GetQueueStatus, guard)
+	public.POST("/:id/ticket", h.LeaveQueue, guard)
+	public.POST("/:id/transcript", h.RequestTranscript, guard)
+	public.POST("/:id/rating", h.RateChat, guard)
+	public.GET("/:id/ws", h.VisitorSocket, guard)
+
+	// Agent routes
+	chats := e.Group("/api/v1/chats")
+	chats.Use(ami.Authenticate, ami.RequireAgent(), authMiddleware.UUIDParam("id", "chat"))
+	chats.GET("", h.ListChats)
+	chats.GET("/:id", h.GetChat)
+	chats.POST("/:id/accept", h.AcceptChat)
+	chats.POST("/:id/messages", h.SendAgentMessage)
+	chats.POST("/:id/end", h.EndChat)
+	chats.POST("/:id/transfer", h.TransferChat)
+	chats.GET("/:id/ws", h.StaffSocket)
+
+	// Supervisor routes
+	chats.POST("/:id/monitor", h.MonitorChat, ami.RequireManager())
+	chats.POST("/:id/join", h.JoinChat, ami.RequireManager())
+	chats.GET("/capacity", h.GetCapacity, ami.RequireManager())
+	// Its own group so a malformed ID is reported as an agent rather than a chat
+	agents := e.Group("/api/v1/chats/agents", ami.Authenticate, ami.RequireManager(), authMiddleware.UUIDParam("id", "agent"))
+	agents.PUT("/:id/capacity", h.SetAgentCapacity)
+
+	// Reports
+	chats.GET("/csat", h.GetCSATReport, ami.RequirePermission(models.PermReportRead))
+}
+
+// StartChat handles a visitor opening a chat
+// @Summary Start a chat
+// @Description Open a chat with the auto-responder. It collects the visitor's name, email and issue, suggests knowledge articles, and hands off to an agent or a ticket. Send the returned token in the X-Chat-Token header of later calls.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param chat body models.StartChatRequest false "Visitor details already known"
+// @Success 201 {object} models.StartChatResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats [post]
+func (h *ChatHandler) StartChat(c echo.Context) error {
+	var req models.StartChatRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	result, err := h.chatService.StartChat(c.Request().Context(), &req, c.RealIP())
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetVisitorChat handles a visitor reading their chat
+// @Summary Get a chat transcript
+// @Description Get the visitor's chat and its transcript; poll for replies
+// @Tags public
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id} [get]
+func (h *ChatHandler) GetVisitorChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	session, err := h.chatService.GetVisitorChat(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader))
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// SendVisitorMessage handles a visitor's chat message
+// @Summary Send a chat message as the visitor
+// @Description Post a message; while the auto-responder has the chat, its replies are included in the response
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Param message body models.SendChatMessageRequest true "Message"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/messages [post]
+func (h *ChatHandler) SendVisitorMessage(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.SendChatMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	session, err := h.chatService.SendVisitorMessage(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader), &req)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// EndVisitorChat handles a visitor leaving their chat
+// @Summary End a chat as the visitor
+// @Description Leave the chat; the transcript is kept
+// @Tags public
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/end [post]
+func (h *ChatHandler) EndVisitorChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	session, err := h.chatService.EndVisitorChat(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader))
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// GetQueueStatus handles a waiting visitor checking their place in the queue
+// @Summary Get queue position
+// @Description Get the visitor's position in the agent queue and the estimated wait, based on the average wait over the last day. Wait times are omitted when there is no recent history.
+// @Tags public
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Success 200 {object} models.ChatQueueStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/queue [get]
+func (h *ChatHandler) GetQueueStatus(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	status, err := h.chatService.QueueStatus(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader))
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// LeaveQueue handles a waiting visitor leaving the queue for a ticket
+// @Summary Leave the queue and create a ticket
+// @Description Leave the agent queue and pass the conversation on as a support request instead; the chat ends
+// @Tags public
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/ticket [post]
+func (h *ChatHandler) LeaveQueue(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	session, err := h.chatService.LeaveQueue(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader))
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// RequestTranscript handles a visitor asking for a copy of their chat
+// @Summary Email the chat transcript
+// @Description Ask for the transcript to be emailed to the visitor's address when the chat ends, or straight away if it already has
+// @Tags public
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/transcript [post]
+func (h *ChatHandler) RequestTranscript(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	session, err := h.chatService.RequestTranscript(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader))
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// RateChat handles a visitor rating their chat
+// @Summary Rate a chat
+// @Description Rate an ended chat from 1 to 5, with an optional comment. A chat can be rated once.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param X-Chat-Token header string true "Chat token"
+// @Param rating body models.RateChatRequest true "Rating"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/rating [post]
+func (h *ChatHandler) RateChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.RateChatRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	session, err := h.chatService.RateChat(c.Request().Context(), id, c.Request().Header.Get(ChatTokenHeader), &req)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// ListChats handles listing chats for agents
+// @Summary List chats
+// @Description List chats, optionally by status; queued chats are listed longest-waiting first
+// @Tags chats
+// @Produce json
+// @Param status query string false "Chat status (BOT, QUEUED, ACTIVE or ENDED)"
+// @Success 200 {array} models.ChatSession
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats [get]
+// @Security ApiKeyAuth
+func (h *ChatHandler) ListChats(c echo.Context) error {
+	chats, err := h.chatService.ListChats(c.Request().Context(), models.ChatStatus(c.QueryParam("status")))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list chats"))
+	}
+	return c.JSON(http.StatusOK, chats)
+}
+
+// GetChat handles retrieving a chat transcript for agents
+// @Summary Get a chat
+// @Description Get a chat and its full transcript, including the conversation with the auto-responder
+// @Tags chats
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id} [get]
+// @Security ApiKeyAuth
+func (h *ChatHandler) GetChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	session, err := h.chatService.GetChat(c.Request().Context(), id)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// AcceptChat handles an agent picking up a queued chat
+// @Summary Accept a chat
+// @Description Pick up a chat waiting in the queue
+// @Tags chats
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/accept [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) AcceptChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	session, err := h.chatService.AcceptChat(c.Request().Context(), id, user)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// SendAgentMessage handles an agent's chat message
+// @Summary Send a chat message as the agent
+// @Description Post a message in a chat you have accepted
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param message body models.SendChatMessageRequest true "Message"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/messages [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) SendAgentMessage(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.SendChatMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	session, err := h.chatService.SendAgentMessage(c.Request().Context(), id, user, &req)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// EndChat handles an agent ending a chat
+// @Summary End a chat
+// @Description End a chat; the transcript is kept
+// @Tags chats
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/end [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) EndChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	session, err := h.chatService.EndChat(c.Request().Context(), id, user)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// TransferChat handles an agent handing a chat on
+// @Summary Transfer a chat
+// @Description Hand an active chat to another agent with free capacity, or back to the queue for a team's members. The note is recorded in the transcript for staff only. Managers may transfer any chat.
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Param transfer body models.TransferChatRequest true "Transfer target"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/transfer [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) TransferChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.TransferChatRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	session, err := h.chatService.TransferChat(c.Request().Context(), id, user, &req)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// MonitorChat handles a supervisor silently following a chat
+// @Summary Monitor a chat
+// @Description Follow a chat without the visitor being told. The transcript records the monitoring for staff.
+// @Tags chats
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/monitor [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) MonitorChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	session, err := h.chatService.MonitorChat(c.Request().Context(), id, user)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// JoinChat handles a supervisor barging in on a chat
+// @Summary Join a chat
+// @Description Join an active chat alongside the assigned agent; the visitor is told and the supervisor can send messages
+// @Tags chats
+// @Produce json
+// @Param id path string true "Chat ID"
+// @Success 200 {object} models.ChatSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/join [post]
+// @Security ApiKeyAuth
+func (h *ChatHandler) JoinChat(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	session, err := h.chatService.JoinChat(c.Request().Context(), id, user)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, session)
+}
+
+// GetCapacity handles supervisors checking live chat capacity
+// @Summary Get chat capacity
+// @Description Get each agent's active chats against their concurrent chat limit, overall utilization of the available agents, and the number of chats waiting
+// @Tags chats
+// @Produce json
+// @Success 200 {object} models.ChatCapacityReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/capacity [get]
+// @Security ApiKeyAuth
+func (h *ChatHandler) GetCapacity(c echo.Context) error {
+	report, err := h.chatService.CapacityReport(c.Request().Context())
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// SetAgentCapacity handles supervisors changing an agent's chat limit
+// @Summary Set an agent's chat limit
+// @Description Set how many chats are routed to an agent at once; null restores the default. Waiting chats are routed to any capacity freed up.
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param id path string true "Agent user ID"
+// @Param capacity body models.SetChatCapacityRequest true "Chat limit"
+// @Success 200 {object} models.AgentChatCapacity
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/agents/{id}/capacity [put]
+// @Security ApiKeyAuth
+func (h *ChatHandler) SetAgentCapacity(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.SetChatCapacityRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	capacity, err := h.chatService.SetAgentCapacity(c.Request().Context(), id, &req)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, capacity)
+}
+
+// chatErrorResponse maps chat service errors to HTTP responses
+func chatErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process chat request"))
+	}
+}
+
+// GetCSATReport handles reporting on chat ratings
+// @Summary Get chat CSAT
+// @Description Summarise visitor ratings of chats that ended in the period, overall and per agent. Chat CSAT is the share of ratings of 4 or 5 and is reported separately from tickets.
+// @Tags chats
+// @Produce json
+// @Param days query int false "Days to cover (default 30, max 365)"
+// @Success 200 {object} models.ChatCSATReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/chats/csat [get]
+// @Security ApiKeyAuth
+func (h *ChatHandler) GetCSATReport(c echo.Context) error {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	report, err := h.chatService.CSATReport(c.Request().Context(), days)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// chatSocketMaxFrame caps the size of a client frame
+const chatSocketMaxFrame = 4096
+
+// chatFrameHandler applies a frame received from a client
+type chatFrameHandler func(ctx context.Context, frame *models.ChatClientFrame) error
+
+// staffOriginAllowed reports whether a staff chat WebSocket comes from an allowed origin
+func (h *ChatHandler) staffOriginAllowed(r *http.Request) bool {
+	return staffOriginAllowed(r, h.allowedOrigins)
+}
+
+// VisitorSocket handles a visitor's live chat connection
+// @Summary Open a visitor chat WebSocket
+// @Description Upgrade to a WebSocket that pushes new messages ("message"), the agent typing ("typing.start"/"typing.stop") and receipts ("message.delivered"/"message.read"). Send {"type":"typing.start"}, {"type":"typing.stop"}, or {"type":"message.read","message_id":"..."} to report the visitor's state. Browsers cannot set headers on WebSockets, so the chat token may be given as the token query parameter.
+// @Tags public
+// @Param id path string true "Chat ID"
+// @Param token query string false "Chat token, if not sent in the X-Chat-Token header"
+// @Success 101 {object} models.ChatEvent
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/v1/public/chats/{id}/ws [get]
+func (h *ChatHandler) VisitorSocket(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	token := c.Request().Header.Get(ChatTokenHeader)
+	if token == "" {
+		token = c.QueryParam("token")
+	}
+	sub, err := h.chatService.SubscribeVisitor(c.Request().Context(), id, token)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	defer sub.Close()
+
+	// Any site embedding the widget may connect; the chat token authenticates the visitor
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	return h.serveChatSocket(c, upgrader, id, sub, func(ctx context.Context, frame *models.ChatClientFrame) error {
+		return h.chatService.HandleVisitorFrame(ctx, id, sub, frame)
+	})
+}
+
+// StaffSocket handles an agent's or supervisor's live chat connection
+// @Summary Open an agent chat WebSocket
+// @Description Upgrade to a WebSocket carrying the same events as the visitor socket, plus staff-only messages. Supervisors who are only monitoring receive events but their typing and receipts are not relayed.
+// @Tags chats
+// @Param id path string true "Chat ID"
+// @Success 101 {object} models.ChatEvent
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/v1/chats/{id}/ws [get]
+// @Security ApiKeyAuth
+func (h *ChatHandler) StaffSocket(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	if !h.staffOriginAllowed(c.Request()) {
+		return c.JSON(http.StatusForbidden, models.NewErrorResponse("Origin not allowed"))
+	}
+
+	sub, err := h.chatService.SubscribeStaff(c.Request().Context(), id)
+	if err != nil {
+		return chatErrorResponse(c, err)
+	}
+	defer sub.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: h.staffOriginAllowed}
+	return h.serveChatSocket(c, upgrader, id, sub, func(ctx context.Context, frame *models.ChatClientFrame) error {
+		return h.chatService.HandleStaffFrame(ctx, id, sub, user, frame)
+	})
+}
+
+// serveChatSocket upgrades the connection, then writes the subscription's
+// events to the client while applying the frames it sends
+func (h *ChatHandler) serveChatSocket(c echo.Context, upgrader websocket.Upgrader, id uuid.UUID, sub *realtime.Subscription, handle chatFrameHandler) error {
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Replies to bad frames go through the writer so only one goroutine writes
+	replies := make(chan *models.ChatEvent, 1)
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(chatSocketMaxFrame)
+		conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var frame models.ChatClientFrame
+			if err = json.Unmarshal(data, &frame); err != nil {
+				err = fmt.Errorf("%w: frames must be JSON objects", services.ErrInvalidInput)
+			} else {
+				err = handle(ctx, &frame)
+			}
+			if err != nil {
+				reply := &models.ChatEvent{Type: models.ChatEventError, ChatID: id, Error: "Failed to process frame"}
+				if errors.Is(err, services.ErrInvalidInput) {
+					reply.Error = err.Error()
+				}
+				select {
+				case replies <- reply:
+				default:
+				}
+			}
+		}
+	}()
+
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return nil
+			}
+		case reply := <-replies:
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteJSON(reply); err != nil {
+				return nil
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// CustomDomainHandler reports the setup of the white-label domain to administrators
+type CustomDomainHandler struct {
+	customDomainService *services.CustomDomainService
+}
+
+// NewCustomDomainHandler creates a new custom domain handler
+func NewCustomDomainHandler(customDomainService *services.CustomDomainService) *CustomDomainHandler {
+	return &CustomDomainHandler{
+		customDomainService: customDomainService,
+	}
+}
+
+// RegisterRoutes registers the custom domain routes
+func (h *CustomDomainHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	customDomain := e.Group("/api/v1/admin/custom-domain")
+	customDomain.Use(ami.Authenticate, ami.RequireAdmin())
+	customDomain.GET("", h.GetStatus)
+}
+
+// GetStatus handles checking the setup of the custom domain
+// @Summary Get custom domain setup status
+// @Description Check that the portal and its emails are ready to use CUSTOM_DOMAIN: that it resolves, that the sign-in cookie domain, CORS origins, share link base URL and sender address agree with it, and that CUSTOM_EMAIL_DOMAIN publishes an SPF record and, when replies by email are on, EMAIL_REPLY_DOMAIN is under it with an MX record. ready is true when every check passes. When no custom domain is set, configured is false and there are no checks.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.CustomDomainStatus
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/admin/custom-domain [get]
+// @Security ApiKeyAuth
+func (h *CustomDomainHandler) GetStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.customDomainService.Status(c.Request().Context()))
+}
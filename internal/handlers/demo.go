@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// DemoHandler handles resetting demo environments
+type DemoHandler struct {
+	demoService *services.DemoService
+	enabled     bool
+}
+
+// NewDemoHandler creates a new demo handler; it registers no routes unless
+// DEMO_MODE is set
+func NewDemoHandler(demoService *services.DemoService, cfg *config.Config) *DemoHandler {
+	return &DemoHandler{
+		demoService: demoService,
+		enabled:     cfg.Demo.Enabled,
+	}
+}
+
+// RegisterRoutes registers the demo routes when demo mode is on
+func (h *DemoHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	if !h.enabled {
+		return
+	}
+	demo := e.Group("/api/v1/admin/demo")
+	demo.Use(ami.Authenticate, ami.RequirePermission(models.PermSystemAdmin))
+	demo.POST("/reset", h.Reset)
+}
+
+// Reset handles resetting the database to the demo data
+// @Summary Reset the demo data
+// @Description Wipe the database and reseed it with the demo data: an administrator, a manager and two agents, four customers, two teams, the default categories and a month of ticket history with comments, assignments and an escalation. Every user signs in with the password "password", including the administrator admin@helpchat.com; sessions from before the reset end, so sign in again. Attachment files are deleted. Only available when DEMO_MODE is set, and the request must confirm with "RESET".
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param reset body models.DemoResetRequest true "Confirmation"
+// @Success 200 {object} models.DemoResetResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/demo/reset [post]
+// @Security ApiKeyAuth
+func (h *DemoHandler) Reset(c echo.Context) error {
+	var req models.DemoResetRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	result, err := h.demoService.Reset(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to reset demo data"))
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// EmailDeliveryHandler handles delivery reports from the mail provider and
+// the administration of outbound email tracking
+type EmailDeliveryHandler struct {
+	deliveryService *services.EmailDeliveryService
+	config          *config.Config
+}
+
+// NewEmailDeliveryHandler creates a new email delivery handler
+func NewEmailDeliveryHandler(deliveryService *services.EmailDeliveryService, config *config.Config) *EmailDeliveryHandler {
+	return &EmailDeliveryHandler{
+		deliveryService: deliveryService,
+		config:          config,
+	}
+}
+
+// RegisterRoutes registers the email delivery routes
+func (h *EmailDeliveryHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Authenticated by a shared secret rather than a user token
+	e.POST("/api/v1/inbound/email/events", h.ReceiveEvents, requireInboundSecret(h.config))
+
+	admin := e.Group("/api/v1/admin/email")
+	admin.Use(ami.Authenticate, ami.RequireAdmin())
+	admin.GET("/deliveries", h.ListDeliveries)
+	admin.GET("/suppressions", h.ListSuppressions)
+	admin.DELETE("/suppressions/:address", h.DeleteSuppression)
+	admin.GET("/failing-users", h.ListFailingUsers)
+}
+
+// ReceiveEvents handles delivery reports from the mail provider
+// @Summary Receive email delivery events
+// @Description Webhook for the mail provider to report deliveries, bounces and complaints. Events are matched to the email by its Message-ID, or to the recipient's latest email when it is left out. A hard bounce or complaint stops all further email to the address and flags its user; soft bounces flag the user once EMAIL_FAILURE_THRESHOLD emails in a row have failed; a delivery clears the flag.
+// @Tags inbound
+// @Accept json
+// @Produce json
+// @Param X-Inbound-Secret header string false "Shared inbound secret (alternatively the secret query parameter)"
+// @Param events body models.EmailDeliveryEventsRequest true "Delivery events"
+// @Success 200 {object} models.EmailDeliveryEventsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/v1/inbound/email/events [post]
+func (h *EmailDeliveryHandler) ReceiveEvents(c echo.Context) error {
+	var req models.EmailDeliveryEventsRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	result, err := h.deliveryService.ApplyEvents(c.Request().Context(), req.Events)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to apply delivery events"))
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// ListDeliveries handles listing outbound emails and their status
+// @Summary List email deliveries
+// @Description List outbound emails per recipient with their latest status, newest first
+// @Tags admin
+// @Produce json
+// @Param status query string false "Filter by status" Enums(QUEUED, SENT, DELIVERED, FAILED, BOUNCED, COMPLAINED, SUPPRESSED)
+// @Param recipient query string false "Filter by recipient address"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.EmailDeliveryListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email/deliveries [get]
+// @Security ApiKeyAuth
+func (h *EmailDeliveryHandler) ListDeliveries(c echo.Context) error {
+	query := &models.EmailDeliveryQuery{
+		Status:    models.EmailDeliveryStatus(c.QueryParam("status")),
+		Recipient: c.QueryParam("recipient"),
+	}
+	query.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	query.PageSize, _ = strconv.Atoi(c.QueryParam("page_size"))
+	if query.PageSize > 100 {
+		query.PageSize = 100
+	}
+
+	deliveries, err := h.deliveryService.List(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list email deliveries"))
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// ListSuppressions handles listing the addresses no email is sent to
+// @Summary List suppressed email addresses
+// @Description List the addresses that hard-bounced or complained, which no email is sent to
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.EmailSuppression
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email/suppressions [get]
+// @Security ApiKeyAuth
+func (h *EmailDeliveryHandler) ListSuppressions(c echo.Context) error {
+	suppressions, err := h.deliveryService.ListSuppressions(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list email suppressions"))
+	}
+	return c.JSON(http.StatusOK, suppressions)
+}
+
+// DeleteSuppression handles sending email to a suppressed address again
+// @Summary Remove an email suppression
+// @Description Send email to a suppressed address again, for example once its owner has fixed their mailbox, and clear their user's failing flag
+// @Tags admin
+// @Param address path string true "Email address"
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email/suppressions/{address} [delete]
+// @Security ApiKeyAuth
+func (h *EmailDeliveryHandler) DeleteSuppression(c echo.Context) error {
+	if err := h.deliveryService.Unsuppress(c.Request().Context(), c.Param("address")); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to remove email suppression"))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListFailingUsers handles listing users whose notifications are failing
+// @Summary List users with failing email
+// @Description List users whose address is suppressed or whose latest emails all failed, longest failing first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.User
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email/failing-users [get]
+// @Security ApiKeyAuth
+func (h *EmailDeliveryHandler) ListFailingUsers(c echo.Context) error {
+	users, err := h.deliveryService.ListFailingUsers(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list users with failing email"))
+	}
+	return c.JSON(http.StatusOK, users)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// EmailTemplateHandler handles editing the notification email templates
+type EmailTemplateHandler struct {
+	templateService *services.EmailTemplateService
+}
+
+// NewEmailTemplateHandler creates a new email template handler
+func NewEmailTemplateHandler(templateService *services.EmailTemplateService) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// RegisterRoutes registers the email template routes
+func (h *EmailTemplateHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Email template management - admin only
+	admin := e.Group("/api/v1/admin/email-templates")
+	admin.Use(ami.Authenticate, ami.RequireAdmin())
+	admin.GET("", h.ListTemplates)
+	admin.GET("/:name", h.GetTemplate)
+	admin.PUT("/:name", h.UpdateTemplate)
+	admin.DELETE("/:name", h.RevertTemplate)
+	admin.POST("/:name/preview", h.PreviewTemplate)
+	admin.GET("/:name/translations", h.ListTranslations)
+	admin.PUT("/:name/translations/:language", h.SaveTranslation)
+	admin.DELETE("/:name/translations/:language", h.DeleteTranslation)
+}
+
+// ListTemplates handles listing the notification email templates
+// @Summary List email templates
+// @Description List every notification email with the subject and text currently sent, the placeholders it may use and whether it has been customized
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.EmailTemplateResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates [get]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) ListTemplates(c echo.Context) error {
+	templates, err := h.templateService.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list email templates"))
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate handles retrieving a notification email template
+// @Summary Get an email template
+// @Tags admin
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Success 200 {object} models.EmailTemplateResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name} [get]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) GetTemplate(c echo.Context) error {
+	template, err := h.templateService.Get(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, template)
+}
+
+// UpdateTemplate handles replacing a notification email's subject and text
+// @Summary Update an email template
+// @Description Replace the subject and text of a notification email. They may only use the template's {{name}} placeholders, and replyable templates must keep {{reply_marker}} in their text.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Param template body models.EmailTemplateRequest true "Subject and text"
+// @Success 200 {object} models.EmailTemplateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name} [put]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) UpdateTemplate(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.EmailTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	template, err := h.templateService.Update(c.Request().Context(), c.Param("name"), &req, userID)
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, template)
+}
+
+// RevertTemplate handles going back to a notification email's built-in template
+// @Summary Revert an email template to its default
+// @Tags admin
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Success 200 {object} models.EmailTemplateResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name} [delete]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) RevertTemplate(c echo.Context) error {
+	template, err := h.templateService.Revert(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, template)
+}
+
+// PreviewTemplate handles rendering a notification email with a sample ticket
+// @Summary Preview an email template
+// @Description Render a notification email with a sample ticket. Send a draft subject and text to check them before saving; without them the saved template is rendered as sent to a recipient preferring the given language, and the response names the translation used.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Param template body models.EmailTemplatePreviewRequest false "Draft subject and text, or the language to preview"
+// @Success 200 {object} models.EmailTemplatePreview
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name}/preview [post]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) PreviewTemplate(c echo.Context) error {
+	var req models.EmailTemplatePreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	preview, err := h.templateService.Preview(c.Request().Context(), c.Param("name"), &req)
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// ListTranslations handles listing a notification email's translations
+// @Summary List email template translations
+// @Tags admin
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Success 200 {array} models.EmailTemplateTranslation
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name}/translations [get]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) ListTranslations(c echo.Context) error {
+	translations, err := h.templateService.ListTranslations(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, translations)
+}
+
+// SaveTranslation handles adding or replacing a notification email's translation
+// @Summary Save an email template translation
+// @Description Add or replace the subject and text sent to recipients preferring a language, given as a BCP 47 tag such as "fr" or "pt-BR". The same placeholders are accepted as by the untranslated template.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Param language path string true "Language"
+// @Param template body models.EmailTemplateRequest true "Subject and text"
+// @Success 200 {object} models.EmailTemplateTranslation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name}/translations/{language} [put]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) SaveTranslation(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.EmailTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	translation, err := h.templateService.SaveTranslation(c.Request().Context(), c.Param("name"), c.Param("language"), &req, userID)
+	if err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+// DeleteTranslation handles removing a notification email's translation
+// @Summary Delete an email template translation
+// @Description Remove a translation; recipients preferring its language get the default language's template instead
+// @Tags admin
+// @Param name path string true "Template name" Enums(ticket_received, ticket_created, comment_added, mentioned, ticket_escalated, ticket_de_escalated, ticket_reassigned_often, ticket_assigned, ticket_status_changed, welcome, password_reset)
+// @Param language path string true "Language"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/email-templates/{name}/translations/{language} [delete]
+// @Security ApiKeyAuth
+func (h *EmailTemplateHandler) DeleteTranslation(c echo.Context) error {
+	if err := h.templateService.DeleteTranslation(c.Request().Context(), c.Param("name"), c.Param("language")); err != nil {
+		return emailTemplateErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// emailTemplateErrorResponse maps email template service errors to HTTP responses
+func emailTemplateErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process email template request"))
+	}
+}
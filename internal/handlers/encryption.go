@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// EncryptionHandler handles encryption at rest administration
+type EncryptionHandler struct {
+	encryptionService *services.EncryptionService
+}
+
+// NewEncryptionHandler creates a new encryption handler
+func NewEncryptionHandler(encryptionService *services.EncryptionService) *EncryptionHandler {
+	return &EncryptionHandler{
+		encryptionService: encryptionService,
+	}
+}
+
+// RegisterRoutes registers the encryption administration routes
+func (h *EncryptionHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	encryption := e.Group("/api/v1/admin/encryption")
+	encryption.Use(ami.Authenticate, ami.RequireAdmin())
+	encryption.POST("/rotate", h.RotateKeys)
+}
+
+// RotateKeys handles re-encrypting stored values with the primary key
+// @Summary Rotate encryption keys
+// @Description Re-encrypt every sensitive column sealed with a retired key, or still stored as plaintext, using the primary key. Run after adding a new primary key; retired keys can be removed once it succeeds.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.KeyRotationResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/encryption/rotate [post]
+// @Security ApiKeyAuth
+func (h *EncryptionHandler) RotateKeys(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	response, err := h.encryptionService.RotateKeys(c.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrEncryptionDisabled) {
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to rotate encryption keys"))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ExportHandler handles analytics export administration
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// RegisterRoutes registers the export administration routes
+func (h *ExportHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	exports := e.Group("/api/v1/admin/exports")
+	exports.Use(ami.Authenticate, ami.RequireAdmin())
+	exports.GET("", h.ListExports)
+	exports.POST("", h.RunExport)
+	exports.GET("/:day", h.GetExport)
+}
+
+// ListExports handles listing the days shipped to the analytics warehouse
+// @Summary List analytics exports
+// @Description List the days exported to the analytics warehouse, most recent first, with the files written and how many rows they hold
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.DataExport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/exports [get]
+// @Security ApiKeyAuth
+func (h *ExportHandler) ListExports(c echo.Context) error {
+	exports, err := h.exportService.ListExports(c.Request().Context())
+	if err != nil {
+		return exportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, exports)
+}
+
+// GetExport handles retrieving the export of one day
+// @Summary Get an analytics export
+// @Description Get the export of one office day
+// @Tags admin
+// @Produce json
+// @Param day path string true "Day as YYYY-MM-DD"
+// @Success 200 {object} models.DataExport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/exports/{day} [get]
+// @Security ApiKeyAuth
+func (h *ExportHandler) GetExport(c echo.Context) error {
+	export, err := h.exportService.GetExport(c.Request().Context(), c.Param("day"))
+	if err != nil {
+		return exportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, export)
+}
+
+// RunExport handles exporting one day on demand
+// @Summary Export a day
+// @Description Export one finished office day now, replacing its earlier files. Use it to backfill days from before exports were enabled or to re-send a day after fixing the destination.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.RunExportRequest true "Day to export"
+// @Success 200 {object} models.DataExport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/exports [post]
+// @Security ApiKeyAuth
+func (h *ExportHandler) RunExport(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.RunExportRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	export, err := h.exportService.ExportDay(c.Request().Context(), req.Day, &userID)
+	if err != nil {
+		return exportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, export)
+}
+
+// exportErrorResponse maps export service errors to HTTP responses
+func exportErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process export request"))
+	}
+}
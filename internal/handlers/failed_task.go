@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// FailedTaskHandler lets administrators inspect, retry and discard failed background work
+type FailedTaskHandler struct {
+	failedTaskService *services.FailedTaskService
+}
+
+// NewFailedTaskHandler creates a new failed task handler
+func NewFailedTaskHandler(failedTaskService *services.FailedTaskService) *FailedTaskHandler {
+	return &FailedTaskHandler{
+		failedTaskService: failedTaskService,
+	}
+}
+
+// RegisterRoutes registers the failed task routes
+func (h *FailedTaskHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	tasks := e.Group("/api/v1/admin/failed-tasks")
+	tasks.Use(ami.Authenticate, ami.RequireAdmin())
+	tasks.GET("", h.ListFailedTasks)
+	tasks.POST("/retry", h.RetryFailedTasks)
+	tasks.POST("/discard", h.DiscardFailedTasks)
+	tasks.GET("/:id", h.GetFailedTask, authMiddleware.UUIDParam("id", "failed task"))
+	tasks.POST("/:id/retry", h.RetryFailedTask, authMiddleware.UUIDParam("id", "failed task"))
+	tasks.DELETE("/:id", h.DiscardFailedTask, authMiddleware.UUIDParam("id", "failed task"))
+}
+
+// ListFailedTasks handles listing failed background work
+// @Summary List failed background work
+// @Description List scheduled job runs, notification emails and audit batches for SIEM sinks that failed, newest first. A job is listed once however often it failed; failures counts them.
+// @Tags admin
+// @Produce json
+// @Param kind query string false "Filter by kind" Enums(job, email, audit_sink)
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.FailedTaskListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks [get]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) ListFailedTasks(c echo.Context) error {
+	query := &models.FailedTaskQuery{
+		Kind: c.QueryParam("kind"),
+	}
+	query.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	query.PageSize, _ = strconv.Atoi(c.QueryParam("page_size"))
+	if query.PageSize > 100 {
+		query.PageSize = 100
+	}
+
+	tasks, err := h.failedTaskService.List(c.Request().Context(), query)
+	if err != nil {
+		return failedTaskErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, tasks)
+}
+
+// GetFailedTask handles getting a failed task
+// @Summary Get failed background work
+// @Description Get a failed task with the payload a retry would use: the email or the batch of audit events
+// @Tags admin
+// @Produce json
+// @Param id path string true "Failed task ID"
+// @Success 200 {object} models.FailedTaskDetail
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks/{id} [get]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) GetFailedTask(c echo.Context) error {
+	task, err := h.failedTaskService.Get(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return failedTaskErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, task)
+}
+
+// RetryFailedTask handles retrying a failed task
+// @Summary Retry failed background work
+// @Description Run a failed task again. A task that succeeds is removed; one that fails again keeps the new error and is reported in failed.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Failed task ID"
+// @Success 200 {object} models.FailedTaskBulkResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks/{id}/retry [post]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) RetryFailedTask(c echo.Context) error {
+	return h.applyOne(c, h.failedTaskService.Retry, http.StatusOK)
+}
+
+// DiscardFailedTask handles discarding a failed task
+// @Summary Discard failed background work
+// @Description Remove a failed task without running it again
+// @Tags admin
+// @Param id path string true "Failed task ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks/{id} [delete]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) DiscardFailedTask(c echo.Context) error {
+	return h.applyOne(c, h.failedTaskService.Discard, http.StatusNoContent)
+}
+
+// RetryFailedTasks handles retrying failed tasks in bulk
+// @Summary Retry failed background work in bulk
+// @Description Run up to 100 failed tasks again, one after another. Tasks that succeed are removed; tasks that fail again or are not found are reported in failed with the reason.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.FailedTaskBulkRequest true "Failed tasks to retry"
+// @Success 200 {object} models.FailedTaskBulkResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks/retry [post]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) RetryFailedTasks(c echo.Context) error {
+	return h.applyBulk(c, h.failedTaskService.Retry)
+}
+
+// DiscardFailedTasks handles discarding failed tasks in bulk
+// @Summary Discard failed background work in bulk
+// @Description Remove up to 100 failed tasks without running them again. Tasks that are not found are reported in failed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.FailedTaskBulkRequest true "Failed tasks to discard"
+// @Success 200 {object} models.FailedTaskBulkResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/failed-tasks/discard [post]
+// @Security ApiKeyAuth
+func (h *FailedTaskHandler) DiscardFailedTasks(c echo.Context) error {
+	return h.applyBulk(c, h.failedTaskService.Discard)
+}
+
+// failedTaskAction is a bulk action of the failed task service
+type failedTaskAction func(ctx context.Context, ids []uuid.UUID, actorID uuid.UUID) (*models.FailedTaskBulkResult, error)
+
+// applyOne applies action to the task in the path, which must exist
+func (h *FailedTaskHandler) applyOne(c echo.Context, action failedTaskAction, status int) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	id := authMiddleware.ParamUUID(c, "id")
+	if _, err := h.failedTaskService.Get(c.Request().Context(), id); err != nil {
+		return failedTaskErrorResponse(c, err)
+	}
+	result, err := action(c.Request().Context(), []uuid.UUID{id}, user.ID)
+	if err != nil {
+		return failedTaskErrorResponse(c, err)
+	}
+	if status == http.StatusNoContent {
+		return c.NoContent(status)
+	}
+	return c.JSON(status, result)
+}
+
+// applyBulk applies action to the tasks in the request body
+func (h *FailedTaskHandler) applyBulk(c echo.Context, action failedTaskAction) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.FailedTaskBulkRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	result, err := action(c.Request().Context(), req.IDs, user.ID)
+	if err != nil {
+		return failedTaskErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// failedTaskErrorResponse maps failed task service errors to HTTP responses
+func failedTaskErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process failed task request"))
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// FeatureUsageHandler reports which API features are used to administrators
+type FeatureUsageHandler struct {
+	usageService *services.FeatureUsageService
+}
+
+// NewFeatureUsageHandler creates a new feature usage handler
+func NewFeatureUsageHandler(usageService *services.FeatureUsageService) *FeatureUsageHandler {
+	return &FeatureUsageHandler{
+		usageService: usageService,
+	}
+}
+
+// RegisterRoutes registers the feature usage routes
+func (h *FeatureUsageHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	usage := e.Group("/api/v1/admin/feature-usage")
+	usage.Use(ami.Authenticate, ami.RequireAdmin())
+	usage.GET("", h.GetFeatureUsage)
+}
+
+// GetFeatureUsage handles summarizing which API routes are used and by whom
+// @Summary Get API feature usage
+// @Description Summarize the API requests recorded between from and to: the total, the most requested routes with their distinct users, error rate and latency, the requests made by each role, and the users who made the most. Requests are recorded per route template, such as /api/v1/tickets/:id, and written every FEATURE_USAGE_FLUSH_INTERVAL, so the latest requests may not be counted yet. Anonymous requests count towards the totals and routes with an empty role, but not towards users.
+// @Tags admin
+// @Produce json
+// @Param from query string false "First date, YYYY-MM-DD in UTC; defaults to 30 days before to"
+// @Param to query string false "Last date, YYYY-MM-DD in UTC; defaults to today"
+// @Param limit query int false "Maximum number of routes and of users, 1 to 100" default(20)
+// @Success 200 {object} models.FeatureUsageReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/feature-usage [get]
+// @Security ApiKeyAuth
+func (h *FeatureUsageHandler) GetFeatureUsage(c echo.Context) error {
+	limit := 0
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("limit must be a positive number"))
+		}
+	}
+
+	report, err := h.usageService.Report(c.Request().Context(), c.QueryParam("from"), c.QueryParam("to"), limit)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
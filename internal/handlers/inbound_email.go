@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// maxInboundEmailMemory bounds the in-memory part of a parsed inbound email form
+const maxInboundEmailMemory = 32 << 20
+
+// InboundEmailHandler handles email webhooks posted by the inbound mail provider
+type InboundEmailHandler struct {
+	intakeService *services.IntakeService
+	config        *config.Config
+}
+
+// NewInboundEmailHandler creates a new inbound email handler
+func NewInboundEmailHandler(intakeService *services.IntakeService, config *config.Config) *InboundEmailHandler {
+	return &InboundEmailHandler{
+		intakeService: intakeService,
+		config:        config,
+	}
+}
+
+// RegisterRoutes registers the inbound email routes
+func (h *InboundEmailHandler) RegisterRoutes(e *echo.Echo) {
+	// Authenticated by a shared secret rather than a user token
+	e.POST("/api/v1/inbound/email", h.ReceiveEmail, requireInboundSecret(h.config))
+}
+
+// requireInboundSecret rejects mail provider webhooks without the shared
+// inbound secret, given in the X-Inbound-Secret header or secret query parameter
+func requireInboundSecret(cfg *config.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			expected := cfg.Email.InboundSecret
+			if expected == "" {
+				return c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Inbound email is not configured"))
+			}
+
+			secret := c.Request().Header.Get("X-Inbound-Secret")
+			if secret == "" {
+				secret = c.QueryParam("secret")
+			}
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+				return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid inbound secret"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// ReceiveEmail handles an inbound email
+// @Summary Receive an inbound email
+// @Description Webhook for the inbound mail provider (SendGrid Inbound Parse compatible multipart form). Replies sent to a ticket's reply-to address are stored as comments with quoted text and signatures removed, and any attachments are saved to the ticket. Other emails open a new ticket unless they score as spam, in which case they are quarantined for review.
+// @Tags inbound
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-Inbound-Secret header string false "Shared inbound secret (alternatively the secret query parameter)"
+// @Param from formData string true "Sender address"
+// @Param to formData string true "Recipient addresses"
+// @Param subject formData string false "Subject"
+// @Param text formData string false "Plain text body"
+// @Param html formData string false "HTML body"
+// @Success 200 {object} models.InboundEmailResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/v1/inbound/email [post]
+func (h *InboundEmailHandler) ReceiveEmail(c echo.Context) error {
+	inbound, err := parseInboundEmail(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid inbound email payload"))
+	}
+
+	comment, err := h.intakeService.ReceiveReply(c.Request().Context(), inbound)
+	if errors.Is(err, services.ErrReplyTokenMissing) {
+		// Not a reply to an existing ticket, so it starts a new one
+		return h.ingestNewTicket(c, inbound)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReplyTokenInvalid), errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponse("Ticket not found"))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusOK, models.InboundEmailResponse{
+		TicketID:    comment.TicketID.String(),
+		CommentID:   comment.ID.String(),
+		Attachments: len(comment.Attachments),
+	})
+}
+
+// ingestNewTicket creates a ticket from an email that is not a reply
+func (h *InboundEmailHandler) ingestNewTicket(c echo.Context, inbound *models.InboundEmail) error {
+	ticket, err := h.intakeService.IngestEmail(c.Request().Context(), inbound)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	if ticket == nil {
+		return c.JSON(http.StatusOK, models.InboundEmailResponse{Quarantined: true})
+	}
+
+	return c.JSON(http.StatusOK, models.InboundEmailResponse{
+		TicketID:    ticket.ID.String(),
+		Attachments: len(inbound.Attachments),
+	})
+}
+
+// parseInboundEmail reads the provider's multipart form into an InboundEmail
+func parseInboundEmail(c echo.Context) (*models.InboundEmail, error) {
+	req := c.Request()
+	if err := req.ParseMultipartForm(maxInboundEmailMemory); err != nil {
+		return nil, err
+	}
+
+	inbound := &models.InboundEmail{
+		From:    req.FormValue("from"),
+		Subject: req.FormValue("subject"),
+		Text:    req.FormValue("text"),
+		HTML:    req.FormValue("html"),
+	}
+	for _, to := range []string{req.FormValue("to"), req.FormValue("cc")} {
+		if strings.TrimSpace(to) != "" {
+			inbound.To = append(inbound.To, to)
+		}
+	}
+
+	// The SMTP envelope also carries BCC'd recipients that are absent from the headers
+	var envelope struct {
+		To []string `json:"to"`
+	}
+	if raw := req.FormValue("envelope"); raw != "" && json.Unmarshal([]byte(raw), &envelope) == nil {
+		inbound.To = append(inbound.To, envelope.To...)
+	}
+
+	for _, files := range req.MultipartForm.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			inbound.Attachments = append(inbound.Attachments, models.InboundAttachment{
+				Filename:    fh.Filename,
+				ContentType: fh.Header.Get("Content-Type"),
+				Content:     content,
+			})
+		}
+	}
+
+	return inbound, nil
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// maxInboundPayload bounds the JSON payloads external systems post to inbound integrations
+const maxInboundPayload = 1 << 20
+
+// InboundIntegrationHandler handles the payloads external systems post to
+// inbound integrations and their management by administrators
+type InboundIntegrationHandler struct {
+	inboundService *services.InboundService
+}
+
+// NewInboundIntegrationHandler creates a new inbound integration handler
+func NewInboundIntegrationHandler(inboundService *services.InboundService) *InboundIntegrationHandler {
+	return &InboundIntegrationHandler{
+		inboundService: inboundService,
+	}
+}
+
+// RegisterRoutes registers the inbound integration routes
+func (h *InboundIntegrationHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Authorized by the integration's secret rather than a session. Keys are
+	// not secret, so failures are not locked out: that would let anyone block
+	// a key, and its generated secret cannot be guessed.
+	e.POST("/api/v1/inbound/:integration_key", h.Receive)
+	e.POST("/api/v1/inbound/:integration_key/alertmanager", h.ReceiveAlertmanager)
+
+	admin := e.Group("/api/v1/admin/inbound-integrations")
+	admin.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "inbound integration"))
+	admin.GET("", h.ListIntegrations)
+	admin.POST("", h.CreateIntegration)
+	admin.GET("/:id", h.GetIntegration)
+	admin.PUT("/:id", h.UpdateIntegration)
+	admin.DELETE("/:id", h.DeleteIntegration)
+	admin.POST("/:id/secret", h.RotateSecret)
+}
+
+// Receive handles a payload posted by an external system
+// @Summary Post to an inbound integration
+// @Description Webhook for external systems such as monitoring, form builders or CI. The JSON payload is mapped onto a ticket by the integration's templates. A payload whose external ID opened a ticket before updates that ticket, moving it to the mapped status and adding the mapped comment; any other payload opens a ticket raised by the integration's requester. Give the integration's secret in the X-Inbound-Secret header or secret query parameter, or sign the raw body with it in X-Signature-256 as sha256=<hex HMAC-SHA256>; a bearer token works too. Unknown and disabled keys are refused like a wrong secret.
+// @Tags inbound
+// @Accept json
+// @Produce json
+// @Param integration_key path string true "Integration key"
+// @Param X-Inbound-Secret header string false "Integration secret (alternatively the secret query parameter)"
+// @Param X-Signature-256 header string false "sha256= followed by the hex HMAC-SHA256 of the body, keyed with the integration secret"
+// @Param payload body object true "Payload to map onto a ticket"
+// @Success 200 {object} models.InboundResult "The payload updated a ticket"
+// @Success 201 {object} models.InboundResult "The payload opened a ticket"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/inbound/{integration_key} [post]
+func (h *InboundIntegrationHandler) Receive(c echo.Context) error {
+	body, err := readInboundPayload(c)
+	if body == nil {
+		return err
+	}
+
+	req := c.Request()
+	result, err := h.inboundService.Receive(req.Context(), c.Param("integration_key"), body, inboundSecret(c), req.Header.Get("X-Signature-256"))
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	if result.Created {
+		return c.JSON(http.StatusCreated, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// ReceiveAlertmanager handles a Prometheus Alertmanager notification
+// @Summary Post Alertmanager alerts to an inbound integration
+// @Description Webhook receiver for Prometheus Alertmanager. Each alert group gets a ticket, raised by the integration's requester with a priority mapped from the severity label. Alerts are deduplicated on fingerprint: notifications about alerts or groups that opened a ticket update it instead, commenting on alerts that start firing or resolve. An alert firing again reopens a resolved ticket, and the notification clearing a ticket's last alert resolves it. Give the integration's secret as a bearer token (the receiver's http_config authorization), in the X-Inbound-Secret header or in the secret query parameter.
+// @Tags inbound
+// @Accept json
+// @Produce json
+// @Param integration_key path string true "Integration key"
+// @Param Authorization header string false "Bearer followed by the integration secret"
+// @Param X-Inbound-Secret header string false "Integration secret (alternatively the secret query parameter)"
+// @Param notification body models.AlertmanagerPayload true "Alertmanager webhook notification"
+// @Success 200 {object} models.AlertmanagerResult "The notification updated a ticket, or only resolved alerts that never opened one"
+// @Success 201 {object} models.AlertmanagerResult "The notification opened a ticket"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/inbound/{integration_key}/alertmanager [post]
+func (h *InboundIntegrationHandler) ReceiveAlertmanager(c echo.Context) error {
+	body, err := readInboundPayload(c)
+	if body == nil {
+		return err
+	}
+
+	req := c.Request()
+	result, err := h.inboundService.ReceiveAlertmanager(req.Context(), c.Param("integration_key"), body, inboundSecret(c), req.Header.Get("X-Signature-256"))
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	if result.Created {
+		return c.JSON(http.StatusCreated, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// readInboundPayload reads the body posted to an inbound integration. When it
+// cannot, it writes the error response and returns nil with the result of
+// writing it.
+func readInboundPayload(c echo.Context) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxInboundPayload))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, c.JSON(http.StatusRequestEntityTooLarge, models.NewErrorResponse("The payload is too large"))
+		}
+		return nil, c.JSON(http.StatusBadRequest, models.NewErrorResponse("Failed to read the payload"))
+	}
+	return body, nil
+}
+
+// inboundSecret returns the secret a caller gave an inbound integration: a
+// bearer token, the X-Inbound-Secret header or the secret query parameter
+func inboundSecret(c echo.Context) string {
+	header := c.Request().Header
+	if token, ok := strings.CutPrefix(header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if secret := header.Get("X-Inbound-Secret"); secret != "" {
+		return secret
+	}
+	return c.QueryParam("secret")
+}
+
+// ListIntegrations handles listing the inbound integrations
+// @Summary List inbound integrations
+// @Description List every inbound integration with its mapping and when it last created or updated a ticket. Secrets are never shown.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.InboundIntegration
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations [get]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) ListIntegrations(c echo.Context) error {
+	integrations, err := h.inboundService.ListIntegrations(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list inbound integrations"))
+	}
+	return c.JSON(http.StatusOK, integrations)
+}
+
+// CreateIntegration handles creating an inbound integration
+// @Summary Create an inbound integration
+// @Description Create a webhook at /api/v1/inbound/{key} that external systems post JSON to. The mapping's templates use {{path}} placeholders naming payload fields, such as {{alert.labels.severity}} or {{alerts.0.summary}}. The integration's secret is generated and returned only in this response; it is enabled unless enabled is false.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param integration body models.InboundIntegrationRequest true "Integration"
+// @Success 201 {object} models.InboundIntegrationSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations [post]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) CreateIntegration(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.InboundIntegrationRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	created, err := h.inboundService.CreateIntegration(c.Request().Context(), &req, userID)
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// GetIntegration handles retrieving an inbound integration
+// @Summary Get an inbound integration
+// @Description Get an inbound integration with its mapping. Its secret is never shown.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Inbound integration ID"
+// @Success 200 {object} models.InboundIntegration
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations/{id} [get]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) GetIntegration(c echo.Context) error {
+	integration, err := h.inboundService.GetIntegration(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, integration)
+}
+
+// UpdateIntegration handles replacing the settings of an inbound integration
+// @Summary Update an inbound integration
+// @Description Replace the key, name, requester, category, team and mapping of an inbound integration. Its secret is kept; enabled is kept unless given.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Inbound integration ID"
+// @Param integration body models.InboundIntegrationRequest true "Integration"
+// @Success 200 {object} models.InboundIntegration
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations/{id} [put]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) UpdateIntegration(c echo.Context) error {
+	var req models.InboundIntegrationRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	integration, err := h.inboundService.UpdateIntegration(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), &req)
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, integration)
+}
+
+// DeleteIntegration handles deleting an inbound integration
+// @Summary Delete an inbound integration
+// @Description Delete an inbound integration so its webhook refuses payloads. The tickets it opened are kept.
+// @Tags admin
+// @Param id path string true "Inbound integration ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations/{id} [delete]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) DeleteIntegration(c echo.Context) error {
+	if err := h.inboundService.DeleteIntegration(c.Request().Context(), authMiddleware.ParamUUID(c, "id")); err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateSecret handles replacing the secret of an inbound integration
+// @Summary Rotate an inbound integration secret
+// @Description Generate a new secret for an inbound integration, returned only in this response. Callers using the old secret are refused from then on.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Inbound integration ID"
+// @Success 200 {object} models.InboundIntegrationSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/inbound-integrations/{id}/secret [post]
+// @Security ApiKeyAuth
+func (h *InboundIntegrationHandler) RotateSecret(c echo.Context) error {
+	rotated, err := h.inboundService.RotateSecret(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return inboundIntegrationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, rotated)
+}
+
+// inboundIntegrationErrorResponse maps inbound service errors to HTTP responses
+func inboundIntegrationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInboundUnauthorized):
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process inbound integration request"))
+	}
+}
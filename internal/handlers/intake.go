@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// categoryCacheGroup groups the cached public category list. Categories are
+// only changed directly in the database, so cached lists expire rather than
+// being invalidated.
+const categoryCacheGroup = "categories"
+
+// IntakeHandler handles guest ticket submission and the spam quarantine review queue
+type IntakeHandler struct {
+	intakeService *services.IntakeService
+	botProtection *authMiddleware.BotProtection
+	responseCache *authMiddleware.ResponseCache
+}
+
+// NewIntakeHandler creates a new intake handler
+func NewIntakeHandler(intakeService *services.IntakeService, botProtection *authMiddleware.BotProtection, responseCache *authMiddleware.ResponseCache) *IntakeHandler {
+	return &IntakeHandler{
+		intakeService: intakeService,
+		botProtection: botProtection,
+		responseCache: responseCache,
+	}
+}
+
+// RegisterRoutes registers the intake routes
+func (h *IntakeHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Public routes (no authentication)
+	public := e.Group("/api/v1/public")
+	public.POST("/tickets", h.SubmitGuestTicket, h.botProtection.RateLimit(), h.botProtection.RequireCaptcha())
+	public.GET("/categories", h.ListCategories, h.responseCache.Cached(categoryCacheGroup))
+
+	// Quarantine review - admin only
+	quarantine := e.Group("/api/v1/admin/quarantine")
+	quarantine.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "submission"))
+	quarantine.GET("", h.ListQuarantine)
+	quarantine.POST("/:id/approve", h.ApproveSubmission)
+	quarantine.POST("/:id/reject", h.RejectSubmission)
+}
+
+// SubmitGuestTicket handles ticket submission without an account
+// @Summary Submit a ticket as a guest
+// @Description Submit a support request without an account. Submissions that score as spam are held for review; the response does not reveal which. A submission from an end user who already has MAX_OPEN_TICKETS_PER_REQUESTER open tickets is refused with a 409 asking them to update one instead.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param ticket body models.GuestTicketRequest true "Guest ticket data"
+// @Param X-Captcha-Token header string false "CAPTCHA response token (required when CAPTCHA is enabled)"
+// @Success 202 {object} models.GuestTicketResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/tickets [post]
+func (h *IntakeHandler) SubmitGuestTicket(c echo.Context) error {
+	var req models.GuestTicketRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	if _, err := h.intakeService.SubmitGuestTicket(c.Request().Context(), &req, c.RealIP()); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusAccepted, models.GuestTicketResponse{
+		Status:  "received",
+		Message: "Your request has been received",
+	})
+}
+
+// ListCategories handles listing the categories guests can choose from
+// @Summary List ticket categories
+// @Description List the active ticket categories, for the guest form's category picker
+// @Tags public
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Success 200 {object} models.CategoryListResponse
+// @Success 304
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/categories [get]
+func (h *IntakeHandler) ListCategories(c echo.Context) error {
+	categories, err := h.intakeService.ListCategories(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list categories"))
+	}
+	return c.JSON(http.StatusOK, models.CategoryListResponse{Categories: categories})
+}
+
+// ListQuarantine handles listing quarantined submissions
+// @Summary List quarantined submissions
+// @Description List guest and email submissions held as suspected spam
+// @Tags admin
+// @Produce json
+// @Param status query string false "Filter by status (PENDING, APPROVED, REJECTED)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.QuarantineListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/quarantine [get]
+// @Security ApiKeyAuth
+func (h *IntakeHandler) ListQuarantine(c echo.Context) error {
+	status := models.QuarantineStatus(c.QueryParam("status"))
+	switch status {
+	case "", models.QuarantinePending, models.QuarantineApproved, models.QuarantineRejected:
+	default:
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid status"))
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	submissions, err := h.intakeService.ListQuarantine(c.Request().Context(), status, page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusOK, submissions)
+}
+
+// ApproveSubmission handles releasing a quarantined submission
+// @Summary Approve a quarantined submission
+// @Description Mark a quarantined submission as legitimate and create its ticket
+// @Tags admin
+// @Produce json
+// @Param id path string true "Submission ID"
+// @Success 201 {object} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/quarantine/{id}/approve [post]
+// @Security ApiKeyAuth
+func (h *IntakeHandler) ApproveSubmission(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ticket, err := h.intakeService.ApproveSubmission(c.Request().Context(), id, userID)
+	if err != nil {
+		return quarantineErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ticket)
+}
+
+// RejectSubmission handles discarding a quarantined submission
+// @Summary Reject a quarantined submission
+// @Description Confirm a quarantined submission as spam; no ticket is created
+// @Tags admin
+// @Produce json
+// @Param id path string true "Submission ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/quarantine/{id}/reject [post]
+// @Security ApiKeyAuth
+func (h *IntakeHandler) RejectSubmission(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.intakeService.RejectSubmission(c.Request().Context(), id, userID); err != nil {
+		return quarantineErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Status:  "success",
+		Message: "Submission rejected",
+	})
+}
+
+// quarantineErrorResponse maps quarantine review errors to HTTP responses
+func quarantineErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponse("Submission not found"))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	}
+	return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// IntegrationHealthHandler reports the health of external integrations to administrators
+type IntegrationHealthHandler struct {
+	integrationHealthService *services.IntegrationHealthService
+}
+
+// NewIntegrationHealthHandler creates a new integration health handler
+func NewIntegrationHealthHandler(integrationHealthService *services.IntegrationHealthService) *IntegrationHealthHandler {
+	return &IntegrationHealthHandler{
+		integrationHealthService: integrationHealthService,
+	}
+}
+
+// RegisterRoutes registers the integration health routes
+func (h *IntegrationHealthHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	integrations := e.Group("/api/v1/admin/integrations")
+	integrations.Use(ami.Authenticate, ami.RequireAdmin())
+	integrations.GET("/health", h.GetHealth)
+}
+
+// GetHealth handles checking the external integrations
+// @Summary Check external integrations
+// @Description Actively check every configured integration: connect and authenticate to the email provider (SMTP, SES or SendGrid), write and remove a file in the analytics export storage, and reach each audit sink (HTTP collector, Event Hub or syslog). Each result has the check's latency and when something was last delivered through the integration; for audit sinks, since the server started. The response is 200 even when checks fail; healthy is true only when every check passed.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.IntegrationHealthReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/admin/integrations/health [get]
+// @Security ApiKeyAuth
+func (h *IntegrationHealthHandler) GetHealth(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.integrationHealthService.Check(c.Request().Context()))
+}
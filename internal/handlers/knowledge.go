@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// knowledgeCacheGroup groups the cached public knowledge base responses
+const knowledgeCacheGroup = "kb"
+
+// KnowledgeHandler handles knowledge base HTTP requests
+type KnowledgeHandler struct {
+	knowledgeService *services.KnowledgeService
+	responseCache    *authMiddleware.ResponseCache
+}
+
+// NewKnowledgeHandler creates a new knowledge base handler
+func NewKnowledgeHandler(knowledgeService *services.KnowledgeService, responseCache *authMiddleware.ResponseCache) *KnowledgeHandler {
+	return &KnowledgeHandler{
+		knowledgeService: knowledgeService,
+		responseCache:    responseCache,
+	}
+}
+
+// RegisterRoutes registers the knowledge base routes
+func (h *KnowledgeHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Public routes (no authentication) serve published articles only. The
+	// widget reads them on every load, so they are cached until an article changes.
+	public := e.Group("/api/v1/public/kb")
+	public.Use(authMiddleware.UUIDParam("id", "article"), h.responseCache.Cached(knowledgeCacheGroup))
+	public.GET("/articles", h.ListPublishedArticles)
+	public.GET("/articles/:id", h.GetPublishedArticle)
+
+	// Article management - agents only
+	articles := e.Group("/api/v1/kb/articles")
+	articles.Use(ami.Authenticate, ami.RequireAgent(), authMiddleware.UUIDParam("id", "article"))
+	articles.GET("", h.ListArticles)
+	articles.POST("", h.CreateArticle)
+	articles.GET("/:id", h.GetArticle)
+	articles.PUT("/:id", h.UpdateArticle)
+	articles.DELETE("/:id", h.DeleteArticle)
+}
+
+// ListPublishedArticles handles listing or searching published articles
+// @Summary List knowledge articles
+// @Description List published knowledge articles by title, or with q, the best matches for a question
+// @Tags public
+// @Produce json
+// @Param q query string false "Search text"
+// @Param limit query int false "Maximum number of search results (default 5)"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Success 200 {array} models.KnowledgeArticle
+// @Success 304
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/kb/articles [get]
+func (h *KnowledgeHandler) ListPublishedArticles(c echo.Context) error {
+	var (
+		articles []models.KnowledgeArticle
+		err      error
+	)
+	if query := c.QueryParam("q"); query != "" {
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		articles, err = h.knowledgeService.Search(c.Request().Context(), query, limit)
+	} else {
+		articles, err = h.knowledgeService.ListArticles(c.Request().Context(), false)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list articles"))
+	}
+	return c.JSON(http.StatusOK, articles)
+}
+
+// GetPublishedArticle handles retrieving a published article
+// @Summary Get a knowledge article
+// @Description Get a published knowledge article
+// @Tags public
+// @Produce json
+// @Param id path string true "Article ID"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Success 200 {object} models.KnowledgeArticle
+// @Success 304
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/kb/articles/{id} [get]
+func (h *KnowledgeHandler) GetPublishedArticle(c echo.Context) error {
+	return h.getArticle(c, false)
+}
+
+// ListArticles handles listing every article
+// @Summary List knowledge articles including drafts
+// @Description List every knowledge article by title, including unpublished drafts
+// @Tags knowledge
+// @Produce json
+// @Success 200 {array} models.KnowledgeArticle
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/kb/articles [get]
+// @Security ApiKeyAuth
+func (h *KnowledgeHandler) ListArticles(c echo.Context) error {
+	articles, err := h.knowledgeService.ListArticles(c.Request().Context(), true)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list articles"))
+	}
+	return c.JSON(http.StatusOK, articles)
+}
+
+// CreateArticle handles writing an article
+// @Summary Create a knowledge article
+// @Description Write a knowledge article; it is visible to customers and the chat bot once published
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param article body models.CreateKnowledgeArticleRequest true "Article data"
+// @Success 201 {object} models.KnowledgeArticle
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/kb/articles [post]
+// @Security ApiKeyAuth
+func (h *KnowledgeHandler) CreateArticle(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.CreateKnowledgeArticleRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	article, err := h.knowledgeService.CreateArticle(c.Request().Context(), &req, userID)
+	if err != nil {
+		return knowledgeErrorResponse(c, err)
+	}
+	h.responseCache.Invalidate(knowledgeCacheGroup)
+	return c.JSON(http.StatusCreated, article)
+}
+
+// GetArticle handles retrieving an article
+// @Summary Get a knowledge article including drafts
+// @Description Get a knowledge article, published or not
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Article ID"
+// @Success 200 {object} models.KnowledgeArticle
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/kb/articles/{id} [get]
+// @Security ApiKeyAuth
+func (h *KnowledgeHandler) GetArticle(c echo.Context) error {
+	return h.getArticle(c, true)
+}
+
+// UpdateArticle handles editing an article
+// @Summary Update a knowledge article
+// @Description Edit or publish a knowledge article
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Article ID"
+// @Param article body models.UpdateKnowledgeArticleRequest true "Article changes"
+// @Success 200 {object} models.KnowledgeArticle
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/kb/articles/{id} [put]
+// @Security ApiKeyAuth
+func (h *KnowledgeHandler) UpdateArticle(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.UpdateKnowledgeArticleRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	article, err := h.knowledgeService.UpdateArticle(c.Request().Context(), id, &req)
+	if err != nil {
+		return knowledgeErrorResponse(c, err)
+	}
+	h.responseCache.Invalidate(knowledgeCacheGroup)
+	return c.JSON(http.StatusOK, article)
+}
+
+// DeleteArticle handles deleting an article
+// @Summary Delete a knowledge article
+// @Description Delete a knowledge article
+// @Tags knowledge
+// @Param id path string true "Article ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/kb/articles/{id} [delete]
+// @Security ApiKeyAuth
+func (h *KnowledgeHandler) DeleteArticle(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	if err := h.knowledgeService.DeleteArticle(c.Request().Context(), id); err != nil {
+		return knowledgeErrorResponse(c, err)
+	}
+	h.responseCache.Invalidate(knowledgeCacheGroup)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getArticle retrieves an article, hiding drafts unless includeDrafts is set
+func (h *KnowledgeHandler) getArticle(c echo.Context, includeDrafts bool) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	article, err := h.knowledgeService.GetArticle(c.Request().Context(), id, includeDrafts)
+	if err != nil {
+		return knowledgeErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, article)
+}
+
+// knowledgeErrorResponse maps knowledge base service errors to HTTP responses
+func knowledgeErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process article request"))
+	}
+}
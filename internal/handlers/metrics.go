@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsHandler serves operational metrics for Prometheus to scrape
+type MetricsHandler struct {
+	db           *database.Database
+	errorFormats *authMiddleware.ProblemJSON
+	token        string
+}
+
+// NewMetricsHandler creates a new metrics handler. Error format adoption is
+// reported when errorFormats is not nil.
+func NewMetricsHandler(db *database.Database, errorFormats *authMiddleware.ProblemJSON, cfg *config.Config) *MetricsHandler {
+	return &MetricsHandler{
+		db:           db,
+		errorFormats: errorFormats,
+		token:        cfg.Metrics.Token,
+	}
+}
+
+// RegisterRoutes registers the metrics route
+func (h *MetricsHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/metrics", h.Metrics)
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format
+type metricsWriter struct {
+	b strings.Builder
+}
+
+// metric writes a metric's help and type lines followed by one sample per label set
+func (w *metricsWriter) metric(name, kind, help string, samples map[string]float64) {
+	if len(samples) == 0 {
+		return
+	}
+	fmt.Fprintf(&w.b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	labels := make([]string, 0, len(samples))
+	for label := range samples {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		value := samples[label]
+		if label != "" {
+			label = "{" + label + "}"
+		}
+		fmt.Fprintf(&w.b, "%s%s %g\n", name, label, value)
+	}
+}
+
+// Metrics handles the /metrics endpoint
+// @Summary Prometheus metrics
+// @Description Database connection pool and lock contention metrics, and how many error responses went out as problem+json versus the legacy format, in the Prometheus text format. When METRICS_TOKEN is set it must be sent as a bearer token.
+// @Tags health
+// @Produce plain
+// @Success 200 {string} string
+// @Failure 401 {object} models.ErrorResponse
+// @Router /metrics [get]
+func (h *MetricsHandler) Metrics(c echo.Context) error {
+	if h.token != "" {
+		given := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(h.token)) != 1 {
+			return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+		}
+	}
+
+	pools := h.db.PoolStats()
+	gauge := func(value func(stats database.PoolStats) float64) map[string]float64 {
+		samples := make(map[string]float64, len(pools))
+		for _, stats := range pools {
+			samples[`db="`+stats.Name+`"`] = value(stats)
+		}
+		return samples
+	}
+
+	var w metricsWriter
+	w.metric("helpchat_db_connections_max", "gauge", "Maximum open database connections allowed.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.MaxOpenConnections) }))
+	w.metric("helpchat_db_connections_open", "gauge", "Open database connections.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.OpenConnections) }))
+	w.metric("helpchat_db_connections_in_use", "gauge", "Database connections currently in use.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.InUse) }))
+	w.metric("helpchat_db_connections_idle", "gauge", "Idle database connections.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.Idle) }))
+	w.metric("helpchat_db_connection_waits_total", "counter", "Times a query waited for a free connection.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.WaitCount) }))
+	w.metric("helpchat_db_connection_wait_seconds_total", "counter", "Time spent waiting for a free connection.",
+		gauge(func(s database.PoolStats) float64 { return s.WaitDuration.Seconds() }))
+	w.metric("helpchat_db_connections_closed_max_lifetime_total", "counter", "Connections closed for reaching their maximum lifetime.",
+		gauge(func(s database.PoolStats) float64 { return float64(s.MaxLifetimeClosed) }))
+	w.metric("helpchat_db_busy_retries_total", "counter", "Transactions retried because the database was locked.",
+		map[string]float64{"": float64(h.db.BusyRetries())})
+	if configured, available := h.db.ReplicaAvailable(); configured {
+		value := 0.0
+		if available {
+			value = 1
+		}
+		w.metric("helpchat_db_replica_available", "gauge", "Whether the read replica is serving queries.", map[string]float64{"": value})
+	}
+	if h.errorFormats != nil {
+		problem, legacy := h.errorFormats.Counts()
+		w.metric("helpchat_error_responses_total", "counter", "Error responses sent, by format negotiated with the client.",
+			map[string]float64{`format="problem+json"`: float64(problem), `format="legacy"`: float64(legacy)})
+	}
+
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(w.b.String()))
+}
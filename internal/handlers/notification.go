@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationHandler handles the current user's in-app notifications and
+// notification preferences
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// RegisterRoutes registers the notification routes
+func (h *NotificationHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	notifications := e.Group("/api/v1/notifications")
+	notifications.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "notification"))
+	notifications.GET("", h.ListNotifications)
+	notifications.POST("/read-all", h.MarkAllRead)
+	notifications.PUT("/:id/read", h.MarkRead)
+	notifications.DELETE("/:id/read", h.MarkUnread)
+	notifications.GET("/preferences", h.GetPreferences)
+	notifications.PUT("/preferences", h.UpdatePreferences)
+}
+
+// ListNotifications handles listing the current user's notifications
+// @Summary List my notifications
+// @Description List the current user's in-app notifications about their tickets being assigned, commented on, escalated or changing status, newest first, with how many are unread. Pass unread=true to list only the unread ones.
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Only list unread notifications"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.NotificationListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications [get]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) ListNotifications(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	notifications, err := h.notificationService.List(c.Request().Context(), userID, c.QueryParam("unread") == "true", page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list notifications"))
+	}
+	return c.JSON(http.StatusOK, notifications)
+}
+
+// MarkRead handles marking one of the current user's notifications read
+// @Summary Mark a notification read
+// @Description Mark one of the current user's notifications read
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} models.Notification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications/{id}/read [put]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) MarkRead(c echo.Context) error {
+	return h.setRead(c, true)
+}
+
+// MarkUnread handles marking one of the current user's notifications unread again
+// @Summary Mark a notification unread
+// @Description Mark one of the current user's notifications unread again
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} models.Notification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications/{id}/read [delete]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) MarkUnread(c echo.Context) error {
+	return h.setRead(c, false)
+}
+
+// setRead marks the notification of the request read or unread
+func (h *NotificationHandler) setRead(c echo.Context, read bool) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	notification, err := h.notificationService.MarkRead(c.Request().Context(), userID, authMiddleware.ParamUUID(c, "id"), read)
+	if err != nil {
+		return notificationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllRead handles marking every one of the current user's notifications read
+// @Summary Mark all notifications read
+// @Description Mark every unread notification of the current user read
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} models.NotificationReadAllResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications/read-all [post]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) MarkAllRead(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	result, err := h.notificationService.MarkAllRead(c.Request().Context(), userID)
+	if err != nil {
+		return notificationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetPreferences handles listing the current user's notification preferences
+// @Summary Get my notification preferences
+// @Description List whether the current user is notified by email and in the app about each type of event: ticket.assigned, ticket.commented (including mentions), ticket.escalated (including hand-backs) and ticket.status_changed. Both channels are on until the user turns them off.
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications/preferences [get]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) GetPreferences(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	preferences, err := h.notificationService.Preferences(c.Request().Context(), userID)
+	if err != nil {
+		return notificationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences handles changing the current user's notification preferences
+// @Summary Set my notification preferences
+// @Description Choose whether the current user is notified by email and in the app about the listed types of event; types left out keep their channels. Ticket confirmations, account emails and emails copied to ticket subscribers are always sent.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param preferences body models.NotificationPreferencesRequest true "Channels by notification type"
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/notifications/preferences [put]
+// @Security ApiKeyAuth
+func (h *NotificationHandler) UpdatePreferences(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Request().Context(), userID, &req)
+	if err != nil {
+		return notificationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, preferences)
+}
+
+// notificationErrorResponse maps notification service errors to HTTP responses
+func notificationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update notifications"))
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// OffboardingHandler handles offboarding staff members who left
+type OffboardingHandler struct {
+	offboardingService *services.OffboardingService
+}
+
+// NewOffboardingHandler creates a new offboarding handler
+func NewOffboardingHandler(offboardingService *services.OffboardingService) *OffboardingHandler {
+	return &OffboardingHandler{offboardingService: offboardingService}
+}
+
+// RegisterRoutes registers the offboarding routes
+func (h *OffboardingHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	offboarding := e.Group("/api/v1/admin/offboarding")
+	offboarding.Use(ami.Authenticate, ami.RequireAdmin())
+	offboarding.POST("", h.Offboard)
+	offboarding.GET("", h.ListJobs)
+	offboarding.GET("/:id", h.GetJob, authMiddleware.UUIDParam("id", "offboarding job"))
+}
+
+// Offboard handles queueing the offboarding of a staff member
+// @Summary Offboard a staff member
+// @Description Queue a background job offboarding a staff member who left. The job unassigns their open tickets, moving them to the queue of team_id when given, then deactivates their account and replaces their name with "Former employee" and their email address with a placeholder, so their comments and history are attributed to "Former employee". It also removes their team memberships, out-of-office windows, including those delegating to them, pins and sign-in history. Follow the job with GET /api/v1/admin/offboarding/{id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param offboarding body models.OffboardRequest true "Staff member to offboard"
+// @Success 202 {object} models.OffboardingJob
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/offboarding [post]
+// @Security ApiKeyAuth
+func (h *OffboardingHandler) Offboard(c echo.Context) error {
+	var req models.OffboardRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	job, err := h.offboardingService.Offboard(c.Request().Context(), &req, user)
+	if err != nil {
+		return offboardingErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// ListJobs handles listing offboarding jobs
+// @Summary List offboarding jobs
+// @Description List offboarding jobs, most recent first, with their status and what they did
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} models.OffboardingJobListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/offboarding [get]
+// @Security ApiKeyAuth
+func (h *OffboardingHandler) ListJobs(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	jobs, err := h.offboardingService.ListJobs(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return offboardingErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob handles getting an offboarding job
+// @Summary Get an offboarding job
+// @Description Get an offboarding job: PENDING until the background job picks it up, then RUNNING, and COMPLETED with the tickets released and comments attributed, or FAILED with the error. A failed job can be requested again.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Offboarding job ID"
+// @Success 200 {object} models.OffboardingJob
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/offboarding/{id} [get]
+// @Security ApiKeyAuth
+func (h *OffboardingHandler) GetJob(c echo.Context) error {
+	job, err := h.offboardingService.GetJob(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return offboardingErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// offboardingErrorResponse maps offboarding service errors to HTTP responses
+func offboardingErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process offboarding request"))
+	}
+}
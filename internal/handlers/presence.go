@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// presenceSocketMaxFrame caps the size of a presence frame
+const presenceSocketMaxFrame = 512
+
+// PresenceHandler handles agent presence HTTP requests
+type PresenceHandler struct {
+	presenceService *services.PresenceService
+	allowedOrigins  []string
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(presenceService *services.PresenceService, config *config.Config) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+		allowedOrigins:  config.CORS.AllowedOrigins,
+	}
+}
+
+// RegisterRoutes registers the presence routes
+func (h *PresenceHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	e.GET("/api/v1/presence/ws", h.Socket, ami.Authenticate, ami.RequireAgent())
+	e.GET("/api/v1/agents", h.ListAgents, ami.Authenticate, ami.RequireAgent())
+}
+
+// Socket handles an agent's presence connection
+// @Summary Open a presence WebSocket
+// @Description Upgrade to a WebSocket that keeps the signed-in agent online while it stays open. Send {"type":"heartbeat"} more often than PRESENCE_TTL; answering the server's pings also counts. The agent goes offline when their last presence socket closes or the heartbeats stop. Only online agents are routed chats.
+// @Tags presence
+// @Success 101
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/presence/ws [get]
+// @Security ApiKeyAuth
+func (h *PresenceHandler) Socket(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	if !staffOriginAllowed(c.Request(), h.allowedOrigins) {
+		return c.JSON(http.StatusForbidden, models.NewErrorResponse("Origin not allowed"))
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+		return staffOriginAllowed(r, h.allowedOrigins)
+	}}
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return nil
+	}
+	defer conn.Close()
+
+	h.presenceService.Connect(user.ID)
+	defer h.presenceService.Disconnect(user.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client must answer pings or send heartbeats often enough to outlast both
+	// the read deadline and the presence TTL
+	pongWait := min(socketPongWait, h.presenceService.TTL())
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(presenceSocketMaxFrame)
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			h.presenceService.Heartbeat(user.ID)
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var frame models.PresenceFrame
+			if json.Unmarshal(data, &frame) != nil || frame.Type != models.PresenceFrameHeartbeat {
+				continue
+			}
+			h.presenceService.Heartbeat(user.ID)
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+		}
+	}()
+
+	ping := time.NewTicker(pongWait * 9 / 10)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// ListAgents handles listing agents for the agent picker
+// @Summary List agents with presence
+// @Description List active agents for assigning or transferring work, online agents first. last_seen_at is the latest heartbeat of an online agent.
+// @Tags presence
+// @Produce json
+// @Success 200 {array} models.AgentPresence
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/agents [get]
+// @Security ApiKeyAuth
+func (h *PresenceHandler) ListAgents(c echo.Context) error {
+	agents, err := h.presenceService.ListAgents(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list agents"))
+	}
+	return c.JSON(http.StatusOK, agents)
+}
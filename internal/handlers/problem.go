@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ProblemHandler handles problem management HTTP requests
+type ProblemHandler struct {
+	problemService *services.ProblemService
+}
+
+// NewProblemHandler creates a new problem handler
+func NewProblemHandler(problemService *services.ProblemService) *ProblemHandler {
+	return &ProblemHandler{
+		problemService: problemService,
+	}
+}
+
+// RegisterRoutes registers the problem routes
+func (h *ProblemHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	problems := e.Group("/api/v1/problems")
+	problems.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "problem"), authMiddleware.UUIDParam("ticket_id", "ticket"))
+	problems.GET("", h.ListProblems, ami.RequirePermission(models.PermTicketRead))
+	problems.POST("", h.CreateProblem, ami.RequireAgent())
+	problems.GET("/top", h.GetTopProblems, ami.RequirePermission(models.PermReportRead))
+	problems.GET("/:id", h.GetProblem, ami.RequirePermission(models.PermTicketRead))
+	problems.POST("/:id/incidents", h.LinkIncident, ami.RequireAgent())
+	problems.DELETE("/:id/incidents/:ticket_id", h.UnlinkIncident, ami.RequireAgent())
+	problems.POST("/:id/close", h.CloseProblem, ami.RequireAgent())
+}
+
+// ListProblems handles listing problems
+// @Summary List problems
+// @Description List problems newest first, optionally filtered by status
+// @Tags problems
+// @Produce json
+// @Param status query string false "Problem status (OPEN or CLOSED)"
+// @Success 200 {array} models.Problem
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems [get]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) ListProblems(c echo.Context) error {
+	status := models.ProblemStatus(c.QueryParam("status"))
+
+	problems, err := h.problemService.ListProblems(c.Request().Context(), status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list problems"))
+	}
+	return c.JSON(http.StatusOK, problems)
+}
+
+// CreateProblem handles opening a problem
+// @Summary Create a problem
+// @Description Open a problem record that groups incident tickets with a shared cause
+// @Tags problems
+// @Accept json
+// @Produce json
+// @Param problem body models.CreateProblemRequest true "Problem data"
+// @Success 201 {object} models.Problem
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems [post]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) CreateProblem(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.CreateProblemRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	problem, err := h.problemService.CreateProblem(c.Request().Context(), &req, userID)
+	if err != nil {
+		return problemErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, problem)
+}
+
+// GetTopProblems handles reporting the problems with the most incidents
+// @Summary Top problems
+// @Description List the problems with the most linked incident tickets
+// @Tags problems
+// @Produce json
+// @Param limit query int false "Maximum number of problems (default 10, max 100)"
+// @Success 200 {array} models.ProblemIncidentCount
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems/top [get]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) GetTopProblems(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	report, err := h.problemService.TopProblems(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to build problem report"))
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetProblem handles retrieving a problem
+// @Summary Get a problem
+// @Description Get a problem and its linked incident tickets
+// @Tags problems
+// @Produce json
+// @Param id path string true "Problem ID"
+// @Success 200 {object} models.Problem
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems/{id} [get]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) GetProblem(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	problem, err := h.problemService.GetProblem(c.Request().Context(), id)
+	if err != nil {
+		return problemErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, problem)
+}
+
+// LinkIncident handles linking an incident ticket to a problem
+// @Summary Link an incident
+// @Description Group an incident ticket under a problem; a ticket linked to another problem is moved
+// @Tags problems
+// @Accept json
+// @Produce json
+// @Param id path string true "Problem ID"
+// @Param incident body models.LinkIncidentRequest true "Incident ticket"
+// @Success 200 {object} models.Problem
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems/{id}/incidents [post]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) LinkIncident(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.LinkIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	problem, err := h.problemService.LinkIncident(c.Request().Context(), id, req.TicketID)
+	if err != nil {
+		return problemErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, problem)
+}
+
+// UnlinkIncident handles removing an incident ticket from a problem
+// @Summary Unlink an incident
+// @Description Remove an incident ticket from a problem
+// @Tags problems
+// @Param id path string true "Problem ID"
+// @Param ticket_id path string true "Ticket ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems/{id}/incidents/{ticket_id} [delete]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) UnlinkIncident(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+	ticketID := authMiddleware.ParamUUID(c, "ticket_id")
+
+	if err := h.problemService.UnlinkIncident(c.Request().Context(), id, ticketID); err != nil {
+		return problemErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CloseProblem handles closing a problem
+// @Summary Close a problem
+// @Description Close a problem with a resolution, optionally resolving its open incidents and posting the resolution on each
+// @Tags problems
+// @Accept json
+// @Produce json
+// @Param id path string true "Problem ID"
+// @Param close body models.CloseProblemRequest true "Resolution"
+// @Success 200 {object} models.CloseProblemResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/problems/{id}/close [post]
+// @Security ApiKeyAuth
+func (h *ProblemHandler) CloseProblem(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.CloseProblemRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	result, err := h.problemService.CloseProblem(c.Request().Context(), id, &req, user)
+	if err != nil {
+		return problemErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// problemErrorResponse maps problem service errors to HTTP responses
+func problemErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process problem request"))
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// ProfilingHandler serves the Go runtime profiles of net/http/pprof, so CPU,
+// heap and goroutine profiles can be taken from a running server
+type ProfilingHandler struct {
+	enabled bool
+}
+
+// NewProfilingHandler creates a new profiling handler; it registers no routes
+// unless PROFILING_ENABLED is set
+func NewProfilingHandler(cfg *config.Config) *ProfilingHandler {
+	return &ProfilingHandler{
+		enabled: cfg.Metrics.Profiling,
+	}
+}
+
+// RegisterRoutes registers the profiling routes when profiling is enabled.
+// They are left out of the API documentation; see the pprof package for the
+// profiles and their parameters.
+func (h *ProfilingHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	if !h.enabled {
+		return
+	}
+	profiles := e.Group("/debug/pprof")
+	profiles.Use(ami.Authenticate, ami.RequirePermission(models.PermSystemAdmin))
+	// pprof.Index serves the index and every named profile (heap, goroutine, ...)
+	profiles.GET("/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+	profiles.GET("/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+	profiles.GET("/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+	profiles.GET("/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	profiles.POST("/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	profiles.GET("/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ReloadHandler handles reloading permissions and settings at runtime
+type ReloadHandler struct {
+	reloadService *services.ReloadService
+}
+
+// NewReloadHandler creates a new reload handler
+func NewReloadHandler(reloadService *services.ReloadService) *ReloadHandler {
+	return &ReloadHandler{
+		reloadService: reloadService,
+	}
+}
+
+// RegisterRoutes registers the reload route
+func (h *ReloadHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	admin := e.Group("/api/v1/admin")
+	admin.POST("/reload", h.Reload, ami.Authenticate, ami.RequirePermission(models.PermSystemAdmin))
+}
+
+// Reload handles reloading the configuration
+// @Summary Reload permissions and settings
+// @Description Re-read CONFIG_FILE and RBAC_POLICY_FILE and apply them without a restart: role permissions, the per-user daily quota, spam filtering, widget office hours and email branding. Other settings are only read at startup. A component whose new settings are invalid keeps its current ones and is listed under failed, with a 422 status. Every reload is audit logged.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.ReloadResult
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ReloadResult
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/reload [post]
+// @Security ApiKeyAuth
+func (h *ReloadHandler) Reload(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	result, err := h.reloadService.Reload(c.Request().Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.JSON(http.StatusUnprocessableEntity, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to reload configuration"))
+	}
+	if len(result.Failed) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ReportHandler handles reporting and wallboard HTTP requests
+type ReportHandler struct {
+	reportService *services.ReportService
+	ticketService *services.TicketService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportService *services.ReportService, ticketService *services.TicketService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+		ticketService: ticketService,
+	}
+}
+
+// RegisterRoutes registers the report routes
+func (h *ReportHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	reports := e.Group("/api/v1/reports")
+	reports.Use(ami.Authenticate, ami.RequirePermission(models.PermReportRead))
+	reports.GET("/queue", h.GetQueue)
+	reports.GET("/queue/stream", h.StreamQueue)
+	reports.GET("/dashboard", h.GetDashboard)
+	reports.GET("/trends", h.GetTrends)
+	reports.GET("/categories", h.GetCategories)
+	reports.GET("/root-causes", h.GetRootCauses)
+	reports.GET("/aging", h.GetAging)
+	reports.GET("/burndown", h.GetBurndown)
+}
+
+// GetQueue handles retrieving the live ticket queue numbers
+// @Summary Get live queue numbers
+// @Description Queue depth, unassigned tickets, tickets at risk of or past their due date, and tickets resolved today. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Success 200 {object} models.QueueSnapshot
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/queue [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetQueue(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load queue"))
+	}
+
+	snapshot, err := h.reportService.QueueSnapshot(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load queue"))
+	}
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// GetDashboard handles retrieving everything a wallboard shows
+// @Summary Get the wallboard dashboard
+// @Description Open tickets by priority, unassigned tickets, tickets created and resolved today, the average chat queue wait today, online agents, and active and queued chats, in one call. Ticket numbers are limited to the teams of managers without global visibility; chat and agent numbers are not. Refreshed at most every REPORT_STREAM_INTERVAL.
+// @Tags reports
+// @Produce json
+// @Success 200 {object} models.Dashboard
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/dashboard [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetDashboard(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load dashboard"))
+	}
+
+	dashboard, err := h.reportService.Dashboard(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load dashboard"))
+	}
+	return c.JSON(http.StatusOK, dashboard)
+}
+
+// GetTrends handles retrieving a time-bucketed ticket series for charting
+// @Summary Get ticket trends
+// @Description Tickets created, tickets resolved, or the unresolved backlog at the end of each day or week (starting Monday) between from and to, inclusive. Dates are in the office time zone; the range defaults to the last 30 days, or 12 weeks for weekly buckets. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Param metric query string true "created, resolved or backlog"
+// @Param interval query string false "day (default) or week"
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD; defaults to today"
+// @Success 200 {object} models.TrendSeries
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/trends [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetTrends(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load trends"))
+	}
+
+	series, err := h.reportService.Trends(ctx, models.TrendQuery{
+		Metric:   models.TrendMetric(c.QueryParam("metric")),
+		Interval: models.TrendInterval(c.QueryParam("interval")),
+		From:     c.QueryParam("from"),
+		To:       c.QueryParam("to"),
+	})
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, series)
+}
+
+// GetBurndown handles charting the backlog with a forecast of when it clears
+// @Summary Get the backlog burndown
+// @Description The unresolved backlog at the end of each day between from and to, inclusive, with a forecast of when today's backlog would clear if tickets keep being created and resolved at their average daily rates over the last window days. days_to_clear and clears_on are null while the backlog is not shrinking. Dates are in the office time zone and default to the last 30 days. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD; defaults to today"
+// @Param window query int false "Days the forecast rates are averaged over, 1 to 90; defaults to 14"
+// @Success 200 {object} models.BurndownReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/burndown [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetBurndown(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load burndown"))
+	}
+
+	window, _ := strconv.Atoi(c.QueryParam("window"))
+	report, err := h.reportService.Burndown(ctx, c.QueryParam("from"), c.QueryParam("to"), window)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetCategories handles breaking tickets down by category
+// @Summary Get the category breakdown
+// @Description Tickets created between from and to, inclusive, with how many were resolved and their average resolution time, for each category and totalled up its hierarchy. Categories are listed parents first; tickets without a category are listed last. Dates are in the office time zone and default to the last 30 days. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD; defaults to today"
+// @Success 200 {object} models.CategoryReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/categories [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetCategories(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load category report"))
+	}
+
+	report, err := h.reportService.CategoryReport(ctx, c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetRootCauses handles listing the most frequent root causes of resolved tickets
+// @Summary Get the top root causes
+// @Description The most frequent root cause codes of the tickets resolved between from and to, inclusive, for each month overall and for each category, most frequent first. Dates are in the office time zone and default to the current month and the five before it. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Param from query string false "First date, YYYY-MM-DD"
+// @Param to query string false "Last date, YYYY-MM-DD; defaults to today"
+// @Param limit query int false "Causes listed per month and category, 1 to 50; defaults to 5"
+// @Success 200 {object} models.RootCauseReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/root-causes [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetRootCauses(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load root cause report"))
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	report, err := h.reportService.RootCauseReport(ctx, c.QueryParam("from"), c.QueryParam("to"), limit)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetAging handles bucketing the open tickets by age
+// @Summary Get the open ticket aging
+// @Description Open and in-progress tickets counted by how long ago they were opened: under a day, one to three days, three to seven days and over seven days. Counts are given overall, by priority, by team, and by team and priority; tickets without a team are listed last. Managers without global visibility only see their teams' tickets.
+// @Tags reports
+// @Produce json
+// @Success 200 {object} models.AgingReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/aging [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) GetAging(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load aging report"))
+	}
+
+	report, err := h.reportService.AgingReport(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load aging report"))
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// StreamQueue handles streaming the live ticket queue numbers to a wallboard
+// @Summary Stream live queue numbers
+// @Description Server-sent events carrying the same numbers as GET /api/v1/reports/queue. A "queue" event is sent on connect and whenever the numbers change, checked every REPORT_STREAM_INTERVAL; a comment line keeps the connection alive in between.
+// @Tags reports
+// @Produce text/event-stream
+// @Success 200 {object} models.QueueSnapshot
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/reports/queue/stream [get]
+// @Security ApiKeyAuth
+func (h *ReportHandler) StreamQueue(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load queue"))
+	}
+	snapshot, err := h.reportService.QueueSnapshot(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load queue"))
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Stop proxies such as nginx from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(h.reportService.StreamInterval())
+	defer ticker.Stop()
+	var last []byte
+	for {
+		if snapshot != nil {
+			// The timestamp changes on every refresh, so only the counts are compared
+			counts := *snapshot
+			counts.At = time.Time{}
+			current, _ := json.Marshal(counts)
+			if string(current) != string(last) {
+				data, _ := json.Marshal(snapshot)
+				if _, err := fmt.Fprintf(res, "event: queue\ndata: %s\n\n", data); err != nil {
+					return nil
+				}
+				last = current
+			} else if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if snapshot, err = h.reportService.QueueSnapshot(ctx); err != nil {
+			// Keep the stream open; the next tick tries again
+			snapshot = nil
+		}
+	}
+}
+
+// reportErrorResponse maps report service errors to HTTP responses
+func reportErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to build report"))
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// RetentionHandler handles the administration of data retention rules
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(retentionService *services.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retentionService: retentionService}
+}
+
+// RegisterRoutes registers the retention routes
+func (h *RetentionHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	retention := e.Group("/api/v1/admin/retention")
+	retention.Use(ami.Authenticate, ami.RequireAdmin())
+	retention.GET("/preview", h.Preview)
+}
+
+// Preview handles a dry run of the retention rules
+// @Summary Preview the retention rules
+// @Description Report what each rule in RETENTION_RULES would do if it ran now, without changing anything: how many requesters would be anonymized or attachments purged, across how many tickets, with a sample of their ids. Rules apply to tickets closed more than after_days days ago; the rules run nightly at RETENTION_TIME.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.RetentionReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/retention/preview [get]
+// @Security ApiKeyAuth
+func (h *RetentionHandler) Preview(c echo.Context) error {
+	report, err := h.retentionService.Preview(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to preview the retention rules"))
+	}
+	return c.JSON(http.StatusOK, report)
+}
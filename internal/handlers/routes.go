@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+// Handlers holds every API handler. The server and the route authorization
+// tests register routes through it, so both see the same routes.
+type Handlers struct {
+	Ping          *PingHandler
+	Metrics       *MetricsHandler
+	Auth          *AuthHandler
+	Ticket        *TicketHandler
+	TicketTrash   *TicketTrashHandler
+	Share         *ShareHandler
+	Subscriber    *TicketSubscriberHandler
+	Attachment    *AttachmentHandler
+	TicketLock    *TicketLockHandler
+	InboundEmail  *InboundEmailHandler
+	Inbound       *InboundIntegrationHandler
+	Webhook       *WebhookHandler
+	Intake        *IntakeHandler
+	Audit         *AuditHandler
+	Encryption    *EncryptionHandler
+	Retention     *RetentionHandler
+	Offboarding   *OffboardingHandler
+	Sandbox       *SandboxHandler
+	Demo          *DemoHandler
+	User          *UserHandler
+	Notification  *NotificationHandler
+	Team          *TeamHandler
+	Category      *CategoryHandler
+	Asset         *AssetHandler
+	Problem       *ProblemHandler
+	StatusBanner  *StatusBannerHandler
+	Widget        *WidgetHandler
+	Knowledge     *KnowledgeHandler
+	Chat          *ChatHandler
+	Presence      *PresenceHandler
+	TicketEvents  *TicketEventHandler
+	Report        *ReportHandler
+	Export        *ExportHandler
+	Search        *SearchHandler
+	Calendar      *CalendarHandler
+	Reload        *ReloadHandler
+	EmailTemplate *EmailTemplateHandler
+	EmailDelivery *EmailDeliveryHandler
+	SlowQuery     *SlowQueryHandler
+	Profiling     *ProfilingHandler
+	FeatureUsage  *FeatureUsageHandler
+	CustomDomain  *CustomDomainHandler
+	Integrations  *IntegrationHealthHandler
+	FailedTasks   *FailedTaskHandler
+}
+
+// RouteAuthorizations maps each registered route, as "METHOD path", to what it
+// requires of callers; routes that do not authenticate callers map to nil
+type RouteAuthorizations map[string]*authMiddleware.RouteAuthorization
+
+// RegisterRoutes registers the routes of every handler and returns what each
+// route requires, read from its middleware
+func (h *Handlers) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) RouteAuthorizations {
+	authorizations := make(RouteAuthorizations)
+	previous := e.OnAddRouteHandler
+	e.OnAddRouteHandler = func(host string, route echo.Route, handler echo.HandlerFunc, middleware []echo.MiddlewareFunc) {
+		authorizations[route.Method+" "+route.Path] = ami.Describe(middleware)
+		if previous != nil {
+			previous(host, route, handler, middleware)
+		}
+	}
+	defer func() { e.OnAddRouteHandler = previous }()
+
+	h.Ping.RegisterRoutes(e)
+	h.Metrics.RegisterRoutes(e)
+	h.Widget.RegisterRoutes(e, ami)
+	h.Auth.RegisterRoutes(e, ami)
+	h.Ticket.RegisterRoutes(e, ami)
+	h.TicketTrash.RegisterRoutes(e, ami)
+	h.Share.RegisterRoutes(e, ami)
+	h.Subscriber.RegisterRoutes(e, ami)
+	h.Attachment.RegisterRoutes(e, ami)
+	h.TicketLock.RegisterRoutes(e, ami)
+	h.InboundEmail.RegisterRoutes(e)
+	h.Inbound.RegisterRoutes(e, ami)
+	h.Webhook.RegisterRoutes(e, ami)
+	h.Intake.RegisterRoutes(e, ami)
+	h.Audit.RegisterRoutes(e, ami)
+	h.Encryption.RegisterRoutes(e, ami)
+	h.Retention.RegisterRoutes(e, ami)
+	h.Offboarding.RegisterRoutes(e, ami)
+	h.Sandbox.RegisterRoutes(e, ami)
+	h.Demo.RegisterRoutes(e, ami)
+	h.User.RegisterRoutes(e, ami)
+	h.Notification.RegisterRoutes(e, ami)
+	h.Team.RegisterRoutes(e, ami)
+	h.Category.RegisterRoutes(e, ami)
+	h.Asset.RegisterRoutes(e, ami)
+	h.Problem.RegisterRoutes(e, ami)
+	h.StatusBanner.RegisterRoutes(e, ami)
+	h.Knowledge.RegisterRoutes(e, ami)
+	h.Chat.RegisterRoutes(e, ami)
+	h.Presence.RegisterRoutes(e, ami)
+	h.TicketEvents.RegisterRoutes(e, ami)
+	h.Report.RegisterRoutes(e, ami)
+	h.Export.RegisterRoutes(e, ami)
+	h.Search.RegisterRoutes(e, ami)
+	h.Calendar.RegisterRoutes(e, ami)
+	h.Reload.RegisterRoutes(e, ami)
+	h.EmailTemplate.RegisterRoutes(e, ami)
+	h.EmailDelivery.RegisterRoutes(e, ami)
+	h.SlowQuery.RegisterRoutes(e, ami)
+	h.Profiling.RegisterRoutes(e, ami)
+	h.FeatureUsage.RegisterRoutes(e, ami)
+	h.CustomDomain.RegisterRoutes(e, ami)
+	h.Integrations.RegisterRoutes(e, ami)
+	h.FailedTasks.RegisterRoutes(e, ami)
+	return authorizations
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// SandboxHandler handles agents' training sandboxes
+type SandboxHandler struct {
+	sandboxService *services.SandboxService
+}
+
+// NewSandboxHandler creates a new sandbox handler
+func NewSandboxHandler(sandboxService *services.SandboxService) *SandboxHandler {
+	return &SandboxHandler{sandboxService: sandboxService}
+}
+
+// RegisterRoutes registers the sandbox routes
+func (h *SandboxHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	sandbox := e.Group("/api/v1/sandbox")
+	sandbox.Use(ami.Authenticate, ami.RequireAgent())
+	sandbox.POST("", h.Start)
+	sandbox.GET("", h.Get)
+	sandbox.DELETE("", h.End)
+}
+
+// Start handles entering a training sandbox
+// @Summary Start a training sandbox
+// @Description Put the agent in a training sandbox with demo requesters and tickets to practice assigning, commenting on and escalating tickets. Until the sandbox ends, ticket lists, statistics, reports, search and live updates only show its demo tickets, and tickets the agent creates are demo tickets; other tickets cannot be opened. Demo tickets stay hidden from everyone else and send no notifications. The sandbox and its demo data are deleted at expires_at, after SANDBOX_TTL.
+// @Tags sandbox
+// @Produce json
+// @Success 201 {object} models.Sandbox
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/sandbox [post]
+// @Security ApiKeyAuth
+func (h *SandboxHandler) Start(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	sandbox, err := h.sandboxService.Start(c.Request().Context(), user)
+	if err != nil {
+		return sandboxErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, sandbox)
+}
+
+// Get handles getting the agent's training sandbox
+// @Summary Get the training sandbox
+// @Description Get the training sandbox the agent is in, with when it expires
+// @Tags sandbox
+// @Produce json
+// @Success 200 {object} models.Sandbox
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/sandbox [get]
+// @Security ApiKeyAuth
+func (h *SandboxHandler) Get(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	sandbox, err := h.sandboxService.Get(c.Request().Context(), user.ID)
+	if err != nil {
+		return sandboxErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, sandbox)
+}
+
+// End handles leaving a training sandbox
+// @Summary End the training sandbox
+// @Description Take the agent out of their training sandbox, deleting its demo requesters and tickets with everything added to them
+// @Tags sandbox
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/sandbox [delete]
+// @Security ApiKeyAuth
+func (h *SandboxHandler) End(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.sandboxService.End(c.Request().Context(), user.ID); err != nil {
+		return sandboxErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// sandboxErrorResponse maps sandbox service errors to HTTP responses
+func sandboxErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process sandbox request"))
+	}
+}
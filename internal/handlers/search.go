@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// SearchHandler handles global search requests
+type SearchHandler struct {
+	searchService *services.SearchService
+	ticketService *services.TicketService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searchService *services.SearchService, ticketService *services.TicketService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		ticketService: ticketService,
+	}
+}
+
+// RegisterRoutes registers the search routes
+func (h *SearchHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	search := e.Group("/api/v1/search")
+	search.Use(ami.Authenticate, ami.RequirePermission(models.PermTicketRead))
+	search.GET("/suggest", h.Suggest)
+
+	e.GET("/api/v1/lookup", h.Lookup, ami.Authenticate, ami.RequirePermission(models.PermTicketRead))
+}
+
+// Suggest handles typeahead lookups for the global search box
+// @Summary Suggest search matches
+// @Description Return ticket titles, requesters, categories and knowledge articles matching a prefix, for the global search box. Ticket titles and article titles match on any word; names, emails and categories match from the start. Prefixes shorter than 2 characters return no matches. Results are cached for 30 seconds.
+// @Tags search
+// @Produce json
+// @Param q query string true "Prefix to match"
+// @Param limit query int false "Maximum matches of each kind" default(5) maximum(20)
+// @Success 200 {object} models.SearchSuggestions
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/search/suggest [get]
+// @Security ApiKeyAuth
+func (h *SearchHandler) Suggest(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	limit := services.DefaultSuggestLimit
+	if value := c.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("limit must be a number"))
+		}
+	}
+
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to search"))
+	}
+
+	suggestions, err := h.searchService.Suggest(ctx, c.QueryParam("q"), limit, user.IsAgent())
+	if err != nil {
+		return searchErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, suggestions)
+}
+
+// Lookup handles the quick lookup in the agent console
+// @Summary Look up a ticket or user
+// @Description Resolve a ticket id, a short ticket reference such as "#1A2B3C4D" from an email subject, or an email address. A reference lists every ticket whose id starts with it, since short references are not unique; an email address returns its user and their 10 latest tickets. Tickets are limited to those the caller can list. Staff accounts are only found by users who manage users.
+// @Tags search
+// @Produce json
+// @Param q query string true "Ticket reference or email address"
+// @Success 200 {object} models.LookupResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/lookup [get]
+// @Security ApiKeyAuth
+func (h *SearchHandler) Lookup(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to search"))
+	}
+
+	result, err := h.searchService.Lookup(ctx, c.QueryParam("q"), user.HasPermission(models.PermUserManage))
+	if err != nil {
+		return searchErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// searchErrorResponse maps service errors to HTTP responses
+func searchErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to search"))
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// ShareHandler handles ticket share link HTTP requests
+type ShareHandler struct {
+	shareService *services.ShareService
+	tokenLockout *authMiddleware.TokenLockout
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService, tokenLockout *authMiddleware.TokenLockout) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		tokenLockout: tokenLockout,
+	}
+}
+
+// RegisterRoutes registers the share link routes
+func (h *ShareHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	tickets := e.Group("/api/v1/tickets")
+	tickets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "ticket"))
+	tickets.POST("/:id/share", h.ShareTicket)
+
+	// Public routes (no authentication, access is granted by the signed token)
+	public := e.Group("/api/v1/public")
+	public.GET("/shared-tickets/:token", h.GetSharedTicket, h.tokenLockout.Guard(models.AuditEntityShareLink, "token"))
+}
+
+// ShareTicket handles creating a public share link for a ticket
+// @Summary Share a ticket
+// @Description Generate a signed, expiring read-only URL showing the ticket status and public comments
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param share body models.ShareTicketRequest false "Share options"
+// @Success 201 {object} models.TicketShareResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/share [post]
+// @Security ApiKeyAuth
+func (h *ShareHandler) ShareTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.ShareTicketRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	share, err := h.shareService.CreateShareLink(c.Request().Context(), ticketID, user, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponse("Ticket not found"))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusCreated, share)
+}
+
+// GetSharedTicket handles viewing a ticket through a share link
+// @Summary View a shared ticket
+// @Description Retrieve the read-only status and public comments of a ticket using a share token
+// @Tags public
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} models.SharedTicketView
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 410 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Too many bad tokens from this client"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/shared-tickets/{token} [get]
+func (h *ShareHandler) GetSharedTicket(c echo.Context) error {
+	view, err := h.shareService.GetSharedTicket(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrShareLinkExpired):
+			return c.JSON(http.StatusGone, models.NewErrorResponse("Share link has expired"))
+		case errors.Is(err, services.ErrShareLinkInvalid), errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponse("Shared ticket not found"))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	// Shared views must not be cached by intermediaries beyond the link's lifetime
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, view)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/labstack/echo/v4"
+)
+
+// slowQueryDefaultLimit is how many statements the summary lists unless asked otherwise
+const slowQueryDefaultLimit = 20
+
+// SlowQueryHandler reports the slowest database statements to administrators
+type SlowQueryHandler struct {
+	db *database.Database
+}
+
+// NewSlowQueryHandler creates a new slow query handler
+func NewSlowQueryHandler(db *database.Database) *SlowQueryHandler {
+	return &SlowQueryHandler{
+		db: db,
+	}
+}
+
+// RegisterRoutes registers the slow query routes
+func (h *SlowQueryHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	slowQueries := e.Group("/api/v1/admin/slow-queries")
+	slowQueries.Use(ami.Authenticate, ami.RequireAdmin())
+	slowQueries.GET("", h.ListSlowQueries)
+}
+
+// ListSlowQueries handles summarizing the slow statements seen since startup
+// @Summary List slow queries
+// @Description Summarize the statements that ran longer than DB_SLOW_QUERY_THRESHOLD since the server started, grouped by their SQL with literal values replaced by ?. Use it to find the queries worth indexing for.
+// @Tags admin
+// @Produce json
+// @Param sort query string false "Order by total time (default), max time or count" Enums(total, max, count)
+// @Param limit query int false "Maximum number of statements" default(20)
+// @Success 200 {object} database.SlowQueryReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/admin/slow-queries [get]
+// @Security ApiKeyAuth
+func (h *SlowQueryHandler) ListSlowQueries(c echo.Context) error {
+	orderBy := c.QueryParam("sort")
+	switch orderBy {
+	case "", "total", "max", "count":
+	default:
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("sort must be total, max or count"))
+	}
+
+	limit := slowQueryDefaultLimit
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("limit must be a positive number"))
+		}
+	}
+
+	return c.JSON(http.StatusOK, h.db.SlowQueries(orderBy, limit))
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	// socketWriteWait is how long a WebSocket frame may take to write
+	socketWriteWait = 10 * time.Second
+	// socketPongWait is how long the server waits for a client to answer a ping
+	socketPongWait = 60 * time.Second
+	// socketPingPeriod keeps idle connections alive through proxies
+	socketPingPeriod = socketPongWait * 9 / 10
+)
+
+// staffOriginAllowed reports whether a staff WebSocket comes from the app itself
+// or a configured CORS origin. Staff sockets are authenticated by cookie, so
+// other sites must not be able to open them.
+func staffOriginAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host || slices.Contains(allowedOrigins, origin)
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// StatusBannerHandler handles major incident banner HTTP requests
+type StatusBannerHandler struct {
+	bannerService *services.StatusBannerService
+	botProtection *authMiddleware.BotProtection
+}
+
+// NewStatusBannerHandler creates a new status banner handler
+func NewStatusBannerHandler(bannerService *services.StatusBannerService, botProtection *authMiddleware.BotProtection) *StatusBannerHandler {
+	return &StatusBannerHandler{
+		bannerService: bannerService,
+		botProtection: botProtection,
+	}
+}
+
+// RegisterRoutes registers the status banner routes
+func (h *StatusBannerHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Public routes (no authentication) polled by the portal and chat widget
+	public := e.Group("/api/v1/public")
+	public.GET("/status-banners", h.ListActiveBanners)
+	public.POST("/status-banners/:id/subscribe", h.Subscribe, h.botProtection.RateLimit(), authMiddleware.UUIDParam("id", "status banner"))
+
+	// Signed-in users subscribe with their account address
+	banners := e.Group("/api/v1/status-banners")
+	banners.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "status banner"))
+	banners.POST("/:id/subscribe", h.SubscribeCurrentUser)
+
+	// Banner management - admin only
+	admin := e.Group("/api/v1/admin/status-banners")
+	admin.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "status banner"))
+	admin.GET("", h.ListBanners)
+	admin.POST("", h.CreateBanner)
+	admin.GET("/:id", h.GetBanner)
+	admin.DELETE("/:id", h.DeleteBanner)
+	admin.POST("/:id/resolve", h.ResolveBanner)
+}
+
+// ListActiveBanners handles listing the banners currently shown to users
+// @Summary List active status banners
+// @Description List the known-issue banners shown now. With category_id, only banners affecting that category are returned so ticket creation can be deflected.
+// @Tags public
+// @Produce json
+// @Param category_id query string false "Ticket category ID"
+// @Success 200 {array} models.StatusBanner
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/status-banners [get]
+func (h *StatusBannerHandler) ListActiveBanners(c echo.Context) error {
+	var categoryID *uuid.UUID
+	if param := c.QueryParam("category_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid category ID"))
+		}
+		categoryID = &id
+	}
+
+	banners, err := h.bannerService.ActiveBanners(c.Request().Context(), categoryID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list status banners"))
+	}
+	return c.JSON(http.StatusOK, banners)
+}
+
+// Subscribe handles subscribing to a known issue instead of raising a ticket
+// @Summary Subscribe to a known issue
+// @Description Be emailed when the issue behind an active status banner is resolved
+// @Tags public
+// @Accept json
+// @Param id path string true "Status banner ID"
+// @Param subscription body models.SubscribeBannerRequest true "Subscriber"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/status-banners/{id}/subscribe [post]
+func (h *StatusBannerHandler) Subscribe(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.SubscribeBannerRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	if err := h.bannerService.Subscribe(c.Request().Context(), id, &req); err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SubscribeCurrentUser handles subscribing the signed-in user to a known issue
+// @Summary Subscribe to a known issue
+// @Description Be emailed at your account address when the issue behind an active status banner is resolved, instead of filing a duplicate ticket
+// @Tags status-banners
+// @Param id path string true "Status banner ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/status-banners/{id}/subscribe [post]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) SubscribeCurrentUser(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.bannerService.SubscribeUser(c.Request().Context(), id, user); err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListBanners handles listing every status banner
+// @Summary List status banners
+// @Description List every status banner, including scheduled and resolved ones, with subscriber counts
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.StatusBanner
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/status-banners [get]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) ListBanners(c echo.Context) error {
+	banners, err := h.bannerService.ListBanners(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list status banners"))
+	}
+	return c.JSON(http.StatusOK, banners)
+}
+
+// GetBanner handles retrieving a status banner
+// @Summary Get a status banner
+// @Description Get a status banner with its subscriber count, a gauge of the issue's impact
+// @Tags admin
+// @Produce json
+// @Param id path string true "Status banner ID"
+// @Success 200 {object} models.StatusBanner
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/status-banners/{id} [get]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) GetBanner(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	banner, err := h.bannerService.GetBanner(c.Request().Context(), id)
+	if err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, banner)
+}
+
+// CreateBanner handles publishing a status banner
+// @Summary Create a status banner
+// @Description Publish a known-issue banner, optionally limited to ticket categories and a time window
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param banner body models.CreateStatusBannerRequest true "Banner data"
+// @Success 201 {object} models.StatusBanner
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/status-banners [post]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) CreateBanner(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.CreateStatusBannerRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	banner, err := h.bannerService.CreateBanner(c.Request().Context(), &req, userID)
+	if err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, banner)
+}
+
+// DeleteBanner handles deleting a status banner
+// @Summary Delete a status banner
+// @Description Delete a status banner without notifying its subscribers
+// @Tags admin
+// @Param id path string true "Status banner ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/status-banners/{id} [delete]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) DeleteBanner(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	if err := h.bannerService.DeleteBanner(c.Request().Context(), id); err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ResolveBanner handles resolving a status banner
+// @Summary Resolve a status banner
+// @Description End a status banner and email everyone who subscribed to the issue
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Status banner ID"
+// @Param resolution body models.ResolveStatusBannerRequest false "Resolution note"
+// @Success 200 {object} models.StatusBanner
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/status-banners/{id}/resolve [post]
+// @Security ApiKeyAuth
+func (h *StatusBannerHandler) ResolveBanner(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.ResolveStatusBannerRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	banner, err := h.bannerService.ResolveBanner(c.Request().Context(), id, &req)
+	if err != nil {
+		return statusBannerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, banner)
+}
+
+// statusBannerErrorResponse maps status banner service errors to HTTP responses
+func statusBannerErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process status banner request"))
+	}
+}
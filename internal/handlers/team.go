@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TeamHandler handles team administration HTTP requests
+type TeamHandler struct {
+	teamService *services.TeamService
+}
+
+// NewTeamHandler creates a new team handler
+func NewTeamHandler(teamService *services.TeamService) *TeamHandler {
+	return &TeamHandler{
+		teamService: teamService,
+	}
+}
+
+// RegisterRoutes registers the team administration routes
+func (h *TeamHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	teams := e.Group("/api/v1/admin/teams")
+	// Team membership decides what managers can see, so managers may not edit it themselves
+	teams.Use(ami.Authenticate, ami.RequirePermission(models.PermSystemAdmin), authMiddleware.UUIDParam("id", "team"), authMiddleware.UUIDParam("user_id", "user"))
+	teams.GET("", h.ListTeams)
+	teams.POST("", h.CreateTeam)
+	teams.GET("/:id", h.GetTeam)
+	teams.DELETE("/:id", h.DeleteTeam)
+	teams.POST("/:id/members", h.AddMember)
+	teams.DELETE("/:id/members/:user_id", h.RemoveMember)
+}
+
+// ListTeams handles listing teams
+// @Summary List teams
+// @Description List all teams ordered by name
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Team
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams [get]
+// @Security ApiKeyAuth
+func (h *TeamHandler) ListTeams(c echo.Context) error {
+	teams, err := h.teamService.ListTeams(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list teams"))
+	}
+	return c.JSON(http.StatusOK, teams)
+}
+
+// CreateTeam handles creating a team
+// @Summary Create a team
+// @Description Create a team of support staff
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param team body models.CreateTeamRequest true "Team data"
+// @Success 201 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams [post]
+// @Security ApiKeyAuth
+func (h *TeamHandler) CreateTeam(c echo.Context) error {
+	var req models.CreateTeamRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	team, err := h.teamService.CreateTeam(c.Request().Context(), &req)
+	if err != nil {
+		return teamErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, team)
+}
+
+// GetTeam handles retrieving a team
+// @Summary Get a team
+// @Description Get a team and its members
+// @Tags admin
+// @Produce json
+// @Param id path string true "Team ID"
+// @Success 200 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams/{id} [get]
+// @Security ApiKeyAuth
+func (h *TeamHandler) GetTeam(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	team, err := h.teamService.GetTeam(c.Request().Context(), id)
+	if err != nil {
+		return teamErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam handles deleting a team
+// @Summary Delete a team
+// @Description Delete a team and its memberships; tickets keep their team reference
+// @Tags admin
+// @Param id path string true "Team ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams/{id} [delete]
+// @Security ApiKeyAuth
+func (h *TeamHandler) DeleteTeam(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	if err := h.teamService.DeleteTeam(c.Request().Context(), id); err != nil {
+		return teamErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddMember handles adding a user to a team
+// @Summary Add a team member
+// @Description Add a user to a team
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID"
+// @Param member body models.AddTeamMemberRequest true "Member data"
+// @Success 200 {object} models.Team
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams/{id}/members [post]
+// @Security ApiKeyAuth
+func (h *TeamHandler) AddMember(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.AddTeamMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	team, err := h.teamService.AddMember(c.Request().Context(), id, req.UserID)
+	if err != nil {
+		return teamErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, team)
+}
+
+// RemoveMember handles removing a user from a team
+// @Summary Remove a team member
+// @Description Remove a user from a team
+// @Tags admin
+// @Param id path string true "Team ID"
+// @Param user_id path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/teams/{id}/members/{user_id} [delete]
+// @Security ApiKeyAuth
+func (h *TeamHandler) RemoveMember(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+	userID := authMiddleware.ParamUUID(c, "user_id")
+
+	if err := h.teamService.RemoveMember(c.Request().Context(), id, userID); err != nil {
+		return teamErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// teamErrorResponse maps team service errors to HTTP responses
+func teamErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process team request"))
+	}
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/timezone"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -27,33 +32,57 @@ func NewTicketHandler(ticketService *services.TicketService) *TicketHandler {
 func (h *TicketHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
 	// Public routes (require authentication)
 	tickets := e.Group("/api/v1/tickets")
-	tickets.Use(ami.Authenticate)
+	tickets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "ticket"))
 
 	// // Ticket CRUD operations
-	tickets.GET("", h.ListTickets, ami.RequireAgent(), ami.RequireManager(), ami.RequireAdmin())
-	tickets.POST("", h.CreateTicket)
-	tickets.GET("/:id", h.GetTicket, ami.RequireAnyRole(models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator), ami.RequireOwnerOrAdmin(func(c echo.Context) (string, error) {
+	// Read routes are permission based so read-only roles such as VIEWER can browse
+	tickets.GET("", h.ListTickets, ami.RequirePermission(models.PermTicketRead))
+	tickets.POST("", h.CreateTicket, ami.RequirePermission(models.PermTicketCreate))
+	tickets.POST("/similar", h.FindSimilarTickets, ami.RequirePermission(models.PermTicketCreate))
+	tickets.GET("/:id", h.GetTicket, ami.RequirePermissionOrOwner(models.PermTicketRead, func(c echo.Context) (string, error) {
 		return h.getUserId(c)
 	}))
-	tickets.PUT("/:id", h.UpdateTicket)
+	tickets.PUT("/:id", h.UpdateTicket, ami.RequireAnyPermission(models.PermTicketUpdate, models.PermTicketUpdateOwn))
 	tickets.DELETE("/:id", h.DeleteTicket, ami.RequireAdmin()) // Admin only
+	tickets.GET("/:id/versions", h.ListTicketVersions, ami.RequireAdmin())
+	tickets.POST("/:id/restore", h.RestoreTicketVersion, ami.Require(authMiddleware.Roles(models.RoleAdministrator))) // Administrators only, not managers
 
 	// Ticket actions - require agent or admin privileges
 	tickets.POST("/:id/assign", h.AssignTicket, ami.RequireAgent())
+	tickets.POST("/:id/unassign", h.UnassignTicket, ami.RequireAgent())
+	tickets.POST("/:id/take", h.TakeTicket, ami.RequireAgent())
 	tickets.POST("/:id/status", h.UpdateTicketStatus, ami.RequireAgent())
 	tickets.POST("/:id/escalate", h.EscalateTicket, ami.RequireAgent())
+	tickets.POST("/:id/de-escalate", h.DeEscalateTicket, ami.RequireAgent())
+	tickets.GET("/:id/activity", h.GetTicketActivity, ami.RequireAgent())
+
+	// Requester transfer - access is checked against the ticket in the service
+	tickets.POST("/:id/requester", h.ChangeRequester)
+
+	// Comments - access is checked against the ticket in the service
+	tickets.GET("/:id/comments", h.GetComments)
+	tickets.POST("/:id/comments", h.AddComment)
+	tickets.POST("/comments/preview", h.PreviewComment, ami.RequirePermission(models.PermCommentCreate))
 
 	// User-specific routes
 	tickets.GET("/my", h.GetMyTickets)
 	tickets.GET("/assigned", h.GetAssignedTickets)
+	tickets.GET("/recent", h.GetRecentTickets)
+
+	// Pins are per user; access is checked against the ticket in the service
+	tickets.PUT("/:id/pin", h.PinTicket)
+	tickets.DELETE("/:id/pin", h.UnpinTicket)
+
+	// Root cause codes offered when resolving
+	tickets.GET("/root-causes", h.ListRootCauses, ami.RequireAgent())
 
-	// Statistics - require agent or admin privileges
-	tickets.GET("/stats", h.GetTicketStats, ami.RequireAgent())
+	// Statistics - require the stats permission
+	tickets.GET("/stats", h.GetTicketStats, ami.RequirePermission(models.PermTicketStatsRead))
 }
 
 // CreateTicket handles ticket creation
 // @Summary Create a new ticket
-// @Description Create a new support ticket
+// @Description Create a new support ticket. Agents may set requester_id to log a ticket on behalf of another user. End users who already have MAX_OPEN_TICKETS_PER_REQUESTER open or in-progress tickets get a 409 asking them to update one instead; agents, managers and administrators are exempt. Files uploaded to /api/v1/tickets/draft-attachments are attached by listing their IDs in attachment_ids, and images uploaded to /api/v1/tickets/inline-images for the description by listing theirs in inline_image_ids; the ticket is only created if they all can be.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -61,13 +90,15 @@ func (h *TicketHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMid
 // @Success 201 {object} models.Ticket
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/tickets [post]
 // @Security ApiKeyAuth
 func (h *TicketHandler) CreateTicket(c echo.Context) error {
 	var req models.CreateTicketRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid request body"))
+		return bindErrorResponse(c, err)
 	}
 
 	if err := c.Validate(&req); err != nil {
@@ -82,15 +113,63 @@ func (h *TicketHandler) CreateTicket(c echo.Context) error {
 
 	ticket, err := h.ticketService.CreateTicket(c.Request().Context(), &req, userID)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		}
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
 	return c.JSON(http.StatusCreated, ticket)
 }
 
+// FindSimilarTickets handles checking a draft ticket for likely duplicates
+// @Summary Find tickets similar to a draft
+// @Description List the open and in-progress tickets that share the most distinctive words with a draft's title and description, closest first, so the requester can update an existing ticket instead of opening a duplicate. Only the requester's own tickets are searched unless include_others is set, which needs ticket:read and keeps to the caller's ticket scope. Agents may set requester_id to check the tickets of the user they are logging a ticket for.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param draft body models.SimilarTicketsRequest true "Draft ticket"
+// @Success 200 {object} models.SimilarTicketsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/similar [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) FindSimilarTickets(c echo.Context) error {
+	var req models.SimilarTicketsRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	similar, err := h.ticketService.FindSimilarTickets(c.Request().Context(), &req, user)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to find similar tickets"))
+	}
+
+	return c.JSON(http.StatusOK, similar)
+}
+
 // GetTicket handles retrieving a single ticket
 // @Summary Get a ticket by ID
-// @Description Retrieve a ticket by its ID
+// @Description Retrieve a ticket by its ID. Internal notes and their attachments are only included for support staff.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -103,12 +182,14 @@ func (h *TicketHandler) CreateTicket(c echo.Context) error {
 // @Router /api/v1/tickets/{id} [get]
 // @Security ApiKeyAuth
 func (h *TicketHandler) GetTicket(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
 	}
 
-	ticket, err := h.ticketService.GetTicket(c.Request().Context(), ticketID)
+	ticket, err := h.ticketService.ViewTicket(c.Request().Context(), ticketID, user)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
@@ -117,12 +198,14 @@ func (h *TicketHandler) GetTicket(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, models.NewErrorResponse("Ticket not found"))
 	}
 
+	h.ticketService.RecordView(c.Request().Context(), user.ID, ticket.ID)
+
 	return c.JSON(http.StatusOK, ticket)
 }
 
 // UpdateTicket handles ticket updates
 // @Summary Update a ticket
-// @Description Update an existing ticket
+// @Description Update an existing ticket. Images uploaded to /api/v1/tickets/inline-images for the description are attached by listing their IDs in inline_image_ids; images attached before stay attached.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -132,18 +215,16 @@ func (h *TicketHandler) GetTicket(c echo.Context) error {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/tickets/{id} [put]
 // @Security ApiKeyAuth
 func (h *TicketHandler) UpdateTicket(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
-	}
+	ticketID := authMiddleware.ParamUUID(c, "id")
 
 	var req models.UpdateTicketRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid request body"))
+		return bindErrorResponse(c, err)
 	}
 
 	if err := c.Validate(&req); err != nil {
@@ -158,6 +239,12 @@ func (h *TicketHandler) UpdateTicket(c echo.Context) error {
 
 	ticket, err := h.ticketService.UpdateTicket(c.Request().Context(), ticketID, &req, userID)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		}
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
@@ -180,10 +267,7 @@ func (h *TicketHandler) UpdateTicket(c echo.Context) error {
 // @Router /api/v1/tickets/{id} [delete]
 // @Security ApiKeyAuth
 func (h *TicketHandler) DeleteTicket(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
-	}
+	ticketID := authMiddleware.ParamUUID(c, "id")
 
 	// Get user ID from context for authorization
 	userID, err := getUserIDFromContext(c)
@@ -199,56 +283,125 @@ func (h *TicketHandler) DeleteTicket(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// ListTicketVersions handles listing the versions of a ticket
+// @Summary List ticket versions
+// @Description Retrieve every version of a ticket, oldest first, including the current one (admin only). Each version has the ticket's id and its own version_id; expiration_time is null on the current version.
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {array} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/versions [get]
+// @Security ApiKeyAuth
+func (h *TicketHandler) ListTicketVersions(c echo.Context) error {
+	versions, err := h.ticketService.ListVersions(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to get ticket versions"))
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+// RestoreTicketVersion handles making an earlier version of a ticket current again
+// @Summary Restore a ticket version
+// @Description Make an earlier version of a ticket current again (administrators only). Its fields are copied into a new version, so the versions it replaces stay in the ticket's history.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param version body models.RestoreTicketVersionRequest true "Version to restore"
+// @Success 200 {object} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) RestoreTicketVersion(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.RestoreTicketVersionRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ticket, err := h.ticketService.RestoreVersion(c.Request().Context(), ticketID, req.VersionID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to restore ticket version"))
+	}
+
+	return c.JSON(http.StatusOK, ticket)
+}
+
 // ListTickets handles listing tickets with filtering and pagination
 // @Summary List tickets
-// @Description Retrieve a list of tickets with filtering and pagination
+// @Description Retrieve a list of tickets with filtering and pagination. Managers without global visibility only see tickets of their teams. With search, each ticket lists where it matched in matches, with HTML-escaped snippets that wrap the match in <mark>.
 // @Tags tickets
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Param count query bool false "Set to false to skip counting the total; use has_more and the Link header to page"
 // @Param status query string false "Filter by status"
 // @Param priority query string false "Filter by priority"
 // @Param category_id query string false "Filter by category ID"
 // @Param assigned_to query string false "Filter by assigned agent ID"
+// @Param unassigned query bool false "Set to true to list only the tickets no agent is assigned to"
+// @Param team_id query string false "Filter by team ID"
 // @Param created_by query string false "Filter by creator ID"
-// @Param search query string false "Search in title and description"
+// @Param favorites query bool false "Set to true to list only the tickets the current user has pinned"
+// @Param date_from query string false "Only tickets created from this RFC 3339 time, or the start of this YYYY-MM-DD date in the user's time zone"
+// @Param date_to query string false "Only tickets created up to this RFC 3339 time, or the end of this YYYY-MM-DD date in the user's time zone"
+// @Param search query string false "Search text; each listed ticket's matches come with highlighted snippets"
+// @Param search_in query string false "Comma-separated parts to search: title, description, comments, attachments (default: title,description)"
+// @Param sort_field query string false "Sort by creation_time, due_date, priority, status or title; ties fall back to newest first" Enums(creation_time, due_date, priority, status, title)
+// @Param sort_direction query string false "Sort direction (default: asc)" Enums(asc, desc)
 // @Success 200 {object} models.TicketListResponse
+// @Header 200 {string} Link "Previous and next pages (RFC 5988)"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/tickets [get]
 // @Security ApiKeyAuth
 func (h *TicketHandler) ListTickets(c echo.Context) error {
-	query := &models.TicketQuery{
-		Page:     1,
-		PageSize: 20,
-	}
-
-	// Parse pagination parameters
-	if pageStr := c.QueryParam("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
-			query.Page = page
-		}
-	}
-
-	if pageSizeStr := c.QueryParam("page_size"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil && pageSize > 0 && pageSize <= 100 {
-			query.PageSize = pageSize
-		}
-	}
+	query := buildTicketQueryFromRequest(c)
 
 	// Parse filter parameters
 	filter := &models.TicketFilter{}
 
-	if status := c.QueryParam("status"); status != "" {
-		ticketStatus := models.TicketStatus(status)
-		filter.Status = &ticketStatus
+	var status models.TicketStatus
+	var priority models.TicketPriority
+	if err := echo.QueryParamsBinder(c).
+		TextUnmarshaler("status", &status).
+		TextUnmarshaler("priority", &priority).
+		BindError(); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse(bindErrorMessage(err, "Invalid query parameters")))
 	}
-
-	if priority := c.QueryParam("priority"); priority != "" {
-		ticketPriority := models.TicketPriority(priority)
-		filter.Priority = &ticketPriority
+	if status != "" {
+		filter.Status = &status
+	}
+	if priority != "" {
+		filter.Priority = &priority
 	}
 
 	if categoryIDStr := c.QueryParam("category_id"); categoryIDStr != "" {
@@ -263,33 +416,82 @@ func (h *TicketHandler) ListTickets(c echo.Context) error {
 		}
 	}
 
+	filter.Unassigned = c.QueryParam("unassigned") == "true"
+
+	if teamIDStr := c.QueryParam("team_id"); teamIDStr != "" {
+		if teamID, err := uuid.Parse(teamIDStr); err == nil {
+			filter.TeamID = &teamID
+		}
+	}
+
 	if createdByStr := c.QueryParam("created_by"); createdByStr != "" {
 		if createdBy, err := uuid.Parse(createdByStr); err == nil {
 			filter.CreatedBy = &createdBy
 		}
 	}
 
+	var err error
+	if filter.DateFrom, err = parseTimeParam(c, "date_from", false); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+	}
+	if filter.DateTo, err = parseTimeParam(c, "date_to", true); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+	}
+
 	if search := c.QueryParam("search"); search != "" {
 		filter.Search = search
 	}
 
+	if searchIn := c.QueryParam("search_in"); searchIn != "" {
+		for _, name := range strings.Split(searchIn, ",") {
+			field := models.TicketSearchField(strings.TrimSpace(name))
+			if !field.IsValid() {
+				return c.JSON(http.StatusBadRequest, models.NewErrorResponse("search_in must list title, description, comments or attachments"))
+			}
+			filter.SearchIn = append(filter.SearchIn, field)
+		}
+	}
+
 	query.Filter = filter
 
-	// Parse sorting parameters
+	// Parse sorting parameters; only the fields TicketSort allows are accepted
 	if sortField := c.QueryParam("sort_field"); sortField != "" {
-		if sortDirection := c.QueryParam("sort_direction"); sortDirection != "" {
-			query.Sort = &models.TicketSort{
-				Field:     sortField,
-				Direction: sortDirection,
-			}
+		query.Sort = &models.TicketSort{
+			Field:     sortField,
+			Direction: strings.ToLower(c.QueryParam("sort_direction")),
+		}
+		if query.Sort.Direction == "" {
+			query.Sort.Direction = "asc"
+		}
+		if err := c.Validate(query.Sort); err != nil {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
 		}
 	}
 
-	tickets, err := h.ticketService.ListTickets(c.Request().Context(), query)
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	// Internal notes are only searched for support staff
+	filter.SearchInternal = user.IsAgent()
+
+	if c.QueryParam("favorites") == "true" {
+		filter.PinnedBy = &user.ID
+	}
+
+	// Managers without global visibility only list their teams' tickets
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
+	tickets, err := h.ticketService.ListTickets(ctx, query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	setPageLinks(c, tickets)
 	return c.JSON(http.StatusOK, tickets)
 }
 
@@ -309,14 +511,11 @@ func (h *TicketHandler) ListTickets(c echo.Context) error {
 // @Router /api/v1/tickets/{id}/assign [post]
 // @Security ApiKeyAuth
 func (h *TicketHandler) AssignTicket(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
-	}
+	ticketID := authMiddleware.ParamUUID(c, "id")
 
 	var req models.AssignTicketRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid request body"))
+		return bindErrorResponse(c, err)
 	}
 
 	if err := c.Validate(&req); err != nil {
@@ -340,9 +539,101 @@ func (h *TicketHandler) AssignTicket(c echo.Context) error {
 	})
 }
 
+// TakeTicket handles an agent assigning an unassigned ticket to themselves
+// @Summary Take a ticket
+// @Description Assign an unassigned ticket to the current agent. Fails with 409 when another agent has it, including when several agents take it at once; taking a ticket you already have has no effect.
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/take [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) TakeTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.TakeTicket(c.Request().Context(), ticketID, user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to take ticket"))
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Status:  "success",
+		Message: "Ticket taken successfully",
+	})
+}
+
+// UnassignTicket handles returning a ticket to the pool
+// @Summary Unassign a ticket
+// @Description Clear a ticket's assigned agent with a reason, returning it to the pool listed by GET /api/v1/tickets?unassigned=true
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param request body models.UnassignTicketRequest true "Reason"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/unassign [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) UnassignTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.UnassignTicketRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.UnassignTicket(c.Request().Context(), ticketID, &req, userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to unassign ticket"))
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Status:  "success",
+		Message: "Ticket unassigned successfully",
+	})
+}
+
 // UpdateTicketStatus handles ticket status updates
 // @Summary Update ticket status
-// @Description Update the status of a ticket
+// @Description Update the status of a ticket. Resolving or closing it records the resolution details, and fails with 400 when its category requires details that are missing.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -356,14 +647,11 @@ func (h *TicketHandler) AssignTicket(c echo.Context) error {
 // @Router /api/v1/tickets/{id}/status [post]
 // @Security ApiKeyAuth
 func (h *TicketHandler) UpdateTicketStatus(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
-	}
+	ticketID := authMiddleware.ParamUUID(c, "id")
 
 	var req models.UpdateTicketStatusRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid request body"))
+		return bindErrorResponse(c, err)
 	}
 
 	if err := c.Validate(&req); err != nil {
@@ -378,6 +666,9 @@ func (h *TicketHandler) UpdateTicketStatus(c echo.Context) error {
 
 	err = h.ticketService.UpdateTicketStatus(c.Request().Context(), ticketID, &req, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		}
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
@@ -389,7 +680,7 @@ func (h *TicketHandler) UpdateTicketStatus(c echo.Context) error {
 
 // EscalateTicket handles ticket escalation
 // @Summary Escalate a ticket
-// @Description Escalate a ticket to a manager or administrator
+// @Description Escalate a ticket to a manager or administrator. The reason is kept on the ticket and emailed to them.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -403,14 +694,11 @@ func (h *TicketHandler) UpdateTicketStatus(c echo.Context) error {
 // @Router /api/v1/tickets/{id}/escalate [post]
 // @Security ApiKeyAuth
 func (h *TicketHandler) EscalateTicket(c echo.Context) error {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid ticket ID"))
-	}
+	ticketID := authMiddleware.ParamUUID(c, "id")
 
 	var req models.EscalateTicketRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid request body"))
+		return bindErrorResponse(c, err)
 	}
 
 	if err := c.Validate(&req); err != nil {
@@ -425,6 +713,12 @@ func (h *TicketHandler) EscalateTicket(c echo.Context) error {
 
 	err = h.ticketService.EscalateTicket(c.Request().Context(), ticketID, &req, userID)
 	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		}
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
@@ -434,6 +728,60 @@ func (h *TicketHandler) EscalateTicket(c echo.Context) error {
 	})
 }
 
+// DeEscalateTicket handles handing an escalated ticket back to its assignee
+// @Summary De-escalate a ticket
+// @Description Hand an escalated ticket back to its assignee with a note, clearing the escalation so it can be escalated again. Only the user the ticket was escalated to or an administrator can do this. The step is recorded in the ticket's escalation_events and the note is emailed to the assignee.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param de_escalation body models.DeEscalateTicketRequest true "Note for the assignee"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/de-escalate [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) DeEscalateTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.DeEscalateTicketRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.DeEscalateTicket(c.Request().Context(), ticketID, &req, user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrConflict):
+			return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to de-escalate ticket"))
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Status:  "success",
+		Message: "Ticket de-escalated successfully",
+	})
+}
+
 // GetMyTickets handles retrieving tickets created by the current user
 // @Summary Get my tickets
 // @Description Retrieve tickets created by the current user
@@ -442,7 +790,9 @@ func (h *TicketHandler) EscalateTicket(c echo.Context) error {
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Param count query bool false "Set to false to skip counting the total; use has_more and the Link header to page"
 // @Success 200 {object} models.TicketListResponse
+// @Header 200 {string} Link "Previous and next pages (RFC 5988)"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -460,6 +810,7 @@ func (h *TicketHandler) GetMyTickets(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
+	setPageLinks(c, tickets)
 	return c.JSON(http.StatusOK, tickets)
 }
 
@@ -471,7 +822,9 @@ func (h *TicketHandler) GetMyTickets(c echo.Context) error {
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Param count query bool false "Set to false to skip counting the total; use has_more and the Link header to page"
 // @Success 200 {object} models.TicketListResponse
+// @Header 200 {string} Link "Previous and next pages (RFC 5988)"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -489,12 +842,13 @@ func (h *TicketHandler) GetAssignedTickets(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
 
+	setPageLinks(c, tickets)
 	return c.JSON(http.StatusOK, tickets)
 }
 
 // GetTicketStats handles retrieving ticket statistics
 // @Summary Get ticket statistics
-// @Description Retrieve ticket statistics
+// @Description Retrieve ticket statistics. Managers without global visibility only see statistics for their teams.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -504,7 +858,17 @@ func (h *TicketHandler) GetAssignedTickets(c echo.Context) error {
 // @Router /api/v1/tickets/stats [get]
 // @Security ApiKeyAuth
 func (h *TicketHandler) GetTicketStats(c echo.Context) error {
-	stats, err := h.ticketService.GetTicketStats(c.Request().Context())
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ctx, err := h.ticketService.ScopeTickets(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+	}
+
+	stats, err := h.ticketService.GetTicketStats(ctx)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
 	}
@@ -512,8 +876,335 @@ func (h *TicketHandler) GetTicketStats(c echo.Context) error {
 	return c.JSON(http.StatusOK, stats)
 }
 
+// ChangeRequester handles transferring a ticket to another requester
+// @Summary Change ticket requester
+// @Description Transfer a ticket to another requester. Allowed for the current requester and staff; the original creator is kept in opened_by.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param requester body models.ChangeRequesterRequest true "New requester"
+// @Success 200 {object} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/requester [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) ChangeRequester(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.ChangeRequesterRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	ticket, err := h.ticketService.ChangeRequester(c.Request().Context(), ticketID, &req, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to change requester"))
+	}
+
+	return c.JSON(http.StatusOK, ticket)
+}
+
+// AddComment handles adding a comment to a ticket
+// @Summary Add a comment
+// @Description Add a comment to a ticket, written as plain text or Markdown; the response has it rendered as sanitized HTML in html. Public comments are emailed to the other participant and to the addresses copied on the ticket
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param comment body models.CreateCommentRequest true "Comment data"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/comments [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) AddComment(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	comment, err := h.ticketService.AddComment(c.Request().Context(), ticketID, &req, user)
+	if err != nil {
+		return commentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, comment)
+}
+
+// PreviewComment handles rendering a draft comment
+// @Summary Preview a comment
+// @Description Render a draft comment as the html field of the comment it would become: Markdown or plain text as sanitized HTML, with sensitive data masked. Raw HTML in the draft is shown as text, and links only point to http, https and mailto URLs or paths on this site.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param comment body models.CommentPreviewRequest true "Draft comment"
+// @Success 200 {object} models.CommentPreviewResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/tickets/comments/preview [post]
+// @Security ApiKeyAuth
+func (h *TicketHandler) PreviewComment(c echo.Context) error {
+	var req models.CommentPreviewRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	return c.JSON(http.StatusOK, h.ticketService.PreviewComment(&req))
+}
+
+// ListRootCauses handles listing the root cause codes tickets can be resolved with
+// @Summary List root cause codes
+// @Description List the ROOT_CAUSE_CODES agents choose from when resolving a ticket (agents only)
+// @Tags tickets
+// @Produce json
+// @Success 200 {array} string
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/v1/tickets/root-causes [get]
+// @Security ApiKeyAuth
+func (h *TicketHandler) ListRootCauses(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.ticketService.RootCauses())
+}
+
+// GetTicketActivity handles listing the assignment and escalation history of a ticket
+// @Summary Ticket activity
+// @Description List who a ticket was assigned, escalated and de-escalated to, by whom and when, oldest first (agents only)
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {array} models.TicketActivity
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/activity [get]
+// @Security ApiKeyAuth
+func (h *TicketHandler) GetTicketActivity(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	activity, err := h.ticketService.GetActivity(c.Request().Context(), ticketID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to get ticket activity"))
+	}
+
+	return c.JSON(http.StatusOK, activity)
+}
+
+// GetComments handles listing the comments of a ticket
+// @Summary List ticket comments
+// @Description List the comments of a ticket; internal notes are only returned to agents
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {array} models.Comment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/comments [get]
+// @Security ApiKeyAuth
+func (h *TicketHandler) GetComments(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	comments, err := h.ticketService.GetComments(c.Request().Context(), ticketID, user)
+	if err != nil {
+		return commentErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, comments)
+}
+
+// PinTicket handles starring a ticket for the current user
+// @Summary Pin a ticket
+// @Description Star a ticket so it is listed by GET /api/v1/tickets?favorites=true, whatever queue it is in. Pinning a ticket twice has no effect.
+// @Tags tickets
+// @Param id path string true "Ticket ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/pin [put]
+// @Security ApiKeyAuth
+func (h *TicketHandler) PinTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.PinTicket(c.Request().Context(), ticketID, user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponse("Ticket not found"))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to pin ticket"))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnpinTicket handles removing the current user's star from a ticket
+// @Summary Unpin a ticket
+// @Description Remove a ticket from the current user's favorites. Unpinning a ticket that is not pinned has no effect.
+// @Tags tickets
+// @Param id path string true "Ticket ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/pin [delete]
+// @Security ApiKeyAuth
+func (h *TicketHandler) UnpinTicket(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.UnpinTicket(c.Request().Context(), ticketID, userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to unpin ticket"))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetRecentTickets handles retrieving the tickets the current user viewed most recently
+// @Summary Get recently viewed tickets
+// @Description Retrieve the tickets the current user opened most recently, newest first, so they can jump back to them. The last 20 views are remembered; tickets the user can no longer see are left out.
+// @Tags tickets
+// @Produce json
+// @Param limit query int false "Maximum number of tickets" default(10) maximum(20)
+// @Success 200 {array} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/recent [get]
+// @Security ApiKeyAuth
+func (h *TicketHandler) GetRecentTickets(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	limit := services.DefaultRecentTickets
+	if value := c.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponse("limit must be a number"))
+		}
+	}
+
+	tickets, err := h.ticketService.GetRecentTickets(c.Request().Context(), user, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to get recent tickets"))
+	}
+	return c.JSON(http.StatusOK, tickets)
+}
+
 // Helper functions
 
+// commentErrorResponse maps comment service errors to HTTP responses
+func commentErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponse("Ticket not found"))
+	}
+	return c.JSON(http.StatusInternalServerError, models.NewErrorResponseFromError(err))
+}
+
+// bindErrorMessage describes a c.Bind error, naming the allowed values when an
+// enum such as a ticket status was given an unknown value
+func bindErrorMessage(err error, fallback string) string {
+	var enumErr *models.EnumValueError
+	if errors.As(err, &enumErr) {
+		return enumErr.Error()
+	}
+	return fallback
+}
+
+// parseTimeParam parses a query parameter holding an RFC 3339 timestamp or a
+// YYYY-MM-DD date in the user's time zone, which is taken as the start of the
+// day, or its end when endOfDay is set. It returns nil when the parameter is absent.
+func parseTimeParam(c echo.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	if at, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return &at, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, timezone.Or(c.Request().Context(), time.UTC))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &day, nil
+}
+
+// bindErrorResponse maps a c.Bind error to a 400 response
+func bindErrorResponse(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, models.NewErrorResponse(bindErrorMessage(err, "Invalid request body")))
+}
+
 func getUserIDFromContext(c echo.Context) (uuid.UUID, error) {
 	userIDStr := c.Get("user_id").(string)
 	if userIDStr == "" {
@@ -535,19 +1226,8 @@ func getUserRoleFromContext(c echo.Context) (models.UserRole, error) {
 	}
 
 	userRole := models.UserRole(userRoleStr)
-
-	// Validate the role
-	validRoles := []models.UserRole{
-		models.RoleEndUser,
-		models.RoleSupportAgent,
-		models.RoleManager,
-		models.RoleAdministrator,
-	}
-
-	for _, validRole := range validRoles {
-		if userRole == validRole {
-			return userRole, nil
-		}
+	if userRole.IsValid() {
+		return userRole, nil
 	}
 
 	return "", echo.NewHTTPError(http.StatusUnauthorized, "invalid user role in context")
@@ -580,19 +1260,40 @@ func buildTicketQueryFromRequest(c echo.Context) *models.TicketQuery {
 		}
 	}
 
+	query.SkipCount = c.QueryParam("count") == "false"
+
 	return query
 }
 
+// setPageLinks sets an RFC 5988 Link header pointing at the previous and next
+// pages of a ticket list, so clients can page without the total
+func setPageLinks(c echo.Context, list *models.TicketListResponse) {
+	link := func(page int, rel string) string {
+		u := *c.Request().URL
+		values := u.Query()
+		values.Set("page", strconv.Itoa(page))
+		u.RawQuery = values.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+	}
+
+	var links []string
+	if list.HasMore {
+		links = append(links, link(list.Page+1, "next"))
+	}
+	if list.Page > 1 {
+		links = append(links, link(list.Page-1, "prev"))
+	}
+	if len(links) > 0 {
+		c.Response().Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
 // func(c echo.Context) (string, error) {
 // 	return h.getUserId(c)
 // }
 
 func (h *TicketHandler) getUserId(c echo.Context) (string, error) {
-	ticketID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		return "", err
-	}
-	ticket, err := h.ticketService.GetTicket(c.Request().Context(), ticketID)
+	ticket, err := h.ticketService.GetTicket(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// ticketSocketMaxFrame caps the size of a client frame; clients have nothing to send
+const ticketSocketMaxFrame = 512
+
+// TicketEventHandler handles the live ticket updates WebSocket
+type TicketEventHandler struct {
+	eventService   *services.TicketEventService
+	allowedOrigins []string
+}
+
+// NewTicketEventHandler creates a new ticket event handler
+func NewTicketEventHandler(eventService *services.TicketEventService, config *config.Config) *TicketEventHandler {
+	return &TicketEventHandler{
+		eventService:   eventService,
+		allowedOrigins: config.CORS.AllowedOrigins,
+	}
+}
+
+// RegisterRoutes registers the ticket event routes
+func (h *TicketEventHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	e.GET("/api/v1/ws", h.Socket, ami.Authenticate)
+}
+
+// Socket handles a live ticket updates connection
+// @Summary Open a ticket updates WebSocket
+// @Description Upgrade to a WebSocket that pushes ticket changes as they happen: "ticket.created", "ticket.updated", "ticket.assigned" (assigned_agent_id is null when a ticket is returned to the pool) and "ticket.status_changed". Each event carries the fields ticket lists show; fetch the ticket for the rest. Users receive the events of the tickets they could list, plus those they requested or are or were just assigned; a change to their teams applies when they reconnect. Clients do not send frames.
+// @Tags tickets
+// @Success 101 {object} models.TicketEvent
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/ws [get]
+// @Security ApiKeyAuth
+func (h *TicketEventHandler) Socket(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	if !staffOriginAllowed(c.Request(), h.allowedOrigins) {
+		return c.JSON(http.StatusForbidden, models.NewErrorResponse("Origin not allowed"))
+	}
+
+	sub, err := h.eventService.Subscribe(c.Request().Context(), user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to subscribe to ticket updates"))
+	}
+	defer sub.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool {
+		return staffOriginAllowed(r, h.allowedOrigins)
+	}}
+	conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Reading is only needed to handle pongs and notice the client leaving
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(ticketSocketMaxFrame)
+		conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return nil
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				return nil
+			}
+		}
+	}
+}
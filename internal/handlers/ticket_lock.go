@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TicketLockHandler handles ticket edit lock HTTP requests
+type TicketLockHandler struct {
+	lockService *services.TicketLockService
+}
+
+// NewTicketLockHandler creates a new ticket lock handler
+func NewTicketLockHandler(lockService *services.TicketLockService) *TicketLockHandler {
+	return &TicketLockHandler{
+		lockService: lockService,
+	}
+}
+
+// RegisterRoutes registers the ticket lock routes
+func (h *TicketLockHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	tickets := e.Group("/api/v1/tickets")
+	tickets.Use(ami.Authenticate, ami.RequireAgent(), authMiddleware.UUIDParam("id", "ticket"))
+	tickets.GET("/:id/lock", h.GetLock)
+	tickets.POST("/:id/lock", h.AcquireLock)
+	tickets.POST("/:id/lock/heartbeat", h.Heartbeat)
+	tickets.DELETE("/:id/lock", h.ReleaseLock)
+}
+
+// GetLock handles showing who is editing a ticket
+// @Summary Get a ticket's edit lock
+// @Description Show which agent is editing a ticket and until when; 204 when nobody is
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.TicketLock
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/lock [get]
+// @Security ApiKeyAuth
+func (h *TicketLockHandler) GetLock(c echo.Context) error {
+	lock, err := h.lockService.Get(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return ticketLockErrorResponse(c, err)
+	}
+	if lock == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// AcquireLock handles an agent starting to edit a ticket
+// @Summary Lock a ticket for editing
+// @Description Take the advisory edit lock on a ticket, or renew your own, so other agents are warned you are editing it. The lock lapses TICKET_LOCK_TTL after the last heartbeat. It does not block updates, which are still checked against the ticket version.
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.TicketLock
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Another agent is editing the ticket"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/lock [post]
+// @Security ApiKeyAuth
+func (h *TicketLockHandler) AcquireLock(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	lock, err := h.lockService.Acquire(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), user)
+	if err != nil {
+		return ticketLockErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// Heartbeat handles keeping an edit lock
+// @Summary Renew a ticket edit lock
+// @Description Keep your edit lock on a ticket for another TICKET_LOCK_TTL. Fails with 409 once the lock has lapsed or been released.
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.TicketLock
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "You no longer hold the lock"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/lock/heartbeat [post]
+// @Security ApiKeyAuth
+func (h *TicketLockHandler) Heartbeat(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	lock, err := h.lockService.Heartbeat(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), user)
+	if err != nil {
+		return ticketLockErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, lock)
+}
+
+// ReleaseLock handles an agent finishing editing a ticket
+// @Summary Unlock a ticket
+// @Description Release your edit lock on a ticket. Administrators can release anyone's lock. Releasing a ticket that is not locked has no effect.
+// @Tags tickets
+// @Param id path string true "Ticket ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/lock [delete]
+// @Security ApiKeyAuth
+func (h *TicketLockHandler) ReleaseLock(c echo.Context) error {
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.lockService.Release(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), user); err != nil {
+		return ticketLockErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ticketLockErrorResponse maps ticket lock service errors to HTTP responses
+func ticketLockErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update ticket lock"))
+	}
+}
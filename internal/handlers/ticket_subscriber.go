@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TicketSubscriberHandler handles copying outside email addresses on tickets
+type TicketSubscriberHandler struct {
+	ticketService       *services.TicketService
+	emailChannelService *services.EmailChannelService
+}
+
+// NewTicketSubscriberHandler creates a new ticket subscriber handler
+func NewTicketSubscriberHandler(ticketService *services.TicketService, emailChannelService *services.EmailChannelService) *TicketSubscriberHandler {
+	return &TicketSubscriberHandler{
+		ticketService:       ticketService,
+		emailChannelService: emailChannelService,
+	}
+}
+
+// RegisterRoutes registers the ticket subscriber routes
+func (h *TicketSubscriberHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	// Access is checked against the ticket in the service
+	tickets := e.Group("/api/v1/tickets")
+	tickets.Use(ami.Authenticate, authMiddleware.UUIDParam("id", "ticket"))
+	tickets.GET("/:id/subscribers", h.ListSubscribers)
+	tickets.POST("/:id/subscribers", h.AddSubscriber)
+	tickets.DELETE("/:id/subscribers/:subscriberId", h.RemoveSubscriber, authMiddleware.UUIDParam("subscriberId", "subscriber"))
+
+	// Public routes (no authentication, access is granted by the signed token).
+	// POST serves one-click unsubscribe from the List-Unsubscribe header.
+	public := e.Group("/api/v1/public")
+	public.GET("/unsubscribe/:token", h.Unsubscribe)
+	public.POST("/unsubscribe/:token", h.Unsubscribe)
+}
+
+// ListSubscribers handles listing the addresses copied on a ticket
+// @Summary List ticket subscribers
+// @Description List the outside email addresses copied on a ticket. The requester and staff who can read tickets can see them.
+// @Tags tickets
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.TicketSubscriberListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/subscribers [get]
+// @Security ApiKeyAuth
+func (h *TicketSubscriberHandler) ListSubscribers(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	subscribers, err := h.ticketService.ListSubscribers(c.Request().Context(), ticketID, user)
+	if err != nil {
+		return subscriberErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, models.TicketSubscriberListResponse{Subscribers: subscribers})
+}
+
+// AddSubscriber handles copying an email address on a ticket
+// @Summary Copy an address on a ticket
+// @Description Copy an outside email address, such as a manager or vendor without an account, on a ticket. They are emailed every public comment with a reply-to address, so they can answer by email, and an unsubscribe link. The requester or an agent can copy up to 10 addresses.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Param subscriber body models.AddTicketSubscriberRequest true "Address to copy"
+// @Success 201 {object} models.TicketSubscriber
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/subscribers [post]
+// @Security ApiKeyAuth
+func (h *TicketSubscriberHandler) AddSubscriber(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+
+	var req models.AddTicketSubscriberRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	subscriber, err := h.ticketService.AddSubscriber(c.Request().Context(), ticketID, &req, user)
+	if err != nil {
+		return subscriberErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, subscriber)
+}
+
+// RemoveSubscriber handles removing an address copied on a ticket
+// @Summary Remove a ticket subscriber
+// @Description Stop copying an email address on a ticket. The requester or an agent can remove subscribers.
+// @Tags tickets
+// @Param id path string true "Ticket ID"
+// @Param subscriberId path string true "Subscriber ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tickets/{id}/subscribers/{subscriberId} [delete]
+// @Security ApiKeyAuth
+func (h *TicketSubscriberHandler) RemoveSubscriber(c echo.Context) error {
+	ticketID := authMiddleware.ParamUUID(c, "id")
+	subscriberID := authMiddleware.ParamUUID(c, "subscriberId")
+
+	user, err := getUserFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.ticketService.RemoveSubscriber(c.Request().Context(), ticketID, subscriberID, user); err != nil {
+		return subscriberErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Unsubscribe handles the unsubscribe link emailed to ticket subscribers
+// @Summary Unsubscribe from a ticket
+// @Description Stop emailing a ticket's updates to the subscriber the link was sent to. The link is in every email sent to a subscriber and in its List-Unsubscribe header, which mail clients POST to for one-click unsubscribe. Unsubscribing twice has no effect.
+// @Tags public
+// @Produce json
+// @Param token path string true "Unsubscribe token"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/unsubscribe/{token} [get]
+// @Router /api/v1/public/unsubscribe/{token} [post]
+func (h *TicketSubscriberHandler) Unsubscribe(c echo.Context) error {
+	if err := h.emailChannelService.Unsubscribe(c.Request().Context(), c.Param("token")); err != nil {
+		if errors.Is(err, services.ErrUnsubscribeLinkInvalid) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponse("Unsubscribe link is invalid or has expired"))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to unsubscribe"))
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Status:  "success",
+		Message: "You will no longer receive emails about this ticket",
+	})
+}
+
+// subscriberErrorResponse maps ticket subscriber service errors to HTTP responses
+func subscriberErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrForbidden):
+		return c.JSON(http.StatusForbidden, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process subscriber request"))
+	}
+}
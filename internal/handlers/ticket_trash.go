@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// TicketTrashHandler handles the administration of deleted tickets
+type TicketTrashHandler struct {
+	trashService *services.TicketTrashService
+}
+
+// NewTicketTrashHandler creates a new ticket trash handler
+func NewTicketTrashHandler(trashService *services.TicketTrashService) *TicketTrashHandler {
+	return &TicketTrashHandler{trashService: trashService}
+}
+
+// RegisterRoutes registers the ticket trash routes
+func (h *TicketTrashHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	trash := e.Group("/api/v1/admin/tickets/trash")
+	trash.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "ticket"))
+	trash.GET("", h.ListTrash)
+	trash.POST("/:id/restore", h.RestoreTicket)
+}
+
+// ListTrash handles listing deleted tickets
+// @Summary List deleted tickets
+// @Description List the tickets in the trash, most recently deleted first. Deleted tickets are hidden everywhere else and are purged for good TICKET_TRASH_RETENTION_DAYS after deletion, at purge_at, unless restored.
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param page_size query int false "Page size (default: 20, max: 100)"
+// @Success 200 {object} models.TrashedTicketListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/tickets/trash [get]
+// @Security ApiKeyAuth
+func (h *TicketTrashHandler) ListTrash(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	trash, err := h.trashService.List(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list deleted tickets"))
+	}
+	return c.JSON(http.StatusOK, trash)
+}
+
+// RestoreTicket handles taking a ticket out of the trash
+// @Summary Restore a deleted ticket
+// @Description Take a ticket out of the trash, as it was when it was deleted
+// @Tags admin
+// @Produce json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} models.Ticket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/tickets/trash/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *TicketTrashHandler) RestoreTicket(c echo.Context) error {
+	ticket, err := h.trashService.Restore(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		}
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to restore ticket"))
+	}
+	return c.JSON(http.StatusOK, ticket)
+}
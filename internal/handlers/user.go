@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// UserHandler handles HTTP requests about the current user's account
+type UserHandler struct {
+	userService         *services.UserService
+	loginHistoryService *services.LoginHistoryService
+	delegationService   *services.DelegationService
+	usageService        *services.UsageService
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *services.UserService, loginHistoryService *services.LoginHistoryService, delegationService *services.DelegationService, usageService *services.UsageService) *UserHandler {
+	return &UserHandler{
+		userService:         userService,
+		loginHistoryService: loginHistoryService,
+		delegationService:   delegationService,
+		usageService:        usageService,
+	}
+}
+
+// RegisterRoutes registers the current user routes
+func (h *UserHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	me := e.Group("/api/v1/users/me")
+	me.Use(ami.Authenticate)
+	me.GET("/logins", h.ListLogins)
+	me.GET("/usage", h.GetUsage)
+	me.PUT("/language", h.SetLanguage)
+	me.PUT("/timezone", h.SetTimezone)
+	me.PUT("/quiet-hours", h.SetQuietHours)
+
+	outOfOffice := me.Group("/out-of-office", ami.RequireAgent())
+	outOfOffice.GET("", h.GetOutOfOffice)
+	outOfOffice.PUT("", h.SetOutOfOffice)
+	outOfOffice.DELETE("", h.ClearOutOfOffice)
+	outOfOffice.POST("/handover", h.HandoverTickets)
+}
+
+// SetLanguage handles setting the current user's preferred language
+// @Summary Set my language
+// @Description Set the language notification emails are sent to the current user in, as a BCP 47 tag such as "fr" or "pt-BR". Templates without a translation into it fall back to its base language, then the default language (DEFAULT_LANGUAGE). Send an empty language to use the default language.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param language body models.LanguageRequest true "Language"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/language [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) SetLanguage(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.LanguageRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := h.userService.SetLanguage(c.Request().Context(), userID, req.Language)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to set language"))
+		}
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// SetTimezone handles setting the current user's time zone
+// @Summary Set my time zone
+// @Description Set the IANA time zone the current user's timestamps and dates are in. Timestamps in responses are returned as RFC 3339 with the zone's offset, date-only due dates end at the end of that day, and report dates and days start at local midnight. An empty time zone is UTC.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param timezone body models.TimezoneRequest true "Time zone"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/timezone [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) SetTimezone(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.TimezoneRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := h.userService.SetTimezone(c.Request().Context(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to set time zone"))
+		}
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// SetQuietHours handles setting the current user's quiet hours
+// @Summary Set my quiet hours
+// @Description Set the daily period, in the given IANA time zone, during which ticket notification emails to the current user are held back and sent once it ends. Quiet hours may span midnight, such as 22:00 to 07:00. Notifications about tickets of a priority in QUIET_HOURS_BREAKTHROUGH_PRIORITIES, or past their due date when QUIET_HOURS_BREAKTHROUGH_OVERDUE is set, are sent straight away. Send an empty start and end to turn quiet hours off.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param quiet_hours body models.QuietHoursRequest true "Quiet hours"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/quiet-hours [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) SetQuietHours(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.QuietHoursRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	user, err := h.userService.SetQuietHours(c.Request().Context(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+		case errors.Is(err, services.ErrNotFound):
+			return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+		default:
+			return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to set quiet hours"))
+		}
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// GetUsage handles reporting the current user's API usage
+// @Summary Get my API usage
+// @Description Report how many API requests the current user has made today (UTC) against their daily quota, and when it resets. This request counts towards the quota. The same figures are sent on every authenticated response in the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (Unix time) headers; once the quota is used up requests get 429 until it resets.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.APIUsage
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Router /api/v1/users/me/usage [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) GetUsage(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+	return c.JSON(http.StatusOK, h.usageService.Usage(userID))
+}
+
+// ListLogins handles listing the current user's login history
+// @Summary List my logins
+// @Description List the current user's successful logins with IP address, device and location hint, newest first
+// @Tags users
+// @Produce json
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.LoginHistoryResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/logins [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) ListLogins(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	pageSize, _ := strconv.Atoi(c.QueryParam("page_size"))
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	logins, err := h.loginHistoryService.ListLogins(c.Request().Context(), userID, page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list logins"))
+	}
+
+	return c.JSON(http.StatusOK, logins)
+}
+
+// GetOutOfOffice handles retrieving the current user's out-of-office window
+// @Summary Get my out-of-office window
+// @Description Get the current agent's out-of-office window and delegate
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.OutOfOffice
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/out-of-office [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) GetOutOfOffice(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	window, err := h.delegationService.GetOutOfOffice(c.Request().Context(), userID)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, window)
+}
+
+// SetOutOfOffice handles setting the current user's out-of-office window
+// @Summary Set my out-of-office window
+// @Description Set or replace the current agent's out-of-office window. While it is active, new assignments and mentions are routed to the delegate.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param window body models.SetOutOfOfficeRequest true "Out-of-office window"
+// @Success 200 {object} models.OutOfOffice
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/out-of-office [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) SetOutOfOffice(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.SetOutOfOfficeRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	window, err := h.delegationService.SetOutOfOffice(c.Request().Context(), userID, &req)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, window)
+}
+
+// ClearOutOfOffice handles removing the current user's out-of-office window
+// @Summary Clear my out-of-office window
+// @Description Remove the current agent's out-of-office window
+// @Tags users
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/out-of-office [delete]
+// @Security ApiKeyAuth
+func (h *UserHandler) ClearOutOfOffice(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	if err := h.delegationService.ClearOutOfOffice(c.Request().Context(), userID); err != nil {
+		return delegationErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandoverTickets handles reassigning the current user's open tickets to their delegate
+// @Summary Hand over my open tickets
+// @Description Reassign every open or in-progress ticket assigned to the current agent to their out-of-office delegate
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.HandoverResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/users/me/out-of-office/handover [post]
+// @Security ApiKeyAuth
+func (h *UserHandler) HandoverTickets(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	result, err := h.delegationService.HandoverOpenTickets(c.Request().Context(), userID)
+	if err != nil {
+		return delegationErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// delegationErrorResponse maps delegation service errors to HTTP responses
+func delegationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrConflict):
+		return c.JSON(http.StatusConflict, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process out-of-office request"))
+	}
+}
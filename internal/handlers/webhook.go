@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// WebhookHandler handles the administration of outbound webhook subscriptions
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// RegisterRoutes registers the webhook routes
+func (h *WebhookHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	admin := e.Group("/api/v1/admin/webhooks")
+	admin.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "webhook subscription"))
+	admin.GET("", h.ListSubscriptions)
+	admin.POST("", h.CreateSubscription)
+	admin.GET("/:id", h.GetSubscription)
+	admin.PUT("/:id", h.UpdateSubscription)
+	admin.DELETE("/:id", h.DeleteSubscription)
+	admin.POST("/:id/secret", h.RotateSecret)
+	admin.GET("/:id/deliveries", h.ListDeliveries)
+}
+
+// ListSubscriptions handles listing the webhook subscriptions
+// @Summary List webhook subscriptions
+// @Description List every outbound webhook subscription with the events it subscribes to. Secrets are never shown.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks [get]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) ListSubscriptions(c echo.Context) error {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list webhook subscriptions"))
+	}
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+// CreateSubscription handles creating a webhook subscription
+// @Summary Create a webhook subscription
+// @Description Have ticket.created, ticket.status_changed or comment.added events posted to a URL as JSON. Each payload is signed in the X-Signature-256 header as sha256=<hex HMAC-SHA256 of the body>, keyed with the subscription's secret, which is generated and returned only in this response. The X-Webhook-Event and X-Webhook-Delivery headers name the event and the delivery, whose ID stays the same across retries. Responses other than 2xx are retried with exponential backoff, eight attempts in all over about an hour. The subscription is enabled unless enabled is false.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param subscription body models.WebhookSubscriptionRequest true "Subscription"
+// @Success 201 {object} models.WebhookSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks [post]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) CreateSubscription(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	created, err := h.webhookService.CreateSubscription(c.Request().Context(), &req, userID)
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// GetSubscription handles retrieving a webhook subscription
+// @Summary Get a webhook subscription
+// @Description Get a webhook subscription. Its secret is never shown.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks/{id} [get]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) GetSubscription(c echo.Context) error {
+	subscription, err := h.webhookService.GetSubscription(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// UpdateSubscription handles replacing the settings of a webhook subscription
+// @Summary Update a webhook subscription
+// @Description Replace the URL, description and events of a webhook subscription. Its secret is kept; enabled is kept unless given. Deliveries still pending when a subscription is disabled fail unsent.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook subscription ID"
+// @Param subscription body models.WebhookSubscriptionRequest true "Subscription"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks/{id} [put]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) UpdateSubscription(c echo.Context) error {
+	var req models.WebhookSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	subscription, err := h.webhookService.UpdateSubscription(c.Request().Context(), authMiddleware.ParamUUID(c, "id"), &req)
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteSubscription handles deleting a webhook subscription
+// @Summary Delete a webhook subscription
+// @Description Delete a webhook subscription with its delivery log. Events not yet delivered are dropped.
+// @Tags admin
+// @Param id path string true "Webhook subscription ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks/{id} [delete]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) DeleteSubscription(c echo.Context) error {
+	if err := h.webhookService.DeleteSubscription(c.Request().Context(), authMiddleware.ParamUUID(c, "id")); err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateSecret handles replacing the signing secret of a webhook subscription
+// @Summary Rotate a webhook subscription secret
+// @Description Generate a new signing secret for a webhook subscription, returned only in this response. Deliveries attempted from then on, including retries, are signed with it.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook subscription ID"
+// @Success 200 {object} models.WebhookSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks/{id}/secret [post]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) RotateSecret(c echo.Context) error {
+	rotated, err := h.webhookService.RotateSecret(c.Request().Context(), authMiddleware.ParamUUID(c, "id"))
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, rotated)
+}
+
+// ListDeliveries handles listing the delivery log of a webhook subscription
+// @Summary List webhook deliveries
+// @Description List the events posted or to be posted to a webhook subscription, newest first, with their payload, attempts, the response status and error of the latest attempt and when the next is due
+// @Tags admin
+// @Produce json
+// @Param id path string true "Webhook subscription ID"
+// @Param status query string false "Filter by status" Enums(PENDING, DELIVERED, FAILED)
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} models.WebhookDeliveryListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/webhooks/{id}/deliveries [get]
+// @Security ApiKeyAuth
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	query := &models.WebhookDeliveryQuery{
+		SubscriptionID: authMiddleware.ParamUUID(c, "id"),
+		Status:         models.WebhookDeliveryStatus(c.QueryParam("status")),
+	}
+	query.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	query.PageSize, _ = strconv.Atoi(c.QueryParam("page_size"))
+	if query.PageSize > 100 {
+		query.PageSize = 100
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request().Context(), query)
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// webhookErrorResponse maps webhook service errors to HTTP responses
+func webhookErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process webhook request"))
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	authMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// widgetScript is the embeddable widget, served to external websites as-is
+//
+//go:embed widget.js
+var widgetScript []byte
+
+// WidgetHandler serves the embeddable chat widget and its bootstrap configuration
+type WidgetHandler struct {
+	widgetService *services.WidgetService
+}
+
+// NewWidgetHandler creates a new widget handler
+func NewWidgetHandler(widgetService *services.WidgetService) *WidgetHandler {
+	return &WidgetHandler{
+		widgetService: widgetService,
+	}
+}
+
+// RegisterRoutes registers the widget routes. The script and its configuration
+// are public and may be requested from any origin, since the widget runs on
+// customers' websites.
+func (h *WidgetHandler) RegisterRoutes(e *echo.Echo, ami *authMiddleware.AuthMiddleware) {
+	e.GET("/widget.js", h.GetScript)
+	e.GET("/api/v1/public/widget/config", h.GetConfig)
+
+	// Proactive trigger management - admin only
+	admin := e.Group("/api/v1/admin/chat-triggers")
+	admin.Use(ami.Authenticate, ami.RequireAdmin(), authMiddleware.UUIDParam("id", "chat trigger"))
+	admin.GET("", h.ListTriggers)
+	admin.POST("", h.CreateTrigger)
+	admin.GET("/:id", h.GetTrigger)
+	admin.PUT("/:id", h.UpdateTrigger)
+	admin.DELETE("/:id", h.DeleteTrigger)
+}
+
+// GetScript handles serving the embeddable widget script
+// @Summary Embeddable widget script
+// @Description JavaScript that renders the help-chat widget. Embed with <script src="https://helpchat.example.com/widget.js" async></script>; the script talks to the server it was loaded from.
+// @Tags widget
+// @Produce application/javascript
+// @Success 200 {string} string "Widget script"
+// @Router /widget.js [get]
+func (h *WidgetHandler) GetScript(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.Blob(http.StatusOK, "application/javascript; charset=utf-8", widgetScript)
+}
+
+// GetConfig handles retrieving the widget bootstrap configuration
+// @Summary Widget configuration
+// @Description Branding, office hours and queue availability used by the embeddable widget. When the page being visited is given, the highest priority matching proactive trigger is returned for the widget to open with after its delay.
+// @Tags widget
+// @Produce json
+// @Param page_url query string false "URL of the page the widget is on"
+// @Param page_title query string false "Title of the page the widget is on"
+// @Param returning query bool false "Whether the visitor has been to the site before"
+// @Success 200 {object} models.WidgetConfigResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/public/widget/config [get]
+func (h *WidgetHandler) GetConfig(c echo.Context) error {
+	returning, _ := strconv.ParseBool(c.QueryParam("returning"))
+	visit := &models.WidgetVisit{
+		PageURL:   c.QueryParam("page_url"),
+		PageTitle: c.QueryParam("page_title"),
+		Returning: returning,
+	}
+	cfg, err := h.widgetService.GetConfig(c.Request().Context(), time.Now(), visit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load widget configuration"))
+	}
+
+	// The configuration holds nothing private, so any embedding site may read it.
+	// Origins listed in the CORS configuration keep their credentialed response.
+	if c.Response().Header().Get(echo.HeaderAccessControlAllowOrigin) == "" {
+		c.Response().Header().Set(echo.HeaderAccessControlAllowOrigin, "*")
+	}
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	return c.JSON(http.StatusOK, cfg)
+}
+
+// ListTriggers handles listing proactive chat triggers
+// @Summary List proactive chat triggers
+// @Description List every proactive chat trigger in evaluation order: highest priority first, then oldest first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.ChatTrigger
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/chat-triggers [get]
+// @Security ApiKeyAuth
+func (h *WidgetHandler) ListTriggers(c echo.Context) error {
+	triggers, err := h.widgetService.ListTriggers(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list chat triggers"))
+	}
+	return c.JSON(http.StatusOK, triggers)
+}
+
+// GetTrigger handles retrieving a proactive chat trigger
+// @Summary Get a proactive chat trigger
+// @Tags admin
+// @Produce json
+// @Param id path string true "Chat trigger ID"
+// @Success 200 {object} models.ChatTrigger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/chat-triggers/{id} [get]
+// @Security ApiKeyAuth
+func (h *WidgetHandler) GetTrigger(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	trigger, err := h.widgetService.GetTrigger(c.Request().Context(), id)
+	if err != nil {
+		return chatTriggerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, trigger)
+}
+
+// CreateTrigger handles adding a proactive chat trigger
+// @Summary Create a proactive chat trigger
+// @Description Open the widget by itself with a greeting on pages matching the URL pattern, after the visitor has spent the given time on the page, optionally only for new or returning visitors. The greeting may use the {{page_title}}, {{page_url}} and {{site_title}} placeholders.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param trigger body models.ChatTriggerRequest true "Trigger"
+// @Success 201 {object} models.ChatTrigger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/chat-triggers [post]
+// @Security ApiKeyAuth
+func (h *WidgetHandler) CreateTrigger(c echo.Context) error {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unauthorized"))
+	}
+
+	var req models.ChatTriggerRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	trigger, err := h.widgetService.CreateTrigger(c.Request().Context(), &req, userID)
+	if err != nil {
+		return chatTriggerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, trigger)
+}
+
+// UpdateTrigger handles replacing a proactive chat trigger
+// @Summary Update a proactive chat trigger
+// @Description Replace a trigger's rule, greeting and priority, or disable it
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Chat trigger ID"
+// @Param trigger body models.ChatTriggerRequest true "Trigger"
+// @Success 200 {object} models.ChatTrigger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/chat-triggers/{id} [put]
+// @Security ApiKeyAuth
+func (h *WidgetHandler) UpdateTrigger(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	var req models.ChatTriggerRequest
+	if err := c.Bind(&req); err != nil {
+		return bindErrorResponse(c, err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	}
+
+	trigger, err := h.widgetService.UpdateTrigger(c.Request().Context(), id, &req)
+	if err != nil {
+		return chatTriggerErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, trigger)
+}
+
+// DeleteTrigger handles removing a proactive chat trigger
+// @Summary Delete a proactive chat trigger
+// @Tags admin
+// @Param id path string true "Chat trigger ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/chat-triggers/{id} [delete]
+// @Security ApiKeyAuth
+func (h *WidgetHandler) DeleteTrigger(c echo.Context) error {
+	id := authMiddleware.ParamUUID(c, "id")
+
+	if err := h.widgetService.DeleteTrigger(c.Request().Context(), id); err != nil {
+		return chatTriggerErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// chatTriggerErrorResponse maps chat trigger service errors to HTTP responses
+func chatTriggerErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseFromError(err))
+	case errors.Is(err, services.ErrNotFound):
+		return c.JSON(http.StatusNotFound, models.NewErrorResponseFromError(err))
+	default:
+		return c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to process chat trigger request"))
+	}
+}
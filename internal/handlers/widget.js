@@ -0,0 +1,421 @@
+/*
+ * HelpChat embeddable widget.
+ *
+ * <script src="https://helpchat.example.com/widget.js" async></script>
+ *
+ * The widget talks to the server it was loaded from. Set data-api on the
+ * script tag to point it elsewhere.
+ */
+(function () {
+  "use strict";
+
+  if (window.HelpChatWidget) {
+    return;
+  }
+
+  var script = document.currentScript;
+  var base = (script && script.getAttribute("data-api")) || (script ? new URL(script.src).origin : "");
+
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      if (key === "style") {
+        Object.assign(node.style, attrs.style);
+      } else if (key.indexOf("on") === 0) {
+        node.addEventListener(key.slice(2), attrs[key]);
+      } else {
+        node.setAttribute(key, attrs[key]);
+      }
+    });
+    (children || []).forEach(function (child) {
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    });
+    return node;
+  }
+
+  var chat = JSON.parse(sessionStorage.getItem("helpchat.chat") || "null");
+
+  // token defaults to the current chat's; an ended chat passes its own
+  function request(method, path, body, token) {
+    var headers = body ? { "Content-Type": "application/json" } : {};
+    token = token || (chat && chat.token);
+    if (token) {
+      headers["X-Chat-Token"] = token;
+    }
+    return fetch(base + path, {
+      method: method,
+      headers: headers,
+      body: body ? JSON.stringify(body) : undefined,
+    }).then(function (res) {
+      if (!res.ok) {
+        throw new Error("HelpChat request failed: " + res.status);
+      }
+      return res.status === 204 ? null : res.json();
+    });
+  }
+
+  function availability(config) {
+    if (config.queue.available) {
+      return "We're online";
+    }
+    if (!config.office_hours.open_now && config.office_hours.next_open_at) {
+      return "We're away until " + new Date(config.office_hours.next_open_at).toLocaleString();
+    }
+    return "Chat with us and we'll get back to you";
+  }
+
+  function render(config, banners) {
+    var color = config.branding.primary_color;
+    var status = el("p", { style: { margin: "0 0 12px", fontSize: "13px", opacity: "0.8" } }, [availability(config)]);
+
+    var notices = banners.map(function (banner) {
+      return el("div", { style: { padding: "8px", marginBottom: "8px", borderRadius: "4px", background: "#fef3c7", fontSize: "13px" } }, [banner.message]);
+    });
+
+    var transcript = el("div", { style: { maxHeight: "40vh", overflowY: "auto", marginBottom: "8px", fontSize: "14px" } });
+    var typingNotice = el("div", { style: { display: "none", marginBottom: "8px", fontSize: "12px", fontStyle: "italic", opacity: "0.7" } }, ["An agent is typing…"]);
+    var input = el("input", { placeholder: "Type a message", "aria-label": "Message", style: { flex: "1", padding: "6px" } });
+    var poller = null;
+    var socket = null;
+    var typing = false;
+    var typingTimer = null;
+    var lastRead = null;
+
+    function send(frame) {
+      if (socket && socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(frame));
+      }
+    }
+
+    function stopTyping() {
+      clearTimeout(typingTimer);
+      if (typing) {
+        typing = false;
+        send({ type: "typing.stop" });
+      }
+    }
+
+    input.addEventListener("input", function () {
+      if (!typing) {
+        typing = true;
+        send({ type: "typing.start" });
+      }
+      clearTimeout(typingTimer);
+      typingTimer = setTimeout(stopTyping, 3000);
+    });
+
+    // connect follows the chat over a WebSocket, falling back to polling when it is unavailable
+    function connect() {
+      if (socket || !chat || !window.WebSocket) {
+        return;
+      }
+      var url = base.replace(/^http/, "ws") + "/api/v1/public/chats/" + chat.id + "/ws?token=" + encodeURIComponent(chat.token);
+      socket = new WebSocket(url);
+      socket.onopen = function () {
+        clearInterval(poller);
+        poller = null;
+      };
+      socket.onmessage = function (message) {
+        var event = JSON.parse(message.data);
+        if (event.type === "typing.start" || event.type === "typing.stop") {
+          typingNotice.style.display = event.type === "typing.start" ? "block" : "none";
+        } else if (event.type === "message" || event.type === "message.read") {
+          if (event.type === "message" && event.message.sender_type !== "VISITOR") {
+            typingNotice.style.display = "none";
+          }
+          refresh();
+        }
+      };
+      socket.onclose = function () {
+        socket = null;
+        typingNotice.style.display = "none";
+        if (chat && panel.style.display !== "none") {
+          startPolling();
+        }
+      };
+    }
+
+    function disconnect() {
+      if (socket) {
+        socket.onclose = null;
+        socket.close();
+        socket = null;
+      }
+    }
+
+    function startPolling() {
+      if (!poller) {
+        poller = setInterval(function () {
+          if (chat) {
+            refresh();
+          }
+        }, 3000);
+      }
+    }
+
+    var queueText = el("span", {}, []);
+    var queue = el("div", { style: { display: "none", padding: "8px", marginBottom: "8px", borderRadius: "4px", background: "#f3f4f6", fontSize: "13px" } }, [
+      queueText,
+      el("button", {
+        type: "button",
+        style: { display: "block", marginTop: "6px", background: "none", border: "0", padding: "0", color: color, textDecoration: "underline", cursor: "pointer" },
+        onclick: function () {
+          if (!chat) {
+            return;
+          }
+          request("POST", "/api/v1/public/chats/" + chat.id + "/ticket").then(show).catch(function () {
+            status.textContent = "Sorry, we couldn't pass your message on. Please try again.";
+          });
+        },
+      }, ["Leave a message instead"]),
+    ]);
+
+    // Once the chat ends the visitor can rate it and ask for the transcript
+    var ended = null;
+    var feedbackText = el("div", { style: { marginBottom: "6px" } }, ["How did we do?"]);
+    var ratings = el("div", { style: { display: "flex", gap: "4px", marginBottom: "6px" } }, [1, 2, 3, 4, 5].map(function (score) {
+      return el("button", {
+        type: "button",
+        "aria-label": "Rate " + score + " out of 5",
+        style: { flex: "1", padding: "4px", border: "1px solid " + color, borderRadius: "4px", background: "#fff", color: color, cursor: "pointer" },
+        onclick: function () {
+          if (!ended) {
+            return;
+          }
+          request("POST", "/api/v1/public/chats/" + ended.id + "/rating", { score: score }, ended.token).then(function () {
+            ratings.style.display = "none";
+            feedbackText.textContent = "Thanks for your feedback!";
+          }).catch(function () {
+            feedbackText.textContent = "Sorry, we couldn't save your rating.";
+          });
+        },
+      }, [String(score)]);
+    }));
+    var emailTranscript = el("button", {
+      type: "button",
+      style: { background: "none", border: "0", padding: "0", color: color, textDecoration: "underline", cursor: "pointer" },
+      onclick: function () {
+        if (!ended) {
+          return;
+        }
+        request("POST", "/api/v1/public/chats/" + ended.id + "/transcript", null, ended.token).then(function () {
+          emailTranscript.style.display = "none";
+          status.textContent = "We've emailed you a copy of this chat.";
+        }).catch(function () {
+          status.textContent = "Sorry, we couldn't email the transcript.";
+        });
+      },
+    }, ["Email me a transcript"]);
+    var feedback = el("div", { style: { display: "none", padding: "8px", marginBottom: "8px", borderRadius: "4px", background: "#f3f4f6", fontSize: "13px" } }, [feedbackText, ratings, emailTranscript]);
+
+    function minutes(seconds) {
+      var mins = Math.max(1, Math.round(seconds / 60));
+      return mins === 1 ? "about a minute" : "about " + mins + " minutes";
+    }
+
+    function showQueue(session) {
+      if (session.status !== "QUEUED") {
+        queue.style.display = "none";
+        return;
+      }
+      request("GET", "/api/v1/public/chats/" + chat.id + "/queue").then(function (info) {
+        if (info.status !== "QUEUED") {
+          queue.style.display = "none";
+          return;
+        }
+        var text = "You are number " + info.position + " in the queue.";
+        if (info.estimated_wait_seconds != null) {
+          text += " Estimated wait: " + minutes(info.estimated_wait_seconds) + ".";
+        }
+        queueText.textContent = text;
+        queue.style.display = "block";
+      }).catch(function (err) {
+        console.error(err);
+      });
+    }
+
+    function show(session) {
+      transcript.textContent = "";
+      var messages = session.messages || [];
+      var lastOwn = null;
+      var lastTheirs = null;
+      messages.forEach(function (message) {
+        if (message.sender_type === "VISITOR") {
+          lastOwn = message;
+        } else {
+          lastTheirs = message;
+        }
+      });
+      messages.forEach(function (message) {
+        var mine = message.sender_type === "VISITOR";
+        var system = message.sender_type === "SYSTEM";
+        transcript.appendChild(el("div", {
+          style: {
+            margin: "4px 0",
+            textAlign: mine ? "right" : system ? "center" : "left",
+            fontStyle: system ? "italic" : "normal",
+            whiteSpace: "pre-wrap",
+          },
+        }, [el("span", {
+          style: { display: "inline-block", padding: "6px 8px", borderRadius: "6px", background: mine ? color : system ? "transparent" : "#f3f4f6", color: mine ? "#fff" : "inherit" },
+        }, [message.content])]));
+        if (message === lastOwn && message.read_at) {
+          transcript.appendChild(el("div", { style: { textAlign: "right", fontSize: "11px", opacity: "0.6" } }, ["Seen"]));
+        }
+      });
+      transcript.scrollTop = transcript.scrollHeight;
+      showQueue(session);
+
+      // Let the agent know the latest reply has been seen
+      if (lastTheirs && lastTheirs.id !== lastRead && panel.style.display !== "none") {
+        lastRead = lastTheirs.id;
+        send({ type: "message.read", message_id: lastTheirs.id });
+      }
+
+      if (session.status === "ENDED") {
+        clearInterval(poller);
+        poller = null;
+        stopTyping();
+        disconnect();
+        sessionStorage.removeItem("helpchat.chat");
+        if (chat) {
+          ended = chat;
+          ratings.style.display = session.rated_at ? "none" : "flex";
+          emailTranscript.style.display = session.visitor_email && !session.transcript_sent_at ? "inline" : "none";
+          feedback.style.display = "block";
+        }
+        chat = null;
+        input.disabled = true;
+      }
+    }
+
+    function refresh() {
+      request("GET", "/api/v1/public/chats/" + chat.id).then(show).catch(function (err) {
+        console.error(err);
+      });
+    }
+
+    function open() {
+      if (chat) {
+        refresh();
+        connect();
+      } else {
+        request("POST", "/api/v1/public/chats", {}).then(function (result) {
+          chat = { id: result.session.id, token: result.token };
+          sessionStorage.setItem("helpchat.chat", JSON.stringify(chat));
+          show(result.session);
+          connect();
+        }).catch(function () {
+          status.textContent = "Sorry, chat is unavailable right now. Please try again later.";
+        });
+      }
+      startPolling();
+    }
+
+    var form = el("form", {
+      style: { display: "flex", gap: "4px" },
+      onsubmit: function (event) {
+        event.preventDefault();
+        var content = input.value.trim();
+        if (!content || !chat) {
+          return;
+        }
+        input.value = "";
+        stopTyping();
+        request("POST", "/api/v1/public/chats/" + chat.id + "/messages", { content: content }).then(show).catch(function () {
+          status.textContent = "Sorry, your message could not be sent. Please try again.";
+        });
+      },
+    }, [input, el("button", { type: "submit", style: { background: color, color: "#fff", border: "0", padding: "6px 12px", borderRadius: "4px", cursor: "pointer" } }, ["Send"])]);
+
+    var header = [];
+    if (config.branding.logo_url) {
+      header.push(el("img", { src: config.branding.logo_url, alt: "", style: { height: "24px", marginRight: "8px", verticalAlign: "middle" } }));
+    }
+    header.push(config.branding.title);
+
+    var panel = el("div", {
+      role: "dialog",
+      "aria-label": config.branding.title,
+      style: { display: "none", position: "fixed", right: "20px", bottom: "84px", width: "320px", maxHeight: "70vh", overflowY: "auto", background: "#fff", color: "#111", borderRadius: "8px", boxShadow: "0 8px 24px rgba(0,0,0,0.2)", fontFamily: "sans-serif", zIndex: "2147483647" },
+    }, [
+      el("div", { style: { background: color, color: "#fff", padding: "12px", borderRadius: "8px 8px 0 0", fontWeight: "bold" } }, header),
+      el("div", { style: { padding: "12px" } }, [status].concat(notices, [transcript, typingNotice, queue, feedback, form])),
+    ]);
+
+    var launcher = el("button", {
+      "aria-label": config.branding.title,
+      style: { position: "fixed", right: "20px", bottom: "20px", width: "56px", height: "56px", borderRadius: "50%", border: "0", background: color, color: "#fff", fontSize: "24px", cursor: "pointer", boxShadow: "0 4px 12px rgba(0,0,0,0.25)", zIndex: "2147483647" },
+      onclick: function () {
+        var opening = panel.style.display === "none";
+        panel.style.display = opening ? "block" : "none";
+        if (opening) {
+          open();
+        } else {
+          clearInterval(poller);
+          poller = null;
+          stopTyping();
+          disconnect();
+        }
+      },
+    }, ["?"]);
+
+    document.body.appendChild(panel);
+    document.body.appendChild(launcher);
+
+    // A proactive trigger opens the panel with its greeting once per visit; the
+    // chat itself starts when the visitor engages
+    var proactive = config.proactive;
+    if (proactive && !chat && sessionStorage.getItem("helpchat.proactive") !== proactive.trigger_id) {
+      setTimeout(function () {
+        if (chat || panel.style.display !== "none") {
+          return;
+        }
+        sessionStorage.setItem("helpchat.proactive", proactive.trigger_id);
+        transcript.textContent = "";
+        transcript.appendChild(el("div", { style: { margin: "4px 0" } }, [el("span", {
+          style: { display: "inline-block", padding: "6px 8px", borderRadius: "6px", background: "#f3f4f6" },
+        }, [proactive.greeting])]));
+        panel.style.display = "block";
+        input.addEventListener("focus", function engage() {
+          input.removeEventListener("focus", engage);
+          if (!chat && panel.style.display !== "none") {
+            open();
+          }
+        });
+      }, proactive.delay_seconds * 1000);
+    }
+  }
+
+  function start() {
+    var returning = false;
+    try {
+      returning = localStorage.getItem("helpchat.visited") === "1";
+      localStorage.setItem("helpchat.visited", "1");
+    } catch (err) {
+      // Storage may be blocked; treat the visitor as new
+    }
+    var visit = "?page_url=" + encodeURIComponent(location.href) +
+      "&page_title=" + encodeURIComponent(document.title) +
+      "&returning=" + returning;
+
+    Promise.all([
+      request("GET", "/api/v1/public/widget/config" + visit),
+      request("GET", "/api/v1/public/status-banners").catch(function () {
+        return [];
+      }),
+    ]).then(function (results) {
+      render(results[0], results[1]);
+    }).catch(function (err) {
+      console.error(err);
+    });
+  }
+
+  window.HelpChatWidget = { base: base };
+
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", start);
+  } else {
+    start();
+  }
+})();
@@ -2,22 +2,29 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/timezone"
 
 	"github.com/labstack/echo/v4"
 )
 
 // AuthMiddleware provides JWT authentication middleware
 type AuthMiddleware struct {
-	authService *services.AuthService
+	authService  *services.AuthService
+	usageService *services.UsageService
 }
 
-// NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
+// NewAuthMiddleware creates a new authentication middleware. Authenticated
+// requests count against the user's daily quota unless usageService is nil.
+func NewAuthMiddleware(authService *services.AuthService, usageService *services.UsageService) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		authService:  authService,
+		usageService: usageService,
 	}
 }
 
@@ -40,53 +47,49 @@ func (m *AuthMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 		c.Set("user", user)
 		c.Set("user_id", user.ID.String())
 		c.Set("user_role", string(user.Role))
+		if user.Timezone != "" {
+			if location, err := time.LoadLocation(user.Timezone); err == nil {
+				c.SetRequest(c.Request().WithContext(timezone.NewContext(c.Request().Context(), location)))
+			}
+		}
+		// Agents practicing in a training sandbox only see its demo data
+		if user.SandboxID != nil {
+			c.SetRequest(c.Request().WithContext(repository.WithSandbox(c.Request().Context(), *user.SandboxID)))
+		}
+
+		if m.usageService != nil {
+			usage, allowed := m.usageService.Consume(user.ID)
+			setRateLimitHeaders(c, usage)
+			if !allowed {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(time.Until(usage.ResetsAt).Seconds())+1))
+				return c.JSON(http.StatusTooManyRequests, models.NewErrorResponse("Daily request quota exceeded"))
+			}
+		}
 
 		return next(c)
 	}
 }
 
+// setRateLimitHeaders reports the user's daily quota in X-RateLimit-* headers;
+// none are set when the quota is unlimited
+func setRateLimitHeaders(c echo.Context, usage *models.APIUsage) {
+	if usage.Limit == nil {
+		return
+	}
+	header := c.Response().Header()
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(*usage.Limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(*usage.Remaining, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(usage.ResetsAt.Unix(), 10))
+}
+
 // RequireRole creates middleware that requires a specific user role
 func (m *AuthMiddleware) RequireRole(requiredRole models.UserRole) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			user := c.Get("user").(*models.User)
-			if user == nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "user not found in context")
-			}
-
-			if user.Role != requiredRole {
-				return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
-			}
-
-			return next(c)
-		}
-	}
+	return m.Require(Roles(requiredRole))
 }
 
 // RequireAnyRole creates middleware that requires any of the specified roles
 func (m *AuthMiddleware) RequireAnyRole(requiredRoles ...models.UserRole) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			user := c.Get("user").(*models.User)
-			if user == nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "user not found in context")
-			}
-
-			hasRole := false
-			for _, role := range requiredRoles {
-				if user.Role == role {
-					hasRole = true
-					break
-				}
-			}
-
-			if !hasRole {
-				return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
-			}
-
-			return next(c)
-		}
-	}
+	return m.Require(Roles(requiredRoles...))
 }
 
 // RequireAdmin creates middleware that requires admin privileges
@@ -109,112 +112,31 @@ func (m *AuthMiddleware) RequireSpecificRole(role models.UserRole) echo.Middlewa
 	return m.RequireRole(role)
 }
 
-type OwnerIdGetter func(c echo.Context) (string, error)
-
 // RequireOwnerOrAdmin creates middleware that allows access if user owns the resource or is admin
 func (m *AuthMiddleware) RequireOwnerOrAdmin(ownerIDGetter OwnerIdGetter) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			user := c.Get("user").(*models.User)
-			if user == nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "user not found in context")
-			}
-
-			// Admin can access anything
-			if user.IsAdmin() {
-				return next(c)
-			}
-
-			// Get the owner ID of the resource
-			ownerID, err := ownerIDGetter(c)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusBadRequest, "unable to determine resource ownership")
-			}
-
-			// Check if user is the owner
-			if user.ID.String() == ownerID {
-				return next(c)
-			}
-
-			return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
-		}
-	}
+	return m.Require(AnyOf(Roles(models.RoleAdministrator, models.RoleManager), Owner(ownerIDGetter)))
 }
 
 // RequirePermission creates middleware that checks for specific permissions
 func (m *AuthMiddleware) RequirePermission(permission string) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			user := c.Get("user").(*models.User)
-			if user == nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "user not found in context")
-			}
+	return m.Require(Permissions(permission))
+}
 
-			// For now, we'll use role-based permissions
-			// In a more complex system, you might have a separate permissions table
-			hasPermission := m.HasPermission(user.Role, permission)
-			if !hasPermission {
-				return echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
-			}
+// RequireAnyPermission creates middleware that requires at least one of the specified permissions
+func (m *AuthMiddleware) RequireAnyPermission(permissions ...string) echo.MiddlewareFunc {
+	return m.Require(Permissions(permissions...))
+}
 
-			return next(c)
-		}
-	}
+// RequirePermissionOrOwner creates middleware that allows access if the user has
+// the permission, or owns the resource and has the matching ":own" permission
+func (m *AuthMiddleware) RequirePermissionOrOwner(permission string, ownerIDGetter OwnerIdGetter) echo.MiddlewareFunc {
+	return m.Require(AnyOf(
+		Permissions(permission),
+		AllOf(Permissions(permission+":own"), Owner(ownerIDGetter)),
+	))
 }
 
 // HasPermission checks if a role has a specific permission
 func (m *AuthMiddleware) HasPermission(role models.UserRole, permission string) bool {
-	// Define role-based permissions
-	permissions := map[models.UserRole][]string{
-		models.RoleEndUser: {
-			"ticket:create",
-			"ticket:read:own",
-			"ticket:update:own",
-		},
-		models.RoleSupportAgent: {
-			"ticket:create",
-			"ticket:read",
-			"ticket:update",
-			"ticket:assign",
-			"ticket:status:update",
-			"ticket:escalate",
-			"ticket:stats:read",
-		},
-		models.RoleManager: {
-			"ticket:create",
-			"ticket:read",
-			"ticket:update",
-			"ticket:assign",
-			"ticket:status:update",
-			"ticket:escalate",
-			"ticket:stats:read",
-			"ticket:delete",
-			"user:manage",
-		},
-		models.RoleAdministrator: {
-			"ticket:create",
-			"ticket:read",
-			"ticket:update",
-			"ticket:assign",
-			"ticket:status:update",
-			"ticket:escalate",
-			"ticket:stats:read",
-			"ticket:delete",
-			"user:manage",
-			"system:admin",
-		},
-	}
-
-	rolePermissions, exists := permissions[role]
-	if !exists {
-		return false
-	}
-
-	for _, perm := range rolePermissions {
-		if perm == permission {
-			return true
-		}
-	}
-
-	return false
+	return role.HasPermission(permission)
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Binder binds requests like echo.DefaultBinder, but reports an unknown enum
+// value such as a ticket status by listing the allowed values instead of the
+// decoder's own message
+type Binder struct {
+	echo.DefaultBinder
+}
+
+// NewBinder creates a new binder
+func NewBinder() *Binder {
+	return &Binder{}
+}
+
+// Bind binds path, query and body values into i
+func (b *Binder) Bind(i interface{}, c echo.Context) error {
+	err := b.DefaultBinder.Bind(i, c)
+	var enumErr *models.EnumValueError
+	if errors.As(err, &enumErr) {
+		return echo.NewHTTPError(http.StatusBadRequest, enumErr.Error()).SetInternal(enumErr)
+	}
+	return err
+}
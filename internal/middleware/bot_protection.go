@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/captcha"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// CaptchaTokenHeader carries the CAPTCHA response token produced by the client-side widget
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// BotProtection guards unauthenticated endpoints with per-IP rate limits and optional CAPTCHA verification
+type BotProtection struct {
+	verifier          captcha.Verifier
+	requestsPerMinute float64
+	burst             int
+}
+
+// NewBotProtection creates bot protection middleware from configuration
+func NewBotProtection(cfg *config.Config) (*BotProtection, error) {
+	verifier, err := captcha.NewVerifier(cfg.Captcha.Provider, cfg.Captcha.SecretKey, cfg.Captcha.MinScore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BotProtection{
+		verifier:          verifier,
+		requestsPerMinute: cfg.RateLimit.PublicRequestsPerMinute,
+		burst:             cfg.RateLimit.PublicBurst,
+	}, nil
+}
+
+// RateLimit creates middleware limiting requests per client IP. Each call
+// creates an independent limiter, so every route group gets its own budget.
+func (b *BotProtection) RateLimit() echo.MiddlewareFunc {
+	if b.requestsPerMinute <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	store := middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+		Rate:      rate.Limit(b.requestsPerMinute / 60),
+		Burst:     b.burst,
+		ExpiresIn: 10 * time.Minute,
+	})
+
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: store,
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.JSON(http.StatusForbidden, models.NewErrorResponse("Unable to identify client"))
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			c.Response().Header().Set("Retry-After", "60")
+			return c.JSON(http.StatusTooManyRequests, models.NewErrorResponse("Too many requests, please try again later"))
+		},
+	})
+}
+
+// RequireCaptcha creates middleware that verifies the CAPTCHA token sent in
+// the X-Captcha-Token header. It is a no-op when no provider is configured.
+func (b *BotProtection) RequireCaptcha() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if b.verifier == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			err := b.verifier.Verify(c.Request().Context(), c.Request().Header.Get(CaptchaTokenHeader), c.RealIP())
+			if err != nil {
+				if errors.Is(err, captcha.ErrMissingToken) || errors.Is(err, captcha.ErrVerificationFailed) {
+					return c.JSON(http.StatusBadRequest, models.NewErrorResponse("CAPTCHA verification failed"))
+				}
+				log.Printf("captcha verification error: %v", err)
+				return c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("CAPTCHA verification is unavailable"))
+			}
+
+			return next(c)
+		}
+	}
+}
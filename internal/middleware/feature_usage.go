@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+// FeatureUsage creates middleware that records every request to an API route
+// with the feature usage service: the route template, the signed-in user, the
+// status and how long it took. Requests that match no route are not recorded.
+func FeatureUsage(usageService *services.FeatureUsageService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if !strings.HasPrefix(route, "/api/") || errors.Is(err, echo.ErrNotFound) || errors.Is(err, echo.ErrMethodNotAllowed) {
+				return err
+			}
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var httpError *echo.HTTPError
+				if errors.As(err, &httpError) {
+					status = httpError.Code
+				}
+			}
+			user, _ := c.Get("user").(*models.User)
+			usageService.Record(start, c.Request().Method, route, user, status, time.Since(start))
+			return err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/timezone"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LocalTimeJSON is the JSON serializer that writes the timestamps in responses
+// to users who set a time zone as RFC 3339 in that zone, with its offset, so
+// clients can show them as they are. Other responses are left to next.
+type LocalTimeJSON struct {
+	next echo.JSONSerializer
+}
+
+// NewLocalTimeJSON creates the time zone converting serializer around next;
+// install it as the Echo instance's JSONSerializer
+func NewLocalTimeJSON(next echo.JSONSerializer) *LocalTimeJSON {
+	return &LocalTimeJSON{next: next}
+}
+
+// Serialize encodes i with its timestamps in the user's time zone
+func (l *LocalTimeJSON) Serialize(c echo.Context, i interface{}, indent string) error {
+	location, ok := timezone.FromContext(c.Request().Context())
+	if !ok {
+		return l.next.Serialize(c, i, indent)
+	}
+	switch i.(type) {
+	case models.ErrorResponse, *models.ErrorResponse:
+		return l.next.Serialize(c, i, indent)
+	}
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	if data, err = localizeTimestamps(data, location); err != nil {
+		return err
+	}
+	return l.next.Serialize(c, json.RawMessage(data), indent)
+}
+
+// Deserialize decodes the request body with next
+func (l *LocalTimeJSON) Deserialize(c echo.Context, i interface{}) error {
+	return l.next.Deserialize(c, i)
+}
+
+// localizeTimestamps rewrites the RFC 3339 timestamp values in a JSON document
+// into the given time zone, keeping everything else as it is
+func localizeTimestamps(data []byte, location *time.Location) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	// Each open object or array with the number of tokens written into it,
+	// which places the separators and tells object keys from values
+	type container struct {
+		object bool
+		tokens int
+	}
+	var stack []container
+	var out bytes.Buffer
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(delim))
+			continue
+		}
+
+		isKey := false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			switch {
+			case top.object && top.tokens%2 == 1:
+				out.WriteByte(':')
+			case top.tokens > 0:
+				out.WriteByte(',')
+			}
+			isKey = top.object && top.tokens%2 == 0
+			top.tokens++
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			out.WriteByte(byte(value))
+			stack = append(stack, container{object: value == '{'})
+		case string:
+			if !isKey {
+				if at, err := time.Parse(time.RFC3339Nano, value); err == nil {
+					value = at.In(location).Format(time.RFC3339Nano)
+				}
+			}
+			encoded, _ := json.Marshal(value)
+			out.Write(encoded)
+		case json.Number:
+			out.WriteString(value.String())
+		case bool:
+			if value {
+				out.WriteString("true")
+			} else {
+				out.WriteString("false")
+			}
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// paramContextPrefix namespaces parsed path parameters in the Echo context
+const paramContextPrefix = "param:"
+
+// UUIDParam creates middleware that parses the path parameter name as a UUID
+// and stores it for ParamUUID, responding 400 "Invalid <entity> ID" when it is
+// malformed. Routes without the parameter are passed through, so it can be
+// used on a whole group.
+func UUIDParam(name, entity string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !slices.Contains(c.ParamNames(), name) {
+				return next(c)
+			}
+
+			id, err := uuid.Parse(c.Param(name))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid "+entity+" ID"))
+			}
+			c.Set(paramContextPrefix+name, id)
+			return next(c)
+		}
+	}
+}
+
+// ParamUUID returns the path parameter parsed by UUIDParam. On a route without
+// that middleware it parses the parameter itself, returning uuid.Nil when it is
+// malformed.
+func ParamUUID(c echo.Context, name string) uuid.UUID {
+	if id, ok := c.Get(paramContextPrefix + name).(uuid.UUID); ok {
+		return id
+	}
+	id, _ := uuid.Parse(c.Param(name))
+	return id
+}
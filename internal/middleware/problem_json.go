@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationProblemJSON is the RFC 9457 problem details media type
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemJSON is the JSON serializer that negotiates the error format. Clients
+// that list application/problem+json in Accept get RFC 9457 problem details in
+// place of models.ErrorResponse; everyone else keeps the legacy format until
+// they opt in. How many error responses went out in each format is counted so
+// adoption can be tracked before the legacy format is retired.
+type ProblemJSON struct {
+	echo.DefaultJSONSerializer
+
+	problem atomic.Int64
+	legacy  atomic.Int64
+}
+
+// NewProblemJSON creates the error format negotiating serializer; install it as
+// the Echo instance's JSONSerializer
+func NewProblemJSON() *ProblemJSON {
+	return &ProblemJSON{}
+}
+
+// Serialize encodes i, converting error responses to problem details for
+// clients that accept them
+func (p *ProblemJSON) Serialize(c echo.Context, i interface{}, indent string) error {
+	var errorResponse *models.ErrorResponse
+	switch v := i.(type) {
+	case models.ErrorResponse:
+		errorResponse = &v
+	case *models.ErrorResponse:
+		errorResponse = v
+	default:
+		return p.DefaultJSONSerializer.Serialize(c, i, indent)
+	}
+
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	if !acceptsProblemJSON(c.Request().Header.Get(echo.HeaderAccept)) {
+		p.legacy.Add(1)
+		return p.DefaultJSONSerializer.Serialize(c, i, indent)
+	}
+
+	p.problem.Add(1)
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return p.DefaultJSONSerializer.Serialize(c, newProblemDetails(c, errorResponse), indent)
+}
+
+// Counts returns how many error responses were sent as problem details and in
+// the legacy format
+func (p *ProblemJSON) Counts() (problem, legacy int64) {
+	return p.problem.Load(), p.legacy.Load()
+}
+
+// newProblemDetails converts an error response to problem details
+func newProblemDetails(c echo.Context, errorResponse *models.ErrorResponse) *models.ProblemDetails {
+	status := c.Response().Status
+	details := &models.ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    strings.Join(errorResponse.Messages, "; "),
+		Instance:  c.Request().URL.Path,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if len(errorResponse.Messages) > 1 {
+		details.Errors = errorResponse.Messages
+	}
+	return details
+}
+
+// acceptsProblemJSON reports whether an Accept header lists the problem details
+// media type with a non-zero quality
+func acceptsProblemJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != MIMEApplicationProblemJSON {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if quality, err := strconv.ParseFloat(q, 64); err == nil && quality == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OwnerIdGetter returns the ID of the user who owns the requested resource
+type OwnerIdGetter func(c echo.Context) (string, error)
+
+// Requirement decides whether the signed-in user may make a request. The
+// Require* middleware are all built from requirements, so every route is
+// authorized by the same rules, and the rules a route enforces can be read
+// back from its middleware for the API documentation.
+type Requirement struct {
+	// check returns nil to let the request through, or the error to answer
+	// with: 403 when the user is refused, 400 when the request does not say
+	// enough to tell
+	check func(c echo.Context, user *models.User) error
+	// grants reports whether a user of the role can meet the requirement,
+	// assuming they own the resource
+	grants func(role models.UserRole) bool
+	// owner is set when meeting the requirement can depend on owning the resource
+	owner bool
+	text  string
+	// compound is set on requirements joining others, bracketed when nested
+	compound bool
+}
+
+// String describes the requirement, e.g. "permission report:read"
+func (r Requirement) String() string {
+	return r.text
+}
+
+var (
+	errNotSignedIn     = echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+	errForbidden       = echo.NewHTTPError(http.StatusForbidden, "insufficient permissions")
+	errOwnerUnresolved = echo.NewHTTPError(http.StatusBadRequest, "unable to determine resource ownership")
+)
+
+// describeKey is the context key under which Describe collects requirements
+const describeKey = "authorization.describe"
+
+// Require creates middleware that lets a request through when the signed-in
+// user meets every requirement, in order. It must run after Authenticate;
+// without a signed-in user it answers 401.
+func (m *AuthMiddleware) Require(requirements ...Requirement) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if collected, ok := c.Get(describeKey).(*[]Requirement); ok {
+				*collected = append(*collected, requirements...)
+				return nil
+			}
+
+			user, ok := c.Get("user").(*models.User)
+			if !ok || user == nil {
+				return errNotSignedIn
+			}
+			for _, requirement := range requirements {
+				if err := requirement.check(c, user); err != nil {
+					return err
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// Roles requires the user to have one of the roles
+func Roles(roles ...models.UserRole) Requirement {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	text := "role " + names[0]
+	if len(roles) > 1 {
+		text = "one of roles " + strings.Join(names, ", ")
+	}
+	return Requirement{
+		check: func(c echo.Context, user *models.User) error {
+			if !slices.Contains(roles, user.Role) {
+				return errForbidden
+			}
+			return nil
+		},
+		grants: func(role models.UserRole) bool { return slices.Contains(roles, role) },
+		text:   text,
+	}
+}
+
+// Permissions requires the user's role to be granted one of the permissions
+func Permissions(permissions ...string) Requirement {
+	text := "permission " + permissions[0]
+	if len(permissions) > 1 {
+		text = "one of permissions " + strings.Join(permissions, ", ")
+	}
+	grants := func(role models.UserRole) bool {
+		return slices.ContainsFunc(permissions, role.HasPermission)
+	}
+	return Requirement{
+		check: func(c echo.Context, user *models.User) error {
+			if !grants(user.Role) {
+				return errForbidden
+			}
+			return nil
+		},
+		grants: grants,
+		text:   text,
+	}
+}
+
+// Owner requires the user to own the requested resource
+func Owner(ownerIDGetter OwnerIdGetter) Requirement {
+	return Requirement{
+		check: func(c echo.Context, user *models.User) error {
+			ownerID, err := ownerIDGetter(c)
+			if err != nil {
+				return errOwnerUnresolved
+			}
+			if user.ID.String() != ownerID {
+				return errForbidden
+			}
+			return nil
+		},
+		grants: func(role models.UserRole) bool { return true },
+		owner:  true,
+		text:   "owner of the resource",
+	}
+}
+
+// AllOf requires every one of the requirements, stopping at the first unmet
+func AllOf(requirements ...Requirement) Requirement {
+	return Requirement{
+		check: func(c echo.Context, user *models.User) error {
+			for _, requirement := range requirements {
+				if err := requirement.check(c, user); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		grants: func(role models.UserRole) bool {
+			for _, requirement := range requirements {
+				if !requirement.grants(role) {
+					return false
+				}
+			}
+			return true
+		},
+		owner:    slices.ContainsFunc(requirements, func(r Requirement) bool { return r.owner }),
+		text:     joinRequirements(requirements, " and "),
+		compound: len(requirements) > 1,
+	}
+}
+
+// AnyOf requires one of the requirements, trying them in order. When none is
+// met it fails with the last one's error, so put the most specific last.
+func AnyOf(requirements ...Requirement) Requirement {
+	return Requirement{
+		check: func(c echo.Context, user *models.User) error {
+			err := error(errForbidden)
+			for _, requirement := range requirements {
+				if err = requirement.check(c, user); err == nil {
+					return nil
+				}
+			}
+			return err
+		},
+		grants: func(role models.UserRole) bool {
+			return slices.ContainsFunc(requirements, func(r Requirement) bool { return r.grants(role) })
+		},
+		owner:    slices.ContainsFunc(requirements, func(r Requirement) bool { return r.owner }),
+		text:     joinRequirements(requirements, " or "),
+		compound: len(requirements) > 1,
+	}
+}
+
+// joinRequirements describes requirements joined by sep, bracketing those
+// that join others in turn
+func joinRequirements(requirements []Requirement, sep string) string {
+	texts := make([]string, len(requirements))
+	for i, requirement := range requirements {
+		texts[i] = requirement.text
+		if requirement.compound {
+			texts[i] = "(" + texts[i] + ")"
+		}
+	}
+	return strings.Join(texts, sep)
+}
+
+// RouteAuthorization is what a route requires of its callers
+type RouteAuthorization struct {
+	// Roles are the roles whose users can be let through
+	Roles []models.UserRole `json:"roles"`
+	// Rules describe the requirements every caller must meet
+	Rules []string `json:"rules"`
+	// Owner is set when some roles are only let through for resources they own
+	Owner bool `json:"owner,omitempty"`
+}
+
+// Describe reads what a route requires from its middleware, as passed to
+// echo's OnAddRouteHandler. It returns nil for a route that does not
+// authenticate its callers.
+func (m *AuthMiddleware) Describe(middleware []echo.MiddlewareFunc) *RouteAuthorization {
+	authenticate := reflect.ValueOf(m.Authenticate).Pointer()
+	require := reflect.ValueOf(m.Require()).Pointer()
+
+	authenticated := false
+	var requirements []Requirement
+	probe := echo.New().NewContext(nil, nil)
+	probe.Set(describeKey, &requirements)
+	for _, mw := range middleware {
+		switch reflect.ValueOf(mw).Pointer() {
+		case authenticate:
+			authenticated = true
+		case require:
+			// The probe makes Require report its requirements instead of checking them
+			mw(nil)(probe)
+		}
+	}
+	if !authenticated && len(requirements) == 0 {
+		return nil
+	}
+
+	authorization := &RouteAuthorization{Roles: []models.UserRole{}, Rules: []string{}}
+	for _, requirement := range requirements {
+		authorization.Rules = append(authorization.Rules, requirement.text)
+		authorization.Owner = authorization.Owner || requirement.owner
+	}
+	if !authenticated {
+		// Require refuses everyone who is not signed in
+		return authorization
+	}
+	for _, role := range models.UserRoles {
+		if !slices.ContainsFunc(requirements, func(r Requirement) bool { return !r.grants(role) }) {
+			authorization.Roles = append(authorization.Roles, role)
+		}
+	}
+	return authorization
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+
+	"github.com/labstack/echo/v4"
+)
+
+// responseCachePrefix namespaces cached responses in the shared cache
+const responseCachePrefix = "http:"
+
+// ResponseCache serves rarely changing public reads, such as knowledge articles,
+// from a server-side cache and lets clients revalidate them with ETags
+type ResponseCache struct {
+	cache  cache.Cache
+	ttl    time.Duration
+	maxAge int
+}
+
+// NewResponseCache creates response caching middleware from configuration
+func NewResponseCache(cache cache.Cache, cfg *config.Config) *ResponseCache {
+	ttl, err := time.ParseDuration(cfg.HTTPCache.TTL)
+	if err != nil || ttl < 0 {
+		ttl = 5 * time.Minute // fallback
+	}
+	return &ResponseCache{
+		cache:  cache,
+		ttl:    ttl,
+		maxAge: cfg.HTTPCache.MaxAge,
+	}
+}
+
+// Cached caches successful GET responses under group, keyed by path. Requests
+// with a query string, such as searches, are too varied to be worth keeping
+// and only get an ETag.
+func (rc *ResponseCache) Cached(group string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet {
+				return next(c)
+			}
+
+			key := responseCachePrefix + group + ":" + req.URL.Path
+			store := rc.ttl > 0 && req.URL.RawQuery == ""
+			if store {
+				if value, ok := rc.cache.Get(key); ok {
+					// Stored as the ETag, content type and body, one per line
+					parts := strings.SplitN(value, "\n", 3)
+					if len(parts) == 3 {
+						c.Response().Header().Set(echo.HeaderContentType, parts[1])
+						return rc.write(c, http.StatusOK, parts[0], []byte(parts[2]))
+					}
+				}
+			}
+
+			// Buffer the response so its ETag can be sent ahead of the body
+			res := c.Response()
+			original := res.Writer
+			buffer := &bufferedResponse{ResponseWriter: original, status: http.StatusOK}
+			res.Writer = buffer
+			err := next(c)
+			res.Writer = original
+			if err != nil || !res.Committed {
+				return err
+			}
+			if buffer.status != http.StatusOK {
+				original.WriteHeader(buffer.status)
+				_, err = original.Write(buffer.body.Bytes())
+				return err
+			}
+
+			sum := sha256.Sum256(buffer.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			if store {
+				rc.cache.Set(key, etag+"\n"+res.Header().Get(echo.HeaderContentType)+"\n"+buffer.body.String(), rc.ttl)
+			}
+			res.Committed, res.Size = false, 0
+			return rc.write(c, http.StatusOK, etag, buffer.body.Bytes())
+		}
+	}
+}
+
+// Invalidate drops the cached responses of a group after its data changes
+func (rc *ResponseCache) Invalidate(group string) {
+	for key := range rc.cache.Scan(responseCachePrefix + group + ":") {
+		rc.cache.Delete(key)
+	}
+}
+
+// write sends a cacheable response, or 304 Not Modified when the client already has it
+func (rc *ResponseCache) write(c echo.Context, status int, etag string, body []byte) error {
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(rc.maxAge))
+	if etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		header.Del(echo.HeaderContentType)
+		header.Del(echo.HeaderContentLength)
+		return c.NoContent(http.StatusNotModified)
+	}
+	header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+	c.Response().WriteHeader(status)
+	_, err := c.Response().Write(body)
+	return err
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponse holds a handler's response body instead of sending it
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status instead of sending it
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// Write buffers the body
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// tokenFailureStatuses are the responses that mean a presented token was not accepted
+var tokenFailureStatuses = map[int]bool{
+	http.StatusUnauthorized: true,
+	http.StatusForbidden:    true,
+	http.StatusNotFound:     true,
+}
+
+// TokenLockout temporarily blocks guessing on public endpoints that grant
+// access by token, such as share links, visitor chats and password reset and
+// email verification links. Failed attempts are
+// counted both per client IP and per token, so neither spreading guesses over
+// many tokens nor over many addresses gets around the limit.
+type TokenLockout struct {
+	auditService *services.AuditService
+	maxFailures  int
+	window       time.Duration
+	blockFor     time.Duration
+
+	mu       sync.Mutex
+	attempts map[string]*tokenAttempts
+}
+
+// tokenAttempts tracks the recent failures of one client IP or token
+type tokenAttempts struct {
+	failures     int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+// NewTokenLockout creates token lockout middleware from configuration
+func NewTokenLockout(auditService *services.AuditService, cfg *config.Config) *TokenLockout {
+	window, err := time.ParseDuration(cfg.Security.TokenFailureWindow)
+	if err != nil || window <= 0 {
+		window = 15 * time.Minute // fallback
+	}
+	blockFor, err := time.ParseDuration(cfg.Security.TokenLockoutDuration)
+	if err != nil || blockFor <= 0 {
+		blockFor = 15 * time.Minute // fallback
+	}
+	return &TokenLockout{
+		auditService: auditService,
+		maxFailures:  cfg.Security.TokenMaxFailures,
+		window:       window,
+		blockFor:     blockFor,
+		attempts:     make(map[string]*tokenAttempts),
+	}
+}
+
+// Guard counts the failed attempts on a route whose token (or the ID it is
+// checked against) is the path parameter param. Once the client IP or the
+// token reaches the configured number of failures within the window it is
+// refused with 429 until the block expires, and the lockout is audited.
+func (l *TokenLockout) Guard(purpose, param string) echo.MiddlewareFunc {
+	return l.guard(purpose, func(c echo.Context) string {
+		return c.Param(param)
+	})
+}
+
+// GuardBody is Guard for routes that take their token as the string field of
+// a JSON request body, such as password reset and email verification
+func (l *TokenLockout) GuardBody(purpose, field string) echo.MiddlewareFunc {
+	return l.guard(purpose, func(c echo.Context) string {
+		return bodyField(c, field)
+	})
+}
+
+// guard counts the failed attempts on the token tokenOf reads from a request
+func (l *TokenLockout) guard(purpose string, tokenOf func(c echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if l.maxFailures <= 0 {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			token := tokenOf(c)
+			keys := map[string]string{
+				"ip":    purpose + ":ip:" + c.RealIP(),
+				"token": purpose + ":token:" + fingerprint(token),
+			}
+
+			if wait := l.blocked(time.Now(), keys); wait > 0 {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				return c.JSON(http.StatusTooManyRequests, models.NewErrorResponse("Too many failed attempts, please try again later"))
+			}
+
+			err := next(c)
+			status := c.Response().Status
+			var httpErr *echo.HTTPError
+			if err != nil && errors.As(err, &httpErr) {
+				status = httpErr.Code
+			}
+			if tokenFailureStatuses[status] {
+				l.fail(c.Request().Context(), time.Now(), purpose, c.RealIP(), token, keys)
+			}
+			return err
+		}
+	}
+}
+
+// blocked returns how long any of the keys remains blocked
+func (l *TokenLockout) blocked(now time.Time, keys map[string]string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range keys {
+		if attempts, ok := l.attempts[key]; ok {
+			wait = max(wait, attempts.blockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// fail counts a failed attempt against each key, blocking and auditing the
+// keys that reach the limit
+func (l *TokenLockout) fail(ctx context.Context, now time.Time, purpose, ip, token string, keys map[string]string) {
+	l.mu.Lock()
+	l.prune(now)
+	var locked []string
+	for kind, key := range keys {
+		attempts, ok := l.attempts[key]
+		if !ok || now.Sub(attempts.windowStart) > l.window {
+			attempts = &tokenAttempts{windowStart: now}
+			l.attempts[key] = attempts
+		}
+		attempts.failures++
+		if attempts.failures >= l.maxFailures && !attempts.blockedUntil.After(now) {
+			attempts.blockedUntil = now.Add(l.blockFor)
+			locked = append(locked, kind)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, kind := range locked {
+		l.audit(ctx, now, purpose, kind, ip, token)
+	}
+}
+
+// audit records a lockout. The token itself is never stored, only a fingerprint
+// that lets repeated lockouts of the same token be correlated.
+func (l *TokenLockout) audit(ctx context.Context, now time.Time, purpose, kind, ip, token string) {
+	if l.auditService == nil {
+		return
+	}
+
+	// Visitor chats are guarded by chat ID, which identifies the entity
+	var entityID *uuid.UUID
+	if id, err := uuid.Parse(token); err == nil {
+		entityID = &id
+	}
+	details := map[string]any{
+		"purpose":       purpose,
+		"locked":        kind,
+		"ip":            ip,
+		"token":         fingerprint(token),
+		"failures":      l.maxFailures,
+		"blocked_until": now.Add(l.blockFor).UTC(),
+	}
+	if err := l.auditService.Record(ctx, nil, models.AuditActionTokenLockout, purpose, entityID, details); err != nil {
+		log.Printf("failed to audit %s token lockout for %s: %v", purpose, ip, err)
+	}
+}
+
+// prune forgets attempts whose window and block have both passed; l.mu must be held
+func (l *TokenLockout) prune(now time.Time) {
+	for key, attempts := range l.attempts {
+		if now.Sub(attempts.windowStart) > l.window && !attempts.blockedUntil.After(now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// bodyField reads the string field of a JSON request body, putting the body
+// back for the handler to bind
+func bodyField(c echo.Context, field string) string {
+	req := c.Request()
+	if req.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	value, _ := fields[field].(string)
+	return value
+}
+
+// fingerprint identifies a token without revealing it
+func fingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
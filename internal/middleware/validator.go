@@ -32,20 +32,7 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 
 // validateUserRole validates that a UserRole is one of the allowed values
 func validateUserRole(fl validator.FieldLevel) bool {
-	role := fl.Field().String()
-	allowedRoles := []string{
-		string(models.RoleEndUser),
-		string(models.RoleSupportAgent),
-		string(models.RoleAdministrator),
-		string(models.RoleManager),
-	}
-
-	for _, allowedRole := range allowedRoles {
-		if role == allowedRole {
-			return true
-		}
-	}
-	return false
+	return models.UserRole(fl.Field().String()).IsValid()
 }
 
 // ValidationError represents a validation error
@@ -121,7 +108,11 @@ func getValidationMessage(field, tag, value interface{}) string {
 	case "max":
 		return fieldStr + " must be at most " + toString(value) + " characters"
 	case "user_role":
-		return fieldStr + " must be one of: END_USER, SUPPORT_AGENT, ADMINISTRATOR, MANAGER"
+		roles := make([]string, len(models.UserRoles))
+		for i, role := range models.UserRoles {
+			roles[i] = string(role)
+		}
+		return fieldStr + " must be one of: " + strings.Join(roles, ", ")
 	default:
 		return fieldStr + " failed validation: " + tagStr
 	}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Alertmanager alert statuses
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertmanagerPayload is a Prometheus Alertmanager webhook notification about
+// the alerts of one alert group
+type AlertmanagerPayload struct {
+	Version  string `json:"version" example:"4"`
+	GroupKey string `json:"groupKey" example:"{}:{alertname=\"HighCPU\"}"`
+	// Status is resolved once every alert of the group is
+	Status            string              `json:"status" example:"firing"`
+	Receiver          string              `json:"receiver" example:"helpchat"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL" example:"http://alertmanager:9093"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert is one alert of an Alertmanager notification
+type AlertmanagerAlert struct {
+	Status       string            `json:"status" example:"firing"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	// Fingerprint identifies the alert across notifications
+	Fingerprint string `json:"fingerprint" example:"a1b2c3d4e5f60718"`
+}
+
+// InboundAlert is a monitoring alert an inbound integration was notified
+// about, with the ticket of its alert group
+type InboundAlert struct {
+	ID            uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	IntegrationID uuid.UUID `json:"integration_id" gorm:"type:char(36);not null;uniqueIndex:idx_inbound_alerts_fingerprint"`
+	Fingerprint   string    `json:"fingerprint" gorm:"not null;size:64;uniqueIndex:idx_inbound_alerts_fingerprint"`
+	GroupKey      string    `json:"group_key" gorm:"not null;size:1000;index"`
+	TicketID      uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	Name          string    `json:"name" gorm:"size:255"`
+	Firing        bool      `json:"firing" gorm:"not null"`
+	StartsAt      time.Time `json:"starts_at"`
+	// EndsAt is when the alert resolved
+	EndsAt    *time.Time `json:"ends_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the InboundAlert model
+func (InboundAlert) TableName() string {
+	return "inbound_alerts"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an inbound alert
+func (a *InboundAlert) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// AlertmanagerResult reports what an Alertmanager notification did
+type AlertmanagerResult struct {
+	// TicketID is unset when the notification only resolved alerts that never
+	// opened a ticket
+	TicketID *uuid.UUID `json:"ticket_id,omitempty"`
+	// Created is set when the notification opened the ticket
+	Created bool         `json:"created"`
+	Status  TicketStatus `json:"status,omitempty"`
+	// Firing counts the alerts of the ticket still firing
+	Firing int64 `json:"firing"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Asset is a tracked device or configuration item that tickets can be linked to
+type Asset struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	Name         string     `json:"name" gorm:"not null;size:255"`
+	Type         string     `json:"type" gorm:"not null;size:50;index"`
+	SerialNumber *string    `json:"serial_number" gorm:"size:100;uniqueIndex"`
+	OwnerID      *uuid.UUID `json:"owner_id" gorm:"type:char(36);index"`
+	Notes        string     `json:"notes" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Owner *User `json:"owner,omitempty" gorm:"foreignKey:OwnerID"`
+}
+
+// TableName specifies the table name for the Asset model
+func (Asset) TableName() string {
+	return "assets"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an asset
+func (a *Asset) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// TicketAsset links a ticket to an asset
+type TicketAsset struct {
+	TicketID  uuid.UUID `json:"ticket_id" gorm:"type:char(36);primaryKey"`
+	AssetID   uuid.UUID `json:"asset_id" gorm:"type:char(36);primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the TicketAsset model
+func (TicketAsset) TableName() string {
+	return "ticket_assets"
+}
+
+// CreateAssetRequest represents a request to register an asset
+type CreateAssetRequest struct {
+	Name         string     `json:"name" validate:"required,min=1,max=255"`
+	Type         string     `json:"type" validate:"required,min=1,max=50"`
+	SerialNumber *string    `json:"serial_number" validate:"omitempty,max=100"`
+	OwnerID      *uuid.UUID `json:"owner_id"`
+	Notes        string     `json:"notes"`
+}
+
+// UpdateAssetRequest represents a request to update an asset
+type UpdateAssetRequest struct {
+	Name         *string    `json:"name" validate:"omitempty,min=1,max=255"`
+	Type         *string    `json:"type" validate:"omitempty,min=1,max=50"`
+	SerialNumber *string    `json:"serial_number" validate:"omitempty,max=100"`
+	OwnerID      *uuid.UUID `json:"owner_id"`
+	Notes        *string    `json:"notes"`
+}
+
+// AssetFilter represents filters for listing assets
+type AssetFilter struct {
+	Type    string     `query:"type"`
+	OwnerID *uuid.UUID `query:"owner_id"`
+	Search  string     `query:"search"`
+}
+
+// LinkAssetRequest represents a request to link a ticket to an asset
+type LinkAssetRequest struct {
+	AssetID uuid.UUID `json:"asset_id" validate:"required"`
+}
+
+// AssetTicketCount reports how many tickets have been raised against an asset
+type AssetTicketCount struct {
+	AssetID      uuid.UUID `json:"asset_id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	SerialNumber *string   `json:"serial_number"`
+	TicketCount  int64     `json:"ticket_count"`
+	OpenCount    int64     `json:"open_count"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketAssignment records a ticket being assigned to an agent, or returned
+// to the pool
+type TicketAssignment struct {
+	ID       uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	TicketID uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	// AgentID is nil when the ticket was unassigned
+	AgentID *uuid.UUID `json:"agent_id" gorm:"type:char(36)"`
+	// PreviousAgentID is who the ticket was assigned to before, if anyone
+	PreviousAgentID *uuid.UUID `json:"previous_agent_id" gorm:"type:char(36)"`
+	AssignedByID    uuid.UUID  `json:"assigned_by_id" gorm:"type:char(36);not null"`
+	// Note says why the ticket was unassigned, or why it was assigned when it
+	// was not assigned by hand, such as an out-of-office handover
+	Note      string    `json:"note,omitempty" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relationships
+	Agent      *User `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+	AssignedBy *User `json:"assigned_by,omitempty" gorm:"foreignKey:AssignedByID"`
+}
+
+// TableName specifies the table name for the TicketAssignment model
+func (TicketAssignment) TableName() string {
+	return "ticket_assignments"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an assignment
+func (a *TicketAssignment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ActivityType is the kind of change an activity feed entry describes
+type ActivityType string
+
+const (
+	ActivityAssigned    ActivityType = "ASSIGNED"
+	ActivityUnassigned  ActivityType = "UNASSIGNED"
+	ActivityEscalated   ActivityType = "ESCALATED"
+	ActivityDeEscalated ActivityType = "DE_ESCALATED"
+)
+
+// TicketActivity is one change in a ticket's activity feed
+type TicketActivity struct {
+	Type ActivityType `json:"type" example:"ASSIGNED"`
+	At   time.Time    `json:"at"`
+	// ActorID is who made the change
+	ActorID uuid.UUID `json:"actor_id"`
+	Actor   *User     `json:"actor,omitempty"`
+	// FromID is the previous assignee of an assignment or unassignment
+	FromID *uuid.UUID `json:"from_id,omitempty"`
+	// ToID is the new assignee, the user a ticket was escalated to, or the
+	// assignee an escalated ticket was handed back to
+	ToID *uuid.UUID `json:"to_id,omitempty"`
+	Note string     `json:"note,omitempty"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit actions
+const (
+	AuditActionRedaction   = "REDACTION"
+	AuditActionKeyRotation = "KEY_ROTATION"
+	// AuditActionTokenLockout is a client IP or token blocked after repeated bad attempts
+	AuditActionTokenLockout = "TOKEN_LOCKOUT"
+	// AuditActionConfigReload is an administrator reloading permissions and settings at runtime
+	AuditActionConfigReload = "CONFIG_RELOAD"
+	// AuditActionFailedTaskRetry is an administrator retrying failed background work
+	AuditActionFailedTaskRetry = "FAILED_TASK_RETRY"
+	// AuditActionFailedTaskDiscard is an administrator discarding failed background work
+	AuditActionFailedTaskDiscard = "FAILED_TASK_DISCARD"
+	// AuditActionRetention is a retention rule anonymizing or purging the data of closed tickets
+	AuditActionRetention = "RETENTION"
+	// AuditActionOffboarding is an administrator offboarding a staff member who left
+	AuditActionOffboarding = "OFFBOARDING"
+)
+
+// Audited entity types
+const (
+	AuditEntityComment    = "comment"
+	AuditEntityEncryption = "encryption"
+	AuditEntityShareLink  = "share_link"
+	AuditEntityChat       = "chat"
+	AuditEntitySystem     = "system"
+	AuditEntityUser       = "user"
+	// AuditEntityPasswordReset and AuditEntityEmailVerification are the
+	// account links whose tokens are locked out after repeated bad attempts
+	AuditEntityPasswordReset     = "password_reset"
+	AuditEntityEmailVerification = "email_verification"
+)
+
+// AuditLog records a security or compliance relevant event
+type AuditLog struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	ActorID    *uuid.UUID `json:"actor_id" gorm:"type:char(36);index"`
+	Action     string     `json:"action" gorm:"not null;size:50;index"`
+	EntityType string     `json:"entity_type" gorm:"not null;size:50"`
+	EntityID   *uuid.UUID `json:"entity_id" gorm:"type:char(36);index"`
+	// Details holds action specific JSON; it must never contain the sensitive values themselves
+	Details   string    `json:"details" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for the AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an audit log entry
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuditLogQuery represents filters and pagination for audit log queries
+type AuditLogQuery struct {
+	Action     string
+	EntityType string
+	EntityID   *uuid.UUID
+	ActorID    *uuid.UUID
+	Page       int
+	PageSize   int
+}
+
+// AuditLogListResponse represents a paginated list of audit log entries
+type AuditLogListResponse struct {
+	Entries    []AuditLog `json:"entries"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
+}
@@ -53,7 +53,8 @@ type AuthResponse struct {
 	User *User `json:"user"`
 }
 
-// PasswordResetToken represents a password reset token
+// PasswordResetToken represents a password reset token. Token holds the SHA-256
+// hash of the token emailed to the user, never the token itself.
 type PasswordResetToken struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    string    `json:"user_id" gorm:"type:char(36);not null"`
@@ -63,7 +64,8 @@ type PasswordResetToken struct {
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
-// EmailVerificationToken represents an email verification token
+// EmailVerificationToken represents an email verification token. Token holds the SHA-256
+// hash of the token emailed to the user, never the token itself.
 type EmailVerificationToken struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    string    `json:"user_id" gorm:"type:char(36);not null"`
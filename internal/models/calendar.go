@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// BusinessDaysResponse is the close of business a number of business days
+// after a given time
+type BusinessDaysResponse struct {
+	From time.Time `json:"from"`
+	Days int       `json:"days" example:"3"`
+	// Due is the close of business on the last of the business days
+	Due time.Time `json:"due"`
+	// Timezone is the office hours time zone the business days are counted in
+	Timezone string `json:"timezone" example:"Europe/London"`
+}
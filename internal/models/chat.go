@@ -0,0 +1,326 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChatStatus represents where a chat is in its lifecycle
+type ChatStatus string
+
+const (
+	// ChatBot is a chat still being handled by the auto-responder
+	ChatBot ChatStatus = "BOT"
+	// ChatQueued is a chat handed off and waiting for an agent
+	ChatQueued ChatStatus = "QUEUED"
+	// ChatActive is a chat an agent has accepted
+	ChatActive ChatStatus = "ACTIVE"
+	ChatEnded  ChatStatus = "ENDED"
+)
+
+// ChatBotStep is the question the auto-responder is waiting on
+type ChatBotStep string
+
+const (
+	BotStepName    ChatBotStep = "NAME"
+	BotStepEmail   ChatBotStep = "EMAIL"
+	BotStepIssue   ChatBotStep = "ISSUE"
+	BotStepSuggest ChatBotStep = "SUGGEST"
+)
+
+// ChatSenderType identifies who wrote a chat message
+type ChatSenderType string
+
+const (
+	SenderVisitor ChatSenderType = "VISITOR"
+	SenderBot     ChatSenderType = "BOT"
+	SenderAgent   ChatSenderType = "AGENT"
+	// SenderSystem marks events such as an agent joining or the chat ending
+	SenderSystem ChatSenderType = "SYSTEM"
+)
+
+// ChatSession is a live chat started from the widget
+type ChatSession struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:char(36);primary_key"`
+	Status       ChatStatus  `json:"status" gorm:"not null;default:'BOT';size:20;index"`
+	BotStep      ChatBotStep `json:"bot_step,omitempty" gorm:"size:20"`
+	VisitorName  string      `json:"visitor_name" gorm:"size:100"`
+	VisitorEmail string      `json:"visitor_email" gorm:"serializer:encrypted"`
+	Summary      string      `json:"summary" gorm:"type:text"`
+	RemoteIP     string      `json:"-" gorm:"size:45"`
+	AgentID      *uuid.UUID  `json:"agent_id" gorm:"type:char(36);index"`
+	// TeamID limits routing to a team's members after a transfer to the team
+	TeamID *uuid.UUID `json:"team_id" gorm:"type:char(36);index"`
+	// TransferredFromID is the agent who last transferred the chat away
+	TransferredFromID *uuid.UUID `json:"transferred_from_id" gorm:"type:char(36)"`
+	// TicketID is the ticket the conversation was turned into, if any
+	TicketID   *uuid.UUID `json:"ticket_id" gorm:"type:char(36)"`
+	QueuedAt   *time.Time `json:"queued_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	EndedAt    *time.Time `json:"ended_at" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// TranscriptRequested is the visitor opting in to an emailed transcript once the chat ends
+	TranscriptRequested bool       `json:"transcript_requested" gorm:"not null;default:false"`
+	TranscriptSentAt    *time.Time `json:"transcript_sent_at"`
+	// Rating is the visitor's 1-5 score for the chat, given after it ends
+	Rating        *int       `json:"rating"`
+	RatingComment string     `json:"rating_comment" gorm:"type:text"`
+	RatedAt       *time.Time `json:"rated_at" gorm:"index"`
+
+	// Relationships
+	Agent        *User             `json:"agent,omitempty" gorm:"foreignKey:AgentID"`
+	Messages     []ChatMessage     `json:"messages,omitempty" gorm:"foreignKey:SessionID"`
+	Participants []ChatParticipant `json:"participants,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// TableName specifies the table name for the ChatSession model
+func (ChatSession) TableName() string {
+	return "chat_sessions"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a chat session
+func (s *ChatSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChatMessage is one line of a chat transcript
+type ChatMessage struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:char(36);primary_key"`
+	SessionID  uuid.UUID      `json:"session_id" gorm:"type:char(36);not null;index"`
+	SenderType ChatSenderType `json:"sender_type" gorm:"not null;size:20"`
+	SenderID   *uuid.UUID     `json:"sender_id" gorm:"type:char(36)"`
+	Content    string         `json:"content" gorm:"type:text;not null"`
+	// Internal messages, such as transfer notes and monitoring, are only shown to staff
+	Internal bool `json:"internal" gorm:"not null;default:false"`
+	// DeliveredAt and ReadAt are set when the other side's client acknowledges the message
+	DeliveredAt *time.Time `json:"delivered_at"`
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the ChatMessage model
+func (ChatMessage) TableName() string {
+	return "chat_messages"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a chat message
+func (m *ChatMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChatParticipantMode is how a supervisor takes part in a chat they do not own
+type ChatParticipantMode string
+
+const (
+	// ParticipantMonitoring is a supervisor silently following the chat
+	ParticipantMonitoring ChatParticipantMode = "MONITORING"
+	// ParticipantJoined is a supervisor who has joined the conversation
+	ParticipantJoined ChatParticipantMode = "JOINED"
+)
+
+// ChatParticipant is a supervisor monitoring or joining another agent's chat
+type ChatParticipant struct {
+	SessionID uuid.UUID           `json:"session_id" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID           `json:"user_id" gorm:"type:char(36);primaryKey"`
+	Mode      ChatParticipantMode `json:"mode" gorm:"not null;size:20"`
+	CreatedAt time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName specifies the table name for the ChatParticipant model
+func (ChatParticipant) TableName() string {
+	return "chat_participants"
+}
+
+// ChatQueueEventType identifies what happened to a chat in the agent queue
+type ChatQueueEventType string
+
+const (
+	QueueEventJoined ChatQueueEventType = "JOINED"
+	// QueueEventAccepted records an agent picking the chat up
+	QueueEventAccepted ChatQueueEventType = "ACCEPTED"
+	// QueueEventAbandoned records the visitor leaving while waiting
+	QueueEventAbandoned ChatQueueEventType = "ABANDONED"
+	// QueueEventConverted records the visitor leaving a message as a ticket instead of waiting
+	QueueEventConverted ChatQueueEventType = "CONVERTED"
+)
+
+// ChatQueueEvent records a chat entering or leaving the agent queue, kept for analytics
+type ChatQueueEvent struct {
+	ID        uuid.UUID          `json:"id" gorm:"type:char(36);primary_key"`
+	SessionID uuid.UUID          `json:"session_id" gorm:"type:char(36);not null;index"`
+	Type      ChatQueueEventType `json:"type" gorm:"not null;size:20;index"`
+	// WaitSeconds is how long the chat had been queued; zero for JOINED events
+	WaitSeconds int64     `json:"wait_seconds" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for the ChatQueueEvent model
+func (ChatQueueEvent) TableName() string {
+	return "chat_queue_events"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a chat queue event
+func (e *ChatQueueEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChatQueueStatus tells a waiting visitor where they stand in the queue
+type ChatQueueStatus struct {
+	Status ChatStatus `json:"status"`
+	// Position is 1 for the next chat to be picked up; zero once the chat is no longer queued
+	Position int64 `json:"position"`
+	// AverageWaitSeconds is the average wait of chats accepted recently; omitted without recent history
+	AverageWaitSeconds *int64 `json:"average_wait_seconds,omitempty"`
+	// EstimatedWaitSeconds scales the average wait by the visitor's position
+	EstimatedWaitSeconds *int64 `json:"estimated_wait_seconds,omitempty"`
+}
+
+// StartChatRequest represents a visitor opening a chat; details already known
+// to the embedding site let the bot skip those questions
+type StartChatRequest struct {
+	Name  string `json:"name" validate:"max=100"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// StartChatResponse returns the new chat and the token the visitor uses to continue it
+type StartChatResponse struct {
+	Session *ChatSession `json:"session"`
+	// Token must be sent in the X-Chat-Token header of later visitor requests
+	Token string `json:"token"`
+}
+
+// SendChatMessageRequest represents a chat message
+type SendChatMessageRequest struct {
+	Content string `json:"content" validate:"required,min=1,max=5000"`
+}
+
+// SetChatCapacityRequest sets how many chats an agent handles at once
+type SetChatCapacityRequest struct {
+	// MaxConcurrentChats of null restores the default limit; zero stops chats being routed to the agent
+	MaxConcurrentChats *int `json:"max_concurrent_chats" validate:"omitempty,min=0,max=50"`
+}
+
+// AgentChatCapacity is an agent's live chat load against their limit
+type AgentChatCapacity struct {
+	AgentID     uuid.UUID `json:"agent_id"`
+	Name        string    `json:"name"`
+	ActiveChats int64     `json:"active_chats"`
+	MaxChats    int       `json:"max_chats"`
+	// Available is false while the agent is out of office; their chats still count but no new ones are routed
+	Available bool `json:"available"`
+}
+
+// ChatCapacityReport shows supervisors how much live chat capacity is in use
+type ChatCapacityReport struct {
+	Agents      []AgentChatCapacity `json:"agents"`
+	ActiveChats int64               `json:"active_chats"`
+	// Capacity is the combined limit of the available agents
+	Capacity int64 `json:"capacity"`
+	// Utilization is active chats over capacity, from 0 to 1; above 1 when agents are over their limits
+	Utilization float64 `json:"utilization"`
+	QueuedChats int64   `json:"queued_chats"`
+}
+
+// TransferChatRequest hands an active chat to another agent or to a team's queue;
+// exactly one of agent_id and team_id is given
+type TransferChatRequest struct {
+	AgentID *uuid.UUID `json:"agent_id"`
+	TeamID  *uuid.UUID `json:"team_id"`
+	// Note gives the next agent context; it is not shown to the visitor
+	Note string `json:"note" validate:"max=1000"`
+}
+
+// ChatEventType identifies a frame in the chat WebSocket protocol
+type ChatEventType string
+
+const (
+	// ChatEventMessage carries a new transcript message, sent by the server
+	ChatEventMessage ChatEventType = "message"
+	// ChatEventTypingStart and ChatEventTypingStop are sent by a client and relayed to the other side
+	ChatEventTypingStart ChatEventType = "typing.start"
+	ChatEventTypingStop  ChatEventType = "typing.stop"
+	// ChatEventDelivered and ChatEventRead acknowledge the other side's messages up to
+	// and including message_id; the server relays them with every message they cover
+	ChatEventDelivered ChatEventType = "message.delivered"
+	ChatEventRead      ChatEventType = "message.read"
+	// ChatEventError reports a frame the server could not handle
+	ChatEventError ChatEventType = "error"
+)
+
+// ChatClientFrame is a frame sent by a visitor or agent over the chat WebSocket
+type ChatClientFrame struct {
+	Type ChatEventType `json:"type"`
+	// MessageID is the latest message acknowledged by delivered and read frames
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+}
+
+// ChatEvent is a frame sent by the server over the chat WebSocket
+type ChatEvent struct {
+	Type   ChatEventType `json:"type"`
+	ChatID uuid.UUID     `json:"chat_id"`
+	// Status is the chat's status after a message
+	Status  ChatStatus   `json:"status,omitempty"`
+	Message *ChatMessage `json:"message,omitempty"`
+	// Sender is who is typing or acknowledging
+	Sender     ChatSenderType `json:"sender,omitempty"`
+	SenderID   *uuid.UUID     `json:"sender_id,omitempty"`
+	MessageIDs []uuid.UUID    `json:"message_ids,omitempty"`
+	At         *time.Time     `json:"at,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// RateChatRequest is a visitor's rating of a chat that has ended
+type RateChatRequest struct {
+	Score   int    `json:"score" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+// ChatRatingCount is the number of chats an agent handled that got a rating
+type ChatRatingCount struct {
+	// AgentID is nil for chats the auto-responder resolved on its own
+	AgentID *uuid.UUID
+	Rating  int
+	Count   int64
+}
+
+// ChatCSATReport summarises post-chat ratings. It is reported separately from
+// ticket satisfaction because chats are rated straight after the conversation.
+type ChatCSATReport struct {
+	Since      time.Time `json:"since"`
+	EndedChats int64     `json:"ended_chats"`
+	RatedChats int64     `json:"rated_chats"`
+	// ResponseRate is the share of ended chats that were rated, from 0 to 1
+	ResponseRate  float64  `json:"response_rate"`
+	AverageRating *float64 `json:"average_rating"`
+	// CSAT is the share of ratings of 4 or 5, from 0 to 1
+	CSAT *float64 `json:"csat"`
+	// Distribution counts the ratings given for each score
+	Distribution map[int]int64   `json:"distribution"`
+	Agents       []AgentChatCSAT `json:"agents"`
+}
+
+// AgentChatCSAT summarises the ratings of one agent's chats
+type AgentChatCSAT struct {
+	AgentID       uuid.UUID `json:"agent_id"`
+	Name          string    `json:"name"`
+	RatedChats    int64     `json:"rated_chats"`
+	AverageRating float64   `json:"average_rating"`
+	CSAT          float64   `json:"csat"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/markdown"
+
+// CommentFormat is how the content of a comment is written
+type CommentFormat string
+
+const (
+	// CommentFormatPlain comments are shown as written, with URLs linked
+	CommentFormatPlain CommentFormat = "plain"
+	// CommentFormatMarkdown comments are Markdown; HTML in them is shown as text
+	CommentFormatMarkdown CommentFormat = "markdown"
+)
+
+// RenderComment renders the content of a comment as HTML that is safe to
+// insert into a page: only the tags the renderer writes itself are kept
+func RenderComment(content string, format CommentFormat) string {
+	if format == CommentFormatMarkdown {
+		return markdown.Render(content)
+	}
+	return markdown.RenderPlain(content)
+}
+
+// CommentPreviewRequest represents a draft comment to render
+type CommentPreviewRequest struct {
+	Content string        `json:"content" validate:"required,min=1" example:"**Fixed** in the latest release, see https://status.example.com"`
+	Format  CommentFormat `json:"format" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+}
+
+// CommentPreviewResponse is a draft comment rendered as it would be shown
+type CommentPreviewResponse struct {
+	HTML string `json:"html" example:"<p><strong>Fixed</strong> in the latest release</p>"`
+}
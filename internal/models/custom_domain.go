@@ -0,0 +1,21 @@
+package models
+
+// CustomDomainCheck is one step of setting up the custom domain
+type CustomDomainCheck struct {
+	Name   string `json:"name" example:"cookie_domain"`
+	OK     bool   `json:"ok" example:"true"`
+	Detail string `json:"detail" example:"session cookies are sent to support.example.com"`
+}
+
+// CustomDomainStatus reports whether the custom domain is set up, one check
+// per setting or DNS record it depends on
+// @Description Setup status of the white-label domain
+type CustomDomainStatus struct {
+	Domain      string `json:"domain" example:"support.example.com"`
+	EmailDomain string `json:"email_domain" example:"example.com"`
+	// Configured is false when CUSTOM_DOMAIN is not set; there are no checks then
+	Configured bool `json:"configured" example:"true"`
+	// Ready is true when every check passes
+	Ready  bool                `json:"ready" example:"false"`
+	Checks []CustomDomainCheck `json:"checks"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutOfOffice is an agent's absence window during which work is handed to a delegate
+type OutOfOffice struct {
+	ID         uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:char(36);not null;uniqueIndex"`
+	DelegateID uuid.UUID `json:"delegate_id" gorm:"type:char(36);not null"`
+	StartsAt   time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt     time.Time `json:"ends_at" gorm:"not null"`
+	Note       string    `json:"note" gorm:"size:500"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Delegate *User `json:"delegate,omitempty" gorm:"foreignKey:DelegateID"`
+}
+
+// TableName specifies the table name for the OutOfOffice model
+func (OutOfOffice) TableName() string {
+	return "out_of_office"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an out-of-office window
+func (o *OutOfOffice) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether the window covers the given time
+func (o *OutOfOffice) IsActive(at time.Time) bool {
+	return !at.Before(o.StartsAt) && at.Before(o.EndsAt)
+}
+
+// SetOutOfOfficeRequest represents a request to set the current user's out-of-office window
+type SetOutOfOfficeRequest struct {
+	DelegateID uuid.UUID `json:"delegate_id" validate:"required"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required"`
+	Note       string    `json:"note" validate:"max=500"`
+}
+
+// HandoverResponse reports the result of handing open tickets to a delegate
+type HandoverResponse struct {
+	DelegateID uuid.UUID `json:"delegate_id"`
+	Reassigned int64     `json:"reassigned"`
+}
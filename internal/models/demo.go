@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DemoResetConfirmation must be sent to reset the database to the demo data
+const DemoResetConfirmation = "RESET"
+
+// DemoDataset is the known state a demo environment is reset to
+type DemoDataset struct {
+	Users       []User
+	Teams       []Team
+	TeamMembers []TeamMember
+	Categories  []Category
+	Tickets     []Ticket
+	Comments    []Comment
+	Assignments []TicketAssignment
+	Escalations []TicketEscalationEvent
+}
+
+// DemoResetRequest asks for the database to be wiped and reseeded with the demo data
+type DemoResetRequest struct {
+	// Confirm must be "RESET", since every user, ticket and setting is deleted
+	Confirm string `json:"confirm" validate:"required,eq=RESET" example:"RESET"`
+}
+
+// DemoResetResponse describes the demo data the database was reset to
+type DemoResetResponse struct {
+	Users      int       `json:"users"`
+	Categories int       `json:"categories"`
+	Tickets    int       `json:"tickets"`
+	Comments   int       `json:"comments"`
+	ResetAt    time.Time `json:"reset_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DraftAttachment is a file uploaded while a ticket is being written, before
+// the ticket exists. Saving a ticket that references it turns it into an
+// attachment of the ticket with the same ID; drafts never referenced are
+// removed once they expire.
+type DraftAttachment struct {
+	ID       uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	Filename string    `json:"filename" gorm:"not null;size:255"`
+	FilePath string    `json:"-" gorm:"not null;type:text;serializer:encrypted"`
+	FileSize int64     `json:"file_size" gorm:"not null"`
+	MimeType string    `json:"mime_type" gorm:"not null;size:100"`
+	// Inline drafts are images to embed in the description, referenced by
+	// inline_image_ids rather than attachment_ids
+	Inline       bool      `json:"inline" gorm:"not null;default:false"`
+	UploadedByID uuid.UUID `json:"uploaded_by_id" gorm:"type:char(36);not null;index"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the DraftAttachment model
+func (DraftAttachment) TableName() string {
+	return "draft_attachments"
+}
+
+// BeforeCreate is a GORM hook that sets the ID before creating a draft attachment
+func (d *DraftAttachment) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Attachment returns the attachment the draft becomes on a ticket
+func (d *DraftAttachment) Attachment(ticketID uuid.UUID) *Attachment {
+	return &Attachment{
+		ID:           d.ID,
+		TicketID:     ticketID,
+		Filename:     d.Filename,
+		FilePath:     d.FilePath,
+		FileSize:     d.FileSize,
+		MimeType:     d.MimeType,
+		UploadedByID: d.UploadedByID,
+		Inline:       d.Inline,
+	}
+}
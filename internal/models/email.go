@@ -0,0 +1,26 @@
+package models
+
+// InboundEmail represents an email received from the inbound mail webhook
+type InboundEmail struct {
+	From        string
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []InboundAttachment
+}
+
+// InboundAttachment represents a file attached to an inbound email
+type InboundAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// InboundEmailResponse represents the result of processing an inbound email
+type InboundEmailResponse struct {
+	TicketID    string `json:"ticket_id,omitempty"`
+	CommentID   string `json:"comment_id,omitempty"`
+	Attachments int    `json:"attachments"`
+	Quarantined bool   `json:"quarantined"`
+}
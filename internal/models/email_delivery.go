@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailDeliveryStatus is where an outbound email to one recipient stands
+type EmailDeliveryStatus string
+
+const (
+	DeliveryQueued     EmailDeliveryStatus = "QUEUED"
+	DeliverySent       EmailDeliveryStatus = "SENT"
+	DeliveryDelivered  EmailDeliveryStatus = "DELIVERED"
+	DeliveryFailed     EmailDeliveryStatus = "FAILED"
+	DeliveryBounced    EmailDeliveryStatus = "BOUNCED"
+	DeliveryComplained EmailDeliveryStatus = "COMPLAINED"
+	// DeliverySuppressed emails were not sent because the address hard-bounced or complained before
+	DeliverySuppressed EmailDeliveryStatus = "SUPPRESSED"
+)
+
+// Bounce types reported by the mail provider
+const (
+	BounceHard = "hard"
+	BounceSoft = "soft"
+)
+
+// Email suppression reasons
+const (
+	SuppressionHardBounce = "HARD_BOUNCE"
+	SuppressionComplaint  = "COMPLAINT"
+)
+
+// EmailDelivery tracks an outbound email to one recipient
+type EmailDelivery struct {
+	ID uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	// MessageID is the Message-ID header shared by every recipient of the email
+	MessageID string              `json:"message_id" gorm:"not null;size:255;index"`
+	Recipient string              `json:"recipient" gorm:"not null;size:320;index"`
+	Subject   string              `json:"subject" gorm:"size:255"`
+	Status    EmailDeliveryStatus `json:"status" gorm:"not null;size:20;index"`
+	// BounceType is "hard" or "soft" for bounced emails
+	BounceType string `json:"bounce_type,omitempty" gorm:"size:10"`
+	// Detail is the send error or the provider's reason for a bounce or complaint
+	Detail    string    `json:"detail,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the EmailDelivery model
+func (EmailDelivery) TableName() string {
+	return "email_deliveries"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an email delivery
+func (d *EmailDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// EmailSuppression is an address no email is sent to after it hard-bounced
+// or its owner complained
+type EmailSuppression struct {
+	Address    string     `json:"address" gorm:"primary_key;size:320"`
+	Reason     string     `json:"reason" gorm:"not null;size:20"`
+	DeliveryID *uuid.UUID `json:"delivery_id" gorm:"type:char(36)"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the EmailSuppression model
+func (EmailSuppression) TableName() string {
+	return "email_suppressions"
+}
+
+// EmailDeliveryQuery represents filters and pagination for email delivery queries
+type EmailDeliveryQuery struct {
+	Status    EmailDeliveryStatus
+	Recipient string
+	Page      int
+	PageSize  int
+}
+
+// EmailDeliveryListResponse represents a paginated list of email deliveries
+type EmailDeliveryListResponse struct {
+	Deliveries []EmailDelivery `json:"deliveries"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// EmailDeliveryEvent is a delivery status update posted by the mail provider
+type EmailDeliveryEvent struct {
+	// MessageID identifies the email; without it the recipient's latest email is updated
+	MessageID string `json:"message_id" example:"<comment-3f1c...@reply.helpchat.com>"`
+	Recipient string `json:"recipient" validate:"required,email" example:"jane@example.com"`
+	Event     string `json:"event" validate:"required,oneof=delivered bounce complaint" example:"bounce"`
+	// BounceType is "hard" for permanent failures such as unknown mailboxes, otherwise "soft"
+	BounceType string `json:"bounce_type" validate:"omitempty,oneof=hard soft" example:"hard"`
+	Reason     string `json:"reason" validate:"max=1000" example:"550 5.1.1 mailbox does not exist"`
+}
+
+// EmailDeliveryEventsRequest is a batch of delivery status updates
+type EmailDeliveryEventsRequest struct {
+	Events []EmailDeliveryEvent `json:"events" validate:"required,min=1,max=1000,dive"`
+}
+
+// EmailDeliveryEventsResponse reports how a batch of delivery events was applied
+type EmailDeliveryEventsResponse struct {
+	Applied int `json:"applied"`
+	// Unmatched events name no email that was sent to the recipient
+	Unmatched int `json:"unmatched"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailTemplate is a tenant's replacement for a built-in notification email.
+// Templates without a row are sent with their built-in subject and text.
+type EmailTemplate struct {
+	Name        string     `json:"name" gorm:"primary_key;size:50"`
+	Subject     string     `json:"subject" gorm:"not null;size:255"`
+	Text        string     `json:"text" gorm:"not null;type:text"`
+	UpdatedByID *uuid.UUID `json:"updated_by_id" gorm:"type:char(36)"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the EmailTemplate model
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+// EmailTemplateTranslation is a notification email in another language, sent
+// to recipients who prefer that language
+type EmailTemplateTranslation struct {
+	Name        string     `json:"name" gorm:"primary_key;size:50"`
+	Language    string     `json:"language" gorm:"primary_key;size:35" example:"fr"`
+	Subject     string     `json:"subject" gorm:"not null;size:255"`
+	Text        string     `json:"text" gorm:"not null;type:text"`
+	UpdatedByID *uuid.UUID `json:"updated_by_id" gorm:"type:char(36)"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the EmailTemplateTranslation model
+func (EmailTemplateTranslation) TableName() string {
+	return "email_template_translations"
+}
+
+// EmailTemplateResponse describes a notification email template as it is sent
+type EmailTemplateResponse struct {
+	Name        string `json:"name" example:"comment_added"`
+	Description string `json:"description"`
+	Subject     string `json:"subject" example:"Re: [#{{ticket_reference}}] {{ticket_title}}"`
+	Text        string `json:"text"`
+	// Variables are the {{name}} placeholders the subject and text may use
+	Variables []string `json:"variables"`
+	// Customized is false while the built-in default is in use
+	Customized bool `json:"customized"`
+	// Translations lists the languages the template has been translated into
+	Translations []string   `json:"translations"`
+	UpdatedByID  *uuid.UUID `json:"updated_by_id,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// EmailTemplateRequest replaces a notification email's subject and text
+type EmailTemplateRequest struct {
+	Subject string `json:"subject" validate:"required,max=255"`
+	Text    string `json:"text" validate:"required,max=20000"`
+}
+
+// EmailTemplatePreviewRequest renders a draft subject and text; the saved
+// template is rendered when both are empty, in Language when it is given
+type EmailTemplatePreviewRequest struct {
+	Subject  string `json:"subject" validate:"max=255"`
+	Text     string `json:"text" validate:"max=20000"`
+	Language string `json:"language" validate:"max=35" example:"fr"`
+}
+
+// EmailTemplatePreview is a template rendered with a sample ticket
+type EmailTemplatePreview struct {
+	// Language is the language rendered, empty for the untranslated template
+	Language string `json:"language,omitempty"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+}
+
+// LanguageRequest sets the language a user's notifications are sent in
+type LanguageRequest struct {
+	// Language is a BCP 47 tag such as "fr" or "pt-BR"; empty uses the default language
+	Language string `json:"language" validate:"max=35" example:"fr"`
+}
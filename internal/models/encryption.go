@@ -0,0 +1,39 @@
+package models
+
+// EncryptedColumn identifies a column stored with the encrypted serializer
+type EncryptedColumn struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// String returns the column as table.column
+func (c EncryptedColumn) String() string {
+	return c.Table + "." + c.Column
+}
+
+// EncryptedColumns lists every column encrypted at rest. Columns tagged
+// serializer:encrypted must be added here so key rotation re-encrypts them.
+var EncryptedColumns = []EncryptedColumn{
+	{Table: "attachments", Column: "file_path"},
+	{Table: "quarantined_submissions", Column: "email"},
+	{Table: "banner_subscriptions", Column: "email"},
+	{Table: "chat_sessions", Column: "visitor_email"},
+	{Table: "ticket_subscribers", Column: "email"},
+	{Table: "failed_tasks", Column: "payload"},
+	{Table: "draft_attachments", Column: "file_path"},
+	{Table: "inbound_integrations", Column: "secret"},
+	{Table: "webhook_subscriptions", Column: "secret"},
+}
+
+// EncryptedValue is a raw stored value of an encrypted column
+type EncryptedValue struct {
+	ID    string
+	Value string
+}
+
+// KeyRotationResponse reports the outcome of re-encrypting stored values
+type KeyRotationResponse struct {
+	PrimaryKeyID string `json:"primary_key_id"`
+	// Rotated counts the values re-encrypted per table.column
+	Rotated map[string]int `json:"rotated"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TicketStatuses lists every ticket status
+var TicketStatuses = []TicketStatus{StatusOpen, StatusInProgress, StatusResolved, StatusClosed}
+
+// TicketPriorities lists every ticket priority, lowest first
+var TicketPriorities = []TicketPriority{PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical}
+
+// UserRoles lists every user role
+var UserRoles = []UserRole{RoleEndUser, RoleSupportAgent, RoleAdministrator, RoleManager, RoleViewer}
+
+// EnumValueError reports a value that is not one of an enum's allowed values
+type EnumValueError struct {
+	Name    string
+	Value   string
+	Allowed []string
+}
+
+func (e *EnumValueError) Error() string {
+	return fmt.Sprintf("%s must be one of: %s (got %q)", e.Name, strings.Join(e.Allowed, ", "), e.Value)
+}
+
+// parseEnum returns value as one of allowed. An empty value is left for
+// validation to require or not.
+func parseEnum[T ~string](name, value string, allowed []T) (T, error) {
+	for _, v := range allowed {
+		if string(v) == value {
+			return v, nil
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+
+	names := make([]string, len(allowed))
+	for i, v := range allowed {
+		names[i] = string(v)
+	}
+	return "", &EnumValueError{Name: name, Value: value, Allowed: names}
+}
+
+// UnmarshalText rejects unknown statuses when binding query, form and JSON values
+func (s *TicketStatus) UnmarshalText(text []byte) error {
+	status, err := parseEnum("status", string(text), TicketStatuses)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// UnmarshalText rejects unknown priorities when binding query, form and JSON values
+func (p *TicketPriority) UnmarshalText(text []byte) error {
+	priority, err := parseEnum("priority", string(text), TicketPriorities)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
+// UnmarshalText rejects unknown roles when binding query, form and JSON values
+func (r *UserRole) UnmarshalText(text []byte) error {
+	role, err := parseEnum("role", string(text), UserRoles)
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
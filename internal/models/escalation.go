@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EscalationAction is a step in a ticket's escalation history
+type EscalationAction string
+
+const (
+	EscalationEscalated   EscalationAction = "ESCALATED"
+	EscalationDeEscalated EscalationAction = "DE_ESCALATED"
+)
+
+// TicketEscalationEvent records a ticket being escalated, or handed back to its
+// assignee, so a ticket can go through several rounds of escalation
+type TicketEscalationEvent struct {
+	ID       uuid.UUID        `json:"id" gorm:"type:char(36);primary_key"`
+	TicketID uuid.UUID        `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	Action   EscalationAction `json:"action" gorm:"not null;size:20" example:"ESCALATED"`
+	ActorID  uuid.UUID        `json:"actor_id" gorm:"type:char(36);not null"`
+	// TargetID is who the ticket went to: the escalation target, or the
+	// assignee it was handed back to
+	TargetID *uuid.UUID `json:"target_id" gorm:"type:char(36)"`
+	// Note is the escalation reason, or the note left when handing the ticket back
+	Note      string    `json:"note" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relationships
+	Actor  *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Target *User `json:"target,omitempty" gorm:"foreignKey:TargetID"`
+}
+
+// TableName specifies the table name for the TicketEscalationEvent model
+func (TicketEscalationEvent) TableName() string {
+	return "ticket_escalation_events"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an escalation event
+func (e *TicketEscalationEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
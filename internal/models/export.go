@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataExport records one day of data shipped to the analytics warehouse
+type DataExport struct {
+	ID uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	// Day is the exported office day as YYYY-MM-DD
+	Day string `json:"day" gorm:"not null;size:10;uniqueIndex"`
+	// Files lists the keys written, one per dataset
+	Files []string `json:"files" gorm:"serializer:json"`
+	Rows  int64    `json:"rows" gorm:"not null"`
+	// TriggeredByID is the admin who re-ran the export; null for the nightly job
+	TriggeredByID *uuid.UUID `json:"triggered_by_id" gorm:"type:char(36)"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the DataExport model
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a data export record
+func (d *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// RunExportRequest asks for one day to be exported again
+type RunExportRequest struct {
+	// Day is the office day to export as YYYY-MM-DD
+	Day string `json:"day" validate:"required"`
+}
+
+// ExportDataset describes a table shipped to the warehouse. Rows are picked by
+// TimeColumn falling within the exported day.
+type ExportDataset struct {
+	Name       string
+	Table      string
+	TimeColumn string
+	Columns    []string
+}
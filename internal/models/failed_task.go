@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of background work kept when it fails
+const (
+	// FailedTaskJob is a scheduled job run; it is retried by running the job again
+	FailedTaskJob = "job"
+	// FailedTaskEmail is a notification email the provider did not accept
+	FailedTaskEmail = "email"
+	// FailedTaskAuditSink is a batch of audit events a SIEM sink did not accept
+	FailedTaskAuditSink = "audit_sink"
+)
+
+// FailedTask is background work that failed, kept so an administrator can
+// inspect it and retry or discard it
+type FailedTask struct {
+	ID   uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	Kind string    `json:"kind" gorm:"not null;size:20;index" example:"email"`
+	// Name identifies the work within its kind: the job, the email's subject or the sink
+	Name string `json:"name" gorm:"not null;size:255" example:"Your ticket was updated"`
+	// Payload is what a retry needs, as JSON. It is encrypted as emails hold ticket content.
+	Payload string `json:"-" gorm:"type:text;serializer:encrypted"`
+	// Error is the error of the latest failure
+	Error string `json:"error" gorm:"type:text"`
+	// Failures counts the failures, including failed retries
+	Failures     int       `json:"failures" gorm:"not null;default:1"`
+	LastFailedAt time.Time `json:"last_failed_at" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for the FailedTask model
+func (FailedTask) TableName() string {
+	return "failed_tasks"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a failed task
+func (t *FailedTask) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// FailedTaskDetail is a failed task with the payload a retry would use
+type FailedTaskDetail struct {
+	FailedTask
+	Payload json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+}
+
+// FailedTaskQuery represents filters and pagination for failed task queries
+type FailedTaskQuery struct {
+	Kind     string
+	Page     int
+	PageSize int
+}
+
+// FailedTaskListResponse represents a paginated list of failed tasks
+type FailedTaskListResponse struct {
+	Tasks      []FailedTask `json:"tasks"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
+
+// FailedTaskBulkRequest names the failed tasks to retry or discard
+type FailedTaskBulkRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// FailedTaskBulkResult reports which failed tasks were retried or discarded.
+// Tasks that failed again, or could not be found, are in Failed with the reason.
+type FailedTaskBulkResult struct {
+	Succeeded []uuid.UUID       `json:"succeeded"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeatureUsage counts one user's requests to one API route within an hour.
+// Requests are rolled up as they are served, so the table grows with the
+// routes and users active each hour rather than with traffic.
+type FeatureUsage struct {
+	// Hour is the start of the hour, in UTC, the requests were made in
+	Hour   time.Time `json:"hour" gorm:"not null;uniqueIndex:idx_feature_usage_bucket,priority:1"`
+	Method string    `json:"method" gorm:"not null;size:10;uniqueIndex:idx_feature_usage_bucket,priority:2"`
+	// Route is the route template, such as /api/v1/tickets/:id
+	Route string `json:"route" gorm:"not null;size:255;uniqueIndex:idx_feature_usage_bucket,priority:3"`
+	// UserID is the signed-in user, or the nil UUID for anonymous requests
+	UserID uuid.UUID `json:"user_id" gorm:"type:char(36);not null;uniqueIndex:idx_feature_usage_bucket,priority:4;index"`
+	// Role is the user's role when they made the requests
+	Role     UserRole `json:"role" gorm:"size:20"`
+	Requests int64    `json:"requests" gorm:"not null;default:0"`
+	// Errors counts the requests answered with a 4xx or 5xx status
+	Errors int64 `json:"errors" gorm:"not null;default:0"`
+	// LatencyMicros is the total time spent serving the requests
+	LatencyMicros    int64 `json:"latency_micros" gorm:"not null;default:0"`
+	MaxLatencyMicros int64 `json:"max_latency_micros" gorm:"not null;default:0"`
+}
+
+// TableName specifies the table name for the FeatureUsage model
+func (FeatureUsage) TableName() string {
+	return "feature_usage"
+}
+
+// FeatureUsageReport summarizes which API routes were used, and by whom,
+// between From and To
+type FeatureUsageReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Requests counts every recorded request in the period
+	Requests int64 `json:"requests"`
+	// Users counts the distinct signed-in users who made them
+	Users int64 `json:"users"`
+	// Features lists the most requested routes, busiest first
+	Features []FeatureUsageSummary `json:"features"`
+	// Roles lists the requests made by each role; anonymous requests have an empty role
+	Roles []RoleUsageSummary `json:"roles"`
+	// TopUsers lists the signed-in users who made the most requests
+	TopUsers []UserUsageSummary `json:"top_users"`
+}
+
+// FeatureUsageSummary is the use of one route
+type FeatureUsageSummary struct {
+	Method   string `json:"method" example:"GET"`
+	Route    string `json:"route" example:"/api/v1/tickets/:id"`
+	Requests int64  `json:"requests"`
+	// Users counts the distinct signed-in users who made requests
+	Users  int64 `json:"users"`
+	Errors int64 `json:"errors"`
+	// ErrorRate is the share of requests answered with an error, from 0 to 1
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// RoleUsageSummary is the use of the API by one role
+type RoleUsageSummary struct {
+	Role     UserRole `json:"role"`
+	Requests int64    `json:"requests"`
+	Users    int64    `json:"users"`
+	// Features counts the distinct routes the role used
+	Features int64 `json:"features"`
+}
+
+// UserUsageSummary is the use of the API by one user
+type UserUsageSummary struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     UserRole  `json:"role"`
+	Requests int64     `json:"requests"`
+	// Features counts the distinct routes the user used
+	Features int64 `json:"features"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InboundIntegration lets an external system, such as monitoring, a form
+// builder or CI, create and update tickets by posting JSON to
+// /api/v1/inbound/{key}. Its mapping turns each payload into a ticket.
+type InboundIntegration struct {
+	ID uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	// Key is the integration's part of its webhook URL
+	Key     string `json:"key" gorm:"not null;size:64;uniqueIndex" example:"grafana"`
+	Name    string `json:"name" gorm:"not null;size:100" example:"Grafana alerts"`
+	Enabled bool   `json:"enabled" gorm:"not null"`
+	// Secret authenticates callers; it is only shown when it is generated
+	Secret string `json:"-" gorm:"type:text;not null;serializer:encrypted"`
+	// RequesterID is the user tickets from the integration are raised by
+	RequesterID uuid.UUID      `json:"requester_id" gorm:"type:char(36);not null"`
+	CategoryID  *uuid.UUID     `json:"category_id" gorm:"type:char(36)"`
+	TeamID      *uuid.UUID     `json:"team_id" gorm:"type:char(36)"`
+	Mapping     InboundMapping `json:"mapping" gorm:"type:text;serializer:json"`
+	// LastReceivedAt is when the integration last created or updated a ticket
+	LastReceivedAt *time.Time `json:"last_received_at"`
+	CreatedByID    uuid.UUID  `json:"created_by_id" gorm:"type:char(36);not null"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the InboundIntegration model
+func (InboundIntegration) TableName() string {
+	return "inbound_integrations"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an inbound integration
+func (i *InboundIntegration) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// InboundMapping maps a JSON payload onto a ticket. Its templates use {{path}}
+// placeholders naming payload fields, with dots into nested objects and array
+// indexes, such as {{alert.labels.severity}} or {{alerts.0.summary}}.
+// Placeholders of missing fields are left empty.
+type InboundMapping struct {
+	// Title must not render empty for a payload to open a ticket. Alertmanager
+	// notifications make their own and only use the priorities.
+	Title string `json:"title" validate:"max=500" example:"{{alert.name}} on {{alert.host}}"`
+	// Description defaults to the whole payload, indented
+	Description string `json:"description" validate:"max=10000" example:"{{alert.message}}"`
+	// ExternalID identifies what the payload is about in the external system.
+	// Payloads with the external ID of an earlier payload update its ticket,
+	// even once closed, instead of opening another.
+	ExternalID string `json:"external_id" validate:"max=500" example:"{{alert.id}}"`
+	// Priority renders to a key of Priorities or a ticket priority; tickets
+	// get DefaultPriority, or MEDIUM, when it renders to neither
+	Priority        string                    `json:"priority" validate:"max=500" example:"{{alert.severity}}"`
+	Priorities      map[string]TicketPriority `json:"priorities" example:"critical:CRITICAL,warning:HIGH"`
+	DefaultPriority TicketPriority            `json:"default_priority" validate:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
+	// Status renders to a key of Statuses or a ticket status, which updates
+	// move the ticket to; creating a ticket always opens it
+	Status   string                  `json:"status" validate:"max=500" example:"{{alert.state}}"`
+	Statuses map[string]TicketStatus `json:"statuses" example:"resolved:RESOLVED"`
+	// Comment is added to the ticket by updates when it renders to anything
+	Comment string `json:"comment" validate:"max=10000" example:"{{alert.message}}"`
+}
+
+// InboundTicketLink records the ticket a payload's external ID opened, so
+// later payloads about the same thing update it
+type InboundTicketLink struct {
+	ID            uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	IntegrationID uuid.UUID `json:"integration_id" gorm:"type:char(36);not null;uniqueIndex:idx_inbound_ticket_links_external"`
+	ExternalID    string    `json:"external_id" gorm:"not null;size:500;uniqueIndex:idx_inbound_ticket_links_external"`
+	TicketID      uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the InboundTicketLink model
+func (InboundTicketLink) TableName() string {
+	return "inbound_ticket_links"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an inbound ticket link
+func (l *InboundTicketLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// InboundIntegrationRequest represents a request to create or replace an inbound integration
+type InboundIntegrationRequest struct {
+	// Key may use lowercase letters, digits and dashes
+	Key         string         `json:"key" validate:"required,min=2,max=64" example:"grafana"`
+	Name        string         `json:"name" validate:"required,min=1,max=100" example:"Grafana alerts"`
+	Enabled     *bool          `json:"enabled"`
+	RequesterID uuid.UUID      `json:"requester_id" validate:"required"`
+	CategoryID  *uuid.UUID     `json:"category_id"`
+	TeamID      *uuid.UUID     `json:"team_id"`
+	Mapping     InboundMapping `json:"mapping"`
+}
+
+// InboundIntegrationSecretResponse is an integration with its newly generated
+// secret, which is not shown again
+type InboundIntegrationSecretResponse struct {
+	Integration *InboundIntegration `json:"integration"`
+	Secret      string              `json:"secret"`
+}
+
+// InboundResult reports what a payload posted to an inbound integration did
+type InboundResult struct {
+	TicketID uuid.UUID `json:"ticket_id"`
+	// Created is set when the payload opened a ticket rather than updated one
+	Created bool         `json:"created"`
+	Status  TicketStatus `json:"status"`
+	// CommentID is set when an update added a comment
+	CommentID *uuid.UUID `json:"comment_id,omitempty"`
+}
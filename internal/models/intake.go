@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SubmissionSource identifies the inbound channel a ticket submission arrived through
+type SubmissionSource string
+
+const (
+	SourceGuestForm SubmissionSource = "GUEST_FORM"
+	SourceEmail     SubmissionSource = "EMAIL"
+	SourceChat      SubmissionSource = "CHAT"
+)
+
+// QuarantineStatus represents the review state of a quarantined submission
+type QuarantineStatus string
+
+const (
+	QuarantinePending  QuarantineStatus = "PENDING"
+	QuarantineApproved QuarantineStatus = "APPROVED"
+	QuarantineRejected QuarantineStatus = "REJECTED"
+)
+
+// QuarantinedSubmission is an inbound submission held for review because it scored as likely spam
+type QuarantinedSubmission struct {
+	ID           uuid.UUID        `json:"id" gorm:"type:char(36);primary_key"`
+	Source       SubmissionSource `json:"source" gorm:"not null;size:20"`
+	Name         string           `json:"name" gorm:"size:100"`
+	Email        string           `json:"email" gorm:"not null;type:text;serializer:encrypted"`
+	Title        string           `json:"title" gorm:"not null;size:255"`
+	Description  string           `json:"description" gorm:"not null;type:text"`
+	Priority     TicketPriority   `json:"priority" gorm:"not null;default:'MEDIUM';size:20"`
+	CategoryID   *uuid.UUID       `json:"category_id" gorm:"type:char(36)"`
+	RemoteIP     string           `json:"remote_ip" gorm:"size:45"`
+	SpamScore    float64          `json:"spam_score"`
+	SpamReasons  string           `json:"spam_reasons" gorm:"type:text"`
+	Status       QuarantineStatus `json:"status" gorm:"not null;default:'PENDING';size:20;index"`
+	ReviewedByID *uuid.UUID       `json:"reviewed_by_id" gorm:"type:char(36)"`
+	ReviewedAt   *time.Time       `json:"reviewed_at"`
+	TicketID     *uuid.UUID       `json:"ticket_id" gorm:"type:char(36)"`
+	CreatedAt    time.Time        `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the QuarantinedSubmission model
+func (QuarantinedSubmission) TableName() string {
+	return "quarantined_submissions"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a quarantined submission
+func (q *QuarantinedSubmission) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	return nil
+}
+
+// GuestTicketRequest represents a ticket submitted without an account
+type GuestTicketRequest struct {
+	Name        string         `json:"name" validate:"required,min=1,max=100"`
+	Email       string         `json:"email" validate:"required,email"`
+	Title       string         `json:"title" validate:"required,min=1,max=255"`
+	Description string         `json:"description" validate:"required,min=1"`
+	Priority    TicketPriority `json:"priority" validate:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
+	CategoryID  *uuid.UUID     `json:"category_id"`
+}
+
+// GuestTicketResponse acknowledges a guest submission. It deliberately does not
+// reveal whether the submission was accepted or quarantined.
+type GuestTicketResponse struct {
+	Status  string `json:"status" example:"received"`
+	Message string `json:"message" example:"Your request has been received"`
+}
+
+// QuarantineListResponse represents a paginated list of quarantined submissions
+type QuarantineListResponse struct {
+	Submissions []QuarantinedSubmission `json:"submissions"`
+	Total       int64                   `json:"total"`
+	Page        int                     `json:"page"`
+	PageSize    int                     `json:"page_size"`
+	TotalPages  int                     `json:"total_pages"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// IntegrationHealth is the result of checking one external integration
+type IntegrationHealth struct {
+	Name    string `json:"name" example:"email"`
+	Healthy bool   `json:"healthy"`
+	// Error is why the check failed
+	Error         string `json:"error,omitempty"`
+	LatencyMillis int64  `json:"latency_ms"`
+	// LastDelivery is when something was last delivered through the integration
+	LastDelivery *time.Time `json:"last_delivery,omitempty"`
+}
+
+// IntegrationHealthReport is the result of checking every configured integration
+type IntegrationHealthReport struct {
+	// Healthy is true when every integration passed its check
+	Healthy      bool                `json:"healthy"`
+	Integrations []IntegrationHealth `json:"integrations"`
+	CheckedAt    time.Time           `json:"checked_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KnowledgeArticle is a self-help article in the knowledge base
+type KnowledgeArticle struct {
+	ID    uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	Title string    `json:"title" gorm:"not null;size:255"`
+	Body  string    `json:"body" gorm:"type:text;not null"`
+	// Keywords are extra comma-separated search terms that do not appear in the text
+	Keywords    string    `json:"keywords" gorm:"size:500"`
+	IsPublished bool      `json:"is_published" gorm:"not null;default:false;index"`
+	AuthorID    uuid.UUID `json:"author_id" gorm:"type:char(36);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the KnowledgeArticle model
+func (KnowledgeArticle) TableName() string {
+	return "knowledge_articles"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a knowledge article
+func (a *KnowledgeArticle) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// CreateKnowledgeArticleRequest represents a request to write a knowledge article
+type CreateKnowledgeArticleRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=255"`
+	Body        string `json:"body" validate:"required,min=1"`
+	Keywords    string `json:"keywords" validate:"max=500"`
+	IsPublished bool   `json:"is_published"`
+}
+
+// UpdateKnowledgeArticleRequest represents a request to edit a knowledge article
+type UpdateKnowledgeArticleRequest struct {
+	Title       *string `json:"title" validate:"omitempty,min=1,max=255"`
+	Body        *string `json:"body" validate:"omitempty,min=1"`
+	Keywords    *string `json:"keywords" validate:"omitempty,max=500"`
+	IsPublished *bool   `json:"is_published"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginEvent records a successful login
+type LoginEvent struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null;index"`
+	IPAddress string    `json:"ip_address" gorm:"size:45"`
+	UserAgent string    `json:"user_agent" gorm:"size:500"`
+	// Device is a coarse browser and OS label derived from the user agent, e.g. "Firefox on Windows"
+	Device string `json:"device" gorm:"size:100"`
+	// Location is a geo hint supplied by the CDN or load balancer, usually a country code
+	Location string `json:"location" gorm:"size:100"`
+	// NewDevice is set when the device and location combination had not been seen before
+	NewDevice bool      `json:"new_device"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for the LoginEvent model
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a login event
+func (l *LoginEvent) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// LoginMetadata describes the client a login came from
+type LoginMetadata struct {
+	IPAddress string
+	UserAgent string
+	Location  string
+}
+
+// LoginHistoryResponse represents a paginated list of login events
+type LoginHistoryResponse struct {
+	Logins     []LoginEvent `json:"logins"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	TotalPages int          `json:"total_pages"`
+}
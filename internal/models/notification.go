@@ -0,0 +1,153 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// QueuedNotification is a notification email held back during its
+// recipient's quiet hours until DeliverAt
+type QueuedNotification struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:char(36);primary_key"`
+	UserID    uuid.UUID         `json:"user_id" gorm:"type:char(36);not null;index"`
+	To        string            `json:"to" gorm:"not null;size:320"`
+	Subject   string            `json:"subject" gorm:"size:998"`
+	Text      string            `json:"text" gorm:"type:text"`
+	HTML      string            `json:"html" gorm:"type:text"`
+	ReplyTo   string            `json:"reply_to" gorm:"size:320"`
+	Headers   map[string]string `json:"headers" gorm:"serializer:json"`
+	DeliverAt time.Time         `json:"deliver_at" gorm:"not null;index"`
+	CreatedAt time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the QueuedNotification model
+func (QueuedNotification) TableName() string {
+	return "queued_notifications"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a queued notification
+func (n *QueuedNotification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+// TimezoneRequest sets the time zone a user's timestamps and dates are in
+type TimezoneRequest struct {
+	// Timezone is an IANA time zone such as "Europe/Paris"; empty is UTC
+	Timezone string `json:"timezone" validate:"max=64" example:"Europe/Paris"`
+}
+
+// QuietHoursRequest sets when a user's notifications are held back
+type QuietHoursRequest struct {
+	// Timezone is an IANA time zone such as "Europe/Paris"; empty is UTC
+	Timezone string `json:"timezone" validate:"max=64" example:"Europe/Paris"`
+	// Start and End ("HH:MM") bound the quiet hours; leave both empty to turn them off
+	Start string `json:"start" validate:"omitempty,len=5" example:"22:00"`
+	End   string `json:"end" validate:"omitempty,len=5" example:"07:00"`
+}
+
+// NotificationType is the kind of ticket event a user is notified about
+type NotificationType string
+
+const (
+	// NotificationTicketAssigned is sent to the agent a ticket is assigned to
+	NotificationTicketAssigned NotificationType = "ticket.assigned"
+	// NotificationTicketCommented is sent to the other side of the
+	// conversation about a public comment, and to users mentioned in one
+	NotificationTicketCommented NotificationType = "ticket.commented"
+	// NotificationTicketEscalated is sent to the user a ticket is escalated
+	// to, and to its assignee when it is handed back
+	NotificationTicketEscalated NotificationType = "ticket.escalated"
+	// NotificationTicketStatusChanged is sent to the requester and the
+	// assignee of a ticket whose status someone else changed
+	NotificationTicketStatusChanged NotificationType = "ticket.status_changed"
+)
+
+// NotificationTypes lists every type of notification users can choose channels for
+var NotificationTypes = []NotificationType{
+	NotificationTicketAssigned,
+	NotificationTicketCommented,
+	NotificationTicketEscalated,
+	NotificationTicketStatusChanged,
+}
+
+// Notification is an in-app notification about a change to a ticket
+type Notification struct {
+	ID       uuid.UUID        `json:"id" gorm:"type:char(36);primary_key"`
+	UserID   uuid.UUID        `json:"user_id" gorm:"type:char(36);not null;index"`
+	Type     NotificationType `json:"type" gorm:"not null;size:50"`
+	TicketID uuid.UUID        `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	// ActorID is the user whose change the notification is about
+	ActorID *uuid.UUID `json:"actor_id" gorm:"type:char(36)"`
+	Title   string     `json:"title" gorm:"not null;size:255"`
+	Body    string     `json:"body" gorm:"type:text"`
+	// ReadAt is null while the notification is unread
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for the Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a notification
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+// NotificationPreference is the channels a user is notified on about one type
+// of event. Types without a preference are sent on every channel.
+type NotificationPreference struct {
+	UserID    uuid.UUID        `json:"-" gorm:"type:char(36);primaryKey"`
+	Type      NotificationType `json:"type" gorm:"primaryKey;size:50"`
+	Email     bool             `json:"email"`
+	InApp     bool             `json:"in_app"`
+	UpdatedAt time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the NotificationPreference model
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// NotificationListResponse is a page of a user's notifications, newest first
+type NotificationListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	// Unread counts all of the user's unread notifications, not only this page's
+	Unread     int64 `json:"unread"`
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// NotificationReadAllResponse reports how many notifications were marked read
+type NotificationReadAllResponse struct {
+	Marked int64 `json:"marked"`
+}
+
+// NotificationChannels are the channels a user is notified on about one type of event
+type NotificationChannels struct {
+	Type  NotificationType `json:"type" validate:"required" example:"ticket.commented"`
+	Email bool             `json:"email" example:"false"`
+	InApp bool             `json:"in_app" example:"true"`
+}
+
+// NotificationPreferencesRequest changes the channels of the listed
+// notification types; types left out keep their channels
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationChannels `json:"preferences" validate:"required,min=1,dive"`
+}
+
+// NotificationPreferencesResponse lists the channels of every notification type
+type NotificationPreferencesResponse struct {
+	Preferences []NotificationChannels `json:"preferences"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OffboardingStatus is where an offboarding job stands
+type OffboardingStatus string
+
+const (
+	OffboardingPending   OffboardingStatus = "PENDING"
+	OffboardingRunning   OffboardingStatus = "RUNNING"
+	OffboardingCompleted OffboardingStatus = "COMPLETED"
+	OffboardingFailed    OffboardingStatus = "FAILED"
+)
+
+// FormerEmployeeFirstName and FormerEmployeeLastName replace the name of an
+// offboarded staff member, so their comments and history read "Former employee"
+const (
+	FormerEmployeeFirstName = "Former"
+	FormerEmployeeLastName  = "employee"
+)
+
+// OffboardingJob tracks the background job releasing the tickets of a staff
+// member who left and anonymizing their account
+type OffboardingJob struct {
+	ID     uuid.UUID         `json:"id" gorm:"type:char(36);primary_key"`
+	UserID uuid.UUID         `json:"user_id" gorm:"type:char(36);not null;index"`
+	Status OffboardingStatus `json:"status" gorm:"not null;size:20;index" example:"PENDING"`
+	// TeamID is the team whose queue the user's open tickets are moved to;
+	// nil leaves them in their teams' queues
+	TeamID        *uuid.UUID `json:"team_id,omitempty" gorm:"type:char(36)"`
+	RequestedByID uuid.UUID  `json:"requested_by_id" gorm:"type:char(36);not null"`
+	// TicketsReleased counts the open tickets unassigned from the user
+	TicketsReleased int64 `json:"tickets_released"`
+	// CommentsAttributed counts the comments now attributed to "Former employee"
+	CommentsAttributed int64      `json:"comments_attributed"`
+	Error              string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt          *time.Time `json:"started_at,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt          time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the OffboardingJob model
+func (OffboardingJob) TableName() string {
+	return "offboarding_jobs"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an offboarding job
+func (j *OffboardingJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// OffboardRequest asks for a staff member who left to be offboarded
+type OffboardRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+	// TeamID is the team whose queue the user's open tickets go to; omit it
+	// to leave them unassigned in their current teams' queues
+	TeamID *uuid.UUID `json:"team_id"`
+}
+
+// OffboardingJobListResponse represents a paginated list of offboarding jobs
+type OffboardingJobListResponse struct {
+	Jobs       []OffboardingJob `json:"jobs"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
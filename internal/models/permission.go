@@ -0,0 +1,138 @@
+package models
+
+import (
+	"slices"
+	"sync"
+)
+
+// Permissions granted to roles
+const (
+	PermTicketCreate       = "ticket:create"
+	PermTicketRead         = "ticket:read"
+	PermTicketReadAll      = "ticket:read:all"
+	PermTicketReadOwn      = "ticket:read:own"
+	PermTicketUpdate       = "ticket:update"
+	PermTicketUpdateOwn    = "ticket:update:own"
+	PermTicketDelete       = "ticket:delete"
+	PermTicketAssign       = "ticket:assign"
+	PermTicketStatusUpdate = "ticket:status:update"
+	PermTicketEscalate     = "ticket:escalate"
+	PermTicketStatsRead    = "ticket:stats:read"
+	PermCommentCreate      = "comment:create"
+	PermReportRead         = "report:read"
+	PermUserManage         = "user:manage"
+	PermSystemAdmin        = "system:admin"
+)
+
+// Permissions lists every permission a role can be granted
+var Permissions = []string{
+	PermTicketCreate,
+	PermTicketRead,
+	PermTicketReadAll,
+	PermTicketReadOwn,
+	PermTicketUpdate,
+	PermTicketUpdateOwn,
+	PermTicketDelete,
+	PermTicketAssign,
+	PermTicketStatusUpdate,
+	PermTicketEscalate,
+	PermTicketStatsRead,
+	PermCommentCreate,
+	PermReportRead,
+	PermUserManage,
+	PermSystemAdmin,
+}
+
+// defaultRolePermissions maps each role to the permissions it is granted
+// unless a role policy overrides it
+var defaultRolePermissions = map[UserRole][]string{
+	RoleEndUser: {
+		PermTicketCreate,
+		PermTicketReadOwn,
+		PermTicketUpdateOwn,
+		PermCommentCreate,
+	},
+	// Viewers are stakeholders who browse tickets and reports but cannot change anything
+	RoleViewer: {
+		PermTicketRead,
+		PermTicketReadAll,
+		PermTicketReadOwn,
+		PermTicketStatsRead,
+		PermReportRead,
+	},
+	RoleSupportAgent: {
+		PermTicketCreate,
+		PermTicketRead,
+		PermTicketReadAll,
+		PermTicketUpdate,
+		PermTicketAssign,
+		PermTicketStatusUpdate,
+		PermTicketEscalate,
+		PermTicketStatsRead,
+		PermCommentCreate,
+	},
+	// Managers list and report over their teams' tickets only; see PermTicketReadAll
+	RoleManager: {
+		PermTicketCreate,
+		PermTicketRead,
+		PermTicketUpdate,
+		PermTicketAssign,
+		PermTicketStatusUpdate,
+		PermTicketEscalate,
+		PermTicketStatsRead,
+		PermTicketDelete,
+		PermCommentCreate,
+		PermReportRead,
+		PermUserManage,
+	},
+	RoleAdministrator: {
+		PermTicketCreate,
+		PermTicketRead,
+		PermTicketReadAll,
+		PermTicketUpdate,
+		PermTicketAssign,
+		PermTicketStatusUpdate,
+		PermTicketEscalate,
+		PermTicketStatsRead,
+		PermTicketDelete,
+		PermCommentCreate,
+		PermReportRead,
+		PermUserManage,
+		PermSystemAdmin,
+	},
+}
+
+var (
+	rolePermissionsMu sync.RWMutex
+	rolePermissions   = defaultRolePermissions
+)
+
+// SetRolePermissions replaces the permissions of the roles in overrides; the
+// other roles go back to their defaults. Roles and permissions are not checked
+// here, callers validate them first.
+func SetRolePermissions(overrides map[UserRole][]string) {
+	permissions := make(map[UserRole][]string, len(defaultRolePermissions))
+	for role, granted := range defaultRolePermissions {
+		permissions[role] = granted
+	}
+	for role, granted := range overrides {
+		permissions[role] = slices.Clone(granted)
+	}
+
+	rolePermissionsMu.Lock()
+	defer rolePermissionsMu.Unlock()
+	rolePermissions = permissions
+}
+
+// HasPermission reports whether the role is granted a permission
+func (r UserRole) HasPermission(permission string) bool {
+	rolePermissionsMu.RLock()
+	defer rolePermissionsMu.RUnlock()
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// IsValid reports whether the role is one of the defined roles
+func (r UserRole) IsValid() bool {
+	_, ok := defaultRolePermissions[r]
+	return ok
+}
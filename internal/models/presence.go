@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PresenceFrameHeartbeat is the frame an agent's client sends over the presence
+// WebSocket to show it is still open
+const PresenceFrameHeartbeat = "heartbeat"
+
+// PresenceFrame is a frame sent by an agent's client over the presence WebSocket
+type PresenceFrame struct {
+	Type string `json:"type" example:"heartbeat"`
+}
+
+// AgentPresence is an agent as shown in the agent picker
+type AgentPresence struct {
+	AgentID uuid.UUID `json:"agent_id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	Role    UserRole  `json:"role"`
+	// Online is true while the agent has the app open
+	Online bool `json:"online"`
+	// LastSeenAt is the agent's latest heartbeat; it is omitted once they have been offline a while
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProblemStatus represents the status of a problem
+type ProblemStatus string
+
+const (
+	ProblemOpen   ProblemStatus = "OPEN"
+	ProblemClosed ProblemStatus = "CLOSED"
+)
+
+// Problem is the underlying cause shared by a group of incident tickets
+type Problem struct {
+	ID          uuid.UUID     `json:"id" gorm:"type:char(36);primary_key"`
+	Title       string        `json:"title" gorm:"not null;size:255"`
+	Description string        `json:"description" gorm:"type:text"`
+	Status      ProblemStatus `json:"status" gorm:"not null;default:'OPEN';size:20;index"`
+	Resolution  string        `json:"resolution" gorm:"type:text"`
+	CreatedByID uuid.UUID     `json:"created_by_id" gorm:"type:char(36);not null"`
+	ClosedAt    *time.Time    `json:"closed_at"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	CreatedBy *User    `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	Incidents []Ticket `json:"incidents,omitempty" gorm:"-"`
+}
+
+// TableName specifies the table name for the Problem model
+func (Problem) TableName() string {
+	return "problems"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a problem
+func (p *Problem) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// ProblemIncident links an incident ticket to its problem; a ticket belongs to at most one problem
+type ProblemIncident struct {
+	TicketID  uuid.UUID `json:"ticket_id" gorm:"type:char(36);primaryKey"`
+	ProblemID uuid.UUID `json:"problem_id" gorm:"type:char(36);not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the ProblemIncident model
+func (ProblemIncident) TableName() string {
+	return "problem_incidents"
+}
+
+// CreateProblemRequest represents a request to open a problem
+type CreateProblemRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=255"`
+	Description string `json:"description"`
+}
+
+// LinkIncidentRequest represents a request to link an incident ticket to a problem
+type LinkIncidentRequest struct {
+	TicketID uuid.UUID `json:"ticket_id" validate:"required"`
+}
+
+// CloseProblemRequest represents a request to close a problem
+type CloseProblemRequest struct {
+	Resolution string `json:"resolution" validate:"required,min=1"`
+	// ResolveIncidents also resolves every open linked ticket, posting the resolution on each
+	ResolveIncidents bool `json:"resolve_incidents"`
+}
+
+// CloseProblemResponse reports the closed problem and how many incidents were resolved with it
+type CloseProblemResponse struct {
+	Problem           *Problem `json:"problem"`
+	ResolvedIncidents int      `json:"resolved_incidents"`
+}
+
+// ProblemIncidentCount reports how many incidents are linked to a problem
+type ProblemIncidentCount struct {
+	ProblemID     uuid.UUID     `json:"problem_id"`
+	Title         string        `json:"title"`
+	Status        ProblemStatus `json:"status"`
+	IncidentCount int64         `json:"incident_count"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ReloadResult reports which components picked up a configuration reload.
+// A component that failed keeps its previous settings.
+type ReloadResult struct {
+	Reloaded   []string          `json:"reloaded"`
+	Failed     map[string]string `json:"failed,omitempty"`
+	ReloadedAt time.Time         `json:"reloaded_at"`
+}
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueueSnapshot is the live state of the ticket queue shown on wallboards
+type QueueSnapshot struct {
+	At time.Time `json:"at"`
+	// QueueDepth counts open and in-progress tickets
+	QueueDepth int64 `json:"queue_depth"`
+	// Unassigned counts open tickets no agent has picked up
+	Unassigned int64 `json:"unassigned"`
+	// SLAAtRisk counts unresolved tickets due within the SLA risk window
+	SLAAtRisk int64 `json:"sla_at_risk"`
+	// SLABreached counts unresolved tickets past their due date
+	SLABreached int64 `json:"sla_breached"`
+	// ResolvedToday counts tickets resolved or closed since midnight in the support time zone
+	ResolvedToday int64 `json:"resolved_today"`
+}
+
+// Dashboard is everything a wallboard shows, gathered in one call
+type Dashboard struct {
+	At time.Time `json:"at"`
+	// OpenByPriority counts open and in-progress tickets by priority
+	OpenByPriority map[TicketPriority]int64 `json:"open_by_priority"`
+	Unassigned     int64                    `json:"unassigned"`
+	CreatedToday   int64                    `json:"created_today"`
+	ResolvedToday  int64                    `json:"resolved_today"`
+	// AverageWaitSeconds is how long chats accepted today waited in the queue; null before the first
+	AverageWaitSeconds *float64 `json:"average_wait_seconds"`
+	OnlineAgents       int64    `json:"online_agents"`
+	ActiveChats        int64    `json:"active_chats"`
+	QueuedChats        int64    `json:"queued_chats"`
+}
+
+// PriorityCount is the number of tickets with a priority
+type PriorityCount struct {
+	Priority TicketPriority
+	Count    int64
+}
+
+// TrendMetric is a ticket measure charted over time
+type TrendMetric string
+
+const (
+	// TrendCreated counts tickets created in each bucket
+	TrendCreated TrendMetric = "created"
+	// TrendResolved counts tickets resolved in each bucket
+	TrendResolved TrendMetric = "resolved"
+	// TrendBacklog counts tickets still unresolved at the end of each bucket
+	TrendBacklog TrendMetric = "backlog"
+)
+
+// TrendInterval is the width of a trend bucket
+type TrendInterval string
+
+const (
+	TrendDay  TrendInterval = "day"
+	TrendWeek TrendInterval = "week"
+)
+
+// TrendQuery selects a trend series. From and To are YYYY-MM-DD dates in the
+// office time zone and both are included.
+type TrendQuery struct {
+	Metric   TrendMetric
+	Interval TrendInterval
+	From     string
+	To       string
+}
+
+// TrendPoint is one bucket of a trend series; weeks start on Monday
+type TrendPoint struct {
+	Start time.Time `json:"start"`
+	Value int64     `json:"value"`
+}
+
+// TrendSeries is a ticket measure bucketed over a date range
+type TrendSeries struct {
+	Metric   TrendMetric   `json:"metric"`
+	Interval TrendInterval `json:"interval"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Points   []TrendPoint  `json:"points"`
+}
+
+// BacklogForecast projects when the backlog would clear if tickets keep being
+// created and resolved at their average daily rates over the last few days
+type BacklogForecast struct {
+	// Backlog is the number of tickets unresolved now
+	Backlog        int64   `json:"backlog"`
+	WindowDays     int     `json:"window_days"`
+	CreatedPerDay  float64 `json:"created_per_day"`
+	ResolvedPerDay float64 `json:"resolved_per_day"`
+	// DaysToClear and ClearsOn are null when the backlog is not shrinking
+	DaysToClear *int       `json:"days_to_clear"`
+	ClearsOn    *time.Time `json:"clears_on"`
+}
+
+// BurndownReport is the backlog at the end of each day of a date range, with a
+// forecast of when it would clear
+type BurndownReport struct {
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Points   []TrendPoint    `json:"points"`
+	Forecast BacklogForecast `json:"forecast"`
+}
+
+// TicketFlow is how many tickets were created and resolved in one bucket. The
+// bucket is a YYYY-MM-DD start date, or empty for everything before the range.
+type TicketFlow struct {
+	Bucket   string
+	Created  int64
+	Resolved int64
+}
+
+// CategoryTicketCount is the number of tickets created in a category and how
+// long the resolved ones took, as counted by the database
+type CategoryTicketCount struct {
+	CategoryID        *uuid.UUID
+	Created           int64
+	Resolved          int64
+	ResolutionSeconds float64
+}
+
+// CategoryStats is the ticket volume and resolution time of a category
+type CategoryStats struct {
+	Created  int64 `json:"created"`
+	Resolved int64 `json:"resolved"`
+	// AverageResolutionHours is null when none of the tickets are resolved
+	AverageResolutionHours *float64 `json:"average_resolution_hours"`
+
+	resolutionSeconds float64
+}
+
+// Add counts a category's tickets in these stats
+func (s *CategoryStats) Add(created, resolved int64, resolutionSeconds float64) {
+	s.Created += created
+	s.Resolved += resolved
+	s.resolutionSeconds += resolutionSeconds
+	if s.Resolved > 0 {
+		average := s.resolutionSeconds / float64(s.Resolved) / 3600
+		s.AverageResolutionHours = &average
+	}
+}
+
+// ResolutionSeconds returns the total time the resolved tickets took
+func (s *CategoryStats) ResolutionSeconds() float64 {
+	return s.resolutionSeconds
+}
+
+// CategoryBreakdown is one category's row in a category report
+type CategoryBreakdown struct {
+	// CategoryID is null for tickets without a category
+	CategoryID *uuid.UUID `json:"category_id"`
+	Name       string     `json:"name"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	// Depth is how many parents the category has
+	Depth int `json:"depth"`
+	// Own counts tickets in the category itself
+	Own CategoryStats `json:"own"`
+	// Total adds the tickets of all its subcategories
+	Total CategoryStats `json:"total"`
+}
+
+// CategoryReport breaks down tickets created between From and To by category,
+// listed parents first in name order
+type CategoryReport struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Categories []CategoryBreakdown `json:"categories"`
+}
+
+// RootCauseTicketCount is how many tickets in a category were resolved with a
+// root cause code in a month
+type RootCauseTicketCount struct {
+	Month      string
+	CategoryID *uuid.UUID
+	Code       string
+	Tickets    int64
+}
+
+// RootCauseCount is how many tickets were resolved with a root cause code
+type RootCauseCount struct {
+	Code    string `json:"code" example:"CONFIGURATION_ERROR"`
+	Tickets int64  `json:"tickets"`
+}
+
+// RootCauseCategory is the top root causes of a category in a month
+type RootCauseCategory struct {
+	// CategoryID is null for tickets without a category
+	CategoryID *uuid.UUID       `json:"category_id"`
+	Name       string           `json:"name"`
+	Tickets    int64            `json:"tickets"`
+	Causes     []RootCauseCount `json:"causes"`
+}
+
+// RootCauseMonth is the top root causes of the tickets resolved in a month,
+// overall and in each category
+type RootCauseMonth struct {
+	Month      string              `json:"month" example:"2026-03"`
+	Top        []RootCauseCount    `json:"top"`
+	Categories []RootCauseCategory `json:"categories"`
+}
+
+// RootCauseReport is the top root causes of resolved tickets per month and category
+type RootCauseReport struct {
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Months []RootCauseMonth `json:"months"`
+}
+
+// AgingCounts counts open tickets by how long ago they were opened
+type AgingCounts struct {
+	UnderOneDay      int64 `json:"under_1d"`
+	OneToThreeDays   int64 `json:"1d_to_3d"`
+	ThreeToSevenDays int64 `json:"3d_to_7d"`
+	OverSevenDays    int64 `json:"over_7d"`
+	Total            int64 `json:"total"`
+}
+
+// Add adds other's counts to a
+func (a *AgingCounts) Add(other AgingCounts) {
+	a.UnderOneDay += other.UnderOneDay
+	a.OneToThreeDays += other.OneToThreeDays
+	a.ThreeToSevenDays += other.ThreeToSevenDays
+	a.OverSevenDays += other.OverSevenDays
+	a.Total += other.Total
+}
+
+// AgingTicketCount is the age of the open tickets of a team with a priority
+type AgingTicketCount struct {
+	Priority TicketPriority
+	TeamID   *uuid.UUID
+	TeamName string
+	AgingCounts
+}
+
+// AgingRow is the age of a group of open tickets: those with a priority, those
+// of a team, or those of a team with a priority
+type AgingRow struct {
+	Priority TicketPriority `json:"priority,omitempty"`
+	TeamID   *uuid.UUID     `json:"team_id,omitempty"`
+	// TeamName is "No team" for tickets without a team
+	TeamName string `json:"team_name,omitempty"`
+	AgingCounts
+}
+
+// AgingReport is how long the open tickets have been waiting, overall, by
+// priority, by team, and by team and priority
+type AgingReport struct {
+	At         time.Time   `json:"at"`
+	Total      AgingCounts `json:"total"`
+	ByPriority []AgingRow  `json:"by_priority"`
+	ByTeam     []AgingRow  `json:"by_team"`
+	Rows       []AgingRow  `json:"rows"`
+}
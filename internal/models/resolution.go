@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+// ResolutionField names a resolution detail a category can require before its
+// tickets are resolved
+type ResolutionField string
+
+const (
+	ResolutionFieldCode             ResolutionField = "resolution_code"
+	ResolutionFieldRootCause        ResolutionField = "root_cause"
+	ResolutionFieldRootCauseCode    ResolutionField = "root_cause_code"
+	ResolutionFieldKnowledgeArticle ResolutionField = "knowledge_article_id"
+)
+
+// IsValid reports whether the field is one a category can require
+func (f ResolutionField) IsValid() bool {
+	switch f {
+	case ResolutionFieldCode, ResolutionFieldRootCause, ResolutionFieldRootCauseCode, ResolutionFieldKnowledgeArticle:
+		return true
+	}
+	return false
+}
+
+// TicketResolution holds the details recorded when a ticket is resolved
+type TicketResolution struct {
+	ResolutionCode string `json:"resolution_code,omitempty" validate:"omitempty,max=50" example:"FIXED"`
+	RootCause      string `json:"root_cause,omitempty" validate:"omitempty,max=2000"`
+	// RootCauseCode is one of the configured ROOT_CAUSE_CODES
+	RootCauseCode string `json:"root_cause_code,omitempty" validate:"omitempty,max=50" example:"CONFIGURATION_ERROR"`
+	// KnowledgeArticleID links the knowledge base article that solved the ticket
+	KnowledgeArticleID *uuid.UUID `json:"knowledge_article_id,omitempty"`
+}
+
+// ResolutionRequirementsRequest represents a request to set the details a
+// category's tickets need before they can be resolved
+type ResolutionRequirementsRequest struct {
+	RequiredFields []ResolutionField `json:"required_fields" example:"resolution_code,root_cause"`
+}
@@ -16,6 +16,20 @@ type ErrorResponse struct {
 	Messages []string `json:"messages" example:"[\"Invalid email format\", \"Password too short\"]"`
 }
 
+// ProblemDetails is an RFC 9457 error response, sent in place of ErrorResponse
+// to clients that accept application/problem+json
+// @Description Problem details error response (application/problem+json)
+type ProblemDetails struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Bad Request"`
+	Status   int    `json:"status" example:"400"`
+	Detail   string `json:"detail" example:"Invalid email format"`
+	Instance string `json:"instance" example:"/api/v1/auth/register"`
+	// Errors lists every message when there is more than one
+	Errors    []string `json:"errors,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+}
+
 // HealthResponse represents a comprehensive health check response
 // @Description Comprehensive health check response
 type HealthResponse struct {
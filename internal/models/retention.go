@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionAction is what a retention rule does to the data of closed tickets
+type RetentionAction string
+
+const (
+	// RetentionAnonymizeRequester replaces the personal data of end users whose
+	// tickets are all closed, and removes the outside addresses copied on them
+	RetentionAnonymizeRequester RetentionAction = "anonymize_requester"
+	// RetentionPurgeAttachments deletes the attachments of closed tickets
+	RetentionPurgeAttachments RetentionAction = "purge_attachments"
+)
+
+// RetentionActions lists the actions retention rules can take
+var RetentionActions = []RetentionAction{RetentionAnonymizeRequester, RetentionPurgeAttachments}
+
+// RetentionRule applies an action to tickets closed more than AfterDays days ago
+type RetentionRule struct {
+	Action    RetentionAction `json:"action"`
+	AfterDays int             `json:"after_days"`
+}
+
+// RetentionRuleResult is what a retention rule did, or would do in a dry run
+type RetentionRuleResult struct {
+	RetentionRule
+	// ClosedBefore is the closing time before which tickets are affected
+	ClosedBefore time.Time `json:"closed_before"`
+	// Count is how many requesters or attachments are affected
+	Count int64 `json:"count"`
+	// Tickets is how many tickets they belong to
+	Tickets int64 `json:"tickets"`
+	// Bytes is the size of the affected attachments
+	Bytes int64 `json:"bytes,omitempty"`
+	// SampleIDs are the ids of up to 20 of the affected requesters or attachments
+	SampleIDs []uuid.UUID `json:"sample_ids,omitempty"`
+}
+
+// RetentionReport lists what each configured retention rule did or would do
+type RetentionReport struct {
+	DryRun bool                  `json:"dry_run"`
+	At     time.Time             `json:"at"`
+	Rules  []RetentionRuleResult `json:"rules"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sandbox is an agent's training sandbox: made-up requesters and tickets the
+// agent practices on instead of customer data until it expires
+type Sandbox struct {
+	ID      uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	AgentID uuid.UUID `json:"agent_id" gorm:"type:char(36);not null;uniqueIndex"`
+	// Tickets counts the demo tickets the sandbox was started with
+	Tickets   int       `json:"tickets"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the Sandbox model
+func (Sandbox) TableName() string {
+	return "sandboxes"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a sandbox
+func (s *Sandbox) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
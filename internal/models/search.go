@@ -0,0 +1,32 @@
+package models
+
+import "github.com/google/uuid"
+
+// SearchSuggestion is one typeahead match for the global search box
+type SearchSuggestion struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	// Detail is secondary text, such as a ticket's status or a requester's email
+	Detail string `json:"detail,omitempty"`
+}
+
+// SearchSuggestions groups the typeahead matches for a prefix by kind. Tickets
+// have no tags, so categories take their place.
+type SearchSuggestions struct {
+	Query      string             `json:"query"`
+	Tickets    []SearchSuggestion `json:"tickets"`
+	Requesters []SearchSuggestion `json:"requesters"`
+	Categories []SearchSuggestion `json:"categories"`
+	Articles   []SearchSuggestion `json:"articles"`
+}
+
+// LookupResult is what the agent console's quick lookup resolved a ticket
+// reference or email address to
+type LookupResult struct {
+	Query string `json:"query"`
+	// Tickets are the tickets the reference names, or the latest tickets
+	// requested by the user the email address names
+	Tickets []SearchSuggestion `json:"tickets"`
+	// User is the user with the email address, if any
+	User *SearchSuggestion `json:"user"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareTicketRequest represents a request to create a public share link for a ticket
+type ShareTicketRequest struct {
+	ExpiresIn string `json:"expires_in" example:"72h"`
+}
+
+// TicketShareResponse represents a generated share link
+// @Description Signed, expiring read-only link to a ticket
+type TicketShareResponse struct {
+	URL       string    `json:"url" example:"http://localhost:8080/api/v1/public/shared-tickets/abc123"`
+	Token     string    `json:"token" example:"abc123"`
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-18T10:30:00Z"`
+}
+
+// SharedTicketView represents the read-only view of a ticket exposed through a share link
+// @Description Read-only ticket status and public comments
+type SharedTicketView struct {
+	ID         uuid.UUID       `json:"id"`
+	Title      string          `json:"title"`
+	Status     TicketStatus    `json:"status"`
+	Priority   TicketPriority  `json:"priority"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ResolvedAt *time.Time      `json:"resolved_at"`
+	Comments   []SharedComment `json:"comments"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+// SharedComment represents a public comment shown through a share link
+type SharedComment struct {
+	AuthorName string    `json:"author_name"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
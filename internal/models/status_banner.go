@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BannerSeverity represents how prominently a status banner is shown
+type BannerSeverity string
+
+const (
+	BannerInfo     BannerSeverity = "INFO"
+	BannerWarning  BannerSeverity = "WARNING"
+	BannerCritical BannerSeverity = "CRITICAL"
+)
+
+// StatusBanner announces a known issue to users of the portal and chat widget
+type StatusBanner struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:char(36);primary_key"`
+	Message     string         `json:"message" gorm:"not null;size:500"`
+	Severity    BannerSeverity `json:"severity" gorm:"not null;default:'INFO';size:20"`
+	StartsAt    time.Time      `json:"starts_at" gorm:"not null;index"`
+	EndsAt      *time.Time     `json:"ends_at" gorm:"index"`
+	ProblemID   *uuid.UUID     `json:"problem_id" gorm:"type:char(36)"`
+	Resolution  string         `json:"resolution" gorm:"size:500"`
+	ResolvedAt  *time.Time     `json:"resolved_at"`
+	CreatedByID uuid.UUID      `json:"created_by_id" gorm:"type:char(36);not null"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// SubscriberCount is the number of people waiting on the issue, a gauge of its impact
+	SubscriberCount int64 `json:"subscriber_count" gorm:"-"`
+
+	// Relationships
+	// Categories limits the banner to tickets in these categories; empty means it applies everywhere
+	Categories []Category `json:"categories,omitempty" gorm:"many2many:status_banner_categories"`
+}
+
+// TableName specifies the table name for the StatusBanner model
+func (StatusBanner) TableName() string {
+	return "status_banners"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a status banner
+func (b *StatusBanner) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive reports whether the banner is shown at the given time
+func (b *StatusBanner) IsActive(at time.Time) bool {
+	return !at.Before(b.StartsAt) && (b.EndsAt == nil || at.Before(*b.EndsAt))
+}
+
+// AffectsCategory reports whether the banner applies to tickets in a category
+func (b *StatusBanner) AffectsCategory(categoryID uuid.UUID) bool {
+	if len(b.Categories) == 0 {
+		return true
+	}
+	for _, category := range b.Categories {
+		if category.ID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// BannerSubscription records an address to notify when a banner's issue is resolved
+type BannerSubscription struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	BannerID  uuid.UUID `json:"banner_id" gorm:"type:char(36);not null;index"`
+	Email     string    `json:"email" gorm:"not null;type:text;serializer:encrypted"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the BannerSubscription model
+func (BannerSubscription) TableName() string {
+	return "banner_subscriptions"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a banner subscription
+func (s *BannerSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// CreateStatusBannerRequest represents a request to publish a status banner
+type CreateStatusBannerRequest struct {
+	Message     string         `json:"message" validate:"required,min=1,max=500"`
+	Severity    BannerSeverity `json:"severity" validate:"required,oneof=INFO WARNING CRITICAL"`
+	CategoryIDs []uuid.UUID    `json:"category_ids"`
+	StartsAt    *time.Time     `json:"starts_at"`
+	EndsAt      *time.Time     `json:"ends_at"`
+	ProblemID   *uuid.UUID     `json:"problem_id"`
+}
+
+// ResolveStatusBannerRequest represents a request to end a banner and notify its subscribers
+type ResolveStatusBannerRequest struct {
+	Resolution string `json:"resolution" validate:"max=500"`
+}
+
+// SubscribeBannerRequest represents a request to be notified when a banner's issue is resolved
+type SubscribeBannerRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Team is a group of support staff; managers without global visibility only see their teams' tickets
+type Team struct {
+	ID          uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	Name        string    `json:"name" gorm:"not null;size:100;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relationships
+	Members []User `json:"members,omitempty" gorm:"many2many:team_members;joinForeignKey:TeamID;joinReferences:UserID"`
+}
+
+// TableName specifies the table name for the Team model
+func (Team) TableName() string {
+	return "teams"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a team
+func (t *Team) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TeamMember links a user to a team
+type TeamMember struct {
+	TeamID    uuid.UUID `json:"team_id" gorm:"type:char(36);primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the TeamMember model
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// CreateTeamRequest represents a request to create a team
+type CreateTeamRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// AddTeamMemberRequest represents a request to add a user to a team
+type AddTeamMemberRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// ThumbnailStatus tracks the thumbnails of an image attachment
+type ThumbnailStatus string
+
+const (
+	// ThumbnailPending images wait for the thumbnail job
+	ThumbnailPending ThumbnailStatus = "pending"
+	// ThumbnailReady images have a thumbnail of every size
+	ThumbnailReady ThumbnailStatus = "ready"
+	// ThumbnailFailed images could not be read, or are too large to thumbnail
+	ThumbnailFailed ThumbnailStatus = "failed"
+)
+
+// ThumbnailTypes are the image types thumbnails are made of
+var ThumbnailTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// ThumbnailSize is a size thumbnails are made in
+type ThumbnailSize struct {
+	Name string
+	// MaxSide is the longest side, in pixels, of thumbnails of this size
+	MaxSide int
+}
+
+// ThumbnailSizes are the sizes every image attachment is thumbnailed in
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxSide: 160},
+	{Name: "medium", MaxSide: 480},
+	{Name: "large", MaxSide: 1280},
+}
+
+// Fit returns the size of a width x height image scaled down, keeping its
+// aspect ratio, so its longest side is at most MaxSide. Smaller images keep
+// their size.
+func (s ThumbnailSize) Fit(width, height int) (int, int) {
+	longest := max(width, height)
+	if longest <= s.MaxSide {
+		return width, height
+	}
+	return max(width*s.MaxSide/longest, 1), max(height*s.MaxSide/longest, 1)
+}
+
+// ThumbnailSizeNamed returns the thumbnail size with the given name
+func ThumbnailSizeNamed(name string) (ThumbnailSize, bool) {
+	for _, size := range ThumbnailSizes {
+		if size.Name == name {
+			return size, true
+		}
+	}
+	return ThumbnailSize{}, false
+}
+
+// AttachmentPreview is a thumbnail of an image attachment that clients can
+// show instead of downloading the original
+type AttachmentPreview struct {
+	Size   string `json:"size" example:"small"`
+	URL    string `json:"url" example:"/api/v1/tickets/7c9e6679-7425-40de-944b-e07fc1f90ae7/attachments/9b2d5f5e-3a0b-4a5e-8d7c-1f2e3d4c5b6a/thumbnails/small"`
+	Width  int    `json:"width" example:"160"`
+	Height int    `json:"height" example:"120"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,10 +30,20 @@ const (
 
 // Ticket represents a support ticket in the system with time-series versioning
 type Ticket struct {
-	// Time-series fields
-	ID             uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	// Time-series fields. ID is the business ID shared by every version of the
+	// ticket, which comments, attachments and other rows refer to; VersionID
+	// identifies one version.
+	VersionID      uuid.UUID  `json:"version_id" gorm:"type:char(36);primary_key"`
+	ID             uuid.UUID  `json:"id" gorm:"type:char(36);not null;index"`
 	CreationTime   time.Time  `json:"creation_time" gorm:"autoCreateTime;not null"`
 	ExpirationTime *time.Time `json:"expiration_time" gorm:"index"`
+	// DeletedAt and DeletedByID are set while the ticket is in the trash, where
+	// it is expired but can still be restored
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedByID *uuid.UUID `json:"deleted_by_id,omitempty" gorm:"type:char(36)"`
+	// SandboxID is set on the demo tickets of a training sandbox, which only
+	// its agent sees
+	SandboxID *uuid.UUID `json:"sandbox_id,omitempty" gorm:"type:char(36);index"`
 
 	// Business fields
 	Title           string         `json:"title" gorm:"not null;size:255"`
@@ -41,19 +52,38 @@ type Ticket struct {
 	Priority        TicketPriority `json:"priority" gorm:"not null;default:'MEDIUM';size:20"`
 	CategoryID      *uuid.UUID     `json:"category_id" gorm:"type:char(36)"`
 	AssignedAgentID *uuid.UUID     `json:"assigned_agent_id" gorm:"type:char(36)"`
+	TeamID          *uuid.UUID     `json:"team_id" gorm:"type:char(36);index"`
 	CreatedByID     uuid.UUID      `json:"created_by_id" gorm:"type:char(36);not null"`
+	OpenedBy        *uuid.UUID     `json:"opened_by" gorm:"type:char(36)"`
 	EscalatedAt     *time.Time     `json:"escalated_at"`
 	EscalatedTo     *uuid.UUID     `json:"escalated_to" gorm:"type:char(36)"`
-	ResolvedAt      *time.Time     `json:"resolved_at"`
-	DueDate         *time.Time     `json:"due_date"`
+	// EscalationReason is why the ticket was escalated, as given by the agent
+	EscalationReason string     `json:"escalation_reason,omitempty" gorm:"type:text"`
+	ResolvedAt       *time.Time `json:"resolved_at"`
+	DueDate          *time.Time `json:"due_date"`
+	// ResolutionCode, RootCause, RootCauseCode and KnowledgeArticleID are
+	// recorded when the ticket is resolved
+	ResolutionCode     string     `json:"resolution_code,omitempty" gorm:"size:50"`
+	RootCause          string     `json:"root_cause,omitempty" gorm:"type:text"`
+	RootCauseCode      string     `json:"root_cause_code,omitempty" gorm:"size:50;index"`
+	KnowledgeArticleID *uuid.UUID `json:"knowledge_article_id,omitempty" gorm:"type:char(36)"`
 
 	// Relationships
-	Category        *Category    `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	AssignedAgent   *User        `json:"assigned_agent,omitempty" gorm:"foreignKey:AssignedAgentID"`
-	CreatedBy       *User        `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
-	EscalatedToUser *User        `json:"escalated_to_user,omitempty" gorm:"foreignKey:EscalatedTo"`
-	Comments        []Comment    `json:"comments,omitempty" gorm:"foreignKey:TicketID"`
-	Attachments     []Attachment `json:"attachments,omitempty" gorm:"foreignKey:TicketID"`
+	Category        *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	AssignedAgent   *User     `json:"assigned_agent,omitempty" gorm:"foreignKey:AssignedAgentID"`
+	Team            *Team     `json:"team,omitempty" gorm:"foreignKey:TeamID"`
+	CreatedBy       *User     `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	OpenedByUser    *User     `json:"opened_by_user,omitempty" gorm:"foreignKey:OpenedBy"`
+	EscalatedToUser *User     `json:"escalated_to_user,omitempty" gorm:"foreignKey:EscalatedTo"`
+	// Every version shares the ticket's id, so it cannot be the target of a
+	// foreign key
+	Comments    []Comment    `json:"comments,omitempty" gorm:"foreignKey:TicketID;references:ID;constraint:-"`
+	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:TicketID;references:ID;constraint:-"`
+	// EscalationEvents is the ticket's escalation history, oldest first
+	EscalationEvents []TicketEscalationEvent `json:"escalation_events,omitempty" gorm:"foreignKey:TicketID;references:ID;constraint:-"`
+
+	// Matches shows where the ticket matched a search it was listed by
+	Matches []SearchMatch `json:"matches,omitempty" gorm:"-"`
 }
 
 // Category represents a ticket category
@@ -63,7 +93,10 @@ type Category struct {
 	Description string     `json:"description" gorm:"size:500"`
 	ParentID    *uuid.UUID `json:"parent_id" gorm:"type:char(36)"`
 	IsActive    bool       `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	// RequiredResolutionFields must be filled in before the category's tickets
+	// can be resolved or closed
+	RequiredResolutionFields []ResolutionField `json:"required_resolution_fields,omitempty" gorm:"serializer:json"`
+	CreatedAt                time.Time         `json:"created_at" gorm:"autoCreateTime"`
 
 	// Relationships
 	Parent   *Category  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
@@ -73,37 +106,66 @@ type Category struct {
 
 // Comment represents a comment on a ticket
 type Comment struct {
-	ID         uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	TicketID   uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null"`
-	UserID     uuid.UUID `json:"user_id" gorm:"type:char(36);not null"`
-	Content    string    `json:"content" gorm:"not null;type:text"`
-	IsInternal bool      `json:"is_internal" gorm:"default:false"`
-	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID       uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	TicketID uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null"`
+	UserID   uuid.UUID `json:"user_id" gorm:"type:char(36);not null"`
+	Content  string    `json:"content" gorm:"not null;type:text"`
+	// Format is how Content is written; HTML is Content rendered and sanitized for display
+	Format     CommentFormat `json:"format" gorm:"size:20;not null;default:plain"`
+	HTML       string        `json:"html" gorm:"-"`
+	IsInternal bool          `json:"is_internal" gorm:"default:false"`
+	CreatedAt  time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
 
 	// Relationships
-	Ticket *Ticket `json:"ticket,omitempty" gorm:"foreignKey:TicketID"`
-	User   *User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Ticket      *Ticket      `json:"ticket,omitempty" gorm:"foreignKey:TicketID;references:ID;constraint:-"`
+	User        *User        `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:CommentID"`
 }
 
 // Attachment represents a file attachment on a ticket
 type Attachment struct {
-	ID             uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
-	TicketID       uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null"`
-	Filename       string    `json:"filename" gorm:"not null;size:255"`
-	FilePath       string    `json:"file_path" gorm:"not null;size:500"`
-	FileSize       int64     `json:"file_size" gorm:"not null"`
-	MimeType       string    `json:"mime_type" gorm:"not null;size:100"`
-	UploadedByID   uuid.UUID `json:"uploaded_by_id" gorm:"type:char(36);not null"`
-	IsVirusScanned bool      `json:"is_virus_scanned" gorm:"default:false"`
-	IsSafe         bool      `json:"is_safe" gorm:"default:false"`
-	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID             uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	TicketID       uuid.UUID  `json:"ticket_id" gorm:"type:char(36);not null"`
+	CommentID      *uuid.UUID `json:"comment_id" gorm:"type:char(36);index"`
+	Filename       string     `json:"filename" gorm:"not null;size:255"`
+	FilePath       string     `json:"file_path" gorm:"not null;type:text;serializer:encrypted"`
+	FileSize       int64      `json:"file_size" gorm:"not null"`
+	MimeType       string     `json:"mime_type" gorm:"not null;size:100"`
+	UploadedByID   uuid.UUID  `json:"uploaded_by_id" gorm:"type:char(36);not null"`
+	IsVirusScanned bool       `json:"is_virus_scanned" gorm:"default:false"`
+	IsSafe         bool       `json:"is_safe" gorm:"default:false"`
+	// Inline attachments are images embedded in the ticket description
+	Inline bool `json:"inline" gorm:"not null;default:false"`
+	// Width and Height are the size in pixels of images, known once their thumbnails are made
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// ThumbnailStatus is set for images of a type thumbnails can be made of
+	ThumbnailStatus ThumbnailStatus `json:"thumbnail_status,omitempty" gorm:"size:20;index"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
+
+	// Previews are the thumbnails of the image, once they are ready
+	Previews []AttachmentPreview `json:"previews,omitempty" gorm:"-"`
 
 	// Relationships
-	Ticket     *Ticket `json:"ticket,omitempty" gorm:"foreignKey:TicketID"`
+	Ticket     *Ticket `json:"ticket,omitempty" gorm:"foreignKey:TicketID;references:ID;constraint:-"`
 	UploadedBy *User   `json:"uploaded_by,omitempty" gorm:"foreignKey:UploadedByID"`
 }
 
+// TicketView records when a user last opened a ticket, for their recently viewed list
+type TicketView struct {
+	UserID   uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	TicketID uuid.UUID `json:"ticket_id" gorm:"type:char(36);primaryKey"`
+	ViewedAt time.Time `json:"viewed_at" gorm:"not null;index"`
+}
+
+// TicketPin marks a ticket a user has starred to keep an eye on
+type TicketPin struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	TicketID  uuid.UUID `json:"ticket_id" gorm:"type:char(36);primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
 // TableName specifies the table name for the Ticket model
 func (Ticket) TableName() string {
 	return "tickets"
@@ -124,11 +186,29 @@ func (Attachment) TableName() string {
 	return "attachments"
 }
 
+// TableName specifies the table name for the TicketView model
+func (TicketView) TableName() string {
+	return "ticket_views"
+}
+
+// TableName specifies the table name for the TicketPin model
+func (TicketPin) TableName() string {
+	return "ticket_pins"
+}
+
 // BeforeCreate is a GORM hook that runs before creating a ticket
 func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
+	if t.VersionID == uuid.Nil {
+		t.VersionID = uuid.New()
+	}
 	if t.ID == uuid.Nil {
 		t.ID = uuid.New()
 	}
+	// The requester may change later; remember who originally opened the ticket
+	if t.OpenedBy == nil && t.CreatedByID != uuid.Nil {
+		openedBy := t.CreatedByID
+		t.OpenedBy = &openedBy
+	}
 	return nil
 }
 
@@ -145,6 +225,22 @@ func (c *Comment) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
 	}
+	if c.Format == "" {
+		c.Format = CommentFormatPlain
+	}
+	return nil
+}
+
+// AfterSave is a GORM hook that renders a comment once it is written
+func (c *Comment) AfterSave(tx *gorm.DB) error {
+	c.HTML = RenderComment(c.Content, c.Format)
+	return nil
+}
+
+// AfterFind is a GORM hook that renders a comment when it is read, so
+// comments always show with the current sanitization rules
+func (c *Comment) AfterFind(tx *gorm.DB) error {
+	c.HTML = RenderComment(c.Content, c.Format)
 	return nil
 }
 
@@ -153,6 +249,27 @@ func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
+	if a.ThumbnailStatus == "" && ThumbnailTypes[a.MimeType] {
+		a.ThumbnailStatus = ThumbnailPending
+	}
+	return nil
+}
+
+// AfterFind is a GORM hook that lists the previews of an image attachment
+func (a *Attachment) AfterFind(tx *gorm.DB) error {
+	a.Previews = nil
+	if a.ThumbnailStatus != ThumbnailReady {
+		return nil
+	}
+	for _, size := range ThumbnailSizes {
+		width, height := size.Fit(a.Width, a.Height)
+		a.Previews = append(a.Previews, AttachmentPreview{
+			Size:   size.Name,
+			URL:    fmt.Sprintf("/api/v1/tickets/%s/attachments/%s/thumbnails/%s", a.TicketID, a.ID, size.Name),
+			Width:  width,
+			Height: height,
+		})
+	}
 	return nil
 }
 
@@ -181,11 +298,16 @@ func (t *Ticket) IsOverdue() bool {
 
 // TimeSeriesEntity interface implementation
 
-// GetID returns the unique identifier of the ticket
+// GetID returns the business ID of the ticket, shared by all its versions
 func (t *Ticket) GetID() uuid.UUID {
 	return t.ID
 }
 
+// GetVersionID returns the unique identifier of this version of the ticket
+func (t *Ticket) GetVersionID() uuid.UUID {
+	return t.VersionID
+}
+
 // GetCreationTime returns when this version was created
 func (t *Ticket) GetCreationTime() time.Time {
 	return t.CreationTime
@@ -206,25 +328,33 @@ func (t *Ticket) IsCurrentVersion() bool {
 	return t.ExpirationTime == nil
 }
 
-// Clone creates a new version of this ticket with a new ID and current creation time
+// Clone creates a new version of this ticket with a new version ID and current creation time
 func (t *Ticket) Clone() Cloneable {
 	// Create a new ticket with the same business fields but new time-series fields
 	cloned := &Ticket{
-		Title:           t.Title,
-		Description:     t.Description,
-		Status:          t.Status,
-		Priority:        t.Priority,
-		CategoryID:      t.CategoryID,
-		AssignedAgentID: t.AssignedAgentID,
-		CreatedByID:     t.CreatedByID,
-		EscalatedAt:     t.EscalatedAt,
-		EscalatedTo:     t.EscalatedTo,
-		ResolvedAt:      t.ResolvedAt,
-		DueDate:         t.DueDate,
-		CreationTime:    time.Now(),
-		ExpirationTime:  nil, // New version is current
+		VersionID:          uuid.New(),
+		ID:                 t.ID,
+		SandboxID:          t.SandboxID,
+		Title:              t.Title,
+		Description:        t.Description,
+		Status:             t.Status,
+		Priority:           t.Priority,
+		CategoryID:         t.CategoryID,
+		AssignedAgentID:    t.AssignedAgentID,
+		TeamID:             t.TeamID,
+		CreatedByID:        t.CreatedByID,
+		OpenedBy:           t.OpenedBy,
+		EscalatedAt:        t.EscalatedAt,
+		EscalatedTo:        t.EscalatedTo,
+		EscalationReason:   t.EscalationReason,
+		ResolvedAt:         t.ResolvedAt,
+		DueDate:            t.DueDate,
+		ResolutionCode:     t.ResolutionCode,
+		RootCause:          t.RootCause,
+		RootCauseCode:      t.RootCauseCode,
+		KnowledgeArticleID: t.KnowledgeArticleID,
+		CreationTime:       time.Now(),
+		ExpirationTime:     nil, // New version is current
 	}
-	// Generate new ID for the cloned ticket
-	cloned.ID = uuid.New()
 	return cloned
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TicketEventType identifies a change pushed over the ticket WebSocket
+type TicketEventType string
+
+const (
+	TicketEventCreated       TicketEventType = "ticket.created"
+	TicketEventUpdated       TicketEventType = "ticket.updated"
+	TicketEventAssigned      TicketEventType = "ticket.assigned"
+	TicketEventStatusChanged TicketEventType = "ticket.status_changed"
+)
+
+// TicketEvent is a frame sent by the server over the ticket WebSocket. It
+// carries the fields dashboards list; fetch the ticket for the rest.
+type TicketEvent struct {
+	Type     TicketEventType `json:"type"`
+	TicketID uuid.UUID       `json:"ticket_id"`
+	Title    string          `json:"title"`
+	Status   TicketStatus    `json:"status"`
+	Priority TicketPriority  `json:"priority"`
+	// RequesterID is the ticket's created_by_id
+	RequesterID uuid.UUID  `json:"requester_id"`
+	TeamID      *uuid.UUID `json:"team_id"`
+	// AssignedAgentID is null once a ticket is returned to the pool
+	AssignedAgentID *uuid.UUID `json:"assigned_agent_id"`
+	// PreviousAgentID is set on assignment events to the agent who had the ticket before
+	PreviousAgentID *uuid.UUID `json:"previous_agent_id,omitempty"`
+	ActorID         uuid.UUID  `json:"actor_id"`
+	At              time.Time  `json:"at"`
+	// SandboxID keeps the events of demo tickets within their training sandbox
+	SandboxID *uuid.UUID `json:"-"`
+}
+
+// NewTicketEvent describes a change made by actorID to ticket
+func NewTicketEvent(eventType TicketEventType, ticket *Ticket, actorID uuid.UUID) *TicketEvent {
+	return &TicketEvent{
+		Type:            eventType,
+		TicketID:        ticket.ID,
+		Title:           ticket.Title,
+		Status:          ticket.Status,
+		Priority:        ticket.Priority,
+		RequesterID:     ticket.CreatedByID,
+		TeamID:          ticket.TeamID,
+		AssignedAgentID: ticket.AssignedAgentID,
+		ActorID:         actorID,
+		At:              time.Now().UTC(),
+		SandboxID:       ticket.SandboxID,
+	}
+}
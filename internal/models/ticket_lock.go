@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TicketLock is an advisory lock warning other agents that a ticket is being
+// edited. It does not block changes; the ticket's version still does that.
+type TicketLock struct {
+	TicketID   uuid.UUID `json:"ticket_id"`
+	HolderID   uuid.UUID `json:"holder_id"`
+	HolderName string    `json:"holder_name" example:"Jane Smith"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	// ExpiresAt is when the lock lapses unless its holder sends a heartbeat
+	ExpiresAt time.Time `json:"expires_at"`
+}
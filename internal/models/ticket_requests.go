@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,7 +13,14 @@ type CreateTicketRequest struct {
 	Description string         `json:"description" validate:"required,min=1"`
 	Priority    TicketPriority `json:"priority" validate:"required,oneof=LOW MEDIUM HIGH CRITICAL"`
 	CategoryID  *uuid.UUID     `json:"category_id"`
-	DueDate     *time.Time     `json:"due_date"`
+	TeamID      *uuid.UUID     `json:"team_id"`
+	DueDate     *DueDate       `json:"due_date" swaggertype:"string" example:"2026-03-31"`
+	// RequesterID lets staff log a ticket on behalf of another user
+	RequesterID *uuid.UUID `json:"requester_id"`
+	// AttachmentIDs are draft attachments uploaded while writing the ticket
+	AttachmentIDs []uuid.UUID `json:"attachment_ids" validate:"omitempty,max=20,unique"`
+	// InlineImageIDs are images uploaded for the description to attach to the ticket
+	InlineImageIDs []uuid.UUID `json:"inline_image_ids" validate:"omitempty,max=20,unique"`
 }
 
 // UpdateTicketRequest represents a request to update a ticket
@@ -21,12 +29,54 @@ type UpdateTicketRequest struct {
 	Description *string         `json:"description" validate:"omitempty,min=1"`
 	Priority    *TicketPriority `json:"priority" validate:"omitempty,oneof=LOW MEDIUM HIGH CRITICAL"`
 	CategoryID  *uuid.UUID      `json:"category_id"`
-	DueDate     *time.Time      `json:"due_date"`
+	TeamID      *uuid.UUID      `json:"team_id"`
+	DueDate     *DueDate        `json:"due_date" swaggertype:"string" example:"2026-03-31T17:00:00+02:00"`
+	// InlineImageIDs are images uploaded for the description to attach to the ticket
+	InlineImageIDs []uuid.UUID `json:"inline_image_ids" validate:"omitempty,max=20,unique"`
+}
+
+// DueDate is a due date sent as an RFC 3339 timestamp with an offset, or as a
+// YYYY-MM-DD date meaning the end of that day in the time zone of the user
+// setting it
+type DueDate struct {
+	At time.Time
+	// Date is set instead of At when only a date was given
+	Date string
+}
+
+// UnmarshalText parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func (d *DueDate) UnmarshalText(text []byte) error {
+	if at, err := time.Parse(time.RFC3339Nano, string(text)); err == nil {
+		*d = DueDate{At: at}
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, string(text)); err == nil {
+		*d = DueDate{Date: string(text)}
+		return nil
+	}
+	return fmt.Errorf("due_date must be an RFC 3339 timestamp or a YYYY-MM-DD date (got %q)", text)
+}
+
+// In returns the due time in UTC, taking a date as the last second of that day
+// in location. It returns nil for a nil due date.
+func (d *DueDate) In(location *time.Location) *time.Time {
+	if d == nil {
+		return nil
+	}
+	if d.Date == "" {
+		at := d.At.UTC()
+		return &at
+	}
+	day, _ := time.ParseInLocation(time.DateOnly, d.Date, location)
+	end := day.AddDate(0, 0, 1).Add(-time.Second).UTC()
+	return &end
 }
 
 // UpdateTicketStatusRequest represents a request to update ticket status
 type UpdateTicketStatusRequest struct {
 	Status TicketStatus `json:"status" validate:"required,oneof=OPEN IN_PROGRESS RESOLVED CLOSED"`
+	// The resolution details are recorded when resolving or closing the ticket
+	TicketResolution
 }
 
 // AssignTicketRequest represents a request to assign a ticket to an agent
@@ -34,40 +84,108 @@ type AssignTicketRequest struct {
 	AgentID uuid.UUID `json:"agent_id" validate:"required"`
 }
 
+// ChangeRequesterRequest represents a request to transfer a ticket to another requester
+type ChangeRequesterRequest struct {
+	RequesterID uuid.UUID `json:"requester_id" validate:"required"`
+}
+
+// RestoreTicketVersionRequest represents a request to make an earlier version of a ticket current again
+type RestoreTicketVersionRequest struct {
+	VersionID uuid.UUID `json:"version_id" validate:"required"`
+}
+
 // EscalateTicketRequest represents a request to escalate a ticket
 type EscalateTicketRequest struct {
 	EscalatedTo uuid.UUID `json:"escalated_to" validate:"required"`
 	Reason      string    `json:"reason" validate:"required,min=1"`
 }
 
+// UnassignTicketRequest represents a request to return a ticket to the pool
+type UnassignTicketRequest struct {
+	Reason string `json:"reason" validate:"required,min=1"`
+}
+
+// DeEscalateTicketRequest represents a request to hand an escalated ticket back to its assignee
+type DeEscalateTicketRequest struct {
+	Note string `json:"note" validate:"required,min=1" example:"Approved the refund; please let the customer know"`
+}
+
 // CreateCommentRequest represents a request to create a comment
 type CreateCommentRequest struct {
-	Content    string `json:"content" validate:"required,min=1"`
-	IsInternal bool   `json:"is_internal"`
+	Content string `json:"content" validate:"required,min=1"`
+	// Format defaults to plain
+	Format     CommentFormat `json:"format" validate:"omitempty,oneof=plain markdown" example:"markdown"`
+	IsInternal bool          `json:"is_internal"`
 }
 
 // UpdateCommentRequest represents a request to update a comment
 type UpdateCommentRequest struct {
-	Content string `json:"content" validate:"required,min=1"`
+	Content string        `json:"content" validate:"required,min=1"`
+	Format  CommentFormat `json:"format" validate:"omitempty,oneof=plain markdown" example:"markdown"`
 }
 
 // TicketFilter represents filters for ticket queries
 type TicketFilter struct {
-	Status      *TicketStatus   `json:"status"`
-	Priority    *TicketPriority `json:"priority"`
-	CategoryID  *uuid.UUID      `json:"category_id"`
-	AssignedTo  *uuid.UUID      `json:"assigned_to"`
-	CreatedBy   *uuid.UUID      `json:"created_by"`
-	IsEscalated *bool           `json:"is_escalated"`
-	IsOverdue   *bool           `json:"is_overdue"`
-	DateFrom    *time.Time      `json:"date_from"`
-	DateTo      *time.Time      `json:"date_to"`
-	Search      string          `json:"search"`
+	Status     *TicketStatus   `json:"status"`
+	Priority   *TicketPriority `json:"priority"`
+	CategoryID *uuid.UUID      `json:"category_id"`
+	AssignedTo *uuid.UUID      `json:"assigned_to"`
+	// Unassigned limits the list to tickets no agent is assigned to
+	Unassigned  bool       `json:"unassigned"`
+	TeamID      *uuid.UUID `json:"team_id"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	IsEscalated *bool      `json:"is_escalated"`
+	IsOverdue   *bool      `json:"is_overdue"`
+	DateFrom    *time.Time `json:"date_from"`
+	DateTo      *time.Time `json:"date_to"`
+	Search      string     `json:"search"`
+	// SearchIn lists where Search looks; empty means the title and description
+	SearchIn []TicketSearchField `json:"search_in"`
+	// SearchInternal lets Search match internal notes, for staff who can read them
+	SearchInternal bool `json:"-"`
+	// PinnedBy limits the list to the tickets this user has pinned
+	PinnedBy *uuid.UUID `json:"-"`
+}
+
+// TicketSearchField names a part of a ticket free-text search can look in
+type TicketSearchField string
+
+const (
+	SearchInTitle       TicketSearchField = "title"
+	SearchInDescription TicketSearchField = "description"
+	SearchInComments    TicketSearchField = "comments"
+	SearchInAttachments TicketSearchField = "attachments"
+)
+
+// IsValid reports whether the search field is one tickets can be searched by
+func (f TicketSearchField) IsValid() bool {
+	switch f {
+	case SearchInTitle, SearchInDescription, SearchInComments, SearchInAttachments:
+		return true
+	}
+	return false
+}
+
+// SearchFields returns where the filter's search looks
+func (f *TicketFilter) SearchFields() []TicketSearchField {
+	if len(f.SearchIn) == 0 {
+		return []TicketSearchField{SearchInTitle, SearchInDescription}
+	}
+	return f.SearchIn
+}
+
+// SearchMatch shows where a listed ticket matched a free-text search
+type SearchMatch struct {
+	Field TicketSearchField `json:"field"`
+	// SourceID is the matching comment or attachment
+	SourceID *uuid.UUID `json:"source_id,omitempty"`
+	// Snippet is HTML-escaped text around the match, with the match wrapped in <mark>
+	Snippet string `json:"snippet"`
 }
 
 // TicketSort represents sorting options for ticket queries
 type TicketSort struct {
-	Field     string `json:"field" validate:"required,oneof=creation_time priority status title"`
+	Field     string `json:"field" validate:"required,oneof=creation_time due_date priority status title"`
 	Direction string `json:"direction" validate:"required,oneof=asc desc"`
 }
 
@@ -77,15 +195,37 @@ type TicketQuery struct {
 	Sort     *TicketSort   `json:"sort"`
 	Page     int           `json:"page" validate:"min=1"`
 	PageSize int           `json:"page_size" validate:"min=1,max=100"`
+	// SkipCount leaves out the total, which costs a count over every matching ticket
+	SkipCount bool `json:"skip_count"`
 }
 
 // TicketListResponse represents a paginated list of tickets
 type TicketListResponse struct {
-	Tickets    []Ticket `json:"tickets"`
-	Total      int64    `json:"total"`
-	Page       int      `json:"page"`
-	PageSize   int      `json:"page_size"`
-	TotalPages int      `json:"total_pages"`
+	Tickets []Ticket `json:"tickets"`
+	// Total and TotalPages are left out when the count was skipped
+	Total      *int64 `json:"total,omitempty"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	// HasMore reports whether a later page has tickets
+	HasMore bool `json:"has_more"`
+}
+
+// TrashedTicket is a deleted ticket waiting in the trash
+type TrashedTicket struct {
+	Ticket
+	// PurgeAt is when the ticket is deleted for good unless it is restored;
+	// null when the trash is kept
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// TrashedTicketListResponse is a page of the tickets in the trash
+type TrashedTicketListResponse struct {
+	Tickets    []TrashedTicket `json:"tickets"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
 }
 
 // TicketStats represents ticket statistics
@@ -138,3 +278,33 @@ type TicketHistory struct {
 type TicketHistoryResponse struct {
 	History []TicketHistory `json:"history"`
 }
+
+// SimilarTicketsRequest is a ticket being drafted, checked for likely duplicates
+type SimilarTicketsRequest struct {
+	Title       string `json:"title" validate:"required,max=255"`
+	Description string `json:"description"`
+	// RequesterID lets staff check the tickets of the user they are logging a ticket for
+	RequesterID *uuid.UUID `json:"requester_id"`
+	// IncludeOthers also looks through other users' tickets, for roles that can read them
+	IncludeOthers bool `json:"include_others"`
+	// Limit is how many tickets to return, 1 to 20; defaults to 5
+	Limit int `json:"limit" validate:"omitempty,min=1,max=20"`
+}
+
+// SimilarTicket is an open ticket that looks like a duplicate of a draft
+type SimilarTicket struct {
+	ID          uuid.UUID      `json:"id"`
+	Title       string         `json:"title"`
+	Status      TicketStatus   `json:"status"`
+	Priority    TicketPriority `json:"priority"`
+	CreatedByID uuid.UUID      `json:"created_by_id"`
+	// Own is set on the requester's own tickets
+	Own bool `json:"own"`
+	// Score ranks how closely the ticket matches the draft; higher is closer
+	Score float64 `json:"score"`
+}
+
+// SimilarTicketsResponse lists the open tickets most like a draft, closest first
+type SimilarTicketsResponse struct {
+	Tickets []SimilarTicket `json:"tickets"`
+}
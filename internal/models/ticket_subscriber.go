@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketSubscriber is an outside address copied on a ticket. Subscribers need
+// no account: they are emailed the ticket's public comments and may reply to them.
+type TicketSubscriber struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	TicketID  uuid.UUID `json:"ticket_id" gorm:"type:char(36);not null;index"`
+	Email     string    `json:"email" gorm:"not null;type:text;serializer:encrypted"`
+	Name      string    `json:"name" gorm:"size:100"`
+	AddedByID uuid.UUID `json:"added_by_id" gorm:"type:char(36);not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the TicketSubscriber model
+func (TicketSubscriber) TableName() string {
+	return "ticket_subscribers"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a ticket subscriber
+func (s *TicketSubscriber) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// FirstName returns the first word of the subscriber's name, for greetings
+func (s *TicketSubscriber) FirstName() string {
+	first, _, _ := strings.Cut(strings.TrimSpace(s.Name), " ")
+	return first
+}
+
+// AddTicketSubscriberRequest represents a request to copy an email address on a ticket
+type AddTicketSubscriberRequest struct {
+	Email string `json:"email" validate:"required,email,max=255" example:"manager@partner.com"`
+	Name  string `json:"name" validate:"max=100" example:"Pat Jones"`
+}
+
+// TicketSubscriberListResponse lists the addresses copied on a ticket
+type TicketSubscriberListResponse struct {
+	Subscribers []TicketSubscriber `json:"subscribers"`
+}
@@ -7,8 +7,8 @@ import (
 )
 
 // Cloneable defines an interface for types that can be deeply cloned
-// Clone should return a new instance with a new ID and appropriate fields
-// (for time-series, this means a new version, not a shallow copy)
+// Clone should return a new instance with a new version ID and appropriate fields
+// (for time-series, this means a new version of the same entity, not a shallow copy)
 type Cloneable interface {
 	Clone() Cloneable
 }
@@ -17,9 +17,12 @@ type Cloneable interface {
 // Now embeds Cloneable
 type TimeSeriesEntity interface {
 	Cloneable
-	// GetID returns the unique identifier of the entity
+	// GetID returns the business ID of the entity, shared by all its versions
 	GetID() uuid.UUID
 
+	// GetVersionID returns the unique identifier of this version
+	GetVersionID() uuid.UUID
+
 	// GetCreationTime returns when this version was created
 	GetCreationTime() time.Time
 
@@ -42,7 +45,7 @@ type TimeSeriesRepository[T TimeSeriesEntity] interface {
 	GetCurrentByID(ctx interface{}, id uuid.UUID) (T, error)
 
 	// GetByID retrieves a specific version of an entity by its version ID
-	GetByID(ctx interface{}, id uuid.UUID) (T, error)
+	GetByID(ctx interface{}, versionID uuid.UUID) (T, error)
 
 	// GetHistory retrieves all versions of an entity by its logical ID
 	GetHistory(ctx interface{}, id uuid.UUID) ([]T, error)
@@ -50,6 +53,9 @@ type TimeSeriesRepository[T TimeSeriesEntity] interface {
 	// Update creates a new version by cloning the current version and applying updates
 	Update(ctx interface{}, id uuid.UUID, updates func(T) error) (T, error)
 
+	// RestoreVersion creates a new current version by cloning an earlier version
+	RestoreVersion(ctx interface{}, id, versionID uuid.UUID) (T, error)
+
 	// Archive marks the current version as expired (archives it)
 	Archive(ctx interface{}, id uuid.UUID) error
 
@@ -78,18 +84,26 @@ type TimeSeriesService[T TimeSeriesEntity] interface {
 	Archive(ctx interface{}, id uuid.UUID) error
 }
 
-// BaseTimeSeriesEntity provides a base implementation of TimeSeriesEntity
+// BaseTimeSeriesEntity provides a base implementation of TimeSeriesEntity.
+// ID is the business ID every version of an entity shares, so rows referring
+// to the entity keep pointing at it; VersionID identifies one version.
 type BaseTimeSeriesEntity struct {
-	ID             uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	VersionID      uuid.UUID  `json:"version_id" gorm:"type:char(36);primary_key"`
+	ID             uuid.UUID  `json:"id" gorm:"type:char(36);not null;index"`
 	CreationTime   time.Time  `json:"creation_time" gorm:"autoCreateTime;not null"`
 	ExpirationTime *time.Time `json:"expiration_time" gorm:"index"`
 }
 
-// GetID returns the unique identifier of the entity
+// GetID returns the business ID of the entity, shared by all its versions
 func (b *BaseTimeSeriesEntity) GetID() uuid.UUID {
 	return b.ID
 }
 
+// GetVersionID returns the unique identifier of this version
+func (b *BaseTimeSeriesEntity) GetVersionID() uuid.UUID {
+	return b.VersionID
+}
+
 // GetCreationTime returns when this version was created
 func (b *BaseTimeSeriesEntity) GetCreationTime() time.Time {
 	return b.CreationTime
@@ -110,11 +124,13 @@ func (b *BaseTimeSeriesEntity) IsCurrentVersion() bool {
 	return b.ExpirationTime == nil
 }
 
-// Clone creates a new version of this entity with a new ID and current creation time
+// Clone creates a new version of this entity with a new version ID and current creation time
 func (b *BaseTimeSeriesEntity) Clone() Cloneable {
 	// This is a base implementation - specific entities should override this
 	// to properly clone their specific fields
 	return &BaseTimeSeriesEntity{
+		VersionID:      uuid.New(),
+		ID:             b.ID,
 		CreationTime:   time.Now(),
 		ExpirationTime: nil, // New version is current
 	}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// APIUsage reports a user's API requests against their daily quota
+type APIUsage struct {
+	// Limit is the number of requests allowed per UTC day; null means unlimited
+	Limit *int64 `json:"limit"`
+	Used  int64  `json:"used"`
+	// Remaining is how many requests are left today; null means unlimited
+	Remaining *int64    `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
@@ -15,6 +15,7 @@ const (
 	RoleSupportAgent  UserRole = "SUPPORT_AGENT"
 	RoleAdministrator UserRole = "ADMINISTRATOR"
 	RoleManager       UserRole = "MANAGER"
+	RoleViewer        UserRole = "VIEWER"
 )
 
 // User represents a user in the system
@@ -32,6 +33,35 @@ type User struct {
 	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 	CreatedBy    *string    `json:"created_by" gorm:"type:char(36)"`
 	UpdatedBy    *string    `json:"updated_by" gorm:"type:char(36)"`
+
+	// Language is the BCP 47 tag notifications are sent in; empty uses the default language
+	Language string `json:"language,omitempty" gorm:"size:35"`
+
+	// Timezone is the IANA time zone the user's timestamps, dates and quiet
+	// hours are in; empty is UTC
+	Timezone string `json:"timezone,omitempty" gorm:"size:64"`
+	// QuietHoursStart and QuietHoursEnd ("HH:MM") bound the daily period in
+	// which notifications are held back; the period may span midnight
+	QuietHoursStart string `json:"quiet_hours_start,omitempty" gorm:"size:5"`
+	QuietHoursEnd   string `json:"quiet_hours_end,omitempty" gorm:"size:5"`
+
+	// EmailFailingSince is set while notifications to the user's address keep
+	// failing or it has been suppressed after a hard bounce or complaint
+	EmailFailingSince *time.Time `json:"email_failing_since,omitempty"`
+
+	// MaxConcurrentChats caps the live chats routed to an agent; nil uses the configured default
+	MaxConcurrentChats *int `json:"max_concurrent_chats"`
+
+	// AnonymizedAt is set once the retention policy has replaced the user's
+	// personal data with placeholders
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+
+	// SandboxID is set while an agent works in a training sandbox, and on the
+	// demo requesters made up for it
+	SandboxID *uuid.UUID `json:"sandbox_id,omitempty" gorm:"type:char(36);index"`
+	// Demo marks the made-up requesters of a training sandbox, which are left
+	// out of user lists and deleted with it
+	Demo bool `json:"demo,omitempty" gorm:"not null;default:false"`
 }
 
 // TableName specifies the table name for the User model
@@ -61,3 +91,8 @@ func (u *User) IsAdmin() bool {
 func (u *User) IsAgent() bool {
 	return u.Role == RoleSupportAgent || u.Role == RoleAdministrator || u.Role == RoleManager
 }
+
+// HasPermission reports whether the user's role is granted a permission
+func (u *User) HasPermission(permission string) bool {
+	return u.Role.HasPermission(permission)
+}
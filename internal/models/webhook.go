@@ -0,0 +1,192 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Events outbound webhooks can subscribe to
+const (
+	WebhookEventTicketCreated       = "ticket.created"
+	WebhookEventTicketStatusChanged = "ticket.status_changed"
+	WebhookEventCommentAdded        = "comment.added"
+)
+
+// WebhookEvents lists the events outbound webhooks can subscribe to
+var WebhookEvents = []string{WebhookEventTicketCreated, WebhookEventTicketStatusChanged, WebhookEventCommentAdded}
+
+// WebhookDeliveryStatus is where the delivery of an event to a webhook stands
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their first attempt or a retry
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "DELIVERED"
+	// WebhookDeliveryFailed deliveries ran out of attempts, or their
+	// subscription was disabled before they were delivered
+	WebhookDeliveryFailed WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookSubscription has the events it subscribes to posted to an external
+// URL, signed with its secret
+type WebhookSubscription struct {
+	ID          uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	URL         string    `json:"url" gorm:"not null;size:2000" example:"https://hooks.example.com/helpchat"`
+	Description string    `json:"description" gorm:"size:255" example:"Sync tickets to the CRM"`
+	Events      []string  `json:"events" gorm:"type:text;not null;serializer:json" example:"ticket.created,comment.added"`
+	Enabled     bool      `json:"enabled" gorm:"not null"`
+	// Secret signs the payloads; it is only shown when it is generated
+	Secret      string    `json:"-" gorm:"type:text;not null;serializer:encrypted"`
+	CreatedByID uuid.UUID `json:"created_by_id" gorm:"type:char(36);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the WebhookSubscription model
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a webhook subscription
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subscribes reports whether the subscription has the event posted to it
+func (s *WebhookSubscription) Subscribes(event string) bool {
+	return slices.Contains(s.Events, event)
+}
+
+// WebhookDelivery is an event posted, or to be posted, to a webhook
+// subscription, with the outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	SubscriptionID uuid.UUID `json:"subscription_id" gorm:"type:char(36);not null;index"`
+	Event          string    `json:"event" gorm:"not null;size:50" example:"ticket.created"`
+	// Payload is the JSON body posted
+	Payload  string                `json:"payload" gorm:"type:text;not null"`
+	Status   WebhookDeliveryStatus `json:"status" gorm:"not null;size:20;index"`
+	Attempts int                   `json:"attempts" gorm:"not null"`
+	// NextAttemptAt is when a pending delivery is attempted next
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"index"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	// ResponseStatus is the HTTP status of the latest attempt, or 0 when the
+	// URL could not be reached
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the WebhookDelivery model
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a webhook delivery
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebhookSubscriptionRequest represents a request to create or replace a webhook subscription
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2000" example:"https://hooks.example.com/helpchat"`
+	Description string   `json:"description" validate:"max=255" example:"Sync tickets to the CRM"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=ticket.created ticket.status_changed comment.added" example:"ticket.created,comment.added"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// WebhookSecretResponse is a webhook subscription with its newly generated
+// signing secret, which is not shown again
+type WebhookSecretResponse struct {
+	Subscription *WebhookSubscription `json:"subscription"`
+	Secret       string               `json:"secret"`
+}
+
+// WebhookDeliveryQuery represents filters and pagination for the delivery log of a webhook subscription
+type WebhookDeliveryQuery struct {
+	SubscriptionID uuid.UUID
+	Status         WebhookDeliveryStatus
+	Page           int
+	PageSize       int
+}
+
+// WebhookDeliveryListResponse represents a paginated list of webhook deliveries
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// WebhookPayload is the JSON body posted to webhook subscriptions
+type WebhookPayload struct {
+	// ID is the delivery's ID, which stays the same across retries
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event" example:"ticket.created"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      WebhookData `json:"data"`
+}
+
+// WebhookData describes what happened to a ticket
+type WebhookData struct {
+	Ticket WebhookTicket `json:"ticket"`
+	// ActorID is the user who created the ticket, changed its status or commented
+	ActorID uuid.UUID `json:"actor_id"`
+	// PreviousStatus is set on ticket.status_changed events
+	PreviousStatus TicketStatus `json:"previous_status,omitempty"`
+	// Comment is set on comment.added events
+	Comment *WebhookComment `json:"comment,omitempty"`
+}
+
+// WebhookTicket is the ticket an event is about
+type WebhookTicket struct {
+	ID              uuid.UUID      `json:"id"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	Status          TicketStatus   `json:"status"`
+	Priority        TicketPriority `json:"priority"`
+	CategoryID      *uuid.UUID     `json:"category_id"`
+	TeamID          *uuid.UUID     `json:"team_id"`
+	RequesterID     uuid.UUID      `json:"requester_id"`
+	AssignedAgentID *uuid.UUID     `json:"assigned_agent_id"`
+	// UpdatedAt is when the ticket was last changed
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookComment is the comment of a comment.added event
+type WebhookComment struct {
+	ID       uuid.UUID `json:"id"`
+	AuthorID uuid.UUID `json:"author_id"`
+	Content  string    `json:"content"`
+	// IsInternal is set on notes only agents see
+	IsInternal bool      `json:"is_internal"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewWebhookTicket describes a ticket for webhook payloads
+func NewWebhookTicket(ticket *Ticket) WebhookTicket {
+	return WebhookTicket{
+		ID:              ticket.ID,
+		Title:           ticket.Title,
+		Description:     ticket.Description,
+		Status:          ticket.Status,
+		Priority:        ticket.Priority,
+		CategoryID:      ticket.CategoryID,
+		TeamID:          ticket.TeamID,
+		RequesterID:     ticket.CreatedByID,
+		AssignedAgentID: ticket.AssignedAgentID,
+		UpdatedAt:       ticket.CreationTime,
+	}
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WidgetConfigResponse is everything the embeddable chat widget needs to render
+type WidgetConfigResponse struct {
+	Branding    WidgetBranding    `json:"branding"`
+	OfficeHours WidgetOfficeHours `json:"office_hours"`
+	Queue       WidgetQueue       `json:"queue"`
+	// Proactive is set when a trigger matches the visitor's page; it is omitted otherwise
+	Proactive *WidgetProactive `json:"proactive,omitempty"`
+}
+
+// WidgetBranding holds the widget's look and copy
+type WidgetBranding struct {
+	Title        string `json:"title" example:"HelpChat Support"`
+	Greeting     string `json:"greeting" example:"Hi! How can we help?"`
+	PrimaryColor string `json:"primary_color" example:"#2563eb"`
+	LogoURL      string `json:"logo_url,omitempty"`
+}
+
+// WidgetOfficeHours describes when support is staffed
+type WidgetOfficeHours struct {
+	Timezone string              `json:"timezone" example:"Europe/London"`
+	Windows  []OfficeHoursWindow `json:"windows"`
+	OpenNow  bool                `json:"open_now"`
+	// NextOpenAt is when support next opens; it is omitted while open
+	NextOpenAt *time.Time `json:"next_open_at,omitempty"`
+}
+
+// OfficeHoursWindow is one weekly opening period in the office hours time zone
+type OfficeHoursWindow struct {
+	Day   string `json:"day" example:"monday"`
+	Open  string `json:"open" example:"09:00"`
+	Close string `json:"close" example:"17:00"`
+}
+
+// WidgetQueue reports whether a human can pick up a conversation right now
+type WidgetQueue struct {
+	Available       bool  `json:"available"`
+	AgentsAvailable int64 `json:"agents_available"`
+}
+
+// WidgetProactive tells the widget to open by itself with a greeting
+type WidgetProactive struct {
+	TriggerID uuid.UUID `json:"trigger_id"`
+	// DelaySeconds is how long the visitor must stay on the page before the widget opens
+	DelaySeconds int    `json:"delay_seconds" example:"30"`
+	Greeting     string `json:"greeting" example:"Need a hand with pricing?"`
+}
+
+// WidgetVisit describes the page the widget was loaded on, for matching proactive triggers
+type WidgetVisit struct {
+	PageURL   string
+	PageTitle string
+	Returning bool
+}
+
+// TriggerVisitorType limits a proactive trigger to new or returning visitors
+type TriggerVisitorType string
+
+const (
+	TriggerAnyVisitor       TriggerVisitorType = "ANY"
+	TriggerNewVisitor       TriggerVisitorType = "NEW"
+	TriggerReturningVisitor TriggerVisitorType = "RETURNING"
+)
+
+// ChatTrigger opens the chat widget by itself on matching pages. When several
+// triggers match, the one with the highest priority wins.
+type ChatTrigger struct {
+	ID      uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	Name    string    `json:"name" gorm:"not null;size:100"`
+	Enabled bool      `json:"enabled" gorm:"not null;index"`
+	// URLPattern matches the page path, or the full URL when it contains "://"; * matches anything
+	URLPattern       string             `json:"url_pattern" gorm:"not null;size:500" example:"/pricing*"`
+	MinSecondsOnPage int                `json:"min_seconds_on_page" gorm:"not null;default:0"`
+	VisitorType      TriggerVisitorType `json:"visitor_type" gorm:"not null;default:'ANY';size:20"`
+	// Greeting may use the {{page_title}}, {{page_url}} and {{site_title}} placeholders
+	Greeting    string    `json:"greeting" gorm:"not null;size:500" example:"Questions about {{page_title}}? We're here to help."`
+	Priority    int       `json:"priority" gorm:"not null;default:0"`
+	CreatedByID uuid.UUID `json:"created_by_id" gorm:"type:char(36);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for the ChatTrigger model
+func (ChatTrigger) TableName() string {
+	return "chat_triggers"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a chat trigger
+func (t *ChatTrigger) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChatTriggerRequest represents a request to create or replace a proactive chat trigger
+type ChatTriggerRequest struct {
+	Name             string             `json:"name" validate:"required,min=1,max=100"`
+	Enabled          *bool              `json:"enabled"`
+	URLPattern       string             `json:"url_pattern" validate:"required,max=500"`
+	MinSecondsOnPage int                `json:"min_seconds_on_page" validate:"min=0,max=3600"`
+	VisitorType      TriggerVisitorType `json:"visitor_type" validate:"omitempty,oneof=ANY NEW RETURNING"`
+	Greeting         string             `json:"greeting" validate:"required,min=1,max=500"`
+	Priority         int                `json:"priority"`
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm"
+)
+
+// accountTokenRepository implements AccountTokenRepository
+type accountTokenRepository struct {
+	db *database.Database
+}
+
+// NewAccountTokenRepository creates a new account token repository
+func NewAccountTokenRepository(db *database.Database) AccountTokenRepository {
+	return &accountTokenRepository{db: db}
+}
+
+// CreatePasswordReset records a password reset token
+func (r *accountTokenRepository) CreatePasswordReset(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.DB.WithContext(ctx).Create(token).Error
+}
+
+// UsePasswordReset marks the unused, unexpired password reset token with the
+// given hash as used and returns it; any other token fails with
+// gorm.ErrRecordNotFound
+func (r *accountTokenRepository) UsePasswordReset(ctx context.Context, hash string, now time.Time) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		return useToken(tx, &token, hash, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CreateEmailVerification records an email verification token
+func (r *accountTokenRepository) CreateEmailVerification(ctx context.Context, token *models.EmailVerificationToken) error {
+	return r.db.DB.WithContext(ctx).Create(token).Error
+}
+
+// UseEmailVerification marks the unused, unexpired email verification token
+// with the given hash as used and returns it; any other token fails with
+// gorm.ErrRecordNotFound
+func (r *accountTokenRepository) UseEmailVerification(ctx context.Context, hash string, now time.Time) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		return useToken(tx, &token, hash, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// useToken marks a token as used within tx and loads it into token. The
+// conditional update lets only one of several concurrent requests use it.
+func useToken(tx *gorm.DB, token any, hash string, now time.Time) error {
+	result := tx.Model(token).
+		Where("token = ? AND used = ? AND expires_at > ?", hash, false, now).
+		Update("used", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return tx.Where("token = ?", hash).First(token).Error
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// assetRepository implements AssetRepository
+type assetRepository struct {
+	db *database.Database
+}
+
+// NewAssetRepository creates a new asset repository
+func NewAssetRepository(db *database.Database) AssetRepository {
+	return &assetRepository{db: db}
+}
+
+// Create creates a new asset
+func (r *assetRepository) Create(ctx context.Context, asset *models.Asset) error {
+	return r.db.DB.WithContext(ctx).Create(asset).Error
+}
+
+// GetByID retrieves an asset and its owner by ID
+func (r *assetRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Asset, error) {
+	var asset models.Asset
+	err := r.db.DB.WithContext(ctx).
+		Preload("Owner").
+		Where("id = ?", id).
+		First(&asset).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Update updates an existing asset
+func (r *assetRepository) Update(ctx context.Context, asset *models.Asset) error {
+	return r.db.DB.WithContext(ctx).Omit("Owner").Save(asset).Error
+}
+
+// Delete deletes an asset and its ticket links
+func (r *assetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.DB.WithContext(ctx).Where("asset_id = ?", id).Delete(&models.TicketAsset{}).Error; err != nil {
+		return err
+	}
+	return r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.Asset{}).Error
+}
+
+// List retrieves assets matching the filter ordered by name
+func (r *assetRepository) List(ctx context.Context, filter *models.AssetFilter) ([]models.Asset, error) {
+	db := r.db.DB.WithContext(ctx).Preload("Owner")
+
+	if filter != nil {
+		if filter.Type != "" {
+			db = db.Where("type = ?", filter.Type)
+		}
+		if filter.OwnerID != nil {
+			db = db.Where("owner_id = ?", *filter.OwnerID)
+		}
+		if filter.Search != "" {
+			search := "%" + filter.Search + "%"
+			db = db.Where("name LIKE ? OR serial_number LIKE ?", search, search)
+		}
+	}
+
+	var assets []models.Asset
+	err := db.Order("name ASC").Find(&assets).Error
+	return assets, err
+}
+
+// LinkTicket links a ticket to an asset; linking twice is a no-op
+func (r *assetRepository) LinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.TicketAsset{TicketID: ticketID, AssetID: assetID}).Error
+}
+
+// UnlinkTicket removes the link between a ticket and an asset
+func (r *assetRepository) UnlinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Where("ticket_id = ? AND asset_id = ?", ticketID, assetID).
+		Delete(&models.TicketAsset{}).Error
+}
+
+// GetByTicket retrieves the assets linked to a ticket
+func (r *assetRepository) GetByTicket(ctx context.Context, ticketID uuid.UUID) ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.DB.WithContext(ctx).
+		Joins("JOIN ticket_assets ON ticket_assets.asset_id = assets.id").
+		Where("ticket_assets.ticket_id = ?", ticketID).
+		Order("assets.name ASC").
+		Find(&assets).Error
+	return assets, err
+}
+
+// GetTickets retrieves the current version of every ticket linked to an asset, newest first
+func (r *assetRepository) GetTickets(ctx context.Context, assetID uuid.UUID) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := r.db.DB.WithContext(ctx).
+		Preload("AssignedAgent").
+		Preload("CreatedBy").
+		Joins("JOIN ticket_assets ON ticket_assets.ticket_id = tickets.id").
+		Where("ticket_assets.asset_id = ? AND tickets.expiration_time IS NULL", assetID).
+		Order("tickets.creation_time DESC").
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// TicketCounts reports the assets with the most linked tickets, limited to the ticket scope of ctx
+func (r *assetRepository) TicketCounts(ctx context.Context, limit int) ([]models.AssetTicketCount, error) {
+	var counts []models.AssetTicketCount
+	err := r.db.DB.WithContext(ctx).
+		Table("assets").
+		Select("assets.id AS asset_id, assets.name, assets.type, assets.serial_number, "+
+			"COUNT(tickets.id) AS ticket_count, "+
+			"SUM(CASE WHEN tickets.status IN ? THEN 1 ELSE 0 END) AS open_count",
+			[]models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+		Joins("JOIN ticket_assets ON ticket_assets.asset_id = assets.id").
+		Joins("JOIN tickets ON tickets.id = ticket_assets.ticket_id AND tickets.expiration_time IS NULL").
+		Scopes(scopeTickets(ctx)).
+		Group("assets.id, assets.name, assets.type, assets.serial_number").
+		Order("ticket_count DESC, assets.name ASC").
+		Limit(limit).
+		Find(&counts).Error
+	return counts, err
+}
@@ -27,7 +27,7 @@ func (r *attachmentRepository) Create(ctx context.Context, attachment *models.At
 func (r *attachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Attachment, error) {
 	var attachment models.Attachment
 	err := r.db.DB.WithContext(ctx).
-		Preload("Ticket").
+		Preload("Ticket", currentVersion).
 		Preload("UploadedBy").
 		Where("id = ?", id).
 		First(&attachment).Error
@@ -55,6 +55,17 @@ func (r *attachmentRepository) GetByTicket(ctx context.Context, ticketID uuid.UU
 	return attachments, err
 }
 
+// Search retrieves the attachments on any of the given tickets whose filename contains text
+func (r *attachmentRepository) Search(ctx context.Context, ticketIDs []uuid.UUID, text string) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.DB.WithContext(ctx).
+		Where("ticket_id IN ? AND filename LIKE ?", ticketIDs, "%"+text+"%").
+		Order("created_at ASC").
+		Find(&attachments).Error
+
+	return attachments, err
+}
+
 // UpdateVirusScan updates the virus scan status of an attachment
 func (r *attachmentRepository) UpdateVirusScan(ctx context.Context, id uuid.UUID, isScanned, isSafe bool) error {
 	return r.db.DB.WithContext(ctx).
@@ -65,3 +76,27 @@ func (r *attachmentRepository) UpdateVirusScan(ctx context.Context, id uuid.UUID
 			"is_safe":          isSafe,
 		}).Error
 }
+
+// ListPendingThumbnails retrieves image attachments waiting for thumbnails, oldest first
+func (r *attachmentRepository) ListPendingThumbnails(ctx context.Context, limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.DB.WithContext(ctx).
+		Where("thumbnail_status = ?", models.ThumbnailPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&attachments).Error
+
+	return attachments, err
+}
+
+// UpdateThumbnails updates the image size and thumbnail status of an attachment
+func (r *attachmentRepository) UpdateThumbnails(ctx context.Context, attachment *models.Attachment) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.Attachment{}).
+		Where("id = ?", attachment.ID).
+		Updates(map[string]interface{}{
+			"width":            attachment.Width,
+			"height":           attachment.Height,
+			"thumbnail_status": attachment.ThumbnailStatus,
+		}).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+)
+
+// auditLogRepository implements AuditLogRepository
+type auditLogRepository struct {
+	db *database.Database
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *database.Database) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create creates a new audit log entry
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.DB.WithContext(ctx).Create(entry).Error
+}
+
+// List retrieves audit log entries matching the query, newest first
+func (r *auditLogRepository) List(ctx context.Context, query *models.AuditLogQuery) (*models.AuditLogListResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.AuditLog{})
+
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.EntityType != "" {
+		db = db.Where("entity_type = ?", query.EntityType)
+	}
+	if query.EntityID != nil {
+		db = db.Where("entity_id = ?", *query.EntityID)
+	}
+	if query.ActorID != nil {
+		db = db.Where("actor_id = ?", *query.ActorID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var entries []models.AuditLog
+	err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditLogListResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int((total + int64(query.PageSize) - 1) / int64(query.PageSize)),
+	}, nil
+}
@@ -30,7 +30,7 @@ func (r *categoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	err := r.db.DB.WithContext(ctx).
 		Preload("Parent").
 		Preload("Children").
-		Preload("Tickets").
+		Preload("Tickets", currentVersion).
 		Where("id = ?", id).
 		First(&category).Error
 
@@ -95,6 +95,15 @@ func (r *categoryRepository) ListActive(ctx context.Context) ([]models.Category,
 	return categories, err
 }
 
+// SetRequiredResolutionFields replaces the resolution details a category's
+// tickets need before they can be resolved
+func (r *categoryRepository) SetRequiredResolutionFields(ctx context.Context, id uuid.UUID, fields []models.ResolutionField) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.Category{ID: id}).
+		Select("required_resolution_fields").
+		Updates(&models.Category{RequiredResolutionFields: fields}).Error
+}
+
 // GetWithChildren retrieves a category with all its children
 func (r *categoryRepository) GetWithChildren(ctx context.Context, id uuid.UUID) (*models.Category, error) {
 	var category models.Category
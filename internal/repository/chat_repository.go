@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// chatRepository implements ChatRepository
+type chatRepository struct {
+	db *database.Database
+}
+
+// NewChatRepository creates a new chat repository
+func NewChatRepository(db *database.Database) ChatRepository {
+	return &chatRepository{db: db}
+}
+
+// Create creates a new chat session
+func (r *chatRepository) Create(ctx context.Context, session *models.ChatSession) error {
+	return r.db.DB.WithContext(ctx).Omit("Messages", "Participants").Create(session).Error
+}
+
+// GetByID retrieves a chat session with its transcript in order
+func (r *chatRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := r.db.DB.WithContext(ctx).
+		Preload("Agent").
+		Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Participants.User").
+		Where("id = ?", id).
+		First(&session).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update updates an existing chat session without touching its transcript
+func (r *chatRepository) Update(ctx context.Context, session *models.ChatSession) error {
+	return r.db.DB.WithContext(ctx).Omit("Agent", "Messages", "Participants").Save(session).Error
+}
+
+// List retrieves chat sessions, optionally filtered by status, in the order they
+// joined the queue so the longest-waiting chat leads it
+func (r *chatRepository) List(ctx context.Context, status models.ChatStatus) ([]models.ChatSession, error) {
+	db := r.db.DB.WithContext(ctx).Preload("Agent")
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var sessions []models.ChatSession
+	err := db.Order("COALESCE(queued_at, created_at) ASC").Find(&sessions).Error
+	return sessions, err
+}
+
+// AddMessage appends a message to a chat transcript
+func (r *chatRepository) AddMessage(ctx context.Context, message *models.ChatMessage) error {
+	return r.db.DB.WithContext(ctx).Create(message).Error
+}
+
+// QueuePosition returns the 1-based position of a queued chat, counting the
+// chats that joined the queue before it
+func (r *chatRepository) QueuePosition(ctx context.Context, session *models.ChatSession) (int64, error) {
+	var ahead int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.ChatSession{}).
+		Where("status = ? AND queued_at < ?", models.ChatQueued, session.QueuedAt).
+		Count(&ahead).Error
+	return ahead + 1, err
+}
+
+// AddQueueEvent records a chat entering or leaving the queue
+func (r *chatRepository) AddQueueEvent(ctx context.Context, event *models.ChatQueueEvent) error {
+	return r.db.DB.WithContext(ctx).Create(event).Error
+}
+
+// AverageQueueWait returns the average wait in seconds of chats accepted since
+// the given time, or nil when none were
+func (r *chatRepository) AverageQueueWait(ctx context.Context, since time.Time) (*float64, error) {
+	var average *float64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.ChatQueueEvent{}).
+		Select("AVG(wait_seconds)").
+		Where("type = ? AND created_at >= ?", models.QueueEventAccepted, since).
+		Scan(&average).Error
+	return average, err
+}
+
+// CountActiveByAgent counts the active chats each agent is handling
+func (r *chatRepository) CountActiveByAgent(ctx context.Context) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		AgentID uuid.UUID
+		Count   int64
+	}
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.ChatSession{}).
+		Select("agent_id, COUNT(*) AS count").
+		Where("status = ? AND agent_id IS NOT NULL", models.ChatActive).
+		Group("agent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.AgentID] = row.Count
+	}
+	return counts, nil
+}
+
+// CountByStatus counts the chat sessions in a status
+func (r *chatRepository) CountByStatus(ctx context.Context, status models.ChatStatus) (int64, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.ChatSession{}).
+		Where("status = ?", status).
+		Count(&count).Error
+	return count, err
+}
+
+// SetParticipant adds a supervisor to a chat or changes how they take part
+func (r *chatRepository) SetParticipant(ctx context.Context, participant *models.ChatParticipant) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+		}).
+		Create(participant).Error
+}
+
+// AcknowledgeMessages marks the visible messages from the given senders, up to a
+// point in the transcript, as delivered or as read (which implies delivered). It
+// returns the IDs of the messages that changed.
+func (r *chatRepository) AcknowledgeMessages(ctx context.Context, sessionID uuid.UUID, senders []models.ChatSenderType, upTo time.Time, read bool, at time.Time) ([]uuid.UUID, error) {
+	column := "delivered_at"
+	if read {
+		column = "read_at"
+	}
+
+	var ids []uuid.UUID
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		ids = nil
+		err := tx.Model(&models.ChatMessage{}).
+			Where("session_id = ? AND sender_type IN ? AND internal = ? AND created_at <= ?", sessionID, senders, false, upTo).
+			Where(column+" IS NULL").
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Model(&models.ChatMessage{}).Where("id IN ?", ids).Update(column, at).Error; err != nil {
+			return err
+		}
+		if read {
+			return tx.Model(&models.ChatMessage{}).Where("id IN ? AND delivered_at IS NULL", ids).Update("delivered_at", at).Error
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// CountEndedSince counts the chats that ended since the given time
+func (r *chatRepository) CountEndedSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Reader().WithContext(ctx).
+		Model(&models.ChatSession{}).
+		Where("status = ? AND ended_at >= ?", models.ChatEnded, since).
+		Count(&count).Error
+	return count, err
+}
+
+// RatingCounts counts the ratings given since the given time by agent and score
+func (r *chatRepository) RatingCounts(ctx context.Context, since time.Time) ([]models.ChatRatingCount, error) {
+	var counts []models.ChatRatingCount
+	err := r.db.Reader().WithContext(ctx).
+		Model(&models.ChatSession{}).
+		Select("agent_id, rating, COUNT(*) AS count").
+		Where("rating IS NOT NULL AND rated_at >= ?", since).
+		Group("agent_id, rating").
+		Scan(&counts).Error
+	return counts, err
+}
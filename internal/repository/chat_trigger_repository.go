@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// chatTriggerRepository implements ChatTriggerRepository
+type chatTriggerRepository struct {
+	db *database.Database
+}
+
+// NewChatTriggerRepository creates a new chat trigger repository
+func NewChatTriggerRepository(db *database.Database) ChatTriggerRepository {
+	return &chatTriggerRepository{db: db}
+}
+
+// Create creates a new chat trigger
+func (r *chatTriggerRepository) Create(ctx context.Context, trigger *models.ChatTrigger) error {
+	return r.db.DB.WithContext(ctx).Create(trigger).Error
+}
+
+// GetByID retrieves a chat trigger by ID
+func (r *chatTriggerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ChatTrigger, error) {
+	var trigger models.ChatTrigger
+	err := r.db.DB.WithContext(ctx).Where("id = ?", id).First(&trigger).Error
+	if err != nil {
+		return nil, err
+	}
+	return &trigger, nil
+}
+
+// Update updates an existing chat trigger
+func (r *chatTriggerRepository) Update(ctx context.Context, trigger *models.ChatTrigger) error {
+	return r.db.DB.WithContext(ctx).Save(trigger).Error
+}
+
+// Delete deletes a chat trigger
+func (r *chatTriggerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.ChatTrigger{ID: id}).Error
+}
+
+// List retrieves chat triggers in the order they are evaluated: highest
+// priority first, then oldest first
+func (r *chatTriggerRepository) List(ctx context.Context, enabledOnly bool) ([]models.ChatTrigger, error) {
+	var triggers []models.ChatTrigger
+	db := r.db.DB.WithContext(ctx)
+	if enabledOnly {
+		db = db.Where("enabled = ?", true)
+	}
+	err := db.Order("priority DESC").Order("created_at ASC").Find(&triggers).Error
+	return triggers, err
+}
@@ -27,7 +27,7 @@ func (r *commentRepository) Create(ctx context.Context, comment *models.Comment)
 func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
 	var comment models.Comment
 	err := r.db.DB.WithContext(ctx).
-		Preload("Ticket").
+		Preload("Ticket", currentVersion).
 		Preload("User").
 		Where("id = ?", id).
 		First(&comment).Error
@@ -53,6 +53,7 @@ func (r *commentRepository) GetByTicket(ctx context.Context, ticketID uuid.UUID,
 	var comments []models.Comment
 	query := r.db.DB.WithContext(ctx).
 		Preload("User").
+		Preload("Attachments").
 		Where("ticket_id = ?", ticketID).
 		Order("created_at ASC")
 
@@ -64,11 +65,26 @@ func (r *commentRepository) GetByTicket(ctx context.Context, ticketID uuid.UUID,
 	return comments, err
 }
 
+// Search retrieves the comments on any of the given tickets containing text, oldest first
+func (r *commentRepository) Search(ctx context.Context, ticketIDs []uuid.UUID, text string, includeInternal bool) ([]models.Comment, error) {
+	var comments []models.Comment
+	query := r.db.DB.WithContext(ctx).
+		Where("ticket_id IN ? AND content LIKE ?", ticketIDs, "%"+text+"%").
+		Order("created_at ASC")
+
+	if !includeInternal {
+		query = query.Where("is_internal = ?", false)
+	}
+
+	err := query.Find(&comments).Error
+	return comments, err
+}
+
 // GetByUser retrieves comments created by a specific user
 func (r *commentRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]models.Comment, error) {
 	var comments []models.Comment
 	err := r.db.DB.WithContext(ctx).
-		Preload("Ticket").
+		Preload("Ticket", currentVersion).
 		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Find(&comments).Error
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// demoRepository implements DemoRepository
+type demoRepository struct {
+	db *database.Database
+}
+
+// NewDemoRepository creates a new demo repository
+func NewDemoRepository(db *database.Database) DemoRepository {
+	return &demoRepository{db: db}
+}
+
+// Reset deletes every row of every table but the record of applied
+// migrations, then stores the demo dataset. The full-text index follows the
+// tickets table through its triggers. It returns
+// the attachments and draft attachments that were deleted, whose files are
+// left to the caller.
+func (r *demoRepository) Reset(ctx context.Context, dataset *models.DemoDataset) ([]models.Attachment, []models.DraftAttachment, error) {
+	var attachments []models.Attachment
+	var drafts []models.DraftAttachment
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		attachments, drafts = nil, nil
+		if err := tx.Find(&attachments).Error; err != nil {
+			return err
+		}
+		if err := tx.Find(&drafts).Error; err != nil {
+			return err
+		}
+
+		var tables []string
+		if err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE 'tickets_fts%' AND name != ?", database.MigrationsTable).Scan(&tables).Error; err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if err := tx.Exec("DELETE FROM `" + table + "`").Error; err != nil {
+				return err
+			}
+		}
+
+		for _, rows := range []struct {
+			rows  any
+			count int
+		}{
+			{&dataset.Users, len(dataset.Users)},
+			{&dataset.Teams, len(dataset.Teams)},
+			{&dataset.TeamMembers, len(dataset.TeamMembers)},
+			{&dataset.Categories, len(dataset.Categories)},
+			{&dataset.Tickets, len(dataset.Tickets)},
+			{&dataset.Comments, len(dataset.Comments)},
+			{&dataset.Assignments, len(dataset.Assignments)},
+			{&dataset.Escalations, len(dataset.Escalations)},
+		} {
+			if rows.count == 0 {
+				continue
+			}
+			if err := tx.Omit(clause.Associations).Create(rows.rows).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachments, drafts, nil
+}
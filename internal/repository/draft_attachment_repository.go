@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// draftAttachmentRepository implements DraftAttachmentRepository
+type draftAttachmentRepository struct {
+	db *database.Database
+}
+
+// NewDraftAttachmentRepository creates a new draft attachment repository
+func NewDraftAttachmentRepository(db *database.Database) DraftAttachmentRepository {
+	return &draftAttachmentRepository{db: db}
+}
+
+// Create records an uploaded draft attachment
+func (r *draftAttachmentRepository) Create(ctx context.Context, draft *models.DraftAttachment) error {
+	return r.db.DB.WithContext(ctx).Create(draft).Error
+}
+
+// GetByIDs retrieves the draft attachments with the given IDs that exist
+func (r *draftAttachmentRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.DraftAttachment, error) {
+	var drafts []models.DraftAttachment
+	err := r.db.DB.WithContext(ctx).Where("id IN ?", ids).Find(&drafts).Error
+	return drafts, err
+}
+
+// AttachToTicket turns draft attachments into attachments of an existing ticket
+func (r *draftAttachmentRepository) AttachToTicket(ctx context.Context, ticketID uuid.UUID, drafts []models.DraftAttachment) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		return attachDrafts(tx, ticketID, drafts)
+	})
+}
+
+// ListExpired retrieves draft attachments that expired before now, oldest first
+func (r *draftAttachmentRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DraftAttachment, error) {
+	var drafts []models.DraftAttachment
+	err := r.db.DB.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&drafts).Error
+
+	return drafts, err
+}
+
+// Delete deletes a draft attachment by ID, reporting whether it still existed
+func (r *draftAttachmentRepository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.DraftAttachment{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// attachDrafts moves draft attachments to a ticket within tx. A draft already
+// attached by another request fails it with gorm.ErrRecordNotFound, so a
+// draft is never attached twice.
+func attachDrafts(tx *gorm.DB, ticketID uuid.UUID, drafts []models.DraftAttachment) error {
+	for i := range drafts {
+		result := tx.Where("id = ?", drafts[i].ID).Delete(&models.DraftAttachment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(drafts[i].Attachment(ticketID)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
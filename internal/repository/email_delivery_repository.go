@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+// emailDeliveryRepository implements EmailDeliveryRepository
+type emailDeliveryRepository struct {
+	db *database.Database
+}
+
+// NewEmailDeliveryRepository creates a new email delivery repository
+func NewEmailDeliveryRepository(db *database.Database) EmailDeliveryRepository {
+	return &emailDeliveryRepository{db: db}
+}
+
+// Create records the deliveries of an outbound email
+func (r *emailDeliveryRepository) Create(ctx context.Context, deliveries []*models.EmailDelivery) error {
+	return r.db.DB.WithContext(ctx).Create(deliveries).Error
+}
+
+// Update saves a delivery's status
+func (r *emailDeliveryRepository) Update(ctx context.Context, delivery *models.EmailDelivery) error {
+	return r.db.DB.WithContext(ctx).Save(delivery).Error
+}
+
+// FindLatest retrieves the latest delivery to recipient, of the email with the
+// given Message-ID unless it is empty
+func (r *emailDeliveryRepository) FindLatest(ctx context.Context, messageID, recipient string) (*models.EmailDelivery, error) {
+	db := r.db.DB.WithContext(ctx).Where("recipient = ?", recipient)
+	if messageID != "" {
+		db = db.Where("message_id = ?", messageID)
+	}
+
+	var delivery models.EmailDelivery
+	if err := db.Order("created_at DESC").First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListRecentStatuses retrieves the statuses of the latest deliveries to recipient, newest first
+func (r *emailDeliveryRepository) ListRecentStatuses(ctx context.Context, recipient string, limit int) ([]models.EmailDeliveryStatus, error) {
+	var statuses []models.EmailDeliveryStatus
+	err := r.db.DB.WithContext(ctx).Model(&models.EmailDelivery{}).
+		Where("recipient = ? AND status <> ?", recipient, models.DeliverySuppressed).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("status", &statuses).Error
+	return statuses, err
+}
+
+// LastSent retrieves when the provider last accepted an email, or nil when it never has
+func (r *emailDeliveryRepository) LastSent(ctx context.Context) (*time.Time, error) {
+	var deliveries []models.EmailDelivery
+	err := r.db.DB.WithContext(ctx).
+		Where("status IN ?", []models.EmailDeliveryStatus{models.DeliverySent, models.DeliveryDelivered}).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&deliveries).Error
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0].CreatedAt, nil
+}
+
+// List retrieves deliveries matching the query, newest first
+func (r *emailDeliveryRepository) List(ctx context.Context, query *models.EmailDeliveryQuery) (*models.EmailDeliveryListResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.EmailDelivery{})
+
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Recipient != "" {
+		db = db.Where("recipient = ?", query.Recipient)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.EmailDelivery
+	err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EmailDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int((total + int64(query.PageSize) - 1) / int64(query.PageSize)),
+	}, nil
+}
+
+// Suppress stops email to an address, keeping the first reason it was suppressed for
+func (r *emailDeliveryRepository) Suppress(ctx context.Context, suppression *models.EmailSuppression) error {
+	return r.db.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(suppression).Error
+}
+
+// FindSuppressed returns which of the addresses are suppressed
+func (r *emailDeliveryRepository) FindSuppressed(ctx context.Context, addresses []string) ([]string, error) {
+	var suppressed []string
+	err := r.db.DB.WithContext(ctx).Model(&models.EmailSuppression{}).
+		Where("address IN ?", addresses).
+		Pluck("address", &suppressed).Error
+	return suppressed, err
+}
+
+// GetSuppression retrieves the suppression of an address
+func (r *emailDeliveryRepository) GetSuppression(ctx context.Context, address string) (*models.EmailSuppression, error) {
+	var suppression models.EmailSuppression
+	if err := r.db.DB.WithContext(ctx).Where("address = ?", address).First(&suppression).Error; err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// ListSuppressions retrieves every suppressed address, newest first
+func (r *emailDeliveryRepository) ListSuppressions(ctx context.Context) ([]models.EmailSuppression, error) {
+	var suppressions []models.EmailSuppression
+	err := r.db.DB.WithContext(ctx).Order("created_at DESC").Find(&suppressions).Error
+	return suppressions, err
+}
+
+// DeleteSuppression lets email be sent to an address again
+func (r *emailDeliveryRepository) DeleteSuppression(ctx context.Context, address string) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.EmailSuppression{Address: address}).Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+)
+
+// emailTemplateRepository implements EmailTemplateRepository
+type emailTemplateRepository struct {
+	db *database.Database
+}
+
+// NewEmailTemplateRepository creates a new email template repository
+func NewEmailTemplateRepository(db *database.Database) EmailTemplateRepository {
+	return &emailTemplateRepository{db: db}
+}
+
+// Get retrieves the customized template with the given name
+func (r *emailTemplateRepository) Get(ctx context.Context, name string) (*models.EmailTemplate, error) {
+	var template models.EmailTemplate
+	err := r.db.DB.WithContext(ctx).Where("name = ?", name).First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// List retrieves every customized template
+func (r *emailTemplateRepository) List(ctx context.Context) ([]models.EmailTemplate, error) {
+	var templates []models.EmailTemplate
+	err := r.db.DB.WithContext(ctx).Order("name").Find(&templates).Error
+	return templates, err
+}
+
+// Save creates or replaces a customized template
+func (r *emailTemplateRepository) Save(ctx context.Context, template *models.EmailTemplate) error {
+	return r.db.DB.WithContext(ctx).Save(template).Error
+}
+
+// Delete removes a customized template, reverting it to the built-in default
+func (r *emailTemplateRepository) Delete(ctx context.Context, name string) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.EmailTemplate{Name: name}).Error
+}
+
+// GetTranslation retrieves a template's translation into the given language
+func (r *emailTemplateRepository) GetTranslation(ctx context.Context, name, language string) (*models.EmailTemplateTranslation, error) {
+	var translation models.EmailTemplateTranslation
+	err := r.db.DB.WithContext(ctx).Where("name = ? AND language = ?", name, language).First(&translation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// ListTranslations retrieves every translation of a template, by language
+func (r *emailTemplateRepository) ListTranslations(ctx context.Context, name string) ([]models.EmailTemplateTranslation, error) {
+	var translations []models.EmailTemplateTranslation
+	err := r.db.DB.WithContext(ctx).Where("name = ?", name).Order("language").Find(&translations).Error
+	return translations, err
+}
+
+// SaveTranslation creates or replaces a template's translation
+func (r *emailTemplateRepository) SaveTranslation(ctx context.Context, translation *models.EmailTemplateTranslation) error {
+	return r.db.DB.WithContext(ctx).Save(translation).Error
+}
+
+// DeleteTranslation removes a template's translation into the given language
+func (r *emailTemplateRepository) DeleteTranslation(ctx context.Context, name, language string) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.EmailTemplateTranslation{Name: name, Language: language}).Error
+}
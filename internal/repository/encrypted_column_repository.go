@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+// encryptedColumnRepository implements EncryptedColumnRepository
+type encryptedColumnRepository struct {
+	db *database.Database
+}
+
+// NewEncryptedColumnRepository creates a new encrypted column repository
+func NewEncryptedColumnRepository(db *database.Database) EncryptedColumnRepository {
+	return &encryptedColumnRepository{db: db}
+}
+
+// ListRaw retrieves stored values ordered by ID, starting after afterID.
+// Querying by table name rather than model skips the serializer.
+func (r *encryptedColumnRepository) ListRaw(ctx context.Context, column models.EncryptedColumn, afterID string, limit int) ([]models.EncryptedValue, error) {
+	var values []models.EncryptedValue
+	err := r.db.DB.WithContext(ctx).
+		Table(column.Table).
+		Select("id, ? AS value", clause.Column{Name: column.Column}).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Scan(&values).Error
+	return values, err
+}
+
+// UpdateRaw stores a value exactly as given
+func (r *encryptedColumnRepository) UpdateRaw(ctx context.Context, column models.EncryptedColumn, id, value string) error {
+	return r.db.DB.WithContext(ctx).
+		Table(column.Table).
+		Where("id = ?", id).
+		UpdateColumn(column.Column, value).Error
+}
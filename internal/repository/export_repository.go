@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+// exportRepository implements ExportRepository
+type exportRepository struct {
+	db *database.Database
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db *database.Database) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+// Save records an exported day, replacing any earlier export of the same day
+func (r *exportRepository) Save(ctx context.Context, export *models.DataExport) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"files", "rows", "triggered_by_id", "updated_at"}),
+		}).
+		Create(export).Error
+}
+
+// GetByDay retrieves the export of a day
+func (r *exportRepository) GetByDay(ctx context.Context, day string) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.DB.WithContext(ctx).Where("day = ?", day).First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// List retrieves the most recently exported days first
+func (r *exportRepository) List(ctx context.Context, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.DB.WithContext(ctx).Order("day DESC").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// EachRow calls fn with the values of a dataset's columns for every row whose
+// time column falls between from and to, oldest first
+func (r *exportRepository) EachRow(ctx context.Context, dataset models.ExportDataset, from, to time.Time, fn func(values []any) error) error {
+	at := "datetime(" + sqliteTime(dataset.TimeColumn) + ")"
+	rows, err := r.db.Reader().WithContext(ctx).
+		Table(dataset.Table).
+		Select(strings.Join(dataset.Columns, ", ")).
+		Where(at+" >= ? AND "+at+" < ?", from.UTC().Format(sqliteUTC), to.UTC().Format(sqliteUTC)).
+		Order(at).
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]any, len(dataset.Columns))
+	pointers := make([]any, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// failedTaskRepository implements FailedTaskRepository
+type failedTaskRepository struct {
+	db *database.Database
+}
+
+// NewFailedTaskRepository creates a new failed task repository
+func NewFailedTaskRepository(db *database.Database) FailedTaskRepository {
+	return &failedTaskRepository{db: db}
+}
+
+// Create records a failed task
+func (r *failedTaskRepository) Create(ctx context.Context, task *models.FailedTask) error {
+	return r.db.DB.WithContext(ctx).Create(task).Error
+}
+
+// GetByID retrieves a failed task by ID
+func (r *failedTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.FailedTask, error) {
+	var task models.FailedTask
+	if err := r.db.DB.WithContext(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindByName retrieves the oldest failed task of a kind with the given name
+func (r *failedTaskRepository) FindByName(ctx context.Context, kind, name string) (*models.FailedTask, error) {
+	var task models.FailedTask
+	err := r.db.DB.WithContext(ctx).
+		Where("kind = ? AND name = ?", kind, name).
+		Order("created_at ASC").
+		First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Update saves a failed task
+func (r *failedTaskRepository) Update(ctx context.Context, task *models.FailedTask) error {
+	return r.db.DB.WithContext(ctx).Save(task).Error
+}
+
+// Delete removes a failed task
+func (r *failedTaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.FailedTask{}).Error
+}
+
+// List retrieves failed tasks matching the query, newest first
+func (r *failedTaskRepository) List(ctx context.Context, query *models.FailedTaskQuery) (*models.FailedTaskListResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.FailedTask{})
+	if query.Kind != "" {
+		db = db.Where("kind = ?", query.Kind)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// Payloads are only decrypted for a single task
+	var tasks []models.FailedTask
+	err := db.Omit("payload").
+		Order("created_at DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FailedTaskListResponse{
+		Tasks:      tasks,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int((total + int64(query.PageSize) - 1) / int64(query.PageSize)),
+	}, nil
+}
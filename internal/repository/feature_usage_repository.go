@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// featureUsageRepository implements FeatureUsageRepository
+type featureUsageRepository struct {
+	db *database.Database
+}
+
+// NewFeatureUsageRepository creates a new feature usage repository
+func NewFeatureUsageRepository(db *database.Database) FeatureUsageRepository {
+	return &featureUsageRepository{db: db}
+}
+
+// signedInUsers counts the distinct users behind rows, leaving out anonymous requests
+const signedInUsers = "COUNT(DISTINCT CASE WHEN feature_usage.user_id <> ? THEN feature_usage.user_id END)"
+
+// Record adds requests to the hourly counts, creating the counts that do not exist yet
+func (r *featureUsageRepository) Record(ctx context.Context, usage []models.FeatureUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "hour"}, {Name: "method"}, {Name: "route"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"role":               gorm.Expr("excluded.role"),
+				"requests":           gorm.Expr("feature_usage.requests + excluded.requests"),
+				"errors":             gorm.Expr("feature_usage.errors + excluded.errors"),
+				"latency_micros":     gorm.Expr("feature_usage.latency_micros + excluded.latency_micros"),
+				"max_latency_micros": gorm.Expr("MAX(feature_usage.max_latency_micros, excluded.max_latency_micros)"),
+			}),
+		}).
+		Create(&usage).Error
+}
+
+// Totals counts the requests, and the distinct signed-in users who made them,
+// in the hours from from up to to
+func (r *featureUsageRepository) Totals(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	var totals struct {
+		Requests int64
+		Users    int64
+	}
+	err := r.between(ctx, from, to).
+		Select("COALESCE(SUM(requests), 0) AS requests, "+signedInUsers+" AS users", uuid.Nil).
+		Scan(&totals).Error
+	return totals.Requests, totals.Users, err
+}
+
+// ListFeatures summarizes the limit most requested routes, busiest first
+func (r *featureUsageRepository) ListFeatures(ctx context.Context, from, to time.Time, limit int) ([]models.FeatureUsageSummary, error) {
+	var features []models.FeatureUsageSummary
+	err := r.between(ctx, from, to).
+		Select("method, route, SUM(requests) AS requests, "+signedInUsers+" AS users, SUM(errors) AS errors, "+
+			"CAST(SUM(errors) AS REAL) / SUM(requests) AS error_rate, "+
+			"SUM(latency_micros) / 1000.0 / SUM(requests) AS avg_latency_ms, "+
+			"MAX(max_latency_micros) / 1000.0 AS max_latency_ms", uuid.Nil).
+		Group("method, route").
+		Order("requests DESC, route, method").
+		Limit(limit).
+		Scan(&features).Error
+	return features, err
+}
+
+// ListRoles summarizes the requests made by each role, busiest first
+func (r *featureUsageRepository) ListRoles(ctx context.Context, from, to time.Time) ([]models.RoleUsageSummary, error) {
+	var roles []models.RoleUsageSummary
+	err := r.between(ctx, from, to).
+		Select("role, SUM(requests) AS requests, "+signedInUsers+" AS users, COUNT(DISTINCT method || ' ' || route) AS features", uuid.Nil).
+		Group("role").
+		Order("requests DESC, role").
+		Scan(&roles).Error
+	return roles, err
+}
+
+// ListTopUsers summarizes the limit signed-in users who made the most requests
+func (r *featureUsageRepository) ListTopUsers(ctx context.Context, from, to time.Time, limit int) ([]models.UserUsageSummary, error) {
+	var users []models.UserUsageSummary
+	err := r.between(ctx, from, to).
+		Select("feature_usage.user_id, COALESCE(users.email, '') AS email, COALESCE(users.role, MAX(feature_usage.role)) AS role, "+
+			"SUM(requests) AS requests, COUNT(DISTINCT method || ' ' || route) AS features").
+		Joins("LEFT JOIN users ON users.id = feature_usage.user_id").
+		Where("feature_usage.user_id <> ?", uuid.Nil).
+		Group("feature_usage.user_id").
+		Order("requests DESC, feature_usage.user_id").
+		Limit(limit).
+		Scan(&users).Error
+	return users, err
+}
+
+// between selects the counts for the hours from from up to to
+func (r *featureUsageRepository) between(ctx context.Context, from, to time.Time) *gorm.DB {
+	hour := "datetime(" + sqliteTime("feature_usage.hour") + ")"
+	return r.db.DB.WithContext(ctx).
+		Model(&models.FeatureUsage{}).
+		Where(hour+" >= ? AND "+hour+" < ?", from.UTC().Format(sqliteUTC), to.UTC().Format(sqliteUTC))
+}
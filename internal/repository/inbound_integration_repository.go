@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inboundIntegrationRepository implements InboundIntegrationRepository
+type inboundIntegrationRepository struct {
+	db *database.Database
+}
+
+// NewInboundIntegrationRepository creates a new inbound integration repository
+func NewInboundIntegrationRepository(db *database.Database) InboundIntegrationRepository {
+	return &inboundIntegrationRepository{db: db}
+}
+
+// Create creates a new inbound integration
+func (r *inboundIntegrationRepository) Create(ctx context.Context, integration *models.InboundIntegration) error {
+	return r.db.DB.WithContext(ctx).Create(integration).Error
+}
+
+// GetByID retrieves an inbound integration by ID
+func (r *inboundIntegrationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.InboundIntegration, error) {
+	var integration models.InboundIntegration
+	if err := r.db.DB.WithContext(ctx).Where("id = ?", id).First(&integration).Error; err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// GetByKey retrieves an inbound integration by the key of its webhook URL
+func (r *inboundIntegrationRepository) GetByKey(ctx context.Context, key string) (*models.InboundIntegration, error) {
+	var integration models.InboundIntegration
+	if err := r.db.DB.WithContext(ctx).Where("key = ?", key).First(&integration).Error; err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// List retrieves every inbound integration by name
+func (r *inboundIntegrationRepository) List(ctx context.Context) ([]models.InboundIntegration, error) {
+	var integrations []models.InboundIntegration
+	err := r.db.DB.WithContext(ctx).Order("name ASC").Find(&integrations).Error
+	return integrations, err
+}
+
+// Update updates an existing inbound integration
+func (r *inboundIntegrationRepository) Update(ctx context.Context, integration *models.InboundIntegration) error {
+	return r.db.DB.WithContext(ctx).Save(integration).Error
+}
+
+// Delete deletes an inbound integration with the ticket links of its payloads
+// and the alerts it was notified about
+func (r *inboundIntegrationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&models.InboundTicketLink{}, &models.InboundAlert{}} {
+			if err := tx.Where("integration_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.InboundIntegration{ID: id}).Error
+	})
+}
+
+// MarkReceived records when an integration last created or updated a ticket
+func (r *inboundIntegrationRepository) MarkReceived(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.DB.WithContext(ctx).
+		Model(&models.InboundIntegration{}).
+		Where("id = ?", id).
+		UpdateColumn("last_received_at", at).Error
+}
+
+// GetLink retrieves the ticket link of an external ID, or nil when payloads
+// with it have not opened a ticket
+func (r *inboundIntegrationRepository) GetLink(ctx context.Context, integrationID uuid.UUID, externalID string) (*models.InboundTicketLink, error) {
+	var link models.InboundTicketLink
+	err := r.db.DB.WithContext(ctx).
+		Where("integration_id = ? AND external_id = ?", integrationID, externalID).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// SaveLink links an external ID to a ticket, replacing its previous ticket
+func (r *inboundIntegrationRepository) SaveLink(ctx context.Context, link *models.InboundTicketLink) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "integration_id"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ticket_id", "updated_at"}),
+		}).
+		Create(link).Error
+}
+
+// ListAlerts retrieves the alerts with the given fingerprints an integration
+// was notified about before
+func (r *inboundIntegrationRepository) ListAlerts(ctx context.Context, integrationID uuid.UUID, fingerprints []string) ([]models.InboundAlert, error) {
+	var alerts []models.InboundAlert
+	err := r.db.DB.WithContext(ctx).
+		Where("integration_id = ? AND fingerprint IN ?", integrationID, fingerprints).
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// LatestAlertOfGroup retrieves the alert of an alert group notified about
+// last, or nil when the group is new
+func (r *inboundIntegrationRepository) LatestAlertOfGroup(ctx context.Context, integrationID uuid.UUID, groupKey string) (*models.InboundAlert, error) {
+	var alert models.InboundAlert
+	err := r.db.DB.WithContext(ctx).
+		Where("integration_id = ? AND group_key = ?", integrationID, groupKey).
+		Order("updated_at DESC").
+		First(&alert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// SaveAlert records the state of an alert, replacing what was known of it
+func (r *inboundIntegrationRepository) SaveAlert(ctx context.Context, alert *models.InboundAlert) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "integration_id"}, {Name: "fingerprint"}},
+			DoUpdates: clause.AssignmentColumns([]string{"group_key", "ticket_id", "name", "firing", "starts_at", "ends_at", "updated_at"}),
+		}).
+		Create(alert).Error
+}
+
+// CountFiringAlerts counts the alerts of a ticket that are still firing
+func (r *inboundIntegrationRepository) CountFiringAlerts(ctx context.Context, ticketID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.InboundAlert{}).
+		Where("ticket_id = ? AND firing = ?", ticketID, true).
+		Count(&count).Error
+	return count, err
+}
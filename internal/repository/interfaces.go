@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"github.com/google/uuid"
@@ -10,16 +11,39 @@ import (
 // TicketRepository defines the interface for ticket data operations
 type TicketRepository interface {
 	Create(ctx context.Context, ticket *models.Ticket) error
+	CreateWithAttachments(ctx context.Context, ticket *models.Ticket, drafts []models.DraftAttachment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error)
 	Update(ctx context.Context, ticket *models.Ticket) error
+	GetHistory(ctx context.Context, id uuid.UUID) ([]*models.Ticket, error)
+	RestoreVersion(ctx context.Context, id, versionID uuid.UUID) (*models.Ticket, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	Trash(ctx context.Context, id, deletedByID uuid.UUID) error
+	ListTrashed(ctx context.Context, offset, limit int) ([]models.Ticket, int64, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]models.Ticket, error)
+	Purge(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, query *models.TicketQuery) (*models.TicketListResponse, error)
 	GetStats(ctx context.Context) (*models.TicketStats, error)
-	AssignToAgent(ctx context.Context, ticketID, agentID uuid.UUID) error
-	UpdateStatus(ctx context.Context, ticketID uuid.UUID, status models.TicketStatus) error
-	Escalate(ctx context.Context, ticketID, escalatedTo uuid.UUID) error
+	AssignToAgent(ctx context.Context, assignment *models.TicketAssignment) error
+	TakeIfUnassigned(ctx context.Context, assignment *models.TicketAssignment) (bool, error)
+	ChangeRequester(ctx context.Context, ticketID, requesterID uuid.UUID) error
+	CountOpenByRequester(ctx context.Context, requesterID uuid.UUID) (int64, error)
+	FindSimilar(ctx context.Context, match string, requesterID *uuid.UUID, limit int) ([]models.SimilarTicket, error)
+	ReassignOpenTickets(ctx context.Context, fromAgentID, toAgentID, assignedByID uuid.UUID, note string) (int64, error)
+	ListAssignments(ctx context.Context, ticketID uuid.UUID) ([]models.TicketAssignment, error)
+	UpdateStatus(ctx context.Context, ticketID uuid.UUID, status models.TicketStatus, resolution *models.TicketResolution) error
+	Escalate(ctx context.Context, event *models.TicketEscalationEvent) error
+	DeEscalate(ctx context.Context, event *models.TicketEscalationEvent) error
 	GetByUser(ctx context.Context, userID uuid.UUID, query *models.TicketQuery) (*models.TicketListResponse, error)
 	GetByAgent(ctx context.Context, agentID uuid.UUID, query *models.TicketQuery) (*models.TicketListResponse, error)
+	RecordView(ctx context.Context, view *models.TicketView, keep int) error
+	GetRecentlyViewed(ctx context.Context, userID uuid.UUID, limit int, ownOnly bool) ([]models.Ticket, error)
+	Pin(ctx context.Context, pin *models.TicketPin) error
+	Unpin(ctx context.Context, userID, ticketID uuid.UUID) error
+	AddSubscriber(ctx context.Context, subscriber *models.TicketSubscriber) error
+	GetSubscriber(ctx context.Context, id uuid.UUID) (*models.TicketSubscriber, error)
+	ListSubscribers(ctx context.Context, ticketID uuid.UUID) ([]models.TicketSubscriber, error)
+	RemoveSubscriber(ctx context.Context, id uuid.UUID) error
 }
 
 // CategoryRepository defines the interface for category data operations
@@ -31,6 +55,7 @@ type CategoryRepository interface {
 	List(ctx context.Context) ([]models.Category, error)
 	ListActive(ctx context.Context) ([]models.Category, error)
 	GetWithChildren(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	SetRequiredResolutionFields(ctx context.Context, id uuid.UUID, fields []models.ResolutionField) error
 }
 
 // CommentRepository defines the interface for comment data operations
@@ -40,6 +65,7 @@ type CommentRepository interface {
 	Update(ctx context.Context, comment *models.Comment) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByTicket(ctx context.Context, ticketID uuid.UUID, includeInternal bool) ([]models.Comment, error)
+	Search(ctx context.Context, ticketIDs []uuid.UUID, text string, includeInternal bool) ([]models.Comment, error)
 	GetByUser(ctx context.Context, userID uuid.UUID) ([]models.Comment, error)
 }
 
@@ -49,5 +75,308 @@ type AttachmentRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Attachment, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByTicket(ctx context.Context, ticketID uuid.UUID) ([]models.Attachment, error)
+	Search(ctx context.Context, ticketIDs []uuid.UUID, text string) ([]models.Attachment, error)
 	UpdateVirusScan(ctx context.Context, id uuid.UUID, isScanned, isSafe bool) error
+	ListPendingThumbnails(ctx context.Context, limit int) ([]models.Attachment, error)
+	UpdateThumbnails(ctx context.Context, attachment *models.Attachment) error
+}
+
+// QuarantineRepository defines the interface for quarantined submission data operations
+type QuarantineRepository interface {
+	Create(ctx context.Context, submission *models.QuarantinedSubmission) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.QuarantinedSubmission, error)
+	Update(ctx context.Context, submission *models.QuarantinedSubmission) error
+	List(ctx context.Context, status models.QuarantineStatus, page, pageSize int) (*models.QuarantineListResponse, error)
+}
+
+// AuditLogRepository defines the interface for audit log data operations
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	List(ctx context.Context, query *models.AuditLogQuery) (*models.AuditLogListResponse, error)
+}
+
+// EncryptedColumnRepository reads and rewrites stored ciphertext, bypassing the encrypted serializer
+type EncryptedColumnRepository interface {
+	ListRaw(ctx context.Context, column models.EncryptedColumn, afterID string, limit int) ([]models.EncryptedValue, error)
+	UpdateRaw(ctx context.Context, column models.EncryptedColumn, id, value string) error
+}
+
+// LoginEventRepository defines the interface for login history data operations
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *models.LoginEvent) error
+	CountByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	ExistsForDevice(ctx context.Context, userID uuid.UUID, device, location string) (bool, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, page, pageSize int) (*models.LoginHistoryResponse, error)
+}
+
+// TeamRepository defines the interface for team data operations
+type TeamRepository interface {
+	Create(ctx context.Context, team *models.Team) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error)
+	List(ctx context.Context) ([]models.Team, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	AddMember(ctx context.Context, teamID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, teamID, userID uuid.UUID) error
+	GetTeamIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// OutOfOfficeRepository defines the interface for out-of-office data operations
+type OutOfOfficeRepository interface {
+	Upsert(ctx context.Context, window *models.OutOfOffice) error
+	GetByUser(ctx context.Context, userID uuid.UUID) (*models.OutOfOffice, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// AssetRepository defines the interface for asset data operations
+type AssetRepository interface {
+	Create(ctx context.Context, asset *models.Asset) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Asset, error)
+	Update(ctx context.Context, asset *models.Asset) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter *models.AssetFilter) ([]models.Asset, error)
+	LinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error
+	UnlinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error
+	GetByTicket(ctx context.Context, ticketID uuid.UUID) ([]models.Asset, error)
+	GetTickets(ctx context.Context, assetID uuid.UUID) ([]models.Ticket, error)
+	TicketCounts(ctx context.Context, limit int) ([]models.AssetTicketCount, error)
+}
+
+// ProblemRepository defines the interface for problem data operations
+type ProblemRepository interface {
+	Create(ctx context.Context, problem *models.Problem) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Problem, error)
+	Update(ctx context.Context, problem *models.Problem) error
+	List(ctx context.Context, status models.ProblemStatus) ([]models.Problem, error)
+	LinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) error
+	UnlinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) error
+	GetIncidents(ctx context.Context, problemID uuid.UUID) ([]models.Ticket, error)
+	TopByIncidentCount(ctx context.Context, limit int) ([]models.ProblemIncidentCount, error)
+}
+
+// StatusBannerRepository defines the interface for status banner data operations
+type StatusBannerRepository interface {
+	Create(ctx context.Context, banner *models.StatusBanner) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.StatusBanner, error)
+	Update(ctx context.Context, banner *models.StatusBanner) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context) ([]models.StatusBanner, error)
+	ListActive(ctx context.Context, at time.Time) ([]models.StatusBanner, error)
+	AddSubscription(ctx context.Context, subscription *models.BannerSubscription) error
+	ListSubscriptions(ctx context.Context, bannerID uuid.UUID) ([]models.BannerSubscription, error)
+	CountSubscriptions(ctx context.Context, bannerIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+}
+
+// ReportRepository defines the interface for aggregate reporting queries
+type ReportRepository interface {
+	QueueSnapshot(ctx context.Context, at, riskUntil, dayStart time.Time) (*models.QueueSnapshot, error)
+	OpenByPriority(ctx context.Context) ([]models.PriorityCount, error)
+	CountCreatedSince(ctx context.Context, since time.Time) (int64, error)
+	TicketFlow(ctx context.Context, interval models.TrendInterval, from, to time.Time, offset time.Duration) ([]models.TicketFlow, error)
+	CountByCategory(ctx context.Context, from, to time.Time) ([]models.CategoryTicketCount, error)
+	CountByRootCause(ctx context.Context, from, to time.Time, offset time.Duration) ([]models.RootCauseTicketCount, error)
+	OpenByAge(ctx context.Context, at time.Time) ([]models.AgingTicketCount, error)
+}
+
+// ExportRepository defines the interface for analytics export data operations
+type ExportRepository interface {
+	Save(ctx context.Context, export *models.DataExport) error
+	GetByDay(ctx context.Context, day string) (*models.DataExport, error)
+	List(ctx context.Context, limit int) ([]models.DataExport, error)
+	EachRow(ctx context.Context, dataset models.ExportDataset, from, to time.Time, fn func(values []any) error) error
+}
+
+// ChatTriggerRepository defines the interface for proactive chat trigger data operations
+type ChatTriggerRepository interface {
+	Create(ctx context.Context, trigger *models.ChatTrigger) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ChatTrigger, error)
+	Update(ctx context.Context, trigger *models.ChatTrigger) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, enabledOnly bool) ([]models.ChatTrigger, error)
+}
+
+// KnowledgeRepository defines the interface for knowledge base data operations
+type KnowledgeRepository interface {
+	Create(ctx context.Context, article *models.KnowledgeArticle) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.KnowledgeArticle, error)
+	Update(ctx context.Context, article *models.KnowledgeArticle) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, publishedOnly bool) ([]models.KnowledgeArticle, error)
+	SearchPublished(ctx context.Context, terms []string) ([]models.KnowledgeArticle, error)
+}
+
+// ChatRepository defines the interface for live chat data operations
+type ChatRepository interface {
+	Create(ctx context.Context, session *models.ChatSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ChatSession, error)
+	Update(ctx context.Context, session *models.ChatSession) error
+	List(ctx context.Context, status models.ChatStatus) ([]models.ChatSession, error)
+	AddMessage(ctx context.Context, message *models.ChatMessage) error
+	QueuePosition(ctx context.Context, session *models.ChatSession) (int64, error)
+	AddQueueEvent(ctx context.Context, event *models.ChatQueueEvent) error
+	AverageQueueWait(ctx context.Context, since time.Time) (*float64, error)
+	CountActiveByAgent(ctx context.Context) (map[uuid.UUID]int64, error)
+	CountByStatus(ctx context.Context, status models.ChatStatus) (int64, error)
+	SetParticipant(ctx context.Context, participant *models.ChatParticipant) error
+	AcknowledgeMessages(ctx context.Context, sessionID uuid.UUID, senders []models.ChatSenderType, upTo time.Time, read bool, at time.Time) ([]uuid.UUID, error)
+	CountEndedSince(ctx context.Context, since time.Time) (int64, error)
+	RatingCounts(ctx context.Context, since time.Time) ([]models.ChatRatingCount, error)
+}
+
+// SearchRepository defines the interface for global search typeahead lookups
+type SearchRepository interface {
+	SuggestTickets(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error)
+	SuggestRequesters(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error)
+	SuggestCategories(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error)
+	SuggestArticles(ctx context.Context, prefix string, limit int, includeDrafts bool) ([]models.SearchSuggestion, error)
+	LookupTickets(ctx context.Context, idPrefix string, limit int) ([]models.SearchSuggestion, error)
+	LookupUser(ctx context.Context, email string, includeStaff bool) (*models.SearchSuggestion, error)
+	LookupRequesterTickets(ctx context.Context, requesterID uuid.UUID, limit int) ([]models.SearchSuggestion, error)
+}
+
+// EmailTemplateRepository defines the interface for customized email template data operations
+type EmailTemplateRepository interface {
+	Get(ctx context.Context, name string) (*models.EmailTemplate, error)
+	List(ctx context.Context) ([]models.EmailTemplate, error)
+	Save(ctx context.Context, template *models.EmailTemplate) error
+	Delete(ctx context.Context, name string) error
+	GetTranslation(ctx context.Context, name, language string) (*models.EmailTemplateTranslation, error)
+	ListTranslations(ctx context.Context, name string) ([]models.EmailTemplateTranslation, error)
+	SaveTranslation(ctx context.Context, translation *models.EmailTemplateTranslation) error
+	DeleteTranslation(ctx context.Context, name, language string) error
+}
+
+// EmailDeliveryRepository defines the interface for outbound email tracking data operations
+type EmailDeliveryRepository interface {
+	Create(ctx context.Context, deliveries []*models.EmailDelivery) error
+	Update(ctx context.Context, delivery *models.EmailDelivery) error
+	FindLatest(ctx context.Context, messageID, recipient string) (*models.EmailDelivery, error)
+	ListRecentStatuses(ctx context.Context, recipient string, limit int) ([]models.EmailDeliveryStatus, error)
+	LastSent(ctx context.Context) (*time.Time, error)
+	List(ctx context.Context, query *models.EmailDeliveryQuery) (*models.EmailDeliveryListResponse, error)
+	Suppress(ctx context.Context, suppression *models.EmailSuppression) error
+	FindSuppressed(ctx context.Context, addresses []string) ([]string, error)
+	GetSuppression(ctx context.Context, address string) (*models.EmailSuppression, error)
+	ListSuppressions(ctx context.Context) ([]models.EmailSuppression, error)
+	DeleteSuppression(ctx context.Context, address string) error
+}
+
+// NotificationQueueRepository defines the interface for notifications held back during quiet hours
+type NotificationQueueRepository interface {
+	Create(ctx context.Context, notification *models.QueuedNotification) error
+	ListDue(ctx context.Context, at time.Time, limit int) ([]models.QueuedNotification, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// NotificationRepository defines the interface for in-app notification and notification preference data operations
+type NotificationRepository interface {
+	Create(ctx context.Context, notifications []models.Notification) error
+	ListByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) (*models.NotificationListResponse, error)
+	SetRead(ctx context.Context, userID, id uuid.UUID, readAt *time.Time) (*models.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID, readAt time.Time) (int64, error)
+	ListPreferences(ctx context.Context, userIDs []uuid.UUID) ([]models.NotificationPreference, error)
+	SavePreferences(ctx context.Context, preferences []models.NotificationPreference) error
+}
+
+// InboundIntegrationRepository defines the interface for inbound integration data operations
+type InboundIntegrationRepository interface {
+	Create(ctx context.Context, integration *models.InboundIntegration) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.InboundIntegration, error)
+	GetByKey(ctx context.Context, key string) (*models.InboundIntegration, error)
+	List(ctx context.Context) ([]models.InboundIntegration, error)
+	Update(ctx context.Context, integration *models.InboundIntegration) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	MarkReceived(ctx context.Context, id uuid.UUID, at time.Time) error
+	GetLink(ctx context.Context, integrationID uuid.UUID, externalID string) (*models.InboundTicketLink, error)
+	SaveLink(ctx context.Context, link *models.InboundTicketLink) error
+	ListAlerts(ctx context.Context, integrationID uuid.UUID, fingerprints []string) ([]models.InboundAlert, error)
+	LatestAlertOfGroup(ctx context.Context, integrationID uuid.UUID, groupKey string) (*models.InboundAlert, error)
+	SaveAlert(ctx context.Context, alert *models.InboundAlert) error
+	CountFiringAlerts(ctx context.Context, ticketID uuid.UUID) (int64, error)
+}
+
+// WebhookRepository defines the interface for outbound webhook data operations
+type WebhookRepository interface {
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	List(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListEnabled(ctx context.Context) ([]models.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *models.WebhookSubscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDueDeliveries(ctx context.Context, at time.Time, limit int) ([]models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, query *models.WebhookDeliveryQuery) (*models.WebhookDeliveryListResponse, error)
+}
+
+// FailedTaskRepository defines the interface for failed background work data operations
+type FailedTaskRepository interface {
+	Create(ctx context.Context, task *models.FailedTask) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FailedTask, error)
+	FindByName(ctx context.Context, kind, name string) (*models.FailedTask, error)
+	Update(ctx context.Context, task *models.FailedTask) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, query *models.FailedTaskQuery) (*models.FailedTaskListResponse, error)
+}
+
+// FeatureUsageRepository defines the interface for API usage analytics data operations
+type FeatureUsageRepository interface {
+	Record(ctx context.Context, usage []models.FeatureUsage) error
+	Totals(ctx context.Context, from, to time.Time) (requests, users int64, err error)
+	ListFeatures(ctx context.Context, from, to time.Time, limit int) ([]models.FeatureUsageSummary, error)
+	ListRoles(ctx context.Context, from, to time.Time) ([]models.RoleUsageSummary, error)
+	ListTopUsers(ctx context.Context, from, to time.Time, limit int) ([]models.UserUsageSummary, error)
+}
+
+// DraftAttachmentRepository defines the interface for files uploaded before their ticket is saved
+type DraftAttachmentRepository interface {
+	Create(ctx context.Context, draft *models.DraftAttachment) error
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.DraftAttachment, error)
+	AttachToTicket(ctx context.Context, ticketID uuid.UUID, drafts []models.DraftAttachment) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DraftAttachment, error)
+	Delete(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// AccountTokenRepository defines the interface for the single-use tokens
+// emailed to reset a password or verify an email address
+type AccountTokenRepository interface {
+	CreatePasswordReset(ctx context.Context, token *models.PasswordResetToken) error
+	UsePasswordReset(ctx context.Context, hash string, now time.Time) (*models.PasswordResetToken, error)
+	CreateEmailVerification(ctx context.Context, token *models.EmailVerificationToken) error
+	UseEmailVerification(ctx context.Context, hash string, now time.Time) (*models.EmailVerificationToken, error)
+}
+
+// RetentionRepository defines the interface for finding and clearing the data
+// of closed tickets that retention rules apply to
+type RetentionRepository interface {
+	CountClosedAttachments(ctx context.Context, closedBefore time.Time) (count, tickets, bytes int64, err error)
+	ListClosedAttachments(ctx context.Context, closedBefore time.Time, limit int) ([]models.Attachment, error)
+	CountRequestersToAnonymize(ctx context.Context, closedBefore time.Time) (count, tickets int64, err error)
+	ListRequestersToAnonymize(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error)
+	AnonymizeRequester(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
+
+// OffboardingRepository defines the interface for offboarding jobs and the
+// data operations they run
+type OffboardingRepository interface {
+	Create(ctx context.Context, job *models.OffboardingJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.OffboardingJob, error)
+	FindUnfinished(ctx context.Context, userID uuid.UUID) (*models.OffboardingJob, error)
+	List(ctx context.Context, offset, limit int) ([]models.OffboardingJob, int64, error)
+	ClaimPending(ctx context.Context, at time.Time) (*models.OffboardingJob, error)
+	Update(ctx context.Context, job *models.OffboardingJob) error
+	ReleaseTickets(ctx context.Context, userID uuid.UUID, teamID *uuid.UUID, releasedByID uuid.UUID, note string) (int64, error)
+	AnonymizeStaff(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error)
+}
+
+// SandboxRepository defines the interface for training sandbox data operations
+type SandboxRepository interface {
+	Create(ctx context.Context, sandbox *models.Sandbox, requesters []models.User, tickets []models.Ticket, comments []models.Comment) error
+	GetByAgent(ctx context.Context, agentID uuid.UUID) (*models.Sandbox, error)
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Sandbox, error)
+	Delete(ctx context.Context, sandbox *models.Sandbox) ([]models.Attachment, error)
+}
+
+// DemoRepository defines the interface for resetting a demo environment
+type DemoRepository interface {
+	Reset(ctx context.Context, dataset *models.DemoDataset) ([]models.Attachment, []models.DraftAttachment, error)
 }
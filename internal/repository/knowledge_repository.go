@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// knowledgeRepository implements KnowledgeRepository
+type knowledgeRepository struct {
+	db *database.Database
+}
+
+// NewKnowledgeRepository creates a new knowledge base repository
+func NewKnowledgeRepository(db *database.Database) KnowledgeRepository {
+	return &knowledgeRepository{db: db}
+}
+
+// Create creates a new knowledge article
+func (r *knowledgeRepository) Create(ctx context.Context, article *models.KnowledgeArticle) error {
+	return r.db.DB.WithContext(ctx).Create(article).Error
+}
+
+// GetByID retrieves a knowledge article by ID
+func (r *knowledgeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.KnowledgeArticle, error) {
+	var article models.KnowledgeArticle
+	if err := r.db.DB.WithContext(ctx).Where("id = ?", id).First(&article).Error; err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// Update updates an existing knowledge article
+func (r *knowledgeRepository) Update(ctx context.Context, article *models.KnowledgeArticle) error {
+	return r.db.DB.WithContext(ctx).Save(article).Error
+}
+
+// Delete deletes a knowledge article
+func (r *knowledgeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.KnowledgeArticle{}).Error
+}
+
+// List retrieves knowledge articles by title, optionally only published ones
+func (r *knowledgeRepository) List(ctx context.Context, publishedOnly bool) ([]models.KnowledgeArticle, error) {
+	db := r.db.DB.WithContext(ctx)
+	if publishedOnly {
+		db = db.Where("is_published = ?", true)
+	}
+
+	var articles []models.KnowledgeArticle
+	err := db.Order("title ASC").Find(&articles).Error
+	return articles, err
+}
+
+// SearchPublished retrieves published articles whose title, body or keywords
+// contain any of the terms
+func (r *knowledgeRepository) SearchPublished(ctx context.Context, terms []string) ([]models.KnowledgeArticle, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + strings.ToLower(term) + "%"
+		conditions = append(conditions, "LOWER(title) LIKE ? OR LOWER(body) LIKE ? OR LOWER(keywords) LIKE ?")
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	var articles []models.KnowledgeArticle
+	err := r.db.DB.WithContext(ctx).
+		Where("is_published = ?", true).
+		Where(strings.Join(conditions, " OR "), args...).
+		Find(&articles).Error
+	return articles, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// loginEventRepository implements LoginEventRepository
+type loginEventRepository struct {
+	db *database.Database
+}
+
+// NewLoginEventRepository creates a new login event repository
+func NewLoginEventRepository(db *database.Database) LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+// Create creates a new login event
+func (r *loginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	return r.db.DB.WithContext(ctx).Create(event).Error
+}
+
+// CountByUser counts the recorded logins of a user
+func (r *loginEventRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.LoginEvent{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// ExistsForDevice reports whether the user has logged in from the device and location before
+func (r *loginEventRepository) ExistsForDevice(ctx context.Context, userID uuid.UUID, device, location string) (bool, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.LoginEvent{}).
+		Where("user_id = ? AND device = ? AND location = ?", userID, device, location).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListByUser retrieves the logins of a user, newest first
+func (r *loginEventRepository) ListByUser(ctx context.Context, userID uuid.UUID, page, pageSize int) (*models.LoginHistoryResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.LoginEvent{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var logins []models.LoginEvent
+	err := db.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logins).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginHistoryResponse{
+		Logins:     logins,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
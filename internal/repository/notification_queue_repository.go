@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// notificationQueueRepository implements NotificationQueueRepository
+type notificationQueueRepository struct {
+	db *database.Database
+}
+
+// NewNotificationQueueRepository creates a new notification queue repository
+func NewNotificationQueueRepository(db *database.Database) NotificationQueueRepository {
+	return &notificationQueueRepository{db: db}
+}
+
+// Create queues a notification
+func (r *notificationQueueRepository) Create(ctx context.Context, notification *models.QueuedNotification) error {
+	return r.db.DB.WithContext(ctx).Create(notification).Error
+}
+
+// ListDue retrieves up to limit notifications due for delivery at the given time, oldest first
+func (r *notificationQueueRepository) ListDue(ctx context.Context, at time.Time, limit int) ([]models.QueuedNotification, error) {
+	var notifications []models.QueuedNotification
+	err := r.db.DB.WithContext(ctx).
+		Where("datetime("+sqliteTime("deliver_at")+") <= ?", at.UTC().Format(sqliteUTC)).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// Delete removes a notification from the queue
+func (r *notificationQueueRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.QueuedNotification{}, "id = ?", id).Error
+}
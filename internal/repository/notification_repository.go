@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notificationRepository implements NotificationRepository
+type notificationRepository struct {
+	db *database.Database
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *database.Database) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create stores in-app notifications
+func (r *notificationRepository) Create(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.DB.WithContext(ctx).Create(&notifications).Error
+}
+
+// ListByUser retrieves a user's notifications, newest first, with how many
+// of them are unread
+func (r *notificationRepository) ListByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) (*models.NotificationListResponse, error) {
+	mine := r.db.DB.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+
+	var unread int64
+	if err := mine.Session(&gorm.Session{}).Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		return nil, err
+	}
+
+	db := mine
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	var total int64
+	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	notifications := []models.Notification{}
+	err := db.Order("created_at DESC").
+		Order("id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationListResponse{
+		Notifications: notifications,
+		Unread:        unread,
+		Total:         total,
+		Page:          page,
+		PageSize:      pageSize,
+		TotalPages:    int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// SetRead marks one of a user's notifications read at readAt, or unread when
+// readAt is nil. It returns gorm.ErrRecordNotFound when the user has no such
+// notification.
+func (r *notificationRepository) SetRead(ctx context.Context, userID, id uuid.UUID, readAt *time.Time) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+			return err
+		}
+		notification.ReadAt = readAt
+		return tx.Model(&notification).Update("read_at", readAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of a user read, returning how many there were
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, readAt time.Time) (int64, error) {
+	result := r.db.DB.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+// ListPreferences retrieves the notification preferences the users have set
+func (r *notificationRepository) ListPreferences(ctx context.Context, userIDs []uuid.UUID) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+	err := r.db.DB.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences creates or replaces notification preferences
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "in_app", "updated_at"}),
+		}).
+		Create(&preferences).Error
+}
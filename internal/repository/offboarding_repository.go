@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// offboardingRepository implements OffboardingRepository
+type offboardingRepository struct {
+	db *database.Database
+}
+
+// NewOffboardingRepository creates a new offboarding repository
+func NewOffboardingRepository(db *database.Database) OffboardingRepository {
+	return &offboardingRepository{db: db}
+}
+
+// Create creates a new offboarding job
+func (r *offboardingRepository) Create(ctx context.Context, job *models.OffboardingJob) error {
+	return r.db.DB.WithContext(ctx).Create(job).Error
+}
+
+// GetByID retrieves an offboarding job by ID
+func (r *offboardingRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.OffboardingJob, error) {
+	var job models.OffboardingJob
+	if err := r.db.DB.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindUnfinished retrieves the pending or running offboarding job of a user
+func (r *offboardingRepository) FindUnfinished(ctx context.Context, userID uuid.UUID) (*models.OffboardingJob, error) {
+	var job models.OffboardingJob
+	err := r.db.DB.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []models.OffboardingStatus{models.OffboardingPending, models.OffboardingRunning}).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List retrieves offboarding jobs, most recent first, and how many there are
+func (r *offboardingRepository) List(ctx context.Context, offset, limit int) ([]models.OffboardingJob, int64, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.OffboardingJob{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []models.OffboardingJob
+	err := db.Order("created_at DESC").
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, total, err
+}
+
+// ClaimPending marks the oldest pending offboarding job as running and
+// returns it, or returns gorm.ErrRecordNotFound when none is pending
+func (r *offboardingRepository) ClaimPending(ctx context.Context, at time.Time) (*models.OffboardingJob, error) {
+	for {
+		var job models.OffboardingJob
+		err := r.db.DB.WithContext(ctx).
+			Where("status = ?", models.OffboardingPending).
+			Order("created_at ASC").
+			First(&job).Error
+		if err != nil {
+			return nil, err
+		}
+
+		result := r.db.DB.WithContext(ctx).Model(&models.OffboardingJob{}).
+			Where("id = ? AND status = ?", job.ID, models.OffboardingPending).
+			Updates(map[string]any{"status": models.OffboardingRunning, "started_at": at})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		// Another worker claimed it first
+		if result.RowsAffected == 0 {
+			continue
+		}
+		job.Status = models.OffboardingRunning
+		job.StartedAt = &at
+		return &job, nil
+	}
+}
+
+// Update saves an offboarding job
+func (r *offboardingRepository) Update(ctx context.Context, job *models.OffboardingJob) error {
+	return r.db.DB.WithContext(ctx).Save(job).Error
+}
+
+// ReleaseTickets unassigns the open tickets assigned to a user, moving them
+// to a team's queue when teamID is set, and records each unassignment
+func (r *offboardingRepository) ReleaseTickets(ctx context.Context, userID uuid.UUID, teamID *uuid.UUID, releasedByID uuid.UUID, note string) (int64, error) {
+	var released int64
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		var ticketIDs []uuid.UUID
+		if err := tx.Model(&models.Ticket{}).
+			Where("assigned_agent_id = ? AND status IN ? AND expiration_time IS NULL", userID, []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+			Pluck("id", &ticketIDs).Error; err != nil {
+			return err
+		}
+		if len(ticketIDs) == 0 {
+			return nil
+		}
+
+		updates := map[string]any{"assigned_agent_id": nil}
+		if teamID != nil {
+			updates["team_id"] = *teamID
+		}
+		result := tx.Model(&models.Ticket{}).Where("id IN ? AND expiration_time IS NULL", ticketIDs).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		released = result.RowsAffected
+
+		assignments := make([]models.TicketAssignment, len(ticketIDs))
+		for i, ticketID := range ticketIDs {
+			assignments[i] = models.TicketAssignment{
+				TicketID:        ticketID,
+				PreviousAgentID: &userID,
+				AssignedByID:    releasedByID,
+				Note:            note,
+			}
+		}
+		return tx.Create(&assignments).Error
+	})
+	return released, err
+}
+
+// AnonymizeStaff replaces a staff member's name with "Former employee" and
+// their email address with a placeholder, deactivates them and removes their
+// team memberships, out-of-office windows, including those delegating to
+// them, pins, recent views, sign-in history and pending account links. It
+// returns how many comments are now attributed to "Former employee".
+func (r *offboardingRepository) AnonymizeStaff(ctx context.Context, userID uuid.UUID, at time.Time) (int64, error) {
+	var comments int64
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{
+				"email":                "former-employee-" + userID.String() + "@invalid",
+				"first_name":           models.FormerEmployeeFirstName,
+				"last_name":            models.FormerEmployeeLastName,
+				"password_hash":        "",
+				"is_active":            false,
+				"last_login_at":        nil,
+				"language":             "",
+				"timezone":             "",
+				"quiet_hours_start":    "",
+				"quiet_hours_end":      "",
+				"email_failing_since":  nil,
+				"max_concurrent_chats": nil,
+				"anonymized_at":        at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, model := range []any{
+			&models.TeamMember{},
+			&models.TicketPin{},
+			&models.TicketView{},
+			&models.LoginEvent{},
+			&models.PasswordResetToken{},
+			&models.EmailVerificationToken{},
+		} {
+			if err := tx.Where("user_id = ?", userID.String()).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ? OR delegate_id = ?", userID, userID).Delete(&models.OutOfOffice{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Comment{}).Where("user_id = ?", userID).Count(&comments).Error
+	})
+	return comments, err
+}
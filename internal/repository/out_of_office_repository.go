@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// outOfOfficeRepository implements OutOfOfficeRepository
+type outOfOfficeRepository struct {
+	db *database.Database
+}
+
+// NewOutOfOfficeRepository creates a new out-of-office repository
+func NewOutOfOfficeRepository(db *database.Database) OutOfOfficeRepository {
+	return &outOfOfficeRepository{db: db}
+}
+
+// Upsert creates or replaces the out-of-office window of a user
+func (r *outOfOfficeRepository) Upsert(ctx context.Context, window *models.OutOfOffice) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"delegate_id", "starts_at", "ends_at", "note", "updated_at"}),
+		}).
+		Create(window).Error
+}
+
+// GetByUser retrieves the out-of-office window of a user
+func (r *outOfOfficeRepository) GetByUser(ctx context.Context, userID uuid.UUID) (*models.OutOfOffice, error) {
+	var window models.OutOfOffice
+	err := r.db.DB.WithContext(ctx).
+		Preload("Delegate").
+		Where("user_id = ?", userID).
+		First(&window).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// DeleteByUser removes the out-of-office window of a user
+func (r *outOfOfficeRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&models.OutOfOffice{}).Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// problemRepository implements ProblemRepository
+type problemRepository struct {
+	db *database.Database
+}
+
+// NewProblemRepository creates a new problem repository
+func NewProblemRepository(db *database.Database) ProblemRepository {
+	return &problemRepository{db: db}
+}
+
+// Create creates a new problem
+func (r *problemRepository) Create(ctx context.Context, problem *models.Problem) error {
+	return r.db.DB.WithContext(ctx).Create(problem).Error
+}
+
+// GetByID retrieves a problem by ID
+func (r *problemRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Problem, error) {
+	var problem models.Problem
+	err := r.db.DB.WithContext(ctx).
+		Preload("CreatedBy").
+		Where("id = ?", id).
+		First(&problem).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &problem, nil
+}
+
+// Update updates an existing problem
+func (r *problemRepository) Update(ctx context.Context, problem *models.Problem) error {
+	return r.db.DB.WithContext(ctx).Omit("CreatedBy").Save(problem).Error
+}
+
+// List retrieves problems, optionally filtered by status, newest first
+func (r *problemRepository) List(ctx context.Context, status models.ProblemStatus) ([]models.Problem, error) {
+	db := r.db.DB.WithContext(ctx)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var problems []models.Problem
+	err := db.Order("created_at DESC").Find(&problems).Error
+	return problems, err
+}
+
+// LinkIncident links a ticket to a problem, moving it from any other problem
+func (r *problemRepository) LinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Save(&models.ProblemIncident{TicketID: ticketID, ProblemID: problemID}).Error
+}
+
+// UnlinkIncident removes a ticket from a problem
+func (r *problemRepository) UnlinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Where("problem_id = ? AND ticket_id = ?", problemID, ticketID).
+		Delete(&models.ProblemIncident{}).Error
+}
+
+// GetIncidents retrieves the current version of every ticket linked to a problem, oldest first
+func (r *problemRepository) GetIncidents(ctx context.Context, problemID uuid.UUID) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := r.db.DB.WithContext(ctx).
+		Preload("AssignedAgent").
+		Preload("CreatedBy").
+		Joins("JOIN problem_incidents ON problem_incidents.ticket_id = tickets.id").
+		Where("problem_incidents.problem_id = ? AND tickets.expiration_time IS NULL", problemID).
+		Order("tickets.creation_time ASC").
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// TopByIncidentCount reports the problems with the most linked incidents
+func (r *problemRepository) TopByIncidentCount(ctx context.Context, limit int) ([]models.ProblemIncidentCount, error) {
+	var counts []models.ProblemIncidentCount
+	err := r.db.DB.WithContext(ctx).
+		Table("problems").
+		Select("problems.id AS problem_id, problems.title, problems.status, COUNT(problem_incidents.ticket_id) AS incident_count").
+		Joins("JOIN problem_incidents ON problem_incidents.problem_id = problems.id").
+		Group("problems.id, problems.title, problems.status").
+		Order("incident_count DESC, problems.created_at ASC").
+		Limit(limit).
+		Find(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// quarantineRepository implements QuarantineRepository
+type quarantineRepository struct {
+	db *database.Database
+}
+
+// NewQuarantineRepository creates a new quarantine repository
+func NewQuarantineRepository(db *database.Database) QuarantineRepository {
+	return &quarantineRepository{db: db}
+}
+
+// Create creates a new quarantined submission
+func (r *quarantineRepository) Create(ctx context.Context, submission *models.QuarantinedSubmission) error {
+	return r.db.DB.WithContext(ctx).Create(submission).Error
+}
+
+// GetByID retrieves a quarantined submission by ID
+func (r *quarantineRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.QuarantinedSubmission, error) {
+	var submission models.QuarantinedSubmission
+	err := r.db.DB.WithContext(ctx).
+		Where("id = ?", id).
+		First(&submission).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// Update updates an existing quarantined submission
+func (r *quarantineRepository) Update(ctx context.Context, submission *models.QuarantinedSubmission) error {
+	return r.db.DB.WithContext(ctx).Save(submission).Error
+}
+
+// List retrieves quarantined submissions, optionally filtered by status, newest first
+func (r *quarantineRepository) List(ctx context.Context, status models.QuarantineStatus, page, pageSize int) (*models.QuarantineListResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.QuarantinedSubmission{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var submissions []models.QuarantinedSubmission
+	err := db.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&submissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QuarantineListResponse{
+		Submissions: submissions,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
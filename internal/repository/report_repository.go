@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"gorm.io/gorm"
+)
+
+// reportRepository implements ReportRepository
+type reportRepository struct {
+	db *database.Database
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *database.Database) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// currentTickets starts a query over the current version of each ticket in the
+// scope carried by ctx
+func (r *reportRepository) currentTickets(ctx context.Context) *gorm.DB {
+	return r.db.Reader().WithContext(ctx).Model(&models.Ticket{}).Scopes(scopeTickets(ctx)).Where("expiration_time IS NULL")
+}
+
+// QueueSnapshot counts the queue in a single pass over the current tickets.
+// Tickets due before riskUntil are at risk; those due before at have breached.
+func (r *reportRepository) QueueSnapshot(ctx context.Context, at, riskUntil, dayStart time.Time) (*models.QueueSnapshot, error) {
+	unresolved := []models.TicketStatus{models.StatusOpen, models.StatusInProgress}
+	resolved := []models.TicketStatus{models.StatusResolved, models.StatusClosed}
+	dueDate := "datetime(" + sqliteTime("due_date") + ")"
+	resolvedAt := "datetime(" + sqliteTime("resolved_at") + ")"
+	now := at.UTC().Format(sqliteUTC)
+
+	var snapshot models.QueueSnapshot
+	err := r.currentTickets(ctx).Select(
+		"COUNT(CASE WHEN status IN ? THEN 1 END) AS queue_depth, "+
+			"COUNT(CASE WHEN status = ? AND assigned_agent_id IS NULL THEN 1 END) AS unassigned, "+
+			"COUNT(CASE WHEN status IN ? AND "+dueDate+" >= ? AND "+dueDate+" < ? THEN 1 END) AS sla_at_risk, "+
+			"COUNT(CASE WHEN status IN ? AND "+dueDate+" < ? THEN 1 END) AS sla_breached, "+
+			"COUNT(CASE WHEN status IN ? AND "+resolvedAt+" >= ? THEN 1 END) AS resolved_today",
+		unresolved,
+		models.StatusOpen,
+		unresolved, now, riskUntil.UTC().Format(sqliteUTC),
+		unresolved, now,
+		resolved, dayStart.UTC().Format(sqliteUTC),
+	).Scan(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	snapshot.At = at
+	return &snapshot, nil
+}
+
+// OpenByPriority counts open and in-progress tickets by priority
+func (r *reportRepository) OpenByPriority(ctx context.Context) ([]models.PriorityCount, error) {
+	var counts []models.PriorityCount
+	err := r.currentTickets(ctx).
+		Select("priority, COUNT(*) AS count").
+		Where("status IN ?", []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+		Group("priority").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountCreatedSince counts tickets first created since the given time
+func (r *reportRepository) CountCreatedSince(ctx context.Context, since time.Time) (int64, error) {
+	var count int64
+	err := r.currentTickets(ctx).Where(firstCreated+" >= ?", since.UTC().Format(sqliteUTC)).Count(&count).Error
+	return count, err
+}
+
+// TicketFlow counts the tickets created and resolved in each interval between
+// from and to in one grouped query. Timestamps are compared in UTC and shifted
+// by offset before bucketing so buckets follow the office day; tickets created
+// or resolved before from are totalled in the empty bucket. Tickets count as
+// created when they were first created, however often they were edited since.
+func (r *reportRepository) TicketFlow(ctx context.Context, interval models.TrendInterval, from, to time.Time, offset time.Duration) ([]models.TicketFlow, error) {
+	shift := fmt.Sprintf("'%+d seconds'", int64(offset.Seconds()))
+	bucket := func(utc string) string {
+		start := "date(" + utc + ", " + shift + ")"
+		if interval == models.TrendWeek {
+			start = "date(" + utc + ", " + shift + ", '-6 days', 'weekday 1')"
+		}
+		return "CASE WHEN " + utc + " < ? THEN '' ELSE " + start + " END"
+	}
+	resolvedAt := "datetime(" + sqliteTime("resolved_at") + ")"
+
+	created := r.currentTickets(ctx).
+		Select(bucket(firstCreated)+" AS bucket, 1 AS created, 0 AS resolved", from.UTC().Format(sqliteUTC)).
+		Where(firstCreated+" < ?", to.UTC().Format(sqliteUTC))
+	resolved := r.currentTickets(ctx).
+		Select(bucket(resolvedAt)+" AS bucket, 0 AS created, 1 AS resolved", from.UTC().Format(sqliteUTC)).
+		Where("status IN ? AND "+resolvedAt+" < ?", []models.TicketStatus{models.StatusResolved, models.StatusClosed}, to.UTC().Format(sqliteUTC))
+
+	var flow []models.TicketFlow
+	err := r.db.Reader().WithContext(ctx).
+		Table("(? UNION ALL ?) AS flow", created, resolved).
+		Select("bucket, SUM(created) AS created, SUM(resolved) AS resolved").
+		Group("bucket").
+		Order("bucket").
+		Scan(&flow).Error
+	return flow, err
+}
+
+// CountByCategory counts the tickets first created between from and to in
+// each category, with the total time the resolved ones took since
+func (r *reportRepository) CountByCategory(ctx context.Context, from, to time.Time) ([]models.CategoryTicketCount, error) {
+	created := firstCreated
+	resolved := "datetime(" + sqliteTime("resolved_at") + ")"
+	isResolved := "status IN ? AND resolved_at IS NOT NULL"
+
+	var counts []models.CategoryTicketCount
+	err := r.currentTickets(ctx).
+		Select(
+			"category_id, COUNT(*) AS created, "+
+				"COUNT(CASE WHEN "+isResolved+" THEN 1 END) AS resolved, "+
+				"COALESCE(SUM(CASE WHEN "+isResolved+" THEN (julianday("+resolved+") - julianday("+created+")) * 86400 END), 0) AS resolution_seconds",
+			[]models.TicketStatus{models.StatusResolved, models.StatusClosed},
+			[]models.TicketStatus{models.StatusResolved, models.StatusClosed},
+		).
+		Where(created+" >= ? AND "+created+" < ?", from.UTC().Format(sqliteUTC), to.UTC().Format(sqliteUTC)).
+		Group("category_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountByRootCause counts the tickets resolved between from and to with a root
+// cause code, by month, category and code. Resolution times are shifted by
+// offset before taking the month so months follow the office calendar.
+func (r *reportRepository) CountByRootCause(ctx context.Context, from, to time.Time, offset time.Duration) ([]models.RootCauseTicketCount, error) {
+	resolved := "datetime(" + sqliteTime("resolved_at") + ")"
+	month := "strftime('%Y-%m', " + sqliteTime("resolved_at") + fmt.Sprintf(", '%+d seconds')", int64(offset.Seconds()))
+
+	var counts []models.RootCauseTicketCount
+	err := r.currentTickets(ctx).
+		Select(month+" AS month, category_id, root_cause_code AS code, COUNT(*) AS tickets").
+		Where("status IN ? AND root_cause_code <> '' AND resolved_at IS NOT NULL", []models.TicketStatus{models.StatusResolved, models.StatusClosed}).
+		Where(resolved+" >= ? AND "+resolved+" < ?", from.UTC().Format(sqliteUTC), to.UTC().Format(sqliteUTC)).
+		Group("month, category_id, root_cause_code").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// OpenByAge counts the open and in-progress tickets of each team and priority
+// by how many days before at they were first created
+func (r *reportRepository) OpenByAge(ctx context.Context, at time.Time) ([]models.AgingTicketCount, error) {
+	age := "(julianday(@at) - julianday(" + firstCreated + "))"
+
+	var counts []models.AgingTicketCount
+	err := r.currentTickets(ctx).
+		Select(
+			"priority, team_id, (SELECT name FROM teams WHERE teams.id = tickets.team_id) AS team_name, "+
+				"COUNT(CASE WHEN "+age+" < 1 THEN 1 END) AS under_one_day, "+
+				"COUNT(CASE WHEN "+age+" >= 1 AND "+age+" < 3 THEN 1 END) AS one_to_three_days, "+
+				"COUNT(CASE WHEN "+age+" >= 3 AND "+age+" < 7 THEN 1 END) AS three_to_seven_days, "+
+				"COUNT(CASE WHEN "+age+" >= 7 THEN 1 END) AS over_seven_days, "+
+				"COUNT(*) AS total",
+			sql.Named("at", at.UTC().Format(sqliteUTC)),
+		).
+		Where("status IN ?", []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+		Group("priority, team_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// sqliteUTC is the layout datetime() returns, for comparing against it
+const sqliteUTC = "2006-01-02 15:04:05"
+
+// firstCreated is when the ticket of the current row was first created, in
+// the form datetime() returns. Each update writes a new version with its own
+// creation_time, so the current version's only says when it was last changed.
+var firstCreated = "(SELECT MIN(datetime(" + sqliteTime("first.creation_time") + ")) FROM tickets AS first WHERE first.id = tickets.id)"
+
+// sqliteTime rewrites a timestamp column into a form SQLite's date functions
+// read. The driver stores times as time.Time.String(), such as
+// "2006-01-02 15:04:05.999 -0700 MST", which they do not; this keeps the
+// seconds and the zone offset as "2006-01-02 15:04:05-07:00", which
+// datetime() turns into UTC.
+func sqliteTime(column string) string {
+	offset := "instr(substr(" + column + ", 20), ' ')"
+	return "(substr(" + column + ", 1, 19) || substr(" + column + ", 20 + " + offset + ", 3) || ':' || substr(" + column + ", 23 + " + offset + ", 2))"
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// retentionRepository implements RetentionRepository
+type retentionRepository struct {
+	db *database.Database
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository(db *database.Database) RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// closedTickets selects the ids of current tickets closed before a time
+func (r *retentionRepository) closedTickets(ctx context.Context, closedBefore time.Time) *gorm.DB {
+	return r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Select("id").
+		Where("expiration_time IS NULL AND status = ?", models.StatusClosed).
+		Where("datetime("+sqliteTime("resolved_at")+") < ?", closedBefore.UTC().Format(sqliteUTC))
+}
+
+// CountClosedAttachments counts the attachments of tickets closed before a
+// time, the tickets they belong to and their total size
+func (r *retentionRepository) CountClosedAttachments(ctx context.Context, closedBefore time.Time) (count, tickets, bytes int64, err error) {
+	var totals struct {
+		Count   int64
+		Tickets int64
+		Bytes   int64
+	}
+	err = r.db.DB.WithContext(ctx).Model(&models.Attachment{}).
+		Select("COUNT(*) AS count, COUNT(DISTINCT ticket_id) AS tickets, COALESCE(SUM(file_size), 0) AS bytes").
+		Where("ticket_id IN (?)", r.closedTickets(ctx, closedBefore)).
+		Scan(&totals).Error
+	return totals.Count, totals.Tickets, totals.Bytes, err
+}
+
+// ListClosedAttachments retrieves up to limit attachments of tickets closed
+// before a time, oldest first
+func (r *retentionRepository) ListClosedAttachments(ctx context.Context, closedBefore time.Time, limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.DB.WithContext(ctx).
+		Where("ticket_id IN (?)", r.closedTickets(ctx, closedBefore)).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// requestersToAnonymize selects end users not yet anonymized who requested
+// tickets and whose every current ticket was closed before a time
+func (r *retentionRepository) requestersToAnonymize(ctx context.Context, closedBefore time.Time) *gorm.DB {
+	closed := r.closedTickets(ctx, closedBefore)
+	return r.db.DB.WithContext(ctx).Model(&models.User{}).
+		Where("role = ? AND anonymized_at IS NULL", models.RoleEndUser).
+		Where("id IN (SELECT created_by_id FROM tickets WHERE expiration_time IS NULL)").
+		Where("id NOT IN (SELECT created_by_id FROM tickets WHERE expiration_time IS NULL AND id NOT IN (?))", closed)
+}
+
+// CountRequestersToAnonymize counts the requesters whose every ticket was
+// closed before a time, and their tickets
+func (r *retentionRepository) CountRequestersToAnonymize(ctx context.Context, closedBefore time.Time) (count, tickets int64, err error) {
+	if err := r.requestersToAnonymize(ctx, closedBefore).Count(&count).Error; err != nil {
+		return 0, 0, err
+	}
+	err = r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Where("expiration_time IS NULL AND created_by_id IN (?)", r.requestersToAnonymize(ctx, closedBefore).Select("id")).
+		Count(&tickets).Error
+	return count, tickets, err
+}
+
+// ListRequestersToAnonymize retrieves the ids of up to limit requesters whose
+// every ticket was closed before a time
+func (r *retentionRepository) ListRequestersToAnonymize(ctx context.Context, closedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.requestersToAnonymize(ctx, closedBefore).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AnonymizeRequester replaces a user's name and email address with
+// placeholders, deactivates them and removes their sign-in history and
+// pending account links, along with the outside addresses copied on their
+// tickets
+func (r *retentionRepository) AnonymizeRequester(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND anonymized_at IS NULL", userID).
+			Updates(map[string]any{
+				"email":             "anonymized-" + userID.String() + "@invalid",
+				"first_name":        "Anonymized",
+				"last_name":         "User",
+				"password_hash":     "",
+				"is_active":         false,
+				"last_login_at":     nil,
+				"language":          "",
+				"timezone":          "",
+				"quiet_hours_start": "",
+				"quiet_hours_end":   "",
+				"anonymized_at":     at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, model := range []any{&models.LoginEvent{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{}} {
+			if err := tx.Where("user_id = ?", userID.String()).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("ticket_id IN (?)", tx.Model(&models.Ticket{}).Select("id").Where("created_by_id = ?", userID)).
+			Delete(&models.TicketSubscriber{}).Error
+	})
+}
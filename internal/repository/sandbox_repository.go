@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sandboxRepository implements SandboxRepository
+type sandboxRepository struct {
+	db *database.Database
+}
+
+// NewSandboxRepository creates a new sandbox repository
+func NewSandboxRepository(db *database.Database) SandboxRepository {
+	return &sandboxRepository{db: db}
+}
+
+// Create creates a training sandbox with its demo requesters, tickets and
+// comments, and puts its agent in it
+func (r *sandboxRepository) Create(ctx context.Context, sandbox *models.Sandbox, requesters []models.User, tickets []models.Ticket, comments []models.Comment) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(sandbox).Error; err != nil {
+			return err
+		}
+		for _, rows := range []any{&requesters, &tickets, &comments} {
+			if err := tx.Create(rows).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.User{}).Where("id = ?", sandbox.AgentID).Update("sandbox_id", sandbox.ID).Error
+	})
+}
+
+// GetByAgent retrieves the training sandbox an agent is in
+func (r *sandboxRepository) GetByAgent(ctx context.Context, agentID uuid.UUID) (*models.Sandbox, error) {
+	var sandbox models.Sandbox
+	if err := r.db.DB.WithContext(ctx).First(&sandbox, "agent_id = ?", agentID).Error; err != nil {
+		return nil, err
+	}
+	return &sandbox, nil
+}
+
+// ListExpired retrieves the training sandboxes that expired before a time,
+// oldest first
+func (r *sandboxRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Sandbox, error) {
+	var sandboxes []models.Sandbox
+	err := r.db.DB.WithContext(ctx).
+		Where("datetime("+sqliteTime("expires_at")+") < ?", before.UTC().Format(sqliteUTC)).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sandboxes).Error
+	return sandboxes, err
+}
+
+// Delete deletes a training sandbox with its demo tickets, including
+// everything added to them, and requesters, and takes its agent out of it.
+// It returns the attachments of the deleted tickets, whose files are left to
+// the caller, or gorm.ErrRecordNotFound when the sandbox is already gone.
+func (r *sandboxRepository) Delete(ctx context.Context, sandbox *models.Sandbox) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Sandbox{}, "id = ?", sandbox.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var ticketIDs []uuid.UUID
+		if err := tx.Model(&models.Ticket{}).Distinct("id").Where("sandbox_id = ?", sandbox.ID).Pluck("id", &ticketIDs).Error; err != nil {
+			return err
+		}
+		if len(ticketIDs) > 0 {
+			if err := tx.Where("ticket_id IN ?", ticketIDs).Find(&attachments).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range ticketIDs {
+			if err := deleteTicketRows(tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("demo = ? AND sandbox_id = ?", true, sandbox.ID).Delete(&models.User{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ? AND sandbox_id = ?", sandbox.AgentID, sandbox.ID).Update("sandbox_id", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
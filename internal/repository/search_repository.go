@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// likeEscaper escapes the LIKE wildcards in user input, for use with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchRepository implements SearchRepository
+type searchRepository struct {
+	db *database.Database
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *database.Database) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// SuggestTickets returns current tickets in the scope carried by ctx with a
+// word of their title starting with prefix, titles starting with it first
+func (r *searchRepository) SuggestTickets(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error) {
+	starts, words := likePrefix(prefix), "% "+likePrefix(prefix)
+	var suggestions []models.SearchSuggestion
+	err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Scopes(scopeTickets(ctx)).
+		Select(`id, title AS label, status AS detail, CASE WHEN title LIKE ? ESCAPE '\' THEN 0 ELSE 1 END AS match_rank`, starts).
+		Where("expiration_time IS NULL").
+		Where(`(title LIKE ? ESCAPE '\' OR title LIKE ? ESCAPE '\')`, starts, words).
+		Order("match_rank, creation_time DESC").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestRequesters returns active end users whose name or email starts with prefix
+func (r *searchRepository) SuggestRequesters(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error) {
+	starts := likePrefix(prefix)
+	var suggestions []models.SearchSuggestion
+	err := r.db.DB.WithContext(ctx).Model(&models.User{}).
+		Scopes(scopeUsers(ctx)).
+		Select("id, first_name || ' ' || last_name AS label, email AS detail").
+		Where("role = ? AND is_active = ?", models.RoleEndUser, true).
+		Where(`(first_name LIKE ? ESCAPE '\' OR last_name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\' OR first_name || ' ' || last_name LIKE ? ESCAPE '\')`, starts, starts, starts, starts).
+		Order("first_name, last_name").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestCategories returns active categories whose name starts with prefix
+func (r *searchRepository) SuggestCategories(ctx context.Context, prefix string, limit int) ([]models.SearchSuggestion, error) {
+	var suggestions []models.SearchSuggestion
+	err := r.db.DB.WithContext(ctx).Model(&models.Category{}).
+		Select("id, name AS label, description AS detail").
+		Where("is_active = ?", true).
+		Where(`name LIKE ? ESCAPE '\'`, likePrefix(prefix)).
+		Order("name").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// SuggestArticles returns knowledge articles with a word of their title
+// starting with prefix, drafts only when includeDrafts is set
+func (r *searchRepository) SuggestArticles(ctx context.Context, prefix string, limit int, includeDrafts bool) ([]models.SearchSuggestion, error) {
+	starts, words := likePrefix(prefix), "% "+likePrefix(prefix)
+	query := r.db.DB.WithContext(ctx).Model(&models.KnowledgeArticle{}).
+		Select(`id, title AS label, CASE WHEN title LIKE ? ESCAPE '\' THEN 0 ELSE 1 END AS match_rank`, starts).
+		Where(`(title LIKE ? ESCAPE '\' OR title LIKE ? ESCAPE '\')`, starts, words)
+	if !includeDrafts {
+		query = query.Where("is_published = ?", true)
+	}
+
+	var suggestions []models.SearchSuggestion
+	err := query.
+		Order("match_rank, title").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// LookupTickets returns current tickets in the scope carried by ctx whose id
+// starts with idPrefix, newest first
+func (r *searchRepository) LookupTickets(ctx context.Context, idPrefix string, limit int) ([]models.SearchSuggestion, error) {
+	var suggestions []models.SearchSuggestion
+	err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Scopes(scopeTickets(ctx)).
+		Select("id, title AS label, status AS detail").
+		Where("expiration_time IS NULL").
+		Where(`id LIKE ? ESCAPE '\'`, likePrefix(strings.ToLower(idPrefix))).
+		Order("creation_time DESC").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// LookupUser returns the user with an email address, ignoring case. Staff
+// accounts are only returned when includeStaff is set. It returns nil when
+// there is no such user.
+func (r *searchRepository) LookupUser(ctx context.Context, email string, includeStaff bool) (*models.SearchSuggestion, error) {
+	query := r.db.DB.WithContext(ctx).Model(&models.User{}).
+		Scopes(scopeUsers(ctx)).
+		Select("id, first_name || ' ' || last_name AS label, email AS detail").
+		Where("LOWER(email) = LOWER(?)", email)
+	if !includeStaff {
+		query = query.Where("role = ?", models.RoleEndUser)
+	}
+
+	var suggestions []models.SearchSuggestion
+	if err := query.Limit(1).Scan(&suggestions).Error; err != nil {
+		return nil, err
+	}
+	if len(suggestions) == 0 {
+		return nil, nil
+	}
+	return &suggestions[0], nil
+}
+
+// LookupRequesterTickets returns the latest current tickets requested by a
+// user, limited to the scope carried by ctx
+func (r *searchRepository) LookupRequesterTickets(ctx context.Context, requesterID uuid.UUID, limit int) ([]models.SearchSuggestion, error) {
+	var suggestions []models.SearchSuggestion
+	err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Scopes(scopeTickets(ctx)).
+		Select("id, title AS label, status AS detail").
+		Where("expiration_time IS NULL AND created_by_id = ?", requesterID).
+		Order("creation_time DESC").
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// likePrefix returns a LIKE pattern matching text that starts with prefix
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
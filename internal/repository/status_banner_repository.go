@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+)
+
+// statusBannerRepository implements StatusBannerRepository
+type statusBannerRepository struct {
+	db *database.Database
+}
+
+// NewStatusBannerRepository creates a new status banner repository
+func NewStatusBannerRepository(db *database.Database) StatusBannerRepository {
+	return &statusBannerRepository{db: db}
+}
+
+// Create creates a new status banner linked to its affected categories; the
+// categories themselves must already exist and are not written
+func (r *statusBannerRepository) Create(ctx context.Context, banner *models.StatusBanner) error {
+	return r.db.DB.WithContext(ctx).Omit("Categories.*").Create(banner).Error
+}
+
+// GetByID retrieves a status banner and its categories by ID
+func (r *statusBannerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.StatusBanner, error) {
+	var banner models.StatusBanner
+	err := r.db.DB.WithContext(ctx).
+		Preload("Categories").
+		Where("id = ?", id).
+		First(&banner).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &banner, nil
+}
+
+// Update updates an existing status banner, leaving its categories unchanged
+func (r *statusBannerRepository) Update(ctx context.Context, banner *models.StatusBanner) error {
+	return r.db.DB.WithContext(ctx).Omit("Categories").Save(banner).Error
+}
+
+// Delete deletes a status banner, its category links and its subscriptions
+func (r *statusBannerRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	banner := &models.StatusBanner{ID: id}
+	if err := r.db.DB.WithContext(ctx).Model(banner).Association("Categories").Clear(); err != nil {
+		return err
+	}
+	if err := r.db.DB.WithContext(ctx).Where("banner_id = ?", id).Delete(&models.BannerSubscription{}).Error; err != nil {
+		return err
+	}
+	return r.db.DB.WithContext(ctx).Delete(banner).Error
+}
+
+// List retrieves every status banner, newest first
+func (r *statusBannerRepository) List(ctx context.Context) ([]models.StatusBanner, error) {
+	var banners []models.StatusBanner
+	err := r.db.DB.WithContext(ctx).
+		Preload("Categories").
+		Order("starts_at DESC").
+		Find(&banners).Error
+	return banners, err
+}
+
+// ListActive retrieves the banners shown at the given time, most recent first
+func (r *statusBannerRepository) ListActive(ctx context.Context, at time.Time) ([]models.StatusBanner, error) {
+	var banners []models.StatusBanner
+	err := r.db.DB.WithContext(ctx).
+		Preload("Categories").
+		Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", at, at).
+		Order("starts_at DESC").
+		Find(&banners).Error
+	return banners, err
+}
+
+// AddSubscription records an address to notify about a banner
+func (r *statusBannerRepository) AddSubscription(ctx context.Context, subscription *models.BannerSubscription) error {
+	return r.db.DB.WithContext(ctx).Create(subscription).Error
+}
+
+// ListSubscriptions retrieves the subscriptions of a banner
+func (r *statusBannerRepository) ListSubscriptions(ctx context.Context, bannerID uuid.UUID) ([]models.BannerSubscription, error) {
+	var subscriptions []models.BannerSubscription
+	err := r.db.DB.WithContext(ctx).
+		Where("banner_id = ?", bannerID).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// CountSubscriptions counts the subscriptions of each of the given banners
+func (r *statusBannerRepository) CountSubscriptions(ctx context.Context, bannerIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		BannerID uuid.UUID
+		Count    int64
+	}
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.BannerSubscription{}).
+		Select("banner_id, COUNT(*) AS count").
+		Where("banner_id IN ?", bannerIDs).
+		Group("banner_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.BannerID] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// teamRepository implements TeamRepository
+type teamRepository struct {
+	db *database.Database
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *database.Database) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+// Create creates a new team
+func (r *teamRepository) Create(ctx context.Context, team *models.Team) error {
+	return r.db.DB.WithContext(ctx).Create(team).Error
+}
+
+// GetByID retrieves a team and its members by ID
+func (r *teamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error) {
+	var team models.Team
+	err := r.db.DB.WithContext(ctx).
+		Preload("Members").
+		Where("id = ?", id).
+		First(&team).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// List retrieves all teams ordered by name
+func (r *teamRepository) List(ctx context.Context) ([]models.Team, error) {
+	var teams []models.Team
+	err := r.db.DB.WithContext(ctx).
+		Order("name ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+// Delete deletes a team and its memberships
+func (r *teamRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.DB.WithContext(ctx).Where("team_id = ?", id).Delete(&models.TeamMember{}).Error; err != nil {
+		return err
+	}
+	return r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.Team{}).Error
+}
+
+// AddMember adds a user to a team; adding an existing member is a no-op
+func (r *teamRepository) AddMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.TeamMember{TeamID: teamID, UserID: userID}).Error
+}
+
+// RemoveMember removes a user from a team
+func (r *teamRepository) RemoveMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&models.TeamMember{}).Error
+}
+
+// GetTeamIDsForUser retrieves the IDs of the teams a user belongs to
+func (r *teamRepository) GetTeamIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var teamIDs []uuid.UUID
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.TeamMember{}).
+		Where("user_id = ?", userID).
+		Pluck("team_id", &teamIDs).Error
+	return teamIDs, err
+}
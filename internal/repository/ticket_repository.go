@@ -10,6 +10,7 @@ import (
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ticketRepository implements TicketRepository
@@ -31,7 +32,20 @@ func (r *ticketRepository) Create(ctx context.Context, ticket *models.Ticket) er
 	return r.timeSeriesRepo.Create(ctx, ticket)
 }
 
-// GetByID retrieves the current version of a ticket by ID
+// CreateWithAttachments creates a new ticket and moves draft attachments to it
+// in one transaction, so the ticket is not created if a draft was taken by
+// another request meanwhile (gorm.ErrRecordNotFound)
+func (r *ticketRepository) CreateWithAttachments(ctx context.Context, ticket *models.Ticket, drafts []models.DraftAttachment) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(ticket).Error; err != nil {
+			return err
+		}
+		return attachDrafts(tx, ticket.ID, drafts)
+	})
+}
+
+// GetByID retrieves the current version of a ticket by ID. Demo tickets are
+// only found in their training sandbox, and other tickets only outside one.
 func (r *ticketRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ticket, error) {
 	ticketVal, err := r.timeSeriesRepo.GetCurrentByID(ctx, id)
 	if err != nil {
@@ -39,18 +53,27 @@ func (r *ticketRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.T
 	}
 
 	ticket := ticketVal
+	sandboxID, inSandbox := SandboxFromContext(ctx)
+	if inSandbox != (ticket.SandboxID != nil) || inSandbox && *ticket.SandboxID != sandboxID {
+		return nil, gorm.ErrRecordNotFound
+	}
 
 	// Load relationships
 	err = r.db.DB.WithContext(ctx).
 		Preload("Category").
 		Preload("AssignedAgent").
 		Preload("CreatedBy").
+		Preload("OpenedByUser").
 		Preload("EscalatedToUser").
 		Preload("Comments", func(db *gorm.DB) *gorm.DB {
-			return db.Order("creation_time ASC")
+			return db.Order("created_at ASC")
 		}).
 		Preload("Comments.User").
 		Preload("Attachments").
+		Preload("EscalationEvents", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("EscalationEvents.Actor").
 		First(ticket).Error
 
 	if err != nil {
@@ -69,67 +92,216 @@ func (r *ticketRepository) Update(ctx context.Context, ticket *models.Ticket) er
 		clone.Priority = ticket.Priority
 		clone.CategoryID = ticket.CategoryID
 		clone.AssignedAgentID = ticket.AssignedAgentID
+		clone.TeamID = ticket.TeamID
+		clone.OpenedBy = ticket.OpenedBy
 		clone.EscalatedAt = ticket.EscalatedAt
 		clone.EscalatedTo = ticket.EscalatedTo
+		clone.EscalationReason = ticket.EscalationReason
 		clone.ResolvedAt = ticket.ResolvedAt
 		clone.DueDate = ticket.DueDate
+		clone.ResolutionCode = ticket.ResolutionCode
+		clone.RootCause = ticket.RootCause
+		clone.RootCauseCode = ticket.RootCauseCode
+		clone.KnowledgeArticleID = ticket.KnowledgeArticleID
 		return nil
 	})
 	return err
 }
 
+// GetHistory retrieves every version of a ticket, oldest first, including
+// the current one
+func (r *ticketRepository) GetHistory(ctx context.Context, id uuid.UUID) ([]*models.Ticket, error) {
+	return r.timeSeriesRepo.GetHistory(ctx, id)
+}
+
+// RestoreVersion makes an earlier version of a ticket current again as a new
+// version. It returns gorm.ErrRecordNotFound when the ticket has no current
+// version or no such earlier version.
+func (r *ticketRepository) RestoreVersion(ctx context.Context, id, versionID uuid.UUID) (*models.Ticket, error) {
+	return r.timeSeriesRepo.RestoreVersion(ctx, id, versionID)
+}
+
 // Delete archives the current version of a ticket (marks it as expired)
 func (r *ticketRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.timeSeriesRepo.Archive(ctx, id)
 }
 
-// List retrieves tickets with filtering, sorting, and pagination
+// Trash moves the current version of a ticket to the trash, where it is
+// expired like an archived version but can be restored. It returns
+// gorm.ErrRecordNotFound when there is no such current ticket.
+func (r *ticketRepository) Trash(ctx context.Context, id, deletedByID uuid.UUID) error {
+	now := time.Now()
+	result := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Where("id = ? AND expiration_time IS NULL", id).
+		Updates(map[string]any{"expiration_time": now, "deleted_at": now, "deleted_by_id": deletedByID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListTrashed retrieves the tickets in the trash, most recently deleted
+// first, and how many there are. Demo tickets are left to their sandbox.
+func (r *ticketRepository) ListTrashed(ctx context.Context, offset, limit int) ([]models.Ticket, int64, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).Where("deleted_at IS NOT NULL AND sandbox_id IS NULL")
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tickets []models.Ticket
+	err := db.Preload("Category").
+		Preload("CreatedBy").
+		Order("deleted_at DESC").
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&tickets).Error
+	return tickets, total, err
+}
+
+// Restore takes a ticket out of the trash, making it current again. It
+// returns gorm.ErrRecordNotFound when the ticket is not in the trash.
+func (r *ticketRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	result := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]any{"expiration_time": nil, "deleted_at": nil, "deleted_by_id": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListTrashedBefore retrieves up to limit tickets moved to the trash before a
+// time, with their attachments, oldest first
+func (r *ticketRepository) ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := r.db.DB.WithContext(ctx).
+		Preload("Attachments").
+		Where("deleted_at IS NOT NULL AND datetime("+sqliteTime("deleted_at")+") < ?", before.UTC().Format(sqliteUTC)).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&tickets).Error
+	return tickets, err
+}
+
+// Purge permanently deletes a ticket in the trash with its comments,
+// attachment records, history and links. Chats and quarantined submissions
+// turned into the ticket are kept without it. Files are left to the caller.
+func (r *ticketRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		var trashed int64
+		if err := tx.Model(&models.Ticket{}).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&trashed).Error; err != nil {
+			return err
+		}
+		if trashed == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return deleteTicketRows(tx, id)
+	})
+}
+
+// deleteTicketRows deletes every version of a ticket with its comments,
+// attachment records, history and links, and unlinks the chats and
+// quarantined submissions turned into it
+func deleteTicketRows(tx *gorm.DB, id uuid.UUID) error {
+	if err := tx.Where("id = ?", id).Delete(&models.Ticket{}).Error; err != nil {
+		return err
+	}
+
+	for _, model := range []any{
+		&models.Attachment{},
+		&models.Comment{},
+		&models.TicketAssignment{},
+		&models.TicketEscalationEvent{},
+		&models.TicketView{},
+		&models.TicketPin{},
+		&models.TicketSubscriber{},
+		&models.TicketAsset{},
+		&models.ProblemIncident{},
+		&models.Notification{},
+		&models.InboundTicketLink{},
+		&models.InboundAlert{},
+	} {
+		if err := tx.Where("ticket_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	for _, model := range []any{&models.ChatSession{}, &models.QuarantinedSubmission{}} {
+		if err := tx.Model(model).Where("ticket_id = ?", id).Update("ticket_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List retrieves tickets with filtering, sorting, and pagination, limited to the ticket scope of ctx
 func (r *ticketRepository) List(ctx context.Context, query *models.TicketQuery) (*models.TicketListResponse, error) {
 	db := r.db.DB.WithContext(ctx).
 		Preload("Category").
 		Preload("AssignedAgent").
-		Preload("CreatedBy")
+		Preload("CreatedBy").
+		Scopes(scopeTickets(ctx)).
+		Where("expiration_time IS NULL")
 
 	// Apply filters
 	db = r.applyFilters(db, query.Filter)
 
+	response := &models.TicketListResponse{
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}
+
 	// Get total count
-	var total int64
-	if err := db.Model(&models.Ticket{}).Count(&total).Error; err != nil {
-		return nil, err
+	if !query.SkipCount {
+		var total int64
+		if err := db.Model(&models.Ticket{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		totalPages := int((total + int64(query.PageSize) - 1) / int64(query.PageSize))
+		response.Total = &total
+		response.TotalPages = &totalPages
 	}
 
-	// Apply sorting
+	// Apply sorting, then newest first and ID so pages are stable when values tie
 	if query.Sort != nil {
-		orderClause := fmt.Sprintf("%s %s", query.Sort.Field, strings.ToUpper(query.Sort.Direction))
+		orderClause, err := ticketOrderClause(query.Sort)
+		if err != nil {
+			return nil, err
+		}
 		db = db.Order(orderClause)
-	} else {
+	}
+	if query.Sort == nil || query.Sort.Field != "creation_time" {
 		db = db.Order("creation_time DESC")
 	}
+	db = db.Order("id ASC")
 
-	// Apply pagination
+	// Apply pagination, reading one extra row to tell whether another page follows
 	offset := (query.Page - 1) * query.PageSize
-	db = db.Offset(offset).Limit(query.PageSize)
+	db = db.Offset(offset).Limit(query.PageSize + 1)
 
 	// Execute query
 	var tickets []models.Ticket
 	if err := db.Find(&tickets).Error; err != nil {
 		return nil, err
 	}
+	if len(tickets) > query.PageSize {
+		tickets = tickets[:query.PageSize]
+		response.HasMore = true
+	}
+	response.Tickets = tickets
 
-	// Calculate total pages
-	totalPages := int((total + int64(query.PageSize) - 1) / int64(query.PageSize))
-
-	return &models.TicketListResponse{
-		Tickets:    tickets,
-		Total:      total,
-		Page:       query.Page,
-		PageSize:   query.PageSize,
-		TotalPages: totalPages,
-	}, nil
+	return response, nil
 }
 
-// GetStats retrieves ticket statistics
+// GetStats retrieves ticket statistics, limited to the ticket scope of ctx
 func (r *ticketRepository) GetStats(ctx context.Context) (*models.TicketStats, error) {
 	var stats models.TicketStats
 
@@ -143,7 +315,7 @@ func (r *ticketRepository) GetStats(ctx context.Context) (*models.TicketStats, e
 
 	for _, status := range statuses {
 		var count int64
-		if err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).Where("status = ?", status).Count(&count).Error; err != nil {
+		if err := r.db.Reader().WithContext(ctx).Model(&models.Ticket{}).Scopes(scopeTickets(ctx), currentVersion).Where("status = ?", status).Count(&count).Error; err != nil {
 			return nil, err
 		}
 
@@ -160,33 +332,156 @@ func (r *ticketRepository) GetStats(ctx context.Context) (*models.TicketStats, e
 	}
 
 	// Get total tickets
-	if err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).Count(&stats.TotalTickets).Error; err != nil {
+	if err := r.db.Reader().WithContext(ctx).Model(&models.Ticket{}).Scopes(scopeTickets(ctx), currentVersion).Count(&stats.TotalTickets).Error; err != nil {
 		return nil, err
 	}
 
 	// Get escalated tickets
-	if err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).Where("escalated_at IS NOT NULL").Count(&stats.EscalatedTickets).Error; err != nil {
+	if err := r.db.Reader().WithContext(ctx).Model(&models.Ticket{}).Scopes(scopeTickets(ctx), currentVersion).Where("escalated_at IS NOT NULL").Count(&stats.EscalatedTickets).Error; err != nil {
 		return nil, err
 	}
 
 	// Get overdue tickets
-	if err := r.db.DB.WithContext(ctx).Model(&models.Ticket{}).Where("due_date < ?", time.Now()).Count(&stats.OverdueTickets).Error; err != nil {
+	if err := r.db.Reader().WithContext(ctx).Model(&models.Ticket{}).Scopes(scopeTickets(ctx), currentVersion).Where("due_date < ?", time.Now()).Count(&stats.OverdueTickets).Error; err != nil {
 		return nil, err
 	}
 
 	return &stats, nil
 }
 
-// AssignToAgent assigns a ticket to an agent
-func (r *ticketRepository) AssignToAgent(ctx context.Context, ticketID, agentID uuid.UUID) error {
+// AssignToAgent assigns a ticket to the assignment's agent, or returns it to the
+// pool when the assignment has none, recording it in the ticket's assignment
+// history with the agent it was taken from
+func (r *ticketRepository) AssignToAgent(ctx context.Context, assignment *models.TicketAssignment) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		var ticket models.Ticket
+		if err := tx.Select("assigned_agent_id").Where("id = ? AND expiration_time IS NULL", assignment.TicketID).First(&ticket).Error; err != nil {
+			return err
+		}
+		assignment.PreviousAgentID = ticket.AssignedAgentID
+
+		if err := tx.Model(&models.Ticket{}).
+			Where("id = ? AND expiration_time IS NULL", assignment.TicketID).
+			Update("assigned_agent_id", assignment.AgentID).Error; err != nil {
+			return err
+		}
+		return tx.Create(assignment).Error
+	})
+}
+
+// TakeIfUnassigned assigns a ticket to the assignment's agent only if no agent
+// is assigned to it, reporting whether it was. The check and the assignment
+// are one update, so two agents taking the same ticket cannot both succeed.
+func (r *ticketRepository) TakeIfUnassigned(ctx context.Context, assignment *models.TicketAssignment) (bool, error) {
+	taken := false
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&models.Ticket{}).
+			Where("id = ? AND assigned_agent_id IS NULL AND expiration_time IS NULL", assignment.TicketID).
+			Update("assigned_agent_id", assignment.AgentID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		taken = true
+		assignment.PreviousAgentID = nil
+		return tx.Create(assignment).Error
+	})
+	return taken && err == nil, err
+}
+
+// ChangeRequester transfers a ticket to a new requester, keeping the original
+// creator in opened_by
+func (r *ticketRepository) ChangeRequester(ctx context.Context, ticketID, requesterID uuid.UUID) error {
 	return r.db.DB.WithContext(ctx).
 		Model(&models.Ticket{}).
-		Where("id = ?", ticketID).
-		Update("assigned_agent_id", agentID).Error
+		Where("id = ? AND expiration_time IS NULL", ticketID).
+		Updates(map[string]interface{}{
+			"opened_by":     gorm.Expr("COALESCE(opened_by, created_by_id)"),
+			"created_by_id": requesterID,
+		}).Error
+}
+
+// CountOpenByRequester counts the open and in-progress tickets a user requested
+func (r *ticketRepository) CountOpenByRequester(ctx context.Context, requesterID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.DB.WithContext(ctx).
+		Model(&models.Ticket{}).
+		Where("created_by_id = ? AND status IN ? AND expiration_time IS NULL", requesterID, []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+		Count(&count).Error
+	return count, err
 }
 
-// UpdateStatus updates the status of a ticket
-func (r *ticketRepository) UpdateStatus(ctx context.Context, ticketID uuid.UUID, status models.TicketStatus) error {
+// FindSimilar ranks the open and in-progress tickets matching an FTS5 query
+// against the ticket search index, titles weighing twice as much as
+// descriptions. Only the requester's tickets are searched unless requesterID
+// is nil, which searches every ticket in the ticket scope of ctx.
+func (r *ticketRepository) FindSimilar(ctx context.Context, match string, requesterID *uuid.UUID, limit int) ([]models.SimilarTicket, error) {
+	db := r.db.DB.WithContext(ctx).
+		Table("tickets_fts").
+		Select("tickets.id, tickets.title, tickets.status, tickets.priority, tickets.created_by_id, -bm25(tickets_fts, 2.0, 1.0) AS score").
+		Joins("JOIN tickets ON tickets.rowid = tickets_fts.rowid").
+		Where("tickets_fts MATCH ?", match).
+		Where("tickets.expiration_time IS NULL AND tickets.status IN ?", []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+		Scopes(scopeTickets(ctx))
+	if requesterID != nil {
+		db = db.Where("tickets.created_by_id = ?", *requesterID)
+	}
+
+	var tickets []models.SimilarTicket
+	err := db.Order("score DESC").Limit(limit).Scan(&tickets).Error
+	return tickets, err
+}
+
+// ReassignOpenTickets moves the current version of every open or in-progress ticket
+// from one agent to another, recording each in its assignment history
+func (r *ticketRepository) ReassignOpenTickets(ctx context.Context, fromAgentID, toAgentID, assignedByID uuid.UUID, note string) (int64, error) {
+	var reassigned int64
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		var ticketIDs []uuid.UUID
+		if err := tx.Model(&models.Ticket{}).
+			Where("assigned_agent_id = ? AND status IN ? AND expiration_time IS NULL", fromAgentID, []models.TicketStatus{models.StatusOpen, models.StatusInProgress}).
+			Pluck("id", &ticketIDs).Error; err != nil {
+			return err
+		}
+		if len(ticketIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.Ticket{}).Where("id IN ? AND expiration_time IS NULL", ticketIDs).Update("assigned_agent_id", toAgentID)
+		if result.Error != nil {
+			return result.Error
+		}
+		reassigned = result.RowsAffected
+
+		assignments := make([]models.TicketAssignment, len(ticketIDs))
+		for i, ticketID := range ticketIDs {
+			assignments[i] = models.TicketAssignment{
+				TicketID:        ticketID,
+				AgentID:         &toAgentID,
+				PreviousAgentID: &fromAgentID,
+				AssignedByID:    assignedByID,
+				Note:            note,
+			}
+		}
+		return tx.Create(&assignments).Error
+	})
+	return reassigned, err
+}
+
+// ListAssignments returns a ticket's assignment history, oldest first
+func (r *ticketRepository) ListAssignments(ctx context.Context, ticketID uuid.UUID) ([]models.TicketAssignment, error) {
+	var assignments []models.TicketAssignment
+	err := r.db.Reader().WithContext(ctx).
+		Preload("Agent").
+		Preload("AssignedBy").
+		Where("ticket_id = ?", ticketID).
+		Order("created_at ASC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// UpdateStatus updates the status of a ticket, recording the resolution
+// details when they are given
+func (r *ticketRepository) UpdateStatus(ctx context.Context, ticketID uuid.UUID, status models.TicketStatus, resolution *models.TicketResolution) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -197,22 +492,52 @@ func (r *ticketRepository) UpdateStatus(ctx context.Context, ticketID uuid.UUID,
 		updates["resolved_at"] = &now
 	}
 
+	if resolution != nil {
+		updates["resolution_code"] = resolution.ResolutionCode
+		updates["root_cause"] = resolution.RootCause
+		updates["root_cause_code"] = resolution.RootCauseCode
+		updates["knowledge_article_id"] = resolution.KnowledgeArticleID
+	}
+
 	return r.db.DB.WithContext(ctx).
 		Model(&models.Ticket{}).
-		Where("id = ?", ticketID).
+		Where("id = ? AND expiration_time IS NULL", ticketID).
 		Updates(updates).Error
 }
 
-// Escalate escalates a ticket to another user
-func (r *ticketRepository) Escalate(ctx context.Context, ticketID, escalatedTo uuid.UUID) error {
+// Escalate escalates a ticket to the event's target with its note as the
+// reason, recording the event in the ticket's escalation history
+func (r *ticketRepository) Escalate(ctx context.Context, event *models.TicketEscalationEvent) error {
 	now := time.Now()
-	return r.db.DB.WithContext(ctx).
-		Model(&models.Ticket{}).
-		Where("id = ?", ticketID).
-		Updates(map[string]interface{}{
-			"escalated_to": escalatedTo,
-			"escalated_at": &now,
-		}).Error
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Ticket{}).
+			Where("id = ? AND expiration_time IS NULL", event.TicketID).
+			Updates(map[string]interface{}{
+				"escalated_to":      event.TargetID,
+				"escalated_at":      &now,
+				"escalation_reason": event.Note,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// DeEscalate clears a ticket's escalation, recording the event in its
+// escalation history
+func (r *ticketRepository) DeEscalate(ctx context.Context, event *models.TicketEscalationEvent) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Ticket{}).
+			Where("id = ? AND expiration_time IS NULL", event.TicketID).
+			Updates(map[string]interface{}{
+				"escalated_to":      nil,
+				"escalated_at":      nil,
+				"escalation_reason": "",
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
 }
 
 // GetByUser retrieves tickets created by a specific user
@@ -233,6 +558,113 @@ func (r *ticketRepository) GetByAgent(ctx context.Context, agentID uuid.UUID, qu
 	return r.List(ctx, query)
 }
 
+// RecordView records that a user opened a ticket, keeping only their keep most
+// recent views
+func (r *ticketRepository) RecordView(ctx context.Context, view *models.TicketView, keep int) error {
+	return r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "ticket_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+		}).Create(view).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ? AND ticket_id NOT IN (?)", view.UserID,
+			tx.Model(&models.TicketView{}).Select("ticket_id").Where("user_id = ?", view.UserID).Order("viewed_at DESC").Limit(keep),
+		).Delete(&models.TicketView{}).Error
+	})
+}
+
+// GetRecentlyViewed retrieves the current versions of the tickets a user viewed
+// most recently, newest first, limited to the ticket scope of ctx. With ownOnly
+// set only tickets the user requested are returned.
+func (r *ticketRepository) GetRecentlyViewed(ctx context.Context, userID uuid.UUID, limit int, ownOnly bool) ([]models.Ticket, error) {
+	db := r.db.DB.WithContext(ctx).
+		Preload("Category").
+		Preload("AssignedAgent").
+		Preload("CreatedBy").
+		Scopes(scopeTickets(ctx)).
+		Joins("JOIN ticket_views ON ticket_views.ticket_id = tickets.id AND ticket_views.user_id = ?", userID).
+		Where("tickets.expiration_time IS NULL")
+	if ownOnly {
+		db = db.Where("tickets.created_by_id = ?", userID)
+	}
+
+	var tickets []models.Ticket
+	err := db.Order("ticket_views.viewed_at DESC").Limit(limit).Find(&tickets).Error
+	return tickets, err
+}
+
+// Pin stars a ticket for a user; pinning it again has no effect
+func (r *ticketRepository) Pin(ctx context.Context, pin *models.TicketPin) error {
+	return r.db.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(pin).Error
+}
+
+// Unpin removes a user's star from a ticket
+func (r *ticketRepository) Unpin(ctx context.Context, userID, ticketID uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).
+		Where("user_id = ? AND ticket_id = ?", userID, ticketID).
+		Delete(&models.TicketPin{}).Error
+}
+
+// AddSubscriber copies an email address on a ticket
+func (r *ticketRepository) AddSubscriber(ctx context.Context, subscriber *models.TicketSubscriber) error {
+	return r.db.DB.WithContext(ctx).Create(subscriber).Error
+}
+
+// GetSubscriber retrieves an address copied on a ticket by its ID
+func (r *ticketRepository) GetSubscriber(ctx context.Context, id uuid.UUID) (*models.TicketSubscriber, error) {
+	var subscriber models.TicketSubscriber
+	if err := r.db.DB.WithContext(ctx).First(&subscriber, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+// ListSubscribers retrieves the addresses copied on a ticket, oldest first
+func (r *ticketRepository) ListSubscribers(ctx context.Context, ticketID uuid.UUID) ([]models.TicketSubscriber, error) {
+	var subscribers []models.TicketSubscriber
+	err := r.db.DB.WithContext(ctx).
+		Where("ticket_id = ?", ticketID).
+		Order("created_at ASC").
+		Find(&subscribers).Error
+	return subscribers, err
+}
+
+// RemoveSubscriber stops copying an address on a ticket
+func (r *ticketRepository) RemoveSubscriber(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Delete(&models.TicketSubscriber{}, "id = ?", id).Error
+}
+
+// ticketSortColumns maps the sort fields the API accepts to the expressions
+// they order by. Priority and status order by severity and workflow rather
+// than alphabetically. Only these expressions ever reach ORDER BY.
+var ticketSortColumns = map[string]string{
+	"creation_time": "creation_time",
+	"due_date":      "due_date",
+	"title":         "title",
+	"priority":      "CASE priority WHEN 'LOW' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'HIGH' THEN 3 WHEN 'CRITICAL' THEN 4 END",
+	"status":        "CASE status WHEN 'OPEN' THEN 1 WHEN 'IN_PROGRESS' THEN 2 WHEN 'RESOLVED' THEN 3 WHEN 'CLOSED' THEN 4 END",
+}
+
+// ticketOrderClause builds the ORDER BY term for a sort, refusing fields and
+// directions outside the allow-list
+func ticketOrderClause(sort *models.TicketSort) (string, error) {
+	column, ok := ticketSortColumns[sort.Field]
+	if !ok {
+		return "", fmt.Errorf("unsupported sort field %q", sort.Field)
+	}
+	switch direction := strings.ToUpper(sort.Direction); direction {
+	case "ASC", "DESC":
+		return column + " " + direction, nil
+	default:
+		return "", fmt.Errorf("unsupported sort direction %q", sort.Direction)
+	}
+}
+
 // applyFilters applies filters to the database query
 func (r *ticketRepository) applyFilters(db *gorm.DB, filter *models.TicketFilter) *gorm.DB {
 	if filter == nil {
@@ -255,10 +687,22 @@ func (r *ticketRepository) applyFilters(db *gorm.DB, filter *models.TicketFilter
 		db = db.Where("assigned_agent_id = ?", *filter.AssignedTo)
 	}
 
+	if filter.Unassigned {
+		db = db.Where("assigned_agent_id IS NULL")
+	}
+
+	if filter.TeamID != nil {
+		db = db.Where("team_id = ?", *filter.TeamID)
+	}
+
 	if filter.CreatedBy != nil {
 		db = db.Where("created_by_id = ?", *filter.CreatedBy)
 	}
 
+	if filter.PinnedBy != nil {
+		db = db.Where("id IN (SELECT ticket_id FROM ticket_pins WHERE user_id = ?)", *filter.PinnedBy)
+	}
+
 	if filter.IsEscalated != nil {
 		if *filter.IsEscalated {
 			db = db.Where("escalated_at IS NOT NULL")
@@ -275,17 +719,42 @@ func (r *ticketRepository) applyFilters(db *gorm.DB, filter *models.TicketFilter
 		}
 	}
 
+	// Compared in UTC, as creation times are stored with the offset they were created in
 	if filter.DateFrom != nil {
-		db = db.Where("creation_time >= ?", *filter.DateFrom)
+		db = db.Where("datetime("+sqliteTime("creation_time")+") >= ?", filter.DateFrom.UTC().Format(sqliteUTC))
 	}
 
 	if filter.DateTo != nil {
-		db = db.Where("creation_time <= ?", *filter.DateTo)
+		db = db.Where("datetime("+sqliteTime("creation_time")+") <= ?", filter.DateTo.UTC().Format(sqliteUTC))
 	}
 
 	if filter.Search != "" {
 		searchTerm := "%" + filter.Search + "%"
-		db = db.Where("title LIKE ? OR description LIKE ?", searchTerm, searchTerm)
+		var (
+			conditions []string
+			args       []any
+		)
+		for _, field := range filter.SearchFields() {
+			switch field {
+			case models.SearchInTitle:
+				conditions = append(conditions, "title LIKE ?")
+				args = append(args, searchTerm)
+			case models.SearchInDescription:
+				conditions = append(conditions, "description LIKE ?")
+				args = append(args, searchTerm)
+			case models.SearchInComments:
+				internal := ""
+				if !filter.SearchInternal {
+					internal = " AND comments.is_internal = false"
+				}
+				conditions = append(conditions, "EXISTS (SELECT 1 FROM comments WHERE comments.ticket_id = tickets.id AND comments.content LIKE ?"+internal+")")
+				args = append(args, searchTerm)
+			case models.SearchInAttachments:
+				conditions = append(conditions, "EXISTS (SELECT 1 FROM attachments WHERE attachments.ticket_id = tickets.id AND attachments.filename LIKE ?)")
+				args = append(args, searchTerm)
+			}
+		}
+		db = db.Where("("+strings.Join(conditions, " OR ")+")", args...)
 	}
 
 	return db
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketScope restricts ticket listings and statistics to the tickets of a set of teams
+type TicketScope struct {
+	TeamIDs []uuid.UUID
+}
+
+type ticketScopeKey struct{}
+
+// WithTicketScope returns a context whose ticket listings and statistics are limited to scope
+func WithTicketScope(ctx context.Context, scope *TicketScope) context.Context {
+	return context.WithValue(ctx, ticketScopeKey{}, scope)
+}
+
+// TicketScopeFromContext returns the ticket scope of a context, if any
+func TicketScopeFromContext(ctx context.Context) (*TicketScope, bool) {
+	scope, ok := ctx.Value(ticketScopeKey{}).(*TicketScope)
+	return scope, ok && scope != nil
+}
+
+type sandboxKey struct{}
+
+// WithSandbox returns a context whose ticket queries only see the demo
+// tickets and requesters of a training sandbox
+func WithSandbox(ctx context.Context, sandboxID uuid.UUID) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, sandboxID)
+}
+
+// SandboxFromContext returns the training sandbox of a context, if any
+func SandboxFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sandboxID, ok := ctx.Value(sandboxKey{}).(uuid.UUID)
+	return sandboxID, ok
+}
+
+// scopeTickets limits a ticket query to the scope carried by ctx. A ticket is
+// in scope when it belongs to one of the teams or is assigned to a member of
+// one of them. An empty scope matches nothing. In a training sandbox only its
+// demo tickets are in scope; elsewhere demo tickets never are.
+func scopeTickets(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sandboxID, ok := SandboxFromContext(ctx); ok {
+			return db.Where("sandbox_id = ?", sandboxID)
+		}
+		db = db.Where("sandbox_id IS NULL")
+
+		scope, ok := TicketScopeFromContext(ctx)
+		if !ok {
+			return db
+		}
+		if len(scope.TeamIDs) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where("(team_id IN ? OR assigned_agent_id IN (SELECT user_id FROM team_members WHERE team_id IN ?))", scope.TeamIDs, scope.TeamIDs)
+	}
+}
+
+// currentVersion restricts a ticket query to the current version of each ticket
+func currentVersion(db *gorm.DB) *gorm.DB {
+	return db.Where("expiration_time IS NULL")
+}
+
+// scopeUsers leaves the demo requesters of training sandboxes out of a user
+// query, except those of the sandbox carried by ctx
+func scopeUsers(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sandboxID, ok := SandboxFromContext(ctx); ok {
+			return db.Where("(demo = ? OR sandbox_id = ?)", false, sandboxID)
+		}
+		return db.Where("demo = ?", false)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // TimeSeriesRepositoryImpl provides a generic implementation of TimeSeriesRepository
@@ -25,7 +26,7 @@ func (r *TimeSeriesRepositoryImpl[T]) Create(ctx context.Context, entity T) erro
 	return r.db.DB.WithContext(ctx).Create(entity).Error
 }
 
-// GetCurrentByID retrieves the current version of an entity by its logical ID
+// GetCurrentByID retrieves the current version of an entity by its business ID
 // This finds the version where ExpirationTime is null
 func (r *TimeSeriesRepositoryImpl[T]) GetCurrentByID(ctx context.Context, id uuid.UUID) (T, error) {
 	var entity T
@@ -42,10 +43,10 @@ func (r *TimeSeriesRepositoryImpl[T]) GetCurrentByID(ctx context.Context, id uui
 }
 
 // GetByID retrieves a specific version of an entity by its version ID
-func (r *TimeSeriesRepositoryImpl[T]) GetByID(ctx context.Context, id uuid.UUID) (T, error) {
+func (r *TimeSeriesRepositoryImpl[T]) GetByID(ctx context.Context, versionID uuid.UUID) (T, error) {
 	var entity T
 	err := r.db.DB.WithContext(ctx).
-		Where("id = ?", id).
+		Where("version_id = ?", versionID).
 		First(&entity).Error
 
 	if err != nil {
@@ -56,8 +57,8 @@ func (r *TimeSeriesRepositoryImpl[T]) GetByID(ctx context.Context, id uuid.UUID)
 	return entity, nil
 }
 
-// GetHistory retrieves all versions of an entity by its logical ID
-// This finds all versions with the same business ID, ordered by creation time
+// GetHistory retrieves all versions of an entity by its business ID, ordered
+// by creation time
 func (r *TimeSeriesRepositoryImpl[T]) GetHistory(ctx context.Context, id uuid.UUID) ([]T, error) {
 	var entities []T
 	err := r.db.DB.WithContext(ctx).
@@ -127,6 +128,41 @@ func (r *TimeSeriesRepositoryImpl[T]) Update(ctx context.Context, id uuid.UUID,
 	return cloned, nil
 }
 
+// RestoreVersion creates a new current version by cloning an earlier version
+// of an entity, expiring the current one. It returns gorm.ErrRecordNotFound
+// when the entity has no current version or no such earlier version.
+func (r *TimeSeriesRepositoryImpl[T]) RestoreVersion(ctx context.Context, id, versionID uuid.UUID) (T, error) {
+	var restored T
+	err := r.db.Transaction(ctx, func(tx *gorm.DB) error {
+		var current T
+		if err := tx.Where("id = ? AND expiration_time IS NULL", id).First(&current).Error; err != nil {
+			return err
+		}
+
+		var version T
+		if err := tx.Where("id = ? AND version_id = ? AND expiration_time IS NOT NULL", id, versionID).First(&version).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		current.SetExpirationTime(&now)
+		if err := tx.Save(&current).Error; err != nil {
+			return fmt.Errorf("failed to expire current version: %w", err)
+		}
+
+		restored = version.Clone().(T)
+		if err := tx.Create(&restored).Error; err != nil {
+			return fmt.Errorf("failed to create restored version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return restored, nil
+}
+
 // Archive marks the current version as expired (archives it)
 func (r *TimeSeriesRepositoryImpl[T]) Archive(ctx context.Context, id uuid.UUID) error {
 	// Start a transaction
@@ -171,30 +207,3 @@ func (r *TimeSeriesRepositoryImpl[T]) HardDelete(ctx context.Context, id uuid.UU
 	var entity T
 	return r.db.DB.WithContext(ctx).Where("id = ?", id).Delete(&entity).Error
 }
-
-// GetCurrentByBusinessID retrieves the current version of an entity by its business ID
-// This is useful when you have a separate business ID field
-func (r *TimeSeriesRepositoryImpl[T]) GetCurrentByBusinessID(ctx context.Context, businessID uuid.UUID) (T, error) {
-	var entity T
-	err := r.db.DB.WithContext(ctx).
-		Where("business_id = ? AND expiration_time IS NULL", businessID).
-		First(&entity).Error
-
-	if err != nil {
-		var zero T
-		return zero, err
-	}
-
-	return entity, nil
-}
-
-// GetHistoryByBusinessID retrieves all versions of an entity by its business ID
-func (r *TimeSeriesRepositoryImpl[T]) GetHistoryByBusinessID(ctx context.Context, businessID uuid.UUID) ([]T, error) {
-	var entities []T
-	err := r.db.DB.WithContext(ctx).
-		Where("business_id = ?", businessID).
-		Order("creation_time ASC").
-		Find(&entities).Error
-
-	return entities, err
-}
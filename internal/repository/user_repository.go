@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
@@ -17,6 +18,10 @@ type UserRepository interface {
 	Update(user *models.User) error
 	Delete(id string) error
 	List(limit, offset int) ([]*models.User, error)
+	ListAgents() ([]*models.User, error)
+	ListAvailableAgents(at time.Time) ([]*models.User, error)
+	SetEmailFailing(email string, since *time.Time) error
+	ListEmailFailing() ([]*models.User, error)
 }
 
 // userRepository implements UserRepository
@@ -77,9 +82,59 @@ func (r *userRepository) Delete(id string) error {
 	return r.db.DB.Where("id = ?", id).Delete(&models.User{}).Error
 }
 
-// List retrieves a list of users with pagination
+// List retrieves a list of users with pagination, leaving out the demo
+// requesters of training sandboxes
 func (r *userRepository) List(limit, offset int) ([]*models.User, error) {
 	var users []*models.User
-	err := r.db.DB.Limit(limit).Offset(offset).Find(&users).Error
+	err := r.db.DB.Where("demo = ?", false).Limit(limit).Offset(offset).Find(&users).Error
 	return users, err
 }
+
+// ListAgents retrieves active agents, oldest accounts first
+func (r *userRepository) ListAgents() ([]*models.User, error) {
+	var users []*models.User
+	err := r.agents().Order("created_at ASC").Find(&users).Error
+	return users, err
+}
+
+// ListAvailableAgents retrieves active agents who are not out of office at the
+// given time, oldest accounts first
+func (r *userRepository) ListAvailableAgents(at time.Time) ([]*models.User, error) {
+	var users []*models.User
+	err := r.availableAgents(at).Order("created_at ASC").Find(&users).Error
+	return users, err
+}
+
+// SetEmailFailing flags the users with the given email address as having
+// failing notifications, keeping an earlier flag, or clears the flag when
+// since is nil
+func (r *userRepository) SetEmailFailing(email string, since *time.Time) error {
+	db := r.db.DB.Model(&models.User{}).Where("LOWER(email) = LOWER(?)", email)
+	if since != nil {
+		db = db.Where("email_failing_since IS NULL")
+	}
+	return db.Update("email_failing_since", since).Error
+}
+
+// ListEmailFailing retrieves the users whose notifications are failing, longest failing first
+func (r *userRepository) ListEmailFailing() ([]*models.User, error) {
+	var users []*models.User
+	err := r.db.DB.Where("email_failing_since IS NOT NULL").Order("email_failing_since ASC").Find(&users).Error
+	return users, err
+}
+
+// agents scopes a query to active users who can handle tickets and chats
+func (r *userRepository) agents() *gorm.DB {
+	return r.db.DB.Model(&models.User{}).
+		Where("is_active = ?", true).
+		Where("role IN ?", []models.UserRole{models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator})
+}
+
+// availableAgents scopes a query to active agents who are not out of office at the given time
+func (r *userRepository) availableAgents(at time.Time) *gorm.DB {
+	away := r.db.DB.Model(&models.OutOfOffice{}).
+		Select("user_id").
+		Where("starts_at <= ? AND ends_at > ?", at, at)
+
+	return r.agents().Where("id NOT IN (?)", away)
+}
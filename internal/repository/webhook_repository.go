@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// webhookRepository implements WebhookRepository
+type webhookRepository struct {
+	db *database.Database
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *database.Database) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// Create creates a new webhook subscription
+func (r *webhookRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.DB.WithContext(ctx).Create(subscription).Error
+}
+
+// GetByID retrieves a webhook subscription by ID
+func (r *webhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.DB.WithContext(ctx).Where("id = ?", id).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// List retrieves every webhook subscription, oldest first
+func (r *webhookRepository) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.DB.WithContext(ctx).Order("created_at ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ListEnabled retrieves the webhook subscriptions events are posted to
+func (r *webhookRepository) ListEnabled(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.DB.WithContext(ctx).Where("enabled = ?", true).Order("created_at ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// Update updates an existing webhook subscription
+func (r *webhookRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.DB.WithContext(ctx).Save(subscription).Error
+}
+
+// Delete deletes a webhook subscription with its delivery log
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookSubscription{ID: id}).Error
+	})
+}
+
+// CreateDelivery queues an event for delivery
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.DB.WithContext(ctx).Create(delivery).Error
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.DB.WithContext(ctx).Save(delivery).Error
+}
+
+// ListDueDeliveries retrieves pending deliveries due for an attempt at the
+// given time, the longest waiting first
+func (r *webhookRepository) ListDueDeliveries(ctx context.Context, at time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, at).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ListDeliveries retrieves the deliveries of a subscription matching the query, newest first
+func (r *webhookRepository) ListDeliveries(ctx context.Context, query *models.WebhookDeliveryQuery) (*models.WebhookDeliveryListResponse, error) {
+	db := r.db.DB.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", query.SubscriptionID)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := db.Order("created_at DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int((total + int64(query.PageSize) - 1) / int64(query.PageSize)),
+	}, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Portal pages the links in account emails open; they post the token from
+// the link to the reset-password and verify-email endpoints
+const (
+	PasswordResetPath     = "/reset-password"
+	EmailVerificationPath = "/verify-email"
+)
+
+// ErrAccountTokenInvalid is returned when a password reset or email
+// verification token is unknown, already used or expired
+var ErrAccountTokenInvalid = errors.New("invalid or expired token")
+
+// AccountService emails users the links that verify their address and reset
+// a forgotten password, and redeems those links. Only a hash of each token is
+// stored, and a token can be used once.
+type AccountService struct {
+	userRepo        repository.UserRepository
+	tokenRepo       repository.AccountTokenRepository
+	sender          email.EmailSender
+	templates       *EmailTemplateService
+	baseURL         string
+	resetTTL        time.Duration
+	verificationTTL time.Duration
+}
+
+// NewAccountService creates a new account service; templates may be nil to
+// always send the built-in emails
+func NewAccountService(userRepo repository.UserRepository, tokenRepo repository.AccountTokenRepository, sender email.EmailSender, templates *EmailTemplateService, cfg *config.Config) *AccountService {
+	resetTTL, err := time.ParseDuration(cfg.Security.PasswordResetTTL)
+	if err != nil || resetTTL <= 0 {
+		resetTTL = time.Hour // fallback
+	}
+	verificationTTL, err := time.ParseDuration(cfg.Security.EmailVerificationTTL)
+	if err != nil || verificationTTL <= 0 {
+		verificationTTL = 72 * time.Hour // fallback
+	}
+
+	return &AccountService{
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		sender:          sender,
+		templates:       templates,
+		baseURL:         strings.TrimRight(cfg.Share.BaseURL, "/"),
+		resetTTL:        resetTTL,
+		verificationTTL: verificationTTL,
+	}
+}
+
+// SendWelcome emails a newly registered user a link to verify their address
+func (s *AccountService) SendWelcome(ctx context.Context, user *models.User) error {
+	token, hash, err := newAccountToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	if err := s.tokenRepo.CreateEmailVerification(ctx, &models.EmailVerificationToken{
+		UserID:    user.ID.String(),
+		Token:     hash,
+		ExpiresAt: time.Now().Add(s.verificationTTL),
+	}); err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	values := accountTemplateValues(user, s.verificationTTL)
+	values["verify_url"] = s.baseURL + EmailVerificationPath + "?token=" + url.QueryEscape(token)
+	return s.send(ctx, user, email.TemplateWelcome, values)
+}
+
+// VerifyEmail marks the address of the user a verification link was sent to as verified
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	used, err := s.tokenRepo.UseEmailVerification(ctx, hashAccountToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccountTokenInvalid
+		}
+		return fmt.Errorf("failed to use verification token: %w", err)
+	}
+
+	user, err := s.tokenUser(used.UserID)
+	if err != nil {
+		return err
+	}
+	user.IsVerified = true
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to verify user: %w", err)
+	}
+	return nil
+}
+
+// ForgotPassword emails a password reset link to the active user with the
+// given address. Unknown addresses succeed without sending anything, so the
+// response does not tell whether an account exists.
+func (s *AccountService) ForgotPassword(ctx context.Context, address string) error {
+	user, err := s.userRepo.GetByEmail(address)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, hash, err := newAccountToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	if err := s.tokenRepo.CreatePasswordReset(ctx, &models.PasswordResetToken{
+		UserID:    user.ID.String(),
+		Token:     hash,
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}); err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	values := accountTemplateValues(user, s.resetTTL)
+	values["reset_url"] = s.baseURL + PasswordResetPath + "?token=" + url.QueryEscape(token)
+	return s.send(ctx, user, email.TemplatePasswordReset, values)
+}
+
+// ResetPassword sets a new password for the user a reset link was sent to.
+// Receiving the link proves they own the address, so it is also verified.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	used, err := s.tokenRepo.UsePasswordReset(ctx, hashAccountToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAccountTokenInvalid
+		}
+		return fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	user, err := s.tokenUser(used.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrAccountTokenInvalid
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = string(hashed)
+	user.IsVerified = true
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// tokenUser returns the user a token was issued to; a deleted user makes the
+// token invalid
+func (s *AccountService) tokenUser(userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrAccountTokenInvalid
+	}
+	return user, nil
+}
+
+// send renders an account email in the user's language and sends it to them
+func (s *AccountService) send(ctx context.Context, user *models.User, template string, values map[string]string) error {
+	subject, text := s.templates.Render(ctx, template, user.Language, values)
+	return s.sender.Send(ctx, &email.Message{
+		To:      []string{user.Email},
+		Subject: subject,
+		Text:    text,
+	})
+}
+
+// accountTemplateValues returns the placeholder values every account email may use
+func accountTemplateValues(user *models.User, ttl time.Duration) map[string]string {
+	return map[string]string{
+		"recipient_first_name": user.FirstName,
+		"recipient_name":       user.FullName(),
+		"link_expires_in":      describeDuration(ttl),
+	}
+}
+
+// describeDuration writes a link lifetime the way a person would, such as
+// "1 hour", "3 days" or "90 minutes"
+func describeDuration(d time.Duration) string {
+	count, unit := int64(d/time.Minute), "minute"
+	switch {
+	case d >= 48*time.Hour && d%(24*time.Hour) == 0:
+		count, unit = int64(d/(24*time.Hour)), "day"
+	case d >= time.Hour && d%time.Hour == 0:
+		count, unit = int64(d/time.Hour), "hour"
+	}
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// newAccountToken returns a random token to email and the hash to store
+func newAccountToken() (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(bytes)
+	return token, hashAccountToken(token), nil
+}
+
+// hashAccountToken returns the stored form of an emailed token
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
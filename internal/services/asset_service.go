@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultAssetReportLimit is the number of assets returned by the ticket report by default
+const defaultAssetReportLimit = 20
+
+// AssetService manages assets and their links to tickets
+type AssetService struct {
+	assetRepo  repository.AssetRepository
+	ticketRepo repository.TicketRepository
+	userRepo   repository.UserRepository
+}
+
+// NewAssetService creates a new asset service
+func NewAssetService(assetRepo repository.AssetRepository, ticketRepo repository.TicketRepository, userRepo repository.UserRepository) *AssetService {
+	return &AssetService{
+		assetRepo:  assetRepo,
+		ticketRepo: ticketRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// CreateAsset registers a new asset
+func (s *AssetService) CreateAsset(ctx context.Context, req *models.CreateAssetRequest) (*models.Asset, error) {
+	if err := s.validateOwner(req.OwnerID); err != nil {
+		return nil, err
+	}
+
+	asset := &models.Asset{
+		Name:         strings.TrimSpace(req.Name),
+		Type:         strings.ToLower(strings.TrimSpace(req.Type)),
+		SerialNumber: normalizeSerial(req.SerialNumber),
+		OwnerID:      req.OwnerID,
+		Notes:        req.Notes,
+	}
+	if err := s.assetRepo.Create(ctx, asset); err != nil {
+		return nil, assetWriteError(err, asset.SerialNumber)
+	}
+	return s.GetAsset(ctx, asset.ID)
+}
+
+// GetAsset retrieves an asset by ID
+func (s *AssetService) GetAsset(ctx context.Context, id uuid.UUID) (*models.Asset, error) {
+	asset, err := s.assetRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("asset %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+	return asset, nil
+}
+
+// UpdateAsset updates an existing asset
+func (s *AssetService) UpdateAsset(ctx context.Context, id uuid.UUID, req *models.UpdateAssetRequest) (*models.Asset, error) {
+	asset, err := s.GetAsset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		asset.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil {
+		asset.Type = strings.ToLower(strings.TrimSpace(*req.Type))
+	}
+	if req.SerialNumber != nil {
+		asset.SerialNumber = normalizeSerial(req.SerialNumber)
+	}
+	if req.OwnerID != nil {
+		if err := s.validateOwner(req.OwnerID); err != nil {
+			return nil, err
+		}
+		asset.OwnerID = req.OwnerID
+	}
+	if req.Notes != nil {
+		asset.Notes = *req.Notes
+	}
+
+	if err := s.assetRepo.Update(ctx, asset); err != nil {
+		return nil, assetWriteError(err, asset.SerialNumber)
+	}
+	return s.GetAsset(ctx, id)
+}
+
+// DeleteAsset deletes an asset and its ticket links
+func (s *AssetService) DeleteAsset(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetAsset(ctx, id); err != nil {
+		return err
+	}
+	return s.assetRepo.Delete(ctx, id)
+}
+
+// ListAssets retrieves assets matching the filter
+func (s *AssetService) ListAssets(ctx context.Context, filter *models.AssetFilter) ([]models.Asset, error) {
+	return s.assetRepo.List(ctx, filter)
+}
+
+// GetAssetTickets retrieves the tickets linked to an asset
+func (s *AssetService) GetAssetTickets(ctx context.Context, id uuid.UUID) ([]models.Ticket, error) {
+	if _, err := s.GetAsset(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.assetRepo.GetTickets(ctx, id)
+}
+
+// GetTicketAssets retrieves the assets linked to a ticket
+func (s *AssetService) GetTicketAssets(ctx context.Context, ticketID uuid.UUID) ([]models.Asset, error) {
+	if err := s.ensureTicket(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	return s.assetRepo.GetByTicket(ctx, ticketID)
+}
+
+// LinkTicket links a ticket to an asset
+func (s *AssetService) LinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error {
+	if err := s.ensureTicket(ctx, ticketID); err != nil {
+		return err
+	}
+	if _, err := s.GetAsset(ctx, assetID); err != nil {
+		return err
+	}
+	return s.assetRepo.LinkTicket(ctx, ticketID, assetID)
+}
+
+// UnlinkTicket removes the link between a ticket and an asset
+func (s *AssetService) UnlinkTicket(ctx context.Context, ticketID, assetID uuid.UUID) error {
+	return s.assetRepo.UnlinkTicket(ctx, ticketID, assetID)
+}
+
+// TicketReport reports the assets with the most tickets, so recurring hardware
+// issues stand out
+func (s *AssetService) TicketReport(ctx context.Context, limit int) ([]models.AssetTicketCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultAssetReportLimit
+	}
+	return s.assetRepo.TicketCounts(ctx, limit)
+}
+
+// ensureTicket checks that a ticket exists
+func (s *AssetService) ensureTicket(ctx context.Context, ticketID uuid.UUID) error {
+	if _, err := s.ticketRepo.GetByID(ctx, ticketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return nil
+}
+
+// validateOwner checks that an optional asset owner exists
+func (s *AssetService) validateOwner(ownerID *uuid.UUID) error {
+	if ownerID == nil {
+		return nil
+	}
+	owner, err := s.userRepo.GetByID(ownerID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get owner: %w", err)
+	}
+	if owner == nil {
+		return fmt.Errorf("%w: owner not found", ErrInvalidInput)
+	}
+	return nil
+}
+
+// normalizeSerial trims a serial number, treating a blank one as absent
+func normalizeSerial(serial *string) *string {
+	if serial == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*serial)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// assetWriteError maps a failed asset write to a service error
+func assetWriteError(err error, serial *string) error {
+	if serial != nil && (errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE constraint failed")) {
+		return fmt.Errorf("%w: an asset with serial number %q already exists", ErrConflict, *serial)
+	}
+	return fmt.Errorf("failed to save asset: %w", err)
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/thumbnail"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// thumbnailBatch caps how many images one run of the thumbnail job handles
+	thumbnailBatch = 20
+	// draftBatch caps how many expired draft attachments one cleanup run removes
+	draftBatch = 100
+)
+
+// AttachmentService handles files uploaded to tickets
+type AttachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	draftRepo      repository.DraftAttachmentRepository
+	ticketRepo     repository.TicketRepository
+	commentRepo    repository.CommentRepository
+	storage        storage.Storage
+	maxSize        int64
+	allowedTypes   []string
+	// draftTTL is how long a draft attachment waits for a ticket to be saved with it
+	draftTTL time.Duration
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, draftRepo repository.DraftAttachmentRepository, ticketRepo repository.TicketRepository, commentRepo repository.CommentRepository, storage storage.Storage, cfg *config.Config) *AttachmentService {
+	draftTTL, err := time.ParseDuration(cfg.Storage.DraftAttachmentTTL)
+	if err != nil || draftTTL <= 0 {
+		draftTTL = 24 * time.Hour // fallback
+	}
+
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		draftRepo:      draftRepo,
+		ticketRepo:     ticketRepo,
+		commentRepo:    commentRepo,
+		storage:        storage,
+		maxSize:        cfg.Storage.MaxUploadSize,
+		allowedTypes:   cfg.Storage.AllowedTypes,
+		draftTTL:       draftTTL,
+	}
+}
+
+// MaxUploadSize returns the largest attachment, in bytes, that can be uploaded
+func (s *AttachmentService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+// Upload stores a file on a ticket. Only the requester or an agent can attach
+// files, of an allowed type and no larger than the size limit. The type is the
+// one declared for the file, else the one its extension implies.
+func (s *AttachmentService) Upload(ctx context.Context, ticketID uuid.UUID, filename, contentType string, content io.Reader, user *models.User) (*models.Attachment, error) {
+	ticket, err := s.attachmentTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.CreatedByID != user.ID && !user.IsAgent() {
+		return nil, fmt.Errorf("%w: only the requester or an agent can attach files to this ticket", ErrForbidden)
+	}
+
+	filename = sanitizeFilename(filename)
+	mimeType := uploadMimeType(filename, contentType)
+	if !isAllowedType(s.allowedTypes, mimeType) {
+		return nil, fmt.Errorf("%w: files of type %s cannot be attached", ErrInvalidInput, mimeType)
+	}
+
+	key := path.Join("attachments", ticket.ID.String(), uuid.New().String()+"-"+filename)
+	size, err := s.store(ctx, key, content)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		TicketID:     ticket.ID,
+		Filename:     filename,
+		FilePath:     key,
+		FileSize:     size,
+		MimeType:     mimeType,
+		UploadedByID: user.ID,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// UploadDraft stores a file for a ticket that is yet to be created. Creating
+// the ticket with the draft's ID in attachment_ids attaches it; otherwise it
+// is removed once it expires. Type and size limits are those of attachments.
+func (s *AttachmentService) UploadDraft(ctx context.Context, filename, contentType string, content io.Reader, user *models.User) (*models.DraftAttachment, error) {
+	filename = sanitizeFilename(filename)
+	mimeType := uploadMimeType(filename, contentType)
+	if !isAllowedType(s.allowedTypes, mimeType) {
+		return nil, fmt.Errorf("%w: files of type %s cannot be attached", ErrInvalidInput, mimeType)
+	}
+	return s.uploadDraft(ctx, filename, mimeType, content, user, false)
+}
+
+// UploadInlineImage stores an image to embed in the description of a ticket
+// that is yet to be saved. Saving a ticket with the image's ID in
+// inline_image_ids attaches it; otherwise it is removed once it expires.
+func (s *AttachmentService) UploadInlineImage(ctx context.Context, filename, contentType string, content io.Reader, user *models.User) (*models.DraftAttachment, error) {
+	filename = sanitizeFilename(filename)
+	mimeType := uploadMimeType(filename, contentType)
+	if !models.ThumbnailTypes[mimeType] || !isAllowedType(s.allowedTypes, mimeType) {
+		return nil, fmt.Errorf("%w: only PNG, JPEG and GIF images of an allowed type can be embedded, not %s", ErrInvalidInput, mimeType)
+	}
+	return s.uploadDraft(ctx, filename, mimeType, content, user, true)
+}
+
+// uploadDraft stores a draft attachment of a checked type
+func (s *AttachmentService) uploadDraft(ctx context.Context, filename, mimeType string, content io.Reader, user *models.User, inline bool) (*models.DraftAttachment, error) {
+	key := path.Join("drafts", user.ID.String(), uuid.New().String()+"-"+filename)
+	size, err := s.store(ctx, key, content)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &models.DraftAttachment{
+		Filename:     filename,
+		FilePath:     key,
+		FileSize:     size,
+		MimeType:     mimeType,
+		Inline:       inline,
+		UploadedByID: user.ID,
+		ExpiresAt:    time.Now().UTC().Add(s.draftTTL),
+	}
+	if err := s.draftRepo.Create(ctx, draft); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to create draft attachment: %w", err)
+	}
+	return draft, nil
+}
+
+// store saves an uploaded file under key, refusing empty files and files over
+// the size limit
+func (s *AttachmentService) store(ctx context.Context, key string, content io.Reader) (int64, error) {
+	// One byte past the limit is read so that oversized files are noticed
+	size, err := s.storage.Save(ctx, key, io.LimitReader(content, s.maxSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if size > s.maxSize {
+		s.storage.Delete(ctx, key)
+		return 0, fmt.Errorf("%w: attachments can be at most %d bytes", ErrTooLarge, s.maxSize)
+	}
+	if size == 0 {
+		s.storage.Delete(ctx, key)
+		return 0, fmt.Errorf("%w: the file is empty", ErrInvalidInput)
+	}
+	return size, nil
+}
+
+// Open returns an attachment of a ticket the user can see, with its content.
+// The caller must close the content.
+func (s *AttachmentService) Open(ctx context.Context, ticketID, attachmentID uuid.UUID, user *models.User) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.readableAttachment(ctx, ticketID, attachmentID, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Open(ctx, attachment.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// OpenThumbnail returns the thumbnail of the named size of an image the user
+// can see, with its media type. The caller must close the content.
+func (s *AttachmentService) OpenThumbnail(ctx context.Context, ticketID, attachmentID uuid.UUID, sizeName string, user *models.User) (string, io.ReadCloser, error) {
+	size, ok := models.ThumbnailSizeNamed(sizeName)
+	if !ok {
+		return "", nil, fmt.Errorf("thumbnail size %q %w", sizeName, ErrNotFound)
+	}
+	attachment, err := s.readableAttachment(ctx, ticketID, attachmentID, user)
+	if err != nil {
+		return "", nil, err
+	}
+	if attachment.ThumbnailStatus != models.ThumbnailReady {
+		return "", nil, fmt.Errorf("thumbnail %w", ErrNotFound)
+	}
+
+	content, err := s.storage.Open(ctx, thumbnailKey(attachment, size))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open thumbnail: %w", err)
+	}
+	return thumbnail.ContentType(thumbnailFormat(attachment)), content, nil
+}
+
+// Schedule registers the jobs making thumbnails of uploaded images and
+// removing draft attachments no ticket was saved with
+func (s *AttachmentService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("attachment thumbnails", 10*time.Second, s.GenerateThumbnails)
+	jobs.Every("draft attachment cleanup", 10*time.Minute, s.RemoveExpiredDrafts)
+}
+
+// RemoveExpiredDrafts removes draft attachments that expired before a ticket
+// was saved with them, with their files
+func (s *AttachmentService) RemoveExpiredDrafts(ctx context.Context) error {
+	expired, err := s.draftRepo.ListExpired(ctx, time.Now().UTC(), draftBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list expired draft attachments: %w", err)
+	}
+
+	for _, draft := range expired {
+		// A ticket saved meanwhile has taken the draft, and its file with it
+		deleted, err := s.draftRepo.Delete(ctx, draft.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete draft attachment %s: %w", draft.ID, err)
+		}
+		if deleted {
+			s.storage.Delete(ctx, draft.FilePath)
+		}
+	}
+	return nil
+}
+
+// GenerateThumbnails makes the thumbnails of images waiting for them, oldest
+// first. Images that cannot be read are marked failed; storage errors leave
+// the image waiting for the next run.
+func (s *AttachmentService) GenerateThumbnails(ctx context.Context) error {
+	pending, err := s.attachmentRepo.ListPendingThumbnails(ctx, thumbnailBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list images waiting for thumbnails: %w", err)
+	}
+
+	for i := range pending {
+		attachment := &pending[i]
+		if err := s.generateThumbnails(ctx, attachment); err != nil {
+			return fmt.Errorf("failed to make thumbnails of attachment %s: %w", attachment.ID, err)
+		}
+		if err := s.attachmentRepo.UpdateThumbnails(ctx, attachment); err != nil {
+			return fmt.Errorf("failed to update attachment %s: %w", attachment.ID, err)
+		}
+	}
+	return nil
+}
+
+// generateThumbnails stores a thumbnail of every size of an image, setting its
+// dimensions and thumbnail status
+func (s *AttachmentService) generateThumbnails(ctx context.Context, attachment *models.Attachment) error {
+	content, err := s.storage.Open(ctx, attachment.FilePath)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return err
+	}
+
+	img, _, err := thumbnail.Decode(data)
+	if err != nil {
+		log.Printf("Cannot make thumbnails of attachment %s: %v", attachment.ID, err)
+		attachment.ThumbnailStatus = models.ThumbnailFailed
+		return nil
+	}
+	attachment.Width, attachment.Height = img.Rect.Dx(), img.Rect.Dy()
+
+	format := thumbnailFormat(attachment)
+	for _, size := range models.ThumbnailSizes {
+		width, height := size.Fit(attachment.Width, attachment.Height)
+		var encoded bytes.Buffer
+		if err := thumbnail.Encode(&encoded, thumbnail.Scale(img, width, height), format); err != nil {
+			return err
+		}
+		if _, err := s.storage.Save(ctx, thumbnailKey(attachment, size), &encoded); err != nil {
+			return err
+		}
+	}
+	attachment.ThumbnailStatus = models.ThumbnailReady
+	return nil
+}
+
+// Delete removes an attachment and its file. The uploader or an agent can
+// delete it.
+func (s *AttachmentService) Delete(ctx context.Context, ticketID, attachmentID uuid.UUID, user *models.User) error {
+	ticket, err := s.attachmentTicket(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	attachment, err := s.ticketAttachment(ctx, ticket, attachmentID)
+	if err != nil {
+		return err
+	}
+	if attachment.UploadedByID != user.ID && !user.IsAgent() {
+		return fmt.Errorf("%w: only the uploader or an agent can delete this attachment", ErrForbidden)
+	}
+
+	if err := s.attachmentRepo.Delete(ctx, attachment.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	// The record is gone, so a file left behind is unreachable rather than an error
+	deleteAttachmentFiles(ctx, s.storage, attachment)
+	return nil
+}
+
+// deleteAttachmentFiles removes the file of an attachment whose record is
+// gone, and its thumbnails
+func deleteAttachmentFiles(ctx context.Context, store storage.Storage, attachment *models.Attachment) {
+	store.Delete(ctx, attachment.FilePath)
+	if attachment.ThumbnailStatus == models.ThumbnailReady {
+		for _, size := range models.ThumbnailSizes {
+			store.Delete(ctx, thumbnailKey(attachment, size))
+		}
+	}
+}
+
+// readableAttachment loads an attachment of a ticket the user can see
+func (s *AttachmentService) readableAttachment(ctx context.Context, ticketID, attachmentID uuid.UUID, user *models.User) (*models.Attachment, error) {
+	ticket, err := s.attachmentTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.CreatedByID != user.ID && !user.HasPermission(models.PermTicketRead) {
+		return nil, fmt.Errorf("%w: only the requester or staff can download attachments of this ticket", ErrForbidden)
+	}
+
+	attachment, err := s.ticketAttachment(ctx, ticket, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	// Files of internal notes are as private as the notes
+	if attachment.CommentID != nil && !user.IsAgent() {
+		comment, err := s.commentRepo.GetByID(ctx, *attachment.CommentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get comment: %w", err)
+		}
+		if comment == nil || comment.IsInternal {
+			return nil, fmt.Errorf("attachment %w", ErrNotFound)
+		}
+	}
+	return attachment, nil
+}
+
+// attachmentTicket loads the ticket whose attachments are being managed
+func (s *AttachmentService) attachmentTicket(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// ticketAttachment loads an attachment, which must belong to the ticket
+func (s *AttachmentService) ticketAttachment(ctx context.Context, ticket *models.Ticket, attachmentID uuid.UUID) (*models.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment == nil || attachment.TicketID != ticket.ID {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	return attachment, nil
+}
+
+// thumbnailKey returns where the thumbnail of the given size of an image is stored
+func thumbnailKey(attachment *models.Attachment, size models.ThumbnailSize) string {
+	return path.Join("thumbnails", attachment.TicketID.String(), attachment.ID.String()+"-"+size.Name)
+}
+
+// thumbnailFormat returns the format thumbnails of an image are encoded in:
+// JPEG for photos, PNG otherwise so transparency is kept
+func thumbnailFormat(attachment *models.Attachment) string {
+	if attachment.MimeType == "image/jpeg" {
+		return "jpeg"
+	}
+	return "png"
+}
+
+// isAllowedType reports whether mimeType matches one of allowedTypes, given
+// exactly or as type/*
+func isAllowedType(allowedTypes []string, mimeType string) bool {
+	major, _, _ := strings.Cut(mimeType, "/")
+	for _, allowed := range allowedTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mimeType || allowed == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadMimeType returns the media type declared for an upload, without
+// parameters, falling back to the type of its extension
+func uploadMimeType(filename, declared string) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(path.Ext(filename))); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
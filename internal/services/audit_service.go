@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/auditsink"
+	"github.com/google/uuid"
+)
+
+// AuditService records and queries the audit log
+type AuditService struct {
+	auditRepo repository.AuditLogRepository
+	forwarder *auditsink.Forwarder
+}
+
+// NewAuditService creates a new audit service. Recorded entries are also
+// forwarded to the SIEM sinks of forwarder, which may be nil.
+func NewAuditService(auditRepo repository.AuditLogRepository, forwarder *auditsink.Forwarder) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		forwarder: forwarder,
+	}
+}
+
+// RetryTask sends a batch of audit events a SIEM sink did not accept again
+func (s *AuditService) RetryTask(ctx context.Context, task *models.FailedTask) error {
+	var batch []models.AuditLog
+	if err := json.Unmarshal([]byte(task.Payload), &batch); err != nil {
+		return fmt.Errorf("invalid audit batch payload: %w", err)
+	}
+	return s.forwarder.Resend(ctx, task.Name, batch)
+}
+
+// Record writes an audit log entry; details are stored as JSON
+func (s *AuditService) Record(ctx context.Context, actorID *uuid.UUID, action, entityType string, entityID *uuid.UUID, details interface{}) error {
+	entry := &models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	if details != nil {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit details: %w", err)
+		}
+		entry.Details = string(encoded)
+	}
+
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	s.forwarder.Forward(*entry)
+	return nil
+}
+
+// List retrieves audit log entries
+func (s *AuditService) List(ctx context.Context, query *models.AuditLogQuery) (*models.AuditLogListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 50
+	}
+	return s.auditRepo.List(ctx, query)
+}
@@ -0,0 +1,266 @@
+package auditsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+)
+
+// Sink delivers audit events to a system outside the database, such as a SIEM
+type Sink interface {
+	Send(ctx context.Context, entries []models.AuditLog) error
+}
+
+// Checker is a sink that can verify it is reachable without sending an event
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// MonitoredSink is a sink of a forwarder together with when it last accepted
+// events, for integration health checks
+type MonitoredSink struct {
+	// Name is the sink's name in AUDIT_SINKS
+	Name string
+
+	sink      Sink
+	delivered atomic.Pointer[time.Time]
+}
+
+// Check verifies the sink is reachable. Sinks that cannot be checked always pass.
+func (m *MonitoredSink) Check(ctx context.Context) error {
+	if checker, ok := m.sink.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
+// LastDelivery returns when the sink last accepted a batch since the server
+// started, or nil when it has not
+func (m *MonitoredSink) LastDelivery(ctx context.Context) (*time.Time, error) {
+	return m.delivered.Load(), nil
+}
+
+// send delivers a batch to the sink, noting when it was accepted
+func (m *MonitoredSink) send(ctx context.Context, batch []models.AuditLog) error {
+	if err := m.sink.Send(ctx, batch); err != nil {
+		return err
+	}
+	now := time.Now()
+	m.delivered.Store(&now)
+	return nil
+}
+
+// sinkName returns the AUDIT_SINKS name of a sink
+func sinkName(sink Sink) string {
+	switch sink.(type) {
+	case *SyslogSink:
+		return "syslog"
+	case *HTTPSink:
+		return "http"
+	case *EventHubSink:
+		return "eventhub"
+	}
+	return fmt.Sprintf("%T", sink)
+}
+
+// maxBatch caps how many events are sent to the sinks at once
+const maxBatch = 100
+
+// sendTimeout bounds one delivery of a batch to the sinks
+const sendTimeout = 30 * time.Second
+
+// New creates the forwarder for the sinks selected by configuration. It
+// returns nil when no sink is configured.
+func New(ctx context.Context, cfg config.AuditConfig, secretsProvider secrets.Provider) (*Forwarder, error) {
+	var sinks []Sink
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "syslog":
+			if cfg.SyslogAddress == "" {
+				return nil, fmt.Errorf("AUDIT_SYSLOG_ADDRESS is required")
+			}
+			sink, err := NewSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddress)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "http":
+			if cfg.HTTPURL == "" {
+				return nil, fmt.Errorf("AUDIT_HTTP_URL is required")
+			}
+			token, err := secretsProvider.Get(ctx, cfg.HTTPTokenSecret)
+			if err != nil {
+				return nil, err
+			}
+			sink, err := NewHTTPSink(cfg.HTTPURL, cfg.HTTPFormat, token)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "eventhub":
+			if cfg.EventHubEndpoint == "" || cfg.EventHubName == "" {
+				return nil, fmt.Errorf("AUDIT_EVENTHUB_ENDPOINT and AUDIT_EVENTHUB_NAME are required")
+			}
+			key, err := secretsProvider.Get(ctx, cfg.EventHubKeySecret)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, NewEventHubSink(cfg.EventHubEndpoint, cfg.EventHubName, cfg.EventHubKeyName, key))
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	interval, err := time.ParseDuration(cfg.FlushInterval)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid AUDIT_SINK_FLUSH_INTERVAL %q", cfg.FlushInterval)
+	}
+	return NewForwarder(sinks, cfg.BufferSize, interval), nil
+}
+
+// Forwarder queues audit events and forwards them to its sinks in the
+// background, in batches, so recording an event never waits on a SIEM. The
+// database stays the system of record: events are dropped, and logged, when
+// the queue is full or a sink fails.
+type Forwarder struct {
+	sinks    []*MonitoredSink
+	queue    chan models.AuditLog
+	interval time.Duration
+	done     chan struct{}
+	close    sync.Once
+
+	mu        sync.Mutex
+	onFailure func(sink string, batch []models.AuditLog, err error)
+}
+
+// NewForwarder creates a forwarder holding up to bufferSize waiting events and
+// starts forwarding them every interval
+func NewForwarder(sinks []Sink, bufferSize int, interval time.Duration) *Forwarder {
+	f := &Forwarder{
+		queue:    make(chan models.AuditLog, max(bufferSize, 1)),
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	for _, sink := range sinks {
+		f.sinks = append(f.sinks, &MonitoredSink{Name: sinkName(sink), sink: sink})
+	}
+	go f.run()
+	return f
+}
+
+// Sinks returns the forwarder's sinks, in configuration order
+func (f *Forwarder) Sinks() []*MonitoredSink {
+	if f == nil {
+		return nil
+	}
+	return f.sinks
+}
+
+// OnFailure sets a function called with each batch a sink failed to accept,
+// so it can be kept for a later retry
+func (f *Forwarder) OnFailure(fn func(sink string, batch []models.AuditLog, err error)) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onFailure = fn
+}
+
+// Resend sends a batch that failed earlier to the named sink again
+func (f *Forwarder) Resend(ctx context.Context, sinkName string, batch []models.AuditLog) error {
+	for _, sink := range f.Sinks() {
+		if sink.Name == sinkName {
+			return sink.send(ctx, batch)
+		}
+	}
+	return fmt.Errorf("audit sink %q is not configured", sinkName)
+}
+
+// Forward queues an event for the sinks without waiting for them
+func (f *Forwarder) Forward(entry models.AuditLog) {
+	if f == nil {
+		return
+	}
+	select {
+	case f.queue <- entry:
+	default:
+		log.Printf("audit sink queue full, dropping event %s (%s)", entry.ID, entry.Action)
+	}
+}
+
+// Close forwards the events still queued and stops the forwarder, giving up
+// when ctx is done. Events forwarded after Close are lost.
+func (f *Forwarder) Close(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	f.close.Do(func() { close(f.queue) })
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run sends the queued events whenever a batch fills up or the interval passes
+func (f *Forwarder) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	var batch []models.AuditLog
+	for {
+		select {
+		case entry, ok := <-f.queue:
+			if !ok {
+				f.send(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) == maxBatch {
+				f.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			f.send(batch)
+			batch = nil
+		}
+	}
+}
+
+// send delivers a batch to every sink, logging the failures
+func (f *Forwarder) send(batch []models.AuditLog) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	var errs []error
+	f.mu.Lock()
+	onFailure := f.onFailure
+	f.mu.Unlock()
+	for _, sink := range f.sinks {
+		if err := sink.send(ctx, batch); err != nil {
+			errs = append(errs, err)
+			if onFailure != nil {
+				onFailure(sink.Name, batch, err)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("failed to forward %d audit events: %v", len(batch), err)
+	}
+}
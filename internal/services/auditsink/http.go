@@ -0,0 +1,127 @@
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// HTTPSink posts audit events to an HTTPS collector. The "json" format posts a
+// JSON array of events with the token as a bearer token; the "splunk" format
+// posts HTTP Event Collector events with the token as a Splunk token.
+type HTTPSink struct {
+	url    string
+	format string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink posting to url in the given format
+func NewHTTPSink(url, format, token string) (*HTTPSink, error) {
+	switch format {
+	case "json", "splunk":
+	default:
+		return nil, fmt.Errorf("unknown audit HTTP format %q", format)
+	}
+	return &HTTPSink{
+		url:    url,
+		format: format,
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// splunkEvent is one event of a Splunk HTTP Event Collector request
+type splunkEvent struct {
+	Time       float64         `json:"time"`
+	Source     string          `json:"source"`
+	Sourcetype string          `json:"sourcetype"`
+	Event      models.AuditLog `json:"event"`
+}
+
+// Send posts the events in one request
+func (s *HTTPSink) Send(ctx context.Context, entries []models.AuditLog) error {
+	var body bytes.Buffer
+	authorization := "Bearer " + s.token
+	switch s.format {
+	case "splunk":
+		// The collector takes a stream of concatenated event objects
+		encoder := json.NewEncoder(&body)
+		for _, entry := range entries {
+			event := splunkEvent{
+				Time:       float64(entry.CreatedAt.UnixMilli()) / 1000,
+				Source:     "helpchat",
+				Sourcetype: "helpchat:audit",
+				Event:      entry,
+			}
+			if err := encoder.Encode(event); err != nil {
+				return fmt.Errorf("failed to encode audit event: %w", err)
+			}
+		}
+		authorization = "Splunk " + s.token
+	default:
+		if err := json.NewEncoder(&body).Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode audit events: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+	return post(s.client, req, "audit collector")
+}
+
+// Check verifies the collector is reachable and does not reject the token
+func (s *HTTPSink) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.url, nil)
+	if err != nil {
+		return err
+	}
+	if s.format == "splunk" {
+		req.Header.Set("Authorization", "Splunk "+s.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return probe(s.client, req, "audit collector")
+}
+
+// post sends a request, failing on any status other than 2xx
+func post(client *http.Client, req *http.Request, target string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", target, resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// probe sends a request that delivers nothing, failing when the target cannot
+// be reached, rejects our credentials, does not exist or has an error. Other
+// statuses, such as 405 for a collector that only takes POST, show it is up.
+func probe(client *http.Client, req *http.Request, target string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%s rejected the credentials: %d", target, resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode >= 500:
+		return fmt.Errorf("%s returned %d", target, resp.StatusCode)
+	}
+	return nil
+}
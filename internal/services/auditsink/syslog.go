@@ -0,0 +1,139 @@
+package auditsink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// syslogPriority is the log audit facility (13) at informational severity (6)
+const syslogPriority = 13*8 + 6
+
+// SyslogSink sends each audit event as an RFC 5424 syslog message whose body
+// is the event as JSON. Over TCP and TLS messages are framed by octet counting
+// (RFC 6587) on a connection that is redialled after a failure.
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink creates a syslog sink sending over "udp", "tcp" or "tls"
+func NewSyslogSink(network, address string) (*SyslogSink, error) {
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unknown syslog network %q", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{network: network, address: address, hostname: hostname}, nil
+}
+
+// Send writes one syslog message per event
+func (s *SyslogSink) Send(ctx context.Context, entries []models.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		message, err := s.format(entry)
+		if err != nil {
+			return err
+		}
+		if s.network != "udp" {
+			message = append([]byte(fmt.Sprintf("%d ", len(message))), message...)
+		}
+		if err := s.write(ctx, message); err != nil {
+			return fmt.Errorf("failed to send audit event to syslog: %w", err)
+		}
+	}
+	return nil
+}
+
+// format renders an event as an RFC 5424 message with the action as its MSGID
+func (s *SyslogSink) format(entry models.AuditLog) ([]byte, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	header := fmt.Sprintf("<%d>1 %s %s helpchat %d %s - ",
+		syslogPriority, entry.CreatedAt.UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), syslogToken(entry.Action, 32))
+	return append([]byte(header), body...), nil
+}
+
+// write sends a message, dialling first when there is no connection and
+// retrying once on a fresh connection when a kept one has gone stale
+func (s *SyslogSink) write(ctx context.Context, message []byte) error {
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			conn, err := s.dial(ctx)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			s.conn.SetWriteDeadline(deadline)
+		}
+		_, err := s.conn.Write(message)
+		if err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// Check verifies the syslog server accepts connections. Over UDP nothing is
+// sent, so only the address is checked.
+func (s *SyslogSink) Check(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return conn.Close()
+}
+
+// dial connects to the syslog server
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	if s.network == "tls" {
+		dialer := &tls.Dialer{}
+		return dialer.DialContext(ctx, "tcp", s.address)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, s.network, s.address)
+}
+
+// syslogToken makes value a valid syslog header field: printable ASCII without
+// spaces, at most length characters, or "-" when empty
+func syslogToken(value string, length int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > length {
+		value = value[:length]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
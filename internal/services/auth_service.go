@@ -125,7 +125,7 @@ func (s *AuthService) RefreshToken(refreshToken string) (*models.TokenResponse,
 
 	// Get user
 	user, err := s.userRepo.GetByID(userIDStr)
-	if err != nil {
+	if err != nil || user == nil {
 		return nil, fmt.Errorf("user not found")
 	}
 
@@ -261,7 +261,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
 
 	// Get user from database
 	user, err := s.userRepo.GetByID(userIDStr)
-	if err != nil {
+	if err != nil || user == nil {
 		return nil, fmt.Errorf("user not found")
 	}
 
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/officehours"
+)
+
+// MaxBusinessDays caps how far ahead business days are counted
+const MaxBusinessDays = 365
+
+// BusinessCalendarService does business-day arithmetic on the office hours and
+// holidays, so due dates such as "3 business days from now" are computed the
+// same way everywhere
+type BusinessCalendarService struct {
+	schedule atomic.Pointer[officehours.Schedule]
+}
+
+// NewBusinessCalendarService creates a new business calendar service. It fails
+// when the configured office hours or holidays cannot be parsed.
+func NewBusinessCalendarService(cfg *config.Config) (*BusinessCalendarService, error) {
+	s := &BusinessCalendarService{}
+	if err := s.Reload(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload applies the office hours and holidays of cfg, keeping the current ones
+// when they cannot be parsed
+func (s *BusinessCalendarService) Reload(cfg *config.Config) error {
+	schedule, err := parseOfficeSchedule(cfg)
+	if err != nil {
+		return err
+	}
+	s.schedule.Store(schedule)
+	return nil
+}
+
+// AddBusinessDays returns the close of business on the given number of
+// business days after the day of from, in the office time zone
+func (s *BusinessCalendarService) AddBusinessDays(from time.Time, days int) (*models.BusinessDaysResponse, error) {
+	if days < 1 || days > MaxBusinessDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidInput, MaxBusinessDays)
+	}
+	schedule := s.schedule.Load()
+	due := schedule.AddBusinessDays(from, days)
+	if due.IsZero() {
+		return nil, fmt.Errorf("%w: the office hours have no business days", ErrInvalidInput)
+	}
+	return &models.BusinessDaysResponse{
+		From:     from,
+		Days:     days,
+		Due:      due,
+		Timezone: schedule.Location.String(),
+	}, nil
+}
+
+// parseOfficeSchedule parses the office hours and holidays of cfg
+func parseOfficeSchedule(cfg *config.Config) (*officehours.Schedule, error) {
+	schedule, err := officehours.Parse(cfg.Widget.OfficeHours, cfg.Widget.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid office hours: %w", err)
+	}
+	if err := schedule.AddHolidays(cfg.Widget.Holidays); err != nil {
+		return nil, fmt.Errorf("invalid office holidays: %w", err)
+	}
+	return schedule, nil
+}
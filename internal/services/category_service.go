@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CategoryService manages ticket category settings
+type CategoryService struct {
+	categoryRepo repository.CategoryRepository
+}
+
+// NewCategoryService creates a new category service
+func NewCategoryService(categoryRepo repository.CategoryRepository) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// ListCategories retrieves every category, active or not
+func (s *CategoryService) ListCategories(ctx context.Context) ([]models.Category, error) {
+	return s.categoryRepo.List(ctx)
+}
+
+// SetResolutionRequirements sets the details a category's tickets need
+// before they can be resolved; an empty list lifts the requirements
+func (s *CategoryService) SetResolutionRequirements(ctx context.Context, id uuid.UUID, req *models.ResolutionRequirementsRequest) (*models.Category, error) {
+	var fields []models.ResolutionField
+	for _, field := range req.RequiredFields {
+		if !field.IsValid() {
+			return nil, fmt.Errorf("%w: unknown resolution field %q; use resolution_code, root_cause, root_cause_code or knowledge_article_id", ErrInvalidInput, field)
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	if _, err := s.getCategory(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.categoryRepo.SetRequiredResolutionFields(ctx, id, fields); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	return s.getCategory(ctx, id)
+}
+
+// getCategory retrieves a category, failing with ErrNotFound when it does not exist
+func (s *CategoryService) getCategory(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	category, err := s.categoryRepo.GetWithChildren(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("category %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
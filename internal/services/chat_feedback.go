@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+)
+
+// defaultCSATReportDays is the period the chat CSAT report covers unless asked otherwise
+const defaultCSATReportDays = 30
+
+// RequestTranscript opts the visitor in to an emailed copy of the chat. It is
+// sent when the chat ends, or straight away if it already has.
+func (s *ChatService) RequestTranscript(ctx context.Context, id uuid.UUID, token string) (*models.ChatSession, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+	if session.VisitorEmail == "" {
+		return nil, fmt.Errorf("%w: the chat has no email address to send the transcript to", ErrInvalidInput)
+	}
+	if session.TranscriptSentAt != nil {
+		return session, nil
+	}
+
+	session.TranscriptRequested = true
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to request transcript: %w", err)
+	}
+	if session.Status == models.ChatEnded {
+		if err := s.sendTranscript(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// RateChat records the visitor's rating of a chat once it has ended
+func (s *ChatService) RateChat(ctx context.Context, id uuid.UUID, token string, req *models.RateChatRequest) (*models.ChatSession, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatEnded {
+		return nil, fmt.Errorf("%w: the chat can be rated once it has ended", ErrConflict)
+	}
+	if session.RatedAt != nil {
+		return nil, fmt.Errorf("%w: the chat has already been rated", ErrConflict)
+	}
+
+	now := time.Now()
+	score := req.Score
+	session.Rating = &score
+	session.RatingComment = strings.TrimSpace(req.Comment)
+	session.RatedAt = &now
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to rate chat: %w", err)
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// CSATReport summarises the chat ratings given over the last number of days
+func (s *ChatService) CSATReport(ctx context.Context, days int) (*models.ChatCSATReport, error) {
+	if days <= 0 || days > 365 {
+		days = defaultCSATReportDays
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	ended, err := s.chatRepo.CountEndedSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ended chats: %w", err)
+	}
+	counts, err := s.chatRepo.RatingCounts(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count chat ratings: %w", err)
+	}
+
+	report := &models.ChatCSATReport{
+		Since:        since,
+		EndedChats:   ended,
+		Distribution: make(map[int]int64),
+		Agents:       []models.AgentChatCSAT{},
+	}
+	var total, satisfied int64
+	agents := make(map[uuid.UUID]*ratingTally)
+	for _, count := range counts {
+		report.RatedChats += count.Count
+		report.Distribution[count.Rating] += count.Count
+		total += int64(count.Rating) * count.Count
+		if count.Rating >= 4 {
+			satisfied += count.Count
+		}
+
+		if count.AgentID == nil {
+			continue
+		}
+		tally := agents[*count.AgentID]
+		if tally == nil {
+			tally = &ratingTally{}
+			agents[*count.AgentID] = tally
+		}
+		tally.add(count)
+	}
+	if report.RatedChats == 0 {
+		return report, nil
+	}
+
+	average := float64(total) / float64(report.RatedChats)
+	csat := float64(satisfied) / float64(report.RatedChats)
+	report.AverageRating = &average
+	report.CSAT = &csat
+	if ended > 0 {
+		report.ResponseRate = float64(report.RatedChats) / float64(ended)
+	}
+
+	for agentID, tally := range agents {
+		name := "Former agent"
+		if agent, err := s.userRepo.GetByID(agentID.String()); err == nil && agent != nil {
+			name = agent.FullName()
+		}
+		report.Agents = append(report.Agents, models.AgentChatCSAT{
+			AgentID:       agentID,
+			Name:          name,
+			RatedChats:    tally.count,
+			AverageRating: float64(tally.total) / float64(tally.count),
+			CSAT:          float64(tally.satisfied) / float64(tally.count),
+		})
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		return report.Agents[i].Name < report.Agents[j].Name
+	})
+	return report, nil
+}
+
+// ratingTally accumulates one agent's ratings
+type ratingTally struct {
+	count, total, satisfied int64
+}
+
+// add counts a group of ratings with the same score
+func (t *ratingTally) add(count models.ChatRatingCount) {
+	t.count += count.Count
+	t.total += int64(count.Rating) * count.Count
+	if count.Rating >= 4 {
+		t.satisfied += count.Count
+	}
+}
+
+// sendTranscript emails the visitor a copy of the conversation
+func (s *ChatService) sendTranscript(ctx context.Context, session *models.ChatSession) error {
+	// Reload the messages so the transcript includes the closing note
+	current, err := s.GetChat(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if current.VisitorName != "" {
+		fmt.Fprintf(&b, "Hi %s,\n\n", current.VisitorName)
+	}
+	subject := "Your chat transcript"
+	if s.title != "" {
+		subject += " from " + s.title
+	}
+	b.WriteString("Here is a copy of your chat with us.\n\n")
+	b.WriteString(transcript(current))
+
+	msg := &email.Message{
+		To:      []string{current.VisitorEmail},
+		Subject: subject,
+		Text:    b.String(),
+	}
+	if err := s.sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send chat transcript: %w", err)
+	}
+
+	now := time.Now()
+	session.TranscriptSentAt = &now
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to update chat: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"github.com/google/uuid"
+)
+
+// SubscribeVisitor follows a chat's events for the visitor holding its token.
+// Staff-only messages are not delivered.
+func (s *ChatService) SubscribeVisitor(ctx context.Context, id uuid.UUID, token string) (*realtime.Subscription, error) {
+	if err := s.authorizeVisitor(id, token); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetChat(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.hub.Subscribe(chatTopic(id), false), nil
+}
+
+// SubscribeStaff follows all of a chat's events for an agent or supervisor
+func (s *ChatService) SubscribeStaff(ctx context.Context, id uuid.UUID) (*realtime.Subscription, error) {
+	if _, err := s.GetChat(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.hub.Subscribe(chatTopic(id), true), nil
+}
+
+// HandleVisitorFrame applies a typing or receipt frame from the visitor's client
+func (s *ChatService) HandleVisitorFrame(ctx context.Context, id uuid.UUID, sub *realtime.Subscription, frame *models.ChatClientFrame) error {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.handleFrame(ctx, session, sub, models.SenderVisitor, nil, frame)
+}
+
+// HandleStaffFrame applies a typing or receipt frame from a staff client.
+// Supervisors who are only monitoring stay silent: their frames are ignored.
+func (s *ChatService) HandleStaffFrame(ctx context.Context, id uuid.UUID, sub *realtime.Subscription, user *models.User, frame *models.ChatClientFrame) error {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !canSpeak(session, user.ID) {
+		return nil
+	}
+	return s.handleFrame(ctx, session, sub, models.SenderAgent, &user.ID, frame)
+}
+
+// handleFrame relays typing to the other participants, and persists and
+// relays receipts for the other side's messages
+func (s *ChatService) handleFrame(ctx context.Context, session *models.ChatSession, sub *realtime.Subscription, sender models.ChatSenderType, senderID *uuid.UUID, frame *models.ChatClientFrame) error {
+	switch frame.Type {
+	case models.ChatEventTypingStart, models.ChatEventTypingStop:
+		if session.Status == models.ChatEnded {
+			return nil
+		}
+		event := &models.ChatEvent{Type: frame.Type, ChatID: session.ID, Sender: sender, SenderID: senderID}
+		s.hub.Publish(chatTopic(session.ID), event, func(other *realtime.Subscription) bool {
+			return other != sub
+		})
+		return nil
+
+	case models.ChatEventDelivered, models.ChatEventRead:
+		if frame.MessageID == nil {
+			return fmt.Errorf("%w: message_id is required", ErrInvalidInput)
+		}
+		var upTo *models.ChatMessage
+		for i := range session.Messages {
+			if session.Messages[i].ID == *frame.MessageID {
+				upTo = &session.Messages[i]
+				break
+			}
+		}
+		if upTo == nil {
+			return fmt.Errorf("%w: message not found in this chat", ErrInvalidInput)
+		}
+
+		// Each side acknowledges what the other side said
+		senders := []models.ChatSenderType{models.SenderVisitor}
+		if sender == models.SenderVisitor {
+			senders = []models.ChatSenderType{models.SenderBot, models.SenderAgent, models.SenderSystem}
+		}
+
+		now := time.Now()
+		ids, err := s.chatRepo.AcknowledgeMessages(ctx, session.ID, senders, upTo.CreatedAt, frame.Type == models.ChatEventRead, now)
+		if err != nil {
+			return fmt.Errorf("failed to save receipts: %w", err)
+		}
+		if len(ids) > 0 {
+			event := &models.ChatEvent{Type: frame.Type, ChatID: session.ID, Sender: sender, SenderID: senderID, MessageIDs: ids, At: &now}
+			s.hub.Publish(chatTopic(session.ID), event, nil)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown frame type %q", ErrInvalidInput, frame.Type)
+}
+
+// publishMessage pushes a new transcript message to the chat's subscribers
+func (s *ChatService) publishMessage(session *models.ChatSession, message *models.ChatMessage) {
+	event := &models.ChatEvent{Type: models.ChatEventMessage, ChatID: session.ID, Status: session.Status, Message: message}
+	s.hub.Publish(chatTopic(session.ID), event, func(sub *realtime.Subscription) bool {
+		return sub.Staff || !message.Internal
+	})
+}
+
+// chatTopic is the hub topic a chat's events are published on
+func chatTopic(id uuid.UUID) string {
+	return "chat:" + id.String()
+}
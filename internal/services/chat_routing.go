@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// agentLoad is an agent's live chat load against their limit
+type agentLoad struct {
+	agent  *models.User
+	active int64
+	max    int
+}
+
+// free returns how many more chats the agent can take
+func (l *agentLoad) free() int64 {
+	return int64(l.max) - l.active
+}
+
+// CapacityReport shows the live chat load of every agent against their limit
+func (s *ChatService) CapacityReport(ctx context.Context) (*models.ChatCapacityReport, error) {
+	agents, err := s.userRepo.ListAgents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	available, err := s.availableLoads(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.chatRepo.CountActiveByAgent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active chats: %w", err)
+	}
+	queued, err := s.chatRepo.CountByStatus(ctx, models.ChatQueued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queued chats: %w", err)
+	}
+
+	isAvailable := make(map[uuid.UUID]bool, len(available))
+	report := &models.ChatCapacityReport{Agents: make([]models.AgentChatCapacity, 0, len(agents)), QueuedChats: queued}
+	for _, load := range available {
+		isAvailable[load.agent.ID] = true
+		report.Capacity += int64(load.max)
+	}
+	for _, agent := range agents {
+		report.Agents = append(report.Agents, models.AgentChatCapacity{
+			AgentID:     agent.ID,
+			Name:        agent.FullName(),
+			ActiveChats: active[agent.ID],
+			MaxChats:    s.maxChats(agent),
+			Available:   isAvailable[agent.ID],
+		})
+		report.ActiveChats += active[agent.ID]
+	}
+	if report.Capacity > 0 {
+		report.Utilization = float64(report.ActiveChats) / float64(report.Capacity)
+	}
+	return report, nil
+}
+
+// SetAgentCapacity sets how many chats are routed to an agent at once and
+// routes waiting chats to any capacity that frees up
+func (s *ChatService) SetAgentCapacity(ctx context.Context, agentID uuid.UUID, req *models.SetChatCapacityRequest) (*models.AgentChatCapacity, error) {
+	agent, err := s.userRepo.GetByID(agentID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+	if agent == nil {
+		return nil, fmt.Errorf("agent %w", ErrNotFound)
+	}
+	if !agent.IsAgent() {
+		return nil, fmt.Errorf("%w: only agents handle chats", ErrInvalidInput)
+	}
+
+	agent.MaxConcurrentChats = req.MaxConcurrentChats
+	if err := s.userRepo.Update(agent); err != nil {
+		return nil, fmt.Errorf("failed to update agent: %w", err)
+	}
+	if err := s.dispatch(ctx); err != nil {
+		return nil, err
+	}
+
+	report, err := s.CapacityReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, capacity := range report.Agents {
+		if capacity.AgentID == agent.ID {
+			return &capacity, nil
+		}
+	}
+	// Inactive agents are left out of the report
+	return &models.AgentChatCapacity{AgentID: agent.ID, Name: agent.FullName(), MaxChats: s.maxChats(agent)}, nil
+}
+
+// dispatch routes queued chats, longest-waiting first, to the available agents
+// with the most free capacity. Chats stay queued while every agent is full.
+func (s *ChatService) dispatch(ctx context.Context) error {
+	queued, err := s.chatRepo.List(ctx, models.ChatQueued)
+	if err != nil {
+		return fmt.Errorf("failed to list queued chats: %w", err)
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	loads, err := s.availableLoads(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	teams := make(map[uuid.UUID][]uuid.UUID)
+	for i := range queued {
+		session := &queued[i]
+		var best *agentLoad
+		for j := range loads {
+			load := &loads[j]
+			if load.free() <= 0 || (best != nil && load.free() <= best.free()) {
+				continue
+			}
+			// A chat is not routed back to the agent who transferred it away
+			if session.TransferredFromID != nil && *session.TransferredFromID == load.agent.ID {
+				continue
+			}
+			// A chat transferred to a team only goes to its members
+			if session.TeamID != nil {
+				teamIDs, ok := teams[load.agent.ID]
+				if !ok {
+					if teamIDs, err = s.teamRepo.GetTeamIDsForUser(ctx, load.agent.ID); err != nil {
+						return fmt.Errorf("failed to get agent teams: %w", err)
+					}
+					teams[load.agent.ID] = teamIDs
+				}
+				if !slices.Contains(teamIDs, *session.TeamID) {
+					continue
+				}
+			}
+			best = load
+		}
+		if best == nil {
+			continue
+		}
+
+		if err := s.assign(ctx, session, best.agent); err != nil {
+			return err
+		}
+		best.active++
+	}
+	return nil
+}
+
+// TransferChat hands an active chat to another agent, or back to the queue for
+// a team's members. The note gives the next agent context and is only shown to staff.
+func (s *ChatService) TransferChat(ctx context.Context, id uuid.UUID, actor *models.User, req *models.TransferChatRequest) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatActive {
+		return nil, fmt.Errorf("%w: only active chats can be transferred", ErrConflict)
+	}
+	if (session.AgentID == nil || *session.AgentID != actor.ID) && !actor.IsAdmin() {
+		return nil, fmt.Errorf("%w: the chat is handled by another agent", ErrForbidden)
+	}
+	if (req.AgentID == nil) == (req.TeamID == nil) {
+		return nil, fmt.Errorf("%w: give either agent_id or team_id", ErrInvalidInput)
+	}
+
+	note := strings.TrimSpace(req.Note)
+	from := session.AgentID
+	if req.AgentID != nil {
+		return s.transferToAgent(ctx, session, actor, *req.AgentID, note)
+	}
+
+	team, err := s.teamRepo.GetByID(ctx, *req.TeamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: team not found", ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	now := time.Now()
+	session.Status = models.ChatQueued
+	session.AgentID = nil
+	session.Agent = nil
+	session.TeamID = &team.ID
+	session.TransferredFromID = from
+	session.QueuedAt = &now
+	session.AcceptedAt = nil
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to transfer chat: %w", err)
+	}
+	if err := s.recordQueueEvent(ctx, session, models.QueueEventJoined); err != nil {
+		return nil, err
+	}
+	if err := s.addNote(ctx, session, transferNote(actor, "the "+team.Name+" team", note)); err != nil {
+		return nil, err
+	}
+	if err := s.addMessage(ctx, session, models.SenderSystem, nil, "You're being transferred to another agent. Please hold on."); err != nil {
+		return nil, err
+	}
+	if err := s.dispatch(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// transferToAgent hands an active chat straight to another agent with capacity
+func (s *ChatService) transferToAgent(ctx context.Context, session *models.ChatSession, actor *models.User, agentID uuid.UUID, note string) (*models.ChatSession, error) {
+	target, err := s.userRepo.GetByID(agentID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+	if target == nil || !target.IsActive || !target.IsAgent() {
+		return nil, fmt.Errorf("%w: agent not found", ErrInvalidInput)
+	}
+	if session.AgentID != nil && *session.AgentID == target.ID {
+		return nil, fmt.Errorf("%w: the chat is already handled by that agent", ErrInvalidInput)
+	}
+
+	active, err := s.chatRepo.CountActiveByAgent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active chats: %w", err)
+	}
+	if active[target.ID] >= int64(s.maxChats(target)) {
+		return nil, fmt.Errorf("%w: %s is already handling their maximum number of chats", ErrConflict, target.FullName())
+	}
+
+	session.TransferredFromID = session.AgentID
+	session.AgentID = &target.ID
+	session.Agent = nil
+	session.TeamID = nil
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to transfer chat: %w", err)
+	}
+	if err := s.addNote(ctx, session, transferNote(actor, target.FullName(), note)); err != nil {
+		return nil, err
+	}
+	if err := s.addMessage(ctx, session, models.SenderSystem, nil, target.FirstName+" joined the chat."); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, session.ID)
+}
+
+// transferNote describes a transfer for the transcript
+func transferNote(actor *models.User, to, note string) string {
+	text := fmt.Sprintf("%s transferred the chat to %s.", actor.FullName(), to)
+	if note != "" {
+		text += " Note: " + note
+	}
+	return text
+}
+
+// assign gives a queued chat to an agent
+func (s *ChatService) assign(ctx context.Context, session *models.ChatSession, agent *models.User) error {
+	now := time.Now()
+	session.Status = models.ChatActive
+	session.AgentID = &agent.ID
+	session.AcceptedAt = &now
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to assign chat: %w", err)
+	}
+	if err := s.recordQueueEvent(ctx, session, models.QueueEventAccepted); err != nil {
+		return err
+	}
+	return s.addMessage(ctx, session, models.SenderSystem, nil, agent.FirstName+" joined the chat.")
+}
+
+// availableLoads returns the load of each agent who can take chats now: they
+// are online and not out of office
+func (s *ChatService) availableLoads(ctx context.Context, at time.Time) ([]agentLoad, error) {
+	agents, err := s.widget.OnlineAgents(at)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.chatRepo.CountActiveByAgent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active chats: %w", err)
+	}
+
+	loads := make([]agentLoad, len(agents))
+	for i, agent := range agents {
+		loads[i] = agentLoad{agent: agent, active: active[agent.ID], max: s.maxChats(agent)}
+	}
+	return loads, nil
+}
+
+// maxChats returns the agent's concurrent chat limit
+func (s *ChatService) maxChats(agent *models.User) int {
+	if agent.MaxConcurrentChats != nil {
+		return *agent.MaxConcurrentChats
+	}
+	return s.defaultMaxChats
+}
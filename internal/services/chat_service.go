@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/signedtoken"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// queueWaitHistory is how far back accepted chats count towards the average wait
+const queueWaitHistory = 24 * time.Hour
+
+// ChatService runs live chats: the auto-responder, the hand-off queue and agent conversations
+type ChatService struct {
+	chatRepo        repository.ChatRepository
+	userRepo        repository.UserRepository
+	teamRepo        repository.TeamRepository
+	knowledge       *KnowledgeService
+	widget          *WidgetService
+	intake          *IntakeService
+	presence        *PresenceService
+	hub             *realtime.Hub
+	sender          email.EmailSender
+	signer          *signedtoken.Signer
+	tokenTTL        time.Duration
+	greeting        string
+	title           string
+	defaultMaxChats int
+}
+
+// NewChatService creates a new chat service
+func NewChatService(chatRepo repository.ChatRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository, knowledge *KnowledgeService, widget *WidgetService, intake *IntakeService, presence *PresenceService, hub *realtime.Hub, sender email.EmailSender, config *config.Config) *ChatService {
+	tokenTTL, err := time.ParseDuration(config.Chat.VisitorTokenTTL)
+	if err != nil {
+		tokenTTL = 24 * time.Hour // fallback
+	}
+
+	s := &ChatService{
+		chatRepo:        chatRepo,
+		userRepo:        userRepo,
+		teamRepo:        teamRepo,
+		knowledge:       knowledge,
+		widget:          widget,
+		intake:          intake,
+		presence:        presence,
+		hub:             hub,
+		sender:          sender,
+		signer:          signedtoken.NewSigner(config.Chat.SecretKey, "chat-visitor"),
+		tokenTTL:        tokenTTL,
+		greeting:        config.Widget.Greeting,
+		title:           config.Widget.Title,
+		defaultMaxChats: config.Chat.MaxConcurrentChats,
+	}
+
+	// Chats waiting in the queue go to agents as they come online
+	presence.OnOnline(func(userID uuid.UUID) {
+		if err := s.dispatch(context.Background()); err != nil {
+			log.Printf("failed to route queued chats after agent %s came online: %v", userID, err)
+		}
+	})
+	return s
+}
+
+// StartChat opens a chat with the auto-responder and returns the token the
+// visitor uses to continue it
+func (s *ChatService) StartChat(ctx context.Context, req *models.StartChatRequest, remoteIP string) (*models.StartChatResponse, error) {
+	session := &models.ChatSession{
+		Status:       models.ChatBot,
+		VisitorName:  strings.TrimSpace(req.Name),
+		VisitorEmail: strings.TrimSpace(req.Email),
+		RemoteIP:     remoteIP,
+	}
+	if err := s.chatRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to start chat: %w", err)
+	}
+
+	if s.greeting != "" {
+		if err := s.botSay(ctx, session, s.greeting); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.askNext(ctx, session); err != nil {
+		return nil, err
+	}
+
+	session, err := s.GetChat(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StartChatResponse{
+		Session: forVisitor(session),
+		Token:   s.signer.Sign(session.ID[:], time.Now().Add(s.tokenTTL)),
+	}, nil
+}
+
+// GetVisitorChat retrieves a chat and its transcript, without staff-only
+// messages, for the visitor holding its token
+func (s *ChatService) GetVisitorChat(ctx context.Context, id uuid.UUID, token string) (*models.ChatSession, error) {
+	if err := s.authorizeVisitor(id, token); err != nil {
+		return nil, err
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// SendVisitorMessage posts a visitor's message. While the auto-responder has
+// the chat it replies straight away; the returned chat includes its replies.
+func (s *ChatService) SendVisitorMessage(ctx context.Context, id uuid.UUID, token string, req *models.SendChatMessageRequest) (*models.ChatSession, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.ChatEnded {
+		return nil, fmt.Errorf("%w: the chat has ended", ErrConflict)
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if err := s.addMessage(ctx, session, models.SenderVisitor, nil, content); err != nil {
+		return nil, err
+	}
+
+	if session.Status == models.ChatBot {
+		if err := s.botRespond(ctx, session, content); err != nil {
+			return nil, err
+		}
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// EndVisitorChat ends a chat at the visitor's request
+func (s *ChatService) EndVisitorChat(ctx context.Context, id uuid.UUID, token string) (*models.ChatSession, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.end(ctx, session, "The visitor left the chat.", models.QueueEventAbandoned); err != nil {
+		return nil, err
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// GetChat retrieves a chat and its transcript
+func (s *ChatService) GetChat(ctx context.Context, id uuid.UUID) (*models.ChatSession, error) {
+	session, err := s.chatRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("chat %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+	return session, nil
+}
+
+// ListChats retrieves chats, optionally filtered by status; queued chats are
+// listed longest-waiting first
+func (s *ChatService) ListChats(ctx context.Context, status models.ChatStatus) ([]models.ChatSession, error) {
+	return s.chatRepo.List(ctx, status)
+}
+
+// AcceptChat lets an agent pick up a queued chat, within their concurrent chat limit
+func (s *ChatService) AcceptChat(ctx context.Context, id uuid.UUID, agent *models.User) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatQueued {
+		return nil, fmt.Errorf("%w: only queued chats can be accepted", ErrConflict)
+	}
+
+	active, err := s.chatRepo.CountActiveByAgent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active chats: %w", err)
+	}
+	if active[agent.ID] >= int64(s.maxChats(agent)) {
+		return nil, fmt.Errorf("%w: you are already handling your maximum number of chats", ErrConflict)
+	}
+
+	if err := s.assign(ctx, session, agent); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// SendAgentMessage posts a message from the agent handling a chat
+func (s *ChatService) SendAgentMessage(ctx context.Context, id uuid.UUID, agent *models.User, req *models.SendChatMessageRequest) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatActive {
+		return nil, fmt.Errorf("%w: the chat is not active", ErrConflict)
+	}
+	if !canSpeak(session, agent.ID) {
+		return nil, fmt.Errorf("%w: the chat is handled by another agent", ErrForbidden)
+	}
+
+	if err := s.addMessage(ctx, session, models.SenderAgent, &agent.ID, strings.TrimSpace(req.Content)); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// EndChat ends a chat from the agent side
+func (s *ChatService) EndChat(ctx context.Context, id uuid.UUID, agent *models.User) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.AgentID != nil && *session.AgentID != agent.ID && !agent.IsAdmin() {
+		return nil, fmt.Errorf("%w: the chat is handled by another agent", ErrForbidden)
+	}
+	if err := s.end(ctx, session, agent.FirstName+" ended the chat.", models.QueueEventAbandoned); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// MonitorChat lets a supervisor silently follow a chat. The visitor is not
+// told; the transcript records it for staff.
+func (s *ChatService) MonitorChat(ctx context.Context, id uuid.UUID, supervisor *models.User) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.ChatEnded {
+		return nil, fmt.Errorf("%w: the chat has ended", ErrConflict)
+	}
+	if participant := findParticipant(session, supervisor.ID); participant != nil && participant.Mode == models.ParticipantJoined {
+		return nil, fmt.Errorf("%w: you have already joined the chat", ErrConflict)
+	}
+
+	if err := s.chatRepo.SetParticipant(ctx, &models.ChatParticipant{SessionID: id, UserID: supervisor.ID, Mode: models.ParticipantMonitoring}); err != nil {
+		return nil, fmt.Errorf("failed to monitor chat: %w", err)
+	}
+	if err := s.addNote(ctx, session, supervisor.FullName()+" started monitoring the chat."); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// JoinChat lets a supervisor barge in on an active chat and message the
+// visitor alongside the assigned agent
+func (s *ChatService) JoinChat(ctx context.Context, id uuid.UUID, supervisor *models.User) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatActive {
+		return nil, fmt.Errorf("%w: only active chats can be joined", ErrConflict)
+	}
+	if canSpeak(session, supervisor.ID) {
+		return nil, fmt.Errorf("%w: you are already in the chat", ErrConflict)
+	}
+
+	if err := s.chatRepo.SetParticipant(ctx, &models.ChatParticipant{SessionID: id, UserID: supervisor.ID, Mode: models.ParticipantJoined}); err != nil {
+		return nil, fmt.Errorf("failed to join chat: %w", err)
+	}
+	if err := s.addMessage(ctx, session, models.SenderSystem, nil, supervisor.FirstName+" joined the chat."); err != nil {
+		return nil, err
+	}
+	return s.GetChat(ctx, id)
+}
+
+// QueueStatus tells the visitor holding the chat token where they stand in the agent queue
+func (s *ChatService) QueueStatus(ctx context.Context, id uuid.UUID, token string) (*models.ChatQueueStatus, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.ChatQueueStatus{Status: session.Status}
+	if session.Status != models.ChatQueued {
+		return status, nil
+	}
+
+	if status.Position, err = s.chatRepo.QueuePosition(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to get queue position: %w", err)
+	}
+	average, err := s.chatRepo.AverageQueueWait(ctx, time.Now().Add(-queueWaitHistory))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get average wait: %w", err)
+	}
+	if average != nil {
+		averageWait := int64(math.Round(*average))
+		estimatedWait := averageWait * status.Position
+		status.AverageWaitSeconds = &averageWait
+		status.EstimatedWaitSeconds = &estimatedWait
+	}
+	return status, nil
+}
+
+// LeaveQueue takes a waiting visitor out of the agent queue and turns the
+// conversation into a ticket instead
+func (s *ChatService) LeaveQueue(ctx context.Context, id uuid.UUID, token string) (*models.ChatSession, error) {
+	session, err := s.GetVisitorChat(ctx, id, token)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.ChatQueued {
+		return nil, fmt.Errorf("%w: only queued chats can be converted to a ticket", ErrConflict)
+	}
+
+	if err := s.createTicket(ctx, session); err != nil {
+		return nil, err
+	}
+	if err := s.addMessage(ctx, session, models.SenderBot, nil, fmt.Sprintf("No problem. I've passed this conversation on as a support request and we'll reply to %s.", session.VisitorEmail)); err != nil {
+		return nil, err
+	}
+	if err := s.end(ctx, session, "The visitor left the queue and the chat was converted to a support request.", models.QueueEventConverted); err != nil {
+		return nil, err
+	}
+	return s.visitorChat(ctx, id)
+}
+
+// enqueue hands a chat to the agent queue
+func (s *ChatService) enqueue(ctx context.Context, session *models.ChatSession) error {
+	now := time.Now()
+	session.Status = models.ChatQueued
+	session.BotStep = ""
+	session.QueuedAt = &now
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to queue chat: %w", err)
+	}
+	return s.recordQueueEvent(ctx, session, models.QueueEventJoined)
+}
+
+// createTicket turns the conversation so far into a ticket through the intake
+// screening used for guest submissions
+func (s *ChatService) createTicket(ctx context.Context, session *models.ChatSession) error {
+	// Reload the messages so the transcript includes everything said so far
+	current, err := s.GetChat(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+	session.Messages = current.Messages
+
+	ticket, err := s.intake.SubmitChat(ctx, session, transcript(session))
+	if err != nil {
+		return fmt.Errorf("failed to create ticket from chat: %w", err)
+	}
+	if ticket != nil {
+		session.TicketID = &ticket.ID
+	}
+	return nil
+}
+
+// recordQueueEvent records a queue event, timing the wait from when the chat joined the queue
+func (s *ChatService) recordQueueEvent(ctx context.Context, session *models.ChatSession, eventType models.ChatQueueEventType) error {
+	event := &models.ChatQueueEvent{SessionID: session.ID, Type: eventType}
+	if eventType != models.QueueEventJoined && session.QueuedAt != nil {
+		event.WaitSeconds = int64(time.Since(*session.QueuedAt).Seconds())
+	}
+	if err := s.chatRepo.AddQueueEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record queue event: %w", err)
+	}
+	return nil
+}
+
+// end closes a chat, recording why in the transcript. A chat still waiting in
+// the queue also records queueOutcome as the way it left the queue.
+func (s *ChatService) end(ctx context.Context, session *models.ChatSession, note string, queueOutcome models.ChatQueueEventType) error {
+	if session.Status == models.ChatEnded {
+		return fmt.Errorf("%w: the chat has already ended", ErrConflict)
+	}
+	if session.Status == models.ChatQueued && queueOutcome != "" {
+		if err := s.recordQueueEvent(ctx, session, queueOutcome); err != nil {
+			return err
+		}
+	}
+
+	freesAgent := session.Status == models.ChatActive
+	now := time.Now()
+	session.Status = models.ChatEnded
+	session.BotStep = ""
+	session.EndedAt = &now
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to end chat: %w", err)
+	}
+	if err := s.addMessage(ctx, session, models.SenderSystem, nil, note); err != nil {
+		return err
+	}
+
+	// A failed transcript email should not stop the chat ending; the visitor can ask again
+	if session.TranscriptRequested && session.TranscriptSentAt == nil {
+		if err := s.sendTranscript(ctx, session); err != nil {
+			log.Printf("failed to send transcript of chat %s: %v", session.ID, err)
+		}
+	}
+
+	// The agent's capacity goes to the next chat waiting
+	if freesAgent {
+		return s.dispatch(ctx)
+	}
+	return nil
+}
+
+// authorizeVisitor checks that the token was issued for the chat
+func (s *ChatService) authorizeVisitor(id uuid.UUID, token string) error {
+	payload, _, err := s.signer.Verify(token, time.Now())
+	if err != nil || len(payload) != len(id) || uuid.UUID(payload) != id {
+		return fmt.Errorf("%w: invalid or expired chat token", ErrForbidden)
+	}
+	return nil
+}
+
+// addMessage appends a message to the chat transcript
+func (s *ChatService) addMessage(ctx context.Context, session *models.ChatSession, sender models.ChatSenderType, senderID *uuid.UUID, content string) error {
+	message := &models.ChatMessage{
+		SessionID:  session.ID,
+		SenderType: sender,
+		SenderID:   senderID,
+		Content:    content,
+	}
+	if err := s.chatRepo.AddMessage(ctx, message); err != nil {
+		return fmt.Errorf("failed to save chat message: %w", err)
+	}
+	s.publishMessage(session, message)
+	return nil
+}
+
+// addNote records an event in the transcript that only staff see
+func (s *ChatService) addNote(ctx context.Context, session *models.ChatSession, content string) error {
+	message := &models.ChatMessage{
+		SessionID:  session.ID,
+		SenderType: models.SenderSystem,
+		Content:    content,
+		Internal:   true,
+	}
+	if err := s.chatRepo.AddMessage(ctx, message); err != nil {
+		return fmt.Errorf("failed to save chat note: %w", err)
+	}
+	s.publishMessage(session, message)
+	return nil
+}
+
+// visitorChat retrieves a chat as the visitor sees it
+func (s *ChatService) visitorChat(ctx context.Context, id uuid.UUID) (*models.ChatSession, error) {
+	session, err := s.GetChat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return forVisitor(session), nil
+}
+
+// forVisitor strips staff-only messages and supervisors from a chat
+func forVisitor(session *models.ChatSession) *models.ChatSession {
+	messages := make([]models.ChatMessage, 0, len(session.Messages))
+	for _, message := range session.Messages {
+		if !message.Internal {
+			messages = append(messages, message)
+		}
+	}
+	session.Messages = messages
+	session.Participants = nil
+	return session
+}
+
+// findParticipant returns a supervisor's participation in a chat, if any
+func findParticipant(session *models.ChatSession, userID uuid.UUID) *models.ChatParticipant {
+	for i := range session.Participants {
+		if session.Participants[i].UserID == userID {
+			return &session.Participants[i]
+		}
+	}
+	return nil
+}
+
+// canSpeak reports whether a staff member may message the visitor: the
+// assigned agent or a supervisor who has joined
+func canSpeak(session *models.ChatSession, userID uuid.UUID) bool {
+	if session.AgentID != nil && *session.AgentID == userID {
+		return true
+	}
+	participant := findParticipant(session, userID)
+	return participant != nil && participant.Mode == models.ParticipantJoined
+}
+
+// transcript renders a chat as plain text, one message per line
+func transcript(session *models.ChatSession) string {
+	var b strings.Builder
+	for _, message := range session.Messages {
+		if message.Internal {
+			continue
+		}
+		speaker := "System"
+		switch message.SenderType {
+		case models.SenderVisitor:
+			speaker = session.VisitorName
+			if speaker == "" {
+				speaker = "Visitor"
+			}
+		case models.SenderBot:
+			speaker = "Bot"
+		case models.SenderAgent:
+			speaker = "Agent"
+			if message.SenderID != nil {
+				if name := staffName(session, *message.SenderID); name != "" {
+					speaker = name
+				}
+			}
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", message.CreatedAt.UTC().Format("2006-01-02 15:04:05"), speaker, message.Content)
+	}
+	return b.String()
+}
+
+// staffName returns the first name of the assigned agent or a supervisor in the chat
+func staffName(session *models.ChatSession, userID uuid.UUID) string {
+	if session.Agent != nil && session.Agent.ID == userID {
+		return session.Agent.FirstName
+	}
+	if participant := findParticipant(session, userID); participant != nil && participant.User != nil {
+		return participant.User.FirstName
+	}
+	return ""
+}
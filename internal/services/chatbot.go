@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// chatbotSuggestionLimit is the number of knowledge articles the bot offers
+const chatbotSuggestionLimit = 3
+
+var (
+	// humanRequestPattern matches a visitor asking to skip the bot
+	humanRequestPattern = regexp.MustCompile(`(?i)\b(agent|human|person|someone|representative)\b`)
+	// affirmativePattern matches a visitor confirming a suggestion helped
+	affirmativePattern = regexp.MustCompile(`(?i)^\s*(y|yes|yep|yeah|yup|it did|solved|thanks|thank you)\b`)
+)
+
+// botRespond advances the auto-responder's conversation with the visitor's latest message
+func (s *ChatService) botRespond(ctx context.Context, session *models.ChatSession, input string) error {
+	switch session.BotStep {
+	case models.BotStepName:
+		name := input
+		if len(name) > 100 {
+			name = name[:100]
+		}
+		session.VisitorName = name
+		return s.askNext(ctx, session)
+
+	case models.BotStepEmail:
+		address, err := mail.ParseAddress(input)
+		if err != nil {
+			return s.botSay(ctx, session, "That doesn't look like an email address. Could you check it and try again?")
+		}
+		session.VisitorEmail = address.Address
+		return s.askNext(ctx, session)
+
+	case models.BotStepIssue:
+		session.Summary = input
+		if humanRequestPattern.MatchString(input) {
+			return s.handOff(ctx, session)
+		}
+
+		articles, err := s.knowledge.Search(ctx, input, chatbotSuggestionLimit)
+		if err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			return s.handOff(ctx, session)
+		}
+
+		var b strings.Builder
+		b.WriteString("These articles might help:\n")
+		for _, article := range articles {
+			fmt.Fprintf(&b, "- %s (/api/v1/public/kb/articles/%s)\n", article.Title, article.ID)
+		}
+		b.WriteString("Did one of them answer your question? (yes/no)")
+
+		session.BotStep = models.BotStepSuggest
+		if err := s.chatRepo.Update(ctx, session); err != nil {
+			return fmt.Errorf("failed to update chat: %w", err)
+		}
+		return s.botSay(ctx, session, b.String())
+
+	case models.BotStepSuggest:
+		if affirmativePattern.MatchString(input) {
+			if err := s.botSay(ctx, session, "Great, glad that helped! Feel free to start a new chat any time."); err != nil {
+				return err
+			}
+			return s.end(ctx, session, "The chat was resolved by a knowledge article.", "")
+		}
+		return s.handOff(ctx, session)
+	}
+
+	return s.askNext(ctx, session)
+}
+
+// askNext asks for the first contact detail still missing, or for the issue
+// once the bot knows who it is talking to
+func (s *ChatService) askNext(ctx context.Context, session *models.ChatSession) error {
+	var question string
+	switch {
+	case session.VisitorName == "":
+		session.BotStep = models.BotStepName
+		question = "Before we start, what's your name?"
+	case session.VisitorEmail == "":
+		session.BotStep = models.BotStepEmail
+		question = fmt.Sprintf("Thanks, %s. What's your email address, in case we get disconnected?", session.VisitorName)
+	default:
+		session.BotStep = models.BotStepIssue
+		question = "What can we help you with today?"
+	}
+
+	if err := s.chatRepo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to update chat: %w", err)
+	}
+	return s.botSay(ctx, session, question)
+}
+
+// handOff passes a chat the bot could not resolve to a human: into the agent
+// queue when someone is available, otherwise into a ticket
+func (s *ChatService) handOff(ctx context.Context, session *models.ChatSession) error {
+	available, err := s.widget.AgentsAvailable(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if available {
+		if err := s.enqueue(ctx, session); err != nil {
+			return err
+		}
+		if err := s.botSay(ctx, session, "Let me connect you with an agent. Someone will be with you shortly."); err != nil {
+			return err
+		}
+		// Route straight to an agent with free capacity; otherwise the chat waits in the queue
+		return s.dispatch(ctx)
+	}
+
+	if err := s.createTicket(ctx, session); err != nil {
+		return err
+	}
+	if err := s.botSay(ctx, session, fmt.Sprintf("Our team isn't available right now, so I've passed this conversation on as a support request. We'll reply to %s.", session.VisitorEmail)); err != nil {
+		return err
+	}
+	return s.end(ctx, session, "The chat was converted to a support request.", "")
+}
+
+// botSay posts a message from the auto-responder
+func (s *ChatService) botSay(ctx context.Context, session *models.ChatSession, content string) error {
+	return s.addMessage(ctx, session, models.SenderBot, nil, content)
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// customDomainLookupTimeout bounds each DNS lookup of the setup checks
+const customDomainLookupTimeout = 5 * time.Second
+
+// CustomDomainService reports whether the white-label domain is set up: that
+// the settings it changes agree with it and its DNS records are in place
+type CustomDomainService struct {
+	resolver *net.Resolver
+	config   *config.Config
+}
+
+// NewCustomDomainService creates a new custom domain service. It fails when
+// CUSTOM_DOMAIN or CUSTOM_EMAIL_DOMAIN is not a plain domain name.
+func NewCustomDomainService(cfg *config.Config) (*CustomDomainService, error) {
+	if cfg.CustomDomain.Domain != "" && !validDomainName(cfg.CustomDomain.Domain) {
+		return nil, fmt.Errorf("invalid CUSTOM_DOMAIN %q: expected a domain name such as support.example.com, without a scheme, port or path", cfg.CustomDomain.Domain)
+	}
+	if cfg.CustomDomain.EmailDomain != "" && !validDomainName(cfg.CustomDomain.EmailDomain) {
+		return nil, fmt.Errorf("invalid CUSTOM_EMAIL_DOMAIN %q: expected a domain name such as example.com", cfg.CustomDomain.EmailDomain)
+	}
+
+	return &CustomDomainService{
+		resolver: net.DefaultResolver,
+		config:   cfg,
+	}, nil
+}
+
+// Status runs the setup checks of the custom domain. Settings given
+// explicitly override the ones the domain implies, so each is checked
+// against it; DNS lookups that fail count as records not yet in place.
+func (s *CustomDomainService) Status(ctx context.Context) *models.CustomDomainStatus {
+	domain := s.config.CustomDomain.Domain
+	emailDomain := s.config.CustomDomain.EmailDomain
+	status := &models.CustomDomainStatus{
+		Domain:      domain,
+		EmailDomain: emailDomain,
+		Configured:  domain != "",
+		Checks:      []models.CustomDomainCheck{},
+	}
+	if !status.Configured {
+		return status
+	}
+
+	status.Checks = append(status.Checks,
+		s.checkDNS(ctx, domain),
+		s.checkCookieDomain(domain),
+		s.checkSecureCookies(),
+		s.checkCORS(domain),
+		s.checkShareLinks(domain),
+		s.checkFromAddress(emailDomain),
+		s.checkSPF(ctx, emailDomain),
+		s.checkReplyDomain(ctx, emailDomain),
+	)
+
+	status.Ready = true
+	for _, check := range status.Checks {
+		status.Ready = status.Ready && check.OK
+	}
+	return status
+}
+
+// checkDNS checks that the domain resolves
+func (s *CustomDomainService) checkDNS(ctx context.Context, domain string) models.CustomDomainCheck {
+	ctx, cancel := context.WithTimeout(ctx, customDomainLookupTimeout)
+	defer cancel()
+
+	addresses, err := s.resolver.LookupHost(ctx, domain)
+	if err != nil || len(addresses) == 0 {
+		return models.CustomDomainCheck{Name: "dns", Detail: fmt.Sprintf("%s does not resolve yet; point it at this server with a CNAME or A record", domain)}
+	}
+	return models.CustomDomainCheck{Name: "dns", OK: true, Detail: fmt.Sprintf("%s resolves to %s", domain, strings.Join(addresses, ", "))}
+}
+
+// checkCookieDomain checks that sign-in cookies are sent to the domain
+func (s *CustomDomainService) checkCookieDomain(domain string) models.CustomDomainCheck {
+	cookieDomain := strings.ToLower(strings.TrimPrefix(s.config.JWT.CookieDomain, "."))
+	if cookieDomain != domain && !strings.HasSuffix(domain, "."+cookieDomain) {
+		return models.CustomDomainCheck{Name: "cookie_domain", Detail: fmt.Sprintf("JWT_COOKIE_DOMAIN %q does not cover %s, so sign-in cookies are not sent to it", s.config.JWT.CookieDomain, domain)}
+	}
+	return models.CustomDomainCheck{Name: "cookie_domain", OK: true, Detail: fmt.Sprintf("sign-in cookies are sent to %s", domain)}
+}
+
+// checkSecureCookies checks that sign-in cookies are only sent over HTTPS
+func (s *CustomDomainService) checkSecureCookies() models.CustomDomainCheck {
+	if !s.config.JWT.CookieSecure {
+		return models.CustomDomainCheck{Name: "secure_cookies", Detail: "JWT_COOKIE_SECURE is false, so sign-in cookies are also sent over plain HTTP"}
+	}
+	return models.CustomDomainCheck{Name: "secure_cookies", OK: true, Detail: "sign-in cookies are only sent over HTTPS"}
+}
+
+// checkCORS checks that browsers on the domain may call the API
+func (s *CustomDomainService) checkCORS(domain string) models.CustomDomainCheck {
+	origin := "https://" + domain
+	if !slices.Contains(s.config.CORS.AllowedOrigins, origin) && !slices.Contains(s.config.CORS.AllowedOrigins, "*") {
+		return models.CustomDomainCheck{Name: "cors", Detail: fmt.Sprintf("%s is not an allowed CORS origin", origin)}
+	}
+	return models.CustomDomainCheck{Name: "cors", OK: true, Detail: fmt.Sprintf("%s is an allowed CORS origin", origin)}
+}
+
+// checkShareLinks checks that share and unsubscribe links point at the domain
+func (s *CustomDomainService) checkShareLinks(domain string) models.CustomDomainCheck {
+	baseURL, err := url.Parse(s.config.Share.BaseURL)
+	if err != nil || baseURL.Scheme != "https" || !strings.EqualFold(baseURL.Hostname(), domain) {
+		return models.CustomDomainCheck{Name: "share_links", Detail: fmt.Sprintf("SHARE_BASE_URL %q is not https://%s, so links in emails show another domain", s.config.Share.BaseURL, domain)}
+	}
+	return models.CustomDomainCheck{Name: "share_links", OK: true, Detail: fmt.Sprintf("links in emails start with %s", s.config.Share.BaseURL)}
+}
+
+// checkFromAddress checks that emails are sent from the email domain
+func (s *CustomDomainService) checkFromAddress(emailDomain string) models.CustomDomainCheck {
+	from, err := mail.ParseAddress(s.config.Email.FromAddress)
+	if err != nil || !strings.EqualFold(addressDomain(from.Address), emailDomain) {
+		return models.CustomDomainCheck{Name: "email_from", Detail: fmt.Sprintf("EMAIL_FROM_ADDRESS %q is not an address at %s", s.config.Email.FromAddress, emailDomain)}
+	}
+	return models.CustomDomainCheck{Name: "email_from", OK: true, Detail: fmt.Sprintf("emails are sent from %s", from.Address)}
+}
+
+// checkSPF checks that the email domain publishes an SPF record, without
+// which mail from it is likely to be marked as spam
+func (s *CustomDomainService) checkSPF(ctx context.Context, emailDomain string) models.CustomDomainCheck {
+	ctx, cancel := context.WithTimeout(ctx, customDomainLookupTimeout)
+	defer cancel()
+
+	records, _ := s.resolver.LookupTXT(ctx, emailDomain)
+	for _, record := range records {
+		if strings.HasPrefix(strings.ToLower(record), "v=spf1") {
+			return models.CustomDomainCheck{Name: "email_spf", OK: true, Detail: fmt.Sprintf("%s publishes the SPF record %q", emailDomain, record)}
+		}
+	}
+	return models.CustomDomainCheck{Name: "email_spf", Detail: fmt.Sprintf("%s has no SPF record; add the one your email provider gives", emailDomain)}
+}
+
+// checkReplyDomain checks that replies by email, when enabled, are received
+// under the email domain
+func (s *CustomDomainService) checkReplyDomain(ctx context.Context, emailDomain string) models.CustomDomainCheck {
+	replyDomain := strings.ToLower(s.config.Email.ReplyDomain)
+	if replyDomain == "" {
+		return models.CustomDomainCheck{Name: "email_replies", OK: true, Detail: "EMAIL_REPLY_DOMAIN is not set, so replies by email are off"}
+	}
+	if replyDomain != emailDomain && !strings.HasSuffix(replyDomain, "."+emailDomain) {
+		return models.CustomDomainCheck{Name: "email_replies", Detail: fmt.Sprintf("EMAIL_REPLY_DOMAIN %s is not under %s, so reply addresses show another domain", replyDomain, emailDomain)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, customDomainLookupTimeout)
+	defer cancel()
+	if records, err := s.resolver.LookupMX(ctx, replyDomain); err != nil || len(records) == 0 {
+		return models.CustomDomainCheck{Name: "email_replies", Detail: fmt.Sprintf("%s has no MX record; point it at your inbound email provider", replyDomain)}
+	}
+	return models.CustomDomainCheck{Name: "email_replies", OK: true, Detail: fmt.Sprintf("replies are received at %s", replyDomain)}
+}
+
+// addressDomain returns the domain of an email address
+func addressDomain(address string) string {
+	_, domain, _ := strings.Cut(address, "@")
+	return domain
+}
+
+// validDomainName reports whether name is a lowercase fully qualified domain
+// name, such as support.example.com, rather than a URL or IP address
+func validDomainName(name string) bool {
+	if len(name) > 253 {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	// A numeric top-level label is an IP address
+	return strings.Trim(labels[len(labels)-1], "0123456789") != ""
+}
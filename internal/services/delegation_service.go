@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxDelegationHops bounds how far an assignment follows delegates who are themselves away
+const maxDelegationHops = 5
+
+// DelegationService manages agents' out-of-office windows and the handover of their work
+type DelegationService struct {
+	outOfOfficeRepo repository.OutOfOfficeRepository
+	ticketRepo      repository.TicketRepository
+	userRepo        repository.UserRepository
+}
+
+// NewDelegationService creates a new delegation service
+func NewDelegationService(outOfOfficeRepo repository.OutOfOfficeRepository, ticketRepo repository.TicketRepository, userRepo repository.UserRepository) *DelegationService {
+	return &DelegationService{
+		outOfOfficeRepo: outOfOfficeRepo,
+		ticketRepo:      ticketRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// SetOutOfOffice sets or replaces the user's out-of-office window
+func (s *DelegationService) SetOutOfOffice(ctx context.Context, userID uuid.UUID, req *models.SetOutOfOfficeRequest) (*models.OutOfOffice, error) {
+	if req.DelegateID == userID {
+		return nil, fmt.Errorf("%w: you cannot delegate to yourself", ErrInvalidInput)
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidInput)
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the out-of-office window has already ended", ErrInvalidInput)
+	}
+
+	delegate, err := s.userRepo.GetByID(req.DelegateID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegate: %w", err)
+	}
+	if delegate == nil {
+		return nil, fmt.Errorf("delegate %w", ErrNotFound)
+	}
+	if !delegate.IsAgent() || !delegate.IsActive {
+		return nil, fmt.Errorf("%w: the delegate must be an active support agent", ErrInvalidInput)
+	}
+
+	window := &models.OutOfOffice{
+		UserID:     userID,
+		DelegateID: req.DelegateID,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Note:       req.Note,
+	}
+	if err := s.outOfOfficeRepo.Upsert(ctx, window); err != nil {
+		return nil, fmt.Errorf("failed to save out-of-office window: %w", err)
+	}
+
+	return s.GetOutOfOffice(ctx, userID)
+}
+
+// GetOutOfOffice retrieves the user's out-of-office window
+func (s *DelegationService) GetOutOfOffice(ctx context.Context, userID uuid.UUID) (*models.OutOfOffice, error) {
+	window, err := s.outOfOfficeRepo.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("out-of-office window %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get out-of-office window: %w", err)
+	}
+	return window, nil
+}
+
+// ClearOutOfOffice removes the user's out-of-office window
+func (s *DelegationService) ClearOutOfOffice(ctx context.Context, userID uuid.UUID) error {
+	return s.outOfOfficeRepo.DeleteByUser(ctx, userID)
+}
+
+// ActiveDelegate returns the delegate of a user who is currently out of office, or nil
+func (s *DelegationService) ActiveDelegate(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	window, err := s.outOfOfficeRepo.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get out-of-office window: %w", err)
+	}
+	if !window.IsActive(time.Now()) {
+		return nil, nil
+	}
+	return &window.DelegateID, nil
+}
+
+// ResolveAssignee returns who should receive a new assignment for the given agent,
+// following delegates who are themselves out of office. A delegation cycle ends at
+// the last agent before the cycle repeats.
+func (s *DelegationService) ResolveAssignee(ctx context.Context, agentID uuid.UUID) (uuid.UUID, error) {
+	visited := map[uuid.UUID]bool{agentID: true}
+	assignee := agentID
+	for i := 0; i < maxDelegationHops; i++ {
+		delegateID, err := s.ActiveDelegate(ctx, assignee)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if delegateID == nil || visited[*delegateID] {
+			break
+		}
+		visited[*delegateID] = true
+		assignee = *delegateID
+	}
+	return assignee, nil
+}
+
+// HandoverOpenTickets reassigns the user's open tickets to their delegate
+func (s *DelegationService) HandoverOpenTickets(ctx context.Context, userID uuid.UUID) (*models.HandoverResponse, error) {
+	window, err := s.GetOutOfOffice(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	delegateID, err := s.ResolveAssignee(ctx, window.DelegateID)
+	if err != nil {
+		return nil, err
+	}
+	if delegateID == userID {
+		return nil, fmt.Errorf("%w: the delegate has delegated back to you", ErrConflict)
+	}
+
+	reassigned, err := s.ticketRepo.ReassignOpenTickets(ctx, userID, delegateID, userID, "Out-of-office handover")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign tickets: %w", err)
+	}
+
+	return &models.HandoverResponse{
+		DelegateID: delegateID,
+		Reassigned: reassigned,
+	}, nil
+}
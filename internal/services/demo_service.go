@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// demoUser is a user of the demo data; every one signs in with "password"
+type demoUser struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Role      models.UserRole
+}
+
+// demoUsers are the staff and customers of the demo data. The indexes below
+// refer to them.
+var demoUsers = []demoUser{
+	{"admin@helpchat.com", "Admin", "User", models.RoleAdministrator},
+	{"maria.garcia@helpchat.com", "Maria", "Garcia", models.RoleManager},
+	{"david.chen@helpchat.com", "David", "Chen", models.RoleSupportAgent},
+	{"priya.patel@helpchat.com", "Priya", "Patel", models.RoleSupportAgent},
+	{"emma.wilson@example.com", "Emma", "Wilson", models.RoleEndUser},
+	{"liam.johnson@example.com", "Liam", "Johnson", models.RoleEndUser},
+	{"olivia.brown@example.com", "Olivia", "Brown", models.RoleEndUser},
+	{"noah.davis@example.com", "Noah", "Davis", models.RoleEndUser},
+}
+
+const (
+	demoManager = 1
+	demoDavid   = 2
+	demoPriya   = 3
+	demoEmma    = 4
+	demoLiam    = 5
+	demoOlivia  = 6
+	demoNoah    = 7
+)
+
+// demoTeams are the teams of the demo data, with the indexes of their members
+var demoTeams = []struct {
+	Name        string
+	Description string
+	Members     []int
+}{
+	{"Service Desk", "First line support for every customer", []int{demoManager, demoDavid, demoPriya}},
+	{"Billing", "Invoices, refunds and payment issues", []int{demoManager, demoPriya}},
+}
+
+const (
+	demoServiceDesk = 0
+	demoBilling     = 1
+)
+
+// Indexes of database.DefaultCategories
+const (
+	demoTechnicalSupport = iota
+	demoAccountManagement
+	demoBillingPayments
+	demoFeatureRequests
+	demoBugReports
+)
+
+// demoComment is a comment on a demo ticket
+type demoComment struct {
+	Author   int
+	Content  string
+	Internal bool
+	// After is how long after the ticket was opened the comment was added
+	After time.Duration
+}
+
+// demoTicket is a ticket of the demo data, opened Age ago
+type demoTicket struct {
+	Title       string
+	Description string
+	Category    int
+	Priority    models.TicketPriority
+	Status      models.TicketStatus
+	Requester   int
+	// Team is the index of the ticket's team, or -1 for none
+	Team int
+	// Agent is the index of the assignee, or -1 for none
+	Agent int
+	Age   time.Duration
+	// Resolved is how long after it was opened the ticket was resolved
+	Resolved time.Duration
+	// Due is how long after it was opened the ticket is due; 0 for no due date
+	Due time.Duration
+	// Escalation is the reason the ticket was escalated to the manager, if it was
+	Escalation string
+	Comments   []demoComment
+}
+
+// demoDay is a day of demo ticket history
+const demoDay = 24 * time.Hour
+
+// demoTickets are a month of ticket history, from long closed tickets to
+// ones opened this morning
+var demoTickets = []demoTicket{
+	{
+		Title:       "How do I add a user to my account?",
+		Description: "We hired someone new and I can't find where to give them access to our account.",
+		Category:    demoAccountManagement,
+		Priority:    models.PriorityLow,
+		Status:      models.StatusClosed,
+		Requester:   demoOlivia,
+		Team:        demoServiceDesk,
+		Agent:       demoPriya,
+		Age:         30 * demoDay,
+		Resolved:    time.Hour,
+		Comments: []demoComment{
+			{demoPriya, "Go to Settings > Users and choose Invite user. They'll get an email to set their password.", false, 40 * time.Minute},
+			{demoOlivia, "Found it, thank you!", false, time.Hour},
+		},
+	},
+	{
+		Title:       "Cannot log in after password reset",
+		Description: "I reset my password this morning but the new one is rejected every time.",
+		Category:    demoAccountManagement,
+		Priority:    models.PriorityHigh,
+		Status:      models.StatusClosed,
+		Requester:   demoEmma,
+		Team:        demoServiceDesk,
+		Agent:       demoDavid,
+		Age:         26 * demoDay,
+		Resolved:    3 * time.Hour,
+		Comments: []demoComment{
+			{demoDavid, "Your account was locked after several failed attempts. I've unlocked it, please try again.", false, time.Hour},
+			{demoEmma, "That worked, thanks!", false, 2 * time.Hour},
+		},
+	},
+	{
+		Title:       "Invoice shows the wrong billing address",
+		Description: "Our March invoice still has our old office address on it. Our finance team needs it corrected.",
+		Category:    demoBillingPayments,
+		Priority:    models.PriorityMedium,
+		Status:      models.StatusResolved,
+		Requester:   demoLiam,
+		Team:        demoBilling,
+		Agent:       demoPriya,
+		Age:         21 * demoDay,
+		Resolved:    26 * time.Hour,
+		Comments: []demoComment{
+			{demoPriya, "Billing addresses come from the account profile, which still has the old office.", true, 3 * time.Hour},
+			{demoPriya, "I've corrected the address and a new invoice is on its way to you.", false, 26 * time.Hour},
+		},
+	},
+	{
+		Title:       "Add dark mode to the dashboard",
+		Description: "Our night shift would love a dark theme for the dashboard.",
+		Category:    demoFeatureRequests,
+		Priority:    models.PriorityLow,
+		Status:      models.StatusOpen,
+		Requester:   demoNoah,
+		Team:        -1,
+		Agent:       -1,
+		Age:         14 * demoDay,
+		Comments: []demoComment{
+			{demoManager, "Logged for the next roadmap review.", true, demoDay},
+		},
+	},
+	{
+		Title:       "Export to CSV times out on large reports",
+		Description: "Exporting the yearly report to CSV spins for a minute and then fails with a timeout.",
+		Category:    demoBugReports,
+		Priority:    models.PriorityHigh,
+		Status:      models.StatusInProgress,
+		Requester:   demoOlivia,
+		Team:        demoServiceDesk,
+		Agent:       demoDavid,
+		Age:         9 * demoDay,
+		Due:         5 * demoDay,
+		Escalation:  "Reproduced with reports over 50,000 rows; needs engineering",
+		Comments: []demoComment{
+			{demoDavid, "Reproduced with 50k rows, smaller reports export fine.", true, 4 * time.Hour},
+			{demoOlivia, "Is there any update on this? We need the export for our audit.", false, 6 * demoDay},
+			{demoDavid, "Engineering is on it. Until it's fixed, exporting one quarter at a time works.", false, 6*demoDay + 2*time.Hour},
+		},
+	},
+	{
+		Title:       "App crashes when uploading a photo",
+		Description: "The mobile app closes as soon as I pick a photo to attach.",
+		Category:    demoBugReports,
+		Priority:    models.PriorityMedium,
+		Status:      models.StatusResolved,
+		Requester:   demoNoah,
+		Team:        demoServiceDesk,
+		Agent:       demoDavid,
+		Age:         5 * demoDay,
+		Resolved:    2 * demoDay,
+		Comments: []demoComment{
+			{demoDavid, "Which version of the app are you on?", false, 2 * time.Hour},
+			{demoNoah, "4.2.0 on an iPhone.", false, 5 * time.Hour},
+			{demoDavid, "Version 4.2.1 fixes the crash; please update from the App Store.", false, 2 * demoDay},
+		},
+	},
+	{
+		Title:       "Slow page loads in the afternoon",
+		Description: "Every afternoon pages take 10 seconds or more to load for our whole office.",
+		Category:    demoTechnicalSupport,
+		Priority:    models.PriorityMedium,
+		Status:      models.StatusOpen,
+		Requester:   demoEmma,
+		Team:        demoServiceDesk,
+		Agent:       demoDavid,
+		Age:         3 * demoDay,
+	},
+	{
+		Title:       "Update company name on account",
+		Description: "We rebranded and our company name should now be Brightside Ltd.",
+		Category:    demoAccountManagement,
+		Priority:    models.PriorityLow,
+		Status:      models.StatusOpen,
+		Requester:   demoLiam,
+		Team:        -1,
+		Agent:       -1,
+		Age:         2 * demoDay,
+	},
+	{
+		Title:       "Charged twice for the annual plan",
+		Description: "Our card was charged twice for the annual renewal yesterday.",
+		Category:    demoBillingPayments,
+		Priority:    models.PriorityCritical,
+		Status:      models.StatusInProgress,
+		Requester:   demoEmma,
+		Team:        demoBilling,
+		Agent:       demoPriya,
+		Age:         demoDay,
+		Due:         30 * time.Hour,
+		Comments: []demoComment{
+			{demoPriya, "I can see both charges. I've asked for the second one to be refunded.", false, 2 * time.Hour},
+		},
+	},
+	{
+		Title:       "Two-factor codes not arriving by SMS",
+		Description: "None of our team receive their sign-in codes by text message since this morning.",
+		Category:    demoTechnicalSupport,
+		Priority:    models.PriorityHigh,
+		Status:      models.StatusOpen,
+		Requester:   demoLiam,
+		Team:        demoServiceDesk,
+		Agent:       -1,
+		Age:         5 * time.Hour,
+		Due:         8 * time.Hour,
+	},
+}
+
+// DemoService resets demo and ephemeral environments to a known state: a few
+// staff and customers, the default categories and a month of ticket history
+type DemoService struct {
+	demoRepo repository.DemoRepository
+	storage  storage.Storage
+	enabled  bool
+	interval time.Duration
+}
+
+// NewDemoService creates a new demo service
+func NewDemoService(demoRepo repository.DemoRepository, storage storage.Storage, cfg *config.Config) *DemoService {
+	interval, err := time.ParseDuration(cfg.Demo.ResetInterval)
+	if err != nil || interval < 0 {
+		interval = 0 // only reset on demand
+	}
+
+	return &DemoService{
+		demoRepo: demoRepo,
+		storage:  storage,
+		enabled:  cfg.Demo.Enabled,
+		interval: interval,
+	}
+}
+
+// Reset wipes the database and reseeds it with the demo data, deleting the
+// files of every attachment. It is refused unless demo mode is on.
+func (s *DemoService) Reset(ctx context.Context) (*models.DemoResetResponse, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("%w: the database can only be reset in demo mode", ErrForbidden)
+	}
+
+	now := time.Now()
+	dataset := demoDataset(now)
+	attachments, drafts, err := s.demoRepo.Reset(ctx, dataset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset demo data: %w", err)
+	}
+	for i := range attachments {
+		deleteAttachmentFiles(ctx, s.storage, &attachments[i])
+	}
+	for _, draft := range drafts {
+		s.storage.Delete(ctx, draft.FilePath)
+	}
+
+	log.Printf("Database reset to the demo data: %d users, %d tickets", len(dataset.Users), len(dataset.Tickets))
+	return &models.DemoResetResponse{
+		Users:      len(dataset.Users),
+		Categories: len(dataset.Categories),
+		Tickets:    len(dataset.Tickets),
+		Comments:   len(dataset.Comments),
+		ResetAt:    now.UTC(),
+	}, nil
+}
+
+// Schedule registers the job resetting the demo data every DEMO_RESET_INTERVAL,
+// when demo mode is on and an interval is set
+func (s *DemoService) Schedule(jobs *scheduler.Scheduler) {
+	if !s.enabled || s.interval == 0 {
+		return
+	}
+	jobs.Every("demo reset", s.interval, func(ctx context.Context) error {
+		_, err := s.Reset(ctx)
+		return err
+	})
+}
+
+// demoDataset builds the demo data with its history ending at now
+func demoDataset(now time.Time) *models.DemoDataset {
+	dataset := &models.DemoDataset{Categories: database.DefaultCategories()}
+
+	for _, user := range demoUsers {
+		dataset.Users = append(dataset.Users, models.User{
+			ID:           uuid.New(),
+			Email:        user.Email,
+			PasswordHash: database.DefaultPasswordHash,
+			FirstName:    user.FirstName,
+			LastName:     user.LastName,
+			Role:         user.Role,
+			IsVerified:   true,
+			IsActive:     true,
+		})
+	}
+	for i := range dataset.Categories {
+		dataset.Categories[i].ID = uuid.New()
+	}
+	for _, team := range demoTeams {
+		teamID := uuid.New()
+		dataset.Teams = append(dataset.Teams, models.Team{ID: teamID, Name: team.Name, Description: team.Description})
+		for _, member := range team.Members {
+			dataset.TeamMembers = append(dataset.TeamMembers, models.TeamMember{TeamID: teamID, UserID: dataset.Users[member].ID})
+		}
+	}
+
+	manager := dataset.Users[demoManager].ID
+	for _, scenario := range demoTickets {
+		opened := now.Add(-scenario.Age).UTC()
+		requester := dataset.Users[scenario.Requester].ID
+		ticket := models.Ticket{
+			ID:           uuid.New(),
+			CreationTime: opened,
+			Title:        scenario.Title,
+			Description:  scenario.Description,
+			Status:       scenario.Status,
+			Priority:     scenario.Priority,
+			CategoryID:   &dataset.Categories[scenario.Category].ID,
+			CreatedByID:  requester,
+			OpenedBy:     &requester,
+		}
+		if scenario.Team >= 0 {
+			ticket.TeamID = &dataset.Teams[scenario.Team].ID
+		}
+		if scenario.Agent >= 0 {
+			agent := dataset.Users[scenario.Agent].ID
+			ticket.AssignedAgentID = &agent
+			dataset.Assignments = append(dataset.Assignments, models.TicketAssignment{
+				ID:           uuid.New(),
+				TicketID:     ticket.ID,
+				AgentID:      &agent,
+				AssignedByID: manager,
+				CreatedAt:    opened.Add(30 * time.Minute),
+			})
+		}
+		if scenario.Resolved > 0 {
+			resolved := opened.Add(scenario.Resolved)
+			ticket.ResolvedAt = &resolved
+		}
+		if scenario.Due > 0 {
+			due := opened.Add(scenario.Due)
+			ticket.DueDate = &due
+		}
+		if scenario.Escalation != "" {
+			escalated := opened.Add(2 * demoDay)
+			ticket.EscalatedAt = &escalated
+			ticket.EscalatedTo = &manager
+			ticket.EscalationReason = scenario.Escalation
+			dataset.Escalations = append(dataset.Escalations, models.TicketEscalationEvent{
+				ID:        uuid.New(),
+				TicketID:  ticket.ID,
+				Action:    models.EscalationEscalated,
+				ActorID:   *ticket.AssignedAgentID,
+				TargetID:  &manager,
+				Note:      scenario.Escalation,
+				CreatedAt: escalated,
+			})
+		}
+		dataset.Tickets = append(dataset.Tickets, ticket)
+
+		for _, comment := range scenario.Comments {
+			at := opened.Add(comment.After)
+			dataset.Comments = append(dataset.Comments, models.Comment{
+				ID:         uuid.New(),
+				TicketID:   ticket.ID,
+				UserID:     dataset.Users[comment.Author].ID,
+				Content:    comment.Content,
+				Format:     models.CommentFormatPlain,
+				IsInternal: comment.Internal,
+				CreatedAt:  at,
+				UpdatedAt:  at,
+			})
+		}
+	}
+	return dataset
+}
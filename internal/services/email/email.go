@@ -0,0 +1,131 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+)
+
+// Message represents an outbound email message
+type Message struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text,omitempty"`
+	HTML    string   `json:"html,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	// Headers holds additional headers such as Message-ID, In-Reply-To and References
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// MessageIDDomain returns the domain of the Message-ID headers we generate:
+// the reply domain, else the sender's domain
+func MessageIDDomain(cfg config.EmailConfig) string {
+	if cfg.ReplyDomain != "" {
+		return cfg.ReplyDomain
+	}
+	if _, domain, found := strings.Cut(cfg.FromAddress, "@"); found {
+		return domain
+	}
+	return "helpchat.local"
+}
+
+// EmailSender defines the interface for delivering email messages
+type EmailSender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Checker is a sender that can verify its provider is reachable and accepts
+// our credentials without sending a message
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Check verifies the provider behind sender. Senders that cannot be checked,
+// such as the no-op sender, always pass.
+func Check(ctx context.Context, sender EmailSender) error {
+	if checker, ok := sender.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
+// Default send rates of the providers, in recipients per second. Amazon SES
+// accounts start at 14 per second; SMTP relays are not limited by default.
+var defaultRateLimits = map[string]float64{
+	"ses":      14,
+	"sendgrid": 100,
+}
+
+// NewSender creates the email sender selected by configuration, throttled to
+// the provider's send rate and, in sandbox mode, restricted to the sandbox
+// recipients. When no provider or SMTP host is configured a no-op sender is
+// returned so that development environments work without a mail server.
+func NewSender(ctx context.Context, cfg config.EmailConfig, secretsProvider secrets.Provider) (EmailSender, error) {
+	if _, err := mail.ParseAddress(cfg.FromAddress); err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_FROM_ADDRESS: %w", err)
+	}
+
+	provider := cfg.Provider
+	if provider == "" && cfg.SMTPHost != "" {
+		provider = "smtp"
+	}
+
+	var sender EmailSender
+	switch provider {
+	case "":
+		sender = NewNoopSender()
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required")
+		}
+		sender = NewSMTPSender(cfg)
+	case "ses":
+		if cfg.SESAccessKey == "" {
+			return nil, fmt.Errorf("EMAIL_SES_ACCESS_KEY is required")
+		}
+		secretKey, err := secretsProvider.Get(ctx, cfg.SESSecretKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		sender = NewSESSender(cfg, secretKey)
+	case "sendgrid":
+		apiKey, err := secretsProvider.Get(ctx, cfg.SendGridAPIKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		sender = NewSendGridSender(cfg, apiKey)
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+
+	perSecond := cfg.RateLimit
+	if perSecond == 0 {
+		perSecond = defaultRateLimits[provider]
+	}
+	if perSecond > 0 {
+		sender = NewRateLimitedSender(sender, perSecond)
+	}
+	if cfg.Sandbox {
+		sender = NewSandboxSender(sender, cfg.SandboxRecipients)
+	}
+	return sender, nil
+}
+
+// NoopSender discards messages, logging their recipients and subject
+type NoopSender struct{}
+
+// NewNoopSender creates a new no-op sender
+func NewNoopSender() *NoopSender {
+	return &NoopSender{}
+}
+
+// Send logs the message instead of delivering it
+func (s *NoopSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("email (not sent): to=%v subject=%q", msg.To, msg.Subject)
+	return nil
+}
@@ -0,0 +1,173 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+)
+
+// layoutPartials holds the "layout" template and the "header" and "footer"
+// partials it includes
+//
+//go:embed layout.html
+var layoutPartials string
+
+var layoutTemplate = template.Must(template.New("email").Parse(layoutPartials))
+
+var (
+	hexColorRegex  = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	paragraphRegex = regexp.MustCompile(`\n\s*\n`)
+	// Trailing punctuation is left out so "see https://example.com." links correctly
+	textURLRegex  = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,;:!?)\]'*]`)
+	htmlLinkRegex = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*"([^"]*)"[^>]*>(.*?)</a>`)
+)
+
+// Layout wraps outbound emails in a branded HTML header and footer
+type Layout struct {
+	brandName    string
+	logoURL      string
+	primaryColor string
+	footer       string
+}
+
+// layoutData is what the layout and its partials are rendered with
+type layoutData struct {
+	Subject      string
+	BrandName    string
+	LogoURL      string
+	PrimaryColor string
+	Footer       string
+	Content      template.HTML
+}
+
+// NewLayout creates the email layout from the branding settings
+func NewLayout(cfg config.EmailConfig) (*Layout, error) {
+	if !hexColorRegex.MatchString(cfg.PrimaryColor) {
+		return nil, fmt.Errorf("primary color %q must be a hex color such as #2563eb", cfg.PrimaryColor)
+	}
+	if cfg.LogoURL != "" {
+		logo, err := url.Parse(cfg.LogoURL)
+		if err != nil || (logo.Scheme != "https" && logo.Scheme != "http") || logo.Host == "" {
+			return nil, fmt.Errorf("logo URL %q must be an absolute http or https URL", cfg.LogoURL)
+		}
+	}
+
+	return &Layout{
+		brandName:    cfg.BrandName,
+		logoURL:      cfg.LogoURL,
+		primaryColor: cfg.PrimaryColor,
+		footer:       cfg.Footer,
+	}, nil
+}
+
+// Apply returns a copy of msg with an HTML part in the layout and a plain-text
+// part. A message with only text gets its HTML rendered from the text, and a
+// message with only HTML gets its text generated from the HTML. The text ends
+// with a signature naming the brand.
+func (l *Layout) Apply(msg *Message) (*Message, error) {
+	branded := *msg
+
+	content := template.HTML(msg.HTML)
+	if msg.HTML == "" {
+		content = textToHTML(msg.Text, l.primaryColor)
+	}
+	if branded.Text == "" {
+		branded.Text = HTMLToText(htmlLinkRegex.ReplaceAllString(msg.HTML, "$2 ($1)"))
+	}
+	branded.Text = strings.TrimRight(branded.Text, "\n") + "\n\n-- \n" + l.brandName + "\n"
+	if l.footer != "" {
+		branded.Text += l.footer + "\n"
+	}
+
+	var buf bytes.Buffer
+	err := layoutTemplate.ExecuteTemplate(&buf, "layout", layoutData{
+		Subject:      msg.Subject,
+		BrandName:    l.brandName,
+		LogoURL:      l.logoURL,
+		PrimaryColor: l.primaryColor,
+		Footer:       l.footer,
+		Content:      content,
+	})
+	if err != nil {
+		return nil, err
+	}
+	branded.HTML = buf.String()
+	return &branded, nil
+}
+
+// textToHTML renders plain text as escaped HTML paragraphs, keeping line
+// breaks and turning URLs into links. The reply marker is shown subdued.
+func textToHTML(text, linkColor string) template.HTML {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+
+	var b strings.Builder
+	for _, paragraph := range paragraphRegex.Split(text, -1) {
+		if strings.TrimSpace(paragraph) == ReplyMarker {
+			fmt.Fprintf(&b, "<p style=\"margin:0 0 14px;color:#9ca3af;font-size:12px;\">%s</p>\n", html.EscapeString(ReplyMarker))
+			continue
+		}
+		b.WriteString(`<p style="margin:0 0 14px;">`)
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				b.WriteString("<br>\n")
+			}
+			last := 0
+			for _, loc := range textURLRegex.FindAllStringIndex(line, -1) {
+				b.WriteString(html.EscapeString(line[last:loc[0]]))
+				link := html.EscapeString(line[loc[0]:loc[1]])
+				fmt.Fprintf(&b, `<a href="%s" style="color:%s;">%s</a>`, link, linkColor, link)
+				last = loc[1]
+			}
+			b.WriteString(html.EscapeString(line[last:]))
+		}
+		b.WriteString("</p>\n")
+	}
+	return template.HTML(b.String())
+}
+
+// LayoutSender applies the email layout to messages before handing them to
+// another sender for delivery
+type LayoutSender struct {
+	next   EmailSender
+	layout atomic.Pointer[Layout]
+}
+
+// NewLayoutSender creates a sender that delivers through next
+func NewLayoutSender(next EmailSender, layout *Layout) *LayoutSender {
+	s := &LayoutSender{next: next}
+	s.layout.Store(layout)
+	return s
+}
+
+// Send applies the layout and delivers the message
+func (s *LayoutSender) Send(ctx context.Context, msg *Message) error {
+	branded, err := s.layout.Load().Apply(msg)
+	if err != nil {
+		return fmt.Errorf("failed to apply email layout: %w", err)
+	}
+	return s.next.Send(ctx, branded)
+}
+
+// Check verifies the provider of the next sender
+func (s *LayoutSender) Check(ctx context.Context) error {
+	return Check(ctx, s.next)
+}
+
+// Reload applies new branding settings; invalid ones keep the current layout
+func (s *LayoutSender) Reload(cfg *config.Config) error {
+	layout, err := NewLayout(cfg.Email)
+	if err != nil {
+		return fmt.Errorf("invalid email branding: %w", err)
+	}
+	s.layout.Store(layout)
+	return nil
+}
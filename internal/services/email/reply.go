@@ -0,0 +1,95 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ReplyMarker is placed at the top of outbound messages so that replies can be cut above it
+const ReplyMarker = "##- Please type your reply above this line -##"
+
+var (
+	// "On Mon, 1 Jan 2024 at 10:00, Jane <jane@example.com> wrote:" (may wrap over two lines)
+	onWroteRegex = regexp.MustCompile(`(?is)^\s*On\s.{1,300}?wrote:\s*$`)
+	// Outlook style "From: ..." header block introducing the quoted message
+	outlookHeaderRegex = regexp.MustCompile(`(?i)^\s*\*?From:\*?\s`)
+	// Common mobile client signatures
+	mobileSignatureRegex = regexp.MustCompile(`(?i)^\s*sent from my\s`)
+	// Horizontal rules used by Outlook and others to separate the quoted message
+	separatorRegex = regexp.MustCompile(`^\s*(_{10,}|-{5,}\s*Original Message\s*-{5,}|-{5,}\s*Forwarded message\s*-{5,})\s*$`)
+
+	htmlBreakRegex = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/tr|/h[1-6])\s*/?>`)
+	htmlTagRegex   = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlStyleRegex = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+	blankRunRegex  = regexp.MustCompile(`\n{3,}`)
+)
+
+// ExtractReply returns only the newly written part of an email reply,
+// stripping quoted history, our reply marker and trailing signatures.
+func ExtractReply(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	lines := strings.Split(body, "\n")
+
+	end := len(lines)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.Contains(line, ReplyMarker) ||
+			strings.HasPrefix(trimmed, ">") ||
+			separatorRegex.MatchString(line) ||
+			outlookHeaderRegex.MatchString(line) && looksLikeHeaderBlock(lines[i:]) ||
+			isOnWrote(lines, i) {
+			end = i
+			break
+		}
+	}
+	lines = lines[:end]
+
+	// Strip a trailing signature block ("-- " delimiter or mobile client footer)
+	for i, line := range lines {
+		if line == "-- " || strings.TrimSpace(line) == "--" || mobileSignatureRegex.MatchString(line) {
+			lines = lines[:i]
+			break
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// HTMLToText produces a rough plain-text rendering of an HTML body for
+// emails that arrive without a text part
+func HTMLToText(body string) string {
+	body = htmlStyleRegex.ReplaceAllString(body, "")
+	body = htmlBreakRegex.ReplaceAllString(body, "\n")
+	body = htmlTagRegex.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+	body = blankRunRegex.ReplaceAllString(body, "\n\n")
+	return strings.TrimSpace(body)
+}
+
+// isOnWrote reports whether an "On ... wrote:" attribution starts at line i,
+// allowing for clients that wrap the attribution over two lines
+func isOnWrote(lines []string, i int) bool {
+	if onWroteRegex.MatchString(lines[i]) {
+		return true
+	}
+	if i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "On ") {
+		return onWroteRegex.MatchString(lines[i] + " " + lines[i+1])
+	}
+	return false
+}
+
+// looksLikeHeaderBlock reports whether the lines following a "From:" line
+// contain other quoted header fields, to avoid cutting on a literal "From:"
+func looksLikeHeaderBlock(lines []string) bool {
+	for i := 1; i < len(lines) && i <= 4; i++ {
+		trimmed := strings.TrimLeft(strings.TrimSpace(lines[i]), "*")
+		for _, field := range []string{"Sent:", "Date:", "To:", "Subject:"} {
+			if strings.HasPrefix(trimmed, field) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+)
+
+// SendGridSender delivers messages through the SendGrid v3 Mail Send API.
+// SendGrid signs them with DKIM for the authenticated From domain.
+type SendGridSender struct {
+	endpoint string
+	apiKey   string
+	from     mail.Address
+	client   *http.Client
+}
+
+// NewSendGridSender creates a new SendGrid sender. An empty endpoint uses the
+// SendGrid API.
+func NewSendGridSender(cfg config.EmailConfig, apiKey string) *SendGridSender {
+	endpoint := cfg.SendGridEndpoint
+	if endpoint == "" {
+		endpoint = "https://api.sendgrid.com"
+	}
+	return &SendGridSender{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		apiKey:   apiKey,
+		from:     mail.Address{Name: cfg.FromName, Address: cfg.FromAddress},
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridMailRequest is the body of the Mail Send endpoint
+type sendGridMailRequest struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From    sendGridAddress   `json:"from"`
+	ReplyTo *sendGridAddress  `json:"reply_to,omitempty"`
+	Subject string            `json:"subject"`
+	Content []sendGridContent `json:"content"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Send delivers the message to all recipients
+func (s *SendGridSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("message has no recipients")
+	}
+
+	payload := sendGridMailRequest{
+		From:    sendGridAddress{Email: s.from.Address, Name: s.from.Name},
+		Subject: msg.Subject,
+		Headers: msg.Headers,
+	}
+	payload.Personalizations = make([]struct {
+		To []sendGridAddress `json:"to"`
+	}, 1)
+	for _, to := range msg.To {
+		payload.Personalizations[0].To = append(payload.Personalizations[0].To, sendGridAddress{Email: to})
+	}
+	if msg.ReplyTo != "" {
+		replyTo, err := mail.ParseAddress(msg.ReplyTo)
+		if err != nil {
+			return fmt.Errorf("invalid reply-to address: %w", err)
+		}
+		payload.ReplyTo = &sendGridAddress{Email: replyTo.Address, Name: replyTo.Name}
+	}
+	// SendGrid requires the plain-text part to come first
+	if msg.Text != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SendGrid: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("SendGrid returned %s: %s", res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Check verifies that SendGrid accepts the API key
+func (s *SendGridSender) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/v3/scopes", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SendGrid: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("SendGrid returned %s: %s", res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/awsv4"
+)
+
+// SESSender delivers messages through the Amazon SES v2 API. Messages are sent
+// raw so that our Message-ID and threading headers are kept; SES signs them
+// with DKIM for the verified From domain.
+type SESSender struct {
+	endpoint         string
+	from             mail.Address
+	configurationSet string
+	signer           awsv4.Signer
+	client           *http.Client
+}
+
+// NewSESSender creates a new SES sender. An empty endpoint uses Amazon SES in
+// the configured region.
+func NewSESSender(cfg config.EmailConfig, secretKey string) *SESSender {
+	endpoint := cfg.SESEndpoint
+	if endpoint == "" {
+		endpoint = "https://email." + cfg.SESRegion + ".amazonaws.com"
+	}
+	return &SESSender{
+		endpoint:         strings.TrimSuffix(endpoint, "/"),
+		from:             mail.Address{Name: cfg.FromName, Address: cfg.FromAddress},
+		configurationSet: cfg.SESConfigurationSet,
+		signer:           awsv4.Signer{AccessKey: cfg.SESAccessKey, SecretKey: secretKey, Region: cfg.SESRegion, Service: "ses"},
+		client:           &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// sesSendEmailRequest is the body of the SES v2 SendEmail operation
+type sesSendEmailRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Raw struct {
+			Data []byte `json:"Data"`
+		} `json:"Raw"`
+	} `json:"Content"`
+	ConfigurationSetName string `json:"ConfigurationSetName,omitempty"`
+}
+
+// Send delivers the message to all recipients
+func (s *SESSender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("message has no recipients")
+	}
+
+	raw, err := buildMIME(s.from, msg)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+	var payload sesSendEmailRequest
+	payload.FromEmailAddress = s.from.String()
+	payload.Destination.ToAddresses = msg.To
+	payload.Content.Raw.Data = raw
+	payload.ConfigurationSetName = s.configurationSet
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.signer.Sign(req, body, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SES: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("SES returned %s: %s", res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Check verifies the credentials by reading the SES account, which also tells
+// whether the account may send
+func (s *SESSender) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/v2/email/account", nil)
+	if err != nil {
+		return err
+	}
+	s.signer.Sign(req, nil, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach SES: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("SES returned %s: %s", res.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var account struct {
+		SendingEnabled bool `json:"SendingEnabled"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&account); err != nil {
+		return fmt.Errorf("failed to read SES account: %w", err)
+	}
+	if !account.SendingEnabled {
+		return fmt.Errorf("SES account has sending paused")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package email
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Notification template names
+const (
+	TemplateTicketReceived = "ticket_received"
+	TemplateTicketCreated  = "ticket_created"
+	TemplateCommentAdded   = "comment_added"
+	TemplateMentioned      = "mentioned"
+	TemplateEscalated      = "ticket_escalated"
+	TemplateDeEscalated    = "ticket_de_escalated"
+	TemplateReassigned     = "ticket_reassigned_often"
+	TemplateAssigned       = "ticket_assigned"
+	TemplateStatusChanged  = "ticket_status_changed"
+	TemplateWelcome        = "welcome"
+	TemplatePasswordReset  = "password_reset"
+)
+
+// placeholder finds the {{name}} placeholders of a template
+var placeholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Template is a notification email whose subject and text may use {{name}}
+// placeholders. Tenants may replace the built-in subject and text.
+type Template struct {
+	Name        string
+	Description string
+	Subject     string
+	Text        string
+	// Variables lists the placeholders the template may use
+	Variables []string
+	// Replyable templates are sent with a reply-to address, so their text must
+	// keep {{reply_marker}} for replies to be trimmed to the new content
+	Replyable bool
+}
+
+// ticketVariables are the placeholders every ticket notification may use
+var ticketVariables = []string{"ticket_title", "ticket_reference", "ticket_status", "ticket_priority"}
+
+// accountVariables are the placeholders every account email may use
+var accountVariables = []string{"recipient_first_name", "recipient_name", "link_expires_in"}
+
+// DefaultTemplates are the built-in notification templates
+var DefaultTemplates = []Template{
+	{
+		Name:        TemplateTicketReceived,
+		Description: "Acknowledges a ticket submitted by guest form or email",
+		Subject:     "[#{{ticket_reference}}] {{ticket_title}}",
+		Text: "{{reply_marker}}\n\nHi {{recipient_first_name}},\n\nWe have received your request \"{{ticket_title}}\" and will get back to you soon. " +
+			"You can add more information by replying to this email.\n\nReference: #{{ticket_reference}}\n",
+		Variables: append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "reply_marker"),
+		Replyable: true,
+	},
+	{
+		Name:        TemplateTicketCreated,
+		Description: "Printable summary sent to the requester of a ticket created in the portal or by an agent on their behalf",
+		Subject:     "[#{{ticket_reference}}] {{ticket_title}}",
+		Text: "{{reply_marker}}\n\nHi {{recipient_first_name}},\n\nYour ticket has been created. Keep this email for your records.\n\n" +
+			"Ticket number: #{{ticket_reference}}\nTitle: {{ticket_title}}\nPriority: {{ticket_priority}}\nStatus: {{ticket_status}}\n\n" +
+			"Summary:\n{{ticket_summary}}\n\nExpected response: {{expected_response}}\n\nCheck the status of your ticket at any time:\n{{ticket_url}}\n",
+		Variables: append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "ticket_summary", "expected_response", "ticket_url", "reply_marker"),
+		Replyable: true,
+	},
+	{
+		Name:        TemplateCommentAdded,
+		Description: "Sent to the other side of the conversation when a public comment is added",
+		Subject:     "Re: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "{{reply_marker}}\n\n{{author_name}} wrote:\n\n{{comment}}\n\nTicket: {{ticket_title}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "author_name", "comment", "reply_marker"),
+		Replyable:   true,
+	},
+	{
+		Name:        TemplateMentioned,
+		Description: "Sent to staff mentioned in a comment or internal note",
+		Subject:     "You were mentioned on [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "{{author_name}} mentioned you on ticket \"{{ticket_title}}\":\n\n{{comment}}\n\nReference: #{{ticket_reference}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "author_name", "comment"),
+	},
+	{
+		Name:        TemplateEscalated,
+		Description: "Sent to the manager or administrator a ticket is escalated to",
+		Subject:     "Escalated: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "Hi {{recipient_first_name}},\n\n{{author_name}} escalated ticket \"{{ticket_title}}\" to you.\n\nReason:\n{{reason}}\n\nReference: #{{ticket_reference}}\nPriority: {{ticket_priority}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "author_name", "reason"),
+	},
+	{
+		Name:        TemplateDeEscalated,
+		Description: "Sent to the assignee when an escalated ticket is handed back to them",
+		Subject:     "Back to you: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "Hi {{recipient_first_name}},\n\n{{author_name}} handed ticket \"{{ticket_title}}\" back to you:\n\n{{comment}}\n\nReference: #{{ticket_reference}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "author_name", "comment"),
+	},
+	{
+		Name:        TemplateReassigned,
+		Description: "Alerts managers when a ticket has been assigned to more agents than TICKET_REASSIGNMENT_ALERT_THRESHOLD",
+		Subject:     "Reassigned {{agent_count}} times: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "Ticket \"{{ticket_title}}\" has now been assigned to {{agent_count}} different agents. It may need an owner.\n\nReference: #{{ticket_reference}}\nPriority: {{ticket_priority}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "agent_count"),
+	},
+	{
+		Name:        TemplateAssigned,
+		Description: "Sent to the agent a ticket is assigned to, unless they assigned it to themselves",
+		Subject:     "Assigned to you: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "Hi {{recipient_first_name}},\n\n{{author_name}} assigned ticket \"{{ticket_title}}\" to you.\n\n{{ticket_url}}\n\nReference: #{{ticket_reference}}\nPriority: {{ticket_priority}}\nStatus: {{ticket_status}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "recipient_first_name", "recipient_name", "author_name", "ticket_url"),
+	},
+	{
+		Name:        TemplateStatusChanged,
+		Description: "Sent to the requester and the assignee of a ticket when someone else changes its status",
+		Subject:     "{{ticket_status}}: [#{{ticket_reference}}] {{ticket_title}}",
+		Text:        "{{author_name}} changed the status of ticket \"{{ticket_title}}\" from {{previous_status}} to {{ticket_status}}.\n\n{{ticket_url}}\n\nReference: #{{ticket_reference}}\nPriority: {{ticket_priority}}\n",
+		Variables:   append(slices.Clone(ticketVariables), "author_name", "previous_status", "ticket_url"),
+	},
+	{
+		Name:        TemplateWelcome,
+		Description: "Welcomes a newly registered user and asks them to verify their email address",
+		Subject:     "Welcome! Please verify your email address",
+		Text: "Hi {{recipient_first_name}},\n\nThanks for creating an account. Please verify your email address by opening this link:\n\n{{verify_url}}\n\n" +
+			"The link expires in {{link_expires_in}}. If you did not create an account, you can ignore this email.\n",
+		Variables: append(slices.Clone(accountVariables), "verify_url"),
+	},
+	{
+		Name:        TemplatePasswordReset,
+		Description: "Sent when a user asks to reset a forgotten password",
+		Subject:     "Reset your password",
+		Text: "Hi {{recipient_first_name}},\n\nWe received a request to reset your password. Choose a new one by opening this link:\n\n{{reset_url}}\n\n" +
+			"The link expires in {{link_expires_in}} and can be used once. If you did not ask to reset your password, you can ignore this email; your password has not changed.\n",
+		Variables: append(slices.Clone(accountVariables), "reset_url"),
+	},
+}
+
+// DefaultTemplate returns the built-in template with the given name
+func DefaultTemplate(name string) (Template, bool) {
+	for _, t := range DefaultTemplates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Validate checks that a replacement subject and text only use the template's
+// placeholders, have no unbalanced braces and, for replyable templates, keep
+// the reply marker
+func (t Template) Validate(subject, text string) error {
+	if strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("subject must be a single line")
+	}
+
+	used := make(map[string]bool)
+	for _, part := range [][2]string{{"subject", subject}, {"text", text}} {
+		field, value := part[0], part[1]
+		for _, match := range placeholder.FindAllStringSubmatch(value, -1) {
+			if !slices.Contains(t.Variables, match[1]) {
+				return fmt.Errorf("unknown placeholder %s in %s; use %s", match[0], field, strings.Join(t.Variables, ", "))
+			}
+			used[field+":"+match[1]] = true
+		}
+		if rest := placeholder.ReplaceAllString(value, ""); strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+			return fmt.Errorf("unbalanced braces in %s; placeholders are written {{name}}", field)
+		}
+	}
+	if t.Replyable && !used["text:reply_marker"] {
+		return fmt.Errorf("text must keep {{reply_marker}} so replies can be told apart from the quoted email")
+	}
+	return nil
+}
+
+// Render replaces the placeholders of s with values; placeholders without a
+// value are removed
+func Render(s string, values map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		return values[placeholderName(match)]
+	})
+}
+
+// placeholderName returns the name of a {{name}} placeholder
+func placeholderName(match string) string {
+	return placeholder.FindStringSubmatch(match)[1]
+}
@@ -0,0 +1,98 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedSender holds messages back so the next sender is handed no more
+// recipients per second than its provider accepts
+type RateLimitedSender struct {
+	next    EmailSender
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedSender creates a sender passing at most perSecond recipients
+// per second to next
+func NewRateLimitedSender(next EmailSender, perSecond float64) *RateLimitedSender {
+	burst := int(math.Max(1, math.Ceil(perSecond)))
+	return &RateLimitedSender{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(perSecond), burst),
+	}
+}
+
+// Send waits until the message's recipients fit the rate, then sends it
+func (s *RateLimitedSender) Send(ctx context.Context, msg *Message) error {
+	for range msg.To {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("email send rate exceeded: %w", err)
+		}
+	}
+	return s.next.Send(ctx, msg)
+}
+
+// Check verifies the provider of the next sender
+func (s *RateLimitedSender) Check(ctx context.Context) error {
+	return Check(ctx, s.next)
+}
+
+// SandboxSender keeps email from reaching real users in non-production
+// environments: only the allowed recipients get it and the rest is logged
+type SandboxSender struct {
+	next    EmailSender
+	allowed []string
+}
+
+// NewSandboxSender creates a sender passing to next only the recipients that
+// match allowed, given as full addresses or as @domain
+func NewSandboxSender(next EmailSender, allowed []string) *SandboxSender {
+	s := &SandboxSender{next: next}
+	for _, address := range allowed {
+		s.allowed = append(s.allowed, strings.ToLower(strings.TrimSpace(address)))
+	}
+	return s
+}
+
+// Send drops recipients that are not allowed and sends the message to the rest
+func (s *SandboxSender) Send(ctx context.Context, msg *Message) error {
+	var allowed, dropped []string
+	for _, to := range msg.To {
+		if s.isAllowed(to) {
+			allowed = append(allowed, to)
+		} else {
+			dropped = append(dropped, to)
+		}
+	}
+	if len(dropped) > 0 {
+		log.Printf("email (sandbox, not sent): to=%v subject=%q", dropped, msg.Subject)
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	sandboxed := *msg
+	sandboxed.To = allowed
+	return s.next.Send(ctx, &sandboxed)
+}
+
+// Check verifies the provider of the next sender
+func (s *SandboxSender) Check(ctx context.Context) error {
+	return Check(ctx, s.next)
+}
+
+// isAllowed reports whether address matches an allowed address or domain
+func (s *SandboxSender) isAllowed(address string) bool {
+	address = strings.ToLower(strings.TrimSpace(address))
+	for _, allowed := range s.allowed {
+		if address == allowed || (strings.HasPrefix(allowed, "@") && strings.HasSuffix(address, allowed)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,676 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/signedtoken"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// replyAddressPrefix is the local-part prefix of reply-to addresses, followed by the signed token
+const replyAddressPrefix = "reply+"
+
+// UnsubscribePath is the public route prefix that stops copying a subscriber on a ticket
+const UnsubscribePath = "/api/v1/public/unsubscribe/"
+
+// TicketPath is the portal page prefix showing a ticket, linked from notifications
+const TicketPath = "/tickets/"
+
+// ticketSummaryLength is how much of the description a ticket summary email quotes
+const ticketSummaryLength = 1000
+
+var (
+	// ErrReplyTokenMissing is returned when an inbound email is not addressed to a reply address
+	ErrReplyTokenMissing = errors.New("no reply address found in recipients")
+	// ErrReplyTokenInvalid is returned when a reply token is malformed, tampered with or expired
+	ErrReplyTokenInvalid = errors.New("invalid or expired reply token")
+	// ErrUnsubscribeLinkInvalid is returned when an unsubscribe token is malformed, tampered with or expired
+	ErrUnsubscribeLinkInvalid = errors.New("invalid or expired unsubscribe link")
+)
+
+// EmailChannelService handles ticket conversations over email: outbound
+// comment notifications carrying a signed reply-to address, and inbound
+// replies that are turned back into ticket comments. Addresses copied on a
+// ticket take part in the conversation the same way, without an account.
+type EmailChannelService struct {
+	ticketRepo     repository.TicketRepository
+	commentRepo    repository.CommentRepository
+	attachmentRepo repository.AttachmentRepository
+	userRepo       repository.UserRepository
+	sender         email.EmailSender
+	storage        storage.Storage
+	redaction      *RedactionService
+	templates      *EmailTemplateService
+	quietHours     *QuietHoursService
+	preferences    *NotificationService
+	signer         *signedtoken.Signer
+	unsubscribe    *signedtoken.Signer
+	config         *config.Config
+}
+
+// NewEmailChannelService creates a new email channel service; templates may
+// be nil to always send the built-in notification emails, quietHours nil to
+// send notifications regardless of their recipients' quiet hours, and
+// preferences nil to email every recipient regardless of their notification
+// preferences
+func NewEmailChannelService(
+	ticketRepo repository.TicketRepository,
+	commentRepo repository.CommentRepository,
+	attachmentRepo repository.AttachmentRepository,
+	userRepo repository.UserRepository,
+	sender email.EmailSender,
+	storage storage.Storage,
+	redaction *RedactionService,
+	templates *EmailTemplateService,
+	quietHours *QuietHoursService,
+	preferences *NotificationService,
+	config *config.Config,
+) *EmailChannelService {
+	return &EmailChannelService{
+		ticketRepo:     ticketRepo,
+		commentRepo:    commentRepo,
+		attachmentRepo: attachmentRepo,
+		userRepo:       userRepo,
+		sender:         sender,
+		storage:        storage,
+		redaction:      redaction,
+		templates:      templates,
+		quietHours:     quietHours,
+		preferences:    preferences,
+		signer:         signedtoken.NewSigner(config.Email.ReplySecretKey, "email-reply"),
+		unsubscribe:    signedtoken.NewSigner(config.Email.ReplySecretKey, "ticket-unsubscribe"),
+		config:         config,
+	}
+}
+
+// ReplyAddress returns the signed reply-to address for a ticket, or an empty
+// string when inbound replies are not configured
+func (s *EmailChannelService) ReplyAddress(ticketID uuid.UUID) string {
+	if s.config.Email.ReplyDomain == "" {
+		return ""
+	}
+
+	token := s.signer.Sign(ticketID[:], time.Now().Add(s.replyTokenTTL()))
+	return replyAddressPrefix + token + "@" + s.config.Email.ReplyDomain
+}
+
+// UnsubscribeURL returns the public link that stops copying a subscriber on
+// their ticket. It lasts as long as the reply address sent alongside it.
+func (s *EmailChannelService) UnsubscribeURL(subscriberID uuid.UUID) string {
+	token := s.unsubscribe.Sign(subscriberID[:], time.Now().Add(s.replyTokenTTL()))
+	return strings.TrimRight(s.config.Share.BaseURL, "/") + UnsubscribePath + token
+}
+
+// TicketURL returns the portal link to a ticket
+func (s *EmailChannelService) TicketURL(ticketID uuid.UUID) string {
+	return strings.TrimRight(s.config.Share.BaseURL, "/") + TicketPath + ticketID.String()
+}
+
+// Unsubscribe stops copying the subscriber an unsubscribe link was issued to.
+// Following a link again after it worked has no effect.
+func (s *EmailChannelService) Unsubscribe(ctx context.Context, token string) error {
+	payload, _, err := s.unsubscribe.Verify(token, time.Now())
+	if err != nil {
+		return ErrUnsubscribeLinkInvalid
+	}
+	subscriberID, err := uuid.FromBytes(payload)
+	if err != nil {
+		return ErrUnsubscribeLinkInvalid
+	}
+
+	if err := s.ticketRepo.RemoveSubscriber(ctx, subscriberID); err != nil {
+		return fmt.Errorf("failed to remove subscriber: %w", err)
+	}
+	return nil
+}
+
+// replyTokenTTL returns how long reply addresses and unsubscribe links stay valid
+func (s *EmailChannelService) replyTokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(s.config.Email.ReplyTokenTTL)
+	if err != nil {
+		ttl = 90 * 24 * time.Hour // fallback
+	}
+	return ttl
+}
+
+// NotifyCommentAdded emails the other side of the conversation about a new
+// public comment, and everyone copied on the ticket other than its author
+func (s *EmailChannelService) NotifyCommentAdded(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User) error {
+	if comment.IsInternal {
+		return nil
+	}
+
+	recipient, err := s.commentRecipient(ticket, author)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	notified := []string{author.Email}
+	if recipient != nil {
+		// Whoever opted out of comment emails is not copied as a subscriber either
+		notified = append(notified, recipient.Email)
+	}
+	if recipient != nil && len(s.preferences.EmailRecipients(ctx, models.NotificationTicketCommented, []*models.User{recipient})) > 0 {
+		values := ticketTemplateValues(ticket, recipient)
+		values["author_name"] = author.FullName()
+		values["comment"] = comment.Content
+		subject, text := s.templates.Render(ctx, email.TemplateCommentAdded, recipient.Language, values)
+
+		msg := s.commentMessage(ticket, comment, subject, text)
+		if err := s.notify(ctx, ticket, msg, recipient); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.notifySubscribers(ctx, ticket, comment, author, notified); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// notifySubscribers emails a public comment to each address copied on the
+// ticket, except those already notified. Subscribers have no account, so the
+// email carries an unsubscribe link in place of notification settings.
+func (s *EmailChannelService) notifySubscribers(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User, notified []string) error {
+	subscribers, err := s.ticketRepo.ListSubscribers(ctx, ticket.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list subscribers: %w", err)
+	}
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if slices.ContainsFunc(notified, func(address string) bool { return strings.EqualFold(address, subscriber.Email) }) {
+			continue
+		}
+
+		values := ticketTemplateValues(ticket, nil)
+		values["recipient_first_name"] = subscriber.FirstName()
+		values["recipient_name"] = subscriber.Name
+		values["author_name"] = author.FullName()
+		values["comment"] = comment.Content
+		subject, text := s.templates.Render(ctx, email.TemplateCommentAdded, "", values)
+
+		unsubscribeURL := s.UnsubscribeURL(subscriber.ID)
+		text += "\n\nYou are receiving this because you were copied on this ticket. To stop receiving these emails, unsubscribe: " + unsubscribeURL + "\n"
+		msg := s.commentMessage(ticket, comment, subject, text)
+		msg.To = []string{subscriber.Email}
+		msg.Headers["List-Unsubscribe"] = "<" + unsubscribeURL + ">"
+		msg.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+		if err := s.sender.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commentMessage builds the email about a comment, threaded on its ticket and
+// replying to the ticket's reply address
+func (s *EmailChannelService) commentMessage(ticket *models.Ticket, comment *models.Comment, subject, text string) *email.Message {
+	threadID := s.messageID("ticket", ticket.ID)
+	return &email.Message{
+		Subject: subject,
+		Text:    text,
+		ReplyTo: s.ReplyAddress(ticket.ID),
+		Headers: map[string]string{
+			"Message-ID":  s.messageID("comment", comment.ID),
+			"In-Reply-To": threadID,
+			"References":  threadID,
+		},
+	}
+}
+
+// NotifyMentioned emails staff who were mentioned in a comment, including
+// internal notes. Recipients preferring the same language share one email.
+func (s *EmailChannelService) NotifyMentioned(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User, recipients []*models.User) error {
+	values := ticketTemplateValues(ticket, nil)
+	values["author_name"] = author.FullName()
+	values["comment"] = comment.Content
+	recipients = s.preferences.EmailRecipients(ctx, models.NotificationTicketCommented, recipients)
+	return s.notifyStaff(ctx, ticket, email.TemplateMentioned, values, recipients)
+}
+
+// NotifyReassignedOften alerts managers that a ticket has been assigned to
+// many different agents
+func (s *EmailChannelService) NotifyReassignedOften(ctx context.Context, ticket *models.Ticket, agents int, managers []*models.User) error {
+	values := ticketTemplateValues(ticket, nil)
+	values["agent_count"] = strconv.Itoa(agents)
+	return s.notifyStaff(ctx, ticket, email.TemplateReassigned, values, managers)
+}
+
+// notifyStaff sends a notification threaded on the ticket to staff, or to
+// anyone else it is not personal to, rendering the template once per language
+// so recipients preferring the same language share one email
+func (s *EmailChannelService) notifyStaff(ctx context.Context, ticket *models.Ticket, template string, values map[string]string, recipients []*models.User) error {
+	var languages []string
+	byLanguage := make(map[string][]*models.User)
+	for _, recipient := range recipients {
+		if _, ok := byLanguage[recipient.Language]; !ok {
+			languages = append(languages, recipient.Language)
+		}
+		byLanguage[recipient.Language] = append(byLanguage[recipient.Language], recipient)
+	}
+
+	threadID := s.messageID("ticket", ticket.ID)
+	var errs []error
+	for _, language := range languages {
+		subject, text := s.templates.Render(ctx, template, language, values)
+		msg := &email.Message{
+			Subject: subject,
+			Text:    text,
+			Headers: map[string]string{
+				"In-Reply-To": threadID,
+				"References":  threadID,
+			},
+		}
+		if err := s.notify(ctx, ticket, msg, byLanguage[language]...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyEscalated emails the user a ticket was escalated to, with the reason
+func (s *EmailChannelService) NotifyEscalated(ctx context.Context, ticket *models.Ticket, escalatedBy, escalatedTo *models.User) error {
+	if len(s.preferences.EmailRecipients(ctx, models.NotificationTicketEscalated, []*models.User{escalatedTo})) == 0 {
+		return nil
+	}
+	threadID := s.messageID("ticket", ticket.ID)
+	values := ticketTemplateValues(ticket, escalatedTo)
+	values["author_name"] = escalatedBy.FullName()
+	values["reason"] = ticket.EscalationReason
+	subject, text := s.templates.Render(ctx, email.TemplateEscalated, escalatedTo.Language, values)
+
+	msg := &email.Message{
+		Subject: subject,
+		Text:    text,
+		Headers: map[string]string{
+			"In-Reply-To": threadID,
+			"References":  threadID,
+		},
+	}
+
+	return s.notify(ctx, ticket, msg, escalatedTo)
+}
+
+// NotifyAssigned emails the agent a ticket was assigned to, with a link to it
+func (s *EmailChannelService) NotifyAssigned(ctx context.Context, ticket *models.Ticket, assignedBy, assignee *models.User) error {
+	if len(s.preferences.EmailRecipients(ctx, models.NotificationTicketAssigned, []*models.User{assignee})) == 0 {
+		return nil
+	}
+	threadID := s.messageID("ticket", ticket.ID)
+	values := ticketTemplateValues(ticket, assignee)
+	values["author_name"] = assignedBy.FullName()
+	values["ticket_url"] = s.TicketURL(ticket.ID)
+	subject, text := s.templates.Render(ctx, email.TemplateAssigned, assignee.Language, values)
+
+	msg := &email.Message{
+		Subject: subject,
+		Text:    text,
+		Headers: map[string]string{
+			"In-Reply-To": threadID,
+			"References":  threadID,
+		},
+	}
+
+	return s.notify(ctx, ticket, msg, assignee)
+}
+
+// NotifyDeEscalated emails the assignee an escalated ticket was handed back
+// to, with the note left for them
+func (s *EmailChannelService) NotifyDeEscalated(ctx context.Context, ticket *models.Ticket, returnedBy, assignee *models.User, note string) error {
+	if len(s.preferences.EmailRecipients(ctx, models.NotificationTicketEscalated, []*models.User{assignee})) == 0 {
+		return nil
+	}
+	threadID := s.messageID("ticket", ticket.ID)
+	values := ticketTemplateValues(ticket, assignee)
+	values["author_name"] = returnedBy.FullName()
+	values["comment"] = note
+	subject, text := s.templates.Render(ctx, email.TemplateDeEscalated, assignee.Language, values)
+
+	msg := &email.Message{
+		Subject: subject,
+		Text:    text,
+		Headers: map[string]string{
+			"In-Reply-To": threadID,
+			"References":  threadID,
+		},
+	}
+
+	return s.notify(ctx, ticket, msg, assignee)
+}
+
+// NotifyStatusChanged emails the requester and assignee of a ticket that its
+// status changed, with a link to it
+func (s *EmailChannelService) NotifyStatusChanged(ctx context.Context, ticket *models.Ticket, changedBy *models.User, previous models.TicketStatus, recipients []*models.User) error {
+	recipients = s.preferences.EmailRecipients(ctx, models.NotificationTicketStatusChanged, recipients)
+	values := ticketTemplateValues(ticket, nil)
+	values["author_name"] = changedBy.FullName()
+	values["previous_status"] = string(previous)
+	values["ticket_url"] = s.TicketURL(ticket.ID)
+	return s.notifyStaff(ctx, ticket, email.TemplateStatusChanged, values, recipients)
+}
+
+// NotifyTicketReceived acknowledges a ticket submitted by guest form, email or chat,
+// giving the requester a reply-to address to continue the conversation
+func (s *EmailChannelService) NotifyTicketReceived(ctx context.Context, ticket *models.Ticket, requester *models.User) error {
+	subject, text := s.templates.Render(ctx, email.TemplateTicketReceived, requester.Language, ticketTemplateValues(ticket, requester))
+
+	msg := &email.Message{
+		Subject: subject,
+		Text:    text,
+		ReplyTo: s.ReplyAddress(ticket.ID),
+		Headers: map[string]string{
+			"Message-ID": s.messageID("ticket", ticket.ID),
+		},
+	}
+
+	return s.notify(ctx, ticket, msg, requester)
+}
+
+// NotifyTicketCreated sends the requester of a new ticket a summary of it to
+// keep, with when to expect a response under the SLA policy and a link to
+// follow its status. responseTarget is 0 when the policy promises none.
+func (s *EmailChannelService) NotifyTicketCreated(ctx context.Context, ticket *models.Ticket, requester *models.User, responseTarget time.Duration) error {
+	values := ticketTemplateValues(ticket, requester)
+	values["ticket_summary"] = truncateSummary(ticket.Description, ticketSummaryLength)
+	values["ticket_url"] = s.TicketURL(ticket.ID)
+	values["expected_response"] = "as soon as possible"
+	if responseTarget > 0 {
+		values["expected_response"] = "within " + describeDuration(responseTarget)
+	}
+	subject, text := s.templates.Render(ctx, email.TemplateTicketCreated, requester.Language, values)
+
+	msg := &email.Message{
+		Subject: subject,
+		Text:    text,
+		ReplyTo: s.ReplyAddress(ticket.ID),
+		Headers: map[string]string{
+			"Message-ID": s.messageID("ticket", ticket.ID),
+		},
+	}
+
+	return s.notify(ctx, ticket, msg, requester)
+}
+
+// notify sends a notification about a ticket to the recipients, holding it
+// back for those in their quiet hours unless the ticket is urgent
+func (s *EmailChannelService) notify(ctx context.Context, ticket *models.Ticket, msg *email.Message, recipients ...*models.User) error {
+	if s.quietHours != nil {
+		return s.quietHours.Send(ctx, msg, recipients, s.quietHours.Urgent(ticket))
+	}
+
+	msg.To = nil
+	for _, recipient := range recipients {
+		msg.To = append(msg.To, recipient.Email)
+	}
+	return s.sender.Send(ctx, msg)
+}
+
+// ProcessInboundReply maps an inbound reply to its ticket and stores it as a
+// comment. A reply with an attachment that could not be uploaded is refused.
+// Announcing the reply is up to the caller, through the notifiers.
+func (s *EmailChannelService) ProcessInboundReply(ctx context.Context, inbound *models.InboundEmail) (*models.Comment, error) {
+	ticketID, err := s.ticketIDFromRecipients(inbound.To)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	from, err := mail.ParseAddress(inbound.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sender address", ErrInvalidInput)
+	}
+
+	sender, err := s.userRepo.GetByEmail(from.Address)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get sender: %w", err)
+	}
+	// The token only identifies the ticket; the sender must also be a participant
+	if sender == nil || (sender.ID != ticket.CreatedByID && !sender.IsAgent()) {
+		if sender, err = s.subscriberSender(ctx, ticket, from.Address, sender); err != nil {
+			return nil, err
+		}
+	}
+
+	body := inbound.Text
+	if strings.TrimSpace(body) == "" {
+		body = email.HTMLToText(inbound.HTML)
+	}
+	content := email.ExtractReply(body)
+	if content == "" && len(inbound.Attachments) == 0 {
+		return nil, fmt.Errorf("%w: reply is empty", ErrInvalidInput)
+	}
+	if content == "" {
+		content = "(attachments only)"
+	}
+
+	for _, attachment := range inbound.Attachments {
+		if err := s.checkAttachment(attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	content, redactions := s.redaction.Redact(content)
+
+	comment := &models.Comment{
+		TicketID: ticket.ID,
+		UserID:   sender.ID,
+		Content:  content,
+	}
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+	comment.User = sender
+	s.redaction.RecordRedactions(ctx, models.AuditEntityComment, comment.ID, sender.ID, redactions)
+
+	for _, inboundAttachment := range inbound.Attachments {
+		attachment, err := s.storeAttachment(ctx, ticket.ID, &comment.ID, sender.ID, inboundAttachment)
+		if err != nil {
+			return nil, err
+		}
+		comment.Attachments = append(comment.Attachments, *attachment)
+	}
+
+	return comment, nil
+}
+
+// subscriberSender returns the author of a reply sent by an address copied on
+// the ticket: the user with that address, or a new guest account for it, since
+// every comment has an author. It refuses senders who are not copied.
+func (s *EmailChannelService) subscriberSender(ctx context.Context, ticket *models.Ticket, address string, user *models.User) (*models.User, error) {
+	subscribers, err := s.ticketRepo.ListSubscribers(ctx, ticket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+
+	for _, subscriber := range subscribers {
+		if !strings.EqualFold(subscriber.Email, address) {
+			continue
+		}
+		if user != nil {
+			return user, nil
+		}
+		return findOrCreateGuest(s.userRepo, subscriber.Email, subscriber.Name)
+	}
+	return nil, fmt.Errorf("%w: sender is not allowed to reply to this ticket", ErrForbidden)
+}
+
+// checkAttachment refuses an inbound attachment uploading the same file would
+// refuse: one that is empty, over the email or the upload size limit, or not
+// of an allowed type
+func (s *EmailChannelService) checkAttachment(inbound models.InboundAttachment) error {
+	size := int64(len(inbound.Content))
+	if size > s.config.Email.MaxAttachmentSize || size > s.config.Storage.MaxUploadSize {
+		return fmt.Errorf("%w: attachment %s exceeds the maximum size", ErrInvalidInput, inbound.Filename)
+	}
+	if size == 0 {
+		return fmt.Errorf("%w: attachment %s is empty", ErrInvalidInput, inbound.Filename)
+	}
+	if mimeType := uploadMimeType(inbound.Filename, inbound.ContentType); !isAllowedType(s.config.Storage.AllowedTypes, mimeType) {
+		return fmt.Errorf("%w: attachment %s is of type %s, which cannot be attached", ErrInvalidInput, inbound.Filename, mimeType)
+	}
+	return nil
+}
+
+// storeAttachment saves an inbound attachment checked with checkAttachment and
+// records it against the ticket and optional comment
+func (s *EmailChannelService) storeAttachment(ctx context.Context, ticketID uuid.UUID, commentID *uuid.UUID, uploadedByID uuid.UUID, inbound models.InboundAttachment) (*models.Attachment, error) {
+	filename := sanitizeFilename(inbound.Filename)
+	key := path.Join("attachments", ticketID.String(), uuid.New().String()+"-"+filename)
+
+	size, err := s.storage.Save(ctx, key, bytes.NewReader(inbound.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	mimeType := uploadMimeType(filename, inbound.ContentType)
+
+	attachment := &models.Attachment{
+		TicketID:     ticketID,
+		CommentID:    commentID,
+		Filename:     filename,
+		FilePath:     key,
+		FileSize:     size,
+		MimeType:     mimeType,
+		UploadedByID: uploadedByID,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// commentRecipient determines who should be emailed about a comment:
+// agent comments go to the requester, requester comments to the assigned agent
+func (s *EmailChannelService) commentRecipient(ticket *models.Ticket, author *models.User) (*models.User, error) {
+	var recipientID *uuid.UUID
+	if author.ID == ticket.CreatedByID {
+		recipientID = ticket.AssignedAgentID
+	} else {
+		recipientID = &ticket.CreatedByID
+	}
+
+	if recipientID == nil || *recipientID == author.ID {
+		return nil, nil
+	}
+
+	recipient, err := s.userRepo.GetByID(recipientID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+	if recipient == nil || !recipient.IsActive {
+		return nil, nil
+	}
+	return recipient, nil
+}
+
+// ticketIDFromRecipients finds the reply address among the recipients and verifies its token
+func (s *EmailChannelService) ticketIDFromRecipients(recipients []string) (uuid.UUID, error) {
+	for _, recipient := range recipients {
+		addresses, err := mail.ParseAddressList(recipient)
+		if err != nil {
+			continue
+		}
+
+		for _, address := range addresses {
+			localPart, _, found := strings.Cut(strings.ToLower(address.Address), "@")
+			if !found || !strings.HasPrefix(localPart, replyAddressPrefix) {
+				continue
+			}
+
+			payload, _, err := s.signer.Verify(strings.TrimPrefix(localPart, replyAddressPrefix), time.Now())
+			if err != nil {
+				return uuid.Nil, ErrReplyTokenInvalid
+			}
+			ticketID, err := uuid.FromBytes(payload)
+			if err != nil {
+				return uuid.Nil, ErrReplyTokenInvalid
+			}
+			return ticketID, nil
+		}
+	}
+
+	return uuid.Nil, ErrReplyTokenMissing
+}
+
+// messageID builds a stable RFC 5322 Message-ID for threading
+func (s *EmailChannelService) messageID(kind string, id uuid.UUID) string {
+	return fmt.Sprintf("<%s-%s@%s>", kind, id, email.MessageIDDomain(s.config.Email))
+}
+
+// shortTicketRef returns the short reference used in email subjects
+func shortTicketRef(ticketID uuid.UUID) string {
+	return strings.ToUpper(ticketID.String()[:8])
+}
+
+// ticketTemplateValues returns the placeholder values of a ticket notification
+// sent to recipient, which is nil when there are several recipients
+func ticketTemplateValues(ticket *models.Ticket, recipient *models.User) map[string]string {
+	values := map[string]string{
+		"ticket_title":     ticket.Title,
+		"ticket_reference": shortTicketRef(ticket.ID),
+		"ticket_status":    string(ticket.Status),
+		"ticket_priority":  string(ticket.Priority),
+		"reply_marker":     email.ReplyMarker,
+	}
+	if recipient != nil {
+		values["recipient_first_name"] = recipient.FirstName
+		values["recipient_name"] = recipient.FullName()
+	}
+	return values
+}
+
+// truncateSummary shortens a ticket description to at most n runes, ending
+// it with an ellipsis when it was cut
+func truncateSummary(description string, n int) string {
+	description = strings.TrimSpace(description)
+	runes := []rune(description)
+	if len(runes) <= n {
+		return description
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
+
+// sanitizeFilename strips directory components and unsafe characters from an uploaded filename
+func sanitizeFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	if filename == "" || filename == "." || filename == ".." {
+		return "attachment"
+	}
+	if len(filename) > 200 {
+		filename = filename[len(filename)-200:]
+	}
+	return filename
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailDeliveryService records the status of every outbound email, applies
+// bounce and complaint reports from the mail provider, and stops sending to
+// addresses that hard-bounced or complained. It is an email.EmailSender that
+// delivers through the next sender.
+type EmailDeliveryService struct {
+	next             email.EmailSender
+	deliveryRepo     repository.EmailDeliveryRepository
+	userRepo         repository.UserRepository
+	failures         FailureRecorder
+	messageIDDomain  string
+	failureThreshold int
+}
+
+// NewEmailDeliveryService creates a new email delivery service delivering
+// through next. Emails the provider does not accept are kept by failures, which
+// may be nil, for a later retry.
+func NewEmailDeliveryService(next email.EmailSender, deliveryRepo repository.EmailDeliveryRepository, userRepo repository.UserRepository, failures FailureRecorder, cfg *config.Config) *EmailDeliveryService {
+	return &EmailDeliveryService{
+		next:             next,
+		deliveryRepo:     deliveryRepo,
+		userRepo:         userRepo,
+		failures:         failures,
+		messageIDDomain:  email.MessageIDDomain(cfg.Email),
+		failureThreshold: cfg.Email.FailureThreshold,
+	}
+}
+
+// Send records a delivery per recipient and sends the message to those whose
+// address is not suppressed. Messages without a Message-ID get one, so the
+// provider's reports can be matched to them. Emails the provider does not
+// accept are kept for a retry.
+func (s *EmailDeliveryService) Send(ctx context.Context, msg *email.Message) error {
+	tracked, err := s.send(ctx, msg)
+	if err != nil && tracked != nil && s.failures != nil {
+		s.failures.RecordFailure(ctx, models.FailedTaskEmail, msg.Subject, tracked, err)
+	}
+	return err
+}
+
+// RetryTask sends an email the provider did not accept again, to the
+// recipients that have not been suppressed since
+func (s *EmailDeliveryService) RetryTask(ctx context.Context, task *models.FailedTask) error {
+	var msg email.Message
+	if err := json.Unmarshal([]byte(task.Payload), &msg); err != nil {
+		return fmt.Errorf("invalid email payload: %w", err)
+	}
+	_, err := s.send(ctx, &msg)
+	return err
+}
+
+// send records and sends a message, returning it as handed to the provider,
+// or nil when it was not
+func (s *EmailDeliveryService) send(ctx context.Context, msg *email.Message) (*email.Message, error) {
+	tracked := *msg
+	tracked.Headers = maps.Clone(msg.Headers)
+	if tracked.Headers == nil {
+		tracked.Headers = make(map[string]string)
+	}
+	messageID := tracked.Headers["Message-ID"]
+	if messageID == "" {
+		messageID = fmt.Sprintf("<%s@%s>", uuid.New(), s.messageIDDomain)
+		tracked.Headers["Message-ID"] = messageID
+	}
+
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		recipients = append(recipients, normalizeAddress(to))
+	}
+	suppressed, err := s.deliveryRepo.FindSuppressed(ctx, recipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppressed addresses: %w", err)
+	}
+
+	deliveries := make([]*models.EmailDelivery, 0, len(recipients))
+	tracked.To = nil
+	for i, recipient := range recipients {
+		status := models.DeliveryQueued
+		if slices.Contains(suppressed, recipient) {
+			status = models.DeliverySuppressed
+		} else {
+			tracked.To = append(tracked.To, msg.To[i])
+		}
+		deliveries = append(deliveries, &models.EmailDelivery{
+			MessageID: messageID,
+			Recipient: recipient,
+			Subject:   truncate(msg.Subject, 255),
+			Status:    status,
+		})
+	}
+	if err := s.deliveryRepo.Create(ctx, deliveries); err != nil {
+		return nil, fmt.Errorf("failed to record email deliveries: %w", err)
+	}
+	if len(tracked.To) == 0 {
+		return nil, nil
+	}
+
+	sendErr := s.next.Send(ctx, &tracked)
+	for _, delivery := range deliveries {
+		if delivery.Status == models.DeliverySuppressed {
+			continue
+		}
+		delivery.Status = models.DeliverySent
+		if sendErr != nil {
+			delivery.Status = models.DeliveryFailed
+			delivery.Detail = sendErr.Error()
+		}
+		if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+			log.Printf("Failed to record delivery of email %s to %s: %v", messageID, delivery.Recipient, err)
+			continue
+		}
+		if sendErr != nil {
+			s.flagIfFailing(ctx, delivery.Recipient)
+		}
+	}
+	return &tracked, sendErr
+}
+
+// ApplyEvents updates deliveries from the mail provider's reports. Hard
+// bounces and complaints suppress the address and flag its user; soft bounces
+// flag the user once enough emails in a row have failed; deliveries clear the
+// flag. Events naming no email sent to the recipient are counted as unmatched.
+func (s *EmailDeliveryService) ApplyEvents(ctx context.Context, events []models.EmailDeliveryEvent) (*models.EmailDeliveryEventsResponse, error) {
+	result := &models.EmailDeliveryEventsResponse{}
+	for _, event := range events {
+		recipient := normalizeAddress(event.Recipient)
+		messageID := event.MessageID
+		if messageID != "" && !strings.HasPrefix(messageID, "<") {
+			messageID = "<" + messageID + ">"
+		}
+
+		delivery, err := s.deliveryRepo.FindLatest(ctx, messageID, recipient)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				result.Unmatched++
+				continue
+			}
+			return nil, fmt.Errorf("failed to find email delivery: %w", err)
+		}
+		if err := s.applyEvent(ctx, delivery, &event); err != nil {
+			return nil, err
+		}
+		result.Applied++
+	}
+	return result, nil
+}
+
+// applyEvent applies one provider report to a delivery
+func (s *EmailDeliveryService) applyEvent(ctx context.Context, delivery *models.EmailDelivery, event *models.EmailDeliveryEvent) error {
+	switch event.Event {
+	case "delivered":
+		// A late delivery report must not hide an earlier bounce or complaint
+		if delivery.Status != models.DeliveryQueued && delivery.Status != models.DeliverySent {
+			return nil
+		}
+		delivery.Status = models.DeliveryDelivered
+	case "bounce":
+		delivery.Status = models.DeliveryBounced
+		delivery.BounceType = models.BounceSoft
+		if event.BounceType == models.BounceHard {
+			delivery.BounceType = models.BounceHard
+		}
+		delivery.Detail = event.Reason
+	case "complaint":
+		delivery.Status = models.DeliveryComplained
+		delivery.Detail = event.Reason
+	}
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update email delivery: %w", err)
+	}
+
+	switch {
+	case delivery.Status == models.DeliveryDelivered:
+		if _, err := s.deliveryRepo.GetSuppression(ctx, delivery.Recipient); errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := s.userRepo.SetEmailFailing(delivery.Recipient, nil); err != nil {
+				return fmt.Errorf("failed to clear failing email flag: %w", err)
+			}
+		}
+	case delivery.Status == models.DeliveryComplained || delivery.BounceType == models.BounceHard:
+		reason := models.SuppressionHardBounce
+		if delivery.Status == models.DeliveryComplained {
+			reason = models.SuppressionComplaint
+		}
+		if err := s.deliveryRepo.Suppress(ctx, &models.EmailSuppression{Address: delivery.Recipient, Reason: reason, DeliveryID: &delivery.ID}); err != nil {
+			return fmt.Errorf("failed to suppress email address: %w", err)
+		}
+		now := time.Now()
+		if err := s.userRepo.SetEmailFailing(delivery.Recipient, &now); err != nil {
+			return fmt.Errorf("failed to flag failing email: %w", err)
+		}
+	default:
+		s.flagIfFailing(ctx, delivery.Recipient)
+	}
+	return nil
+}
+
+// flagIfFailing flags the user with the given address once their latest
+// emails have all failed or bounced
+func (s *EmailDeliveryService) flagIfFailing(ctx context.Context, recipient string) {
+	if s.failureThreshold <= 0 {
+		return
+	}
+	statuses, err := s.deliveryRepo.ListRecentStatuses(ctx, recipient, s.failureThreshold)
+	if err != nil {
+		log.Printf("Failed to check recent deliveries to %s: %v", recipient, err)
+		return
+	}
+	if len(statuses) < s.failureThreshold {
+		return
+	}
+	for _, status := range statuses {
+		if status != models.DeliveryFailed && status != models.DeliveryBounced {
+			return
+		}
+	}
+	now := time.Now()
+	if err := s.userRepo.SetEmailFailing(recipient, &now); err != nil {
+		log.Printf("Failed to flag failing email %s: %v", recipient, err)
+	}
+}
+
+// Check verifies that the email provider is reachable and accepts our credentials
+func (s *EmailDeliveryService) Check(ctx context.Context) error {
+	return email.Check(ctx, s.next)
+}
+
+// LastDelivery returns when the email provider last accepted an email
+func (s *EmailDeliveryService) LastDelivery(ctx context.Context) (*time.Time, error) {
+	return s.deliveryRepo.LastSent(ctx)
+}
+
+// List returns deliveries matching the query, newest first
+func (s *EmailDeliveryService) List(ctx context.Context, query *models.EmailDeliveryQuery) (*models.EmailDeliveryListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 50
+	}
+	query.Recipient = normalizeAddress(query.Recipient)
+	return s.deliveryRepo.List(ctx, query)
+}
+
+// ListSuppressions returns the addresses no email is sent to
+func (s *EmailDeliveryService) ListSuppressions(ctx context.Context) ([]models.EmailSuppression, error) {
+	suppressions, err := s.deliveryRepo.ListSuppressions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email suppressions: %w", err)
+	}
+	return suppressions, nil
+}
+
+// Unsuppress lets email be sent to an address again, for example after its
+// owner fixed their mailbox, and clears their user's flag
+func (s *EmailDeliveryService) Unsuppress(ctx context.Context, address string) error {
+	address = normalizeAddress(address)
+	if _, err := s.deliveryRepo.GetSuppression(ctx, address); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("email suppression %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get email suppression: %w", err)
+	}
+	if err := s.deliveryRepo.DeleteSuppression(ctx, address); err != nil {
+		return fmt.Errorf("failed to delete email suppression: %w", err)
+	}
+	if err := s.userRepo.SetEmailFailing(address, nil); err != nil {
+		return fmt.Errorf("failed to clear failing email flag: %w", err)
+	}
+	return nil
+}
+
+// ListFailingUsers returns the users whose notifications are failing
+func (s *EmailDeliveryService) ListFailingUsers(ctx context.Context) ([]*models.User, error) {
+	users, err := s.userRepo.ListEmailFailing()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users with failing email: %w", err)
+	}
+	return users, nil
+}
+
+// normalizeAddress lower-cases an email address so deliveries, reports and
+// suppressions of the same mailbox match
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sampleTemplateValues fill template previews with a sample ticket
+var sampleTemplateValues = map[string]string{
+	"ticket_title":         "Cannot sign in to the customer portal",
+	"ticket_reference":     "1A2B3C4D",
+	"ticket_status":        string(models.StatusOpen),
+	"ticket_priority":      string(models.PriorityHigh),
+	"recipient_first_name": "Alex",
+	"recipient_name":       "Alex Morgan",
+	"author_name":          "Sam Taylor",
+	"comment":              "Could you try resetting your password from the sign-in page and let us know if that helps?",
+	"agent_count":          "4",
+	"reason":               "Customers on the enterprise plan are affected and need a fix today",
+	"reply_marker":         email.ReplyMarker,
+	"ticket_url":           "https://support.example.com/tickets/1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+	"verify_url":           "https://support.example.com/verify-email?token=sample",
+	"reset_url":            "https://support.example.com/reset-password?token=sample",
+	"link_expires_in":      "1 hour",
+	"ticket_summary":       "Since this morning the portal says my password is wrong, although it works in the mobile app.",
+	"expected_response":    "within 4 hours",
+	"previous_status":      string(models.StatusInProgress),
+}
+
+// EmailTemplateService lets administrators replace the subject and text of
+// notification emails, and renders them for sending
+type EmailTemplateService struct {
+	templateRepo    repository.EmailTemplateRepository
+	defaultLanguage string
+}
+
+// NewEmailTemplateService creates a new email template service
+func NewEmailTemplateService(templateRepo repository.EmailTemplateRepository, cfg *config.Config) *EmailTemplateService {
+	return &EmailTemplateService{
+		templateRepo:    templateRepo,
+		defaultLanguage: cfg.Email.DefaultLanguage,
+	}
+}
+
+// List returns every notification template as it is currently sent
+func (s *EmailTemplateService) List(ctx context.Context) ([]models.EmailTemplateResponse, error) {
+	customized, err := s.templateRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+	byName := make(map[string]*models.EmailTemplate, len(customized))
+	for i := range customized {
+		byName[customized[i].Name] = &customized[i]
+	}
+
+	templates := make([]models.EmailTemplateResponse, 0, len(email.DefaultTemplates))
+	for _, template := range email.DefaultTemplates {
+		response := templateResponse(template, byName[template.Name])
+		if response.Translations, err = s.translationLanguages(ctx, template.Name); err != nil {
+			return nil, err
+		}
+		templates = append(templates, *response)
+	}
+	return templates, nil
+}
+
+// Get returns a notification template as it is currently sent
+func (s *EmailTemplateService) Get(ctx context.Context, name string) (*models.EmailTemplateResponse, error) {
+	template, customized, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	response := templateResponse(template, customized)
+	if response.Translations, err = s.translationLanguages(ctx, name); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Update replaces a template's subject and text after checking their placeholders
+func (s *EmailTemplateService) Update(ctx context.Context, name string, req *models.EmailTemplateRequest, actorID uuid.UUID) (*models.EmailTemplateResponse, error) {
+	template, _, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := template.Validate(req.Subject, req.Text); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	customized := &models.EmailTemplate{
+		Name:        name,
+		Subject:     strings.TrimSpace(req.Subject),
+		Text:        req.Text,
+		UpdatedByID: &actorID,
+	}
+	if err := s.templateRepo.Save(ctx, customized); err != nil {
+		return nil, fmt.Errorf("failed to save email template: %w", err)
+	}
+	response := templateResponse(template, customized)
+	if response.Translations, err = s.translationLanguages(ctx, name); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Revert goes back to the built-in subject and text of a template; its
+// translations are kept
+func (s *EmailTemplateService) Revert(ctx context.Context, name string) (*models.EmailTemplateResponse, error) {
+	template, customized, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if customized != nil {
+		if err := s.templateRepo.Delete(ctx, name); err != nil {
+			return nil, fmt.Errorf("failed to revert email template: %w", err)
+		}
+	}
+	response := templateResponse(template, nil)
+	if response.Translations, err = s.translationLanguages(ctx, name); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListTranslations returns a template's translations, by language
+func (s *EmailTemplateService) ListTranslations(ctx context.Context, name string) ([]models.EmailTemplateTranslation, error) {
+	if _, _, err := s.lookup(ctx, name); err != nil {
+		return nil, err
+	}
+	translations, err := s.templateRepo.ListTranslations(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email template translations: %w", err)
+	}
+	return translations, nil
+}
+
+// SaveTranslation adds or replaces a template's translation into a language,
+// checking its placeholders like Update does
+func (s *EmailTemplateService) SaveTranslation(ctx context.Context, name, language string, req *models.EmailTemplateRequest, actorID uuid.UUID) (*models.EmailTemplateTranslation, error) {
+	template, _, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if language, err = normalizeLanguage(language); err != nil {
+		return nil, err
+	}
+	if err := template.Validate(req.Subject, req.Text); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	translation := &models.EmailTemplateTranslation{
+		Name:        name,
+		Language:    language,
+		Subject:     strings.TrimSpace(req.Subject),
+		Text:        req.Text,
+		UpdatedByID: &actorID,
+	}
+	if err := s.templateRepo.SaveTranslation(ctx, translation); err != nil {
+		return nil, fmt.Errorf("failed to save email template translation: %w", err)
+	}
+	return translation, nil
+}
+
+// DeleteTranslation removes a template's translation, so recipients preferring
+// that language fall back to the default language
+func (s *EmailTemplateService) DeleteTranslation(ctx context.Context, name, language string) error {
+	if _, _, err := s.lookup(ctx, name); err != nil {
+		return err
+	}
+	language, err := normalizeLanguage(language)
+	if err != nil {
+		return err
+	}
+	if _, err := s.templateRepo.GetTranslation(ctx, name, language); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("email template translation %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get email template translation: %w", err)
+	}
+	if err := s.templateRepo.DeleteTranslation(ctx, name, language); err != nil {
+		return fmt.Errorf("failed to delete email template translation: %w", err)
+	}
+	return nil
+}
+
+// Preview renders a template with a sample ticket, as sent to a recipient
+// preferring the requested language. A draft subject and text are rendered
+// instead of the saved ones when given, so edits can be checked before they
+// are saved.
+func (s *EmailTemplateService) Preview(ctx context.Context, name string, draft *models.EmailTemplatePreviewRequest) (*models.EmailTemplatePreview, error) {
+	template, _, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	language, err := normalizeLanguage(draft.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.EmailTemplatePreview{}
+	var subject, text string
+	if draft.Subject != "" || draft.Text != "" {
+		subject, text = draft.Subject, draft.Text
+		if err := template.Validate(subject, text); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		preview.Language = language
+	} else {
+		preview.Language, subject, text = s.resolve(ctx, name, language)
+	}
+
+	preview.Subject = email.Render(subject, sampleTemplateValues)
+	preview.Text = email.Render(text, sampleTemplateValues)
+	return preview, nil
+}
+
+// Render returns the subject and text of a notification for a recipient
+// preferring language, with the given placeholder values. The template is
+// picked from the translations into that language or the default language,
+// then the untranslated template. When a template cannot be loaded the
+// built-in one is used, so notifications are still sent.
+func (s *EmailTemplateService) Render(ctx context.Context, name, language string, values map[string]string) (string, string) {
+	var subject, text string
+	if s == nil {
+		template, _ := email.DefaultTemplate(name)
+		subject, text = template.Subject, template.Text
+	} else {
+		_, subject, text = s.resolve(ctx, name, language)
+	}
+	return email.Render(subject, values), email.Render(text, values)
+}
+
+// resolve returns the subject and text sent to a recipient preferring
+// language, and the language of the translation used, if any
+func (s *EmailTemplateService) resolve(ctx context.Context, name, language string) (string, string, string) {
+	for _, candidate := range languageFallbacks(language, s.defaultLanguage) {
+		translation, err := s.templateRepo.GetTranslation(ctx, name, candidate)
+		if err == nil {
+			return candidate, translation.Subject, translation.Text
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load %s translation of email template %s: %v", candidate, name, err)
+		}
+	}
+
+	template, _ := email.DefaultTemplate(name)
+	customized, err := s.templateRepo.Get(ctx, name)
+	switch {
+	case err == nil:
+		return "", customized.Subject, customized.Text
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Printf("Failed to load email template %s, using the default: %v", name, err)
+	}
+	return "", template.Subject, template.Text
+}
+
+// translationLanguages returns the languages a template is translated into
+func (s *EmailTemplateService) translationLanguages(ctx context.Context, name string) ([]string, error) {
+	translations, err := s.templateRepo.ListTranslations(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email template translations: %w", err)
+	}
+	languages := make([]string, 0, len(translations))
+	for _, translation := range translations {
+		languages = append(languages, translation.Language)
+	}
+	return languages, nil
+}
+
+// lookup returns the built-in template with the given name and its
+// customization, which is nil while the default is in use
+func (s *EmailTemplateService) lookup(ctx context.Context, name string) (email.Template, *models.EmailTemplate, error) {
+	template, ok := email.DefaultTemplate(name)
+	if !ok {
+		return template, nil, fmt.Errorf("email template %w", ErrNotFound)
+	}
+
+	customized, err := s.templateRepo.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return template, nil, nil
+		}
+		return template, nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+	return template, customized, nil
+}
+
+// templateResponse describes a template, using its customization when there is one
+func templateResponse(template email.Template, customized *models.EmailTemplate) *models.EmailTemplateResponse {
+	response := &models.EmailTemplateResponse{
+		Name:        template.Name,
+		Description: template.Description,
+		Subject:     template.Subject,
+		Text:        template.Text,
+		Variables:   template.Variables,
+	}
+	if customized != nil {
+		response.Subject = customized.Subject
+		response.Text = customized.Text
+		response.Customized = true
+		response.UpdatedByID = customized.UpdatedByID
+		updatedAt := customized.UpdatedAt
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/encryption"
+	"github.com/google/uuid"
+)
+
+// ErrEncryptionDisabled is returned when no encryption keys are configured
+var ErrEncryptionDisabled = errors.New("encryption at rest is not configured")
+
+// rotationBatchSize bounds the rows loaded per query during key rotation
+const rotationBatchSize = 200
+
+// EncryptionService manages encryption at rest
+type EncryptionService struct {
+	columnRepo   repository.EncryptedColumnRepository
+	auditService *AuditService
+	keyring      *encryption.Keyring
+}
+
+// NewEncryptionService creates a new encryption service; keyring may be nil when encryption is disabled
+func NewEncryptionService(columnRepo repository.EncryptedColumnRepository, auditService *AuditService, keyring *encryption.Keyring) *EncryptionService {
+	return &EncryptionService{
+		columnRepo:   columnRepo,
+		auditService: auditService,
+		keyring:      keyring,
+	}
+}
+
+// RotateKeys re-encrypts every stored value that is plaintext or sealed with a
+// retired key. Once it completes, retired keys can be removed from the keyring.
+func (s *EncryptionService) RotateKeys(ctx context.Context, actorID uuid.UUID) (*models.KeyRotationResponse, error) {
+	if s.keyring == nil {
+		return nil, ErrEncryptionDisabled
+	}
+
+	response := &models.KeyRotationResponse{
+		PrimaryKeyID: s.keyring.PrimaryKeyID(),
+		Rotated:      make(map[string]int),
+	}
+
+	for _, column := range models.EncryptedColumns {
+		rotated, err := s.rotateColumn(ctx, column)
+		response.Rotated[column.String()] = rotated
+		if err != nil {
+			return nil, fmt.Errorf("failed to rotate %s: %w", column, err)
+		}
+	}
+
+	if err := s.auditService.Record(ctx, &actorID, models.AuditActionKeyRotation, models.AuditEntityEncryption, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// rotateColumn re-encrypts the values of one column in ID order
+func (s *EncryptionService) rotateColumn(ctx context.Context, column models.EncryptedColumn) (int, error) {
+	rotated := 0
+	afterID := ""
+	for {
+		values, err := s.columnRepo.ListRaw(ctx, column, afterID, rotationBatchSize)
+		if err != nil {
+			return rotated, err
+		}
+
+		for _, value := range values {
+			if !s.keyring.NeedsRotation(value.Value) {
+				continue
+			}
+
+			plaintext := value.Value
+			if encryption.IsEncrypted(plaintext) {
+				if plaintext, err = s.keyring.Decrypt(plaintext); err != nil {
+					return rotated, fmt.Errorf("row %s: %w", value.ID, err)
+				}
+			}
+
+			ciphertext, err := s.keyring.Encrypt(plaintext)
+			if err != nil {
+				return rotated, err
+			}
+			if err := s.columnRepo.UpdateRaw(ctx, column, value.ID, ciphertext); err != nil {
+				return rotated, fmt.Errorf("row %s: %w", value.ID, err)
+			}
+			rotated++
+		}
+
+		if len(values) < rotationBatchSize {
+			return rotated, nil
+		}
+		afterID = values[len(values)-1].ID
+	}
+}
@@ -0,0 +1,16 @@
+package services
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when a requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the caller is not allowed to perform an action
+	ErrForbidden = errors.New("insufficient permissions")
+	// ErrInvalidInput is returned when a request is well-formed but semantically invalid
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrConflict is returned when an action conflicts with the current state of a resource
+	ErrConflict = errors.New("conflict")
+	// ErrTooLarge is returned when an upload exceeds the configured size limit
+	ErrTooLarge = errors.New("too large")
+)
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// exportCatchUpDays limits how many missed days one nightly run exports
+const exportCatchUpDays = 7
+
+// exportDatasets are the tables shipped to the warehouse. Free text such as
+// titles, descriptions and comment bodies is left out so exports carry no
+// customer content; every ticket version is exported so changes can be replayed.
+var exportDatasets = []models.ExportDataset{
+	{
+		Name:       "ticket_versions",
+		Table:      "tickets",
+		TimeColumn: "creation_time",
+		Columns: []string{"id", "creation_time", "expiration_time", "status", "priority", "category_id", "assigned_agent_id",
+			"team_id", "created_by_id", "opened_by", "escalated_at", "escalated_to", "resolved_at", "due_date", "version_id"},
+	},
+	{
+		Name:       "comments",
+		Table:      "comments",
+		TimeColumn: "created_at",
+		Columns:    []string{"id", "ticket_id", "user_id", "is_internal", "created_at"},
+	},
+	{
+		Name:       "audit_events",
+		Table:      "audit_logs",
+		TimeColumn: "created_at",
+		Columns:    []string{"id", "actor_id", "action", "entity_type", "entity_id", "created_at"},
+	},
+	{
+		// Sessions are exported on the day they last changed
+		Name:       "chat_sessions",
+		Table:      "chat_sessions",
+		TimeColumn: "updated_at",
+		Columns: []string{"id", "status", "agent_id", "team_id", "ticket_id", "queued_at", "accepted_at", "ended_at",
+			"rating", "created_at", "updated_at"},
+	},
+	{
+		Name:       "chat_queue_events",
+		Table:      "chat_queue_events",
+		TimeColumn: "created_at",
+		Columns:    []string{"id", "session_id", "type", "wait_seconds", "created_at"},
+	},
+}
+
+// ExportService ships raw ticket and event data to the analytics warehouse as
+// one CSV file per dataset per day
+type ExportService struct {
+	exportRepo repository.ExportRepository
+	storage    storage.Storage
+	prefix     string
+	location   *time.Location
+}
+
+// NewExportService creates a new export service. Days are office days, in the
+// office hours time zone.
+func NewExportService(exportRepo repository.ExportRepository, storage storage.Storage, config *config.Config) *ExportService {
+	location, err := time.LoadLocation(config.Widget.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return &ExportService{
+		exportRepo: exportRepo,
+		storage:    storage,
+		prefix:     config.Export.Prefix,
+		location:   location,
+	}
+}
+
+// Schedule registers the nightly export to run at a clock time such as "02:00"
+func (s *ExportService) Schedule(jobs *scheduler.Scheduler, at string) error {
+	return jobs.Daily("analytics export", at, s.location, s.RunNightly)
+}
+
+// RunNightly exports every day after the last exported one up to yesterday,
+// so days missed while the server was down are caught up
+func (s *ExportService) RunNightly(ctx context.Context) error {
+	today := s.today()
+	first := today.AddDate(0, 0, -1)
+	if latest, err := s.exportRepo.List(ctx, 1); err != nil {
+		return fmt.Errorf("failed to find the last export: %w", err)
+	} else if len(latest) > 0 {
+		if last, err := time.ParseInLocation(time.DateOnly, latest[0].Day, s.location); err == nil {
+			first = last.AddDate(0, 0, 1)
+		}
+	}
+	if earliest := today.AddDate(0, 0, -exportCatchUpDays); first.Before(earliest) {
+		first = earliest
+	}
+
+	for day := first; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if _, err := s.ExportDay(ctx, day.Format(time.DateOnly), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportDay exports one finished office day, replacing any earlier export of it
+func (s *ExportService) ExportDay(ctx context.Context, day string, triggeredBy *uuid.UUID) (*models.DataExport, error) {
+	start, err := time.ParseInLocation(time.DateOnly, day, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: day must be a YYYY-MM-DD date", ErrInvalidInput)
+	}
+	if !start.Before(s.today()) {
+		return nil, fmt.Errorf("%w: only finished days can be exported", ErrInvalidInput)
+	}
+	end := start.AddDate(0, 0, 1)
+
+	export := &models.DataExport{Day: day, TriggeredByID: triggeredBy}
+	for _, dataset := range exportDatasets {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(dataset.Columns)
+		err := s.exportRepo.EachRow(ctx, dataset, start, end, func(values []any) error {
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = exportValue(value)
+			}
+			export.Rows++
+			return w.Write(record)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s for %s: %w", dataset.Name, day, err)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write %s for %s: %w", dataset.Name, day, err)
+		}
+
+		// Hive-style partitions let warehouse loaders pick days up by path
+		key := path.Join(s.prefix, dataset.Name, "date="+day, dataset.Name+".csv")
+		if _, err := s.storage.Save(ctx, key, &buf); err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		export.Files = append(export.Files, key)
+	}
+
+	if err := s.exportRepo.Save(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to record export: %w", err)
+	}
+	return s.exportRepo.GetByDay(ctx, day)
+}
+
+// ListExports returns the most recently exported days first
+func (s *ExportService) ListExports(ctx context.Context) ([]models.DataExport, error) {
+	exports, err := s.exportRepo.List(ctx, 90)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetExport returns the export of a day
+func (s *ExportService) GetExport(ctx context.Context, day string) (*models.DataExport, error) {
+	export, err := s.exportRepo.GetByDay(ctx, day)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s has not been exported", ErrNotFound, day)
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	return export, nil
+}
+
+// Check verifies that export storage accepts files by writing and removing a
+// probe file next to the datasets
+func (s *ExportService) Check(ctx context.Context) error {
+	key := path.Join(s.prefix, ".healthcheck")
+	if _, err := s.storage.Save(ctx, key, strings.NewReader("ok")); err != nil {
+		return fmt.Errorf("failed to write to export storage: %w", err)
+	}
+	if err := s.storage.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete from export storage: %w", err)
+	}
+	return nil
+}
+
+// LastDelivery returns when the most recent day was exported
+func (s *ExportService) LastDelivery(ctx context.Context) (*time.Time, error) {
+	latest, err := s.exportRepo.List(ctx, 1)
+	if err != nil || len(latest) == 0 {
+		return nil, err
+	}
+	return &latest[0].UpdatedAt, nil
+}
+
+// today returns the start of the current office day
+func (s *ExportService) today() time.Time {
+	now := time.Now().In(s.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+}
+
+// exportValue formats a column value for CSV, with times in UTC
+func exportValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
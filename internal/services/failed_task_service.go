@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FailureRecorder keeps background work that failed so it can be retried later
+type FailureRecorder interface {
+	RecordFailure(ctx context.Context, kind, name string, payload any, failure error)
+}
+
+// TaskRunner retries failed background work of one kind
+type TaskRunner interface {
+	RetryTask(ctx context.Context, task *models.FailedTask) error
+}
+
+// TaskRunnerFunc adapts a function to TaskRunner
+type TaskRunnerFunc func(ctx context.Context, task *models.FailedTask) error
+
+// RetryTask calls f(ctx, task)
+func (f TaskRunnerFunc) RetryTask(ctx context.Context, task *models.FailedTask) error {
+	return f(ctx, task)
+}
+
+// FailedTaskService keeps failed background work, such as scheduled jobs,
+// notification emails and audit batches for SIEM sinks, and lets
+// administrators retry or discard it
+type FailedTaskService struct {
+	taskRepo     repository.FailedTaskRepository
+	auditService *AuditService
+
+	mu      sync.Mutex
+	runners map[string]TaskRunner
+}
+
+// NewFailedTaskService creates a new failed task service. Audit batches that
+// the audit service's SIEM sinks do not accept are always kept and retried.
+func NewFailedTaskService(taskRepo repository.FailedTaskRepository, auditService *AuditService) *FailedTaskService {
+	s := &FailedTaskService{
+		taskRepo:     taskRepo,
+		auditService: auditService,
+		runners:      make(map[string]TaskRunner),
+	}
+	s.Register(models.FailedTaskAuditSink, auditService)
+	auditService.forwarder.OnFailure(func(sink string, batch []models.AuditLog, err error) {
+		s.RecordFailure(context.Background(), models.FailedTaskAuditSink, sink, batch, err)
+	})
+	return s
+}
+
+// WatchJobs keeps the failed runs of the scheduler's jobs, which are retried
+// by running the job again
+func (s *FailedTaskService) WatchJobs(jobs *scheduler.Scheduler) {
+	jobs.OnFailure(func(name string, err error) {
+		s.RecordFailure(context.Background(), models.FailedTaskJob, name, nil, err)
+	})
+	s.Register(models.FailedTaskJob, TaskRunnerFunc(func(ctx context.Context, task *models.FailedTask) error {
+		return jobs.Run(ctx, task.Name)
+	}))
+}
+
+// Register sets the runner that retries failed tasks of a kind
+func (s *FailedTaskService) Register(kind string, runner TaskRunner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runners[kind] = runner
+}
+
+// RecordFailure keeps failed work with the payload a retry needs. Work
+// without a payload, such as a scheduled job, is kept once: later failures
+// are counted on it. Failing to keep it is logged, not returned.
+func (s *FailedTaskService) RecordFailure(ctx context.Context, kind, name string, payload any, failure error) {
+	// The work may have failed because ctx was cancelled
+	ctx = context.WithoutCancel(ctx)
+	name = truncate(name, 255)
+
+	if payload == nil {
+		task, err := s.taskRepo.FindByName(ctx, kind, name)
+		if err == nil {
+			task.Error = failure.Error()
+			task.Failures++
+			task.LastFailedAt = time.Now()
+			if err := s.taskRepo.Update(ctx, task); err != nil {
+				log.Printf("Failed to record failure of %s %s: %v", kind, name, err)
+			}
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to record failure of %s %s: %v", kind, name, err)
+			return
+		}
+	}
+
+	task := &models.FailedTask{
+		Kind:         kind,
+		Name:         name,
+		Error:        failure.Error(),
+		Failures:     1,
+		LastFailedAt: time.Now(),
+	}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Failed to record failure of %s %s: %v", kind, name, err)
+			return
+		}
+		task.Payload = string(data)
+	}
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		log.Printf("Failed to record failure of %s %s: %v", kind, name, err)
+	}
+}
+
+// List returns failed tasks matching the query, newest first
+func (s *FailedTaskService) List(ctx context.Context, query *models.FailedTaskQuery) (*models.FailedTaskListResponse, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 50
+	}
+	tasks, err := s.taskRepo.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// Get returns a failed task with its payload
+func (s *FailedTaskService) Get(ctx context.Context, id uuid.UUID) (*models.FailedTaskDetail, error) {
+	task, err := s.getTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	detail := &models.FailedTaskDetail{FailedTask: *task}
+	if task.Payload != "" {
+		detail.Payload = json.RawMessage(task.Payload)
+	}
+	return detail, nil
+}
+
+// Retry runs failed tasks again. Tasks that succeed are removed; tasks that
+// fail again keep the new error and are reported in the result's Failed.
+func (s *FailedTaskService) Retry(ctx context.Context, ids []uuid.UUID, actorID uuid.UUID) (*models.FailedTaskBulkResult, error) {
+	result := s.each(ctx, ids, func(task *models.FailedTask) error {
+		s.mu.Lock()
+		runner := s.runners[task.Kind]
+		s.mu.Unlock()
+		if runner == nil {
+			return fmt.Errorf("%s tasks cannot be retried on this server", task.Kind)
+		}
+
+		if err := runner.RetryTask(ctx, task); err != nil {
+			task.Error = err.Error()
+			task.Failures++
+			task.LastFailedAt = time.Now()
+			if updateErr := s.taskRepo.Update(ctx, task); updateErr != nil {
+				log.Printf("Failed to record failed retry of task %s: %v", task.ID, updateErr)
+			}
+			return err
+		}
+		return s.taskRepo.Delete(ctx, task.ID)
+	})
+
+	if err := s.auditService.Record(ctx, &actorID, models.AuditActionFailedTaskRetry, models.AuditEntitySystem, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Discard removes failed tasks without running them again
+func (s *FailedTaskService) Discard(ctx context.Context, ids []uuid.UUID, actorID uuid.UUID) (*models.FailedTaskBulkResult, error) {
+	result := s.each(ctx, ids, func(task *models.FailedTask) error {
+		return s.taskRepo.Delete(ctx, task.ID)
+	})
+
+	if err := s.auditService.Record(ctx, &actorID, models.AuditActionFailedTaskDiscard, models.AuditEntitySystem, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// each applies fn to every task in turn, collecting which succeeded
+func (s *FailedTaskService) each(ctx context.Context, ids []uuid.UUID, fn func(task *models.FailedTask) error) *models.FailedTaskBulkResult {
+	result := &models.FailedTaskBulkResult{Succeeded: []uuid.UUID{}}
+	for _, id := range ids {
+		task, err := s.getTask(ctx, id)
+		if err == nil {
+			err = fn(task)
+		}
+		if err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[id.String()] = err.Error()
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, id)
+	}
+	return result
+}
+
+// getTask loads a failed task
+func (s *FailedTaskService) getTask(ctx context.Context, id uuid.UUID) (*models.FailedTask, error) {
+	task, err := s.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed task %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get failed task: %w", err)
+	}
+	return task, nil
+}
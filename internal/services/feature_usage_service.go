@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultFeatureUsageDays is how many days the usage report covers unless asked otherwise
+	defaultFeatureUsageDays = 30
+	// maxFeatureUsageDays bounds the range of a usage report
+	maxFeatureUsageDays = 366
+	// defaultFeatureUsageLimit is how many routes and users the report lists unless asked otherwise
+	defaultFeatureUsageLimit = 20
+	maxFeatureUsageLimit     = 100
+)
+
+// featureUsageKey identifies the hourly count a request is added to
+type featureUsageKey struct {
+	hour   time.Time
+	method string
+	route  string
+	userID uuid.UUID
+}
+
+// FeatureUsageService records which API routes are used, by whom and how
+// fast they answer, and reports on it so the features worth investing in can
+// be told from those nobody uses. Requests are counted in memory and written
+// to the database every FEATURE_USAGE_FLUSH_INTERVAL, so recording one costs
+// no database round trip.
+type FeatureUsageService struct {
+	usageRepo     repository.FeatureUsageRepository
+	flushInterval time.Duration
+
+	mu      sync.Mutex
+	pending map[featureUsageKey]*models.FeatureUsage
+}
+
+// NewFeatureUsageService creates a new feature usage service
+func NewFeatureUsageService(usageRepo repository.FeatureUsageRepository, config *config.Config) *FeatureUsageService {
+	flushInterval, err := time.ParseDuration(config.FeatureUsage.FlushInterval)
+	if err != nil || flushInterval <= 0 {
+		flushInterval = time.Minute // fallback
+	}
+
+	return &FeatureUsageService{
+		usageRepo:     usageRepo,
+		flushInterval: flushInterval,
+		pending:       make(map[featureUsageKey]*models.FeatureUsage),
+	}
+}
+
+// Record counts a request to a route, made at the given time by the user
+// (nil when anonymous), that was answered with status after latency
+func (s *FeatureUsageService) Record(at time.Time, method, route string, user *models.User, status int, latency time.Duration) {
+	key := featureUsageKey{hour: at.UTC().Truncate(time.Hour), method: method, route: route}
+	var role models.UserRole
+	if user != nil {
+		key.userID, role = user.ID, user.Role
+	}
+	micros := latency.Microseconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage, ok := s.pending[key]
+	if !ok {
+		usage = &models.FeatureUsage{Hour: key.hour, Method: method, Route: route, UserID: key.userID}
+		s.pending[key] = usage
+	}
+	usage.Role = role
+	usage.Requests++
+	if status >= 400 {
+		usage.Errors++
+	}
+	usage.LatencyMicros += micros
+	usage.MaxLatencyMicros = max(usage.MaxLatencyMicros, micros)
+}
+
+// Flush writes the requests counted since the last flush to the database. If
+// the write fails the counts are kept and written with the next flush.
+func (s *FeatureUsageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[featureUsageKey]*models.FeatureUsage)
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	usage := make([]models.FeatureUsage, 0, len(pending))
+	for _, counts := range pending {
+		usage = append(usage, *counts)
+	}
+	if err := s.usageRepo.Record(ctx, usage); err != nil {
+		s.restore(pending)
+		return fmt.Errorf("failed to record feature usage: %w", err)
+	}
+	return nil
+}
+
+// restore adds counts that could not be written back to those waiting
+func (s *FeatureUsageService) restore(counts map[featureUsageKey]*models.FeatureUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, usage := range counts {
+		current, ok := s.pending[key]
+		if !ok {
+			s.pending[key] = usage
+			continue
+		}
+		current.Requests += usage.Requests
+		current.Errors += usage.Errors
+		current.LatencyMicros += usage.LatencyMicros
+		current.MaxLatencyMicros = max(current.MaxLatencyMicros, usage.MaxLatencyMicros)
+	}
+}
+
+// Schedule writes the counted requests to the database every flush interval
+func (s *FeatureUsageService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("feature usage", s.flushInterval, s.Flush)
+}
+
+// Close writes the requests counted since the last flush, for use on shutdown
+func (s *FeatureUsageService) Close(ctx context.Context) {
+	if err := s.Flush(ctx); err != nil {
+		log.Println("Failed to record remaining feature usage:", err)
+	}
+}
+
+// Report summarizes the API usage between from and to, inclusive YYYY-MM-DD
+// dates in UTC that default to the last 30 days, listing up to limit routes
+// and users
+func (s *FeatureUsageService) Report(ctx context.Context, from, to string, limit int) (*models.FeatureUsageReport, error) {
+	if limit == 0 {
+		limit = defaultFeatureUsageLimit
+	}
+	if limit < 1 || limit > maxFeatureUsageLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, maxFeatureUsageLimit)
+	}
+	start, end, err := usageRange(from, to, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.FeatureUsageReport{From: start, To: end}
+	if report.Requests, report.Users, err = s.usageRepo.Totals(ctx, start, end); err != nil {
+		return nil, fmt.Errorf("failed to count feature usage: %w", err)
+	}
+	if report.Features, err = s.usageRepo.ListFeatures(ctx, start, end, limit); err != nil {
+		return nil, fmt.Errorf("failed to summarize feature usage: %w", err)
+	}
+	if report.Roles, err = s.usageRepo.ListRoles(ctx, start, end); err != nil {
+		return nil, fmt.Errorf("failed to summarize feature usage by role: %w", err)
+	}
+	if report.TopUsers, err = s.usageRepo.ListTopUsers(ctx, start, end, limit); err != nil {
+		return nil, fmt.Errorf("failed to summarize feature usage by user: %w", err)
+	}
+	return report, nil
+}
+
+// usageRange parses an inclusive range of YYYY-MM-DD dates in UTC, returning
+// the start of the first day and the end of the last
+func usageRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	last := now.UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		var err error
+		if last, err = time.Parse(time.DateOnly, to); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidInput)
+		}
+	}
+	first := last.AddDate(0, 0, 1-defaultFeatureUsageDays)
+	if from != "" {
+		var err error
+		if first, err = time.Parse(time.DateOnly, from); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidInput)
+		}
+	}
+	if first.After(last) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidInput)
+	}
+	end := last.AddDate(0, 0, 1)
+	if end.After(first.AddDate(0, 0, maxFeatureUsageDays)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: ranges are limited to %d days", ErrInvalidInput, maxFeatureUsageDays)
+	}
+	return first, end, nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// alertSeverities maps the usual Prometheus severity labels to ticket
+// priorities, for severities an integration's mapping does not name
+var alertSeverities = map[string]models.TicketPriority{
+	"critical": models.PriorityCritical,
+	"error":    models.PriorityHigh,
+	"warning":  models.PriorityMedium,
+	"info":     models.PriorityLow,
+}
+
+// ReceiveAlertmanager turns a Prometheus Alertmanager webhook notification
+// into a ticket for its alert group. Alerts are told apart by fingerprint: a
+// notification about an alert that opened a ticket before, or about a group
+// that did, updates that ticket rather than opening another. Alerts that
+// start firing or resolve are commented on; firing reopens a resolved ticket
+// and the ticket is resolved when its last alert clears. Repeated
+// notifications that change nothing leave the ticket alone.
+func (s *InboundService) ReceiveAlertmanager(ctx context.Context, key string, body []byte, secret, signature string) (*models.AlertmanagerResult, error) {
+	integration, requester, err := s.authorize(ctx, key, body, secret, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload models.AlertmanagerPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: the payload is not an Alertmanager notification", ErrInvalidInput)
+	}
+	if len(payload.Alerts) == 0 {
+		return nil, fmt.Errorf("%w: the notification has no alerts", ErrInvalidInput)
+	}
+	fingerprints := make([]string, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		if alert.Fingerprint == "" {
+			return nil, fmt.Errorf("%w: every alert needs a fingerprint, which Alertmanager 0.19 and later send", ErrInvalidInput)
+		}
+		fingerprints = append(fingerprints, alert.Fingerprint)
+	}
+
+	s.receiving.Lock()
+	defer s.receiving.Unlock()
+
+	known, err := s.integrationRepo.ListAlerts(ctx, integration.ID, fingerprints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	ticket, err := s.alertTicket(ctx, integration.ID, payload.GroupKey, known)
+	if err != nil {
+		return nil, err
+	}
+
+	firing, resolved := alertChanges(payload.Alerts, known)
+	result := &models.AlertmanagerResult{}
+	if ticket == nil {
+		if len(firing) == 0 {
+			// Only alerts that never opened a ticket have resolved
+			return result, nil
+		}
+		ticket, err = s.ticketService.CreateTicket(ctx, &models.CreateTicketRequest{
+			Title:       truncate(alertGroupTitle(&payload), 255),
+			Description: alertGroupDescription(&payload),
+			Priority:    alertPriority(integration.Mapping, &payload),
+			CategoryID:  integration.CategoryID,
+			TeamID:      integration.TeamID,
+		}, requester.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Created = true
+	} else {
+		if err := s.applyAlertChanges(ctx, ticket, firing, resolved, requester); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, alert := range payload.Alerts {
+		record := &models.InboundAlert{
+			IntegrationID: integration.ID,
+			Fingerprint:   alert.Fingerprint,
+			GroupKey:      payload.GroupKey,
+			TicketID:      ticket.ID,
+			Name:          truncate(alert.Labels["alertname"], 255),
+			Firing:        alert.Status != models.AlertResolved,
+			StartsAt:      alert.StartsAt,
+		}
+		if !record.Firing && !alert.EndsAt.IsZero() {
+			endsAt := alert.EndsAt
+			record.EndsAt = &endsAt
+		}
+		if err := s.integrationRepo.SaveAlert(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to save alert: %w", err)
+		}
+	}
+
+	result.TicketID = &ticket.ID
+	result.Status = ticket.Status
+	result.Firing, err = s.integrationRepo.CountFiringAlerts(ctx, ticket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count firing alerts: %w", err)
+	}
+	// Only the notification clearing the last alert resolves the ticket, so
+	// agents can keep working on it afterwards. A category may need resolution
+	// details no alert can give; the ticket is then left for an agent.
+	if result.Firing == 0 && len(resolved) > 0 && (ticket.Status == models.StatusOpen || ticket.Status == models.StatusInProgress) {
+		if err := s.ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: models.StatusResolved}, requester.ID); err != nil {
+			log.Printf("failed to resolve ticket %s of cleared alerts: %v", ticket.ID, err)
+		} else {
+			result.Status = models.StatusResolved
+		}
+	}
+
+	if err := s.integrationRepo.MarkReceived(ctx, integration.ID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to update inbound integration: %w", err)
+	}
+	return result, nil
+}
+
+// alertTicket returns the ticket of an alert group: the one an alert of the
+// notification opened before, else the one the group last updated. It is nil
+// when there is none or it was deleted since.
+func (s *InboundService) alertTicket(ctx context.Context, integrationID uuid.UUID, groupKey string, known []models.InboundAlert) (*models.Ticket, error) {
+	candidates := make([]uuid.UUID, 0, len(known)+1)
+	for _, alert := range known {
+		candidates = append(candidates, alert.TicketID)
+	}
+	latest, err := s.integrationRepo.LatestAlertOfGroup(ctx, integrationID, groupKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert group: %w", err)
+	}
+	if latest != nil {
+		candidates = append(candidates, latest.TicketID)
+	}
+
+	for _, ticketID := range candidates {
+		ticket, err := s.ticketService.GetTicket(ctx, ticketID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ticket: %w", err)
+		}
+		return ticket, nil
+	}
+	return nil, nil
+}
+
+// applyAlertChanges reopens the ticket of an alert group when alerts start
+// firing again, and comments on the alerts that started firing or resolved
+func (s *InboundService) applyAlertChanges(ctx context.Context, ticket *models.Ticket, firing, resolved []models.AlertmanagerAlert, requester *models.User) error {
+	if len(firing) > 0 && (ticket.Status == models.StatusResolved || ticket.Status == models.StatusClosed) {
+		reopened := models.StatusInProgress
+		if ticket.Status == models.StatusClosed {
+			reopened = models.StatusOpen
+		}
+		if err := s.ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: reopened}, requester.ID); err != nil {
+			return err
+		}
+		ticket.Status = reopened
+	}
+
+	if len(firing) == 0 && len(resolved) == 0 {
+		return nil
+	}
+	var comment strings.Builder
+	for _, section := range []struct {
+		heading string
+		alerts  []models.AlertmanagerAlert
+	}{{"Firing:", firing}, {"Resolved:", resolved}} {
+		if len(section.alerts) == 0 {
+			continue
+		}
+		if comment.Len() > 0 {
+			comment.WriteString("\n")
+		}
+		comment.WriteString(section.heading + "\n")
+		for _, alert := range section.alerts {
+			comment.WriteString("- " + alertLine(alert) + "\n")
+		}
+	}
+	_, err := s.ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: strings.TrimSpace(comment.String())}, requester)
+	return err
+}
+
+// alertChanges returns the alerts of a notification that started firing,
+// including those that fire again, and those that resolved since the
+// integration last heard of them
+func alertChanges(alerts []models.AlertmanagerAlert, known []models.InboundAlert) (firing, resolved []models.AlertmanagerAlert) {
+	wasFiring := make(map[string]bool, len(known))
+	for _, alert := range known {
+		wasFiring[alert.Fingerprint] = alert.Firing
+	}
+	for _, alert := range alerts {
+		before, seen := wasFiring[alert.Fingerprint]
+		switch {
+		case alert.Status != models.AlertResolved && !before:
+			firing = append(firing, alert)
+		case alert.Status == models.AlertResolved && seen && before:
+			resolved = append(resolved, alert)
+		}
+	}
+	return firing, resolved
+}
+
+// alertGroupTitle names an alert group after its alert and grouping labels,
+// such as "HighCPU (instance=web1, job=node)"
+func alertGroupTitle(payload *models.AlertmanagerPayload) string {
+	name := payload.CommonLabels["alertname"]
+	if name == "" {
+		name = cmp.Or(payload.Alerts[0].Labels["alertname"], "Alert")
+	}
+	labels := alertLabels(payload.GroupLabels)
+	if labels == "" {
+		return name
+	}
+	return name + " (" + labels + ")"
+}
+
+// alertGroupDescription describes an alert group with its summary and alerts
+func alertGroupDescription(payload *models.AlertmanagerPayload) string {
+	var description strings.Builder
+	for _, annotation := range []string{"summary", "description"} {
+		if text := strings.TrimSpace(payload.CommonAnnotations[annotation]); text != "" {
+			description.WriteString(text + "\n\n")
+		}
+	}
+	description.WriteString("Alerts:\n")
+	for _, alert := range payload.Alerts {
+		description.WriteString("- " + alertLine(alert))
+		if alert.GeneratorURL != "" {
+			description.WriteString(" (" + alert.GeneratorURL + ")")
+		}
+		description.WriteString("\n")
+	}
+	if payload.ExternalURL != "" {
+		description.WriteString("\nAlertmanager: " + payload.ExternalURL)
+	}
+	return strings.TrimSpace(description.String())
+}
+
+// alertLine describes an alert by its name, labels and summary
+func alertLine(alert models.AlertmanagerAlert) string {
+	line := cmp.Or(alert.Labels["alertname"], "Alert")
+	if labels := alertLabels(alert.Labels); labels != "" {
+		line += " " + labels
+	}
+	if summary := strings.TrimSpace(alert.Annotations["summary"]); summary != "" {
+		line += ": " + summary
+	}
+	return line
+}
+
+// alertLabels lists labels other than the alert name as sorted name=value pairs
+func alertLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		if name != "alertname" {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// alertPriority maps the severity label of an alert group to a ticket
+// priority through the integration's mapping, else the usual severities
+func alertPriority(mapping models.InboundMapping, payload *models.AlertmanagerPayload) models.TicketPriority {
+	severity := payload.CommonLabels["severity"]
+	if severity == "" {
+		severity = payload.Alerts[0].Labels["severity"]
+	}
+	if priority, ok := mappedPriority(mapping, severity); ok {
+		return priority
+	}
+	if priority, ok := alertSeverities[strings.ToLower(severity)]; ok {
+		return priority
+	}
+	return defaultPriority(mapping)
+}
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInboundUnauthorized is returned when a payload is posted to an unknown or
+// disabled inbound integration, or carries neither its secret nor a valid
+// signature. The cases are not told apart, so keys cannot be probed.
+var ErrInboundUnauthorized = errors.New("unknown inbound integration or invalid secret")
+
+// inboundKeyPattern limits integration keys to URL-safe lowercase words
+var inboundKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// inboundPlaceholder matches the {{path}} placeholders of mapping templates
+var inboundPlaceholder = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// InboundService manages inbound integrations and turns the payloads external
+// systems post to them into tickets
+type InboundService struct {
+	integrationRepo repository.InboundIntegrationRepository
+	userRepo        repository.UserRepository
+	categoryRepo    repository.CategoryRepository
+	teamRepo        repository.TeamRepository
+	ticketService   *TicketService
+	// receiving serializes payloads, so two about the same external ID
+	// cannot both open a ticket
+	receiving sync.Mutex
+}
+
+// NewInboundService creates a new inbound service
+func NewInboundService(integrationRepo repository.InboundIntegrationRepository, userRepo repository.UserRepository, categoryRepo repository.CategoryRepository, teamRepo repository.TeamRepository, ticketService *TicketService) *InboundService {
+	return &InboundService{
+		integrationRepo: integrationRepo,
+		userRepo:        userRepo,
+		categoryRepo:    categoryRepo,
+		teamRepo:        teamRepo,
+		ticketService:   ticketService,
+	}
+}
+
+// ListIntegrations lists every inbound integration
+func (s *InboundService) ListIntegrations(ctx context.Context) ([]models.InboundIntegration, error) {
+	integrations, err := s.integrationRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inbound integrations: %w", err)
+	}
+	return integrations, nil
+}
+
+// GetIntegration retrieves an inbound integration by ID
+func (s *InboundService) GetIntegration(ctx context.Context, id uuid.UUID) (*models.InboundIntegration, error) {
+	integration, err := s.integrationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("inbound integration %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get inbound integration: %w", err)
+	}
+	return integration, nil
+}
+
+// CreateIntegration creates an inbound integration with a new secret, which
+// is returned only this once
+func (s *InboundService) CreateIntegration(ctx context.Context, req *models.InboundIntegrationRequest, createdByID uuid.UUID) (*models.InboundIntegrationSecretResponse, error) {
+	integration := &models.InboundIntegration{Enabled: true, CreatedByID: createdByID}
+	if err := s.apply(ctx, integration, req); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	integration.Secret = secret
+
+	if err := s.integrationRepo.Create(ctx, integration); err != nil {
+		return nil, fmt.Errorf("failed to create inbound integration: %w", err)
+	}
+	return &models.InboundIntegrationSecretResponse{Integration: integration, Secret: secret}, nil
+}
+
+// UpdateIntegration replaces the settings and mapping of an inbound
+// integration; its secret is kept
+func (s *InboundService) UpdateIntegration(ctx context.Context, id uuid.UUID, req *models.InboundIntegrationRequest) (*models.InboundIntegration, error) {
+	integration, err := s.GetIntegration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, integration, req); err != nil {
+		return nil, err
+	}
+	if err := s.integrationRepo.Update(ctx, integration); err != nil {
+		return nil, fmt.Errorf("failed to update inbound integration: %w", err)
+	}
+	return integration, nil
+}
+
+// DeleteIntegration deletes an inbound integration. Its tickets are kept.
+func (s *InboundService) DeleteIntegration(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetIntegration(ctx, id); err != nil {
+		return err
+	}
+	if err := s.integrationRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete inbound integration: %w", err)
+	}
+	return nil
+}
+
+// RotateSecret replaces the secret of an inbound integration; callers using
+// the old one are refused from then on
+func (s *InboundService) RotateSecret(ctx context.Context, id uuid.UUID) (*models.InboundIntegrationSecretResponse, error) {
+	integration, err := s.GetIntegration(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	integration.Secret = secret
+	if err := s.integrationRepo.Update(ctx, integration); err != nil {
+		return nil, fmt.Errorf("failed to update inbound integration: %w", err)
+	}
+	return &models.InboundIntegrationSecretResponse{Integration: integration, Secret: secret}, nil
+}
+
+// apply validates an integration request and copies it onto the integration
+func (s *InboundService) apply(ctx context.Context, integration *models.InboundIntegration, req *models.InboundIntegrationRequest) error {
+	key := strings.TrimSpace(req.Key)
+	if !inboundKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: key may only use lowercase letters, digits and dashes", ErrInvalidInput)
+	}
+	if key == "email" {
+		return fmt.Errorf("%w: the key email is taken by the inbound email webhook", ErrInvalidInput)
+	}
+	existing, err := s.integrationRepo.GetByKey(ctx, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get inbound integration: %w", err)
+	}
+	if existing != nil && existing.ID != integration.ID {
+		return fmt.Errorf("%w: another inbound integration uses the key %q", ErrConflict, key)
+	}
+
+	requester, err := s.userRepo.GetByID(req.RequesterID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester == nil || !requester.IsActive || !requester.HasPermission(models.PermTicketCreate) {
+		return fmt.Errorf("%w: the requester must be an active user who can raise tickets", ErrInvalidInput)
+	}
+	if req.CategoryID != nil {
+		category, err := s.categoryRepo.GetByID(ctx, *req.CategoryID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+		if category == nil || !category.IsActive {
+			return fmt.Errorf("%w: category %s not found", ErrInvalidInput, *req.CategoryID)
+		}
+	}
+	if req.TeamID != nil {
+		team, err := s.teamRepo.GetByID(ctx, *req.TeamID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+		if team == nil {
+			return fmt.Errorf("%w: team %s not found", ErrInvalidInput, *req.TeamID)
+		}
+	}
+
+	mapping := req.Mapping
+	for value, priority := range mapping.Priorities {
+		if !slices.Contains(models.TicketPriorities, priority) {
+			return fmt.Errorf("%w: %q maps to unknown priority %q", ErrInvalidInput, value, priority)
+		}
+	}
+	for value, status := range mapping.Statuses {
+		if !slices.Contains(models.TicketStatuses, status) {
+			return fmt.Errorf("%w: %q maps to unknown status %q", ErrInvalidInput, value, status)
+		}
+	}
+	if mapping.DefaultPriority == "" {
+		mapping.DefaultPriority = models.PriorityMedium
+	}
+
+	integration.Key = key
+	integration.Name = strings.TrimSpace(req.Name)
+	if req.Enabled != nil {
+		integration.Enabled = *req.Enabled
+	}
+	integration.RequesterID = requester.ID
+	integration.CategoryID = req.CategoryID
+	integration.TeamID = req.TeamID
+	integration.Mapping = mapping
+	return nil
+}
+
+// Receive turns a JSON payload posted to the integration with the given key
+// into a ticket. The caller proves it knows the integration's secret by
+// giving it as is or by signing the body with it as
+// sha256=<hex HMAC-SHA256>. Payloads whose external ID opened a ticket before
+// update that ticket: they move it to the mapped status and add the mapped
+// comment. Others open a ticket raised by the integration's requester.
+func (s *InboundService) Receive(ctx context.Context, key string, body []byte, secret, signature string) (*models.InboundResult, error) {
+	integration, requester, err := s.authorize(ctx, key, body, secret, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: the payload is not valid JSON", ErrInvalidInput)
+	}
+
+	s.receiving.Lock()
+	defer s.receiving.Unlock()
+
+	mapping := integration.Mapping
+	var result *models.InboundResult
+	externalID := strings.TrimSpace(renderInbound(mapping.ExternalID, payload))
+	ticket, err := s.linkedTicket(ctx, integration.ID, externalID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket != nil {
+		result, err = s.updateTicket(ctx, ticket, mapping, payload, requester)
+	} else {
+		result, err = s.createTicket(ctx, integration, externalID, payload, requester)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.integrationRepo.MarkReceived(ctx, integration.ID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to update inbound integration: %w", err)
+	}
+	return result, nil
+}
+
+// authorize returns the enabled integration with the given key, and the
+// requester its tickets are raised by, when the caller gave its secret or
+// signed the body with it
+func (s *InboundService) authorize(ctx context.Context, key string, body []byte, secret, signature string) (*models.InboundIntegration, *models.User, error) {
+	integration, err := s.integrationRepo.GetByKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInboundUnauthorized
+		}
+		return nil, nil, fmt.Errorf("failed to get inbound integration: %w", err)
+	}
+	if !integration.Enabled || !inboundAuthorized(integration.Secret, body, secret, signature) {
+		return nil, nil, ErrInboundUnauthorized
+	}
+
+	requester, err := s.userRepo.GetByID(integration.RequesterID.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester == nil || !requester.IsActive {
+		return nil, nil, fmt.Errorf("%w: the requester of inbound integration %q is no longer active", ErrConflict, integration.Key)
+	}
+	return integration, requester, nil
+}
+
+// linkedTicket returns the ticket an external ID opened, or nil when it has
+// not opened one or the ticket was deleted since
+func (s *InboundService) linkedTicket(ctx context.Context, integrationID uuid.UUID, externalID string) (*models.Ticket, error) {
+	if externalID == "" {
+		return nil, nil
+	}
+	link, err := s.integrationRepo.GetLink(ctx, integrationID, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbound ticket link: %w", err)
+	}
+	if link == nil {
+		return nil, nil
+	}
+	ticket, err := s.ticketService.GetTicket(ctx, link.TicketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// createTicket opens a ticket for a payload and links its external ID to it
+func (s *InboundService) createTicket(ctx context.Context, integration *models.InboundIntegration, externalID string, payload any, requester *models.User) (*models.InboundResult, error) {
+	mapping := integration.Mapping
+	title := strings.TrimSpace(renderInbound(mapping.Title, payload))
+	if title == "" {
+		return nil, fmt.Errorf("%w: the title template rendered empty for this payload", ErrInvalidInput)
+	}
+	description := strings.TrimSpace(renderInbound(mapping.Description, payload))
+	if description == "" {
+		indented, _ := json.MarshalIndent(payload, "", "  ")
+		description = string(indented)
+	}
+
+	ticket, err := s.ticketService.CreateTicket(ctx, &models.CreateTicketRequest{
+		Title:       truncate(title, 255),
+		Description: description,
+		Priority:    inboundPriority(mapping, payload),
+		CategoryID:  integration.CategoryID,
+		TeamID:      integration.TeamID,
+	}, requester.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if externalID != "" {
+		link := &models.InboundTicketLink{IntegrationID: integration.ID, ExternalID: externalID, TicketID: ticket.ID}
+		if err := s.integrationRepo.SaveLink(ctx, link); err != nil {
+			return nil, fmt.Errorf("failed to link ticket to external ID: %w", err)
+		}
+	}
+	return &models.InboundResult{TicketID: ticket.ID, Created: true, Status: ticket.Status}, nil
+}
+
+// updateTicket applies a payload to the ticket its external ID opened: it
+// moves the ticket to the mapped status and adds the mapped comment
+func (s *InboundService) updateTicket(ctx context.Context, ticket *models.Ticket, mapping models.InboundMapping, payload any, requester *models.User) (*models.InboundResult, error) {
+	result := &models.InboundResult{TicketID: ticket.ID, Status: ticket.Status}
+
+	if status, ok := inboundStatus(mapping, payload); ok && status != ticket.Status {
+		if !s.ticketService.isValidStatusTransition(ticket.Status, status) {
+			return nil, fmt.Errorf("%w: cannot move ticket from %s to %s", ErrConflict, ticket.Status, status)
+		}
+		if err := s.ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: status}, requester.ID); err != nil {
+			return nil, err
+		}
+		result.Status = status
+	}
+
+	if content := strings.TrimSpace(renderInbound(mapping.Comment, payload)); content != "" {
+		comment, err := s.ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: content}, requester)
+		if err != nil {
+			return nil, err
+		}
+		result.CommentID = &comment.ID
+	}
+	return result, nil
+}
+
+// inboundPriority maps the rendered priority of a payload to a ticket priority
+func inboundPriority(mapping models.InboundMapping, payload any) models.TicketPriority {
+	if priority, ok := mappedPriority(mapping, renderInbound(mapping.Priority, payload)); ok {
+		return priority
+	}
+	return defaultPriority(mapping)
+}
+
+// mappedPriority maps a value through the priorities of a mapping, or reads
+// it as a ticket priority, reporting false when it is neither
+func mappedPriority(mapping models.InboundMapping, value string) (models.TicketPriority, bool) {
+	value = strings.TrimSpace(value)
+	if priority, ok := mapping.Priorities[value]; ok {
+		return priority, true
+	}
+	priority := models.TicketPriority(strings.ToUpper(value))
+	return priority, slices.Contains(models.TicketPriorities, priority)
+}
+
+// defaultPriority returns the priority of payloads a mapping cannot map
+func defaultPriority(mapping models.InboundMapping) models.TicketPriority {
+	if mapping.DefaultPriority != "" {
+		return mapping.DefaultPriority
+	}
+	return models.PriorityMedium
+}
+
+// inboundStatus maps the rendered status of a payload to a ticket status,
+// reporting false when it names none
+func inboundStatus(mapping models.InboundMapping, payload any) (models.TicketStatus, bool) {
+	rendered := strings.TrimSpace(renderInbound(mapping.Status, payload))
+	if status, ok := mapping.Statuses[rendered]; ok {
+		return status, true
+	}
+	status := models.TicketStatus(strings.ToUpper(rendered))
+	return status, slices.Contains(models.TicketStatuses, status)
+}
+
+// renderInbound replaces the {{path}} placeholders of a mapping template with
+// the payload fields they name
+func renderInbound(template string, payload any) string {
+	return inboundPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		path := inboundPlaceholder.FindStringSubmatch(placeholder)[1]
+		return inboundText(inboundField(payload, path))
+	})
+}
+
+// inboundField looks up a dotted path in a payload, returning nil when part
+// of it is missing
+func inboundField(value any, path string) any {
+	for _, part := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			value = node[part]
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			value = node[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// inboundText formats a payload field for a template; objects and arrays
+// become compact JSON
+func inboundText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	}
+}
+
+// inboundAuthorized reports whether a caller gave an integration's secret or
+// signed the body with it
+func inboundAuthorized(expected string, body []byte, secret, signature string) bool {
+	if secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) == 1 {
+		return true
+	}
+	given, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	decoded, err := hex.DecodeString(given)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(expected))
+	mac.Write(body)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
+
+// newWebhookSecret returns a random secret authenticating or signing the
+// webhooks of inbound integrations and webhook subscriptions
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
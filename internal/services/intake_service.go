@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// guestPasswordHash is stored for accounts created from guest submissions;
+// it is not a valid bcrypt hash so these accounts cannot log in until a password is set
+const guestPasswordHash = "!"
+
+// IntakeService handles tickets submitted through unauthenticated channels
+// (the guest form and inbound email), screening them for spam first
+type IntakeService struct {
+	ticketRepo     repository.TicketRepository
+	categoryRepo   repository.CategoryRepository
+	userRepo       repository.UserRepository
+	quarantineRepo repository.QuarantineRepository
+	spamFilter     *SpamFilter
+	emailChannel   *EmailChannelService
+	ticketService  *TicketService
+}
+
+// NewIntakeService creates a new intake service
+func NewIntakeService(
+	ticketRepo repository.TicketRepository,
+	categoryRepo repository.CategoryRepository,
+	userRepo repository.UserRepository,
+	quarantineRepo repository.QuarantineRepository,
+	spamFilter *SpamFilter,
+	emailChannel *EmailChannelService,
+	ticketService *TicketService,
+) *IntakeService {
+	return &IntakeService{
+		ticketRepo:     ticketRepo,
+		categoryRepo:   categoryRepo,
+		userRepo:       userRepo,
+		quarantineRepo: quarantineRepo,
+		spamFilter:     spamFilter,
+		emailChannel:   emailChannel,
+		ticketService:  ticketService,
+	}
+}
+
+// ListCategories returns the active categories a guest may file a ticket under
+func (s *IntakeService) ListCategories(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.categoryRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// SubmitGuestTicket creates a ticket from the guest form, or quarantines it when it scores as spam.
+// The returned ticket is nil when the submission was quarantined.
+func (s *IntakeService) SubmitGuestTicket(ctx context.Context, req *models.GuestTicketRequest, remoteIP string) (*models.Ticket, error) {
+	if req.CategoryID != nil {
+		category, err := s.categoryRepo.GetByID(ctx, *req.CategoryID)
+		if err != nil || !category.IsActive {
+			return nil, fmt.Errorf("%w: category not found", ErrInvalidInput)
+		}
+	}
+
+	priority := req.Priority
+	if priority == "" {
+		priority = models.PriorityMedium
+	}
+
+	submission := &models.QuarantinedSubmission{
+		Source:      models.SourceGuestForm,
+		Name:        strings.TrimSpace(req.Name),
+		Email:       strings.TrimSpace(req.Email),
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    priority,
+		CategoryID:  req.CategoryID,
+		RemoteIP:    remoteIP,
+	}
+
+	if err := s.checkOpenTicketQuota(ctx, submission.Email); err != nil {
+		return nil, err
+	}
+	return s.screen(ctx, submission, nil)
+}
+
+// checkOpenTicketQuota refuses a guest form submission from an end user who
+// already has as many open tickets as they may. Email and chat are not
+// refused: nobody would see the error, and the message would be lost.
+func (s *IntakeService) checkOpenTicketQuota(ctx context.Context, emailAddress string) error {
+	if s.emailChannel == nil || s.emailChannel.config.Tickets.MaxOpenPerRequester <= 0 {
+		return nil
+	}
+	requester, err := s.userRepo.GetByEmail(emailAddress)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester.IsAgent() {
+		return nil
+	}
+	return openTicketQuota(ctx, s.ticketRepo, requester.ID, s.emailChannel.config.Tickets.MaxOpenPerRequester)
+}
+
+// ReceiveReply stores an inbound email reply as a comment on its ticket and
+// lets the other side of the conversation and the notifiers know about it.
+// The comment is already stored when they are told, so a delivery failure
+// does not make the mail provider retry.
+func (s *IntakeService) ReceiveReply(ctx context.Context, inbound *models.InboundEmail) (*models.Comment, error) {
+	comment, err := s.emailChannel.ProcessInboundReply(ctx, inbound)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, comment.TicketID)
+	if err != nil {
+		log.Printf("failed to get ticket %s to announce a reply: %v", comment.TicketID, err)
+		return comment, nil
+	}
+	s.ticketService.ReplyReceived(ctx, ticket, comment, comment.User)
+	return comment, nil
+}
+
+// IngestEmail creates a ticket from an inbound email that is not a reply to an
+// existing ticket, or quarantines it when it scores as spam. Attachments of
+// quarantined emails are not kept, nor are those that could not be uploaded.
+func (s *IntakeService) IngestEmail(ctx context.Context, inbound *models.InboundEmail) (*models.Ticket, error) {
+	from, err := mail.ParseAddress(inbound.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sender address", ErrInvalidInput)
+	}
+
+	body := inbound.Text
+	if strings.TrimSpace(body) == "" {
+		body = email.HTMLToText(inbound.HTML)
+	}
+	body = strings.TrimSpace(body)
+
+	title := strings.TrimSpace(inbound.Subject)
+	if title == "" {
+		title = "(no subject)"
+	}
+	if len(title) > 255 {
+		title = title[:255]
+	}
+	if body == "" {
+		body = title
+	}
+
+	submission := &models.QuarantinedSubmission{
+		Source:      models.SourceEmail,
+		Name:        from.Name,
+		Email:       from.Address,
+		Title:       title,
+		Description: body,
+		Priority:    models.PriorityMedium,
+	}
+
+	return s.screen(ctx, submission, inbound.Attachments)
+}
+
+// SubmitChat creates a ticket from a chat the auto-responder could not resolve
+// while no agent was available, or quarantines it when it scores as spam. The
+// transcript becomes the ticket description.
+func (s *IntakeService) SubmitChat(ctx context.Context, session *models.ChatSession, transcript string) (*models.Ticket, error) {
+	title, _, _ := strings.Cut(strings.TrimSpace(session.Summary), "\n")
+	if title == "" {
+		title = "Chat conversation"
+	}
+	if len(title) > 255 {
+		title = title[:255]
+	}
+
+	submission := &models.QuarantinedSubmission{
+		Source:      models.SourceChat,
+		Name:        session.VisitorName,
+		Email:       session.VisitorEmail,
+		Title:       title,
+		Description: transcript,
+		Priority:    models.PriorityMedium,
+		RemoteIP:    session.RemoteIP,
+	}
+
+	return s.screen(ctx, submission, nil)
+}
+
+// ListQuarantine retrieves quarantined submissions for review
+func (s *IntakeService) ListQuarantine(ctx context.Context, status models.QuarantineStatus, page, pageSize int) (*models.QuarantineListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.quarantineRepo.List(ctx, status, page, pageSize)
+}
+
+// ApproveSubmission releases a quarantined submission, creating its ticket
+func (s *IntakeService) ApproveSubmission(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID) (*models.Ticket, error) {
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.createTicket(ctx, submission, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	submission.Status = models.QuarantineApproved
+	submission.ReviewedByID = &reviewerID
+	submission.ReviewedAt = &now
+	submission.TicketID = &ticket.ID
+	if err := s.quarantineRepo.Update(ctx, submission); err != nil {
+		return nil, fmt.Errorf("failed to update submission: %w", err)
+	}
+
+	return ticket, nil
+}
+
+// RejectSubmission marks a quarantined submission as spam without creating a ticket
+func (s *IntakeService) RejectSubmission(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID) error {
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	submission.Status = models.QuarantineRejected
+	submission.ReviewedByID = &reviewerID
+	submission.ReviewedAt = &now
+	if err := s.quarantineRepo.Update(ctx, submission); err != nil {
+		return fmt.Errorf("failed to update submission: %w", err)
+	}
+
+	return nil
+}
+
+// screen scores a submission and either quarantines it or turns it into a ticket
+func (s *IntakeService) screen(ctx context.Context, submission *models.QuarantinedSubmission, attachments []models.InboundAttachment) (*models.Ticket, error) {
+	verdict, isSpam := s.spamFilter.Evaluate(ctx, &SpamCandidate{
+		Source:   submission.Source,
+		Name:     submission.Name,
+		Email:    submission.Email,
+		Subject:  submission.Title,
+		Body:     submission.Description,
+		RemoteIP: submission.RemoteIP,
+	})
+
+	if isSpam {
+		submission.SpamScore = verdict.Score
+		submission.SpamReasons = strings.Join(verdict.Reasons, "; ")
+		submission.Status = models.QuarantinePending
+		if err := s.quarantineRepo.Create(ctx, submission); err != nil {
+			return nil, fmt.Errorf("failed to quarantine submission: %w", err)
+		}
+		return nil, nil
+	}
+
+	return s.createTicket(ctx, submission, attachments)
+}
+
+// createTicket creates the ticket for a submission on behalf of its (possibly new) guest requester
+func (s *IntakeService) createTicket(ctx context.Context, submission *models.QuarantinedSubmission, attachments []models.InboundAttachment) (*models.Ticket, error) {
+	requester, err := findOrCreateGuest(s.userRepo, submission.Email, submission.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := &models.Ticket{
+		Title:       submission.Title,
+		Description: submission.Description,
+		Priority:    submission.Priority,
+		CategoryID:  submission.CategoryID,
+		CreatedByID: requester.ID,
+		Status:      models.StatusOpen,
+	}
+	if err := s.ticketRepo.Create(ctx, ticket); err != nil {
+		return nil, fmt.Errorf("failed to create ticket: %w", err)
+	}
+
+	for _, attachment := range attachments {
+		if err := s.emailChannel.checkAttachment(attachment); err != nil {
+			log.Printf("skipping attachment on ticket %s: %v", ticket.ID, err)
+			continue
+		}
+		if _, err := s.emailChannel.storeAttachment(ctx, ticket.ID, nil, requester.ID, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	s.ticketService.TicketReceived(ctx, ticket, requester)
+
+	return ticket, nil
+}
+
+// findOrCreateGuest returns the user with the given email, creating an unverified end user if none exists
+func findOrCreateGuest(userRepo repository.UserRepository, emailAddress, name string) (*models.User, error) {
+	user, err := userRepo.GetByEmail(emailAddress)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	firstName, lastName := splitName(name)
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(emailAddress, "@")
+	}
+
+	user = &models.User{
+		Email:        emailAddress,
+		PasswordHash: guestPasswordHash,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Role:         models.RoleEndUser,
+		IsVerified:   false,
+		IsActive:     true,
+	}
+	if err := userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create guest user: %w", err)
+	}
+
+	return user, nil
+}
+
+// pendingSubmission loads a quarantined submission that has not been reviewed yet
+func (s *IntakeService) pendingSubmission(ctx context.Context, id uuid.UUID) (*models.QuarantinedSubmission, error) {
+	submission, err := s.quarantineRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("submission %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+
+	if submission.Status != models.QuarantinePending {
+		return nil, fmt.Errorf("%w: submission has already been %s", ErrConflict, strings.ToLower(string(submission.Status)))
+	}
+
+	return submission, nil
+}
+
+// splitName splits a display name into first and last name
+func splitName(name string) (string, string) {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], strings.Join(fields[1:], " ")
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// integrationCheckTimeout bounds the check of each integration
+const integrationCheckTimeout = 10 * time.Second
+
+// Integration is an external system the server delivers to, such as the email
+// provider or the export bucket
+type Integration interface {
+	// Check actively verifies the integration is reachable and accepts our credentials
+	Check(ctx context.Context) error
+	// LastDelivery returns when something was last delivered through the
+	// integration, or nil when nothing has been
+	LastDelivery(ctx context.Context) (*time.Time, error)
+}
+
+// integration is a registered integration and the name it is reported by
+type integration struct {
+	name        string
+	integration Integration
+}
+
+// IntegrationHealthService checks the configured external integrations on demand
+type IntegrationHealthService struct {
+	mu           sync.Mutex
+	integrations []integration
+}
+
+// NewIntegrationHealthService creates a new integration health service
+func NewIntegrationHealthService() *IntegrationHealthService {
+	return &IntegrationHealthService{}
+}
+
+// Register adds an integration to check, in registration order
+func (s *IntegrationHealthService) Register(name string, component Integration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.integrations = append(s.integrations, integration{name: name, integration: component})
+}
+
+// Check checks every integration at once, each bounded by its own timeout.
+// A failed check is reported in the result rather than returned.
+func (s *IntegrationHealthService) Check(ctx context.Context) *models.IntegrationHealthReport {
+	s.mu.Lock()
+	integrations := append([]integration(nil), s.integrations...)
+	s.mu.Unlock()
+
+	report := &models.IntegrationHealthReport{
+		Healthy:      true,
+		Integrations: make([]models.IntegrationHealth, len(integrations)),
+		CheckedAt:    time.Now(),
+	}
+	var wg sync.WaitGroup
+	for i, in := range integrations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Integrations[i] = checkIntegration(ctx, in)
+		}()
+	}
+	wg.Wait()
+
+	for _, health := range report.Integrations {
+		report.Healthy = report.Healthy && health.Healthy
+	}
+	return report
+}
+
+// checkIntegration checks one integration and looks up its last delivery
+func checkIntegration(ctx context.Context, in integration) models.IntegrationHealth {
+	health := models.IntegrationHealth{Name: in.name, Healthy: true}
+
+	checkCtx, cancel := context.WithTimeout(ctx, integrationCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := in.integration.Check(checkCtx); err != nil {
+		health.Healthy = false
+		health.Error = err.Error()
+	}
+	health.LatencyMillis = time.Since(start).Milliseconds()
+
+	// The last delivery is informational, so failing to look it up is not a failed check
+	if delivered, err := in.integration.LastDelivery(ctx); err == nil {
+		health.LastDelivery = delivered
+	}
+	return health
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultKnowledgeSearchLimit is the number of articles returned by a search by default
+const defaultKnowledgeSearchLimit = 5
+
+// searchStopWords are common words ignored when matching articles
+var searchStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "not": true, "can": true,
+	"how": true, "what": true, "why": true, "when": true, "our": true, "you": true,
+	"your": true, "does": true, "doesn": true, "don": true, "isn": true, "won": true,
+	"have": true, "has": true, "this": true, "that": true, "from": true, "get": true,
+	"help": true, "please": true, "need": true, "its": true, "are": true, "was": true,
+}
+
+// KnowledgeService manages knowledge base articles and finds ones relevant to a question
+type KnowledgeService struct {
+	knowledgeRepo repository.KnowledgeRepository
+}
+
+// NewKnowledgeService creates a new knowledge base service
+func NewKnowledgeService(knowledgeRepo repository.KnowledgeRepository) *KnowledgeService {
+	return &KnowledgeService{
+		knowledgeRepo: knowledgeRepo,
+	}
+}
+
+// CreateArticle writes a new knowledge article
+func (s *KnowledgeService) CreateArticle(ctx context.Context, req *models.CreateKnowledgeArticleRequest, authorID uuid.UUID) (*models.KnowledgeArticle, error) {
+	article := &models.KnowledgeArticle{
+		Title:       strings.TrimSpace(req.Title),
+		Body:        req.Body,
+		Keywords:    strings.TrimSpace(req.Keywords),
+		IsPublished: req.IsPublished,
+		AuthorID:    authorID,
+	}
+	if err := s.knowledgeRepo.Create(ctx, article); err != nil {
+		return nil, fmt.Errorf("failed to create article: %w", err)
+	}
+	return article, nil
+}
+
+// GetArticle retrieves a knowledge article. Drafts are only returned when includeDrafts is set.
+func (s *KnowledgeService) GetArticle(ctx context.Context, id uuid.UUID, includeDrafts bool) (*models.KnowledgeArticle, error) {
+	article, err := s.knowledgeRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("article %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get article: %w", err)
+	}
+	if !article.IsPublished && !includeDrafts {
+		return nil, fmt.Errorf("article %w", ErrNotFound)
+	}
+	return article, nil
+}
+
+// UpdateArticle edits a knowledge article
+func (s *KnowledgeService) UpdateArticle(ctx context.Context, id uuid.UUID, req *models.UpdateKnowledgeArticleRequest) (*models.KnowledgeArticle, error) {
+	article, err := s.GetArticle(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		article.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Body != nil {
+		article.Body = *req.Body
+	}
+	if req.Keywords != nil {
+		article.Keywords = strings.TrimSpace(*req.Keywords)
+	}
+	if req.IsPublished != nil {
+		article.IsPublished = *req.IsPublished
+	}
+
+	if err := s.knowledgeRepo.Update(ctx, article); err != nil {
+		return nil, fmt.Errorf("failed to update article: %w", err)
+	}
+	return article, nil
+}
+
+// DeleteArticle deletes a knowledge article
+func (s *KnowledgeService) DeleteArticle(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetArticle(ctx, id, true); err != nil {
+		return err
+	}
+	return s.knowledgeRepo.Delete(ctx, id)
+}
+
+// ListArticles retrieves knowledge articles, optionally including drafts
+func (s *KnowledgeService) ListArticles(ctx context.Context, includeDrafts bool) ([]models.KnowledgeArticle, error) {
+	return s.knowledgeRepo.List(ctx, !includeDrafts)
+}
+
+// Search finds the published articles most relevant to free text such as a
+// customer's description of their issue, best match first
+func (s *KnowledgeService) Search(ctx context.Context, query string, limit int) ([]models.KnowledgeArticle, error) {
+	if limit <= 0 {
+		limit = defaultKnowledgeSearchLimit
+	}
+
+	terms := searchTerms(query)
+	candidates, err := s.knowledgeRepo.SearchPublished(ctx, terms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search articles: %w", err)
+	}
+
+	// Title matches count most, then keywords, then the body
+	scores := make(map[uuid.UUID]int, len(candidates))
+	for _, article := range candidates {
+		title, keywords, body := strings.ToLower(article.Title), strings.ToLower(article.Keywords), strings.ToLower(article.Body)
+		for _, term := range terms {
+			if strings.Contains(title, term) {
+				scores[article.ID] += 3
+			}
+			if strings.Contains(keywords, term) {
+				scores[article.ID] += 2
+			}
+			if strings.Contains(body, term) {
+				scores[article.ID]++
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].ID] > scores[candidates[j].ID]
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// searchTerms splits free text into distinct lowercase words worth matching
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	var terms []string
+	for _, word := range words {
+		if len(word) < 3 || searchStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// normalizeLanguage checks a BCP 47 language tag and returns its canonical
+// form, so "PT-br" and "pt-BR" are stored alike. Empty stays empty.
+func normalizeLanguage(tag string) (string, error) {
+	if tag == "" {
+		return "", nil
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q is not a valid language tag", ErrInvalidInput, tag)
+	}
+	return parsed.String(), nil
+}
+
+// languageFallbacks returns the languages to try for a recipient, most
+// specific first: their preferred language and its base language, then the
+// same for the default language
+func languageFallbacks(preferred, defaultLanguage string) []string {
+	var fallbacks []string
+	for _, tag := range []string{preferred, defaultLanguage} {
+		normalized, err := normalizeLanguage(tag)
+		if err != nil || normalized == "" {
+			continue
+		}
+		base, _ := language.Make(normalized).Base()
+		for _, candidate := range []string{normalized, base.String()} {
+			if !slices.Contains(fallbacks, candidate) {
+				fallbacks = append(fallbacks, candidate)
+			}
+		}
+	}
+	return fallbacks
+}
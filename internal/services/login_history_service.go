@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+)
+
+// LoginHistoryService records logins and alerts users to unfamiliar ones
+type LoginHistoryService struct {
+	loginRepo repository.LoginEventRepository
+	sender    email.EmailSender
+	config    *config.Config
+}
+
+// NewLoginHistoryService creates a new login history service
+func NewLoginHistoryService(loginRepo repository.LoginEventRepository, sender email.EmailSender, config *config.Config) *LoginHistoryService {
+	return &LoginHistoryService{
+		loginRepo: loginRepo,
+		sender:    sender,
+		config:    config,
+	}
+}
+
+// RecordLogin stores a successful login and emails the user when it came from
+// a device and location combination not seen before. The first recorded login
+// of an account establishes the baseline and does not alert.
+func (s *LoginHistoryService) RecordLogin(ctx context.Context, user *models.User, meta *models.LoginMetadata) (*models.LoginEvent, error) {
+	event := &models.LoginEvent{
+		UserID:    user.ID,
+		IPAddress: meta.IPAddress,
+		UserAgent: truncate(meta.UserAgent, 500),
+		Device:    DeviceLabel(meta.UserAgent),
+		Location:  truncate(meta.Location, 100),
+	}
+
+	previous, err := s.loginRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count logins: %w", err)
+	}
+	if previous > 0 {
+		known, err := s.loginRepo.ExistsForDevice(ctx, user.ID, event.Device, event.Location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check known devices: %w", err)
+		}
+		event.NewDevice = !known
+	}
+
+	if err := s.loginRepo.Create(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+
+	if event.NewDevice && s.config.Security.LoginAlertsEnabled {
+		// The login has already succeeded; a failed alert must not undo it
+		if err := s.sendNewDeviceAlert(ctx, user, event); err != nil {
+			log.Printf("failed to send new device alert to user %s: %v", user.ID, err)
+		}
+	}
+
+	return event, nil
+}
+
+// ListLogins retrieves a user's login history, newest first
+func (s *LoginHistoryService) ListLogins(ctx context.Context, userID uuid.UUID, page, pageSize int) (*models.LoginHistoryResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.loginRepo.ListByUser(ctx, userID, page, pageSize)
+}
+
+// sendNewDeviceAlert emails the user about a login from an unfamiliar device or location
+func (s *LoginHistoryService) sendNewDeviceAlert(ctx context.Context, user *models.User, event *models.LoginEvent) error {
+	location := event.Location
+	if location == "" {
+		location = "Unknown"
+	}
+
+	text := fmt.Sprintf("Hi %s,\n\nYour HelpChat account was just signed in to from a new device or location.\n\n"+
+		"Time: %s\nDevice: %s\nLocation: %s\nIP address: %s\n\n"+
+		"If this was you, no action is needed. If not, reset your password immediately.\n",
+		user.FirstName, event.CreatedAt.UTC().Format("Mon, 2 Jan 2006 15:04 MST"), event.Device, location, event.IPAddress)
+
+	return s.sender.Send(ctx, &email.Message{
+		To:      []string{user.Email},
+		Subject: "New sign-in to your HelpChat account",
+		Text:    text,
+	})
+}
+
+// DeviceLabel reduces a user agent to a browser and OS label so that routine
+// browser updates do not count as a new device
+func DeviceLabel(userAgent string) string {
+	browser := "Unknown browser"
+	for _, candidate := range []struct{ token, name string }{
+		// Order matters: Edge and Opera also claim to be Chrome, and Chrome claims to be Safari
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	os := "Unknown OS"
+	for _, candidate := range []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			os = candidate.name
+			break
+		}
+	}
+
+	return browser + " on " + os
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
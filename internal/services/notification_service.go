@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// notificationBodyLength is how much of a comment or note an in-app notification quotes
+const notificationBodyLength = 280
+
+// NotificationService records in-app notifications about ticket events and
+// keeps the channels, email and in-app, each user is notified on about each
+// type of event
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(notificationRepo repository.NotificationRepository) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo}
+}
+
+// Notifier returns a CommentNotifier that records the in-app notifications of
+// each ticket event and then passes the event on to next, usually the email
+// channel. next may be nil to only notify in the app.
+func (s *NotificationService) Notifier(next CommentNotifier) CommentNotifier {
+	return &inAppNotifier{notifications: s, next: next}
+}
+
+// List retrieves a page of a user's notifications, newest first
+func (s *NotificationService) List(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, pageSize int) (*models.NotificationListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.notificationRepo.ListByUser(ctx, userID, unreadOnly, page, pageSize)
+}
+
+// MarkRead marks one of a user's notifications read, or unread again
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uuid.UUID, read bool) (*models.Notification, error) {
+	var readAt *time.Time
+	if read {
+		now := time.Now().UTC()
+		readAt = &now
+	}
+
+	notification, err := s.notificationRepo.SetRead(ctx, userID, id, readAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update notification: %w", err)
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification of a user read
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (*models.NotificationReadAllResponse, error) {
+	marked, err := s.notificationRepo.MarkAllRead(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return &models.NotificationReadAllResponse{Marked: marked}, nil
+}
+
+// Preferences lists the channels a user is notified on about every type of event
+func (s *NotificationService) Preferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferencesResponse, error) {
+	channels, err := s.channels(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.NotificationPreferencesResponse{}
+	for _, kind := range models.NotificationTypes {
+		response.Preferences = append(response.Preferences, channels(userID, kind))
+	}
+	return response, nil
+}
+
+// UpdatePreferences changes the channels of the notification types in req,
+// leaving the others as they were
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *models.NotificationPreferencesRequest) (*models.NotificationPreferencesResponse, error) {
+	var preferences []models.NotificationPreference
+	for _, channels := range req.Preferences {
+		if !slices.Contains(models.NotificationTypes, channels.Type) {
+			return nil, fmt.Errorf("%w: unknown notification type %q", ErrInvalidInput, channels.Type)
+		}
+		preferences = append(preferences, models.NotificationPreference{
+			UserID: userID,
+			Type:   channels.Type,
+			Email:  channels.Email,
+			InApp:  channels.InApp,
+		})
+	}
+
+	if err := s.notificationRepo.SavePreferences(ctx, preferences); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return s.Preferences(ctx, userID)
+}
+
+// channels reads the preferences of users and returns a lookup of the
+// channels each is notified on about a type of event. Types a user has not
+// chosen channels for are sent on every channel.
+func (s *NotificationService) channels(ctx context.Context, userIDs []uuid.UUID) (func(uuid.UUID, models.NotificationType) models.NotificationChannels, error) {
+	preferences, err := s.notificationRepo.ListPreferences(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return func(userID uuid.UUID, kind models.NotificationType) models.NotificationChannels {
+		for _, preference := range preferences {
+			if preference.UserID == userID && preference.Type == kind {
+				return models.NotificationChannels{Type: kind, Email: preference.Email, InApp: preference.InApp}
+			}
+		}
+		return models.NotificationChannels{Type: kind, Email: true, InApp: true}
+	}, nil
+}
+
+// EmailRecipients returns the recipients who want to be emailed about kind.
+// It is safe to call on a nil service, which keeps every recipient, and keeps
+// every recipient when their preferences cannot be read, since a notification
+// sent against a preference is better than one lost.
+func (s *NotificationService) EmailRecipients(ctx context.Context, kind models.NotificationType, recipients []*models.User) []*models.User {
+	if s == nil || len(recipients) == 0 {
+		return recipients
+	}
+
+	channels, err := s.channels(ctx, userIDs(recipients))
+	if err != nil {
+		log.Printf("failed to check email preferences, emailing every recipient: %v", err)
+		return recipients
+	}
+
+	var wanted []*models.User
+	for _, recipient := range recipients {
+		if channels(recipient.ID, kind).Email {
+			wanted = append(wanted, recipient)
+		}
+	}
+	return wanted
+}
+
+// record stores an in-app notification about a ticket for each of the
+// recipients who want them, other than the actor who caused it
+func (s *NotificationService) record(ctx context.Context, kind models.NotificationType, ticket *models.Ticket, actor *models.User, title, body string, recipientIDs ...uuid.UUID) error {
+	var actorID *uuid.UUID
+	if actor != nil {
+		actorID = &actor.ID
+		recipientIDs = slices.DeleteFunc(recipientIDs, func(id uuid.UUID) bool { return id == actor.ID })
+	}
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+	slices.SortFunc(recipientIDs, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	recipientIDs = slices.Compact(recipientIDs)
+
+	channels, err := s.channels(ctx, recipientIDs)
+	if err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	for _, userID := range recipientIDs {
+		if !channels(userID, kind).InApp {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:   userID,
+			Type:     kind,
+			TicketID: ticket.ID,
+			ActorID:  actorID,
+			Title:    truncate(title, 255),
+			Body:     truncateSummary(body, notificationBodyLength),
+		})
+	}
+	if err := s.notificationRepo.Create(ctx, notifications); err != nil {
+		return fmt.Errorf("failed to record notifications: %w", err)
+	}
+	return nil
+}
+
+// inAppNotifier records in-app notifications for ticket events before
+// passing them on to the next notifier
+type inAppNotifier struct {
+	notifications *NotificationService
+	next          CommentNotifier
+}
+
+// forward passes an event on to the next notifier, joining its error with
+// the error of recording the in-app notifications
+func (n *inAppNotifier) forward(recordErr error, send func(next CommentNotifier) error) error {
+	if n.next == nil {
+		return recordErr
+	}
+	return errors.Join(recordErr, send(n.next))
+}
+
+// NotifyTicketCreated passes the new ticket's summary on; its requester
+// already knows about it
+func (n *inAppNotifier) NotifyTicketCreated(ctx context.Context, ticket *models.Ticket, requester *models.User, responseTarget time.Duration) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyTicketCreated(ctx, ticket, requester, responseTarget)
+	})
+}
+
+// NotifyTicketReceived passes the acknowledgement of a submitted ticket on
+func (n *inAppNotifier) NotifyTicketReceived(ctx context.Context, ticket *models.Ticket, requester *models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyTicketReceived(ctx, ticket, requester)
+	})
+}
+
+// NotifyCommentAdded notifies the other side of the conversation about a
+// public comment: the assignee of the requester's comments, the requester of
+// everyone else's
+func (n *inAppNotifier) NotifyCommentAdded(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User) error {
+	var err error
+	if !comment.IsInternal {
+		recipientID := &ticket.CreatedByID
+		if author.ID == ticket.CreatedByID {
+			recipientID = ticket.AssignedAgentID
+		}
+		if recipientID != nil {
+			title := fmt.Sprintf("%s commented on \"%s\"", author.FullName(), ticket.Title)
+			err = n.notifications.record(ctx, models.NotificationTicketCommented, ticket, author, title, comment.Content, *recipientID)
+		}
+	}
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyCommentAdded(ctx, ticket, comment, author)
+	})
+}
+
+// NotifyMentioned notifies the staff mentioned in a comment or internal note
+func (n *inAppNotifier) NotifyMentioned(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User, recipients []*models.User) error {
+	title := fmt.Sprintf("%s mentioned you on \"%s\"", author.FullName(), ticket.Title)
+	err := n.notifications.record(ctx, models.NotificationTicketCommented, ticket, author, title, comment.Content, userIDs(recipients)...)
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyMentioned(ctx, ticket, comment, author, recipients)
+	})
+}
+
+// NotifyAssigned notifies the agent a ticket was assigned to
+func (n *inAppNotifier) NotifyAssigned(ctx context.Context, ticket *models.Ticket, assignedBy, assignee *models.User) error {
+	title := fmt.Sprintf("%s assigned \"%s\" to you", assignedBy.FullName(), ticket.Title)
+	err := n.notifications.record(ctx, models.NotificationTicketAssigned, ticket, assignedBy, title, "", assignee.ID)
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyAssigned(ctx, ticket, assignedBy, assignee)
+	})
+}
+
+// NotifyEscalated notifies the user a ticket was escalated to, with the reason
+func (n *inAppNotifier) NotifyEscalated(ctx context.Context, ticket *models.Ticket, escalatedBy, escalatedTo *models.User) error {
+	title := fmt.Sprintf("%s escalated \"%s\" to you", escalatedBy.FullName(), ticket.Title)
+	err := n.notifications.record(ctx, models.NotificationTicketEscalated, ticket, escalatedBy, title, ticket.EscalationReason, escalatedTo.ID)
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyEscalated(ctx, ticket, escalatedBy, escalatedTo)
+	})
+}
+
+// NotifyDeEscalated notifies the assignee an escalated ticket was handed back
+// to, with the note left for them
+func (n *inAppNotifier) NotifyDeEscalated(ctx context.Context, ticket *models.Ticket, returnedBy, assignee *models.User, note string) error {
+	title := fmt.Sprintf("%s handed \"%s\" back to you", returnedBy.FullName(), ticket.Title)
+	err := n.notifications.record(ctx, models.NotificationTicketEscalated, ticket, returnedBy, title, note, assignee.ID)
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyDeEscalated(ctx, ticket, returnedBy, assignee, note)
+	})
+}
+
+// NotifyReassignedOften passes the alert to managers on; it is only emailed
+func (n *inAppNotifier) NotifyReassignedOften(ctx context.Context, ticket *models.Ticket, agents int, managers []*models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyReassignedOften(ctx, ticket, agents, managers)
+	})
+}
+
+// NotifyStatusChanged notifies the requester and assignee of a ticket that
+// its status changed
+func (n *inAppNotifier) NotifyStatusChanged(ctx context.Context, ticket *models.Ticket, changedBy *models.User, previous models.TicketStatus, recipients []*models.User) error {
+	title := fmt.Sprintf("%s changed the status of \"%s\" from %s to %s", changedBy.FullName(), ticket.Title, previous, ticket.Status)
+	err := n.notifications.record(ctx, models.NotificationTicketStatusChanged, ticket, changedBy, title, "", userIDs(recipients)...)
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyStatusChanged(ctx, ticket, changedBy, previous, recipients)
+	})
+}
+
+// userIDs returns the IDs of users
+func userIDs(users []*models.User) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
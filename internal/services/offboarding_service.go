@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// offboardingInterval is how often pending offboarding jobs are picked up
+const offboardingInterval = 30 * time.Second
+
+// offboardingNote is recorded on each ticket released from an offboarded user
+const offboardingNote = "Offboarding"
+
+// OffboardingService offboards staff members who left: a background job
+// releases their open tickets to a queue and anonymizes their account, so
+// their comments and history are attributed to "Former employee"
+type OffboardingService struct {
+	offboardingRepo repository.OffboardingRepository
+	userRepo        repository.UserRepository
+	teamRepo        repository.TeamRepository
+	auditService    *AuditService
+}
+
+// NewOffboardingService creates a new offboarding service
+func NewOffboardingService(offboardingRepo repository.OffboardingRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository, auditService *AuditService) *OffboardingService {
+	return &OffboardingService{
+		offboardingRepo: offboardingRepo,
+		userRepo:        userRepo,
+		teamRepo:        teamRepo,
+		auditService:    auditService,
+	}
+}
+
+// Offboard queues a job offboarding a staff member
+func (s *OffboardingService) Offboard(ctx context.Context, req *models.OffboardRequest, actor *models.User) (*models.OffboardingJob, error) {
+	if req.UserID == actor.ID {
+		return nil, fmt.Errorf("%w: you cannot offboard yourself", ErrInvalidInput)
+	}
+	user, err := s.userRepo.GetByID(req.UserID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+	if user.Role == models.RoleEndUser {
+		return nil, fmt.Errorf("%w: only staff members can be offboarded", ErrInvalidInput)
+	}
+	if req.TeamID != nil {
+		if _, err := s.teamRepo.GetByID(ctx, *req.TeamID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("team %w", ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to get team: %w", err)
+		}
+	}
+
+	if _, err := s.offboardingRepo.FindUnfinished(ctx, user.ID); err == nil {
+		return nil, fmt.Errorf("%w: the user is already being offboarded", ErrConflict)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check offboarding jobs: %w", err)
+	}
+
+	job := &models.OffboardingJob{
+		UserID:        user.ID,
+		Status:        models.OffboardingPending,
+		TeamID:        req.TeamID,
+		RequestedByID: actor.ID,
+	}
+	if err := s.offboardingRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create offboarding job: %w", err)
+	}
+	if err := s.auditService.Record(ctx, &actor.ID, models.AuditActionOffboarding, models.AuditEntityUser, &user.ID, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetJob returns an offboarding job
+func (s *OffboardingService) GetJob(ctx context.Context, id uuid.UUID) (*models.OffboardingJob, error) {
+	job, err := s.offboardingRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("offboarding job %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get offboarding job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns a page of offboarding jobs, most recent first
+func (s *OffboardingService) ListJobs(ctx context.Context, page, pageSize int) (*models.OffboardingJobListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	jobs, total, err := s.offboardingRepo.List(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offboarding jobs: %w", err)
+	}
+	return &models.OffboardingJobListResponse{
+		Jobs:       jobs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Schedule registers the job running pending offboarding jobs
+func (s *OffboardingService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("staff offboarding", offboardingInterval, s.RunPending)
+}
+
+// RunPending runs the pending offboarding jobs, oldest first. A job that fails
+// is marked failed with its error, and can be requested again.
+func (s *OffboardingService) RunPending(ctx context.Context) error {
+	for {
+		job, err := s.offboardingRepo.ClaimPending(ctx, time.Now())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to claim offboarding job: %w", err)
+		}
+
+		if err := s.run(ctx, job); err != nil {
+			log.Printf("Offboarding job %s failed: %v", job.ID, err)
+			job.Status = models.OffboardingFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = models.OffboardingCompleted
+		}
+		completedAt := time.Now()
+		job.CompletedAt = &completedAt
+		if err := s.offboardingRepo.Update(ctx, job); err != nil {
+			return fmt.Errorf("failed to update offboarding job: %w", err)
+		}
+	}
+}
+
+// run releases the user's open tickets, then anonymizes their account
+func (s *OffboardingService) run(ctx context.Context, job *models.OffboardingJob) error {
+	released, err := s.offboardingRepo.ReleaseTickets(ctx, job.UserID, job.TeamID, job.RequestedByID, offboardingNote)
+	if err != nil {
+		return fmt.Errorf("failed to release tickets: %w", err)
+	}
+	job.TicketsReleased = released
+
+	comments, err := s.offboardingRepo.AnonymizeStaff(ctx, job.UserID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	job.CommentsAttributed = comments
+	return nil
+}
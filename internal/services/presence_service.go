@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"github.com/google/uuid"
+)
+
+// presenceKeyPrefix namespaces presence entries in the cache
+const presenceKeyPrefix = "presence:"
+
+// PresenceService tracks which agents are online. Each heartbeat from an
+// agent's presence WebSocket refreshes an entry in the cache; an agent is
+// online until their entry expires or their last connection closes.
+type PresenceService struct {
+	cache    cache.Cache
+	userRepo repository.UserRepository
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	connections map[uuid.UUID]int
+	onOnline    []func(userID uuid.UUID)
+}
+
+// NewPresenceService creates a new presence service
+func NewPresenceService(cache cache.Cache, userRepo repository.UserRepository, config *config.Config) *PresenceService {
+	ttl, err := time.ParseDuration(config.Presence.TTL)
+	if err != nil || ttl <= 0 {
+		ttl = 90 * time.Second // fallback
+	}
+
+	return &PresenceService{
+		cache:       cache,
+		userRepo:    userRepo,
+		ttl:         ttl,
+		connections: make(map[uuid.UUID]int),
+	}
+}
+
+// TTL returns how long an agent stays online after their last heartbeat
+func (s *PresenceService) TTL() time.Duration {
+	return s.ttl
+}
+
+// OnOnline registers a function called whenever an agent comes online
+func (s *PresenceService) OnOnline(fn func(userID uuid.UUID)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onOnline = append(s.onOnline, fn)
+}
+
+// Connect records a newly opened presence connection and marks the agent online
+func (s *PresenceService) Connect(userID uuid.UUID) {
+	s.mu.Lock()
+	s.connections[userID]++
+	s.mu.Unlock()
+	s.Heartbeat(userID)
+}
+
+// Disconnect records a closed presence connection. The agent goes offline
+// straight away once their last connection closes.
+func (s *PresenceService) Disconnect(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connections[userID] > 1 {
+		s.connections[userID]--
+		return
+	}
+	delete(s.connections, userID)
+	s.cache.Delete(presenceKey(userID))
+}
+
+// Heartbeat keeps an agent online for another TTL
+func (s *PresenceService) Heartbeat(userID uuid.UUID) {
+	key := presenceKey(userID)
+	_, wasOnline := s.cache.Get(key)
+	s.cache.Set(key, time.Now().UTC().Format(time.RFC3339Nano), s.ttl)
+	if wasOnline {
+		return
+	}
+
+	s.mu.Lock()
+	listeners := append([]func(uuid.UUID){}, s.onOnline...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(userID)
+	}
+}
+
+// IsOnline reports whether an agent is online
+func (s *PresenceService) IsOnline(userID uuid.UUID) bool {
+	_, ok := s.cache.Get(presenceKey(userID))
+	return ok
+}
+
+// ListAgents returns every agent with whether they are online, online agents
+// first, for choosing who to assign or transfer work to
+func (s *PresenceService) ListAgents(ctx context.Context) ([]models.AgentPresence, error) {
+	agents, err := s.userRepo.ListAgents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	lastSeen := make(map[string]time.Time)
+	for key, value := range s.cache.Scan(presenceKeyPrefix) {
+		if at, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			lastSeen[strings.TrimPrefix(key, presenceKeyPrefix)] = at
+		}
+	}
+
+	presence := make([]models.AgentPresence, 0, len(agents))
+	for _, agent := range agents {
+		entry := models.AgentPresence{
+			AgentID: agent.ID,
+			Name:    agent.FullName(),
+			Email:   agent.Email,
+			Role:    agent.Role,
+		}
+		if at, ok := lastSeen[agent.ID.String()]; ok {
+			entry.Online = true
+			entry.LastSeenAt = &at
+		}
+		presence = append(presence, entry)
+	}
+	sort.SliceStable(presence, func(i, j int) bool {
+		if presence[i].Online != presence[j].Online {
+			return presence[i].Online
+		}
+		return presence[i].Name < presence[j].Name
+	})
+	return presence, nil
+}
+
+// presenceKey is the cache key of an agent's presence
+func presenceKey(userID uuid.UUID) string {
+	return presenceKeyPrefix + userID.String()
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultProblemReportLimit is the number of problems returned by the top problems report by default
+const defaultProblemReportLimit = 10
+
+// ProblemService manages problems and the incident tickets grouped under them
+type ProblemService struct {
+	problemRepo   repository.ProblemRepository
+	ticketRepo    repository.TicketRepository
+	ticketService *TicketService
+}
+
+// NewProblemService creates a new problem service
+func NewProblemService(problemRepo repository.ProblemRepository, ticketRepo repository.TicketRepository, ticketService *TicketService) *ProblemService {
+	return &ProblemService{
+		problemRepo:   problemRepo,
+		ticketRepo:    ticketRepo,
+		ticketService: ticketService,
+	}
+}
+
+// CreateProblem opens a new problem
+func (s *ProblemService) CreateProblem(ctx context.Context, req *models.CreateProblemRequest, createdByID uuid.UUID) (*models.Problem, error) {
+	problem := &models.Problem{
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Status:      models.ProblemOpen,
+		CreatedByID: createdByID,
+	}
+	if err := s.problemRepo.Create(ctx, problem); err != nil {
+		return nil, fmt.Errorf("failed to create problem: %w", err)
+	}
+	return s.problemRepo.GetByID(ctx, problem.ID)
+}
+
+// GetProblem retrieves a problem with its incidents
+func (s *ProblemService) GetProblem(ctx context.Context, id uuid.UUID) (*models.Problem, error) {
+	problem, err := s.problemRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("problem %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	problem.Incidents, err = s.problemRepo.GetIncidents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents: %w", err)
+	}
+	return problem, nil
+}
+
+// ListProblems retrieves problems, optionally filtered by status
+func (s *ProblemService) ListProblems(ctx context.Context, status models.ProblemStatus) ([]models.Problem, error) {
+	return s.problemRepo.List(ctx, status)
+}
+
+// LinkIncident groups an incident ticket under a problem. A ticket already
+// linked to another problem is moved.
+func (s *ProblemService) LinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) (*models.Problem, error) {
+	problem, err := s.GetProblem(ctx, problemID)
+	if err != nil {
+		return nil, err
+	}
+	if problem.Status == models.ProblemClosed {
+		return nil, fmt.Errorf("%w: the problem is closed", ErrConflict)
+	}
+	if _, err := s.ticketRepo.GetByID(ctx, ticketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if err := s.problemRepo.LinkIncident(ctx, problemID, ticketID); err != nil {
+		return nil, fmt.Errorf("failed to link incident: %w", err)
+	}
+	return s.GetProblem(ctx, problemID)
+}
+
+// UnlinkIncident removes an incident ticket from a problem
+func (s *ProblemService) UnlinkIncident(ctx context.Context, problemID, ticketID uuid.UUID) error {
+	return s.problemRepo.UnlinkIncident(ctx, problemID, ticketID)
+}
+
+// CloseProblem closes a problem with a resolution. When requested, every linked
+// incident that is still open is resolved and the resolution is posted on it.
+func (s *ProblemService) CloseProblem(ctx context.Context, id uuid.UUID, req *models.CloseProblemRequest, actor *models.User) (*models.CloseProblemResponse, error) {
+	problem, err := s.GetProblem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if problem.Status == models.ProblemClosed {
+		return nil, fmt.Errorf("%w: the problem is already closed", ErrConflict)
+	}
+
+	now := time.Now()
+	problem.Status = models.ProblemClosed
+	problem.Resolution = req.Resolution
+	problem.ClosedAt = &now
+	if err := s.problemRepo.Update(ctx, problem); err != nil {
+		return nil, fmt.Errorf("failed to close problem: %w", err)
+	}
+
+	resolved := 0
+	if req.ResolveIncidents {
+		note := fmt.Sprintf("Resolved as part of problem \"%s\":\n\n%s", problem.Title, req.Resolution)
+		for _, incident := range problem.Incidents {
+			if incident.Status == models.StatusResolved || incident.Status == models.StatusClosed {
+				continue
+			}
+			// One failing incident should not block resolving the rest
+			if err := s.ticketService.UpdateTicketStatus(ctx, incident.ID, &models.UpdateTicketStatusRequest{Status: models.StatusResolved}, actor.ID); err != nil {
+				log.Printf("failed to resolve incident %s of problem %s: %v", incident.ID, problem.ID, err)
+				continue
+			}
+			if _, err := s.ticketService.AddComment(ctx, incident.ID, &models.CreateCommentRequest{Content: note}, actor); err != nil {
+				log.Printf("failed to post resolution on incident %s of problem %s: %v", incident.ID, problem.ID, err)
+			}
+			resolved++
+		}
+	}
+
+	problem, err = s.GetProblem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CloseProblemResponse{
+		Problem:           problem,
+		ResolvedIncidents: resolved,
+	}, nil
+}
+
+// TopProblems reports the problems with the most linked incidents
+func (s *ProblemService) TopProblems(ctx context.Context, limit int) ([]models.ProblemIncidentCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultProblemReportLimit
+	}
+	return s.problemRepo.TopByIncidentCount(ctx, limit)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/officehours"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+)
+
+// quietHoursBatchSize is how many queued notifications are delivered per query
+const quietHoursBatchSize = 100
+
+// QuietHoursService holds notification emails back during their recipients'
+// quiet hours and delivers them once the quiet hours end. Notifications about
+// urgent tickets break through.
+type QuietHoursService struct {
+	queueRepo              repository.NotificationQueueRepository
+	sender                 email.EmailSender
+	breakthroughPriorities []models.TicketPriority
+	breakthroughOverdue    bool
+}
+
+// NewQuietHoursService creates a new quiet hours service
+func NewQuietHoursService(queueRepo repository.NotificationQueueRepository, sender email.EmailSender, cfg *config.Config) *QuietHoursService {
+	s := &QuietHoursService{
+		queueRepo:           queueRepo,
+		sender:              sender,
+		breakthroughOverdue: cfg.Email.BreakthroughOverdue,
+	}
+	for _, priority := range cfg.Email.BreakthroughPriorities {
+		s.breakthroughPriorities = append(s.breakthroughPriorities, models.TicketPriority(strings.ToUpper(strings.TrimSpace(priority))))
+	}
+	return s
+}
+
+// Urgent reports whether notifications about the ticket are sent during quiet
+// hours: its priority breaks through, or it is unresolved and past its due date
+func (s *QuietHoursService) Urgent(ticket *models.Ticket) bool {
+	if slices.Contains(s.breakthroughPriorities, ticket.Priority) {
+		return true
+	}
+	return s.breakthroughOverdue && !ticket.IsResolved() && ticket.IsOverdue()
+}
+
+// Send sends the message to the recipients outside their quiet hours and
+// queues a copy for each of the others, unless it is urgent
+func (s *QuietHoursService) Send(ctx context.Context, msg *email.Message, recipients []*models.User, urgent bool) error {
+	now := time.Now()
+	var to []string
+	for _, recipient := range recipients {
+		until, quiet := QuietUntil(recipient, now)
+		if !quiet || urgent {
+			to = append(to, recipient.Email)
+			continue
+		}
+
+		if err := s.queueRepo.Create(ctx, &models.QueuedNotification{
+			UserID:    recipient.ID,
+			To:        recipient.Email,
+			Subject:   msg.Subject,
+			Text:      msg.Text,
+			HTML:      msg.HTML,
+			ReplyTo:   msg.ReplyTo,
+			Headers:   msg.Headers,
+			DeliverAt: until,
+		}); err != nil {
+			return fmt.Errorf("failed to queue notification: %w", err)
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	immediate := *msg
+	immediate.To = to
+	return s.sender.Send(ctx, &immediate)
+}
+
+// DeliverDue sends the queued notifications whose quiet hours ended by the
+// given time, returning how many were sent. Notifications that fail to send
+// are dropped, as the failure is recorded with the delivery.
+func (s *QuietHoursService) DeliverDue(ctx context.Context, at time.Time) (int, error) {
+	sent := 0
+	for {
+		notifications, err := s.queueRepo.ListDue(ctx, at, quietHoursBatchSize)
+		if err != nil {
+			return sent, fmt.Errorf("failed to list queued notifications: %w", err)
+		}
+
+		for _, notification := range notifications {
+			msg := &email.Message{
+				To:      []string{notification.To},
+				Subject: notification.Subject,
+				Text:    notification.Text,
+				HTML:    notification.HTML,
+				ReplyTo: notification.ReplyTo,
+				Headers: notification.Headers,
+			}
+			if err := s.sender.Send(ctx, msg); err != nil {
+				log.Printf("Failed to send queued notification %s: %v", notification.ID, err)
+			} else {
+				sent++
+			}
+			if err := s.queueRepo.Delete(ctx, notification.ID); err != nil {
+				return sent, fmt.Errorf("failed to dequeue notification: %w", err)
+			}
+		}
+		if len(notifications) < quietHoursBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// Schedule delivers queued notifications every minute
+func (s *QuietHoursService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("quiet hours notifications", time.Minute, func(ctx context.Context) error {
+		_, err := s.DeliverDue(ctx, time.Now())
+		return err
+	})
+}
+
+// QuietUntil reports whether the user is in their quiet hours at the given
+// time and, if so, when they end
+func QuietUntil(user *models.User, at time.Time) (time.Time, bool) {
+	if user.QuietHoursStart == "" || user.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := officehours.ParseClock(user.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := officehours.ParseClock(user.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	endsOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, end/60, end%60, 0, 0, location)
+	}
+	switch {
+	case start < end && minute >= start && minute < end:
+		return endsOn(0), true
+	case start > end && minute >= start:
+		// Quiet hours spanning midnight end tomorrow
+		return endsOn(1), true
+	case start > end && minute < end:
+		return endsOn(0), true
+	}
+	return time.Time{}, false
+}
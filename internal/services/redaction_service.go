@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/redact"
+	"github.com/google/uuid"
+)
+
+// RedactionService masks PII and blocked words in user content before it is
+// persisted, and audits which rules fired (never the redacted values)
+type RedactionService struct {
+	redactor     *redact.Redactor
+	auditService *AuditService
+}
+
+// NewRedactionService creates a redaction service from the configured rules and dictionary
+func NewRedactionService(cfg *config.Config, auditService *AuditService) (*RedactionService, error) {
+	var rules []redact.Rule
+	if cfg.Redaction.Enabled {
+		for _, name := range cfg.Redaction.Rules {
+			rule, err := redact.BuiltinRule(name)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+		if rule, ok := redact.DictionaryRule(cfg.Redaction.Dictionary); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return &RedactionService{
+		redactor:     redact.New(rules...),
+		auditService: auditService,
+	}, nil
+}
+
+// Redact returns the masked text and the rules that matched
+func (s *RedactionService) Redact(text string) (string, []redact.Match) {
+	return s.redactor.Redact(text)
+}
+
+// RecordRedactions audits the redactions applied to an entity. Failures are
+// logged rather than returned because the content has already been saved.
+func (s *RedactionService) RecordRedactions(ctx context.Context, entityType string, entityID, actorID uuid.UUID, matches []redact.Match) {
+	if len(matches) == 0 {
+		return
+	}
+
+	details := map[string]interface{}{"matches": matches}
+	if err := s.auditService.Record(ctx, &actorID, models.AuditActionRedaction, entityType, &entityID, details); err != nil {
+		log.Printf("failed to audit redaction of %s %s: %v", entityType, entityID, err)
+	}
+}
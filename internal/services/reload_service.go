@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+)
+
+// Reloadable is a component that can apply new settings while the server runs
+type Reloadable interface {
+	Reload(cfg *config.Config) error
+}
+
+// ReloadFunc adapts a function to Reloadable
+type ReloadFunc func(cfg *config.Config) error
+
+// Reload calls f(cfg)
+func (f ReloadFunc) Reload(cfg *config.Config) error {
+	return f(cfg)
+}
+
+// reloadComponent is a registered component and the name it is reported by
+type reloadComponent struct {
+	name      string
+	component Reloadable
+}
+
+// ReloadService re-reads the config file and role policy and hands the result
+// to the registered components, so permissions and selected settings change
+// without a restart
+type ReloadService struct {
+	auditService *AuditService
+	configFile   string
+
+	mu         sync.Mutex
+	components []reloadComponent
+}
+
+// NewReloadService creates a reload service that reads cfg's config file. The
+// role policy is always reloaded, under the name "permissions".
+func NewReloadService(auditService *AuditService, cfg *config.Config) *ReloadService {
+	s := &ReloadService{
+		auditService: auditService,
+		configFile:   cfg.Server.ConfigFile,
+	}
+	s.Register("permissions", ReloadFunc(LoadRolePolicy))
+	return s
+}
+
+// Register adds a component to reload, in registration order
+func (s *ReloadService) Register(name string, component Reloadable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.components = append(s.components, reloadComponent{name: name, component: component})
+}
+
+// Reload re-reads the configuration and applies it to every component. A
+// component that rejects the new settings keeps its current ones and is
+// reported in the result's Failed; the others are still reloaded. It fails
+// with ErrInvalidInput when the config file cannot be read.
+func (s *ReloadService) Reload(ctx context.Context, actorID uuid.UUID) (*models.ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, err := config.LoadFile(s.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	result := &models.ReloadResult{Reloaded: []string{}, ReloadedAt: time.Now()}
+	for _, c := range s.components {
+		if err := c.component.Reload(cfg); err != nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[c.name] = err.Error()
+			continue
+		}
+		result.Reloaded = append(result.Reloaded, c.name)
+	}
+
+	if err := s.auditService.Record(ctx, &actorID, models.AuditActionConfigReload, models.AuditEntitySystem, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadRolePolicy applies the role policy file named by cfg, a JSON object
+// mapping roles to the permissions that replace their defaults. Roles left
+// out, or every role when no file is configured, keep their defaults. The
+// administrator role must keep system:admin so it can still reload.
+func LoadRolePolicy(cfg *config.Config) error {
+	if cfg.Security.RBACPolicyFile == "" {
+		models.SetRolePermissions(nil)
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.Security.RBACPolicyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read role policy: %w", err)
+	}
+	var policy map[models.UserRole][]string
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return fmt.Errorf("invalid role policy: %w", err)
+	}
+
+	for role, granted := range policy {
+		if !role.IsValid() {
+			return fmt.Errorf("invalid role policy: unknown role %q", role)
+		}
+		for _, permission := range granted {
+			if !slices.Contains(models.Permissions, permission) {
+				return fmt.Errorf("invalid role policy: unknown permission %q for %s", permission, role)
+			}
+		}
+	}
+	if granted, ok := policy[models.RoleAdministrator]; ok && !slices.Contains(granted, models.PermSystemAdmin) {
+		return fmt.Errorf("invalid role policy: %s must keep %s", models.RoleAdministrator, models.PermSystemAdmin)
+	}
+
+	models.SetRolePermissions(policy)
+	return nil
+}
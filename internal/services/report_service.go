@@ -0,0 +1,592 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/timezone"
+	"github.com/google/uuid"
+)
+
+// ReportService builds aggregate reports and the live numbers shown on wallboards
+type ReportService struct {
+	reportRepo     repository.ReportRepository
+	categoryRepo   repository.CategoryRepository
+	chatRepo       repository.ChatRepository
+	presence       *PresenceService
+	cache          cache.Cache
+	streamInterval time.Duration
+	riskWindow     time.Duration
+	location       *time.Location
+}
+
+// NewReportService creates a new report service. "Today" is measured in the
+// office hours time zone.
+func NewReportService(reportRepo repository.ReportRepository, categoryRepo repository.CategoryRepository, chatRepo repository.ChatRepository, presence *PresenceService, cache cache.Cache, config *config.Config) *ReportService {
+	streamInterval, err := time.ParseDuration(config.Reports.StreamInterval)
+	if err != nil || streamInterval <= 0 {
+		streamInterval = 5 * time.Second // fallback
+	}
+	riskWindow, err := time.ParseDuration(config.Reports.SLARiskWindow)
+	if err != nil || riskWindow < 0 {
+		riskWindow = time.Hour // fallback
+	}
+	location, err := time.LoadLocation(config.Widget.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return &ReportService{
+		reportRepo:     reportRepo,
+		categoryRepo:   categoryRepo,
+		chatRepo:       chatRepo,
+		presence:       presence,
+		cache:          cache,
+		streamInterval: streamInterval,
+		riskWindow:     riskWindow,
+		location:       location,
+	}
+}
+
+// StreamInterval returns how often live queue numbers should be pushed
+func (s *ReportService) StreamInterval() time.Duration {
+	return s.streamInterval
+}
+
+// QueueSnapshot returns the live queue numbers for the ticket scope carried by
+// ctx. Snapshots are shared for one stream interval, so any number of
+// wallboards costs one query per scope per interval.
+func (s *ReportService) QueueSnapshot(ctx context.Context) (*models.QueueSnapshot, error) {
+	return memoize(s, "report:queue:"+scopeKey(ctx)+":"+s.locationFor(ctx).String(), func() (*models.QueueSnapshot, error) {
+		now := time.Now()
+		snapshot, err := s.reportRepo.QueueSnapshot(ctx, now, now.Add(s.riskWindow), s.dayStart(ctx, now))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count the ticket queue: %w", err)
+		}
+		return snapshot, nil
+	})
+}
+
+// Dashboard returns everything a wallboard shows for the ticket scope carried
+// by ctx. Chat and agent numbers are not team scoped. Like queue snapshots,
+// dashboards are shared for one stream interval.
+func (s *ReportService) Dashboard(ctx context.Context) (*models.Dashboard, error) {
+	return memoize(s, "report:dashboard:"+scopeKey(ctx)+":"+s.locationFor(ctx).String(), func() (*models.Dashboard, error) {
+		now := time.Now()
+		dayStart := s.dayStart(ctx, now)
+		snapshot, err := s.reportRepo.QueueSnapshot(ctx, now, now.Add(s.riskWindow), dayStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count the ticket queue: %w", err)
+		}
+		priorities, err := s.reportRepo.OpenByPriority(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count open tickets: %w", err)
+		}
+		created, err := s.reportRepo.CountCreatedSince(ctx, dayStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tickets created today: %w", err)
+		}
+
+		dashboard := &models.Dashboard{
+			At:             now,
+			OpenByPriority: make(map[models.TicketPriority]int64),
+			Unassigned:     snapshot.Unassigned,
+			CreatedToday:   created,
+			ResolvedToday:  snapshot.ResolvedToday,
+		}
+		for _, priority := range []models.TicketPriority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityCritical} {
+			dashboard.OpenByPriority[priority] = 0
+		}
+		for _, count := range priorities {
+			dashboard.OpenByPriority[count.Priority] = count.Count
+		}
+
+		if dashboard.AverageWaitSeconds, err = s.chatRepo.AverageQueueWait(ctx, dayStart); err != nil {
+			return nil, fmt.Errorf("failed to average the chat wait: %w", err)
+		}
+		if dashboard.ActiveChats, err = s.chatRepo.CountByStatus(ctx, models.ChatActive); err != nil {
+			return nil, fmt.Errorf("failed to count active chats: %w", err)
+		}
+		if dashboard.QueuedChats, err = s.chatRepo.CountByStatus(ctx, models.ChatQueued); err != nil {
+			return nil, fmt.Errorf("failed to count queued chats: %w", err)
+		}
+
+		agents, err := s.presence.ListAgents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range agents {
+			if agent.Online {
+				dashboard.OnlineAgents++
+			}
+		}
+		return dashboard, nil
+	})
+}
+
+// locationFor returns the time zone of the user in ctx, falling back to the
+// office time zone
+func (s *ReportService) locationFor(ctx context.Context) *time.Location {
+	return timezone.Or(ctx, s.location)
+}
+
+// dayStart returns the start of the day containing t in the user's time zone
+func (s *ReportService) dayStart(ctx context.Context, t time.Time) time.Time {
+	location := s.locationFor(ctx)
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// memoize returns the report cached under key, building and caching it for one
+// stream interval when there is none
+func memoize[T any](s *ReportService, key string, build func() (*T, error)) (*T, error) {
+	if cached, ok := s.cache.Get(key); ok {
+		var report T
+		if err := json.Unmarshal([]byte(cached), &report); err == nil {
+			return &report, nil
+		}
+	}
+
+	report, err := build()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(report); err == nil {
+		s.cache.Set(key, string(data), s.streamInterval)
+	}
+	return report, nil
+}
+
+// scopeKey identifies the ticket scope carried by ctx, for caching reports per
+// scope. Training sandboxes only see their own tickets, so each is a scope of
+// its own that never shares results with real callers.
+func scopeKey(ctx context.Context) string {
+	key := "all"
+	if scope, ok := repository.TicketScopeFromContext(ctx); ok {
+		ids := make([]string, len(scope.TeamIDs))
+		for i, id := range scope.TeamIDs {
+			ids[i] = id.String()
+		}
+		slices.Sort(ids)
+		key = "teams:" + strings.Join(ids, ",")
+	}
+	if sandboxID, ok := repository.SandboxFromContext(ctx); ok {
+		key = "sandbox:" + sandboxID.String() + ":" + key
+	}
+	return key
+}
+
+// maxTrendPoints caps how many buckets one trend series may have
+const maxTrendPoints = 400
+
+// Trends returns a ticket measure bucketed by day or week for the ticket scope
+// carried by ctx. The range defaults to the last 30 days, or the last 12 weeks
+// for weekly buckets.
+func (s *ReportService) Trends(ctx context.Context, query models.TrendQuery) (*models.TrendSeries, error) {
+	switch query.Metric {
+	case models.TrendCreated, models.TrendResolved, models.TrendBacklog:
+	default:
+		return nil, fmt.Errorf("%w: metric must be created, resolved or backlog", ErrInvalidInput)
+	}
+	step := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	switch query.Interval {
+	case "":
+		query.Interval = models.TrendDay
+	case models.TrendDay:
+	case models.TrendWeek:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	default:
+		return nil, fmt.Errorf("%w: interval must be day or week", ErrInvalidInput)
+	}
+
+	days := 30
+	if query.Interval == models.TrendWeek {
+		days = 7 * 12
+	}
+	first, end, err := s.dateRange(ctx, query.From, query.To, days)
+	if err != nil {
+		return nil, err
+	}
+	if query.Interval == models.TrendWeek {
+		// Weeks start on Monday
+		first = first.AddDate(0, 0, -(int(first.Weekday())+6)%7)
+	}
+
+	var starts []time.Time
+	for start := first; start.Before(end); start = step(start) {
+		if len(starts) == maxTrendPoints {
+			return nil, fmt.Errorf("%w: ranges are limited to %d buckets", ErrInvalidInput, maxTrendPoints)
+		}
+		starts = append(starts, start)
+	}
+
+	_, offset := first.Zone()
+	flow, err := s.reportRepo.TicketFlow(ctx, query.Interval, first, end, time.Duration(offset)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ticket flow: %w", err)
+	}
+	byBucket := make(map[string]models.TicketFlow, len(flow))
+	for _, row := range flow {
+		byBucket[row.Bucket] = row
+	}
+
+	series := &models.TrendSeries{
+		Metric:   query.Metric,
+		Interval: query.Interval,
+		From:     first,
+		To:       end,
+		Points:   make([]models.TrendPoint, len(starts)),
+	}
+	backlog := byBucket[""].Created - byBucket[""].Resolved
+	for i, start := range starts {
+		row := byBucket[start.Format(time.DateOnly)]
+		point := models.TrendPoint{Start: start}
+		switch query.Metric {
+		case models.TrendCreated:
+			point.Value = row.Created
+		case models.TrendResolved:
+			point.Value = row.Resolved
+		case models.TrendBacklog:
+			backlog += row.Created - row.Resolved
+			point.Value = backlog
+		}
+		series.Points[i] = point
+	}
+	return series, nil
+}
+
+// Burndown forecast window limits, in days
+const (
+	defaultForecastWindow = 14
+	maxForecastWindow     = 90
+)
+
+// Burndown returns the daily backlog between from and to, as Trends does, with
+// a forecast of when the current backlog would clear at the average create and
+// resolve rates of the last window days, today included. window defaults to 14.
+func (s *ReportService) Burndown(ctx context.Context, from, to string, window int) (*models.BurndownReport, error) {
+	if window == 0 {
+		window = defaultForecastWindow
+	}
+	if window < 1 || window > maxForecastWindow {
+		return nil, fmt.Errorf("%w: window must be between 1 and %d days", ErrInvalidInput, maxForecastWindow)
+	}
+	series, err := s.Trends(ctx, models.TrendQuery{Metric: models.TrendBacklog, Interval: models.TrendDay, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.dayStart(ctx, time.Now())
+	first := today.AddDate(0, 0, 1-window)
+	_, offset := first.Zone()
+	flow, err := s.reportRepo.TicketFlow(ctx, models.TrendDay, first, today.AddDate(0, 0, 1), time.Duration(offset)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ticket flow: %w", err)
+	}
+	var backlog, created, resolved int64
+	for _, row := range flow {
+		backlog += row.Created - row.Resolved
+		if row.Bucket != "" {
+			created += row.Created
+			resolved += row.Resolved
+		}
+	}
+
+	forecast := models.BacklogForecast{
+		Backlog:        max(backlog, 0),
+		WindowDays:     window,
+		CreatedPerDay:  float64(created) / float64(window),
+		ResolvedPerDay: float64(resolved) / float64(window),
+	}
+	days := -1
+	switch burn := resolved - created; {
+	case forecast.Backlog == 0:
+		days = 0
+	case burn > 0:
+		days = int(math.Ceil(float64(forecast.Backlog) * float64(window) / float64(burn)))
+	}
+	if days >= 0 {
+		clearsOn := today.AddDate(0, 0, days)
+		forecast.DaysToClear, forecast.ClearsOn = &days, &clearsOn
+	}
+	return &models.BurndownReport{From: series.From, To: series.To, Points: series.Points, Forecast: forecast}, nil
+}
+
+// CategoryReport breaks down the tickets created in a date range by category
+// for the ticket scope carried by ctx. Each category also totals its
+// subcategories, so problem areas show up at every level of the hierarchy.
+// The range defaults to the last 30 days.
+func (s *ReportService) CategoryReport(ctx context.Context, from, to string) (*models.CategoryReport, error) {
+	first, end, err := s.dateRange(ctx, from, to, 30)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	counts, err := s.reportRepo.CountByCategory(ctx, first, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tickets by category: %w", err)
+	}
+
+	children := make(map[uuid.UUID][]models.Category)
+	known := make(map[uuid.UUID]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID != nil && known[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+	own := make(map[uuid.UUID]models.CategoryTicketCount, len(counts))
+	uncategorized := models.CategoryTicketCount{}
+	for _, count := range counts {
+		if count.CategoryID == nil {
+			uncategorized = count
+		} else {
+			own[*count.CategoryID] = count
+		}
+	}
+
+	report := &models.CategoryReport{From: first, To: end, Categories: []models.CategoryBreakdown{}}
+	visited := make(map[uuid.UUID]bool, len(categories))
+	// add appends a category and its subcategories, returning its row's index
+	var add func(category models.Category, depth int) int
+	add = func(category models.Category, depth int) int {
+		visited[category.ID] = true
+		count := own[category.ID]
+		row := models.CategoryBreakdown{CategoryID: &category.ID, Name: category.Name, ParentID: category.ParentID, Depth: depth}
+		row.Own.Add(count.Created, count.Resolved, count.ResolutionSeconds)
+		row.Total.Add(count.Created, count.Resolved, count.ResolutionSeconds)
+		index := len(report.Categories)
+		report.Categories = append(report.Categories, row)
+
+		var total models.CategoryStats
+		for _, child := range children[category.ID] {
+			if visited[child.ID] {
+				continue
+			}
+			childTotal := report.Categories[add(child, depth+1)].Total
+			total.Add(childTotal.Created, childTotal.Resolved, childTotal.ResolutionSeconds())
+		}
+		report.Categories[index].Total.Add(total.Created, total.Resolved, total.ResolutionSeconds())
+		return index
+	}
+	for _, category := range roots {
+		add(category, 0)
+	}
+	if uncategorized.Created > 0 {
+		row := models.CategoryBreakdown{Name: "Uncategorized"}
+		row.Own.Add(uncategorized.Created, uncategorized.Resolved, uncategorized.ResolutionSeconds)
+		row.Total = row.Own
+		report.Categories = append(report.Categories, row)
+	}
+	return report, nil
+}
+
+// AgingReport buckets the open tickets in the ticket scope carried by ctx by
+// how long they have been open, by priority and team, so a rotting backlog
+// shows up before it breaches
+func (s *ReportService) AgingReport(ctx context.Context) (*models.AgingReport, error) {
+	at := time.Now()
+	counts, err := s.reportRepo.OpenByAge(ctx, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open tickets by age: %w", err)
+	}
+
+	priorities := []models.TicketPriority{models.PriorityCritical, models.PriorityHigh, models.PriorityMedium, models.PriorityLow}
+	report := &models.AgingReport{At: at, ByPriority: make([]models.AgingRow, len(priorities)), ByTeam: []models.AgingRow{}, Rows: []models.AgingRow{}}
+	for i, priority := range priorities {
+		report.ByPriority[i].Priority = priority
+	}
+	teams := make(map[uuid.UUID]int)
+	for _, count := range counts {
+		if count.TeamID == nil {
+			count.TeamName = "No team"
+		}
+		report.Total.Add(count.AgingCounts)
+		if i := slices.Index(priorities, count.Priority); i >= 0 {
+			report.ByPriority[i].Add(count.AgingCounts)
+		}
+		report.Rows = append(report.Rows, models.AgingRow{Priority: count.Priority, TeamID: count.TeamID, TeamName: count.TeamName, AgingCounts: count.AgingCounts})
+
+		// Tickets without a team are kept under the nil UUID
+		team := uuid.Nil
+		if count.TeamID != nil {
+			team = *count.TeamID
+		}
+		index, ok := teams[team]
+		if !ok {
+			index = len(report.ByTeam)
+			teams[team] = index
+			report.ByTeam = append(report.ByTeam, models.AgingRow{TeamID: count.TeamID, TeamName: count.TeamName})
+		}
+		report.ByTeam[index].Add(count.AgingCounts)
+	}
+
+	// Teams by name with tickets without a team last; each team's rows by priority
+	byTeam := func(a, b models.AgingRow) int {
+		return cmp.Or(cmp.Compare(boolRank(a.TeamID == nil), boolRank(b.TeamID == nil)), strings.Compare(a.TeamName, b.TeamName))
+	}
+	slices.SortFunc(report.ByTeam, byTeam)
+	slices.SortFunc(report.Rows, func(a, b models.AgingRow) int {
+		return cmp.Or(byTeam(a, b), cmp.Compare(slices.Index(priorities, a.Priority), slices.Index(priorities, b.Priority)))
+	})
+	return report, nil
+}
+
+// boolRank orders false before true
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Root cause report limits: how many causes are listed per month and category
+const (
+	defaultRootCauseLimit = 5
+	maxRootCauseLimit     = 50
+)
+
+// RootCauseReport lists the most frequent root causes of the tickets resolved
+// in a date range, per month overall and per category, for the ticket scope
+// carried by ctx. The range defaults to the current month and the five before
+// it; limit defaults to 5 causes per list.
+func (s *ReportService) RootCauseReport(ctx context.Context, from, to string, limit int) (*models.RootCauseReport, error) {
+	if limit == 0 {
+		limit = defaultRootCauseLimit
+	}
+	if limit < 1 || limit > maxRootCauseLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, maxRootCauseLimit)
+	}
+	if from == "" {
+		if last, err := s.parseDate(ctx, to, s.dayStart(ctx, time.Now())); err == nil {
+			from = time.Date(last.Year(), last.Month()-5, 1, 0, 0, 0, 0, last.Location()).Format(time.DateOnly)
+		}
+	}
+	first, end, err := s.dateRange(ctx, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	_, offset := first.Zone()
+	counts, err := s.reportRepo.CountByRootCause(ctx, first, end, time.Duration(offset)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tickets by root cause: %w", err)
+	}
+
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	byMonth := make(map[string][]models.RootCauseTicketCount)
+	for _, count := range counts {
+		byMonth[count.Month] = append(byMonth[count.Month], count)
+	}
+
+	report := &models.RootCauseReport{From: first, To: end, Months: []models.RootCauseMonth{}}
+	for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, first.Location()); month.Before(end); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		overall := make(map[string]int64)
+		perCategory := make(map[uuid.UUID]map[string]int64)
+		var uncategorized map[string]int64
+		for _, count := range byMonth[key] {
+			overall[count.Code] += count.Tickets
+			causes := uncategorized
+			if count.CategoryID != nil {
+				causes = perCategory[*count.CategoryID]
+			}
+			if causes == nil {
+				causes = make(map[string]int64)
+				if count.CategoryID != nil {
+					perCategory[*count.CategoryID] = causes
+				} else {
+					uncategorized = causes
+				}
+			}
+			causes[count.Code] += count.Tickets
+		}
+
+		row := models.RootCauseMonth{Month: key, Categories: []models.RootCauseCategory{}}
+		row.Top, _ = topRootCauses(overall, limit)
+		for id, causes := range perCategory {
+			category := models.RootCauseCategory{CategoryID: &id, Name: names[id]}
+			category.Causes, category.Tickets = topRootCauses(causes, limit)
+			row.Categories = append(row.Categories, category)
+		}
+		if uncategorized != nil {
+			category := models.RootCauseCategory{Name: "Uncategorized"}
+			category.Causes, category.Tickets = topRootCauses(uncategorized, limit)
+			row.Categories = append(row.Categories, category)
+		}
+		slices.SortFunc(row.Categories, func(a, b models.RootCauseCategory) int {
+			return cmp.Or(cmp.Compare(b.Tickets, a.Tickets), strings.Compare(a.Name, b.Name))
+		})
+		report.Months = append(report.Months, row)
+	}
+	return report, nil
+}
+
+// topRootCauses returns the limit most frequent causes, most frequent first,
+// and the number of tickets over all causes
+func topRootCauses(causes map[string]int64, limit int) ([]models.RootCauseCount, int64) {
+	top := make([]models.RootCauseCount, 0, len(causes))
+	var total int64
+	for code, tickets := range causes {
+		top = append(top, models.RootCauseCount{Code: code, Tickets: tickets})
+		total += tickets
+	}
+	slices.SortFunc(top, func(a, b models.RootCauseCount) int {
+		return cmp.Or(cmp.Compare(b.Tickets, a.Tickets), strings.Compare(a.Code, b.Code))
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, total
+}
+
+// dateRange parses an inclusive range of YYYY-MM-DD dates in the user's time
+// zone, returning the start of the first day and the end of the last. The range
+// defaults to the given number of days ending today.
+func (s *ReportService) dateRange(ctx context.Context, from, to string, days int) (time.Time, time.Time, error) {
+	last, err := s.parseDate(ctx, to, s.dayStart(ctx, time.Now()))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidInput)
+	}
+	first, err := s.parseDate(ctx, from, last.AddDate(0, 0, 1-days))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidInput)
+	}
+	if first.After(last) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidInput)
+	}
+	return first, last.AddDate(0, 0, 1), nil
+}
+
+// parseDate parses a YYYY-MM-DD date in the user's time zone, returning
+// fallback for an empty value
+func (s *ReportService) parseDate(ctx context.Context, value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, s.locationFor(ctx))
+}
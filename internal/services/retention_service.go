@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// retentionBatch is how many requesters or attachments are loaded at a time
+	retentionBatch = 100
+	// retentionSample caps the ids a report lists per rule
+	retentionSample = 20
+)
+
+// RetentionService applies the configured retention rules to the data of
+// closed tickets every night, and previews what they would do
+type RetentionService struct {
+	retentionRepo  repository.RetentionRepository
+	attachmentRepo repository.AttachmentRepository
+	storage        storage.Storage
+	auditService   *AuditService
+	rules          []models.RetentionRule
+	at             string
+	location       *time.Location
+}
+
+// NewRetentionService creates a new retention service. Rules run daily at the
+// configured clock time in the office hours time zone.
+func NewRetentionService(retentionRepo repository.RetentionRepository, attachmentRepo repository.AttachmentRepository, storage storage.Storage, auditService *AuditService, config *config.Config) *RetentionService {
+	location, err := time.LoadLocation(config.Widget.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return &RetentionService{
+		retentionRepo:  retentionRepo,
+		attachmentRepo: attachmentRepo,
+		storage:        storage,
+		auditService:   auditService,
+		rules:          parseRetentionRules(config.Retention.Rules),
+		at:             config.Retention.Time,
+		location:       location,
+	}
+}
+
+// parseRetentionRules reads action=days pairs; invalid pairs are logged and
+// skipped so that a typo never applies a rule it did not mean
+func parseRetentionRules(pairs []string) []models.RetentionRule {
+	var rules []models.RetentionRule
+	for _, pair := range pairs {
+		action, value, _ := strings.Cut(pair, "=")
+		action = strings.ToLower(strings.TrimSpace(action))
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days <= 0 || !slices.Contains(models.RetentionActions, models.RetentionAction(action)) {
+			log.Printf("Ignoring invalid RETENTION_RULES entry %q", pair)
+			continue
+		}
+		rules = append(rules, models.RetentionRule{Action: models.RetentionAction(action), AfterDays: days})
+	}
+	return rules
+}
+
+// Rules returns the configured retention rules
+func (s *RetentionService) Rules() []models.RetentionRule {
+	return s.rules
+}
+
+// Schedule registers the nightly run of the retention rules
+func (s *RetentionService) Schedule(jobs *scheduler.Scheduler) error {
+	if len(s.rules) == 0 {
+		return nil
+	}
+	return jobs.Daily("retention rules", s.at, s.location, func(ctx context.Context) error {
+		_, err := s.Apply(ctx)
+		return err
+	})
+}
+
+// Preview reports what each rule would do if it ran now, without changing anything
+func (s *RetentionService) Preview(ctx context.Context) (*models.RetentionReport, error) {
+	now := time.Now()
+	report := &models.RetentionReport{DryRun: true, At: now, Rules: []models.RetentionRuleResult{}}
+	for _, rule := range s.rules {
+		result := models.RetentionRuleResult{RetentionRule: rule, ClosedBefore: closedBefore(rule, now)}
+		var err error
+		switch rule.Action {
+		case models.RetentionAnonymizeRequester:
+			result.Count, result.Tickets, err = s.retentionRepo.CountRequestersToAnonymize(ctx, result.ClosedBefore)
+			if err == nil {
+				result.SampleIDs, err = s.retentionRepo.ListRequestersToAnonymize(ctx, result.ClosedBefore, retentionSample)
+			}
+		case models.RetentionPurgeAttachments:
+			result.Count, result.Tickets, result.Bytes, err = s.retentionRepo.CountClosedAttachments(ctx, result.ClosedBefore)
+			if err == nil {
+				var attachments []models.Attachment
+				attachments, err = s.retentionRepo.ListClosedAttachments(ctx, result.ClosedBefore, retentionSample)
+				for _, attachment := range attachments {
+					result.SampleIDs = append(result.SampleIDs, attachment.ID)
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview %s rule: %w", rule.Action, err)
+		}
+		report.Rules = append(report.Rules, result)
+	}
+	return report, nil
+}
+
+// Apply runs every rule now, records each in the audit log and reports what
+// they did
+func (s *RetentionService) Apply(ctx context.Context) (*models.RetentionReport, error) {
+	now := time.Now()
+	report := &models.RetentionReport{At: now, Rules: []models.RetentionRuleResult{}}
+	for _, rule := range s.rules {
+		result := models.RetentionRuleResult{RetentionRule: rule, ClosedBefore: closedBefore(rule, now)}
+		var err error
+		switch rule.Action {
+		case models.RetentionAnonymizeRequester:
+			err = s.anonymizeRequesters(ctx, &result, now)
+		case models.RetentionPurgeAttachments:
+			err = s.purgeAttachments(ctx, &result)
+		}
+		if result.Count > 0 {
+			if auditErr := s.auditService.Record(ctx, nil, models.AuditActionRetention, models.AuditEntitySystem, nil, result); auditErr != nil {
+				log.Printf("Failed to audit %s retention rule: %v", rule.Action, auditErr)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply %s rule: %w", rule.Action, err)
+		}
+		report.Rules = append(report.Rules, result)
+	}
+	return report, nil
+}
+
+// closedBefore is the closing time before which a rule applies
+func closedBefore(rule models.RetentionRule, now time.Time) time.Time {
+	return now.AddDate(0, 0, -rule.AfterDays)
+}
+
+// anonymizeRequesters anonymizes, a batch at a time, every requester whose
+// tickets all closed before the rule's cutoff
+func (s *RetentionService) anonymizeRequesters(ctx context.Context, result *models.RetentionRuleResult, now time.Time) error {
+	_, tickets, err := s.retentionRepo.CountRequestersToAnonymize(ctx, result.ClosedBefore)
+	if err != nil {
+		return err
+	}
+	result.Tickets = tickets
+
+	for {
+		ids, err := s.retentionRepo.ListRequestersToAnonymize(ctx, result.ClosedBefore, retentionBatch)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.retentionRepo.AnonymizeRequester(ctx, id, now); err != nil {
+				return fmt.Errorf("failed to anonymize user %s: %w", id, err)
+			}
+			result.Count++
+			appendSample(result, id)
+		}
+		if len(ids) < retentionBatch {
+			return nil
+		}
+	}
+}
+
+// purgeAttachments deletes, a batch at a time, the attachments of tickets
+// closed before the rule's cutoff, along with their files
+func (s *RetentionService) purgeAttachments(ctx context.Context, result *models.RetentionRuleResult) error {
+	_, tickets, _, err := s.retentionRepo.CountClosedAttachments(ctx, result.ClosedBefore)
+	if err != nil {
+		return err
+	}
+	result.Tickets = tickets
+
+	for {
+		attachments, err := s.retentionRepo.ListClosedAttachments(ctx, result.ClosedBefore, retentionBatch)
+		if err != nil {
+			return err
+		}
+		for i := range attachments {
+			attachment := &attachments[i]
+			if err := s.attachmentRepo.Delete(ctx, attachment.ID); err != nil {
+				return fmt.Errorf("failed to delete attachment %s: %w", attachment.ID, err)
+			}
+			deleteAttachmentFiles(ctx, s.storage, attachment)
+			result.Count++
+			result.Bytes += attachment.FileSize
+			appendSample(result, attachment.ID)
+		}
+		if len(attachments) < retentionBatch {
+			return nil
+		}
+	}
+}
+
+// appendSample adds an id to a rule result while it has room
+func appendSample(result *models.RetentionRuleResult, id uuid.UUID) {
+	if len(result.SampleIDs) < retentionSample {
+		result.SampleIDs = append(result.SampleIDs, id)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sandboxCleanupBatch caps how many expired sandboxes one run of the cleanup job deletes
+const sandboxCleanupBatch = 50
+
+// sandboxEmailDomain is the reserved domain of demo requesters' addresses, so
+// nothing is ever delivered to them
+const sandboxEmailDomain = "sandbox.invalid"
+
+// sandboxRequesters are the made-up customers of a training sandbox
+var sandboxRequesters = []struct{ FirstName, LastName string }{
+	{"Alex", "Morgan"},
+	{"Sam", "Rivera"},
+	{"Jordan", "Lee"},
+}
+
+// sandboxScenario is a demo ticket of a training sandbox
+type sandboxScenario struct {
+	Title       string
+	Description string
+	Priority    models.TicketPriority
+	// Requester indexes sandboxRequesters
+	Requester int
+	// Comment is a follow-up from the requester, if any
+	Comment string
+	// Assigned starts the ticket in progress with the agent practicing
+	Assigned bool
+	// Due is how long until the ticket is due; 0 leaves it without a due date
+	Due time.Duration
+}
+
+// sandboxScenarios cover assigning, commenting on and escalating tickets
+var sandboxScenarios = []sandboxScenario{
+	{
+		Title:       "Cannot connect to the VPN from home",
+		Description: "Since this morning the VPN client times out right after I enter my code. It worked fine yesterday.",
+		Priority:    models.PriorityHigh,
+		Requester:   0,
+		Comment:     "I restarted my laptop and the router, still the same.",
+	},
+	{
+		Title:       "Printer on the third floor jams on every job",
+		Description: "The printer next to the kitchen jams on every print, even single pages.",
+		Priority:    models.PriorityMedium,
+		Requester:   1,
+	},
+	{
+		Title:       "Access to the finance shared drive",
+		Description: "I joined the finance team this week and need access to the team's shared drive.",
+		Priority:    models.PriorityLow,
+		Requester:   2,
+	},
+	{
+		Title:       "Email stopped syncing on my phone",
+		Description: "New emails no longer show up on my phone, only on my laptop.",
+		Priority:    models.PriorityMedium,
+		Requester:   0,
+		Comment:     "It's an Android phone, if that helps.",
+		Assigned:    true,
+	},
+	{
+		Title:       "Payroll system down for the whole team",
+		Description: "Nobody on the payroll team can sign in to the payroll system and payslips are due today.",
+		Priority:    models.PriorityCritical,
+		Requester:   1,
+		Due:         time.Hour,
+	},
+}
+
+// SandboxService gives agents a training sandbox: demo requesters and tickets
+// they practice assigning, commenting on and escalating, while everything
+// else is hidden from them and their practice from everyone else. Sandboxes
+// are cleaned up once they expire.
+type SandboxService struct {
+	sandboxRepo repository.SandboxRepository
+	storage     storage.Storage
+	ttl         time.Duration
+}
+
+// NewSandboxService creates a new sandbox service
+func NewSandboxService(sandboxRepo repository.SandboxRepository, storage storage.Storage, cfg *config.Config) *SandboxService {
+	ttl, err := time.ParseDuration(cfg.Tickets.SandboxTTL)
+	if err != nil || ttl <= 0 {
+		ttl = 8 * time.Hour // fallback
+	}
+
+	return &SandboxService{
+		sandboxRepo: sandboxRepo,
+		storage:     storage,
+		ttl:         ttl,
+	}
+}
+
+// Start puts an agent in a new training sandbox filled with demo data
+func (s *SandboxService) Start(ctx context.Context, agent *models.User) (*models.Sandbox, error) {
+	if !agent.IsAgent() {
+		return nil, fmt.Errorf("%w: only support staff can practice in a sandbox", ErrForbidden)
+	}
+	if _, err := s.sandboxRepo.GetByAgent(ctx, agent.ID); err == nil {
+		return nil, fmt.Errorf("%w: you are already in a training sandbox", ErrConflict)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check sandbox: %w", err)
+	}
+
+	now := time.Now()
+	sandbox := &models.Sandbox{
+		ID:        uuid.New(),
+		AgentID:   agent.ID,
+		Tickets:   len(sandboxScenarios),
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	requesters := make([]models.User, len(sandboxRequesters))
+	for i, name := range sandboxRequesters {
+		requesters[i] = models.User{
+			ID:    uuid.New(),
+			Email: strings.ToLower(fmt.Sprintf("%s.%s.%s@%s", name.FirstName, name.LastName, sandbox.ID.String()[:8], sandboxEmailDomain)),
+			// Not a password hash, so demo requesters cannot sign in
+			PasswordHash: "!",
+			FirstName:    name.FirstName,
+			LastName:     name.LastName,
+			Role:         models.RoleEndUser,
+			IsVerified:   true,
+			IsActive:     true,
+			SandboxID:    &sandbox.ID,
+			Demo:         true,
+		}
+	}
+
+	var tickets []models.Ticket
+	var comments []models.Comment
+	for _, scenario := range sandboxScenarios {
+		requester := requesters[scenario.Requester]
+		ticket := models.Ticket{
+			ID:          uuid.New(),
+			SandboxID:   &sandbox.ID,
+			Title:       scenario.Title,
+			Description: scenario.Description,
+			Status:      models.StatusOpen,
+			Priority:    scenario.Priority,
+			CreatedByID: requester.ID,
+			OpenedBy:    &requester.ID,
+		}
+		if scenario.Assigned {
+			ticket.Status = models.StatusInProgress
+			ticket.AssignedAgentID = &agent.ID
+		}
+		if scenario.Due > 0 {
+			due := now.Add(scenario.Due).UTC()
+			ticket.DueDate = &due
+		}
+		tickets = append(tickets, ticket)
+
+		if scenario.Comment != "" {
+			comments = append(comments, models.Comment{
+				TicketID: ticket.ID,
+				UserID:   requester.ID,
+				Content:  scenario.Comment,
+				Format:   models.CommentFormatPlain,
+			})
+		}
+	}
+
+	if err := s.sandboxRepo.Create(ctx, sandbox, requesters, tickets, comments); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	return sandbox, nil
+}
+
+// Get retrieves the training sandbox an agent is in
+func (s *SandboxService) Get(ctx context.Context, agentID uuid.UUID) (*models.Sandbox, error) {
+	sandbox, err := s.sandboxRepo.GetByAgent(ctx, agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("sandbox %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get sandbox: %w", err)
+	}
+	return sandbox, nil
+}
+
+// End takes an agent out of their training sandbox and deletes its demo data
+func (s *SandboxService) End(ctx context.Context, agentID uuid.UUID) error {
+	sandbox, err := s.Get(ctx, agentID)
+	if err != nil {
+		return err
+	}
+	if err := s.remove(ctx, sandbox); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("sandbox %w", ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// Schedule registers the job cleaning up expired sandboxes
+func (s *SandboxService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("sandbox cleanup", 15*time.Minute, s.RemoveExpired)
+}
+
+// RemoveExpired deletes the training sandboxes that have expired, taking
+// their agents out of them
+func (s *SandboxService) RemoveExpired(ctx context.Context) error {
+	expired, err := s.sandboxRepo.ListExpired(ctx, time.Now(), sandboxCleanupBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list expired sandboxes: %w", err)
+	}
+	for i := range expired {
+		// Ended by its agent meanwhile
+		if err := s.remove(ctx, &expired[i]); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove deletes a sandbox with its demo data and attachment files
+func (s *SandboxService) remove(ctx context.Context, sandbox *models.Sandbox) error {
+	attachments, err := s.sandboxRepo.Delete(ctx, sandbox)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete sandbox %s: %w", sandbox.ID, err)
+	}
+	for i := range attachments {
+		deleteAttachmentFiles(ctx, s.storage, &attachments[i])
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"github.com/google/uuid"
+)
+
+const (
+	// suggestMinPrefix is the shortest prefix worth looking up; shorter ones match too much
+	suggestMinPrefix = 2
+	// suggestMaxPrefix caps the prefix length, since titles and names are short
+	suggestMaxPrefix = 100
+	// DefaultSuggestLimit is how many matches of each kind are returned by default
+	DefaultSuggestLimit = 5
+	// MaxSuggestLimit caps how many matches of each kind may be asked for
+	MaxSuggestLimit = 20
+	// suggestCacheTTL is how long suggestions for a prefix are reused. Typing the
+	// same prefix again, or several agents searching alike, then skips the database.
+	suggestCacheTTL = 30 * time.Second
+	// lookupTicketLimit caps how many tickets a lookup returns
+	lookupTicketLimit = 10
+)
+
+// ticketReferencePattern matches the short ticket reference shown in email
+// subjects, the first 8 characters of the ticket id, with an optional "#"
+var ticketReferencePattern = regexp.MustCompile(`^#?([0-9A-Fa-f]{8})$`)
+
+// SearchService powers the typeahead in the global search box
+type SearchService struct {
+	searchRepo repository.SearchRepository
+	cache      cache.Cache
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(searchRepo repository.SearchRepository, cache cache.Cache) *SearchService {
+	return &SearchService{
+		searchRepo: searchRepo,
+		cache:      cache,
+	}
+}
+
+// Suggest returns up to limit tickets, requesters, categories and knowledge
+// articles of each kind matching prefix. Tickets are limited to the scope
+// carried by ctx, and draft articles are only included when includeDrafts is set.
+func (s *SearchService) Suggest(ctx context.Context, prefix string, limit int, includeDrafts bool) (*models.SearchSuggestions, error) {
+	prefix = strings.TrimSpace(prefix)
+	if utf8.RuneCountInString(prefix) > suggestMaxPrefix {
+		return nil, fmt.Errorf("%w: q must be at most %d characters", ErrInvalidInput, suggestMaxPrefix)
+	}
+	if limit < 1 || limit > MaxSuggestLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, MaxSuggestLimit)
+	}
+
+	suggestions := &models.SearchSuggestions{
+		Query:      prefix,
+		Tickets:    []models.SearchSuggestion{},
+		Requesters: []models.SearchSuggestion{},
+		Categories: []models.SearchSuggestion{},
+		Articles:   []models.SearchSuggestion{},
+	}
+	if utf8.RuneCountInString(prefix) < suggestMinPrefix {
+		return suggestions, nil
+	}
+
+	key := fmt.Sprintf("suggest:%s:%t:%d:%s", scopeKey(ctx), includeDrafts, limit, strings.ToLower(prefix))
+	if cached, ok := s.cache.Get(key); ok {
+		if err := json.Unmarshal([]byte(cached), suggestions); err == nil {
+			suggestions.Query = prefix
+			return suggestions, nil
+		}
+	}
+
+	var err error
+	if suggestions.Tickets, err = s.searchRepo.SuggestTickets(ctx, prefix, limit); err != nil {
+		return nil, fmt.Errorf("failed to suggest tickets: %w", err)
+	}
+	if suggestions.Requesters, err = s.searchRepo.SuggestRequesters(ctx, prefix, limit); err != nil {
+		return nil, fmt.Errorf("failed to suggest requesters: %w", err)
+	}
+	if suggestions.Categories, err = s.searchRepo.SuggestCategories(ctx, prefix, limit); err != nil {
+		return nil, fmt.Errorf("failed to suggest categories: %w", err)
+	}
+	if suggestions.Articles, err = s.searchRepo.SuggestArticles(ctx, prefix, limit, includeDrafts); err != nil {
+		return nil, fmt.Errorf("failed to suggest articles: %w", err)
+	}
+	for _, group := range []*[]models.SearchSuggestion{&suggestions.Tickets, &suggestions.Requesters, &suggestions.Categories, &suggestions.Articles} {
+		if *group == nil {
+			*group = []models.SearchSuggestion{}
+		}
+	}
+
+	if data, err := json.Marshal(suggestions); err == nil {
+		s.cache.Set(key, string(data), suggestCacheTTL)
+	}
+	return suggestions, nil
+}
+
+// Lookup resolves a ticket id, a short ticket reference such as "#1A2B3C4D"
+// or an email address, for the quick lookup in the agent console. Tickets are
+// limited to the scope carried by ctx. Staff accounts are only found when
+// includeStaff is set; other users are end users who may raise tickets.
+func (s *SearchService) Lookup(ctx context.Context, q string, includeStaff bool) (*models.LookupResult, error) {
+	q = strings.TrimSpace(q)
+	result := &models.LookupResult{Query: q, Tickets: []models.SearchSuggestion{}}
+
+	var err error
+	if id, parseErr := uuid.Parse(strings.TrimPrefix(q, "#")); parseErr == nil {
+		result.Tickets, err = s.searchRepo.LookupTickets(ctx, id.String(), 1)
+	} else if match := ticketReferencePattern.FindStringSubmatch(q); match != nil {
+		// Short references are not unique, so every ticket sharing one is listed
+		result.Tickets, err = s.searchRepo.LookupTickets(ctx, match[1], lookupTicketLimit)
+	} else if address, parseErr := mail.ParseAddress(q); parseErr == nil && address.Name == "" {
+		if result.User, err = s.searchRepo.LookupUser(ctx, address.Address, includeStaff); err != nil {
+			return nil, fmt.Errorf("failed to look up user: %w", err)
+		}
+		if result.User != nil {
+			result.Tickets, err = s.searchRepo.LookupRequesterTickets(ctx, result.User.ID, lookupTicketLimit)
+		}
+	} else {
+		return nil, fmt.Errorf("%w: q must be a ticket reference or an email address", ErrInvalidInput)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up tickets: %w", err)
+	}
+	if result.Tickets == nil {
+		result.Tickets = []models.SearchSuggestion{}
+	}
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/signedtoken"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SharedTicketPath is the public route prefix that serves shared tickets
+const SharedTicketPath = "/api/v1/public/shared-tickets/"
+
+var (
+	// ErrShareLinkInvalid is returned when a share token is malformed or tampered with
+	ErrShareLinkInvalid = errors.New("invalid share link")
+	// ErrShareLinkExpired is returned when a share token is past its expiry
+	ErrShareLinkExpired = errors.New("share link has expired")
+)
+
+// ShareService handles public, read-only ticket share links
+type ShareService struct {
+	ticketRepo  repository.TicketRepository
+	commentRepo repository.CommentRepository
+	signer      *signedtoken.Signer
+	config      *config.Config
+}
+
+// NewShareService creates a new share service
+func NewShareService(ticketRepo repository.TicketRepository, commentRepo repository.CommentRepository, config *config.Config) *ShareService {
+	return &ShareService{
+		ticketRepo:  ticketRepo,
+		commentRepo: commentRepo,
+		signer:      signedtoken.NewSigner(config.Share.SecretKey, "ticket-share"),
+		config:      config,
+	}
+}
+
+// CreateShareLink generates a signed, expiring link to the read-only view of a ticket
+func (s *ShareService) CreateShareLink(ctx context.Context, ticketID uuid.UUID, user *models.User, req *models.ShareTicketRequest) (*models.TicketShareResponse, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	// Only the requester or support staff may share a ticket
+	if ticket.CreatedByID != user.ID && !user.IsAgent() {
+		return nil, fmt.Errorf("%w: only the requester or an agent can share this ticket", ErrForbidden)
+	}
+
+	ttl, err := s.resolveTTL(req.ExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token := s.signer.Sign(ticket.ID[:], expiresAt)
+
+	return &models.TicketShareResponse{
+		URL:       strings.TrimRight(s.config.Share.BaseURL, "/") + SharedTicketPath + token,
+		Token:     token,
+		ExpiresAt: expiresAt.Truncate(time.Second),
+	}, nil
+}
+
+// GetSharedTicket resolves a share token to the read-only view of its ticket
+func (s *ShareService) GetSharedTicket(ctx context.Context, token string) (*models.SharedTicketView, error) {
+	payload, expiresAt, err := s.signer.Verify(token, time.Now())
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpiredToken) {
+			return nil, ErrShareLinkExpired
+		}
+		return nil, ErrShareLinkInvalid
+	}
+
+	ticketID, err := uuid.FromBytes(payload)
+	if err != nil {
+		return nil, ErrShareLinkInvalid
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	// Internal notes are never exposed through share links
+	comments, err := s.commentRepo.GetByTicket(ctx, ticketID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	view := &models.SharedTicketView{
+		ID:         ticket.ID,
+		Title:      ticket.Title,
+		Status:     ticket.Status,
+		Priority:   ticket.Priority,
+		CreatedAt:  ticket.CreationTime,
+		ResolvedAt: ticket.ResolvedAt,
+		Comments:   make([]models.SharedComment, 0, len(comments)),
+		ExpiresAt:  expiresAt,
+	}
+
+	for _, comment := range comments {
+		// Only expose first names to people outside the organisation
+		authorName := ""
+		if comment.User != nil {
+			authorName = comment.User.FirstName
+		}
+		view.Comments = append(view.Comments, models.SharedComment{
+			AuthorName: authorName,
+			Content:    comment.Content,
+			CreatedAt:  comment.CreatedAt,
+		})
+	}
+
+	return view, nil
+}
+
+// resolveTTL parses the requested lifetime of a link, applying the configured default and maximum
+func (s *ShareService) resolveTTL(expiresIn string) (time.Duration, error) {
+	maxTTL, err := time.ParseDuration(s.config.Share.MaxTTL)
+	if err != nil {
+		maxTTL = 30 * 24 * time.Hour // fallback
+	}
+
+	if expiresIn == "" {
+		ttl, err := time.ParseDuration(s.config.Share.DefaultTTL)
+		if err != nil {
+			ttl = 72 * time.Hour // fallback
+		}
+		return min(ttl, maxTTL), nil
+	}
+
+	ttl, err := time.ParseDuration(expiresIn)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("%w: expires_in must be a positive duration such as 24h", ErrInvalidInput)
+	}
+	if ttl > maxTTL {
+		return 0, fmt.Errorf("%w: expires_in must not exceed %s", ErrInvalidInput, maxTTL)
+	}
+
+	return ttl, nil
+}
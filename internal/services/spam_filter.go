@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+// SpamCandidate is an inbound submission to be scored
+type SpamCandidate struct {
+	Source   models.SubmissionSource `json:"source"`
+	Name     string                  `json:"name"`
+	Email    string                  `json:"email"`
+	Subject  string                  `json:"subject"`
+	Body     string                  `json:"body"`
+	RemoteIP string                  `json:"remote_ip,omitempty"`
+}
+
+// SpamVerdict is the score assigned to a candidate and the rules that contributed to it
+type SpamVerdict struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// SpamChecker scores a candidate; higher scores are more likely spam
+type SpamChecker interface {
+	Check(ctx context.Context, candidate *SpamCandidate) (*SpamVerdict, error)
+}
+
+// SpamFilter combines the configured checkers and decides whether a candidate is spam
+type SpamFilter struct {
+	mu        sync.RWMutex
+	enabled   bool
+	threshold float64
+	checkers  []SpamChecker
+}
+
+// NewSpamFilter creates a spam filter with keyword rules and, when configured, an external scoring service
+func NewSpamFilter(cfg *config.Config) *SpamFilter {
+	f := &SpamFilter{}
+	f.Reload(cfg)
+	return f
+}
+
+// Reload applies the spam settings of cfg to later evaluations
+func (f *SpamFilter) Reload(cfg *config.Config) error {
+	checkers := []SpamChecker{NewKeywordSpamChecker(cfg.Spam.Keywords)}
+	if cfg.Spam.ExternalURL != "" {
+		checkers = append(checkers, NewHTTPSpamChecker(cfg.Spam))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = cfg.Spam.Enabled
+	f.threshold = cfg.Spam.Threshold
+	f.checkers = checkers
+	return nil
+}
+
+// Evaluate scores a candidate and reports whether it should be quarantined.
+// Checker failures are logged and skipped so an unavailable external
+// service never blocks legitimate submissions.
+func (f *SpamFilter) Evaluate(ctx context.Context, candidate *SpamCandidate) (*SpamVerdict, bool) {
+	f.mu.RLock()
+	enabled, threshold, checkers := f.enabled, f.threshold, f.checkers
+	f.mu.RUnlock()
+
+	verdict := &SpamVerdict{}
+	if !enabled {
+		return verdict, false
+	}
+
+	for _, checker := range checkers {
+		result, err := checker.Check(ctx, candidate)
+		if err != nil {
+			log.Printf("spam checker failed: %v", err)
+			continue
+		}
+		verdict.Score += result.Score
+		verdict.Reasons = append(verdict.Reasons, result.Reasons...)
+	}
+
+	return verdict, verdict.Score >= threshold
+}
+
+var linkRegex = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// KeywordSpamChecker scores candidates using keyword rules and simple content heuristics
+type KeywordSpamChecker struct {
+	keywords []string
+}
+
+// NewKeywordSpamChecker creates a new keyword spam checker
+func NewKeywordSpamChecker(keywords []string) *KeywordSpamChecker {
+	normalized := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			normalized = append(normalized, keyword)
+		}
+	}
+	return &KeywordSpamChecker{keywords: normalized}
+}
+
+// Check applies the keyword rules and heuristics to a candidate
+func (k *KeywordSpamChecker) Check(ctx context.Context, candidate *SpamCandidate) (*SpamVerdict, error) {
+	verdict := &SpamVerdict{}
+	text := strings.ToLower(candidate.Subject + "\n" + candidate.Body)
+
+	for _, keyword := range k.keywords {
+		if hits := strings.Count(text, keyword); hits > 0 {
+			// Repeated keywords add up, but only to a point
+			verdict.Score += 2.5 * float64(min(hits, 2))
+			verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("keyword: %s", keyword))
+		}
+	}
+
+	if links := len(linkRegex.FindAllString(text, -1)); links > 3 {
+		verdict.Score += float64(links - 3)
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("links: %d", links))
+	}
+
+	if isShouting(candidate.Subject) {
+		verdict.Score += 1.5
+		verdict.Reasons = append(verdict.Reasons, "all caps subject")
+	}
+
+	return verdict, nil
+}
+
+// isShouting reports whether a string is long enough to judge and written entirely in capitals
+func isShouting(s string) bool {
+	letters, upper := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 10 && upper == letters
+}
+
+// HTTPSpamChecker delegates scoring to an external service. The service
+// receives the candidate as JSON and responds with {"score": n, "reasons": [...]}.
+type HTTPSpamChecker struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPSpamChecker creates a new external spam checker
+func NewHTTPSpamChecker(cfg config.SpamConfig) *HTTPSpamChecker {
+	timeout, err := time.ParseDuration(cfg.ExternalTimeout)
+	if err != nil {
+		timeout = 3 * time.Second // fallback
+	}
+
+	return &HTTPSpamChecker{
+		url:    cfg.ExternalURL,
+		apiKey: cfg.ExternalAPIKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Check sends the candidate to the external service
+func (h *HTTPSpamChecker) Check(ctx context.Context, candidate *SpamCandidate) (*SpamVerdict, error) {
+	body, err := json.Marshal(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode candidate: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("spam service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spam service returned status %d", resp.StatusCode)
+	}
+
+	var verdict SpamVerdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("failed to decode spam service response: %w", err)
+	}
+
+	return &verdict, nil
+}
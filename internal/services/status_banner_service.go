@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StatusBannerService manages major incident banners and their subscribers
+type StatusBannerService struct {
+	bannerRepo   repository.StatusBannerRepository
+	categoryRepo repository.CategoryRepository
+	problemRepo  repository.ProblemRepository
+	sender       email.EmailSender
+}
+
+// NewStatusBannerService creates a new status banner service
+func NewStatusBannerService(bannerRepo repository.StatusBannerRepository, categoryRepo repository.CategoryRepository, problemRepo repository.ProblemRepository, sender email.EmailSender) *StatusBannerService {
+	return &StatusBannerService{
+		bannerRepo:   bannerRepo,
+		categoryRepo: categoryRepo,
+		problemRepo:  problemRepo,
+		sender:       sender,
+	}
+}
+
+// CreateBanner publishes a status banner. It starts immediately unless starts_at is given.
+func (s *StatusBannerService) CreateBanner(ctx context.Context, req *models.CreateStatusBannerRequest, createdByID uuid.UUID) (*models.StatusBanner, error) {
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidInput)
+	}
+
+	categories := make([]models.Category, 0, len(req.CategoryIDs))
+	for _, categoryID := range req.CategoryIDs {
+		category, err := s.categoryRepo.GetByID(ctx, categoryID)
+		if err != nil || category == nil {
+			return nil, fmt.Errorf("%w: category %s not found", ErrInvalidInput, categoryID)
+		}
+		categories = append(categories, *category)
+	}
+
+	if req.ProblemID != nil {
+		if _, err := s.problemRepo.GetByID(ctx, *req.ProblemID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: problem not found", ErrInvalidInput)
+			}
+			return nil, fmt.Errorf("failed to get problem: %w", err)
+		}
+	}
+
+	banner := &models.StatusBanner{
+		Message:     strings.TrimSpace(req.Message),
+		Severity:    req.Severity,
+		StartsAt:    startsAt,
+		EndsAt:      req.EndsAt,
+		ProblemID:   req.ProblemID,
+		CreatedByID: createdByID,
+		Categories:  categories,
+	}
+	if err := s.bannerRepo.Create(ctx, banner); err != nil {
+		return nil, fmt.Errorf("failed to create status banner: %w", err)
+	}
+	return s.GetBanner(ctx, banner.ID)
+}
+
+// GetBanner retrieves a status banner by ID
+func (s *StatusBannerService) GetBanner(ctx context.Context, id uuid.UUID) (*models.StatusBanner, error) {
+	banner, err := s.bannerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("status banner %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get status banner: %w", err)
+	}
+
+	banners := []models.StatusBanner{*banner}
+	if err := s.withSubscriberCounts(ctx, banners); err != nil {
+		return nil, err
+	}
+	return &banners[0], nil
+}
+
+// ListBanners retrieves every status banner
+func (s *StatusBannerService) ListBanners(ctx context.Context) ([]models.StatusBanner, error) {
+	banners, err := s.bannerRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status banners: %w", err)
+	}
+	if err := s.withSubscriberCounts(ctx, banners); err != nil {
+		return nil, err
+	}
+	return banners, nil
+}
+
+// ActiveBanners retrieves the banners shown now, optionally only those that
+// affect a ticket category
+func (s *StatusBannerService) ActiveBanners(ctx context.Context, categoryID *uuid.UUID) ([]models.StatusBanner, error) {
+	banners, err := s.bannerRepo.ListActive(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status banners: %w", err)
+	}
+	if categoryID != nil {
+		affected := make([]models.StatusBanner, 0, len(banners))
+		for _, banner := range banners {
+			if banner.AffectsCategory(*categoryID) {
+				affected = append(affected, banner)
+			}
+		}
+		banners = affected
+	}
+
+	if err := s.withSubscriberCounts(ctx, banners); err != nil {
+		return nil, err
+	}
+	return banners, nil
+}
+
+// DeleteBanner deletes a status banner without notifying subscribers
+func (s *StatusBannerService) DeleteBanner(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetBanner(ctx, id); err != nil {
+		return err
+	}
+	return s.bannerRepo.Delete(ctx, id)
+}
+
+// Subscribe registers an address to be told when an active banner's issue is resolved.
+// Subscribing twice is a no-op.
+func (s *StatusBannerService) Subscribe(ctx context.Context, id uuid.UUID, req *models.SubscribeBannerRequest) error {
+	banner, err := s.GetBanner(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !banner.IsActive(time.Now()) {
+		return fmt.Errorf("%w: the issue is no longer active", ErrConflict)
+	}
+
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// Addresses are encrypted at rest, so duplicates are found after decryption
+	subscriptions, err := s.bannerRepo.ListSubscriptions(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Email == address {
+			return nil
+		}
+	}
+
+	if err := s.bannerRepo.AddSubscription(ctx, &models.BannerSubscription{BannerID: id, Email: address}); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return nil
+}
+
+// SubscribeUser subscribes a signed-in user to an active issue, so they are told
+// when it is resolved instead of filing a duplicate ticket
+func (s *StatusBannerService) SubscribeUser(ctx context.Context, id uuid.UUID, user *models.User) error {
+	return s.Subscribe(ctx, id, &models.SubscribeBannerRequest{Email: user.Email})
+}
+
+// ResolveBanner ends a banner and emails its subscribers that the issue is resolved
+func (s *StatusBannerService) ResolveBanner(ctx context.Context, id uuid.UUID, req *models.ResolveStatusBannerRequest) (*models.StatusBanner, error) {
+	banner, err := s.GetBanner(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if banner.ResolvedAt != nil {
+		return nil, fmt.Errorf("%w: the status banner is already resolved", ErrConflict)
+	}
+
+	now := time.Now()
+	banner.ResolvedAt = &now
+	banner.Resolution = req.Resolution
+	if banner.EndsAt == nil || banner.EndsAt.After(now) {
+		banner.EndsAt = &now
+	}
+	if err := s.bannerRepo.Update(ctx, banner); err != nil {
+		return nil, fmt.Errorf("failed to resolve status banner: %w", err)
+	}
+
+	subscriptions, err := s.bannerRepo.ListSubscriptions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	text := fmt.Sprintf("The following issue has been resolved:\n\n%s\n", banner.Message)
+	if banner.Resolution != "" {
+		text += fmt.Sprintf("\n%s\n", banner.Resolution)
+	}
+	for _, subscription := range subscriptions {
+		// Each subscriber gets their own message so addresses are not disclosed to each other
+		msg := &email.Message{
+			To:      []string{subscription.Email},
+			Subject: "Resolved: " + banner.Message,
+			Text:    text,
+		}
+		if err := s.sender.Send(ctx, msg); err != nil {
+			log.Printf("failed to notify subscriber of status banner %s: %v", banner.ID, err)
+		}
+	}
+
+	return banner, nil
+}
+
+// withSubscriberCounts fills in the subscriber count of each banner
+func (s *StatusBannerService) withSubscriberCounts(ctx context.Context, banners []models.StatusBanner) error {
+	if len(banners) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(banners))
+	for i := range banners {
+		ids[i] = banners[i].ID
+	}
+	counts, err := s.bannerRepo.CountSubscriptions(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	for i := range banners {
+		banners[i].SubscriberCount = counts[banners[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TeamService manages teams and their membership
+type TeamService struct {
+	teamRepo repository.TeamRepository
+	userRepo repository.UserRepository
+}
+
+// NewTeamService creates a new team service
+func NewTeamService(teamRepo repository.TeamRepository, userRepo repository.UserRepository) *TeamService {
+	return &TeamService{
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+	}
+}
+
+// CreateTeam creates a new team
+func (s *TeamService) CreateTeam(ctx context.Context, req *models.CreateTeamRequest) (*models.Team, error) {
+	team := &models.Team{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+	}
+	if err := s.teamRepo.Create(ctx, team); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("%w: a team named %q already exists", ErrConflict, team.Name)
+		}
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+	return team, nil
+}
+
+// GetTeam retrieves a team with its members
+func (s *TeamService) GetTeam(ctx context.Context, id uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("team %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	return team, nil
+}
+
+// ListTeams retrieves all teams
+func (s *TeamService) ListTeams(ctx context.Context) ([]models.Team, error) {
+	return s.teamRepo.List(ctx)
+}
+
+// DeleteTeam deletes a team and its memberships
+func (s *TeamService) DeleteTeam(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetTeam(ctx, id); err != nil {
+		return err
+	}
+	return s.teamRepo.Delete(ctx, id)
+}
+
+// AddMember adds a user to a team
+func (s *TeamService) AddMember(ctx context.Context, teamID, userID uuid.UUID) (*models.Team, error) {
+	if _, err := s.GetTeam(ctx, teamID); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	if err := s.teamRepo.AddMember(ctx, teamID, userID); err != nil {
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+	return s.GetTeam(ctx, teamID)
+}
+
+// RemoveMember removes a user from a team
+func (s *TeamService) RemoveMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	if _, err := s.GetTeam(ctx, teamID); err != nil {
+		return err
+	}
+	return s.teamRepo.RemoveMember(ctx, teamID, userID)
+}
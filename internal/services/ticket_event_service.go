@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"github.com/google/uuid"
+)
+
+// ticketsTopic is the hub topic every ticket event is published to
+const ticketsTopic = "tickets"
+
+// TicketEventService pushes ticket changes to connected clients so that
+// dashboards and ticket lists update live instead of polling. Each client
+// only receives the events of tickets it could list.
+type TicketEventService struct {
+	hub      *realtime.Hub
+	teamRepo repository.TeamRepository
+}
+
+// NewTicketEventService creates a new ticket event service
+func NewTicketEventService(hub *realtime.Hub, teamRepo repository.TeamRepository) *TicketEventService {
+	return &TicketEventService{
+		hub:      hub,
+		teamRepo: teamRepo,
+	}
+}
+
+// ticketViewer is what a subscription needs to know about its user to decide
+// which ticket events they may see
+type ticketViewer struct {
+	userID uuid.UUID
+	// all is set for users who may see every ticket
+	all bool
+	// teamIDs are the teams whose tickets staff without all may see, read when
+	// they subscribe
+	teamIDs []uuid.UUID
+	// sandboxID is set while the viewer practices in a training sandbox
+	sandboxID *uuid.UUID
+}
+
+// canSee reports whether the viewer may see an event. Besides the tickets in
+// their scope, everyone sees the tickets they requested or are or were just
+// assigned. assigneeTeams are the teams of the ticket's assigned agent. In a
+// training sandbox the viewer sees its demo tickets and nothing else.
+func (v *ticketViewer) canSee(event *models.TicketEvent, assigneeTeams []uuid.UUID) bool {
+	switch {
+	case v.sandboxID != nil || event.SandboxID != nil:
+		return v.sandboxID != nil && event.SandboxID != nil && *v.sandboxID == *event.SandboxID
+	case v.all, event.RequesterID == v.userID:
+		return true
+	case event.AssignedAgentID != nil && *event.AssignedAgentID == v.userID:
+		return true
+	case event.PreviousAgentID != nil && *event.PreviousAgentID == v.userID:
+		return true
+	case event.TeamID != nil && slices.Contains(v.teamIDs, *event.TeamID):
+		return true
+	}
+	for _, teamID := range assigneeTeams {
+		if slices.Contains(v.teamIDs, teamID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe starts receiving the events of the tickets user may see. Changes
+// to the user's teams, or entering or leaving a training sandbox, apply from
+// their next subscription.
+func (s *TicketEventService) Subscribe(ctx context.Context, user *models.User) (*realtime.Subscription, error) {
+	viewer := &ticketViewer{userID: user.ID, all: user.HasPermission(models.PermTicketReadAll), sandboxID: user.SandboxID}
+	if !viewer.all && user.HasPermission(models.PermTicketRead) {
+		teamIDs, err := s.teamRepo.GetTeamIDsForUser(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user teams: %w", err)
+		}
+		viewer.teamIDs = teamIDs
+	}
+	return s.hub.SubscribeAs(ticketsTopic, user.IsAgent(), viewer), nil
+}
+
+// Publish sends an event to the subscribers who may see its ticket. It is
+// safe to call on a nil service, which publishes nothing.
+func (s *TicketEventService) Publish(ctx context.Context, event *models.TicketEvent) {
+	if s == nil || s.hub.Subscribers(ticketsTopic) == 0 {
+		return
+	}
+
+	// Team scopes also cover tickets assigned to a member of the team
+	var assigneeTeams []uuid.UUID
+	if event.AssignedAgentID != nil {
+		teamIDs, err := s.teamRepo.GetTeamIDsForUser(ctx, *event.AssignedAgentID)
+		if err != nil {
+			log.Printf("failed to get teams of agent %s for ticket event: %v", *event.AssignedAgentID, err)
+		}
+		assigneeTeams = teamIDs
+	}
+
+	s.hub.Publish(ticketsTopic, event, func(sub *realtime.Subscription) bool {
+		viewer, ok := sub.Viewer.(*ticketViewer)
+		return ok && viewer.canSee(event, assigneeTeams)
+	})
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ticketLockKeyPrefix namespaces ticket edit locks in the cache
+const ticketLockKeyPrefix = "ticket-lock:"
+
+// TicketLockService keeps advisory edit locks on tickets, so an agent opening
+// a ticket someone else is editing can be warned. Locks live in the cache and
+// lapse when their holder stops sending heartbeats.
+type TicketLockService struct {
+	cache      cache.Cache
+	ticketRepo repository.TicketRepository
+	ttl        time.Duration
+
+	// mu makes checking and taking a lock one step
+	mu sync.Mutex
+}
+
+// NewTicketLockService creates a new ticket lock service
+func NewTicketLockService(cache cache.Cache, ticketRepo repository.TicketRepository, config *config.Config) *TicketLockService {
+	ttl, err := time.ParseDuration(config.Tickets.LockTTL)
+	if err != nil || ttl <= 0 {
+		ttl = 2 * time.Minute // fallback
+	}
+
+	return &TicketLockService{
+		cache:      cache,
+		ticketRepo: ticketRepo,
+		ttl:        ttl,
+	}
+}
+
+// Acquire locks a ticket for the user, or renews their lock. It fails with
+// ErrConflict, returning the current lock, while another agent holds it.
+func (s *TicketLockService) Acquire(ctx context.Context, ticketID uuid.UUID, user *models.User) (*models.TicketLock, error) {
+	if err := s.checkTicket(ctx, ticketID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	lock := s.get(ticketID)
+	switch {
+	case lock == nil:
+		lock = &models.TicketLock{TicketID: ticketID, HolderID: user.ID, HolderName: user.FullName(), AcquiredAt: now}
+	case lock.HolderID != user.ID:
+		return lock, fmt.Errorf("%w: ticket is being edited by %s", ErrConflict, lock.HolderName)
+	}
+	lock.ExpiresAt = now.Add(s.ttl)
+	s.set(lock)
+	return lock, nil
+}
+
+// Heartbeat keeps the user's lock on a ticket for another TTL. It fails with
+// ErrConflict when they no longer hold it, so the client can warn the user.
+func (s *TicketLockService) Heartbeat(ctx context.Context, ticketID uuid.UUID, user *models.User) (*models.TicketLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock := s.get(ticketID)
+	if lock == nil || lock.HolderID != user.ID {
+		return lock, fmt.Errorf("%w: you no longer hold the lock on this ticket", ErrConflict)
+	}
+	lock.ExpiresAt = time.Now().UTC().Add(s.ttl)
+	s.set(lock)
+	return lock, nil
+}
+
+// Release unlocks a ticket. Only its holder or an administrator may release
+// a lock; releasing a ticket that is not locked has no effect.
+func (s *TicketLockService) Release(ctx context.Context, ticketID uuid.UUID, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock := s.get(ticketID)
+	if lock == nil {
+		return nil
+	}
+	if lock.HolderID != user.ID && user.Role != models.RoleAdministrator {
+		return fmt.Errorf("%w: the lock is held by %s", ErrForbidden, lock.HolderName)
+	}
+	s.cache.Delete(ticketLockKey(ticketID))
+	return nil
+}
+
+// Get returns the current lock on a ticket, or nil when it is not locked
+func (s *TicketLockService) Get(ctx context.Context, ticketID uuid.UUID) (*models.TicketLock, error) {
+	if err := s.checkTicket(ctx, ticketID); err != nil {
+		return nil, err
+	}
+	return s.get(ticketID), nil
+}
+
+// checkTicket fails with ErrNotFound when the ticket does not exist
+func (s *TicketLockService) checkTicket(ctx context.Context, ticketID uuid.UUID) error {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket == nil {
+		return fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	return nil
+}
+
+// get reads a ticket's lock from the cache
+func (s *TicketLockService) get(ticketID uuid.UUID) *models.TicketLock {
+	value, ok := s.cache.Get(ticketLockKey(ticketID))
+	if !ok {
+		return nil
+	}
+	var lock models.TicketLock
+	if err := json.Unmarshal([]byte(value), &lock); err != nil {
+		return nil
+	}
+	return &lock
+}
+
+// set stores a lock in the cache until it expires
+func (s *TicketLockService) set(lock *models.TicketLock) {
+	value, _ := json.Marshal(lock)
+	s.cache.Set(ticketLockKey(lock.TicketID), string(value), time.Until(lock.ExpiresAt))
+}
+
+// ticketLockKey is the cache key of a ticket's edit lock
+func ticketLockKey(ticketID uuid.UUID) string {
+	return ticketLockKeyPrefix + ticketID.String()
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxSearchMatches caps how many matches are shown per listed ticket
+	maxSearchMatches = 3
+	// snippetContext is how many characters of context a snippet keeps either side of a match
+	snippetContext = 40
+)
+
+// highlightMatches records on each listed ticket where it matched the filter's
+// search text, with a highlighted snippet of each match
+func (s *TicketService) highlightMatches(ctx context.Context, tickets []models.Ticket, filter *models.TicketFilter) error {
+	if filter == nil || filter.Search == "" || len(tickets) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]*models.Ticket, len(tickets))
+	ids := make([]uuid.UUID, len(tickets))
+	for i := range tickets {
+		index[tickets[i].ID] = &tickets[i]
+		ids[i] = tickets[i].ID
+	}
+	add := func(ticketID uuid.UUID, field models.TicketSearchField, sourceID *uuid.UUID, text string) {
+		ticket := index[ticketID]
+		if ticket == nil || len(ticket.Matches) >= maxSearchMatches {
+			return
+		}
+		if snippet, ok := searchSnippet(text, filter.Search); ok {
+			ticket.Matches = append(ticket.Matches, models.SearchMatch{Field: field, SourceID: sourceID, Snippet: snippet})
+		}
+	}
+
+	for _, field := range filter.SearchFields() {
+		switch field {
+		case models.SearchInTitle:
+			for _, ticket := range tickets {
+				add(ticket.ID, field, nil, ticket.Title)
+			}
+		case models.SearchInDescription:
+			for _, ticket := range tickets {
+				add(ticket.ID, field, nil, ticket.Description)
+			}
+		case models.SearchInComments:
+			comments, err := s.commentRepo.Search(ctx, ids, filter.Search, filter.SearchInternal)
+			if err != nil {
+				return fmt.Errorf("failed to search comments: %w", err)
+			}
+			for _, comment := range comments {
+				add(comment.TicketID, field, &comment.ID, comment.Content)
+			}
+		case models.SearchInAttachments:
+			attachments, err := s.attachmentRepo.Search(ctx, ids, filter.Search)
+			if err != nil {
+				return fmt.Errorf("failed to search attachments: %w", err)
+			}
+			for _, attachment := range attachments {
+				add(attachment.TicketID, field, &attachment.ID, attachment.Filename)
+			}
+		}
+	}
+	return nil
+}
+
+// searchSnippet returns the text around the first case-insensitive occurrence
+// of term, HTML-escaped with the occurrence wrapped in <mark>
+func searchSnippet(text, term string) (string, bool) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	needle := []rune(strings.ToLower(term))
+	if len(needle) == 0 || len(lower) != len(runes) {
+		return "", false
+	}
+
+	at := -1
+	for i := 0; i+len(needle) <= len(lower); i++ {
+		if string(lower[i:i+len(needle)]) == string(needle) {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		return "", false
+	}
+
+	start, end := max(0, at-snippetContext), min(len(runes), at+len(needle)+snippetContext)
+	clean := func(part []rune) string {
+		return html.EscapeString(strings.Join(strings.Fields(string(part)), " "))
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	before := clean(runes[start:at])
+	b.WriteString(before)
+	if before != "" && unicode.IsSpace(runes[at-1]) {
+		b.WriteString(" ")
+	}
+	b.WriteString("<mark>" + clean(runes[at:at+len(needle)]) + "</mark>")
+	after := clean(runes[at+len(needle) : end])
+	if after != "" && unicode.IsSpace(runes[at+len(needle)]) {
+		b.WriteString(" ")
+	}
+	b.WriteString(after)
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
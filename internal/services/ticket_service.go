@@ -1,21 +1,87 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/timezone"
 	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
+// CommentNotifier is notified when a ticket is logged for someone, changes hands or status, or a comment is added to it
+type CommentNotifier interface {
+	NotifyTicketCreated(ctx context.Context, ticket *models.Ticket, requester *models.User, responseTarget time.Duration) error
+	NotifyTicketReceived(ctx context.Context, ticket *models.Ticket, requester *models.User) error
+	NotifyCommentAdded(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User) error
+	NotifyMentioned(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User, recipients []*models.User) error
+	NotifyAssigned(ctx context.Context, ticket *models.Ticket, assignedBy, assignee *models.User) error
+	NotifyEscalated(ctx context.Context, ticket *models.Ticket, escalatedBy, escalatedTo *models.User) error
+	NotifyDeEscalated(ctx context.Context, ticket *models.Ticket, returnedBy, assignee *models.User, note string) error
+	NotifyReassignedOften(ctx context.Context, ticket *models.Ticket, agents int, managers []*models.User) error
+	NotifyStatusChanged(ctx context.Context, ticket *models.Ticket, changedBy *models.User, previous models.TicketStatus, recipients []*models.User) error
+}
+
+const (
+	// RecentTicketsKept is how many recently viewed tickets are remembered per user
+	RecentTicketsKept = 20
+	// DefaultRecentTickets is how many recently viewed tickets are listed by default
+	DefaultRecentTickets = 10
+)
+
+// mentionPattern matches @email mentions in comment content
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
 // TicketService handles ticket-related business logic
 type TicketService struct {
 	ticketRepo     repository.TicketRepository
 	categoryRepo   repository.CategoryRepository
 	commentRepo    repository.CommentRepository
 	attachmentRepo repository.AttachmentRepository
+	draftRepo      repository.DraftAttachmentRepository
 	userRepo       repository.UserRepository
+	teamRepo       repository.TeamRepository
+	delegation     *DelegationService
+	notifier       CommentNotifier
+	events         *TicketEventService
+	redaction      *RedactionService
+	// reassignmentAlertThreshold is how many agents a ticket may be assigned
+	// to before managers are alerted; 0 never alerts
+	reassignmentAlertThreshold int
+	// rootCauses are the root cause codes tickets can be resolved with
+	rootCauses []string
+	// maxOpenPerRequester is how many open tickets an end user may raise; 0 is unlimited
+	maxOpenPerRequester int
+	// responseTargets is how soon the SLA policy promises a response, by priority
+	responseTargets map[models.TicketPriority]time.Duration
+
+	// stats coalesces concurrent statistics requests for the same ticket scope
+	stats singleflight.Group
+}
+
+// TicketServiceDeps are the collaborators a TicketService only needs for some
+// features, so callers set just the ones they use. Comments need Redaction and
+// attachments their repositories. Without Events no live ticket events are
+// published, without a Notifier no one is told of ticket changes, and without
+// Delegation out-of-office agents are assigned work as usual.
+type TicketServiceDeps struct {
+	AttachmentRepo repository.AttachmentRepository
+	DraftRepo      repository.DraftAttachmentRepository
+	Delegation     *DelegationService
+	Notifier       CommentNotifier
+	Events         *TicketEventService
+	Redaction      *RedactionService
 }
 
 // NewTicketService creates a new ticket service
@@ -23,16 +89,62 @@ func NewTicketService(
 	ticketRepo repository.TicketRepository,
 	categoryRepo repository.CategoryRepository,
 	commentRepo repository.CommentRepository,
-	attachmentRepo repository.AttachmentRepository,
 	userRepo repository.UserRepository,
+	teamRepo repository.TeamRepository,
+	cfg *config.Config,
+	deps TicketServiceDeps,
 ) *TicketService {
 	return &TicketService{
-		ticketRepo:     ticketRepo,
-		categoryRepo:   categoryRepo,
-		commentRepo:    commentRepo,
-		attachmentRepo: attachmentRepo,
-		userRepo:       userRepo,
+		ticketRepo:                 ticketRepo,
+		categoryRepo:               categoryRepo,
+		commentRepo:                commentRepo,
+		attachmentRepo:             deps.AttachmentRepo,
+		draftRepo:                  deps.DraftRepo,
+		userRepo:                   userRepo,
+		teamRepo:                   teamRepo,
+		delegation:                 deps.Delegation,
+		notifier:                   deps.Notifier,
+		events:                     deps.Events,
+		redaction:                  deps.Redaction,
+		reassignmentAlertThreshold: cfg.Tickets.ReassignmentAlertThreshold,
+		rootCauses:                 normalizeRootCauses(cfg.Tickets.RootCauses),
+		maxOpenPerRequester:        cfg.Tickets.MaxOpenPerRequester,
+		responseTargets:            parseResponseTargets(cfg.Tickets.ResponseTargets),
+	}
+}
+
+// parseResponseTargets reads PRIORITY=duration pairs; invalid pairs are
+// logged and skipped so that they only cost the promise in emails
+func parseResponseTargets(pairs []string) map[models.TicketPriority]time.Duration {
+	targets := make(map[models.TicketPriority]time.Duration, len(pairs))
+	for _, pair := range pairs {
+		priority, value, _ := strings.Cut(pair, "=")
+		priority = strings.ToUpper(strings.TrimSpace(priority))
+		target, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || target <= 0 || !slices.Contains(models.TicketPriorities, models.TicketPriority(priority)) {
+			log.Printf("Ignoring invalid TICKET_RESPONSE_TARGETS entry %q", pair)
+			continue
+		}
+		targets[models.TicketPriority(priority)] = target
 	}
+	return targets
+}
+
+// normalizeRootCauses upper-cases root cause codes, dropping blanks and repeats
+func normalizeRootCauses(codes []string) []string {
+	var normalized []string
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" && !slices.Contains(normalized, code) {
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized
+}
+
+// RootCauses returns the root cause codes tickets can be resolved with
+func (s *TicketService) RootCauses() []string {
+	return slices.Clone(s.rootCauses)
 }
 
 // CreateTicket creates a new ticket
@@ -50,6 +162,33 @@ func (s *TicketService) CreateTicket(ctx context.Context, req *models.CreateTick
 			return nil, fmt.Errorf("category is not active")
 		}
 	}
+	if err := s.validateTeam(ctx, req.TeamID); err != nil {
+		return nil, err
+	}
+
+	requester, err := s.onBehalfOfRequester(ctx, req.RequesterID, createdByID)
+	if err != nil {
+		return nil, err
+	}
+	requesterID := createdByID
+	if requester != nil {
+		// Only agents log tickets for others, and agents are not held to the quota
+		requesterID = requester.ID
+	} else if err := s.checkOpenTicketQuota(ctx, createdByID); err != nil {
+		return nil, err
+	}
+	dueDate := req.DueDate.In(timezone.Or(ctx, time.UTC))
+	if err := validateDueDate(dueDate); err != nil {
+		return nil, err
+	}
+	drafts, err := s.draftAttachments(ctx, req.AttachmentIDs, false, createdByID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := s.draftAttachments(ctx, req.InlineImageIDs, true, createdByID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create ticket
 	ticket := &models.Ticket{
@@ -57,24 +196,164 @@ func (s *TicketService) CreateTicket(ctx context.Context, req *models.CreateTick
 		Description: req.Description,
 		Priority:    req.Priority,
 		CategoryID:  req.CategoryID,
-		CreatedByID: createdByID,
+		TeamID:      req.TeamID,
+		CreatedByID: requesterID,
+		OpenedBy:    &createdByID,
 		Status:      models.StatusOpen,
-		DueDate:     req.DueDate,
+		DueDate:     dueDate,
+	}
+	// Tickets logged while practicing stay in the training sandbox
+	if sandboxID, ok := repository.SandboxFromContext(ctx); ok {
+		ticket.SandboxID = &sandboxID
 	}
 
-	if err := s.ticketRepo.Create(ctx, ticket); err != nil {
+	if err := s.ticketRepo.CreateWithAttachments(ctx, ticket, append(drafts, images...)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: an attachment was added to another ticket meanwhile", ErrConflict)
+		}
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 
+	s.events.Publish(ctx, models.NewTicketEvent(models.TicketEventCreated, ticket, createdByID))
+
+	// The requester gets a summary to keep, whether or not they logged the ticket themselves
+	if s.notifies(ticket) {
+		if err := s.notifyCreated(ctx, ticket, requester); err != nil {
+			log.Printf("failed to send ticket notification for ticket %s: %v", ticket.ID, err)
+		}
+	}
+
 	// Get the created ticket with relationships
 	return s.ticketRepo.GetByID(ctx, ticket.ID)
 }
 
+// notifies reports whether changes to a ticket are emailed; the demo tickets
+// of training sandboxes never are
+func (s *TicketService) notifies(ticket *models.Ticket) bool {
+	return s.notifier != nil && ticket.SandboxID == nil
+}
+
+// notifyCreated sends the requester of a new ticket its summary; requester is
+// nil when the creator raised the ticket for themselves
+func (s *TicketService) notifyCreated(ctx context.Context, ticket *models.Ticket, requester *models.User) error {
+	if requester == nil {
+		creator, err := s.userRepo.GetByID(ticket.CreatedByID.String())
+		if err != nil {
+			return fmt.Errorf("failed to get requester: %w", err)
+		}
+		if creator == nil {
+			return fmt.Errorf("requester %s not found", ticket.CreatedByID)
+		}
+		requester = creator
+	}
+	return s.notifier.NotifyTicketCreated(ctx, ticket, requester, s.responseTargets[ticket.Priority])
+}
+
 // GetTicket retrieves a ticket by ID
 func (s *TicketService) GetTicket(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, error) {
 	return s.ticketRepo.GetByID(ctx, ticketID)
 }
 
+// TicketReceived announces a ticket the intake created from the guest form,
+// an email or a chat: it is published live and to the notifiers, and its
+// requester is acknowledged. Failures are logged, since the ticket is stored.
+func (s *TicketService) TicketReceived(ctx context.Context, ticket *models.Ticket, requester *models.User) {
+	s.events.Publish(ctx, models.NewTicketEvent(models.TicketEventCreated, ticket, requester.ID))
+	if s.notifies(ticket) {
+		if err := s.notifier.NotifyTicketReceived(ctx, ticket, requester); err != nil {
+			log.Printf("failed to send acknowledgement for ticket %s: %v", ticket.ID, err)
+		}
+	}
+}
+
+// ReplyReceived announces a comment added by email reply to the notifiers,
+// as AddComment does for comments added in the app
+func (s *TicketService) ReplyReceived(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User) {
+	if s.notifies(ticket) {
+		if err := s.notifier.NotifyCommentAdded(ctx, ticket, comment, author); err != nil {
+			log.Printf("failed to send reply notification for ticket %s: %v", ticket.ID, err)
+		}
+	}
+}
+
+// ViewTicket retrieves a ticket as the given user may see it. Like comment
+// listings, internal notes and the files attached to them are only included
+// for support staff.
+func (s *TicketService) ViewTicket(ctx context.Context, ticketID uuid.UUID, user *models.User) (*models.Ticket, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil || user.IsAgent() {
+		return ticket, err
+	}
+
+	internal := make(map[uuid.UUID]bool)
+	ticket.Comments = slices.DeleteFunc(ticket.Comments, func(comment models.Comment) bool {
+		internal[comment.ID] = comment.IsInternal
+		return comment.IsInternal
+	})
+	ticket.Attachments = slices.DeleteFunc(ticket.Attachments, func(attachment models.Attachment) bool {
+		return attachment.CommentID != nil && internal[*attachment.CommentID]
+	})
+	return ticket, nil
+}
+
+// RecordView remembers that a user opened a ticket. Failures are logged rather
+// than returned, since they should not stop the ticket being shown.
+func (s *TicketService) RecordView(ctx context.Context, userID, ticketID uuid.UUID) {
+	view := &models.TicketView{UserID: userID, TicketID: ticketID, ViewedAt: time.Now().UTC()}
+	if err := s.ticketRepo.RecordView(ctx, view, RecentTicketsKept); err != nil {
+		log.Printf("failed to record view of ticket %s by user %s: %v", ticketID, userID, err)
+	}
+}
+
+// PinTicket stars a ticket the user can see, so they can list it with the favorites filter
+func (s *TicketService) PinTicket(ctx context.Context, ticketID uuid.UUID, user *models.User) error {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket.CreatedByID != user.ID && !user.HasPermission(models.PermTicketRead) {
+		return fmt.Errorf("%w: only the requester or staff can pin this ticket", ErrForbidden)
+	}
+
+	if err := s.ticketRepo.Pin(ctx, &models.TicketPin{UserID: user.ID, TicketID: ticket.ID}); err != nil {
+		return fmt.Errorf("failed to pin ticket: %w", err)
+	}
+	return nil
+}
+
+// UnpinTicket removes the user's star from a ticket; unpinning a ticket that is
+// not pinned has no effect
+func (s *TicketService) UnpinTicket(ctx context.Context, ticketID, userID uuid.UUID) error {
+	if err := s.ticketRepo.Unpin(ctx, userID, ticketID); err != nil {
+		return fmt.Errorf("failed to unpin ticket: %w", err)
+	}
+	return nil
+}
+
+// GetRecentTickets retrieves the tickets a user viewed most recently that they
+// can still see, newest first
+func (s *TicketService) GetRecentTickets(ctx context.Context, user *models.User, limit int) ([]models.Ticket, error) {
+	if limit < 1 || limit > RecentTicketsKept {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, RecentTicketsKept)
+	}
+
+	ctx, err := s.ScopeTickets(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := s.ticketRepo.GetRecentlyViewed(ctx, user.ID, limit, !user.HasPermission(models.PermTicketRead))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent tickets: %w", err)
+	}
+	if tickets == nil {
+		tickets = []models.Ticket{}
+	}
+	return tickets, nil
+}
+
 // UpdateTicket updates an existing ticket
 func (s *TicketService) UpdateTicket(ctx context.Context, ticketID uuid.UUID, req *models.UpdateTicketRequest, updatedByID uuid.UUID) (*models.Ticket, error) {
 	// Get existing ticket
@@ -100,6 +379,12 @@ func (s *TicketService) UpdateTicket(ctx context.Context, ticketID uuid.UUID, re
 		}
 		ticket.CategoryID = req.CategoryID
 	}
+	if req.TeamID != nil {
+		if err := s.validateTeam(ctx, req.TeamID); err != nil {
+			return nil, err
+		}
+		ticket.TeamID = req.TeamID
+	}
 
 	// Update fields
 	if req.Title != nil {
@@ -112,19 +397,36 @@ func (s *TicketService) UpdateTicket(ctx context.Context, ticketID uuid.UUID, re
 		ticket.Priority = *req.Priority
 	}
 	if req.DueDate != nil {
-		ticket.DueDate = req.DueDate
+		ticket.DueDate = req.DueDate.In(timezone.Or(ctx, time.UTC))
+		if err := validateDueDate(ticket.DueDate); err != nil {
+			return nil, err
+		}
+	}
+	images, err := s.draftAttachments(ctx, req.InlineImageIDs, true, updatedByID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Update ticket
 	if err := s.ticketRepo.Update(ctx, ticket); err != nil {
 		return nil, fmt.Errorf("failed to update ticket: %w", err)
 	}
+	s.events.Publish(ctx, models.NewTicketEvent(models.TicketEventUpdated, ticket, updatedByID))
+	if len(images) > 0 {
+		if err := s.draftRepo.AttachToTicket(ctx, ticket.ID, images); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: an inline image was added to another ticket meanwhile", ErrConflict)
+			}
+			return nil, fmt.Errorf("failed to attach inline images: %w", err)
+		}
+	}
 
 	// Get the updated ticket with relationships
 	return s.ticketRepo.GetByID(ctx, ticket.ID)
 }
 
-// DeleteTicket deletes a ticket
+// DeleteTicket moves a ticket to the trash, from which administrators can
+// restore it until it is purged
 func (s *TicketService) DeleteTicket(ctx context.Context, ticketID uuid.UUID, userID uuid.UUID) error {
 	// Check if ticket exists
 	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
@@ -154,7 +456,7 @@ func (s *TicketService) DeleteTicket(ctx context.Context, ticketID uuid.UUID, us
 		return fmt.Errorf("can only delete open tickets")
 	}
 
-	return s.ticketRepo.Delete(ctx, ticketID)
+	return s.ticketRepo.Trash(ctx, ticketID, userID)
 }
 
 // ListTickets retrieves tickets with filtering and pagination
@@ -167,15 +469,36 @@ func (s *TicketService) ListTickets(ctx context.Context, query *models.TicketQue
 		query.PageSize = 20
 	}
 
-	return s.ticketRepo.List(ctx, query)
+	list, err := s.ticketRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.highlightMatches(ctx, list.Tickets, query.Filter); err != nil {
+		return nil, err
+	}
+	return list, nil
 }
 
-// GetTicketStats retrieves ticket statistics
+// GetTicketStats retrieves ticket statistics. Dashboards poll this at the same
+// time, so concurrent calls for the same ticket scope share one set of queries.
 func (s *TicketService) GetTicketStats(ctx context.Context) (*models.TicketStats, error) {
-	return s.ticketRepo.GetStats(ctx)
+	// The shared queries run to completion even if the caller that started them gives up
+	result := s.stats.DoChan(scopeKey(ctx), func() (any, error) {
+		return s.ticketRepo.GetStats(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		stats := *r.Val.(*models.TicketStats)
+		return &stats, nil
+	}
 }
 
-// AssignTicket assigns a ticket to an agent
+// AssignTicket assigns a ticket to an agent, or to their delegate while they are out of office
 func (s *TicketService) AssignTicket(ctx context.Context, ticketID, agentID uuid.UUID, assignedByID uuid.UUID) error {
 	// Check if ticket exists
 	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
@@ -198,14 +521,302 @@ func (s *TicketService) AssignTicket(ctx context.Context, ticketID, agentID uuid
 		return fmt.Errorf("user is not a support agent")
 	}
 
+	assignment := &models.TicketAssignment{
+		TicketID:     ticketID,
+		AgentID:      &agentID,
+		AssignedByID: assignedByID,
+	}
+
+	// New work goes to the delegate of an agent who is out of office
+	if s.delegation != nil {
+		assigneeID, err := s.delegation.ResolveAssignee(ctx, agentID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve delegate: %w", err)
+		}
+		assignment.AgentID = &assigneeID
+		if assigneeID != agentID {
+			assignment.Note = fmt.Sprintf("Delegated by %s, who is out of office", agent.FullName())
+		}
+	}
+
 	// Assign ticket
-	if err := s.ticketRepo.AssignToAgent(ctx, ticketID, agentID); err != nil {
+	if err := s.ticketRepo.AssignToAgent(ctx, assignment); err != nil {
 		return fmt.Errorf("failed to assign ticket: %w", err)
 	}
+	s.publishAssigned(ctx, ticket, assignment)
 
+	if err := s.notifyAssigned(ctx, ticket, assignment); err != nil {
+		log.Printf("failed to send assignment notification for ticket %s: %v", ticketID, err)
+	}
+	if err := s.alertIfReassignedOften(ctx, ticket); err != nil {
+		log.Printf("failed to check reassignments of ticket %s: %v", ticketID, err)
+	}
 	return nil
 }
 
+// publishAssigned publishes the event of an assignment, including returning
+// a ticket to the pool
+func (s *TicketService) publishAssigned(ctx context.Context, ticket *models.Ticket, assignment *models.TicketAssignment) {
+	event := models.NewTicketEvent(models.TicketEventAssigned, ticket, assignment.AssignedByID)
+	event.AssignedAgentID, event.PreviousAgentID = assignment.AgentID, assignment.PreviousAgentID
+	s.events.Publish(ctx, event)
+}
+
+// notifyAssigned emails the agent a ticket was assigned to, unless they
+// assigned it to themselves
+func (s *TicketService) notifyAssigned(ctx context.Context, ticket *models.Ticket, assignment *models.TicketAssignment) error {
+	if !s.notifies(ticket) || *assignment.AgentID == assignment.AssignedByID {
+		return nil
+	}
+	assignee, err := s.userRepo.GetByID(assignment.AgentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get assignee: %w", err)
+	}
+	assignedBy, err := s.userRepo.GetByID(assignment.AssignedByID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get assigning user: %w", err)
+	}
+	return s.notifier.NotifyAssigned(ctx, ticket, assignedBy, assignee)
+}
+
+// TakeTicket assigns an unassigned ticket to the calling agent. It fails with
+// ErrConflict when another agent has the ticket, including one who took it
+// first when several agents try at once.
+func (s *TicketService) TakeTicket(ctx context.Context, ticketID uuid.UUID, agent *models.User) error {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket == nil {
+		return fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	if ticket.AssignedAgentID != nil {
+		if *ticket.AssignedAgentID == agent.ID {
+			return nil
+		}
+		return fmt.Errorf("%w: ticket is already assigned to another agent", ErrConflict)
+	}
+
+	assignment := &models.TicketAssignment{
+		TicketID:     ticketID,
+		AgentID:      &agent.ID,
+		AssignedByID: agent.ID,
+	}
+	taken, err := s.ticketRepo.TakeIfUnassigned(ctx, assignment)
+	if err != nil {
+		return fmt.Errorf("failed to take ticket: %w", err)
+	}
+	if !taken {
+		return fmt.Errorf("%w: ticket was taken by another agent", ErrConflict)
+	}
+	s.publishAssigned(ctx, ticket, assignment)
+
+	if err := s.alertIfReassignedOften(ctx, ticket); err != nil {
+		log.Printf("failed to check reassignments of ticket %s: %v", ticketID, err)
+	}
+	return nil
+}
+
+// UnassignTicket returns a ticket to the pool with a reason, recorded in its
+// assignment history
+func (s *TicketService) UnassignTicket(ctx context.Context, ticketID uuid.UUID, req *models.UnassignTicketRequest, unassignedByID uuid.UUID) error {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket == nil {
+		return fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	if ticket.AssignedAgentID == nil {
+		return fmt.Errorf("%w: ticket is not assigned", ErrConflict)
+	}
+
+	assignment := &models.TicketAssignment{
+		TicketID:     ticketID,
+		AssignedByID: unassignedByID,
+		Note:         reason,
+	}
+	if err := s.ticketRepo.AssignToAgent(ctx, assignment); err != nil {
+		return fmt.Errorf("failed to unassign ticket: %w", err)
+	}
+	s.publishAssigned(ctx, ticket, assignment)
+	return nil
+}
+
+// alertIfReassignedOften alerts managers when the latest assignment took a
+// ticket to more agents than the reassignment alert threshold. Each ticket
+// alerts once, when it crosses the threshold.
+func (s *TicketService) alertIfReassignedOften(ctx context.Context, ticket *models.Ticket) error {
+	if s.reassignmentAlertThreshold <= 0 || !s.notifies(ticket) {
+		return nil
+	}
+
+	assignments, err := s.ticketRepo.ListAssignments(ctx, ticket.ID)
+	if err != nil || len(assignments) == 0 {
+		return err
+	}
+	// Tickets may have been assigned before their first recorded assignment
+	agents := make(map[uuid.UUID]bool)
+	if first := assignments[0].PreviousAgentID; first != nil {
+		agents[*first] = true
+	}
+	for _, assignment := range assignments[:len(assignments)-1] {
+		if assignment.AgentID != nil {
+			agents[*assignment.AgentID] = true
+		}
+	}
+	before := len(agents)
+	agents[*assignments[len(assignments)-1].AgentID] = true
+	if before > s.reassignmentAlertThreshold || len(agents) <= s.reassignmentAlertThreshold {
+		return nil
+	}
+
+	staff, err := s.userRepo.ListAgents()
+	if err != nil {
+		return fmt.Errorf("failed to list managers: %w", err)
+	}
+	var managers []*models.User
+	for _, user := range staff {
+		if user.Role == models.RoleManager {
+			managers = append(managers, user)
+		}
+	}
+	if len(managers) == 0 {
+		return nil
+	}
+	return s.notifier.NotifyReassignedOften(ctx, ticket, len(agents), managers)
+}
+
+// GetActivity returns the feed of assignment and escalation changes to a
+// ticket, oldest first
+func (s *TicketService) GetActivity(ctx context.Context, ticketID uuid.UUID) ([]models.TicketActivity, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket == nil {
+		return nil, fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	assignments, err := s.ticketRepo.ListAssignments(ctx, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assignments: %w", err)
+	}
+
+	activity := make([]models.TicketActivity, 0, len(assignments)+len(ticket.EscalationEvents))
+	for _, assignment := range assignments {
+		activityType := models.ActivityAssigned
+		if assignment.AgentID == nil {
+			activityType = models.ActivityUnassigned
+		}
+		activity = append(activity, models.TicketActivity{
+			Type:    activityType,
+			At:      assignment.CreatedAt,
+			ActorID: assignment.AssignedByID,
+			Actor:   assignment.AssignedBy,
+			FromID:  assignment.PreviousAgentID,
+			ToID:    assignment.AgentID,
+			Note:    assignment.Note,
+		})
+	}
+	for _, event := range ticket.EscalationEvents {
+		activityType := models.ActivityEscalated
+		if event.Action == models.EscalationDeEscalated {
+			activityType = models.ActivityDeEscalated
+		}
+		activity = append(activity, models.TicketActivity{
+			Type:    activityType,
+			At:      event.CreatedAt,
+			ActorID: event.ActorID,
+			Actor:   event.Actor,
+			ToID:    event.TargetID,
+			Note:    event.Note,
+		})
+	}
+	slices.SortStableFunc(activity, func(a, b models.TicketActivity) int {
+		return a.At.Compare(b.At)
+	})
+	return activity, nil
+}
+
+// ChangeRequester transfers a ticket to another requester. The current requester
+// and staff who may update tickets can do this; the original creator is kept in opened_by.
+func (s *TicketService) ChangeRequester(ctx context.Context, ticketID uuid.UUID, req *models.ChangeRequesterRequest, actor *models.User) (*models.Ticket, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if ticket.CreatedByID != actor.ID && !actor.HasPermission(models.PermTicketUpdate) {
+		return nil, fmt.Errorf("%w: only the requester or staff can change the requester of this ticket", ErrForbidden)
+	}
+	if req.RequesterID == ticket.CreatedByID {
+		return ticket, nil
+	}
+
+	requester, err := s.userRepo.GetByID(req.RequesterID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester == nil {
+		return nil, fmt.Errorf("requester %w", ErrNotFound)
+	}
+	if !requester.IsActive || !requester.HasPermission(models.PermTicketCreate) {
+		return nil, fmt.Errorf("%w: the new requester must be an active user who can raise tickets", ErrInvalidInput)
+	}
+
+	if err := s.ticketRepo.ChangeRequester(ctx, ticketID, requester.ID); err != nil {
+		return nil, fmt.Errorf("failed to change requester: %w", err)
+	}
+
+	return s.ticketRepo.GetByID(ctx, ticketID)
+}
+
+// ListVersions retrieves every version of a ticket, oldest first, so an
+// earlier one can be picked to restore
+func (s *TicketService) ListVersions(ctx context.Context, ticketID uuid.UUID) ([]*models.Ticket, error) {
+	versions, err := s.ticketRepo.GetHistory(ctx, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	return versions, nil
+}
+
+// RestoreVersion makes an earlier version of a ticket current again. The
+// ticket's fields are copied from that version into a new version, so the
+// history keeps the versions it replaces.
+func (s *TicketService) RestoreVersion(ctx context.Context, ticketID, versionID, restoredByID uuid.UUID) (*models.Ticket, error) {
+	restored, err := s.ticketRepo.RestoreVersion(ctx, ticketID, versionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket version %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to restore ticket version: %w", err)
+	}
+	s.events.Publish(ctx, models.NewTicketEvent(models.TicketEventUpdated, restored, restoredByID))
+
+	return s.ticketRepo.GetByID(ctx, restored.ID)
+}
+
 // UpdateTicketStatus updates the status of a ticket
 func (s *TicketService) UpdateTicketStatus(ctx context.Context, ticketID uuid.UUID, req *models.UpdateTicketStatusRequest, updatedByID uuid.UUID) error {
 	// Check if ticket exists
@@ -222,16 +833,80 @@ func (s *TicketService) UpdateTicketStatus(ctx context.Context, ticketID uuid.UU
 		return fmt.Errorf("invalid status transition from %s to %s", ticket.Status, req.Status)
 	}
 
+	// Resolving records the resolution details, keeping any given before
+	var resolution *models.TicketResolution
+	if req.Status == models.StatusResolved || req.Status == models.StatusClosed {
+		rootCauseCode := strings.ToUpper(strings.TrimSpace(req.RootCauseCode))
+		if rootCauseCode != "" && !slices.Contains(s.rootCauses, rootCauseCode) {
+			return fmt.Errorf("%w: unknown root cause code %q; use one of %s", ErrInvalidInput, req.RootCauseCode, strings.Join(s.rootCauses, ", "))
+		}
+		resolution = &models.TicketResolution{
+			ResolutionCode:     cmp.Or(strings.TrimSpace(req.ResolutionCode), ticket.ResolutionCode),
+			RootCause:          cmp.Or(strings.TrimSpace(req.RootCause), ticket.RootCause),
+			RootCauseCode:      cmp.Or(rootCauseCode, ticket.RootCauseCode),
+			KnowledgeArticleID: cmp.Or(req.KnowledgeArticleID, ticket.KnowledgeArticleID),
+		}
+		if err := checkResolution(ticket, resolution); err != nil {
+			return err
+		}
+	}
+
 	// Update status
-	if err := s.ticketRepo.UpdateStatus(ctx, ticketID, req.Status); err != nil {
+	if err := s.ticketRepo.UpdateStatus(ctx, ticketID, req.Status, resolution); err != nil {
 		return fmt.Errorf("failed to update ticket status: %w", err)
 	}
+	previous := ticket.Status
+	ticket.Status = req.Status
+	s.events.Publish(ctx, models.NewTicketEvent(models.TicketEventStatusChanged, ticket, updatedByID))
 
+	if err := s.notifyStatusChanged(ctx, ticket, previous, updatedByID); err != nil {
+		log.Printf("failed to send status notification for ticket %s: %v", ticketID, err)
+	}
 	return nil
 }
 
-// EscalateTicket escalates a ticket to another user
+// notifyStatusChanged lets the requester and the assignee of a ticket know
+// its status changed, unless they changed it themselves
+func (s *TicketService) notifyStatusChanged(ctx context.Context, ticket *models.Ticket, previous models.TicketStatus, changedByID uuid.UUID) error {
+	if !s.notifies(ticket) || previous == ticket.Status {
+		return nil
+	}
+	changedBy, err := s.userRepo.GetByID(changedByID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get user changing status: %w", err)
+	}
+	if changedBy == nil {
+		return fmt.Errorf("user %s changing status not found", changedByID)
+	}
+
+	recipientIDs := []uuid.UUID{ticket.CreatedByID}
+	if ticket.AssignedAgentID != nil && *ticket.AssignedAgentID != ticket.CreatedByID {
+		recipientIDs = append(recipientIDs, *ticket.AssignedAgentID)
+	}
+	var recipients []*models.User
+	for _, id := range recipientIDs {
+		if id == changedByID {
+			continue
+		}
+		recipient, err := s.userRepo.GetByID(id.String())
+		if err != nil {
+			return fmt.Errorf("failed to get recipient: %w", err)
+		}
+		if recipient != nil && recipient.IsActive {
+			recipients = append(recipients, recipient)
+		}
+	}
+	// Notifiers hear of the change even with no one to tell, for webhooks
+	return s.notifier.NotifyStatusChanged(ctx, ticket, changedBy, previous, recipients)
+}
+
+// EscalateTicket escalates a ticket to another user and lets them know why
 func (s *TicketService) EscalateTicket(ctx context.Context, ticketID uuid.UUID, req *models.EscalateTicketRequest, escalatedByID uuid.UUID) error {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+
 	// Check if ticket exists
 	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
 	if err != nil {
@@ -243,7 +918,7 @@ func (s *TicketService) EscalateTicket(ctx context.Context, ticketID uuid.UUID,
 
 	// Check if ticket is already escalated
 	if ticket.IsEscalated() {
-		return fmt.Errorf("ticket is already escalated")
+		return fmt.Errorf("%w: ticket is already escalated", ErrConflict)
 	}
 
 	// Check if target user exists and is a manager or admin
@@ -259,10 +934,81 @@ func (s *TicketService) EscalateTicket(ctx context.Context, ticketID uuid.UUID,
 	}
 
 	// Escalate ticket
-	if err := s.ticketRepo.Escalate(ctx, ticketID, req.EscalatedTo); err != nil {
+	event := &models.TicketEscalationEvent{
+		TicketID: ticketID,
+		Action:   models.EscalationEscalated,
+		ActorID:  escalatedByID,
+		TargetID: &req.EscalatedTo,
+		Note:     reason,
+	}
+	if err := s.ticketRepo.Escalate(ctx, event); err != nil {
 		return fmt.Errorf("failed to escalate ticket: %w", err)
 	}
 
+	if s.notifies(ticket) {
+		escalatedBy, err := s.userRepo.GetByID(escalatedByID.String())
+		if err != nil || escalatedBy == nil {
+			log.Printf("failed to get user %s escalating ticket %s: %v", escalatedByID, ticketID, err)
+			return nil
+		}
+		now := time.Now()
+		ticket.EscalatedAt, ticket.EscalatedTo, ticket.EscalationReason = &now, &req.EscalatedTo, reason
+		if err := s.notifier.NotifyEscalated(ctx, ticket, escalatedBy, targetUser); err != nil {
+			log.Printf("failed to send escalation notification for ticket %s: %v", ticketID, err)
+		}
+	}
+
+	return nil
+}
+
+// DeEscalateTicket hands an escalated ticket back to its assignee with a note,
+// clearing the escalation so it may be escalated again later. Only the user it
+// was escalated to or an administrator may hand it back.
+func (s *TicketService) DeEscalateTicket(ctx context.Context, ticketID uuid.UUID, req *models.DeEscalateTicketRequest, actor *models.User) error {
+	note := strings.TrimSpace(req.Note)
+	if note == "" {
+		return fmt.Errorf("%w: note is required", ErrInvalidInput)
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket == nil {
+		return fmt.Errorf("ticket %w", ErrNotFound)
+	}
+	if !ticket.IsEscalated() {
+		return fmt.Errorf("%w: ticket is not escalated", ErrConflict)
+	}
+	if actor.Role != models.RoleAdministrator && (ticket.EscalatedTo == nil || *ticket.EscalatedTo != actor.ID) {
+		return fmt.Errorf("%w: only the user the ticket was escalated to or an administrator can de-escalate it", ErrForbidden)
+	}
+
+	event := &models.TicketEscalationEvent{
+		TicketID: ticketID,
+		Action:   models.EscalationDeEscalated,
+		ActorID:  actor.ID,
+		TargetID: ticket.AssignedAgentID,
+		Note:     note,
+	}
+	if err := s.ticketRepo.DeEscalate(ctx, event); err != nil {
+		return fmt.Errorf("failed to de-escalate ticket: %w", err)
+	}
+
+	if s.notifies(ticket) && ticket.AssignedAgentID != nil && *ticket.AssignedAgentID != actor.ID {
+		assignee, err := s.userRepo.GetByID(ticket.AssignedAgentID.String())
+		if err != nil || assignee == nil {
+			log.Printf("failed to get assignee of de-escalated ticket %s: %v", ticketID, err)
+			return nil
+		}
+		ticket.EscalatedAt, ticket.EscalatedTo, ticket.EscalationReason = nil, nil, ""
+		if err := s.notifier.NotifyDeEscalated(ctx, ticket, actor, assignee, note); err != nil {
+			log.Printf("failed to send de-escalation notification for ticket %s: %v", ticketID, err)
+		}
+	}
 	return nil
 }
 
@@ -276,6 +1022,257 @@ func (s *TicketService) GetTicketsByAgent(ctx context.Context, agentID uuid.UUID
 	return s.ticketRepo.GetByAgent(ctx, agentID, query)
 }
 
+// AddComment adds a comment to a ticket and notifies the other participants
+func (s *TicketService) AddComment(ctx context.Context, ticketID uuid.UUID, req *models.CreateCommentRequest, author *models.User) (*models.Comment, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	// Only the requester or support staff may comment on a ticket; read-only roles never can
+	if !author.HasPermission(models.PermCommentCreate) {
+		return nil, fmt.Errorf("%w: your role cannot comment on tickets", ErrForbidden)
+	}
+	if ticket.CreatedByID != author.ID && !author.IsAgent() {
+		return nil, fmt.Errorf("%w: only the requester or an agent can comment on this ticket", ErrForbidden)
+	}
+	if req.IsInternal && !author.IsAgent() {
+		return nil, fmt.Errorf("%w: only agents can add internal notes", ErrForbidden)
+	}
+
+	// Sensitive data is masked before it is ever written
+	content, redactions := s.redaction.Redact(req.Content)
+
+	comment := &models.Comment{
+		TicketID:   ticket.ID,
+		UserID:     author.ID,
+		Content:    content,
+		Format:     req.Format,
+		IsInternal: req.IsInternal,
+	}
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+	comment.User = author
+	s.redaction.RecordRedactions(ctx, models.AuditEntityComment, comment.ID, author.ID, redactions)
+
+	if s.notifies(ticket) {
+		if err := s.notifier.NotifyCommentAdded(ctx, ticket, comment, author); err != nil {
+			log.Printf("failed to send comment notification for ticket %s: %v", ticket.ID, err)
+		}
+		if mentioned := s.mentionedUsers(ctx, comment.Content, author); len(mentioned) > 0 {
+			if err := s.notifier.NotifyMentioned(ctx, ticket, comment, author, mentioned); err != nil {
+				log.Printf("failed to send mention notification for ticket %s: %v", ticket.ID, err)
+			}
+		}
+	}
+
+	return comment, nil
+}
+
+// PreviewComment renders a draft comment as it would be shown once added,
+// with the sensitive data that would be masked already masked
+func (s *TicketService) PreviewComment(req *models.CommentPreviewRequest) *models.CommentPreviewResponse {
+	content, _ := s.redaction.Redact(req.Content)
+	return &models.CommentPreviewResponse{HTML: models.RenderComment(content, req.Format)}
+}
+
+// mentionedUsers resolves the staff mentioned in a comment, adding the delegate of
+// anyone who is out of office. Unknown addresses and non-staff are ignored.
+func (s *TicketService) mentionedUsers(ctx context.Context, content string, author *models.User) []*models.User {
+	seen := map[uuid.UUID]bool{author.ID: true}
+	var users []*models.User
+	add := func(user *models.User) {
+		if user == nil || seen[user.ID] || !user.IsAgent() || !user.IsActive {
+			return
+		}
+		seen[user.ID] = true
+		users = append(users, user)
+	}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimRight(match[1], ".")))
+		if err != nil {
+			continue
+		}
+		add(user)
+
+		if s.delegation == nil {
+			continue
+		}
+		delegateID, err := s.delegation.ActiveDelegate(ctx, user.ID)
+		if err != nil || delegateID == nil {
+			continue
+		}
+		delegate, err := s.userRepo.GetByID(delegateID.String())
+		if err == nil {
+			add(delegate)
+		}
+	}
+	return users
+}
+
+// GetComments retrieves the comments of a ticket visible to the given user
+func (s *TicketService) GetComments(ctx context.Context, ticketID uuid.UUID, user *models.User) ([]models.Comment, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if ticket.CreatedByID != user.ID && !user.HasPermission(models.PermTicketRead) {
+		return nil, fmt.Errorf("%w: only the requester or staff can view comments on this ticket", ErrForbidden)
+	}
+
+	// Internal notes are only visible to support staff
+	return s.commentRepo.GetByTicket(ctx, ticketID, user.IsAgent())
+}
+
+// ScopeTickets returns a context that limits ticket listings and statistics to
+// the user's teams, unless the user may see every ticket
+func (s *TicketService) ScopeTickets(ctx context.Context, user *models.User) (context.Context, error) {
+	if user.HasPermission(models.PermTicketReadAll) {
+		return ctx, nil
+	}
+
+	teamIDs, err := s.teamRepo.GetTeamIDsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user teams: %w", err)
+	}
+	return repository.WithTicketScope(ctx, &repository.TicketScope{TeamIDs: teamIDs}), nil
+}
+
+// onBehalfOfRequester resolves the requester of a ticket logged by staff for
+// someone else. It returns nil when the creator is the requester.
+func (s *TicketService) onBehalfOfRequester(ctx context.Context, requesterID *uuid.UUID, createdByID uuid.UUID) (*models.User, error) {
+	if requesterID == nil || *requesterID == createdByID {
+		return nil, nil
+	}
+
+	creator, err := s.userRepo.GetByID(createdByID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if creator == nil || !creator.IsAgent() {
+		return nil, fmt.Errorf("%w: only agents can log tickets on behalf of another user", ErrForbidden)
+	}
+
+	requester, err := s.userRepo.GetByID(requesterID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester == nil {
+		return nil, fmt.Errorf("%w: requester not found", ErrInvalidInput)
+	}
+	if !requester.IsActive || !requester.HasPermission(models.PermTicketCreate) {
+		return nil, fmt.Errorf("%w: the requester must be an active user who can raise tickets", ErrInvalidInput)
+	}
+	return requester, nil
+}
+
+// draftAttachments loads the draft attachments, or with inline the inline
+// images, a ticket is being saved with. The user must have uploaded them and
+// they must not have expired.
+func (s *TicketService) draftAttachments(ctx context.Context, ids []uuid.UUID, inline bool, uploaderID uuid.UUID) ([]models.DraftAttachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	drafts, err := s.draftRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft attachments: %w", err)
+	}
+
+	kind := "attachment"
+	if inline {
+		kind = "inline image"
+	}
+	now := time.Now()
+	for _, id := range ids {
+		i := slices.IndexFunc(drafts, func(draft models.DraftAttachment) bool { return draft.ID == id })
+		if i < 0 || drafts[i].Inline != inline || drafts[i].UploadedByID != uploaderID || now.After(drafts[i].ExpiresAt) {
+			return nil, fmt.Errorf("%w: %s %s was not found or has expired; upload it again", ErrInvalidInput, kind, id)
+		}
+	}
+	return drafts, nil
+}
+
+// checkOpenTicketQuota refuses a new ticket from an end user who already has
+// as many open tickets as they may. Agents, managers and administrators are exempt.
+func (s *TicketService) checkOpenTicketQuota(ctx context.Context, requesterID uuid.UUID) error {
+	if s.maxOpenPerRequester <= 0 {
+		return nil
+	}
+	requester, err := s.userRepo.GetByID(requesterID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if requester == nil || requester.IsAgent() {
+		return nil
+	}
+	return openTicketQuota(ctx, s.ticketRepo, requester.ID, s.maxOpenPerRequester)
+}
+
+// openTicketQuota refuses a new ticket for a requester who already has limit open tickets
+func openTicketQuota(ctx context.Context, ticketRepo repository.TicketRepository, requesterID uuid.UUID, limit int) error {
+	open, err := ticketRepo.CountOpenByRequester(ctx, requesterID)
+	if err != nil {
+		return fmt.Errorf("failed to count open tickets: %w", err)
+	}
+	if open >= int64(limit) {
+		return fmt.Errorf("%w: you already have the maximum of %d open tickets; please add any new details to one of them, or wait for one to be resolved, instead of opening another", ErrConflict, limit)
+	}
+	return nil
+}
+
+// validateDueDate checks that an optional due date is in the future
+func validateDueDate(dueDate *time.Time) error {
+	if dueDate != nil && !dueDate.After(time.Now()) {
+		return fmt.Errorf("%w: due_date must be in the future", ErrInvalidInput)
+	}
+	return nil
+}
+
+// validateTeam checks that an optional team reference exists
+func (s *TicketService) validateTeam(ctx context.Context, teamID *uuid.UUID) error {
+	if teamID == nil {
+		return nil
+	}
+	if _, err := s.teamRepo.GetByID(ctx, *teamID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("team not found")
+		}
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+	return nil
+}
+
+// checkResolution fails with ErrInvalidInput naming every resolution detail
+// the ticket's category requires that is missing
+func checkResolution(ticket *models.Ticket, resolution *models.TicketResolution) error {
+	if ticket.Category == nil {
+		return nil
+	}
+	var missing []string
+	for _, field := range ticket.Category.RequiredResolutionFields {
+		switch {
+		case field == models.ResolutionFieldCode && resolution.ResolutionCode == "",
+			field == models.ResolutionFieldRootCause && resolution.RootCause == "",
+			field == models.ResolutionFieldRootCauseCode && resolution.RootCauseCode == "",
+			field == models.ResolutionFieldKnowledgeArticle && resolution.KnowledgeArticleID == nil:
+			missing = append(missing, string(field))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: tickets in category %q need %s to be resolved", ErrInvalidInput, ticket.Category.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // isValidStatusTransition checks if a status transition is valid
 func (s *TicketService) isValidStatusTransition(from, to models.TicketStatus) bool {
 	validTransitions := map[models.TicketStatus][]models.TicketStatus{
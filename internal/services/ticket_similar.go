@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+)
+
+const (
+	// defaultSimilarTickets is how many similar tickets are returned unless asked otherwise
+	defaultSimilarTickets = 5
+	// maxSimilarTerms caps how many words of a draft are searched for
+	maxSimilarTerms = 32
+)
+
+// similarStopWords are words too common in tickets to suggest a duplicate
+var similarStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true,
+	"all": true, "any": true, "can": true, "had": true, "has": true, "have": true, "her": true,
+	"his": true, "our": true, "out": true, "was": true, "were": true, "will": true, "with": true,
+	"this": true, "that": true, "from": true, "they": true, "them": true, "then": true, "than": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true, "who": true, "why": true,
+	"how": true, "get": true, "got": true, "into": true, "just": true, "been": true, "being": true,
+	"does": true, "doesn": true, "did": true, "didn": true, "don": true, "isn": true, "its": true,
+	"please": true, "thanks": true, "thank": true, "hello": true, "help": true, "issue": true,
+	"problem": true, "still": true, "also": true, "there": true, "their": true, "some": true,
+}
+
+// FindSimilarTickets lists the open tickets most like a draft, so the
+// requester can be pointed at an existing ticket before opening a duplicate.
+// Only the requester's tickets are searched unless IncludeOthers is set,
+// which needs the ticket read permission and keeps to the user's ticket scope.
+func (s *TicketService) FindSimilarTickets(ctx context.Context, req *models.SimilarTicketsRequest, user *models.User) (*models.SimilarTicketsResponse, error) {
+	requesterID := user.ID
+	if req.RequesterID != nil && *req.RequesterID != user.ID {
+		if !user.IsAgent() {
+			return nil, fmt.Errorf("%w: only agents can check another user's tickets", ErrForbidden)
+		}
+		requesterID = *req.RequesterID
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSimilarTickets
+	}
+
+	response := &models.SimilarTicketsResponse{Tickets: []models.SimilarTicket{}}
+	match := similarTicketsQuery(req.Title + " " + req.Description)
+	if match == "" {
+		return response, nil
+	}
+
+	searchRequester := &requesterID
+	if req.IncludeOthers {
+		if !user.HasPermission(models.PermTicketRead) {
+			return nil, fmt.Errorf("%w: include_others needs permission to read other users' tickets", ErrForbidden)
+		}
+		scoped, err := s.ScopeTickets(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		ctx, searchRequester = scoped, nil
+	}
+
+	tickets, err := s.ticketRepo.FindSimilar(ctx, match, searchRequester, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar tickets: %w", err)
+	}
+	for i := range tickets {
+		tickets[i].Own = tickets[i].CreatedByID == requesterID
+	}
+	response.Tickets = append(response.Tickets, tickets...)
+	return response, nil
+}
+
+// similarTicketsQuery turns draft text into an FTS5 query matching tickets
+// that share any of its distinctive words. Each word is quoted so that none
+// is read as query syntax. It returns "" when the draft has no such words.
+func similarTicketsQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var terms []string
+	for _, word := range words {
+		if len([]rune(word)) < 3 || similarStopWords[word] || slices.Contains(terms, word) {
+			continue
+		}
+		terms = append(terms, word)
+		if len(terms) == maxSimilarTerms {
+			break
+		}
+	}
+	for i, term := range terms {
+		terms[i] = `"` + term + `"`
+	}
+	return strings.Join(terms, " OR ")
+}
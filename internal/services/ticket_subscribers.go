@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxTicketSubscribers caps how many outside addresses can be copied on one ticket
+const maxTicketSubscribers = 10
+
+// AddSubscriber copies an outside email address on a ticket, so it is emailed
+// the ticket's public comments. Only the requester or an agent can copy
+// someone, and each address is copied once.
+func (s *TicketService) AddSubscriber(ctx context.Context, ticketID uuid.UUID, req *models.AddTicketSubscriberRequest, user *models.User) (*models.TicketSubscriber, error) {
+	ticket, err := s.subscriberTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.CreatedByID != user.ID && !user.IsAgent() {
+		return nil, fmt.Errorf("%w: only the requester or an agent can copy someone on this ticket", ErrForbidden)
+	}
+
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+	requester, err := s.userRepo.GetByID(ticket.CreatedByID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requester: %w", err)
+	}
+	if requester != nil && strings.EqualFold(requester.Email, address) {
+		return nil, fmt.Errorf("%w: the requester already receives updates on this ticket", ErrConflict)
+	}
+
+	// Emails are encrypted at rest, so duplicates are found here rather than by the database
+	subscribers, err := s.ticketRepo.ListSubscribers(ctx, ticket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+	for _, subscriber := range subscribers {
+		if strings.EqualFold(subscriber.Email, address) {
+			return nil, fmt.Errorf("%w: %s is already copied on this ticket", ErrConflict, address)
+		}
+	}
+	if len(subscribers) >= maxTicketSubscribers {
+		return nil, fmt.Errorf("%w: a ticket can copy at most %d addresses", ErrInvalidInput, maxTicketSubscribers)
+	}
+
+	subscriber := &models.TicketSubscriber{
+		TicketID:  ticket.ID,
+		Email:     address,
+		Name:      strings.TrimSpace(req.Name),
+		AddedByID: user.ID,
+	}
+	if err := s.ticketRepo.AddSubscriber(ctx, subscriber); err != nil {
+		return nil, fmt.Errorf("failed to add subscriber: %w", err)
+	}
+	return subscriber, nil
+}
+
+// ListSubscribers lists the addresses copied on a ticket the user can see
+func (s *TicketService) ListSubscribers(ctx context.Context, ticketID uuid.UUID, user *models.User) ([]models.TicketSubscriber, error) {
+	ticket, err := s.subscriberTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.CreatedByID != user.ID && !user.HasPermission(models.PermTicketRead) {
+		return nil, fmt.Errorf("%w: only the requester or staff can see who is copied on this ticket", ErrForbidden)
+	}
+
+	subscribers, err := s.ticketRepo.ListSubscribers(ctx, ticket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+	if subscribers == nil {
+		subscribers = []models.TicketSubscriber{}
+	}
+	return subscribers, nil
+}
+
+// RemoveSubscriber stops copying an address on a ticket
+func (s *TicketService) RemoveSubscriber(ctx context.Context, ticketID, subscriberID uuid.UUID, user *models.User) error {
+	ticket, err := s.subscriberTicket(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket.CreatedByID != user.ID && !user.IsAgent() {
+		return fmt.Errorf("%w: only the requester or an agent can remove someone from this ticket", ErrForbidden)
+	}
+
+	subscriber, err := s.ticketRepo.GetSubscriber(ctx, subscriberID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get subscriber: %w", err)
+	}
+	if subscriber == nil || subscriber.TicketID != ticket.ID {
+		return fmt.Errorf("subscriber %w", ErrNotFound)
+	}
+
+	if err := s.ticketRepo.RemoveSubscriber(ctx, subscriber.ID); err != nil {
+		return fmt.Errorf("failed to remove subscriber: %w", err)
+	}
+	return nil
+}
+
+// subscriberTicket loads the ticket whose subscribers are being managed
+func (s *TicketService) subscriberTicket(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return ticket, nil
+}
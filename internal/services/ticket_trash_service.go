@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// trashPurgeBatch caps how many tickets one run of the purge job deletes
+const trashPurgeBatch = 100
+
+// TicketTrashService keeps deleted tickets restorable for a while and purges
+// them for good once their retention has passed
+type TicketTrashService struct {
+	ticketRepo repository.TicketRepository
+	storage    storage.Storage
+	// retention is how long tickets stay in the trash; 0 keeps them
+	retention time.Duration
+}
+
+// NewTicketTrashService creates a new ticket trash service
+func NewTicketTrashService(ticketRepo repository.TicketRepository, storage storage.Storage, cfg *config.Config) *TicketTrashService {
+	retention := time.Duration(max(cfg.Tickets.TrashRetentionDays, 0)) * 24 * time.Hour
+	return &TicketTrashService{
+		ticketRepo: ticketRepo,
+		storage:    storage,
+		retention:  retention,
+	}
+}
+
+// List returns a page of the tickets in the trash, most recently deleted
+// first, each with the time it will be purged
+func (s *TicketTrashService) List(ctx context.Context, page, pageSize int) (*models.TrashedTicketListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	tickets, total, err := s.ticketRepo.ListTrashed(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed tickets: %w", err)
+	}
+
+	response := &models.TrashedTicketListResponse{
+		Tickets:    make([]models.TrashedTicket, len(tickets)),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+	for i, ticket := range tickets {
+		response.Tickets[i].Ticket = ticket
+		if s.retention > 0 && ticket.DeletedAt != nil {
+			purgeAt := ticket.DeletedAt.Add(s.retention)
+			response.Tickets[i].PurgeAt = &purgeAt
+		}
+	}
+	return response, nil
+}
+
+// Restore takes a ticket out of the trash
+func (s *TicketTrashService) Restore(ctx context.Context, ticketID uuid.UUID) (*models.Ticket, error) {
+	if err := s.ticketRepo.Restore(ctx, ticketID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("ticket %w in the trash", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to restore ticket: %w", err)
+	}
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	return ticket, nil
+}
+
+// Schedule registers the job purging tickets whose retention has passed
+func (s *TicketTrashService) Schedule(jobs *scheduler.Scheduler) {
+	if s.retention > 0 {
+		jobs.Every("ticket trash purge", time.Hour, s.PurgeExpired)
+	}
+}
+
+// PurgeExpired permanently deletes the tickets that have been in the trash
+// longer than the retention, with their attachment files
+func (s *TicketTrashService) PurgeExpired(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	expired, err := s.ticketRepo.ListTrashedBefore(ctx, time.Now().Add(-s.retention), trashPurgeBatch)
+	if err != nil {
+		return fmt.Errorf("failed to list expired trashed tickets: %w", err)
+	}
+	for _, ticket := range expired {
+		if err := s.ticketRepo.Purge(ctx, ticket.ID); err != nil {
+			// Restored meanwhile
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to purge ticket %s: %w", ticket.ID, err)
+		}
+		for i := range ticket.Attachments {
+			deleteAttachmentFiles(ctx, s.storage, &ticket.Attachments[i])
+		}
+	}
+	return nil
+}
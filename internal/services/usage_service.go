@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+)
+
+// UsageService counts each user's API requests per UTC day against a daily
+// quota. Counts are kept in memory, so they start over when the server restarts.
+type UsageService struct {
+	mu     sync.Mutex
+	limit  int64
+	day    time.Time
+	counts map[uuid.UUID]int64
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(cfg *config.Config) *UsageService {
+	return &UsageService{
+		limit:  cfg.RateLimit.UserDailyQuota,
+		counts: make(map[uuid.UUID]int64),
+	}
+}
+
+// Reload applies the daily quota of cfg; requests already counted today still count
+func (s *UsageService) Reload(cfg *config.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = cfg.RateLimit.UserDailyQuota
+	return nil
+}
+
+// Consume counts a request by the user and returns their usage. It reports
+// false, without counting the request, once the day's quota is used up.
+func (s *UsageService) Consume(userID uuid.UUID) (*models.APIUsage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollOver(time.Now())
+	if s.limit > 0 && s.counts[userID] >= s.limit {
+		return s.usage(userID), false
+	}
+	s.counts[userID]++
+	return s.usage(userID), true
+}
+
+// Usage returns the user's usage so far today
+func (s *UsageService) Usage(userID uuid.UUID) *models.APIUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollOver(time.Now())
+	return s.usage(userID)
+}
+
+// rollOver forgets the counts of previous days
+func (s *UsageService) rollOver(now time.Time) {
+	if day := now.UTC().Truncate(24 * time.Hour); !day.Equal(s.day) {
+		s.day = day
+		clear(s.counts)
+	}
+}
+
+// usage builds the user's usage report; s.mu must be held
+func (s *UsageService) usage(userID uuid.UUID) *models.APIUsage {
+	usage := &models.APIUsage{
+		Used:     s.counts[userID],
+		ResetsAt: s.day.Add(24 * time.Hour),
+	}
+	if s.limit > 0 {
+		limit, remaining := s.limit, max(s.limit-usage.Used, 0)
+		usage.Limit, usage.Remaining = &limit, &remaining
+	}
+	return usage
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/officehours"
+	"github.com/google/uuid"
+)
+
+// UserService manages the current user's account preferences
+type UserService struct {
+	userRepo repository.UserRepository
+}
+
+// NewUserService creates a new user service
+func NewUserService(userRepo repository.UserRepository) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+	}
+}
+
+// SetLanguage sets the language the user's notifications are sent in. An
+// empty language goes back to the default language.
+func (s *UserService) SetLanguage(ctx context.Context, userID uuid.UUID, language string) (*models.User, error) {
+	language, err := normalizeLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	user.Language = language
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// SetTimezone sets the time zone the user's timestamps and dates are in
+func (s *UserService) SetTimezone(ctx context.Context, userID uuid.UUID, req *models.TimezoneRequest) (*models.User, error) {
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidInput, req.Timezone)
+	}
+
+	user, err := s.userRepo.GetByID(userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	user.Timezone = req.Timezone
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// SetQuietHours sets the time zone and daily quiet hours during which the
+// user's notifications are held back. Empty start and end turn them off.
+func (s *UserService) SetQuietHours(ctx context.Context, userID uuid.UUID, req *models.QuietHoursRequest) (*models.User, error) {
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidInput, req.Timezone)
+	}
+	if (req.Start == "") != (req.End == "") {
+		return nil, fmt.Errorf("%w: quiet hours need both a start and an end", ErrInvalidInput)
+	}
+	if req.Start != "" {
+		start, err := officehours.ParseClock(req.Start)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		end, err := officehours.ParseClock(req.End)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("%w: quiet hours must not start and end at the same time", ErrInvalidInput)
+		}
+	}
+
+	user, err := s.userRepo.GetByID(userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	user.Timezone = req.Timezone
+	user.QuietHoursStart = req.Start
+	user.QuietHoursEnd = req.End
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
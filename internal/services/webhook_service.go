@@ -0,0 +1,434 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries are attempted per run
+	webhookBatchSize = 50
+	// webhookMaxAttempts is how often an event is posted before its delivery fails
+	webhookMaxAttempts = 8
+	// webhookRetryDelay is how long the first retry waits; each later retry
+	// waits twice as long as the one before, so the last comes about an hour
+	// after the event
+	webhookRetryDelay = 30 * time.Second
+)
+
+// WebhookService manages webhook subscriptions and posts the ticket events
+// they subscribe to, retrying failed deliveries with exponential backoff
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Schedule attempts due deliveries every ten seconds
+func (s *WebhookService) Schedule(jobs *scheduler.Scheduler) {
+	jobs.Every("webhook deliveries", 10*time.Second, func(ctx context.Context) error {
+		_, err := s.DeliverDue(ctx, time.Now())
+		return err
+	})
+}
+
+// ListSubscriptions lists every webhook subscription
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (s *WebhookService) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook subscription %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// CreateSubscription creates a webhook subscription with a new signing
+// secret, which is returned only this once
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *models.WebhookSubscriptionRequest, createdByID uuid.UUID) (*models.WebhookSecretResponse, error) {
+	subscription := &models.WebhookSubscription{Enabled: true, CreatedByID: createdByID}
+	if err := applyWebhookRequest(subscription, req); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	subscription.Secret = secret
+
+	if err := s.webhookRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return &models.WebhookSecretResponse{Subscription: subscription, Secret: secret}, nil
+}
+
+// UpdateSubscription replaces the URL, description and events of a webhook
+// subscription; its secret is kept
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id uuid.UUID, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookRequest(subscription, req); err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription deletes a webhook subscription with its delivery log;
+// events not yet delivered are dropped
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// RotateSecret replaces the signing secret of a webhook subscription.
+// Deliveries attempted from then on, including retries, are signed with it.
+func (s *WebhookService) RotateSecret(ctx context.Context, id uuid.UUID) (*models.WebhookSecretResponse, error) {
+	subscription, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	subscription.Secret = secret
+	if err := s.webhookRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return &models.WebhookSecretResponse{Subscription: subscription, Secret: secret}, nil
+}
+
+// ListDeliveries returns a page of the delivery log of a webhook subscription
+func (s *WebhookService) ListDeliveries(ctx context.Context, query *models.WebhookDeliveryQuery) (*models.WebhookDeliveryListResponse, error) {
+	if _, err := s.GetSubscription(ctx, query.SubscriptionID); err != nil {
+		return nil, err
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 50
+	}
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// applyWebhookRequest validates a subscription request and copies it onto the subscription
+func applyWebhookRequest(subscription *models.WebhookSubscription, req *models.WebhookSubscriptionRequest) error {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return fmt.Errorf("%w: the URL must be an http or https URL", ErrInvalidInput)
+	}
+	events := slices.Clone(req.Events)
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q; use %s", ErrInvalidInput, event, strings.Join(models.WebhookEvents, ", "))
+		}
+	}
+	slices.Sort(events)
+
+	subscription.URL = target.String()
+	subscription.Description = strings.TrimSpace(req.Description)
+	subscription.Events = slices.Compact(events)
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// publish queues an event for delivery to every enabled subscription to it
+func (s *WebhookService) publish(ctx context.Context, event string, data models.WebhookData) error {
+	subscriptions, err := s.webhookRepo.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var errs []error
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event) {
+			continue
+		}
+		delivery := &models.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			Event:          event,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		payload, err := json.Marshal(models.WebhookPayload{ID: delivery.ID, Event: event, CreatedAt: now, Data: data})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		delivery.Payload = string(payload)
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("failed to queue webhook delivery: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliverDue attempts the deliveries due at the given time, returning how
+// many were delivered. Failed attempts are retried later until the delivery
+// runs out of attempts.
+func (s *WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.webhookRepo.ListDueDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+
+	subscriptions := make(map[uuid.UUID]*models.WebhookSubscription)
+	delivered := 0
+	for i := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return delivered, err
+		}
+		delivery := &deliveries[i]
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.webhookRepo.GetByID(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return delivered, fmt.Errorf("failed to get webhook subscription: %w", err)
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		s.attempt(ctx, delivery, subscription, now)
+		if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return delivered, fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+		if delivery.Status == models.WebhookDeliveryDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt posts a delivery to its subscription and records the outcome,
+// scheduling the next attempt of a failed delivery with exponential backoff.
+// Deliveries of subscriptions disabled or deleted since are failed unsent.
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery, subscription *models.WebhookSubscription, now time.Time) {
+	delivery.NextAttemptAt = nil
+	if subscription == nil || !subscription.Enabled {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "the subscription was disabled before the event was delivered"
+		return
+	}
+
+	attemptedAt := now.UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &attemptedAt
+	status, err := s.post(ctx, subscription, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &attemptedAt
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), 1000)
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	next := attemptedAt.Add(webhookRetryDelay << (delivery.Attempts - 1))
+	delivery.NextAttemptAt = &next
+}
+
+// post sends a delivery's payload to its subscription's URL, returning the
+// response status. Any status other than 2xx fails the attempt.
+func (s *WebhookService) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "helpchat-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set("X-Signature-256", WebhookSignature(subscription.Secret, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach the webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("the webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return resp.StatusCode, nil
+}
+
+// WebhookSignature signs a payload for the X-Signature-256 header: sha256=
+// followed by the hex HMAC-SHA256 of the body, keyed with the secret
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier returns a CommentNotifier that posts new tickets, status changes
+// and comments to the webhook subscriptions for them, then passes every
+// notification on to next
+func (s *WebhookService) Notifier(next CommentNotifier) CommentNotifier {
+	return &webhookNotifier{webhooks: s, next: next}
+}
+
+// webhookNotifier queues webhook deliveries for ticket events before
+// passing notifications on
+type webhookNotifier struct {
+	webhooks *WebhookService
+	next     CommentNotifier
+}
+
+// forward passes a notification on to the next notifier, keeping the error
+// of queueing its webhook deliveries
+func (n *webhookNotifier) forward(publishErr error, send func(next CommentNotifier) error) error {
+	if n.next == nil {
+		return publishErr
+	}
+	return errors.Join(publishErr, send(n.next))
+}
+
+// NotifyTicketCreated posts ticket.created, with the user who logged the
+// ticket as its actor
+func (n *webhookNotifier) NotifyTicketCreated(ctx context.Context, ticket *models.Ticket, requester *models.User, responseTarget time.Duration) error {
+	return n.forward(n.publishCreated(ctx, ticket), func(next CommentNotifier) error {
+		return next.NotifyTicketCreated(ctx, ticket, requester, responseTarget)
+	})
+}
+
+// NotifyTicketReceived posts ticket.created for tickets submitted by guest
+// form, email or chat
+func (n *webhookNotifier) NotifyTicketReceived(ctx context.Context, ticket *models.Ticket, requester *models.User) error {
+	return n.forward(n.publishCreated(ctx, ticket), func(next CommentNotifier) error {
+		return next.NotifyTicketReceived(ctx, ticket, requester)
+	})
+}
+
+// publishCreated queues the ticket.created deliveries of a new ticket
+func (n *webhookNotifier) publishCreated(ctx context.Context, ticket *models.Ticket) error {
+	actorID := ticket.CreatedByID
+	if ticket.OpenedBy != nil {
+		actorID = *ticket.OpenedBy
+	}
+	return n.webhooks.publish(ctx, models.WebhookEventTicketCreated, models.WebhookData{
+		Ticket:  models.NewWebhookTicket(ticket),
+		ActorID: actorID,
+	})
+}
+
+// NotifyCommentAdded posts comment.added, including internal notes
+func (n *webhookNotifier) NotifyCommentAdded(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User) error {
+	err := n.webhooks.publish(ctx, models.WebhookEventCommentAdded, models.WebhookData{
+		Ticket:  models.NewWebhookTicket(ticket),
+		ActorID: author.ID,
+		Comment: &models.WebhookComment{
+			ID:         comment.ID,
+			AuthorID:   author.ID,
+			Content:    comment.Content,
+			IsInternal: comment.IsInternal,
+			CreatedAt:  comment.CreatedAt,
+		},
+	})
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyCommentAdded(ctx, ticket, comment, author)
+	})
+}
+
+// NotifyStatusChanged posts ticket.status_changed with the previous status
+func (n *webhookNotifier) NotifyStatusChanged(ctx context.Context, ticket *models.Ticket, changedBy *models.User, previous models.TicketStatus, recipients []*models.User) error {
+	err := n.webhooks.publish(ctx, models.WebhookEventTicketStatusChanged, models.WebhookData{
+		Ticket:         models.NewWebhookTicket(ticket),
+		ActorID:        changedBy.ID,
+		PreviousStatus: previous,
+	})
+	return n.forward(err, func(next CommentNotifier) error {
+		return next.NotifyStatusChanged(ctx, ticket, changedBy, previous, recipients)
+	})
+}
+
+// NotifyMentioned passes the mention on; comment.added covers it
+func (n *webhookNotifier) NotifyMentioned(ctx context.Context, ticket *models.Ticket, comment *models.Comment, author *models.User, recipients []*models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyMentioned(ctx, ticket, comment, author, recipients)
+	})
+}
+
+// NotifyAssigned passes the assignment on
+func (n *webhookNotifier) NotifyAssigned(ctx context.Context, ticket *models.Ticket, assignedBy, assignee *models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyAssigned(ctx, ticket, assignedBy, assignee)
+	})
+}
+
+// NotifyEscalated passes the escalation on
+func (n *webhookNotifier) NotifyEscalated(ctx context.Context, ticket *models.Ticket, escalatedBy, escalatedTo *models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyEscalated(ctx, ticket, escalatedBy, escalatedTo)
+	})
+}
+
+// NotifyDeEscalated passes the de-escalation on
+func (n *webhookNotifier) NotifyDeEscalated(ctx context.Context, ticket *models.Ticket, returnedBy, assignee *models.User, note string) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyDeEscalated(ctx, ticket, returnedBy, assignee, note)
+	})
+}
+
+// NotifyReassignedOften passes the reassignment alert on
+func (n *webhookNotifier) NotifyReassignedOften(ctx context.Context, ticket *models.Ticket, agents int, managers []*models.User) error {
+	return n.forward(nil, func(next CommentNotifier) error {
+		return next.NotifyReassignedOften(ctx, ticket, agents, managers)
+	})
+}
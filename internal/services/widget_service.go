@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/officehours"
+)
+
+// WidgetService builds the bootstrap configuration of the embeddable chat widget
+type WidgetService struct {
+	userRepo    repository.UserRepository
+	triggerRepo repository.ChatTriggerRepository
+	presence    *PresenceService
+	branding    models.WidgetBranding
+	officeHours atomic.Pointer[officehours.Schedule]
+}
+
+// NewWidgetService creates a new widget service. It fails when the configured
+// office hours cannot be parsed.
+func NewWidgetService(userRepo repository.UserRepository, triggerRepo repository.ChatTriggerRepository, presence *PresenceService, cfg *config.Config) (*WidgetService, error) {
+	s := &WidgetService{
+		userRepo:    userRepo,
+		triggerRepo: triggerRepo,
+		presence:    presence,
+		branding: models.WidgetBranding{
+			Title:        cfg.Widget.Title,
+			Greeting:     cfg.Widget.Greeting,
+			PrimaryColor: cfg.Widget.PrimaryColor,
+			LogoURL:      cfg.Widget.LogoURL,
+		},
+	}
+	if err := s.Reload(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload applies the office hours and holidays of cfg, keeping the current ones when they
+// cannot be parsed. Branding is only read at startup.
+func (s *WidgetService) Reload(cfg *config.Config) error {
+	schedule, err := parseOfficeSchedule(cfg)
+	if err != nil {
+		return err
+	}
+	s.officeHours.Store(schedule)
+	return nil
+}
+
+// GetConfig returns the widget's branding, office hours and whether an agent
+// can pick up a conversation at the given time. When the page being visited is
+// given, the first matching proactive trigger is included.
+func (s *WidgetService) GetConfig(ctx context.Context, at time.Time, visit *models.WidgetVisit) (*models.WidgetConfigResponse, error) {
+	schedule := s.officeHours.Load()
+	hours := models.WidgetOfficeHours{
+		Timezone: schedule.Location.String(),
+		Windows:  make([]models.OfficeHoursWindow, 0, len(schedule.Windows)),
+		OpenNow:  schedule.IsOpen(at),
+	}
+	for _, window := range schedule.Windows {
+		hours.Windows = append(hours.Windows, models.OfficeHoursWindow{
+			Day:   strings.ToLower(window.Day.String()),
+			Open:  officehours.FormatClock(window.Open),
+			Close: officehours.FormatClock(window.Close),
+		})
+	}
+	if !hours.OpenNow {
+		next := schedule.NextOpen(at)
+		hours.NextOpenAt = &next
+	}
+
+	agents, err := s.OnlineAgents(at)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &models.WidgetConfigResponse{
+		Branding:    s.branding,
+		OfficeHours: hours,
+		Queue: models.WidgetQueue{
+			Available:       hours.OpenNow && len(agents) > 0,
+			AgentsAvailable: int64(len(agents)),
+		},
+	}
+	if visit != nil && visit.PageURL != "" {
+		if config.Proactive, err = s.matchTrigger(ctx, visit); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// AgentsAvailable reports whether support is open and at least one agent can
+// pick up a conversation at the given time
+func (s *WidgetService) AgentsAvailable(ctx context.Context, at time.Time) (bool, error) {
+	if !s.officeHours.Load().IsOpen(at) {
+		return false, nil
+	}
+	agents, err := s.OnlineAgents(at)
+	if err != nil {
+		return false, err
+	}
+	return len(agents) > 0, nil
+}
+
+// OnlineAgents returns the agents who are online and not out of office at the
+// given time, oldest accounts first
+func (s *WidgetService) OnlineAgents(at time.Time) ([]*models.User, error) {
+	agents, err := s.userRepo.ListAvailableAgents(at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list available agents: %w", err)
+	}
+	online := agents[:0]
+	for _, agent := range agents {
+		if s.presence.IsOnline(agent.ID) {
+			online = append(online, agent)
+		}
+	}
+	return online, nil
+}
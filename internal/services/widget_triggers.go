@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// greetingPlaceholder finds the {{name}} placeholders of a trigger greeting
+var greetingPlaceholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// greetingValues are the placeholders a trigger greeting may use
+var greetingValues = map[string]func(s *WidgetService, visit *models.WidgetVisit) string{
+	"page_title": func(s *WidgetService, visit *models.WidgetVisit) string { return visit.PageTitle },
+	"page_url":   func(s *WidgetService, visit *models.WidgetVisit) string { return visit.PageURL },
+	"site_title": func(s *WidgetService, visit *models.WidgetVisit) string { return s.branding.Title },
+}
+
+// CreateTrigger adds a proactive chat trigger
+func (s *WidgetService) CreateTrigger(ctx context.Context, req *models.ChatTriggerRequest, createdByID uuid.UUID) (*models.ChatTrigger, error) {
+	trigger := &models.ChatTrigger{CreatedByID: createdByID}
+	if err := applyTriggerRequest(trigger, req); err != nil {
+		return nil, err
+	}
+	if err := s.triggerRepo.Create(ctx, trigger); err != nil {
+		return nil, fmt.Errorf("failed to create chat trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// GetTrigger retrieves a proactive chat trigger by ID
+func (s *WidgetService) GetTrigger(ctx context.Context, id uuid.UUID) (*models.ChatTrigger, error) {
+	trigger, err := s.triggerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("chat trigger %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get chat trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// ListTriggers retrieves every proactive chat trigger in evaluation order
+func (s *WidgetService) ListTriggers(ctx context.Context) ([]models.ChatTrigger, error) {
+	triggers, err := s.triggerRepo.List(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat triggers: %w", err)
+	}
+	return triggers, nil
+}
+
+// UpdateTrigger replaces a proactive chat trigger's rule and greeting
+func (s *WidgetService) UpdateTrigger(ctx context.Context, id uuid.UUID, req *models.ChatTriggerRequest) (*models.ChatTrigger, error) {
+	trigger, err := s.GetTrigger(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTriggerRequest(trigger, req); err != nil {
+		return nil, err
+	}
+	if err := s.triggerRepo.Update(ctx, trigger); err != nil {
+		return nil, fmt.Errorf("failed to update chat trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// DeleteTrigger removes a proactive chat trigger
+func (s *WidgetService) DeleteTrigger(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetTrigger(ctx, id); err != nil {
+		return err
+	}
+	if err := s.triggerRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete chat trigger: %w", err)
+	}
+	return nil
+}
+
+// matchTrigger finds the highest priority enabled trigger for a visit and
+// renders its greeting; it returns nil when none match
+func (s *WidgetService) matchTrigger(ctx context.Context, visit *models.WidgetVisit) (*models.WidgetProactive, error) {
+	triggers, err := s.triggerRepo.List(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat triggers: %w", err)
+	}
+
+	for _, trigger := range triggers {
+		if !triggerMatches(&trigger, visit) {
+			continue
+		}
+		greeting := greetingPlaceholder.ReplaceAllStringFunc(trigger.Greeting, func(placeholder string) string {
+			name := greetingPlaceholder.FindStringSubmatch(placeholder)[1]
+			return greetingValues[name](s, visit)
+		})
+		return &models.WidgetProactive{
+			TriggerID:    trigger.ID,
+			DelaySeconds: trigger.MinSecondsOnPage,
+			Greeting:     strings.TrimSpace(greeting),
+		}, nil
+	}
+	return nil, nil
+}
+
+// triggerMatches reports whether a trigger's visitor type and URL pattern match a visit
+func triggerMatches(trigger *models.ChatTrigger, visit *models.WidgetVisit) bool {
+	switch trigger.VisitorType {
+	case models.TriggerNewVisitor:
+		if visit.Returning {
+			return false
+		}
+	case models.TriggerReturningVisitor:
+		if !visit.Returning {
+			return false
+		}
+	}
+
+	target := visit.PageURL
+	if !strings.Contains(trigger.URLPattern, "://") {
+		// Patterns without a scheme match the path, whichever site embeds the widget
+		page, err := url.Parse(visit.PageURL)
+		if err != nil {
+			return false
+		}
+		target = page.Path
+		if target == "" {
+			target = "/"
+		}
+	}
+	return urlPatternRegexp(trigger.URLPattern).MatchString(target)
+}
+
+// urlPatternRegexp compiles a URL pattern where * matches any run of characters
+func urlPatternRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// applyTriggerRequest validates a trigger request and copies it onto the trigger
+func applyTriggerRequest(trigger *models.ChatTrigger, req *models.ChatTriggerRequest) error {
+	for _, match := range greetingPlaceholder.FindAllStringSubmatch(req.Greeting, -1) {
+		if _, ok := greetingValues[match[1]]; !ok {
+			return fmt.Errorf("%w: unknown greeting placeholder %s; use page_title, page_url or site_title", ErrInvalidInput, strconv.Quote(match[0]))
+		}
+	}
+
+	trigger.Name = strings.TrimSpace(req.Name)
+	trigger.Enabled = req.Enabled == nil || *req.Enabled
+	trigger.URLPattern = strings.TrimSpace(req.URLPattern)
+	trigger.MinSecondsOnPage = req.MinSecondsOnPage
+	trigger.VisitorType = req.VisitorType
+	if trigger.VisitorType == "" {
+		trigger.VisitorType = models.TriggerAnyVisitor
+	}
+	trigger.Greeting = strings.TrimSpace(req.Greeting)
+	trigger.Priority = req.Priority
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package cache stores short-lived values that expire after a time to live,
+// such as which agents are online.
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a key-value store whose entries expire. Memory is the in-process
+// implementation; a shared store can implement it when running several servers.
+type Cache interface {
+	// Set stores a value, replacing any existing one, until the ttl elapses
+	Set(key, value string, ttl time.Duration)
+	// Get returns a value and whether it was found and has not expired
+	Get(key string) (string, bool)
+	// Delete removes a value
+	Delete(key string)
+	// Scan returns the unexpired entries whose keys start with prefix
+	Scan(prefix string) map[string]string
+}
+
+// entry is a cached value and when it expires
+type entry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Memory is a Cache held in process memory. Expired entries are removed as
+// they are read, and by Scan.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewMemory creates an empty in-memory cache
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]entry)}
+}
+
+// Set stores a value until the ttl elapses
+func (m *Memory) Set(key, value string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+// Get returns a value if it exists and has not expired
+func (m *Memory) Get(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return "", false
+	}
+	if !time.Now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return "", false
+	}
+	return e.value, true
+}
+
+// Delete removes a value
+func (m *Memory) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+// Scan returns the unexpired entries whose keys start with prefix, removing
+// any expired entries it finds along the way
+func (m *Memory) Scan(prefix string) map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	found := make(map[string]string)
+	for key, e := range m.entries {
+		if !now.Before(e.expiresAt) {
+			delete(m.entries, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			found[key] = e.value
+		}
+	}
+	return found
+}
@@ -1,25 +1,76 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	_ "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/encryption" // Registers the encrypted column serializer
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	_ "modernc.org/sqlite"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
+// replicaCheckInterval is how long a read replica's health is trusted before it is checked again
+const replicaCheckInterval = 10 * time.Second
+
 // Database represents the database connection
 type Database struct {
 	DB *gorm.DB
+
+	// busyRetries counts transactions retried because the database was locked
+	busyRetries atomic.Int64
+
+	// queries logs and summarizes slow statements on the primary and the replica
+	queries *queryLog
+
+	// replica serves heavy read-only queries such as reports, when configured
+	replica *replica
+}
+
+// replica is a read-only copy of the database and whether it last answered
+type replica struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	healthy   bool
+	checkedAt time.Time
 }
 
 // NewDatabase creates a new database connection
 func NewDatabase(cfg *config.Config) (*Database, error) {
+	busyTimeout, err := time.ParseDuration(cfg.Database.BusyTimeout)
+	if err != nil || busyTimeout < 0 {
+		busyTimeout = 5 * time.Second // fallback
+	}
+	slowThreshold, err := time.ParseDuration(cfg.Database.SlowQueryThreshold)
+	if err != nil || slowThreshold < 0 {
+		slowThreshold = 200 * time.Millisecond // fallback
+	}
+	queries := newQueryLog(slowThreshold)
+
+	// Pragmas go in the DSN so every pooled connection gets them. WAL lets
+	// readers carry on during a write, busy_timeout makes writers wait for each
+	// other instead of failing, and immediate transactions take the write lock
+	// up front, where busy_timeout applies, rather than failing on upgrade.
+	dsn := withParams(cfg.Database.FilePath,
+		fmt.Sprintf("_pragma=busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"_pragma=journal_mode(WAL)",
+		"_pragma=synchronous(NORMAL)",
+		"_pragma=cache_size(1000)",
+		"_pragma=temp_store(MEMORY)",
+		"_txlock=immediate",
+	)
+
 	// Use the pure Go SQLite driver
-	db, err := sql.Open("sqlite", cfg.Database.FilePath)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -27,42 +78,108 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 	// Create GORM DB with the pure Go driver
 	gormDB, err := gorm.Open(sqlite.New(sqlite.Config{
 		Conn: db,
-	}), &gorm.Config{})
+	}), &gorm.Config{Logger: queries})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Configure connection pool with more conservative settings
-	sqlDB, err := gormDB.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	maxOpen, maxIdle := cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns
+	if maxOpen <= 0 {
+		maxOpen = 4
+	}
+	// Each connection to an in-memory database gets its own empty database
+	if strings.Contains(cfg.Database.FilePath, ":memory:") || strings.Contains(cfg.Database.FilePath, "mode=memory") {
+		maxOpen = 1
+	}
+	if maxIdle <= 0 || maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	lifetime, err := time.ParseDuration(cfg.Database.ConnMaxLifetime)
+	if err != nil || lifetime <= 0 {
+		lifetime = time.Hour // fallback
 	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
 
-	// Use more conservative connection pool settings for SQLite
-	sqlDB.SetMaxIdleConns(1)
-	sqlDB.SetMaxOpenConns(1)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	// Test connection
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
 
-	// Configure SQLite pragmas for better performance and memory management
-	if _, err := sqlDB.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		return nil, fmt.Errorf("failed to set journal mode: %w", err)
+	database := &Database{DB: gormDB, queries: queries}
+	if cfg.Database.ReplicaFilePath != "" {
+		replicaDB, err := openReplica(cfg.Database.ReplicaFilePath, queries)
+		if err != nil {
+			return nil, err
+		}
+		database.replica = &replica{db: replicaDB}
 	}
-	if _, err := sqlDB.Exec("PRAGMA synchronous=NORMAL"); err != nil {
-		return nil, fmt.Errorf("failed to set synchronous mode: %w", err)
+	return database, nil
+}
+
+// openReplica connects to a read replica. Every connection is query-only, so a
+// misrouted write fails instead of diverging from the primary.
+func openReplica(path string, queries *queryLog) (*gorm.DB, error) {
+	db, err := sql.Open("sqlite", withParams(path, "_pragma=query_only(1)"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read replica: %w", err)
 	}
-	if _, err := sqlDB.Exec("PRAGMA cache_size=1000"); err != nil {
-		return nil, fmt.Errorf("failed to set cache size: %w", err)
+	gormDB, err := gorm.Open(sqlite.New(sqlite.Config{
+		Conn: db,
+	}), &gorm.Config{Logger: queries})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to read replica: %w", err)
 	}
-	if _, err := sqlDB.Exec("PRAGMA temp_store=MEMORY"); err != nil {
-		return nil, fmt.Errorf("failed to set temp store: %w", err)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(time.Hour)
+	return gormDB, nil
+}
+
+// withParams appends driver query parameters to a database path
+func withParams(path string, params ...string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
 	}
+	return path + separator + strings.Join(params, "&")
+}
 
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+// Reader returns the connection heavy read-only queries such as reports should
+// use: the read replica when one is configured and answering, otherwise the
+// primary. A replica may lag, so reads that must see a write just made stay on DB.
+func (d *Database) Reader() *gorm.DB {
+	if d.replica == nil || !d.replica.available() {
+		return d.DB
+	}
+	return d.replica.db
+}
+
+// available reports whether the replica can serve queries, checking it at most
+// once per replicaCheckInterval
+func (r *replica) available() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) < replicaCheckInterval {
+		return r.healthy
 	}
 
-	return &Database{DB: gormDB}, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// An empty or missing file opens fine, so look for the schema too
+	var tables int64
+	err := r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables).Error
+	healthy := err == nil && tables > 0
+	if healthy != r.healthy || r.checkedAt.IsZero() {
+		if healthy {
+			log.Println("Read replica is available, routing report queries to it")
+		} else {
+			log.Printf("Read replica is unavailable, routing report queries to the primary: tables=%d err=%v", tables, err)
+		}
+	}
+	r.healthy = healthy
+	r.checkedAt = time.Now()
+	return healthy
 }
 
 // Ping checks if the database is reachable
@@ -74,11 +191,89 @@ func (d *Database) Ping() error {
 	return sqlDB.Ping()
 }
 
-// Close closes the database connection
+// Close closes the database connection and any read replica
 func (d *Database) Close() error {
+	if d.replica != nil {
+		if replicaDB, err := d.replica.db.DB(); err == nil {
+			replicaDB.Close()
+		}
+	}
 	sqlDB, err := d.DB.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 	return sqlDB.Close()
 }
+
+// busyAttempts is how many times a transaction is tried while the database is locked
+const busyAttempts = 3
+
+// Transaction runs fn in a transaction, retrying it with a short backoff when
+// the database stays locked longer than the busy timeout
+func (d *Database) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = d.DB.WithContext(ctx).Transaction(fn)
+		if !IsBusy(err) || attempt == busyAttempts {
+			return err
+		}
+		d.busyRetries.Add(1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+		}
+	}
+}
+
+// IsBusy reports whether err is SQLite refusing a query because another
+// connection holds a lock
+func IsBusy(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// PoolStats is the connection pool state of the primary or the read replica
+type PoolStats struct {
+	Name string
+	sql.DBStats
+}
+
+// PoolStats returns the connection pool state of each connection
+func (d *Database) PoolStats() []PoolStats {
+	var stats []PoolStats
+	if sqlDB, err := d.DB.DB(); err == nil {
+		stats = append(stats, PoolStats{Name: "primary", DBStats: sqlDB.Stats()})
+	}
+	if d.replica != nil {
+		if sqlDB, err := d.replica.db.DB(); err == nil {
+			stats = append(stats, PoolStats{Name: "replica", DBStats: sqlDB.Stats()})
+		}
+	}
+	return stats
+}
+
+// BusyRetries returns how many transactions have been retried because the
+// database was locked
+func (d *Database) BusyRetries() int64 {
+	return d.busyRetries.Load()
+}
+
+// ReplicaAvailable reports whether a read replica is configured and currently
+// serving queries
+func (d *Database) ReplicaAvailable() (configured, available bool) {
+	if d.replica == nil {
+		return false, false
+	}
+	return true, d.replica.available()
+}
+
+// SlowQueries summarizes the statements slower than the slow query threshold
+// since the server started, worst first by total time, or by "max" or "count"
+func (d *Database) SlowQueries(orderBy string, limit int) *SlowQueryReport {
+	return d.queries.report(orderBy, limit)
+}
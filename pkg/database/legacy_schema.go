@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// schemaObject is a table, index or trigger of a schema
+type schemaObject struct {
+	Type string
+	Name string
+	SQL  string
+}
+
+// schemaColumn is a column of a table
+type schemaColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default sql.NullString
+}
+
+// hasLegacySchema reports whether the database has tables but no recorded
+// migrations: it was migrated with AutoMigrate, before versioned migrations
+func hasLegacySchema(db *Database) (bool, error) {
+	var tables int64
+	if err := db.DB.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != ?", MigrationsTable).Scan(&tables).Error; err != nil {
+		return false, fmt.Errorf("failed to list tables: %w", err)
+	}
+	return tables > 0, nil
+}
+
+// upgradeLegacySchema brings a database migrated with AutoMigrate by an older
+// release to the baseline schema: it adds the tables, columns, indexes and
+// triggers the database is missing, as AutoMigrate would have. The baseline is
+// built in a scratch database to compare against, so the upgrade stays the
+// same however the models change later.
+func upgradeLegacySchema(db *Database, baseline string) error {
+	scratch, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return fmt.Errorf("failed to open scratch database: %w", err)
+	}
+	defer scratch.Close()
+	// Every connection to :memory: is a database of its own
+	scratch.SetMaxOpenConns(1)
+	if _, err := scratch.Exec(baseline); err != nil {
+		return fmt.Errorf("failed to build baseline schema: %w", err)
+	}
+
+	objects, err := listSchemaObjects(scratch)
+	if err != nil {
+		return err
+	}
+	baselineTables := make(map[string]string)
+	for _, object := range objects {
+		if object.Type == "table" {
+			baselineTables[object.Name] = object.SQL
+		}
+	}
+	if err := migrateTicketVersions(db, baselineTables["tickets"]); err != nil {
+		return err
+	}
+	if err := dropTicketForeignKeys(db, baselineTables); err != nil {
+		return err
+	}
+
+	rawDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	existing, err := listSchemaObjects(rawDB)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool)
+	for _, object := range existing {
+		present[object.Type+" "+object.Name] = true
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		rebuildSearch := false
+		for _, object := range objects {
+			if !present[object.Type+" "+object.Name] {
+				if err := tx.Exec(object.SQL).Error; err != nil {
+					return fmt.Errorf("failed to create %s %s: %w", object.Type, object.Name, err)
+				}
+				// The full-text index is filled from the tickets table once its
+				// triggers are in place
+				rebuildSearch = rebuildSearch || object.Type == "trigger" && strings.HasPrefix(object.Name, "tickets_fts_")
+				continue
+			}
+			if object.Type != "table" || strings.HasPrefix(object.SQL, "CREATE VIRTUAL TABLE") {
+				continue
+			}
+			if err := addMissingColumns(tx, scratch, object.Name); err != nil {
+				return err
+			}
+		}
+		if rebuildSearch {
+			if err := tx.Exec("INSERT INTO tickets_fts(tickets_fts) VALUES ('rebuild')").Error; err != nil {
+				return fmt.Errorf("failed to rebuild ticket search index: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// listSchemaObjects lists the tables, indexes and triggers of a database in
+// the order they were created, leaving out SQLite's own and the full-text
+// index's shadow tables
+func listSchemaObjects(db *sql.DB) ([]schemaObject, error) {
+	rows, err := db.Query("SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND name != ? AND NOT (type = 'table' AND name LIKE 'tickets_fts_%') ORDER BY rowid", MigrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []schemaObject
+	for rows.Next() {
+		var object schemaObject
+		if err := rows.Scan(&object.Type, &object.Name, &object.SQL); err != nil {
+			return nil, fmt.Errorf("failed to list schema: %w", err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+// addMissingColumns adds the columns of a baseline table the database's table
+// lacks. SQLite cannot add a NOT NULL column without a default, so those are
+// added as nullable, as AutoMigrate did.
+func addMissingColumns(tx *gorm.DB, scratch *sql.DB, table string) error {
+	var have []string
+	if err := tx.Raw("SELECT name FROM pragma_table_info(?)", table).Scan(&have).Error; err != nil {
+		return fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	present := make(map[string]bool)
+	for _, name := range have {
+		present[name] = true
+	}
+
+	rows, err := scratch.Query("SELECT name, type, \"notnull\", dflt_value FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return fmt.Errorf("failed to list baseline columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column schemaColumn
+		if err := rows.Scan(&column.Name, &column.Type, &column.NotNull, &column.Default); err != nil {
+			return fmt.Errorf("failed to list baseline columns of %s: %w", table, err)
+		}
+		if present[column.Name] {
+			continue
+		}
+
+		definition := fmt.Sprintf("`%s` %s", column.Name, column.Type)
+		if column.Default.Valid {
+			if column.NotNull {
+				definition += " NOT NULL"
+			}
+			definition += " DEFAULT " + column.Default.String
+		}
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", table, definition)).Error; err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", column.Name, table, err)
+		}
+	}
+	return rows.Err()
+}
+
+// migrateTicketVersions gives a tickets table from before versions had their
+// own ID a version_id primary key. SQLite cannot add a primary key column, so
+// the table is recreated with createTickets, the baseline's, and each existing
+// row keeps its id as its version ID.
+func migrateTicketVersions(db *Database, createTickets string) error {
+	migrator := db.DB.Migrator()
+	if !migrator.HasTable("tickets") || migrator.HasColumn("tickets", "version_id") {
+		return nil
+	}
+	log.Println("Adding version IDs to tickets...")
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return recreateTable(tx, "tickets", createTickets, "version_id", "id")
+	})
+}
+
+// dropTicketForeignKeys recreates the tables whose foreign keys reference the
+// ticket id, from before it was shared by every version of a ticket, as the
+// baseline creates them: without those foreign keys
+func dropTicketForeignKeys(db *Database, baselineTables map[string]string) error {
+	var tables []string
+	if err := db.DB.Raw("SELECT DISTINCT m.name FROM sqlite_master AS m, pragma_foreign_key_list(m.name) AS f WHERE m.type = 'table' AND f.\"table\" = 'tickets' AND f.\"to\" = 'id'").Scan(&tables).Error; err != nil {
+		return fmt.Errorf("failed to list foreign keys to tickets: %w", err)
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			create, ok := baselineTables[table]
+			if !ok {
+				continue
+			}
+			log.Printf("Dropping foreign keys to tickets from %s...", table)
+			if err := recreateTable(tx, table, create, "", ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// recreateTable replaces a table with the one create makes, copying over the
+// columns the two share. A column of the new table named by column is filled
+// from the old table's from column. The table's indexes and triggers are
+// dropped with it, for the baseline to create again.
+func recreateTable(tx *gorm.DB, table, create, column, from string) error {
+	// The triggers and the indexes go with the old table; their names must
+	// be free for the new one
+	var dropped []struct{ Type, Name string }
+	if err := tx.Raw("SELECT type, name FROM sqlite_master WHERE type IN ('trigger', 'index') AND tbl_name = ? AND sql IS NOT NULL", table).Scan(&dropped).Error; err != nil {
+		return fmt.Errorf("failed to list %s triggers and indexes: %w", table, err)
+	}
+	for _, object := range dropped {
+		if err := tx.Exec(fmt.Sprintf("DROP %s `%s`", strings.ToUpper(object.Type), object.Name)).Error; err != nil {
+			return fmt.Errorf("failed to drop %s %s: %w", object.Type, object.Name, err)
+		}
+	}
+
+	// The legacy rename leaves the foreign keys of other tables pointing at
+	// the table's name rather than following the old table
+	old := table + "_old"
+	for _, statement := range []string{
+		"PRAGMA legacy_alter_table = ON",
+		fmt.Sprintf("ALTER TABLE `%s` RENAME TO `%s`", table, old),
+		"PRAGMA legacy_alter_table = OFF",
+	} {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to rename %s table: %w", table, err)
+		}
+	}
+	if err := tx.Exec(create).Error; err != nil {
+		return fmt.Errorf("failed to create %s table: %w", table, err)
+	}
+
+	var columns []string
+	if err := tx.Raw("SELECT name FROM pragma_table_info(?) WHERE name IN (SELECT name FROM pragma_table_info(?))", old, table).Scan(&columns).Error; err != nil {
+		return fmt.Errorf("failed to list %s columns: %w", table, err)
+	}
+	into, selected := "`"+strings.Join(columns, "`, `")+"`", "`"+strings.Join(columns, "`, `")+"`"
+	if column != "" {
+		into, selected = "`"+column+"`, "+into, "`"+from+"`, "+selected
+	}
+	for _, statement := range []string{
+		fmt.Sprintf("INSERT INTO `%s` (%s) SELECT %s FROM `%s`", table, into, selected, old),
+		fmt.Sprintf("DROP TABLE `%s`", old),
+	} {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to copy %s: %w", table, err)
+		}
+	}
+	return nil
+}
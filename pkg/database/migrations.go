@@ -1,18 +1,265 @@
 package database
 
 import (
+	"embed"
 	"fmt"
+	"io/fs"
 	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"gorm.io/gorm"
 )
 
-// RunMigrations runs all database migrations
+// migrationFiles are the versioned migrations, a pair of NNNN_name.up.sql and
+// NNNN_name.down.sql files per version. A migration, once released, must not
+// change; change the schema with a new one. Models() must stay in step with
+// the schema they build.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one version of the schema
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// loadMigrations reads the embedded migrations, in version order
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		number, title, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || !found || err != nil || version <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration file %s is not named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// schemaMigration records a migration applied to the database
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName specifies the table name for the schemaMigration model
+func (schemaMigration) TableName() string {
+	return MigrationsTable
+}
+
+// MigrationsTable records the migrations applied to the database. It is not
+// part of any migration, and is kept when the demo data is reset.
+const MigrationsTable = "schema_migrations"
+
+// RunMigrations migrates the database to the latest version. It fails if the
+// database was migrated past the versions this build knows.
 func RunMigrations(db *Database) error {
 	log.Println("Running database migrations...")
 
-	// Auto migrate all models
-	err := db.DB.AutoMigrate(
+	// Team membership uses an explicit join model so it can carry its own columns
+	if err := db.DB.SetupJoinTable(&models.Team{}, "Members", &models.TeamMember{}); err != nil {
+		return fmt.Errorf("failed to set up team members join table: %w", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := MigrationVersion(db)
+	if err != nil {
+		return err
+	}
+	if latest := len(migrations); current > latest {
+		return fmt.Errorf("database schema is at version %d, newer than the latest migration of this build, %d", current, latest)
+	}
+	if err := migrate(db, migrations, len(migrations)); err != nil {
+		return err
+	}
+	if err := repairTicketSearch(db); err != nil {
+		return err
+	}
+
+	log.Println("Database migrations completed successfully")
+	return nil
+}
+
+// ticketSearchTriggers keep the tickets_fts index in step with the tickets table
+var ticketSearchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS tickets_fts_insert AFTER INSERT ON tickets BEGIN
+		INSERT INTO tickets_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tickets_fts_delete AFTER DELETE ON tickets BEGIN
+		INSERT INTO tickets_fts(tickets_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tickets_fts_update AFTER UPDATE OF title, description ON tickets BEGIN
+		INSERT INTO tickets_fts(tickets_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+		INSERT INTO tickets_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END`,
+}
+
+// repairTicketSearch puts back the triggers keeping the full-text index over
+// ticket titles and descriptions in step with the tickets table when any is
+// missing, such as after the table was recreated by hand, and rebuilds the
+// index from the table.
+func repairTicketSearch(db *Database) error {
+	var triggers int64
+	if err := db.DB.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'tickets_fts_%'").Scan(&triggers).Error; err != nil {
+		return fmt.Errorf("failed to check ticket search index: %w", err)
+	}
+
+	statements := append([]string{"CREATE VIRTUAL TABLE IF NOT EXISTS tickets_fts USING fts5(title, description, content='tickets')"}, ticketSearchTriggers...)
+	if triggers < int64(len(ticketSearchTriggers)) {
+		statements = append(statements, "INSERT INTO tickets_fts(tickets_fts) VALUES ('rebuild')")
+	}
+	for _, statement := range statements {
+		if err := db.DB.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create ticket search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// MigrateTo runs the up migrations, or rolls back with the down migrations,
+// until the database schema is at version; 0 rolls every migration back
+func MigrateTo(db *Database, version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if version < 0 || version > len(migrations) {
+		return fmt.Errorf("no migration version %d; versions go from 0 to %d", version, len(migrations))
+	}
+	return migrate(db, migrations, version)
+}
+
+// MigrationVersion returns the version the database schema is at: its latest
+// applied migration, or 0 for none
+func MigrationVersion(db *Database) (int, error) {
+	if !db.DB.Migrator().HasTable(MigrationsTable) {
+		return 0, nil
+	}
+	var version int
+	if err := db.DB.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// migrate applies or rolls back migrations, each in its own transaction, until
+// the schema is at target
+func migrate(db *Database, migrations []migration, target int) error {
+	if err := db.DB.Exec("CREATE TABLE IF NOT EXISTS `" + MigrationsTable + "` (`version` integer PRIMARY KEY, `name` text NOT NULL, `applied_at` datetime NOT NULL)").Error; err != nil {
+		return fmt.Errorf("failed to create %s table: %w", MigrationsTable, err)
+	}
+	current, err := MigrationVersion(db)
+	if err != nil {
+		return err
+	}
+
+	// A database migrated by AutoMigrate, from before versioned migrations, is
+	// brought to the baseline and recorded as being at version 1
+	if current == 0 {
+		legacy, err := hasLegacySchema(db)
+		if err != nil {
+			return err
+		}
+		if legacy {
+			log.Println("Upgrading a database from before versioned migrations to the baseline schema...")
+			if err := upgradeLegacySchema(db, migrations[0].Up); err != nil {
+				return err
+			}
+			if err := db.DB.Create(&schemaMigration{Version: 1, Name: migrations[0].Name, AppliedAt: time.Now().UTC()}).Error; err != nil {
+				return fmt.Errorf("failed to record baseline migration: %w", err)
+			}
+			current = 1
+		}
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current || m.Version > target {
+			continue
+		}
+		log.Printf("Applying migration %d %s...", m.Version, m.Name)
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d %s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		log.Printf("Rolling back migration %d %s...", m.Version, m.Name)
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to roll back migration %d %s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	log.Printf("Database schema is at version %d", target)
+	return nil
+}
+
+// Models are the models stored in the database. The migrations must create
+// and keep their tables as AutoMigrate would, which the tests check.
+func Models() []any {
+	return []any{
 		&models.User{},
 		&models.PasswordResetToken{},
 		&models.EmailVerificationToken{},
@@ -20,15 +267,53 @@ func RunMigrations(db *Database) error {
 		&models.Ticket{},
 		&models.Comment{},
 		&models.Attachment{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+		&models.TicketEscalationEvent{},
+		&models.TicketAssignment{},
+		&models.TicketView{},
+		&models.TicketPin{},
+		&models.TicketSubscriber{},
+		&models.QuarantinedSubmission{},
+		&models.AuditLog{},
+		&models.LoginEvent{},
+		&models.Team{},
+		&models.OutOfOffice{},
+		&models.Asset{},
+		&models.TicketAsset{},
+		&models.Problem{},
+		&models.ProblemIncident{},
+		&models.StatusBanner{},
+		&models.BannerSubscription{},
+		&models.ChatTrigger{},
+		&models.KnowledgeArticle{},
+		&models.ChatSession{},
+		&models.ChatMessage{},
+		&models.ChatQueueEvent{},
+		&models.ChatParticipant{},
+		&models.DataExport{},
+		&models.EmailTemplate{},
+		&models.EmailTemplateTranslation{},
+		&models.EmailDelivery{},
+		&models.EmailSuppression{},
+		&models.QueuedNotification{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.FeatureUsage{},
+		&models.FailedTask{},
+		&models.DraftAttachment{},
+		&models.OffboardingJob{},
+		&models.Sandbox{},
+		&models.InboundIntegration{},
+		&models.InboundTicketLink{},
+		&models.InboundAlert{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 	}
-
-	log.Println("Database migrations completed successfully")
-	return nil
 }
 
+// DefaultPasswordHash is the bcrypt hash of "password", the password of the
+// seeded users
+const DefaultPasswordHash = "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"
+
 // SeedDatabase seeds the database with initial data
 func SeedDatabase(db *Database) error {
 	log.Println("Seeding database with initial data...")
@@ -44,7 +329,7 @@ func SeedDatabase(db *Database) error {
 	// Create default admin user
 	adminUser := &models.User{
 		Email:        "admin@helpchat.com",
-		PasswordHash: "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // "password"
+		PasswordHash: DefaultPasswordHash,
 		FirstName:    "Admin",
 		LastName:     "User",
 		Role:         models.RoleAdministrator,
@@ -57,7 +342,20 @@ func SeedDatabase(db *Database) error {
 	}
 
 	// Create default categories
-	categories := []models.Category{
+	categories := DefaultCategories()
+	for _, category := range categories {
+		if err := db.DB.Create(&category).Error; err != nil {
+			return fmt.Errorf("failed to create category %s: %w", category.Name, err)
+		}
+	}
+
+	log.Println("Database seeded successfully")
+	return nil
+}
+
+// DefaultCategories are the ticket categories a new database starts with
+func DefaultCategories() []models.Category {
+	return []models.Category{
 		{
 			Name:        "Technical Support",
 			Description: "Technical issues and troubleshooting",
@@ -84,15 +382,6 @@ func SeedDatabase(db *Database) error {
 			IsActive:    true,
 		},
 	}
-
-	for _, category := range categories {
-		if err := db.DB.Create(&category).Error; err != nil {
-			return fmt.Errorf("failed to create category %s: %w", category.Name, err)
-		}
-	}
-
-	log.Println("Database seeded successfully")
-	return nil
 }
 
 // CreateIndexes creates database indexes for better performance
@@ -129,6 +418,13 @@ func CreateIndexes(db *Database) error {
 		"CREATE INDEX IF NOT EXISTS idx_attachments_ticket_id ON attachments(ticket_id)",
 		"CREATE INDEX IF NOT EXISTS idx_attachments_uploaded_by_id ON attachments(uploaded_by_id)",
 		"CREATE INDEX IF NOT EXISTS idx_attachments_created_at ON attachments(created_at)",
+		// Case-insensitive indexes let prefix LIKE searches for typeahead use an index
+		"CREATE INDEX IF NOT EXISTS idx_tickets_title_nocase ON tickets(title COLLATE NOCASE) WHERE expiration_time IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_users_email_nocase ON users(email COLLATE NOCASE)",
+		"CREATE INDEX IF NOT EXISTS idx_users_first_name_nocase ON users(first_name COLLATE NOCASE)",
+		"CREATE INDEX IF NOT EXISTS idx_users_last_name_nocase ON users(last_name COLLATE NOCASE)",
+		"CREATE INDEX IF NOT EXISTS idx_categories_name_nocase ON categories(name COLLATE NOCASE)",
+		"CREATE INDEX IF NOT EXISTS idx_knowledge_articles_title_nocase ON knowledge_articles(title COLLATE NOCASE)",
 	}
 
 	for _, index := range indexes {
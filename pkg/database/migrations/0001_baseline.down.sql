@@ -0,0 +1,46 @@
+DROP TRIGGER IF EXISTS `tickets_fts_update`;
+DROP TRIGGER IF EXISTS `tickets_fts_delete`;
+DROP TRIGGER IF EXISTS `tickets_fts_insert`;
+DROP TABLE IF EXISTS `tickets_fts`;
+DROP TABLE IF EXISTS `sandboxes`;
+DROP TABLE IF EXISTS `offboarding_jobs`;
+DROP TABLE IF EXISTS `draft_attachments`;
+DROP TABLE IF EXISTS `failed_tasks`;
+DROP TABLE IF EXISTS `feature_usage`;
+DROP TABLE IF EXISTS `queued_notifications`;
+DROP TABLE IF EXISTS `email_suppressions`;
+DROP TABLE IF EXISTS `email_deliveries`;
+DROP TABLE IF EXISTS `email_template_translations`;
+DROP TABLE IF EXISTS `email_templates`;
+DROP TABLE IF EXISTS `data_exports`;
+DROP TABLE IF EXISTS `chat_participants`;
+DROP TABLE IF EXISTS `chat_queue_events`;
+DROP TABLE IF EXISTS `chat_messages`;
+DROP TABLE IF EXISTS `chat_sessions`;
+DROP TABLE IF EXISTS `knowledge_articles`;
+DROP TABLE IF EXISTS `chat_triggers`;
+DROP TABLE IF EXISTS `banner_subscriptions`;
+DROP TABLE IF EXISTS `status_banner_categories`;
+DROP TABLE IF EXISTS `status_banners`;
+DROP TABLE IF EXISTS `problem_incidents`;
+DROP TABLE IF EXISTS `problems`;
+DROP TABLE IF EXISTS `ticket_assets`;
+DROP TABLE IF EXISTS `assets`;
+DROP TABLE IF EXISTS `out_of_office`;
+DROP TABLE IF EXISTS `team_members`;
+DROP TABLE IF EXISTS `login_events`;
+DROP TABLE IF EXISTS `audit_logs`;
+DROP TABLE IF EXISTS `quarantined_submissions`;
+DROP TABLE IF EXISTS `ticket_subscribers`;
+DROP TABLE IF EXISTS `ticket_pins`;
+DROP TABLE IF EXISTS `ticket_views`;
+DROP TABLE IF EXISTS `ticket_assignments`;
+DROP TABLE IF EXISTS `ticket_escalation_events`;
+DROP TABLE IF EXISTS `attachments`;
+DROP TABLE IF EXISTS `comments`;
+DROP TABLE IF EXISTS `tickets`;
+DROP TABLE IF EXISTS `teams`;
+DROP TABLE IF EXISTS `categories`;
+DROP TABLE IF EXISTS `email_verification_tokens`;
+DROP TABLE IF EXISTS `password_reset_tokens`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,119 @@
+-- The schema of the last release migrated with AutoMigrate, the baseline
+-- later migrations build on
+CREATE TABLE `users` (`id` char(36),`email` text NOT NULL,`password_hash` text NOT NULL,`first_name` text NOT NULL,`last_name` text NOT NULL,`role` text NOT NULL DEFAULT "END_USER",`is_verified` numeric DEFAULT false,`is_active` numeric DEFAULT true,`last_login_at` datetime,`created_at` datetime,`updated_at` datetime,`created_by` char(36),`updated_by` char(36),`language` text,`timezone` text,`quiet_hours_start` text,`quiet_hours_end` text,`email_failing_since` datetime,`max_concurrent_chats` integer,`anonymized_at` datetime,`sandbox_id` char(36),`demo` numeric NOT NULL DEFAULT false,PRIMARY KEY (`id`));
+CREATE INDEX `idx_users_sandbox_id` ON `users`(`sandbox_id`);
+CREATE UNIQUE INDEX `idx_users_email` ON `users`(`email`);
+CREATE TABLE `password_reset_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` char(36) NOT NULL,`token` text NOT NULL,`expires_at` datetime NOT NULL,`used` numeric DEFAULT false,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_password_reset_tokens_token` ON `password_reset_tokens`(`token`);
+CREATE TABLE `email_verification_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` char(36) NOT NULL,`token` text NOT NULL,`expires_at` datetime NOT NULL,`used` numeric DEFAULT false,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_email_verification_tokens_token` ON `email_verification_tokens`(`token`);
+CREATE TABLE `categories` (`id` char(36),`name` text NOT NULL,`description` text,`parent_id` char(36),`is_active` numeric DEFAULT true,`required_resolution_fields` text,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_categories_children` FOREIGN KEY (`parent_id`) REFERENCES `categories`(`id`));
+CREATE TABLE `teams` (`id` char(36),`name` text NOT NULL,`description` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX `idx_teams_name` ON `teams`(`name`);
+CREATE TABLE `tickets` (`version_id` char(36),`id` char(36) NOT NULL,`creation_time` datetime NOT NULL,`expiration_time` datetime,`deleted_at` datetime,`deleted_by_id` char(36),`sandbox_id` char(36),`title` text NOT NULL,`description` text NOT NULL,`status` text NOT NULL DEFAULT "OPEN",`priority` text NOT NULL DEFAULT "MEDIUM",`category_id` char(36),`assigned_agent_id` char(36),`team_id` char(36),`created_by_id` char(36) NOT NULL,`opened_by` char(36),`escalated_at` datetime,`escalated_to` char(36),`escalation_reason` text,`resolved_at` datetime,`due_date` datetime,`resolution_code` text,`root_cause` text,`root_cause_code` text,`knowledge_article_id` char(36),PRIMARY KEY (`version_id`),CONSTRAINT `fk_categories_tickets` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`),CONSTRAINT `fk_tickets_assigned_agent` FOREIGN KEY (`assigned_agent_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_tickets_opened_by_user` FOREIGN KEY (`opened_by`) REFERENCES `users`(`id`),CONSTRAINT `fk_tickets_escalated_to_user` FOREIGN KEY (`escalated_to`) REFERENCES `users`(`id`),CONSTRAINT `fk_tickets_team` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`),CONSTRAINT `fk_tickets_created_by` FOREIGN KEY (`created_by_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_tickets_root_cause_code` ON `tickets`(`root_cause_code`);
+CREATE INDEX `idx_tickets_team_id` ON `tickets`(`team_id`);
+CREATE INDEX `idx_tickets_sandbox_id` ON `tickets`(`sandbox_id`);
+CREATE INDEX `idx_tickets_deleted_at` ON `tickets`(`deleted_at`);
+CREATE INDEX `idx_tickets_expiration_time` ON `tickets`(`expiration_time`);
+CREATE INDEX `idx_tickets_id` ON `tickets`(`id`);
+CREATE TABLE `comments` (`id` char(36),`ticket_id` char(36) NOT NULL,`user_id` char(36) NOT NULL,`content` text NOT NULL,`format` text NOT NULL DEFAULT "plain",`is_internal` numeric DEFAULT false,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE TABLE `attachments` (`id` char(36),`ticket_id` char(36) NOT NULL,`comment_id` char(36),`filename` text NOT NULL,`file_path` text NOT NULL,`file_size` integer NOT NULL,`mime_type` text NOT NULL,`uploaded_by_id` char(36) NOT NULL,`is_virus_scanned` numeric DEFAULT false,`is_safe` numeric DEFAULT false,`inline` numeric NOT NULL DEFAULT false,`width` integer,`height` integer,`thumbnail_status` text,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_attachments_uploaded_by` FOREIGN KEY (`uploaded_by_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_comments_attachments` FOREIGN KEY (`comment_id`) REFERENCES `comments`(`id`));
+CREATE INDEX `idx_attachments_thumbnail_status` ON `attachments`(`thumbnail_status`);
+CREATE INDEX `idx_attachments_comment_id` ON `attachments`(`comment_id`);
+CREATE TABLE `ticket_escalation_events` (`id` char(36),`ticket_id` char(36) NOT NULL,`action` text NOT NULL,`actor_id` char(36) NOT NULL,`target_id` char(36),`note` text,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_ticket_escalation_events_actor` FOREIGN KEY (`actor_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_ticket_escalation_events_target` FOREIGN KEY (`target_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_ticket_escalation_events_ticket_id` ON `ticket_escalation_events`(`ticket_id`);
+CREATE TABLE `ticket_assignments` (`id` char(36),`ticket_id` char(36) NOT NULL,`agent_id` char(36),`previous_agent_id` char(36),`assigned_by_id` char(36) NOT NULL,`note` text,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_ticket_assignments_agent` FOREIGN KEY (`agent_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_ticket_assignments_assigned_by` FOREIGN KEY (`assigned_by_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_ticket_assignments_ticket_id` ON `ticket_assignments`(`ticket_id`);
+CREATE TABLE `ticket_views` (`user_id` char(36),`ticket_id` char(36),`viewed_at` datetime NOT NULL,PRIMARY KEY (`user_id`,`ticket_id`));
+CREATE INDEX `idx_ticket_views_viewed_at` ON `ticket_views`(`viewed_at`);
+CREATE TABLE `ticket_pins` (`user_id` char(36),`ticket_id` char(36),`created_at` datetime,PRIMARY KEY (`user_id`,`ticket_id`));
+CREATE TABLE `ticket_subscribers` (`id` char(36),`ticket_id` char(36) NOT NULL,`email` text NOT NULL,`name` text,`added_by_id` char(36) NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_ticket_subscribers_ticket_id` ON `ticket_subscribers`(`ticket_id`);
+CREATE TABLE `quarantined_submissions` (`id` char(36),`source` text NOT NULL,`name` text,`email` text NOT NULL,`title` text NOT NULL,`description` text NOT NULL,`priority` text NOT NULL DEFAULT "MEDIUM",`category_id` char(36),`remote_ip` text,`spam_score` real,`spam_reasons` text,`status` text NOT NULL DEFAULT "PENDING",`reviewed_by_id` char(36),`reviewed_at` datetime,`ticket_id` char(36),`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_quarantined_submissions_status` ON `quarantined_submissions`(`status`);
+CREATE TABLE `audit_logs` (`id` char(36),`actor_id` char(36),`action` text NOT NULL,`entity_type` text NOT NULL,`entity_id` char(36),`details` text,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_audit_logs_created_at` ON `audit_logs`(`created_at`);
+CREATE INDEX `idx_audit_logs_entity_id` ON `audit_logs`(`entity_id`);
+CREATE INDEX `idx_audit_logs_action` ON `audit_logs`(`action`);
+CREATE INDEX `idx_audit_logs_actor_id` ON `audit_logs`(`actor_id`);
+CREATE TABLE `login_events` (`id` char(36),`user_id` char(36) NOT NULL,`ip_address` text,`user_agent` text,`device` text,`location` text,`new_device` numeric,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_login_events_created_at` ON `login_events`(`created_at`);
+CREATE INDEX `idx_login_events_user_id` ON `login_events`(`user_id`);
+CREATE TABLE `team_members` (`team_id` char(36),`user_id` char(36),`created_at` datetime,PRIMARY KEY (`team_id`,`user_id`),CONSTRAINT `fk_team_members_team` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`),CONSTRAINT `fk_team_members_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_team_members_user_id` ON `team_members`(`user_id`);
+CREATE TABLE `out_of_office` (`id` char(36),`user_id` char(36) NOT NULL,`delegate_id` char(36) NOT NULL,`starts_at` datetime NOT NULL,`ends_at` datetime NOT NULL,`note` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_out_of_office_delegate` FOREIGN KEY (`delegate_id`) REFERENCES `users`(`id`));
+CREATE UNIQUE INDEX `idx_out_of_office_user_id` ON `out_of_office`(`user_id`);
+CREATE TABLE `assets` (`id` char(36),`name` text NOT NULL,`type` text NOT NULL,`serial_number` text,`owner_id` char(36),`notes` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_assets_owner` FOREIGN KEY (`owner_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_assets_owner_id` ON `assets`(`owner_id`);
+CREATE UNIQUE INDEX `idx_assets_serial_number` ON `assets`(`serial_number`);
+CREATE INDEX `idx_assets_type` ON `assets`(`type`);
+CREATE TABLE `ticket_assets` (`ticket_id` char(36),`asset_id` char(36),`created_at` datetime,PRIMARY KEY (`ticket_id`,`asset_id`));
+CREATE INDEX `idx_ticket_assets_asset_id` ON `ticket_assets`(`asset_id`);
+CREATE TABLE `problems` (`id` char(36),`title` text NOT NULL,`description` text,`status` text NOT NULL DEFAULT "OPEN",`resolution` text,`created_by_id` char(36) NOT NULL,`closed_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_problems_created_by` FOREIGN KEY (`created_by_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_problems_status` ON `problems`(`status`);
+CREATE TABLE `problem_incidents` (`ticket_id` char(36),`problem_id` char(36) NOT NULL,`created_at` datetime,PRIMARY KEY (`ticket_id`));
+CREATE INDEX `idx_problem_incidents_problem_id` ON `problem_incidents`(`problem_id`);
+CREATE TABLE `status_banners` (`id` char(36),`message` text NOT NULL,`severity` text NOT NULL DEFAULT "INFO",`starts_at` datetime NOT NULL,`ends_at` datetime,`problem_id` char(36),`resolution` text,`resolved_at` datetime,`created_by_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_status_banners_ends_at` ON `status_banners`(`ends_at`);
+CREATE INDEX `idx_status_banners_starts_at` ON `status_banners`(`starts_at`);
+CREATE TABLE `status_banner_categories` (`status_banner_id` char(36),`category_id` char(36),PRIMARY KEY (`status_banner_id`,`category_id`),CONSTRAINT `fk_status_banner_categories_status_banner` FOREIGN KEY (`status_banner_id`) REFERENCES `status_banners`(`id`),CONSTRAINT `fk_status_banner_categories_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`));
+CREATE TABLE `banner_subscriptions` (`id` char(36),`banner_id` char(36) NOT NULL,`email` text NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_banner_subscriptions_banner_id` ON `banner_subscriptions`(`banner_id`);
+CREATE TABLE `chat_triggers` (`id` char(36),`name` text NOT NULL,`enabled` numeric NOT NULL,`url_pattern` text NOT NULL,`min_seconds_on_page` integer NOT NULL DEFAULT 0,`visitor_type` text NOT NULL DEFAULT "ANY",`greeting` text NOT NULL,`priority` integer NOT NULL DEFAULT 0,`created_by_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_chat_triggers_enabled` ON `chat_triggers`(`enabled`);
+CREATE TABLE `knowledge_articles` (`id` char(36),`title` text NOT NULL,`body` text NOT NULL,`keywords` text,`is_published` numeric NOT NULL DEFAULT false,`author_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_knowledge_articles_is_published` ON `knowledge_articles`(`is_published`);
+CREATE TABLE `chat_sessions` (`id` char(36),`status` text NOT NULL DEFAULT "BOT",`bot_step` text,`visitor_name` text,`visitor_email` text,`summary` text,`remote_ip` text,`agent_id` char(36),`team_id` char(36),`transferred_from_id` char(36),`ticket_id` char(36),`queued_at` datetime,`accepted_at` datetime,`ended_at` datetime,`created_at` datetime,`updated_at` datetime,`transcript_requested` numeric NOT NULL DEFAULT false,`transcript_sent_at` datetime,`rating` integer,`rating_comment` text,`rated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_chat_sessions_agent` FOREIGN KEY (`agent_id`) REFERENCES `users`(`id`));
+CREATE INDEX `idx_chat_sessions_rated_at` ON `chat_sessions`(`rated_at`);
+CREATE INDEX `idx_chat_sessions_ended_at` ON `chat_sessions`(`ended_at`);
+CREATE INDEX `idx_chat_sessions_team_id` ON `chat_sessions`(`team_id`);
+CREATE INDEX `idx_chat_sessions_agent_id` ON `chat_sessions`(`agent_id`);
+CREATE INDEX `idx_chat_sessions_status` ON `chat_sessions`(`status`);
+CREATE TABLE `chat_messages` (`id` char(36),`session_id` char(36) NOT NULL,`sender_type` text NOT NULL,`sender_id` char(36),`content` text NOT NULL,`internal` numeric NOT NULL DEFAULT false,`delivered_at` datetime,`read_at` datetime,`created_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_chat_sessions_messages` FOREIGN KEY (`session_id`) REFERENCES `chat_sessions`(`id`));
+CREATE INDEX `idx_chat_messages_session_id` ON `chat_messages`(`session_id`);
+CREATE TABLE `chat_queue_events` (`id` char(36),`session_id` char(36) NOT NULL,`type` text NOT NULL,`wait_seconds` integer NOT NULL DEFAULT 0,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_chat_queue_events_created_at` ON `chat_queue_events`(`created_at`);
+CREATE INDEX `idx_chat_queue_events_type` ON `chat_queue_events`(`type`);
+CREATE INDEX `idx_chat_queue_events_session_id` ON `chat_queue_events`(`session_id`);
+CREATE TABLE `chat_participants` (`session_id` char(36),`user_id` char(36),`mode` text NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`session_id`,`user_id`),CONSTRAINT `fk_chat_participants_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_chat_sessions_participants` FOREIGN KEY (`session_id`) REFERENCES `chat_sessions`(`id`));
+CREATE TABLE `data_exports` (`id` char(36),`day` text NOT NULL,`files` text,`rows` integer NOT NULL,`triggered_by_id` char(36),`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX `idx_data_exports_day` ON `data_exports`(`day`);
+CREATE TABLE `email_templates` (`name` text,`subject` text NOT NULL,`text` text NOT NULL,`updated_by_id` char(36),`updated_at` datetime,PRIMARY KEY (`name`));
+CREATE TABLE `email_template_translations` (`name` text,`language` text,`subject` text NOT NULL,`text` text NOT NULL,`updated_by_id` char(36),`updated_at` datetime,PRIMARY KEY (`name`,`language`));
+CREATE TABLE `email_deliveries` (`id` char(36),`message_id` text NOT NULL,`recipient` text NOT NULL,`subject` text,`status` text NOT NULL,`bounce_type` text,`detail` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_email_deliveries_created_at` ON `email_deliveries`(`created_at`);
+CREATE INDEX `idx_email_deliveries_status` ON `email_deliveries`(`status`);
+CREATE INDEX `idx_email_deliveries_recipient` ON `email_deliveries`(`recipient`);
+CREATE INDEX `idx_email_deliveries_message_id` ON `email_deliveries`(`message_id`);
+CREATE TABLE `email_suppressions` (`address` text,`reason` text NOT NULL,`delivery_id` char(36),`created_at` datetime,PRIMARY KEY (`address`));
+CREATE TABLE `queued_notifications` (`id` char(36),`user_id` char(36) NOT NULL,`to` text NOT NULL,`subject` text,`text` text,`html` text,`reply_to` text,`headers` text,`deliver_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_queued_notifications_deliver_at` ON `queued_notifications`(`deliver_at`);
+CREATE INDEX `idx_queued_notifications_user_id` ON `queued_notifications`(`user_id`);
+CREATE TABLE `feature_usage` (`hour` datetime NOT NULL,`method` text NOT NULL,`route` text NOT NULL,`user_id` char(36) NOT NULL,`role` text,`requests` integer NOT NULL DEFAULT 0,`errors` integer NOT NULL DEFAULT 0,`latency_micros` integer NOT NULL DEFAULT 0,`max_latency_micros` integer NOT NULL DEFAULT 0);
+CREATE INDEX `idx_feature_usage_user_id` ON `feature_usage`(`user_id`);
+CREATE UNIQUE INDEX `idx_feature_usage_bucket` ON `feature_usage`(`hour`,`method`,`route`,`user_id`);
+CREATE TABLE `failed_tasks` (`id` char(36),`kind` text NOT NULL,`name` text NOT NULL,`payload` text,`error` text,`failures` integer NOT NULL DEFAULT 1,`last_failed_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_failed_tasks_created_at` ON `failed_tasks`(`created_at`);
+CREATE INDEX `idx_failed_tasks_kind` ON `failed_tasks`(`kind`);
+CREATE TABLE `draft_attachments` (`id` char(36),`filename` text NOT NULL,`file_path` text NOT NULL,`file_size` integer NOT NULL,`mime_type` text NOT NULL,`inline` numeric NOT NULL DEFAULT false,`uploaded_by_id` char(36) NOT NULL,`expires_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_draft_attachments_expires_at` ON `draft_attachments`(`expires_at`);
+CREATE INDEX `idx_draft_attachments_uploaded_by_id` ON `draft_attachments`(`uploaded_by_id`);
+CREATE TABLE `offboarding_jobs` (`id` char(36),`user_id` char(36) NOT NULL,`status` text NOT NULL,`team_id` char(36),`requested_by_id` char(36) NOT NULL,`tickets_released` integer,`comments_attributed` integer,`error` text,`started_at` datetime,`completed_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_offboarding_jobs_created_at` ON `offboarding_jobs`(`created_at`);
+CREATE INDEX `idx_offboarding_jobs_status` ON `offboarding_jobs`(`status`);
+CREATE INDEX `idx_offboarding_jobs_user_id` ON `offboarding_jobs`(`user_id`);
+CREATE TABLE `sandboxes` (`id` char(36),`agent_id` char(36) NOT NULL,`tickets` integer,`expires_at` datetime NOT NULL,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_sandboxes_expires_at` ON `sandboxes`(`expires_at`);
+CREATE UNIQUE INDEX `idx_sandboxes_agent_id` ON `sandboxes`(`agent_id`);
+CREATE VIRTUAL TABLE tickets_fts USING fts5(title, description, content='tickets');
+CREATE TRIGGER tickets_fts_insert AFTER INSERT ON tickets BEGIN
+		INSERT INTO tickets_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END;
+CREATE TRIGGER tickets_fts_delete AFTER DELETE ON tickets BEGIN
+		INSERT INTO tickets_fts(tickets_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+	END;
+CREATE TRIGGER tickets_fts_update AFTER UPDATE OF title, description ON tickets BEGIN
+		INSERT INTO tickets_fts(tickets_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+		INSERT INTO tickets_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END;
@@ -0,0 +1,6 @@
+-- In-app notifications and the channels each user is notified on
+CREATE TABLE `notifications` (`id` char(36),`user_id` char(36) NOT NULL,`type` text NOT NULL,`ticket_id` char(36) NOT NULL,`actor_id` char(36),`title` text NOT NULL,`body` text,`read_at` datetime,`created_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_notifications_created_at` ON `notifications`(`created_at`);
+CREATE INDEX `idx_notifications_ticket_id` ON `notifications`(`ticket_id`);
+CREATE INDEX `idx_notifications_user_id` ON `notifications`(`user_id`);
+CREATE TABLE `notification_preferences` (`user_id` char(36),`type` text,`email` numeric,`in_app` numeric,`updated_at` datetime,PRIMARY KEY (`user_id`,`type`));
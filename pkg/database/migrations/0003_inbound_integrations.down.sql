@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `inbound_ticket_links`;
+DROP TABLE IF EXISTS `inbound_integrations`;
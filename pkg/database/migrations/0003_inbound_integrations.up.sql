@@ -0,0 +1,6 @@
+-- Keyed webhooks external systems post JSON to, and the tickets their payloads opened
+CREATE TABLE `inbound_integrations` (`id` char(36),`key` text NOT NULL,`name` text NOT NULL,`enabled` numeric NOT NULL,`secret` text NOT NULL,`requester_id` char(36) NOT NULL,`category_id` char(36),`team_id` char(36),`mapping` text,`last_received_at` datetime,`created_by_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE UNIQUE INDEX `idx_inbound_integrations_key` ON `inbound_integrations`(`key`);
+CREATE TABLE `inbound_ticket_links` (`id` char(36),`integration_id` char(36) NOT NULL,`external_id` text NOT NULL,`ticket_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_inbound_ticket_links_ticket_id` ON `inbound_ticket_links`(`ticket_id`);
+CREATE UNIQUE INDEX `idx_inbound_ticket_links_external` ON `inbound_ticket_links`(`integration_id`,`external_id`);
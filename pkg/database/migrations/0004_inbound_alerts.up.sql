@@ -0,0 +1,5 @@
+-- Alerts inbound integrations were notified about by Prometheus Alertmanager
+CREATE TABLE `inbound_alerts` (`id` char(36),`integration_id` char(36) NOT NULL,`fingerprint` text NOT NULL,`group_key` text NOT NULL,`ticket_id` char(36) NOT NULL,`name` text,`firing` numeric NOT NULL,`starts_at` datetime,`ends_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_inbound_alerts_ticket_id` ON `inbound_alerts`(`ticket_id`);
+CREATE INDEX `idx_inbound_alerts_group_key` ON `inbound_alerts`(`group_key`);
+CREATE UNIQUE INDEX `idx_inbound_alerts_fingerprint` ON `inbound_alerts`(`integration_id`,`fingerprint`);
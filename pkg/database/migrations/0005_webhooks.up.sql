@@ -0,0 +1,7 @@
+-- Outbound webhook subscriptions and the log of events delivered to them
+CREATE TABLE `webhook_subscriptions` (`id` char(36),`url` text NOT NULL,`description` text,`events` text NOT NULL,`enabled` numeric NOT NULL,`secret` text NOT NULL,`created_by_id` char(36) NOT NULL,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE TABLE `webhook_deliveries` (`id` char(36),`subscription_id` char(36) NOT NULL,`event` text NOT NULL,`payload` text NOT NULL,`status` text NOT NULL,`attempts` integer NOT NULL,`next_attempt_at` datetime,`last_attempt_at` datetime,`response_status` integer,`last_error` text,`delivered_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));
+CREATE INDEX `idx_webhook_deliveries_created_at` ON `webhook_deliveries`(`created_at`);
+CREATE INDEX `idx_webhook_deliveries_next_attempt_at` ON `webhook_deliveries`(`next_attempt_at`);
+CREATE INDEX `idx_webhook_deliveries_status` ON `webhook_deliveries`(`status`);
+CREATE INDEX `idx_webhook_deliveries_subscription_id` ON `webhook_deliveries`(`subscription_id`);
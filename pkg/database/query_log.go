@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/requestid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// maxSlowQueryShapes bounds how many distinct slow statements are summarized
+const maxSlowQueryShapes = 500
+
+var (
+	sqlBlob       = regexp.MustCompile(`(?i)\bx'[0-9a-f]*'`)
+	sqlString     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumber     = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlList       = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	sqlWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// SlowQuery summarizes every slow run of one statement shape
+type SlowQuery struct {
+	// SQL is the statement with its literal values replaced by ?
+	SQL           string    `json:"sql"`
+	Count         int64     `json:"count"`
+	TotalMs       float64   `json:"total_ms"`
+	AverageMs     float64   `json:"average_ms"`
+	MaxMs         float64   `json:"max_ms"`
+	LastSeen      time.Time `json:"last_seen"`
+	LastRequestID string    `json:"last_request_id,omitempty"`
+}
+
+// SlowQueryReport lists the slowest statements seen since the server started
+type SlowQueryReport struct {
+	Since       time.Time `json:"since"`
+	ThresholdMs float64   `json:"threshold_ms"`
+	// Total counts every slow query, including shapes past the summary limit
+	Total   int64       `json:"total"`
+	Queries []SlowQuery `json:"queries"`
+}
+
+// queryLog is a GORM logger that logs statements slower than a threshold,
+// tagged with the request that ran them, and keeps a summary of them
+type queryLog struct {
+	logger.Interface
+	*slowQueries
+}
+
+// slowQueries accumulates the slow statements of every session of a database
+type slowQueries struct {
+	threshold time.Duration
+	since     time.Time
+
+	mu     sync.Mutex
+	total  int64
+	shapes map[string]*SlowQuery
+}
+
+// newQueryLog creates a query log; a zero threshold turns slow query logging off
+func newQueryLog(threshold time.Duration) *queryLog {
+	return &queryLog{
+		Interface: logger.Default,
+		slowQueries: &slowQueries{
+			threshold: threshold,
+			since:     time.Now(),
+			shapes:    make(map[string]*SlowQuery),
+		},
+	}
+}
+
+// LogMode sets the level of the messages GORM logs itself
+func (l *queryLog) LogMode(level logger.LogLevel) logger.Interface {
+	return &queryLog{Interface: l.Interface.LogMode(level), slowQueries: l.slowQueries}
+}
+
+// ParamsFilter leaves the arguments out of logged statements, so personal data
+// never reaches the logs and runs with different arguments group together
+func (l *queryLog) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+// Trace is called by GORM after every statement
+func (l *queryLog) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		l.Interface.Trace(ctx, begin, fc, err)
+		return
+	}
+
+	elapsed := time.Since(begin)
+	if l.threshold <= 0 || elapsed < l.threshold {
+		return
+	}
+
+	statement, rows := fc()
+	shape := normalizeSQL(statement)
+	id := requestid.FromContext(ctx)
+	log.Printf("slow query request_id=%s elapsed=%s rows=%d sql=%q", id, elapsed.Round(time.Microsecond), rows, shape)
+
+	ms := float64(elapsed) / float64(time.Millisecond)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total++
+	summary := l.shapes[shape]
+	if summary == nil {
+		if len(l.shapes) >= maxSlowQueryShapes {
+			return
+		}
+		summary = &SlowQuery{SQL: shape}
+		l.shapes[shape] = summary
+	}
+	summary.Count++
+	summary.TotalMs += ms
+	summary.MaxMs = max(summary.MaxMs, ms)
+	summary.LastSeen = time.Now()
+	if id != "" {
+		summary.LastRequestID = id
+	}
+}
+
+// report returns the slow statement shapes ordered by total, max or count, worst first
+func (l *slowQueries) report(orderBy string, limit int) *SlowQueryReport {
+	l.mu.Lock()
+	report := &SlowQueryReport{
+		Since:       l.since,
+		ThresholdMs: float64(l.threshold) / float64(time.Millisecond),
+		Total:       l.total,
+		Queries:     make([]SlowQuery, 0, len(l.shapes)),
+	}
+	for _, summary := range l.shapes {
+		query := *summary
+		query.AverageMs = query.TotalMs / float64(query.Count)
+		report.Queries = append(report.Queries, query)
+	}
+	l.mu.Unlock()
+
+	key := func(q SlowQuery) float64 { return q.TotalMs }
+	switch orderBy {
+	case "max":
+		key = func(q SlowQuery) float64 { return q.MaxMs }
+	case "count":
+		key = func(q SlowQuery) float64 { return float64(q.Count) }
+	}
+	sort.Slice(report.Queries, func(i, j int) bool {
+		return key(report.Queries[i]) > key(report.Queries[j])
+	})
+	if limit > 0 && len(report.Queries) > limit {
+		report.Queries = report.Queries[:limit]
+	}
+	return report
+}
+
+// normalizeSQL replaces the literal values written into a statement with
+// placeholders, as ParamsFilter does for its arguments
+func normalizeSQL(statement string) string {
+	statement = sqlBlob.ReplaceAllString(statement, "?")
+	statement = sqlString.ReplaceAllString(statement, "?")
+	statement = sqlNumber.ReplaceAllString(statement, "?")
+	statement = sqlList.ReplaceAllString(statement, "(?, ...)")
+	return strings.TrimSpace(sqlWhitespace.ReplaceAllString(statement, " "))
+}
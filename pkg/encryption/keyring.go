@@ -0,0 +1,184 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+)
+
+// prefix marks a value as ciphertext; the full format is enc:<key id>:<base64(nonce|sealed)>
+const prefix = "enc:"
+
+var (
+	// ErrUnknownKey is returned when a value was encrypted with a key that is not in the keyring
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrMalformed is returned when a value carries the ciphertext prefix but cannot be decoded
+	ErrMalformed = errors.New("malformed ciphertext")
+)
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Keyring holds the AES-256 keys used for encryption at rest. New values are
+// always sealed with the primary key; the remaining keys are kept so values
+// written before a rotation can still be read.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from 32-byte keys indexed by key ID
+func NewKeyring(keys map[string][]byte, primary string) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		aeads[id] = aead
+	}
+
+	return &Keyring{primary: primary, aeads: aeads}, nil
+}
+
+// ParseKeyring parses a comma-separated list of id:base64key pairs. The
+// primary key defaults to the first entry when primary is empty.
+func ParseKeyring(spec, primary string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("key entry must be id:base64key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("duplicate key ID %q", id)
+		}
+		keys[id] = key
+		if primary == "" {
+			primary = id
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+	return NewKeyring(keys, primary)
+}
+
+// LoadKeyring reads the key list from the secrets provider. It returns a nil
+// keyring, disabling encryption, when the secret does not exist.
+func LoadKeyring(ctx context.Context, provider secrets.Provider, secretName, primary string) (*Keyring, error) {
+	spec, err := provider.Get(ctx, secretName)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyring(spec, primary)
+}
+
+// PrimaryKeyID returns the ID of the key used for new values
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt seals plaintext with the primary key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The key ID is authenticated so a value cannot be replayed under another key
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.primary))
+	return prefix + k.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with whichever key sealed it
+func (k *Keyring) Decrypt(value string) (string, error) {
+	id, sealed, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether a stored value is plaintext or sealed with a
+// key other than the primary key
+func (k *Keyring) NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	id, ok := KeyID(value)
+	return !ok || id != k.primary
+}
+
+// IsEncrypted reports whether a stored value carries the ciphertext prefix
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the ID of the key that sealed a stored value
+func KeyID(value string) (string, bool) {
+	if !IsEncrypted(value) {
+		return "", false
+	}
+	id, _, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id, ok
+}
+
+// parse splits a stored value into its key ID and sealed bytes
+func parse(value string) (string, []byte, error) {
+	id, ok := KeyID(value)
+	if !ok {
+		return "", nil, ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(value[len(prefix)+len(id)+1:])
+	if err != nil {
+		return "", nil, ErrMalformed
+	}
+	return id, sealed, nil
+}
@@ -0,0 +1,84 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer tag value for encrypted string columns:
+//
+//	FilePath string `gorm:"serializer:encrypted"`
+const SerializerName = "encrypted"
+
+// ErrNoKeyring is returned when an encrypted value is read without a keyring
+var ErrNoKeyring = errors.New("encryption keyring is not configured")
+
+var active atomic.Pointer[Keyring]
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// SetKeyring sets the keyring used by the GORM serializer. With a nil keyring
+// values are written as plaintext, which keeps development setups key-free.
+func SetKeyring(k *Keyring) {
+	active.Store(k)
+}
+
+// CurrentKeyring returns the keyring used by the GORM serializer, or nil
+func CurrentKeyring() *Keyring {
+	return active.Load()
+}
+
+// Serializer encrypts string columns with the active keyring. Plaintext values
+// written before encryption was enabled are read back unchanged until they
+// are rotated.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted column %s", dbValue, field.Name)
+	}
+
+	if IsEncrypted(value) {
+		keyring := CurrentKeyring()
+		if keyring == nil {
+			return fmt.Errorf("column %s: %w", field.Name, ErrNoKeyring)
+		}
+		plaintext, err := keyring.Decrypt(value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", field.Name, err)
+		}
+		value = plaintext
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value implements schema.SerializerInterface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted column %s must be a string, got %T", field.Name, fieldValue)
+	}
+
+	keyring := CurrentKeyring()
+	if keyring == nil || value == "" {
+		return value, nil
+	}
+	return keyring.Encrypt(value)
+}
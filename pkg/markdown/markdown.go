@@ -0,0 +1,515 @@
+// Package markdown renders the Markdown of comments, and plain text, as safe
+// HTML. Raw HTML in the source is never passed through: every character of
+// the text is escaped, and only the tags this package writes itself appear
+// in the output, with links limited to http, https and mailto URLs.
+//
+// The supported syntax is paragraphs with hard line breaks, ATX headings,
+// emphasis, strong, strikethrough, code spans, fenced and indented code
+// blocks, block quotes, bullet and numbered lists, horizontal rules, inline
+// links and bare URLs.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxLinkLength bounds the targets of links, which are searched for their end
+const maxLinkLength = 2048
+
+// maxDepth bounds the nesting of block quotes, lists and inline spans, so
+// that deeply nested input cannot exhaust the stack
+const maxDepth = 16
+
+var (
+	headingRegex = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRegex    = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	bulletRegex  = regexp.MustCompile(`^ {0,3}([-*+])[ \t]+`)
+	orderedRegex = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)][ \t]+`)
+	fenceRegex   = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	urlRegex     = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,;:!?)\]'*]`)
+)
+
+// Render renders Markdown as sanitized HTML
+func Render(source string) string {
+	var b strings.Builder
+	renderBlocks(&b, splitLines(source), 0, false)
+	return b.String()
+}
+
+// RenderPlain renders plain text as sanitized HTML paragraphs, keeping line
+// breaks and turning URLs into links
+func RenderPlain(text string) string {
+	var b strings.Builder
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>")
+			for i, line := range paragraph {
+				if i > 0 {
+					b.WriteString("<br>\n")
+				}
+				writeText(&b, line)
+			}
+			b.WriteString("</p>\n")
+			paragraph = nil
+		}
+	}
+	for _, line := range splitLines(text) {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return b.String()
+}
+
+// splitLines splits text into lines, whatever its line endings
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n")
+}
+
+// renderBlocks renders lines as block elements. The paragraphs of tight list
+// items are written without <p>.
+func renderBlocks(b *strings.Builder, lines []string, depth int, tight bool) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		if tight {
+			writeInline(b, strings.Join(paragraph, "\n"), depth)
+		} else {
+			b.WriteString("<p>")
+			writeInline(b, strings.Join(paragraph, "\n"), depth)
+			b.WriteString("</p>\n")
+		}
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		nested := depth < maxDepth
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fenceRegex.MatchString(line):
+			flush()
+			fence := fenceRegex.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimLeft(lines[i], " "), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			writeCode(b, code)
+
+		case len(paragraph) == 0 && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			var code []string
+			for ; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) != "" && !strings.HasPrefix(lines[i], "    ") && !strings.HasPrefix(lines[i], "\t") {
+					break
+				}
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(lines[i], "\t"), "    "))
+			}
+			i--
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			writeCode(b, code)
+
+		case headingRegex.MatchString(trimmed) && len(line)-len(trimmed) <= 3:
+			flush()
+			match := headingRegex.FindStringSubmatch(trimmed)
+			fmt.Fprintf(b, "<h%d>", len(match[1]))
+			writeInline(b, match[2], depth)
+			fmt.Fprintf(b, "</h%d>\n", len(match[1]))
+
+		case ruleRegex.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case nested && strings.HasPrefix(trimmed, ">") && len(line)-len(trimmed) <= 3:
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				rest := strings.TrimLeft(lines[i], " ")
+				if !strings.HasPrefix(rest, ">") {
+					break
+				}
+				rest = strings.TrimPrefix(rest, ">")
+				quoted = append(quoted, strings.TrimPrefix(rest, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted, depth+1, false)
+			b.WriteString("</blockquote>\n")
+
+		case nested && (bulletRegex.MatchString(line) || orderedRegex.MatchString(line)):
+			flush()
+			i = renderList(b, lines, i, depth) - 1
+
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+}
+
+// renderList renders the list starting at lines[start], returning the index of
+// the first line after it. An item continues on the lines indented under it.
+func renderList(b *strings.Builder, lines []string, start, depth int) int {
+	ordered := orderedRegex.MatchString(lines[start])
+	marker := bulletRegex
+	if ordered {
+		marker = orderedRegex
+		number := orderedRegex.FindStringSubmatch(lines[start])[1]
+		if number = strings.TrimLeft(number, "0"); number != "1" && number != "" {
+			fmt.Fprintf(b, "<ol start=\"%s\">\n", number)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		match := marker.FindStringIndex(lines[i])
+		if match == nil {
+			break
+		}
+		indent := match[1]
+		item := []string{lines[i][indent:]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line ends the item unless the next line is indented under it
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= indent {
+					item = append(item, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) >= indent {
+				item = append(item, dedent(line, indent))
+				continue
+			}
+			// Lines that start another block end the item; others continue its paragraph
+			if marker.MatchString(line) || bulletRegex.MatchString(line) || orderedRegex.MatchString(line) ||
+				fenceRegex.MatchString(line) || ruleRegex.MatchString(line) || strings.HasPrefix(strings.TrimLeft(line, " "), ">") ||
+				strings.HasPrefix(strings.TrimLeft(line, " "), "#") {
+				break
+			}
+			item = append(item, strings.TrimLeft(line, " "))
+		}
+
+		// Items without blank lines inside are tight
+		b.WriteString("<li>")
+		renderBlocks(b, item, depth+1, !slices.Contains(item, ""))
+		b.WriteString("</li>\n")
+
+		// Skip the blank lines between items of the same list
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next < len(lines) && marker.MatchString(lines[next]) {
+			i = next
+			continue
+		}
+		break
+	}
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return i
+}
+
+// leadingSpaces counts the spaces a line starts with, a tab counting as four
+func leadingSpaces(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// dedent removes up to n columns of leading spaces and tabs from a line
+func dedent(line string, n int) string {
+	for n > 0 && line != "" {
+		switch line[0] {
+		case ' ':
+			n--
+		case '\t':
+			n -= 4
+		default:
+			return line
+		}
+		line = line[1:]
+	}
+	return line
+}
+
+// writeCode writes a code block
+func writeCode(b *strings.Builder, lines []string) {
+	b.WriteString("<pre><code>")
+	for _, line := range lines {
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// inlineParser renders the spans of one block of text. It remembers where
+// the search for each closing delimiter failed, as a later search from
+// further on in the same span would fail too, so unmatched delimiters cost
+// linear time.
+type inlineParser struct {
+	b        *strings.Builder
+	noCloser map[string]int
+}
+
+// writeInline renders text with its emphasis, code spans and links
+func writeInline(b *strings.Builder, text string, depth int) {
+	p := &inlineParser{b: b, noCloser: make(map[string]int)}
+	p.write(text, 0, depth, true)
+}
+
+// write renders text[offset:], which sits at offset in the block, allowing
+// links unless inside one
+func (p *inlineParser) write(text string, offset, depth int, links bool) {
+	plain := 0
+	flush := func(i int) {
+		writeTextLinks(p.b, text[plain:i], links)
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!~>|", text[i+1]) >= 0:
+			flush(i)
+			p.b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			plain = i
+			continue
+
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			flush(i)
+			p.b.WriteString("<br>\n")
+			i += 2
+			plain = i
+			continue
+
+		case c == '\n':
+			flush(i)
+			p.b.WriteString("<br>\n")
+			i++
+			plain = i
+			continue
+
+		case c == '`':
+			run := runLength(text, i, '`')
+			delimiter := text[i : i+run]
+			if end := p.closer(text, offset, i+run, delimiter, false); end >= 0 {
+				flush(i)
+				p.b.WriteString("<code>")
+				p.b.WriteString(html.EscapeString(strings.TrimSpace(text[i+run : end])))
+				p.b.WriteString("</code>")
+				i = end + run
+				plain = i
+				continue
+			}
+			i += run
+			continue
+
+		case depth < maxDepth && (c == '*' || c == '_' || c == '~'):
+			run := runLength(text, i, c)
+			// An underscore inside a word, as in snake_case, is not emphasis
+			if c == '_' && i > 0 && isWordByte(text[i-1]) {
+				i += run
+				continue
+			}
+			open, close, width := "", "", 0
+			switch {
+			case c == '~' && run == 2:
+				open, close, width = "<del>", "</del>", 2
+			case c != '~' && run >= 3:
+				open, close, width = "<em><strong>", "</strong></em>", 3
+			case c != '~' && run == 2:
+				open, close, width = "<strong>", "</strong>", 2
+			case c != '~':
+				open, close, width = "<em>", "</em>", 1
+			}
+			if width > 0 && i+width < len(text) && text[i+width] != ' ' {
+				delimiter := text[i : i+width]
+				if end := p.closer(text, offset, i+width, delimiter, c == '_'); end >= 0 {
+					flush(i)
+					p.b.WriteString(open)
+					p.write(text[i+width:end], offset+i+width, depth+1, links)
+					p.b.WriteString(close)
+					i = end + width
+					plain = i
+					continue
+				}
+			}
+			i += run
+			continue
+
+		case c == '[' && links && depth < maxDepth:
+			if label, target, end, ok := p.link(text, offset, i); ok {
+				flush(i)
+				if href, safe := safeURL(target); safe {
+					fmt.Fprintf(p.b, `<a href="%s" rel="nofollow noopener noreferrer">`, html.EscapeString(href))
+					p.write(label, offset+i+1, depth+1, false)
+					p.b.WriteString("</a>")
+				} else {
+					p.write(label, offset+i+1, depth+1, false)
+				}
+				i = end
+				plain = i
+				continue
+			}
+		}
+		i++
+	}
+	flush(len(text))
+}
+
+// closer returns the index in text of the delimiter closing a span opened
+// before from, or -1. Closers must follow a non-space; underscores must not
+// be followed by a word character.
+func (p *inlineParser) closer(text string, offset, from int, delimiter string, wordBoundary bool) int {
+	key := delimiter + "@" + strconv.Itoa(offset+len(text))
+	if failed, ok := p.noCloser[key]; ok && offset+from >= failed {
+		return -1
+	}
+	for i := from; i < len(text); {
+		j := strings.Index(text[i:], delimiter)
+		if j < 0 {
+			break
+		}
+		end := i + j
+		run := runLength(text, end, delimiter[0])
+		valid := end > from && text[end-1] != ' ' && text[end-1] != '\n'
+		if delimiter[0] == '`' {
+			// A code span is closed by a run of exactly as many backticks
+			valid = run == len(delimiter)
+		} else if run > len(delimiter) && len(delimiter) == 1 {
+			valid = false
+		}
+		if wordBoundary && end+run < len(text) && isWordByte(text[end+run]) {
+			valid = false
+		}
+		if valid {
+			return end
+		}
+		i = end + run
+	}
+	p.noCloser[key] = offset + from
+	return -1
+}
+
+// link parses an inline link [label](target) at text[start], returning the
+// index after it
+func (p *inlineParser) link(text string, offset, start int) (label, target string, end int, ok bool) {
+	close := p.closer(text, offset, start+1, "]", false)
+	if close < 0 || close+1 >= len(text) || text[close+1] != '(' {
+		return "", "", 0, false
+	}
+	// The target may hold balanced parentheses, as in Wikipedia URLs
+	open := 1
+	for i := close + 2; i < min(len(text), close+2+maxLinkLength); i++ {
+		switch text[i] {
+		case '(':
+			open++
+		case ')':
+			open--
+		case ' ', '\n':
+			return "", "", 0, false
+		}
+		if open == 0 {
+			return text[start+1 : close], text[close+2 : i], i + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// safeURL returns the URL a link may point to: http, https and mailto URLs,
+// and paths on this site
+func safeURL(target string) (string, bool) {
+	target = strings.Trim(target, "<>")
+	// Browsers read backslashes as slashes, so /\host would leave the site
+	if strings.Contains(target, `\`) {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return u.String(), true
+	case "":
+		// Relative links stay on this site, but not protocol-relative ones
+		return u.String(), u.Host == "" && !strings.HasPrefix(target, "//")
+	}
+	return "", false
+}
+
+// writeText writes escaped text, turning URLs into links
+func writeText(b *strings.Builder, text string) {
+	writeTextLinks(b, text, true)
+}
+
+// writeTextLinks writes escaped text, turning URLs into links when allowed
+func writeTextLinks(b *strings.Builder, text string, links bool) {
+	if !links {
+		b.WriteString(html.EscapeString(text))
+		return
+	}
+	last := 0
+	for _, loc := range urlRegex.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		link := html.EscapeString(text[loc[0]:loc[1]])
+		fmt.Fprintf(b, `<a href="%s" rel="nofollow noopener noreferrer">%s</a>`, link, link)
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+}
+
+// runLength counts the bytes equal to c from text[i]
+func runLength(text string, i int, c byte) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// isWordByte reports whether c is an ASCII letter or digit
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
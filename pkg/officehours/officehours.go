@@ -0,0 +1,270 @@
+// Package officehours parses weekly opening-hours schedules and answers whether
+// support is open at a given time.
+package officehours
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is one opening period on a weekday, in minutes after local midnight
+type Window struct {
+	Day   time.Weekday
+	Open  int
+	Close int
+}
+
+// Schedule is a weekly set of opening windows in a time zone. A schedule
+// without windows is always open, except on its holidays.
+type Schedule struct {
+	Location *time.Location
+	Windows  []Window
+	// Holidays are the dates ("YYYY-MM-DD") the schedule is closed all day
+	Holidays map[string]bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Parse parses a schedule such as "mon-fri 09:00-17:00; sat 10:00-14:00" in the
+// named IANA time zone. An empty spec yields a schedule that is always open.
+func Parse(spec, timezone string) (*Schedule, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", timezone, err)
+	}
+
+	schedule := &Schedule{Location: location}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid office hours %q: expected \"<days> <HH:MM>-<HH:MM>\"", part)
+		}
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		open, close, err := parseHours(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, day := range days {
+			schedule.Windows = append(schedule.Windows, Window{Day: day, Open: open, Close: close})
+		}
+	}
+	return schedule, nil
+}
+
+// AddHolidays parses a comma-separated list of YYYY-MM-DD dates on which the
+// schedule is closed
+func (s *Schedule) AddHolidays(spec string) error {
+	for _, date := range strings.Split(spec, ",") {
+		date = strings.TrimSpace(date)
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid holiday %q: expected YYYY-MM-DD", date)
+		}
+		if s.Holidays == nil {
+			s.Holidays = make(map[string]bool)
+		}
+		s.Holidays[date] = true
+	}
+	return nil
+}
+
+// AlwaysOpen reports whether the schedule has no opening windows
+func (s *Schedule) AlwaysOpen() bool {
+	return len(s.Windows) == 0
+}
+
+// IsHoliday reports whether the given time falls on one of the schedule's holidays
+func (s *Schedule) IsHoliday(at time.Time) bool {
+	return s.Holidays[at.In(s.Location).Format(time.DateOnly)]
+}
+
+// IsBusinessDay reports whether the schedule opens at all on the day of the
+// given time
+func (s *Schedule) IsBusinessDay(at time.Time) bool {
+	if s.IsHoliday(at) {
+		return false
+	}
+	if s.AlwaysOpen() {
+		return true
+	}
+	day := at.In(s.Location).Weekday()
+	for _, window := range s.Windows {
+		if window.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether the schedule is open at the given time
+func (s *Schedule) IsOpen(at time.Time) bool {
+	if s.IsHoliday(at) {
+		return false
+	}
+	if s.AlwaysOpen() {
+		return true
+	}
+
+	local := at.In(s.Location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range s.Windows {
+		if window.Day == local.Weekday() && minute >= window.Open && minute < window.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the time the schedule next opens, or the given time if it is
+// already open
+func (s *Schedule) NextOpen(at time.Time) time.Time {
+	if s.IsOpen(at) {
+		return at
+	}
+
+	local := at.In(s.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Location)
+	var next time.Time
+	for offset := 0; offset <= 7+len(s.Holidays); offset++ {
+		day := midnight.AddDate(0, 0, offset)
+		if s.IsHoliday(day) {
+			continue
+		}
+		if s.AlwaysOpen() {
+			// Reopens the first midnight after the holidays
+			return day
+		}
+		for _, window := range s.Windows {
+			if window.Day != day.Weekday() {
+				continue
+			}
+			opens := day.Add(time.Duration(window.Open) * time.Minute)
+			if opens.After(local) && (next.IsZero() || opens.Before(next)) {
+				next = opens
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// AddBusinessDays returns the close of business on the given number of
+// business days after the day of at, so 1 is the end of the next business day.
+// Days without opening hours and holidays are skipped; an always open schedule
+// closes at the last second of the day. It returns the zero time when the
+// schedule never opens.
+func (s *Schedule) AddBusinessDays(at time.Time, days int) time.Time {
+	local := at.In(s.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Location)
+	// Every week has a business day unless the holidays cover it
+	for limit := 7 * (days + len(s.Holidays) + 1); days > 0 && limit > 0; limit-- {
+		day = day.AddDate(0, 0, 1)
+		if s.IsBusinessDay(day) {
+			days--
+		}
+	}
+	if days > 0 {
+		return time.Time{}
+	}
+	return s.closes(day)
+}
+
+// closes returns when the schedule last closes on the given local midnight
+func (s *Schedule) closes(day time.Time) time.Time {
+	if s.AlwaysOpen() {
+		return day.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	last := 0
+	for _, window := range s.Windows {
+		if window.Day == day.Weekday() && window.Close > last {
+			last = window.Close
+		}
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, last, 0, 0, s.Location)
+}
+
+// parseDays parses a day or day range such as "mon" or "mon-fri"
+func parseDays(field string) ([]time.Weekday, error) {
+	from, to, isRange := strings.Cut(strings.ToLower(field), "-")
+	start, ok := weekdays[from]
+	if !ok {
+		return nil, fmt.Errorf("invalid day %q", from)
+	}
+	if !isRange {
+		return []time.Weekday{start}, nil
+	}
+	end, ok := weekdays[to]
+	if !ok {
+		return nil, fmt.Errorf("invalid day %q", to)
+	}
+
+	// Ranges may wrap around the week, e.g. "sat-sun"
+	days := []time.Weekday{start}
+	for day := start; day != end; {
+		day = (day + 1) % 7
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseHours parses an "HH:MM-HH:MM" range into minutes after midnight
+func parseHours(field string) (int, int, error) {
+	from, to, ok := strings.Cut(field, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid hours %q", field)
+	}
+	open, err := ParseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	close, err := ParseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if close <= open {
+		return 0, 0, fmt.Errorf("invalid hours %q: closing time must be after opening time", field)
+	}
+	return open, close, nil
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight; "24:00" is allowed as a closing time
+func ParseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatClock formats minutes after midnight as "HH:MM"
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,101 @@
+// Package realtime fans events out to in-process subscribers grouped by topic,
+// such as the WebSocket connections following one chat.
+package realtime
+
+import "sync"
+
+// subscriptionBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it
+const subscriptionBuffer = 32
+
+// Hub routes published events to the subscribers of a topic
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// Subscription receives the events published to one topic
+type Subscription struct {
+	// Staff marks subscribers allowed to see staff-only events
+	Staff bool
+	// Viewer describes who subscribed, for accept functions that need more
+	// than Staff to decide which events they may see
+	Viewer any
+
+	hub    *Hub
+	topic  string
+	events chan any
+	once   sync.Once
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscribe starts receiving the events published to a topic. Close the
+// subscription when done with it.
+func (h *Hub) Subscribe(topic string, staff bool) *Subscription {
+	return h.SubscribeAs(topic, staff, nil)
+}
+
+// SubscribeAs is Subscribe for a subscriber described by viewer
+func (h *Hub) SubscribeAs(topic string, staff bool, viewer any) *Subscription {
+	sub := &Subscription{
+		Staff:  staff,
+		Viewer: viewer,
+		hub:    h,
+		topic:  topic,
+		events: make(chan any, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*Subscription]struct{})
+	}
+	h.topics[topic][sub] = struct{}{}
+	return sub
+}
+
+// Publish sends an event to the subscribers of a topic that accept allows; a nil
+// accept sends it to all of them. Subscribers that have fallen too far behind
+// miss the event rather than block the publisher.
+func (h *Hub) Publish(topic string, event any, accept func(*Subscription) bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.topics[topic] {
+		if accept != nil && !accept(sub) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns how many subscribers a topic has
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Events returns the channel events are delivered on; it is closed by Close
+func (s *Subscription) Events() <-chan any {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+		delete(s.hub.topics[s.topic], s)
+		if len(s.hub.topics[s.topic]) == 0 {
+			delete(s.hub.topics, s.topic)
+		}
+		close(s.events)
+	})
+}
@@ -0,0 +1,153 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule names of the built-in rules
+const (
+	RuleSSN        = "ssn"
+	RuleCreditCard = "credit_card"
+	RulePhone      = "phone"
+	RuleDictionary = "dictionary"
+)
+
+// Rule masks every match of a pattern that passes the optional validation
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+	// Validate filters out false positives; nil accepts every match
+	Validate func(match string) bool
+}
+
+// Match records how many times a rule was applied
+type Match struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// Redactor applies an ordered set of rules to text
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a redactor applying rules in order
+func New(rules ...Rule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// Redact masks sensitive content and reports which rules matched
+func (r *Redactor) Redact(text string) (string, []Match) {
+	var matches []Match
+	for _, rule := range r.rules {
+		count := 0
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Validate != nil && !rule.Validate(match) {
+				return match
+			}
+			count++
+			return rule.Replacement
+		})
+		if count > 0 {
+			matches = append(matches, Match{Rule: rule.Name, Count: count})
+		}
+	}
+	return text, matches
+}
+
+// SSNRule masks US social security numbers written as 123-45-6789
+func SSNRule() Rule {
+	return Rule{
+		Name:        RuleSSN,
+		Pattern:     regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		Replacement: "[REDACTED SSN]",
+		Validate: func(match string) bool {
+			// Area numbers 000, 666 and 900-999 are never issued
+			area := match[:3]
+			return area != "000" && area != "666" && area[0] != '9' && match[4:6] != "00" && match[7:] != "0000"
+		},
+	}
+}
+
+// CreditCardRule masks payment card numbers of 13 to 19 digits that pass the Luhn check
+func CreditCardRule() Rule {
+	return Rule{
+		Name:        RuleCreditCard,
+		Pattern:     regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Replacement: "[REDACTED CARD]",
+		Validate:    luhnValid,
+	}
+}
+
+// PhoneRule masks North American and international phone numbers
+func PhoneRule() Rule {
+	return Rule{
+		Name:        RulePhone,
+		Pattern:     regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]?\d{4}\b`),
+		Replacement: "[REDACTED PHONE]",
+	}
+}
+
+// DictionaryRule masks whole-word, case-insensitive occurrences of the given words
+// with asterisks. It returns false when the dictionary is empty.
+func DictionaryRule(words []string) (Rule, bool) {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return Rule{}, false
+	}
+
+	// Longest first so that phrases win over the words they contain
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	return Rule{
+		Name:        RuleDictionary,
+		Pattern:     pattern,
+		Replacement: "****",
+	}, true
+}
+
+// BuiltinRule returns the built-in rule with the given name
+func BuiltinRule(name string) (Rule, error) {
+	switch name {
+	case RuleSSN:
+		return SSNRule(), nil
+	case RuleCreditCard:
+		return CreditCardRule(), nil
+	case RulePhone:
+		return PhoneRule(), nil
+	}
+	return Rule{}, fmt.Errorf("unknown redaction rule %q", name)
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
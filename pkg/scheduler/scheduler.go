@@ -0,0 +1,142 @@
+// Package scheduler runs background jobs on a schedule, such as nightly exports.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is work run by the scheduler. Its context is cancelled when the
+// scheduler stops.
+type Job func(ctx context.Context) error
+
+// entry is a job with the function giving its next run after a time
+type entry struct {
+	name string
+	next func(after time.Time) time.Time
+	job  Job
+}
+
+// Scheduler runs registered jobs until it is stopped
+type Scheduler struct {
+	mu        sync.Mutex
+	entries   []entry
+	onFailure func(name string, err error)
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// New creates a scheduler with no jobs
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Daily runs a job every day at a clock time such as "02:00" in loc
+func (s *Scheduler) Daily(name, at string, loc *time.Location, job Job) error {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return fmt.Errorf("invalid time of day %q for job %s", at, name)
+	}
+	s.add(entry{name: name, job: job, next: func(after time.Time) time.Time {
+		local := after.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !next.After(after) {
+			next = time.Date(local.Year(), local.Month(), local.Day()+1, clock.Hour(), clock.Minute(), 0, 0, loc)
+		}
+		return next
+	}})
+	return nil
+}
+
+// Every runs a job repeatedly, waiting interval after each start
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.add(entry{name: name, job: job, next: func(after time.Time) time.Time {
+		return after.Add(interval)
+	}})
+}
+
+func (s *Scheduler) add(e entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// OnFailure sets a function called with the name and error of each failed
+// scheduled run, in addition to the failure being logged
+func (s *Scheduler) OnFailure(fn func(name string, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFailure = fn
+}
+
+// Run runs a registered job now, outside its schedule
+func (s *Scheduler) Run(ctx context.Context, name string) error {
+	s.mu.Lock()
+	var job Job
+	for _, e := range s.entries {
+		if e.name == name {
+			job = e.job
+		}
+	}
+	s.mu.Unlock()
+	if job == nil {
+		return fmt.Errorf("no job named %q", name)
+	}
+	return job(ctx)
+}
+
+// Start runs every registered job on its schedule in the background. Jobs
+// registered after Start are not run.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.run(ctx, e)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// run loops a job on its schedule until ctx is cancelled
+func (s *Scheduler) run(ctx context.Context, e entry) {
+	defer s.wg.Done()
+	for {
+		timer := time.NewTimer(time.Until(e.next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		started := time.Now()
+		if err := e.job(ctx); err != nil {
+			log.Printf("Job %s failed after %s: %v", e.name, time.Since(started).Round(time.Millisecond), err)
+			s.mu.Lock()
+			onFailure := s.onFailure
+			s.mu.Unlock()
+			if onFailure != nil && ctx.Err() == nil {
+				onFailure(e.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a secret does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider resolves named secrets such as encryption keys
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// NewProvider creates the provider of the given kind: "env" reads environment
+// variables and "file" reads one file per secret from dir, as mounted by
+// Docker and Kubernetes secrets
+func NewProvider(kind, dir string) (Provider, error) {
+	switch kind {
+	case "", "env":
+		return EnvProvider{}, nil
+	case "file":
+		return FileProvider{Dir: dir}, nil
+	}
+	return nil, fmt.Errorf("unknown secrets provider %q", kind)
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct{}
+
+// Get returns the environment variable with the given name
+func (EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// FileProvider reads secrets from files in a directory
+type FileProvider struct {
+	Dir string
+}
+
+// Get returns the trimmed contents of the file with the given name
+func (p FileProvider) Get(ctx context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return value, nil
+}
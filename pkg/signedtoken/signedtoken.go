@@ -0,0 +1,79 @@
+package signedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// expiryLength is the size of the encoded expiry (unix seconds)
+	expiryLength = 4
+	// macLength is the size of the truncated HMAC-SHA256 signature
+	macLength = 12
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed or its signature does not match
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned when a token has a valid signature but is past its expiry
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// encoding is lowercase base32 without padding so tokens are safe in URLs and email local parts
+var encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Signer issues and verifies compact HMAC-signed tokens carrying a short payload and an expiry
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a new signer. The purpose is mixed into the signing key so that
+// tokens issued for one purpose (e.g. share links) cannot be replayed for another.
+func NewSigner(secret, purpose string) *Signer {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return &Signer{key: mac.Sum(nil)}
+}
+
+// Sign creates a token for the payload that expires at the given time
+func (s *Signer) Sign(payload []byte, expiresAt time.Time) string {
+	buf := make([]byte, len(payload)+expiryLength, len(payload)+expiryLength+macLength)
+	copy(buf, payload)
+	binary.BigEndian.PutUint32(buf[len(payload):], uint32(expiresAt.Unix()))
+	buf = append(buf, s.sign(buf)...)
+	return encoding.EncodeToString(buf)
+}
+
+// Verify checks the token signature and expiry and returns the payload and expiry time
+func (s *Signer) Verify(token string, now time.Time) ([]byte, time.Time, error) {
+	raw, err := encoding.DecodeString(strings.ToLower(strings.TrimSpace(token)))
+	if err != nil || len(raw) < expiryLength+macLength {
+		return nil, time.Time{}, ErrInvalidToken
+	}
+
+	signed, mac := raw[:len(raw)-macLength], raw[len(raw)-macLength:]
+	if subtle.ConstantTimeCompare(mac, s.sign(signed)) != 1 {
+		return nil, time.Time{}, ErrInvalidToken
+	}
+
+	payload := signed[:len(signed)-expiryLength]
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint32(signed[len(payload):])), 0)
+	if !now.Before(expiresAt) {
+		return nil, expiresAt, ErrExpiredToken
+	}
+
+	return payload, expiresAt, nil
+}
+
+// sign computes the truncated signature for the given bytes
+func (s *Signer) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return mac.Sum(nil)[:macLength]
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/awsv4"
+)
+
+// S3Storage stores files in a bucket of an S3 compatible object store such
+// as Amazon S3, MinIO or Cloudflare R2, using path-style requests signed with
+// AWS Signature Version 4
+type S3Storage struct {
+	endpoint string
+	bucket   string
+	signer   awsv4.Signer
+	client   *http.Client
+}
+
+// NewS3Storage creates a new S3 storage. An empty endpoint uses Amazon S3 in
+// the given region.
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string) *S3Storage {
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	return &S3Storage{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   bucket,
+		signer:   awsv4.Signer{AccessKey: accessKey, SecretKey: secretKey, Region: region, Service: "s3"},
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Save uploads the content of r to the given key. The content is buffered in
+// memory so its length and hash can be signed.
+func (s *S3Storage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	res, err := s.do(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return int64(len(body)), nil
+}
+
+// Open downloads the object stored at the given key
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Delete removes the object stored at the given key
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// do sends a signed request for an object, returning an error for any
+// unsuccessful response
+func (s *S3Storage) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") {
+		return nil, ErrInvalidKey
+	}
+
+	path := "/" + s.bucket + "/" + escapePath(key)
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.signer.Sign(req, body, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach object store: %w", err)
+	}
+	if res.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("object store returned %s for %s %s: %s", res.Status, method, key, strings.TrimSpace(string(detail)))
+	}
+	return res, nil
+}
+
+// escapePath percent-encodes an object key the way Signature Version 4 expects,
+// leaving only unreserved characters and the slashes between segments
+func escapePath(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
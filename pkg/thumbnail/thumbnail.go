@@ -0,0 +1,90 @@
+// Package thumbnail makes scaled-down copies of images for previews, using
+// only the PNG, JPEG and GIF decoders of the standard library.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// MaxPixels bounds the images decoded, so that a small file cannot expand
+// into a bitmap that exhausts memory
+const MaxPixels = 24_000_000
+
+// ErrTooLarge is returned for images with more than MaxPixels pixels
+var ErrTooLarge = errors.New("image has too many pixels to thumbnail")
+
+// Decode reads a PNG, JPEG or GIF image, returning it with the name of its
+// format. GIFs yield their first frame.
+func Decode(data []byte) (*image.RGBA, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba, format, nil
+}
+
+// Scale shrinks img to width x height, each pixel the average of the source
+// pixels it covers. It does not enlarge images.
+func Scale(img *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := img.Rect.Dx(), img.Rect.Dy()
+	width, height = min(width, srcWidth), min(height, srcHeight)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		for x := range width {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			var r, g, b, a int
+			for sy := y0; sy < y1; sy++ {
+				row := img.Pix[sy*img.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += int(pixel[0])
+					g += int(pixel[1])
+					b += int(pixel[2])
+					a += int(pixel[3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+// Encode writes img as a JPEG when the original was one, else as a PNG so
+// transparency is kept
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == "jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
+
+// ContentType returns the media type of the thumbnails of images in format
+func ContentType(format string) string {
+	if format == "jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
@@ -0,0 +1,29 @@
+// Package timezone carries the time zone of the user a request is served for
+// through a context, so dates they send and see are in their local time.
+package timezone
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a time zone
+func NewContext(ctx context.Context, location *time.Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, location)
+}
+
+// FromContext returns the time zone carried by ctx, if any
+func FromContext(ctx context.Context) (*time.Location, bool) {
+	location, ok := ctx.Value(contextKey{}).(*time.Location)
+	return location, ok
+}
+
+// Or returns the time zone carried by ctx, or fallback when there is none
+func Or(ctx context.Context, fallback *time.Location) *time.Location {
+	if location, ok := FromContext(ctx); ok {
+		return location
+	}
+	return fallback
+}
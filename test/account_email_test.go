@@ -0,0 +1,180 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountEmails(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Share:    config.ShareConfig{BaseURL: "https://support.example.com/"},
+		Security: config.SecurityConfig{PasswordResetTTL: "1h", EmailVerificationTTL: "72h", TokenMaxFailures: 3},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	tokenRepo := repository.NewAccountTokenRepository(db)
+	sender := &recordingSender{}
+	authService := services.NewAuthService(userRepo, cfg)
+	accountService := services.NewAccountService(userRepo, tokenRepo, sender, nil, cfg)
+	botProtection, err := testMiddleware.NewBotProtection(cfg)
+	require.NoError(t, err)
+	loginHistory := services.NewLoginHistoryService(repository.NewLoginEventRepository(db), email.NewNoopSender(), cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.IPExtractor = echo.ExtractIPDirect()
+	auditRepo := repository.NewAuditLogRepository(db)
+	tokenLockout := testMiddleware.NewTokenLockout(services.NewAuditService(auditRepo, nil), cfg)
+	handlers.NewAuthHandler(authService, accountService, loginHistory, botProtection, tokenLockout).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	postFrom := func(remoteAddr, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth"+path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	post := func(path, body string) *httptest.ResponseRecorder {
+		return postFrom("192.0.2.1:1234", path, body)
+	}
+	linkToken := regexp.MustCompile(`\?token=([0-9a-f]+)`)
+	lastLink := func(path string) string {
+		require.NotEmpty(t, sender.messages)
+		text := sender.messages[len(sender.messages)-1].Text
+		require.Contains(t, text, "https://support.example.com"+path+"?token=")
+		return linkToken.FindStringSubmatch(text)[1]
+	}
+
+	rec := post("/register", `{"email":"casey@example.com","password":"password123","first_name":"Casey","last_name":"Lee","role":"END_USER"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	user, err := userRepo.GetByEmail("casey@example.com")
+	require.NoError(t, err)
+
+	t.Run("WelcomeEmailVerifiesAddress", func(t *testing.T) {
+		require.Len(t, sender.messages, 1)
+		welcome := sender.messages[0]
+		assert.Equal(t, []string{"casey@example.com"}, welcome.To)
+		assert.Equal(t, "Welcome! Please verify your email address", welcome.Subject)
+		assert.Contains(t, welcome.Text, "Hi Casey,")
+		assert.Contains(t, welcome.Text, "expires in 3 days")
+		token := lastLink(services.EmailVerificationPath)
+
+		assert.Equal(t, http.StatusUnauthorized, post("/verify-email", `{"token":"not-a-token"}`).Code)
+		verified, err := userRepo.GetByID(user.ID.String())
+		require.NoError(t, err)
+		assert.False(t, verified.IsVerified)
+
+		rec := post("/verify-email", `{"token":"`+token+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		verified, err = userRepo.GetByID(user.ID.String())
+		require.NoError(t, err)
+		assert.True(t, verified.IsVerified)
+
+		// Links can be used once
+		assert.Equal(t, http.StatusUnauthorized, post("/verify-email", `{"token":"`+token+`"}`).Code)
+	})
+
+	t.Run("UnknownAddressesGetTheSameAnswer", func(t *testing.T) {
+		sent := len(sender.messages)
+		rec := post("/forgot-password", `{"email":"nobody@example.com"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "If the email exists")
+		assert.Len(t, sender.messages, sent)
+	})
+
+	t.Run("ResetsPasswordOnce", func(t *testing.T) {
+		rec := post("/forgot-password", `{"email":"casey@example.com"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		reset := sender.messages[len(sender.messages)-1]
+		assert.Equal(t, []string{"casey@example.com"}, reset.To)
+		assert.Equal(t, "Reset your password", reset.Subject)
+		assert.Contains(t, reset.Text, "expires in 1 hour")
+		token := lastLink(services.PasswordResetPath)
+
+		// Only the hash of the token is stored
+		var stored models.PasswordResetToken
+		require.NoError(t, db.DB.Where("user_id = ?", user.ID.String()).First(&stored).Error)
+		assert.NotEqual(t, token, stored.Token)
+
+		rec = post("/reset-password", `{"token":"`+token+`","password":"new-password-456"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		_, _, err := authService.Login(&models.LoginRequest{Email: "casey@example.com", Password: "new-password-456"})
+		assert.NoError(t, err)
+		_, _, err = authService.Login(&models.LoginRequest{Email: "casey@example.com", Password: "password123"})
+		assert.Error(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, post("/reset-password", `{"token":"`+token+`","password":"another-password"}`).Code)
+	})
+
+	t.Run("ExpiredResetLinksAreRefused", func(t *testing.T) {
+		sum := sha256.Sum256([]byte("expired-token"))
+		require.NoError(t, tokenRepo.CreatePasswordReset(ctx, &models.PasswordResetToken{
+			UserID:    user.ID.String(),
+			Token:     hex.EncodeToString(sum[:]),
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}))
+
+		assert.Equal(t, http.StatusUnauthorized, post("/reset-password", `{"token":"expired-token","password":"another-password"}`).Code)
+		_, _, err := authService.Login(&models.LoginRequest{Email: "casey@example.com", Password: "new-password-456"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("BadTokensLockedOut", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("/forgot-password", `{"email":"casey@example.com"}`).Code)
+		token := lastLink(services.PasswordResetPath)
+
+		// Guessing many tokens from one address blocks the address
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, postFrom("198.51.100.1:1000", "/reset-password", `{"token":"guess-`+strings.Repeat("x", i)+`","password":"another-password"}`).Code)
+		}
+		rec := postFrom("198.51.100.1:1000", "/reset-password", `{"token":"`+token+`","password":"another-password"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code, "even a valid token is refused while blocked")
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusOK, postFrom("198.51.100.2:1000", "/reset-password", `{"token":"`+token+`","password":"another-password"}`).Code)
+
+		// Trying one token from many addresses blocks the token
+		for i := 3; i < 6; i++ {
+			assert.Equal(t, http.StatusUnauthorized, postFrom(fmt.Sprintf("203.0.113.%d:1000", i), "/verify-email", `{"token":"tampered"}`).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, postFrom("203.0.113.9:1000", "/verify-email", `{"token":"tampered"}`).Code)
+
+		entries, err := auditRepo.List(ctx, &models.AuditLogQuery{Action: models.AuditActionTokenLockout, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		var purposes []string
+		for _, entry := range entries.Entries {
+			purposes = append(purposes, entry.EntityType)
+			assert.NotContains(t, entry.Details, "tampered", "tokens are only stored as fingerprints")
+		}
+		assert.ElementsMatch(t, []string{models.AuditEntityPasswordReset, models.AuditEntityEmailVerification}, purposes)
+	})
+}
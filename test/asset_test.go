@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetTicketLinks(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	assetService := services.NewAssetService(repository.NewAssetRepository(db), ticketRepo, userRepo)
+
+	owner := &models.User{Email: "owner@example.com", PasswordHash: "x", FirstName: "Own", LastName: "Er", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, userRepo.Create(owner))
+
+	serial := " SN-001 "
+	laptop, err := assetService.CreateAsset(ctx, &models.CreateAssetRequest{Name: "Owner laptop", Type: "Laptop", SerialNumber: &serial, OwnerID: &owner.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "laptop", laptop.Type)
+	assert.Equal(t, "SN-001", *laptop.SerialNumber)
+	if assert.NotNil(t, laptop.Owner) {
+		assert.Equal(t, owner.Email, laptop.Owner.Email)
+	}
+	printer, err := assetService.CreateAsset(ctx, &models.CreateAssetRequest{Name: "3rd floor printer", Type: "printer"})
+	assert.NoError(t, err)
+
+	t.Run("DuplicateSerialRejected", func(t *testing.T) {
+		duplicate := "SN-001"
+		_, err := assetService.CreateAsset(ctx, &models.CreateAssetRequest{Name: "Clone", Type: "laptop", SerialNumber: &duplicate})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("UnknownOwnerRejected", func(t *testing.T) {
+		unknown := printer.ID
+		_, err := assetService.UpdateAsset(ctx, printer.ID, &models.UpdateAssetRequest{OwnerID: &unknown})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	newTicket := func(status models.TicketStatus) *models.Ticket {
+		ticket := &models.Ticket{Title: "Broken", Description: "Again", Status: status, Priority: models.PriorityLow, CreatedByID: owner.ID}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	first := newTicket(models.StatusOpen)
+	second := newTicket(models.StatusClosed)
+	third := newTicket(models.StatusOpen)
+
+	assert.NoError(t, assetService.LinkTicket(ctx, first.ID, laptop.ID))
+	assert.NoError(t, assetService.LinkTicket(ctx, first.ID, laptop.ID), "linking twice is a no-op")
+	assert.NoError(t, assetService.LinkTicket(ctx, second.ID, laptop.ID))
+	assert.NoError(t, assetService.LinkTicket(ctx, third.ID, printer.ID))
+
+	t.Run("TicketsPerAsset", func(t *testing.T) {
+		tickets, err := assetService.GetAssetTickets(ctx, laptop.ID)
+		assert.NoError(t, err)
+		assert.Len(t, tickets, 2)
+
+		assets, err := assetService.GetTicketAssets(ctx, first.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, assets, 1) {
+			assert.Equal(t, laptop.ID, assets[0].ID)
+		}
+	})
+
+	t.Run("ReportRanksAssetsByTickets", func(t *testing.T) {
+		report, err := assetService.TicketReport(ctx, 0)
+		assert.NoError(t, err)
+		if assert.Len(t, report, 2) {
+			assert.Equal(t, laptop.ID, report[0].AssetID)
+			assert.EqualValues(t, 2, report[0].TicketCount)
+			assert.EqualValues(t, 1, report[0].OpenCount)
+			assert.Equal(t, printer.ID, report[1].AssetID)
+		}
+	})
+
+	t.Run("DeleteRemovesLinks", func(t *testing.T) {
+		assert.NoError(t, assetService.DeleteAsset(ctx, printer.ID))
+		assets, err := assetService.GetTicketAssets(ctx, third.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, assets)
+
+		_, err = assetService.GetAsset(ctx, printer.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+}
@@ -0,0 +1,219 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignmentHistory(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com"},
+		Tickets:  config.TicketsConfig{ReassignmentAlertThreshold: 2},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	register := func(email, firstName string, role models.UserRole) (*models.User, string) {
+		response, tokens, err := authService.Register(&models.RegisterRequest{Email: email, Password: "password123", FirstName: firstName, LastName: "Smith", Role: role})
+		require.NoError(t, err)
+		return response.User, tokens.AccessToken
+	}
+	first, token := register("first@example.com", "First", models.RoleSupportAgent)
+	second, secondToken := register("second@example.com", "Second", models.RoleSupportAgent)
+	third, thirdToken := register("third@example.com", "Third", models.RoleSupportAgent)
+	manager, _ := register("manager@example.com", "Maria", models.RoleManager)
+	requester, requesterToken := register("requester@example.com", "Alex", models.RoleEndUser)
+
+	ticket := &models.Ticket{Title: "Printer jammed", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	doAs := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	assign := func(agent *models.User) {
+		rec := doAs(token, http.MethodPost, "/api/v1/tickets/"+ticket.ID.String()+"/assign", `{"agent_id":"`+agent.ID.String()+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	activity := func() []models.TicketActivity {
+		rec := doAs(token, http.MethodGet, "/api/v1/tickets/"+ticket.ID.String()+"/activity", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var activity []models.TicketActivity
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &activity))
+		return activity
+	}
+	managerAlerts := func() int {
+		alerts := 0
+		for _, msg := range sender.messages {
+			if strings.HasPrefix(msg.Subject, "Reassigned") {
+				assert.Equal(t, []string{"manager@example.com"}, msg.To)
+				alerts++
+			}
+		}
+		return alerts
+	}
+
+	t.Run("RecordsAssignments", func(t *testing.T) {
+		assign(first)
+		assign(second)
+
+		feed := activity()
+		require.Len(t, feed, 2)
+		assert.Equal(t, models.ActivityAssigned, feed[0].Type)
+		assert.Equal(t, first.ID, *feed[0].ToID)
+		assert.Nil(t, feed[0].FromID)
+		assert.Equal(t, first.ID, feed[0].ActorID)
+		assert.Equal(t, second.ID, *feed[1].ToID)
+		assert.Equal(t, first.ID, *feed[1].FromID)
+		assert.Zero(t, managerAlerts())
+
+		// Only the agent assigned by someone else is emailed
+		var notices []string
+		for _, msg := range sender.messages {
+			if strings.HasPrefix(msg.Subject, "Assigned to you") {
+				notices = append(notices, msg.To...)
+				assert.Contains(t, msg.Text, "First Smith assigned ticket \"Printer jammed\" to you.")
+				assert.Contains(t, msg.Text, services.TicketPath+ticket.ID.String())
+			}
+		}
+		assert.Equal(t, []string{"second@example.com"}, notices)
+	})
+
+	t.Run("AlertsManagersOnceOverThreshold", func(t *testing.T) {
+		assign(third)
+		assert.Equal(t, 1, managerAlerts())
+
+		// Bouncing back to an earlier agent is not a new agent
+		assign(first)
+		assert.Equal(t, 1, managerAlerts())
+	})
+
+	t.Run("IncludesEscalations", func(t *testing.T) {
+		require.NoError(t, ticketService.EscalateTicket(ctx, ticket.ID, &models.EscalateTicketRequest{EscalatedTo: manager.ID, Reason: "Needs a specialist"}, first.ID))
+
+		feed := activity()
+		require.Len(t, feed, 5)
+		last := feed[len(feed)-1]
+		assert.Equal(t, models.ActivityEscalated, last.Type)
+		assert.Equal(t, manager.ID, *last.ToID)
+		assert.Equal(t, "Needs a specialist", last.Note)
+	})
+
+	t.Run("ReturnsToPool", func(t *testing.T) {
+		unassign := func(body string) *httptest.ResponseRecorder {
+			return doAs(token, http.MethodPost, "/api/v1/tickets/"+ticket.ID.String()+"/unassign", body)
+		}
+		unassignedQueue := func() []models.Ticket {
+			rec := doAs(token, http.MethodGet, "/api/v1/tickets?unassigned=true", "")
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var response models.TicketListResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			return response.Tickets
+		}
+		assert.Empty(t, unassignedQueue())
+
+		assert.Equal(t, http.StatusBadRequest, unassign(`{"reason":"  "}`).Code)
+		rec := unassign(`{"reason":"Wrong team"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, http.StatusConflict, unassign(`{"reason":"Again"}`).Code)
+
+		queue := unassignedQueue()
+		require.Len(t, queue, 1)
+		assert.Equal(t, ticket.ID, queue[0].ID)
+		assert.Nil(t, queue[0].AssignedAgentID)
+
+		feed := activity()
+		last := feed[len(feed)-1]
+		assert.Equal(t, models.ActivityUnassigned, last.Type)
+		assert.Equal(t, first.ID, *last.FromID)
+		assert.Nil(t, last.ToID)
+		assert.Equal(t, "Wrong team", last.Note)
+	})
+
+	t.Run("TakesFromPoolOnce", func(t *testing.T) {
+		path := "/api/v1/tickets/" + ticket.ID.String() + "/take"
+		codes := make(chan int, 2)
+		var wg sync.WaitGroup
+		for _, token := range []string{secondToken, thirdToken} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- doAs(token, http.MethodPost, path, "").Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		var got []int
+		for code := range codes {
+			got = append(got, code)
+		}
+		assert.ElementsMatch(t, []int{http.StatusOK, http.StatusConflict}, got)
+
+		taken, err := ticketRepo.GetByID(ctx, ticket.ID)
+		require.NoError(t, err)
+		require.NotNil(t, taken.AssignedAgentID)
+		assert.Contains(t, []uuid.UUID{second.ID, third.ID}, *taken.AssignedAgentID)
+		feed := activity()
+		last := feed[len(feed)-1]
+		assert.Equal(t, models.ActivityAssigned, last.Type)
+		assert.Equal(t, *taken.AssignedAgentID, last.ActorID)
+
+		// Taking it again is a no-op for the agent who has it
+		takerToken := secondToken
+		if *taken.AssignedAgentID == third.ID {
+			takerToken = thirdToken
+		}
+		assert.Equal(t, http.StatusOK, doAs(takerToken, http.MethodPost, path, "").Code)
+		assert.Equal(t, http.StatusConflict, doAs(token, http.MethodPost, path, "").Code)
+	})
+
+	t.Run("RequiresAgent", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doAs(requesterToken, http.MethodGet, "/api/v1/tickets/"+ticket.ID.String()+"/activity", "").Code)
+		assert.Equal(t, http.StatusNotFound, doAs(token, http.MethodGet, "/api/v1/tickets/"+uuid.NewString()+"/activity", "").Code)
+	})
+}
@@ -0,0 +1,212 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachments(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Storage: config.StorageConfig{
+			MaxUploadSize: 1024,
+			AllowedTypes:  []string{"image/*", "text/plain", "application/pdf"},
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	storageDir := t.TempDir()
+	fileStorage := storage.NewLocalStorage(storageDir)
+	authService := services.NewAuthService(userRepo, cfg)
+
+	attachmentService := services.NewAttachmentService(attachmentRepo, repository.NewDraftAttachmentRepository(db), ticketRepo, commentRepo, fileStorage, cfg)
+
+	e := echo.New()
+	handlers.NewAttachmentHandler(attachmentService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	register := func(email string, role models.UserRole) (*models.User, string) {
+		response, tokens, err := authService.Register(&models.RegisterRequest{Email: email, Password: "password123", FirstName: "Test", LastName: "User", Role: role})
+		require.NoError(t, err)
+		return response.User, tokens.AccessToken
+	}
+	requester, requesterToken := register("requester@example.com", models.RoleEndUser)
+	agent, agentToken := register("agent@example.com", models.RoleSupportAgent)
+	_, strangerToken := register("stranger@example.com", models.RoleEndUser)
+
+	ticket := &models.Ticket{Title: "Screen flickers", Description: "See the photo", Status: models.StatusOpen, Priority: models.PriorityMedium, CreatedByID: requester.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+	base := "/api/v1/tickets/" + ticket.ID.String() + "/attachments"
+
+	call := func(req *http.Request, token string) *httptest.ResponseRecorder {
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	upload := func(token, filename, contentType string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		part, err := form.CreatePart(header)
+		require.NoError(t, err)
+		part.Write(content)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, base, &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		return call(req, token)
+	}
+
+	var photo models.Attachment
+	t.Run("RequesterUploads", func(t *testing.T) {
+		rec := upload(requesterToken, "../screen.png", "image/png", []byte("\x89PNG fake image"))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &photo))
+		assert.Equal(t, "screen.png", photo.Filename, "directories are stripped from the name")
+		assert.Equal(t, "image/png", photo.MimeType)
+		assert.EqualValues(t, 15, photo.FileSize)
+
+		// Without a declared type the extension decides
+		rec = upload(agentToken, "notes.txt", "", []byte("steps to reproduce"))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `"mime_type":"text/plain"`)
+	})
+
+	t.Run("Restrictions", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, upload(requesterToken, "setup.exe", "application/x-msdownload", []byte("MZ")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload(requesterToken, "page.html", "", []byte("<script>")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload(requesterToken, "empty.txt", "text/plain", nil).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, upload(requesterToken, "big.pdf", "application/pdf", bytes.Repeat([]byte("x"), 1025)).Code)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, upload(requesterToken, "huge.pdf", "application/pdf", bytes.Repeat([]byte("x"), 128<<10)).Code, "the body is cut off before it is read")
+		assert.Equal(t, http.StatusForbidden, upload(strangerToken, "spy.png", "image/png", []byte("x")).Code)
+
+		req := httptest.NewRequest(http.MethodPost, base, nil)
+		assert.Equal(t, http.StatusBadRequest, call(req, requesterToken).Code, "a file is required")
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		rec := call(httptest.NewRequest(http.MethodGet, base+"/"+photo.ID.String(), nil), agentToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "\x89PNG fake image", rec.Body.String())
+		assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, `attachment; filename=screen.png`, rec.Header().Get(echo.HeaderContentDisposition))
+		assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+
+		assert.Equal(t, http.StatusForbidden, call(httptest.NewRequest(http.MethodGet, base+"/"+photo.ID.String(), nil), strangerToken).Code)
+
+		other := &models.Ticket{Title: "Other", Description: "Other", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: agent.ID}
+		require.NoError(t, ticketRepo.Create(ctx, other))
+		path := "/api/v1/tickets/" + other.ID.String() + "/attachments/" + photo.ID.String()
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodGet, path, nil), agentToken).Code, "the attachment belongs to another ticket")
+	})
+
+	t.Run("InternalNoteFilesStayInternal", func(t *testing.T) {
+		note := &models.Comment{TicketID: ticket.ID, UserID: agent.ID, Content: "Log from the device", IsInternal: true}
+		require.NoError(t, commentRepo.Create(ctx, note))
+		_, err := fileStorage.Save(ctx, "attachments/internal.log", bytes.NewReader([]byte("secret")))
+		require.NoError(t, err)
+		log := &models.Attachment{TicketID: ticket.ID, CommentID: &note.ID, Filename: "internal.log", FilePath: "attachments/internal.log", FileSize: 6, MimeType: "text/plain", UploadedByID: agent.ID}
+		require.NoError(t, attachmentRepo.Create(ctx, log))
+
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodGet, base+"/"+log.ID.String(), nil), requesterToken).Code)
+		assert.Equal(t, http.StatusOK, call(httptest.NewRequest(http.MethodGet, base+"/"+log.ID.String(), nil), agentToken).Code)
+	})
+
+	t.Run("Thumbnails", func(t *testing.T) {
+		banner := image.NewRGBA(image.Rect(0, 0, 200, 100))
+		draw.Draw(banner, banner.Bounds(), image.NewUniform(color.RGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+		var encoded bytes.Buffer
+		require.NoError(t, png.Encode(&encoded, banner))
+
+		rec := upload(requesterToken, "banner.png", "image/png", encoded.Bytes())
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var uploaded models.Attachment
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &uploaded))
+		assert.Equal(t, models.ThumbnailPending, uploaded.ThumbnailStatus)
+		assert.Empty(t, uploaded.Previews)
+		thumbnailPath := base + "/" + uploaded.ID.String() + "/thumbnails/small"
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodGet, thumbnailPath, nil), requesterToken).Code, "thumbnails are made in the background")
+
+		require.NoError(t, attachmentService.GenerateThumbnails(ctx))
+		fake, err := attachmentRepo.GetByID(ctx, photo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ThumbnailFailed, fake.ThumbnailStatus, "the fake image cannot be read")
+		assert.Empty(t, fake.Previews)
+
+		ready, err := attachmentRepo.GetByID(ctx, uploaded.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ThumbnailReady, ready.ThumbnailStatus)
+		assert.Equal(t, []int{200, 100}, []int{ready.Width, ready.Height})
+		assert.Equal(t, []models.AttachmentPreview{
+			{Size: "small", URL: thumbnailPath, Width: 160, Height: 80},
+			{Size: "medium", URL: base + "/" + uploaded.ID.String() + "/thumbnails/medium", Width: 200, Height: 100},
+			{Size: "large", URL: base + "/" + uploaded.ID.String() + "/thumbnails/large", Width: 200, Height: 100},
+		}, ready.Previews, "images are not enlarged")
+
+		rec = call(httptest.NewRequest(http.MethodGet, thumbnailPath, nil), agentToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+		small, err := png.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 160, 80), small.Bounds())
+		r, _, _, _ := small.At(80, 40).RGBA()
+		assert.Equal(t, uint32(200*0x101), r)
+
+		assert.Equal(t, http.StatusForbidden, call(httptest.NewRequest(http.MethodGet, thumbnailPath, nil), strangerToken).Code)
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodGet, base+"/"+uploaded.ID.String()+"/thumbnails/huge", nil), requesterToken).Code)
+
+		require.Equal(t, http.StatusNoContent, call(httptest.NewRequest(http.MethodDelete, base+"/"+uploaded.ID.String(), nil), requesterToken).Code)
+		entries, err := os.ReadDir(filepath.Join(storageDir, "thumbnails", ticket.ID.String()))
+		require.NoError(t, err)
+		assert.Empty(t, entries, "thumbnails are removed with the attachment")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		path := base + "/" + photo.ID.String()
+		assert.Equal(t, http.StatusForbidden, call(httptest.NewRequest(http.MethodDelete, path, nil), strangerToken).Code)
+		assert.Equal(t, http.StatusNoContent, call(httptest.NewRequest(http.MethodDelete, path, nil), requesterToken).Code)
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodGet, path, nil), requesterToken).Code)
+
+		_, err := fileStorage.Open(ctx, photo.FilePath)
+		assert.Error(t, err, "the file is removed with the record")
+	})
+}
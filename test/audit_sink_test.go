@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/auditsink"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectorRequest is a request received by a fake audit collector
+type collectorRequest struct {
+	Path          string
+	Authorization string
+	ContentType   string
+	Body          string
+}
+
+func TestAuditSinks(t *testing.T) {
+	cfg := &config.Config{Database: config.DatabaseConfig{FilePath: ":memory:"}}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+	auditRepo := repository.NewAuditLogRepository(db)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var received []collectorRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, collectorRequest{Path: r.URL.Path, Authorization: r.Header.Get("Authorization"), ContentType: r.Header.Get("Content-Type"), Body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer collector.Close()
+
+	syslogServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer syslogServer.Close()
+
+	t.Setenv("AUDIT_HTTP_TOKEN", "hec-token")
+	t.Setenv("AUDIT_EVENTHUB_KEY", "hub-key")
+	auditCfg := config.AuditConfig{
+		Sinks:             []string{"syslog", "http", "eventhub"},
+		BufferSize:        10,
+		FlushInterval:     "10ms",
+		SyslogNetwork:     "udp",
+		SyslogAddress:     syslogServer.LocalAddr().String(),
+		HTTPURL:           collector.URL + "/services/collector/event",
+		HTTPFormat:        "splunk",
+		HTTPTokenSecret:   "AUDIT_HTTP_TOKEN",
+		EventHubEndpoint:  collector.URL,
+		EventHubName:      "audit",
+		EventHubKeyName:   "send",
+		EventHubKeySecret: "AUDIT_EVENTHUB_KEY",
+	}
+
+	t.Run("ForwardsRecordedEvents", func(t *testing.T) {
+		forwarder, err := auditsink.New(ctx, auditCfg, secrets.EnvProvider{})
+		require.NoError(t, err)
+		auditService := services.NewAuditService(auditRepo, forwarder)
+
+		require.NoError(t, auditService.Record(ctx, nil, models.AuditActionConfigReload, models.AuditEntitySystem, nil, map[string]string{"by": "test"}))
+		shutdown, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		require.NoError(t, forwarder.Close(shutdown))
+
+		// The database is still the system of record
+		stored, err := auditService.List(ctx, &models.AuditLogQuery{})
+		require.NoError(t, err)
+		require.Len(t, stored.Entries, 1)
+
+		syslogServer.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 4096)
+		n, _, err := syslogServer.ReadFrom(buf)
+		require.NoError(t, err)
+		message := string(buf[:n])
+		assert.True(t, strings.HasPrefix(message, "<110>1 "), message)
+		assert.Contains(t, message, " helpchat ")
+		assert.Contains(t, message, " CONFIG_RELOAD - {")
+		assert.Contains(t, message, stored.Entries[0].ID.String())
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, received, 2)
+		byPath := make(map[string]collectorRequest)
+		for _, req := range received {
+			byPath[req.Path] = req
+		}
+
+		hec := byPath["/services/collector/event"]
+		assert.Equal(t, "Splunk hec-token", hec.Authorization)
+		var event struct {
+			Sourcetype string          `json:"sourcetype"`
+			Event      models.AuditLog `json:"event"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(hec.Body), &event))
+		assert.Equal(t, "helpchat:audit", event.Sourcetype)
+		assert.Equal(t, models.AuditActionConfigReload, event.Event.Action)
+		assert.JSONEq(t, `{"by":"test"}`, event.Event.Details)
+
+		hub := byPath["/audit/messages"]
+		assert.Equal(t, "application/vnd.microsoft.servicebus.json", hub.ContentType)
+		assert.True(t, strings.HasPrefix(hub.Authorization, "SharedAccessSignature sr="), hub.Authorization)
+		assert.Contains(t, hub.Authorization, "&skn=send")
+		var messages []struct{ Body string }
+		require.NoError(t, json.Unmarshal([]byte(hub.Body), &messages))
+		require.Len(t, messages, 1)
+		assert.Contains(t, messages[0].Body, stored.Entries[0].ID.String())
+	})
+
+	t.Run("CollectorFailuresDoNotFailRecording", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer down.Close()
+		sink, err := auditsink.NewHTTPSink(down.URL, "json", "token")
+		require.NoError(t, err)
+		assert.Error(t, sink.Send(ctx, []models.AuditLog{{Action: "TEST"}}))
+
+		forwarder := auditsink.NewForwarder([]auditsink.Sink{sink}, 10, 10*time.Millisecond)
+		auditService := services.NewAuditService(auditRepo, forwarder)
+		assert.NoError(t, auditService.Record(ctx, nil, models.AuditActionConfigReload, models.AuditEntitySystem, nil, nil))
+		assert.NoError(t, forwarder.Close(ctx))
+	})
+
+	t.Run("RejectsInvalidConfiguration", func(t *testing.T) {
+		forwarder, err := auditsink.New(ctx, config.AuditConfig{}, secrets.EnvProvider{})
+		assert.NoError(t, err)
+		assert.Nil(t, forwarder, "no sinks, no forwarder")
+
+		for name, invalid := range map[string]config.AuditConfig{
+			"unknown sink":     {Sinks: []string{"kafka"}, FlushInterval: "1s"},
+			"missing address":  {Sinks: []string{"syslog"}, SyslogNetwork: "udp", FlushInterval: "1s"},
+			"unknown network":  {Sinks: []string{"syslog"}, SyslogNetwork: "sctp", SyslogAddress: "localhost:514", FlushInterval: "1s"},
+			"missing token":    {Sinks: []string{"http"}, HTTPURL: collector.URL, HTTPFormat: "json", HTTPTokenSecret: "AUDIT_MISSING_TOKEN", FlushInterval: "1s"},
+			"unknown format":   {Sinks: []string{"http"}, HTTPURL: collector.URL, HTTPFormat: "xml", HTTPTokenSecret: "AUDIT_HTTP_TOKEN", FlushInterval: "1s"},
+			"missing hub name": {Sinks: []string{"eventhub"}, EventHubEndpoint: collector.URL, FlushInterval: "1s"},
+			"invalid interval": {Sinks: []string{"syslog"}, SyslogNetwork: "udp", SyslogAddress: "localhost:514", FlushInterval: "soon"},
+		} {
+			_, err := auditsink.New(ctx, invalid, secrets.EnvProvider{})
+			assert.Error(t, err, name)
+		}
+	})
+}
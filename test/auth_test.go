@@ -13,6 +13,7 @@ import (
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
 
 	"github.com/labstack/echo/v4"
@@ -45,7 +46,11 @@ func TestAuthEndpoints(t *testing.T) {
 	// Initialize components
 	userRepo := repository.NewUserRepository(db)
 	authService := services.NewAuthService(userRepo, cfg)
-	authHandler := handlers.NewAuthHandler(authService)
+	botProtection, err := testMiddleware.NewBotProtection(cfg)
+	assert.NoError(t, err)
+	loginHistoryService := services.NewLoginHistoryService(repository.NewLoginEventRepository(db), email.NewNoopSender(), cfg)
+	accountService := services.NewAccountService(userRepo, repository.NewAccountTokenRepository(db), email.NewNoopSender(), nil, cfg)
+	authHandler := handlers.NewAuthHandler(authService, accountService, loginHistoryService, botProtection, testMiddleware.NewTokenLockout(nil, cfg))
 
 	// Setup Echo with validator
 	e := echo.New()
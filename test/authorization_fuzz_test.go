@@ -0,0 +1,200 @@
+package test
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grants snapshots which permissions every role holds
+func grants() map[models.UserRole][]string {
+	granted := make(map[models.UserRole][]string)
+	for _, role := range allRoles {
+		granted[role] = []string{}
+		for _, permission := range models.Permissions {
+			if role.HasPermission(permission) {
+				granted[role] = append(granted[role], permission)
+			}
+		}
+	}
+	return granted
+}
+
+// loadPolicy writes a role policy file and loads it
+func loadPolicy(t *testing.T, policy []byte) error {
+	file := filepath.Join(t.TempDir(), "rbac.json")
+	require.NoError(t, os.WriteFile(file, policy, 0o600))
+	return services.LoadRolePolicy(&config.Config{Security: config.SecurityConfig{RBACPolicyFile: file}})
+}
+
+// mutatePermission returns a near miss of a permission: another case, padding,
+// an extra scope or a truncation
+func mutatePermission(rng *rand.Rand, permission string) string {
+	switch rng.IntN(6) {
+	case 0:
+		return strings.ToUpper(permission)
+	case 1:
+		return " " + permission
+	case 2:
+		return permission + "\t"
+	case 3:
+		return permission + ":own"
+	case 4:
+		return permission[:rng.IntN(len(permission))]
+	default:
+		return strings.ReplaceAll(permission, ":", "::")
+	}
+}
+
+func FuzzHasPermission(f *testing.F) {
+	for _, role := range allRoles {
+		for _, permission := range models.Permissions {
+			f.Add(string(role), permission)
+		}
+	}
+	f.Add("administrator", "system:admin")
+	f.Add(" ADMINISTRATOR", "system:admin")
+	f.Add("ADMINISTRATOR", "SYSTEM:ADMIN")
+	f.Add("VIEWER", "report:read ")
+	f.Add("", "")
+	f.Add("END_USER", "ticket:read:own:own")
+
+	ami := testMiddleware.NewAuthMiddleware(nil, nil)
+	f.Fuzz(func(t *testing.T, role, permission string) {
+		granted := models.UserRole(role).HasPermission(permission)
+		assert.Equal(t, granted, (&models.User{Role: models.UserRole(role)}).HasPermission(permission))
+		assert.Equal(t, granted, ami.HasPermission(models.UserRole(role), permission))
+		if !granted {
+			return
+		}
+		// Only exact role and permission names are ever granted
+		assert.True(t, models.UserRole(role).IsValid(), "unknown role %q granted %q", role, permission)
+		assert.Contains(t, models.Permissions, permission, "%s granted unknown permission %q", role, permission)
+	})
+}
+
+func FuzzRolePolicy(f *testing.F) {
+	f.Add([]byte(`{"VIEWER": ["ticket:read", "ticket:read:all"]}`))
+	f.Add([]byte(`{"ADMINISTRATOR": ["system:admin"]}`))
+	f.Add([]byte(`{"ADMINISTRATOR": ["ticket:read"]}`))
+	f.Add([]byte(`{"ADMINISTRATOR": null}`))
+	f.Add([]byte(`{"viewer": ["ticket:read"]}`))
+	f.Add([]byte(`{" VIEWER": ["ticket:read"]}`))
+	f.Add([]byte(`{"VIEWER": ["TICKET:READ"]}`))
+	f.Add([]byte(`{"VIEWER": [" ticket:read"]}`))
+	f.Add([]byte(`{"VIEWER": null, "END_USER": []}`))
+	f.Add([]byte(`{"VIEWER": ["ticket:read"], "VIEWER": ["report:read"]}`))
+	f.Add([]byte(`["VIEWER"]`))
+	f.Add([]byte(`{`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, policy []byte) {
+		models.SetRolePermissions(nil)
+		defer models.SetRolePermissions(nil)
+		defaults := grants()
+
+		if err := loadPolicy(t, policy); err != nil {
+			assert.Equal(t, defaults, grants(), "a rejected policy must not change any role")
+			return
+		}
+
+		assert.True(t, models.RoleAdministrator.HasPermission(models.PermSystemAdmin), "administrators lost %s", models.PermSystemAdmin)
+		var parsed map[models.UserRole][]string
+		require.NoError(t, json.Unmarshal(policy, &parsed))
+		for _, role := range allRoles {
+			replaced, ok := parsed[role]
+			for _, permission := range models.Permissions {
+				want := slices.Contains(defaults[role], permission)
+				if ok {
+					want = slices.Contains(replaced, permission)
+				}
+				assert.Equal(t, want, role.HasPermission(permission), "%s %s", role, permission)
+			}
+		}
+	})
+}
+
+func TestPermissionProperties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(2991, 1))
+	t.Cleanup(func() { models.SetRolePermissions(nil) })
+
+	t.Run("NearMissesAreNotGranted", func(t *testing.T) {
+		models.SetRolePermissions(nil)
+		for range 2000 {
+			role := allRoles[rng.IntN(len(allRoles))]
+			permission := models.Permissions[rng.IntN(len(models.Permissions))]
+			mutated := mutatePermission(rng, permission)
+			if slices.Contains(models.Permissions, mutated) {
+				// Truncating or scoping can land on another real permission
+				continue
+			}
+			assert.False(t, role.HasPermission(mutated), "%s granted %q", role, mutated)
+			assert.False(t, models.UserRole(strings.ToLower(string(role))).HasPermission(permission), "lower-case %s granted %q", role, permission)
+		}
+	})
+
+	t.Run("RandomPoliciesApplyExactly", func(t *testing.T) {
+		for range 300 {
+			models.SetRolePermissions(nil)
+			policy := make(map[models.UserRole][]string)
+			for _, role := range allRoles {
+				if rng.IntN(2) == 0 {
+					continue
+				}
+				granted := []string{}
+				for _, permission := range models.Permissions {
+					if rng.IntN(2) == 0 {
+						granted = append(granted, permission)
+					}
+				}
+				policy[role] = granted
+			}
+			data, err := json.Marshal(policy)
+			require.NoError(t, err)
+
+			err = loadPolicy(t, data)
+			administrators, replaced := policy[models.RoleAdministrator]
+			if replaced && !slices.Contains(administrators, models.PermSystemAdmin) {
+				assert.Error(t, err, "%s", data)
+				continue
+			}
+			require.NoError(t, err, "%s", data)
+			for role, granted := range policy {
+				for _, permission := range models.Permissions {
+					assert.Equal(t, slices.Contains(granted, permission), role.HasPermission(permission), "%s %s under %s", role, permission, data)
+				}
+			}
+		}
+	})
+
+	t.Run("UnknownNamesAreRejected", func(t *testing.T) {
+		for range 500 {
+			models.SetRolePermissions(nil)
+			role := allRoles[rng.IntN(len(allRoles))]
+			permission := models.Permissions[rng.IntN(len(models.Permissions))]
+			mutated := mutatePermission(rng, permission)
+			if slices.Contains(models.Permissions, mutated) {
+				continue
+			}
+			data, err := json.Marshal(map[models.UserRole][]string{role: {models.PermSystemAdmin, mutated}})
+			require.NoError(t, err)
+			assert.Error(t, loadPolicy(t, data), "%s", data)
+
+			data, err = json.Marshal(map[string][]string{" " + strings.ToLower(string(role)): {permission}})
+			require.NoError(t, err)
+			assert.Error(t, loadPolicy(t, data), "%s", data)
+		}
+	})
+}
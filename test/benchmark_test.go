@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/stretchr/testify/require"
+)
+
+// benchmarkTickets is how many tickets the benchmarks run against
+const benchmarkTickets = 2000
+
+// benchmarkFixture is a database seeded with tickets spread over every status
+// and priority, and a signed-in agent
+type benchmarkFixture struct {
+	db          *database.Database
+	ticketRepo  repository.TicketRepository
+	authService *services.AuthService
+	agent       *models.User
+	token       string
+	tickets     []*models.Ticket
+}
+
+func newBenchmarkFixture(b *testing.B) *benchmarkFixture {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(b, err)
+	b.Cleanup(func() { db.Close() })
+	require.NoError(b, database.RunMigrations(db))
+
+	f := &benchmarkFixture{
+		db:          db,
+		ticketRepo:  repository.NewTicketRepository(db),
+		authService: services.NewAuthService(repository.NewUserRepository(db), cfg),
+	}
+	auth, tokens, err := f.authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(b, err)
+	f.agent, f.token = auth.User, tokens.AccessToken
+
+	ctx := context.Background()
+	statuses := []models.TicketStatus{models.StatusOpen, models.StatusInProgress, models.StatusResolved, models.StatusClosed}
+	priorities := []models.TicketPriority{models.PriorityLow, models.PriorityMedium, models.PriorityHigh, models.PriorityCritical}
+	for i := range benchmarkTickets {
+		ticket := &models.Ticket{
+			Title:       fmt.Sprintf("Ticket %d", i),
+			Description: "Seeded for benchmarks",
+			Status:      statuses[i%len(statuses)],
+			Priority:    priorities[i/len(statuses)%len(priorities)],
+			CreatedByID: f.agent.ID,
+		}
+		if i%3 == 0 {
+			ticket.AssignedAgentID = &f.agent.ID
+		}
+		require.NoError(b, f.ticketRepo.Create(ctx, ticket))
+		f.tickets = append(f.tickets, ticket)
+	}
+	return f
+}
+
+func BenchmarkTicketRepositoryList(b *testing.B) {
+	f := newBenchmarkFixture(b)
+	ctx := context.Background()
+	open := models.StatusOpen
+
+	for _, bench := range []struct {
+		name  string
+		query *models.TicketQuery
+	}{
+		{"FirstPage", &models.TicketQuery{Page: 1, PageSize: 20}},
+		{"DeepPage", &models.TicketQuery{Page: 50, PageSize: 20}},
+		{"WithoutCount", &models.TicketQuery{Page: 1, PageSize: 20, SkipCount: true}},
+		{"Filtered", &models.TicketQuery{Page: 1, PageSize: 20, Filter: &models.TicketFilter{Status: &open}}},
+		{"Search", &models.TicketQuery{Page: 1, PageSize: 20, Filter: &models.TicketFilter{Search: "Ticket 1"}}},
+		{"SortedByTitle", &models.TicketQuery{Page: 1, PageSize: 20, Sort: &models.TicketSort{Field: "title", Direction: "asc"}}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := f.ticketRepo.List(ctx, bench.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTicketRepositoryGetStats(b *testing.B) {
+	f := newBenchmarkFixture(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := f.ticketRepo.GetStats(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateToken(b *testing.B) {
+	f := newBenchmarkFixture(b)
+
+	for b.Loop() {
+		if _, err := f.authService.ValidateToken(f.token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTimeSeriesUpdate(b *testing.B) {
+	f := newBenchmarkFixture(b)
+	ctx := context.Background()
+	tickets := repository.NewTimeSeriesRepository[*models.Ticket](f.db)
+	// Each update expires the current version and creates the next
+	current := f.tickets[0]
+
+	for b.Loop() {
+		next, err := tickets.Update(ctx, current.ID, func(clone *models.Ticket) error {
+			clone.Priority = models.PriorityHigh
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		current = next
+	}
+}
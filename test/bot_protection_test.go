@@ -0,0 +1,72 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/captcha"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicRateLimit(t *testing.T) {
+	botProtection, err := testMiddleware.NewBotProtection(&config.Config{
+		RateLimit: config.RateLimitConfig{PublicRequestsPerMinute: 1, PublicBurst: 2},
+	})
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.POST("/limited", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, botProtection.RateLimit())
+
+	request := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("198.51.100.1:1000"))
+	assert.Equal(t, http.StatusOK, request("198.51.100.1:1001"))
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.1:1002"))
+
+	// Other clients have their own budget
+	assert.Equal(t, http.StatusOK, request("198.51.100.2:1000"))
+}
+
+func TestCaptchaSiteVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("response") {
+		case "good":
+			w.Write([]byte(`{"success": true, "score": 0.9}`))
+		case "low-score":
+			w.Write([]byte(`{"success": true, "score": 0.1}`))
+		default:
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	verifier := captcha.NewSiteVerifier(server.URL, "secret", 0.5)
+
+	assert.NoError(t, verifier.Verify(ctx, "good", "203.0.113.1"))
+	assert.ErrorIs(t, verifier.Verify(ctx, "low-score", ""), captcha.ErrVerificationFailed)
+	assert.ErrorIs(t, verifier.Verify(ctx, "bad", ""), captcha.ErrVerificationFailed)
+	assert.ErrorIs(t, verifier.Verify(ctx, "", ""), captcha.ErrMissingToken)
+
+	_, err := captcha.NewVerifier("unknown", "secret", 0)
+	assert.Error(t, err)
+}
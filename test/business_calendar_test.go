@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessCalendar(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Widget: config.WidgetConfig{
+			OfficeHours: "mon-fri 09:00-17:00",
+			Timezone:    "Europe/London",
+			Holidays:    "2026-12-25, 2026-12-28",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	userRepo := repository.NewUserRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(repository.NewTicketRepository(db), repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+	calendarService, err := services.NewBusinessCalendarService(cfg)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, ami)
+	handlers.NewCalendarHandler(calendarService).RegisterRoutes(e, ami)
+
+	_, tokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	t.Run("SkipsWeekendsAndHolidays", func(t *testing.T) {
+		// Thursday 24 December: Friday and the next Monday are holidays
+		from := time.Date(2026, 12, 24, 15, 0, 0, 0, london)
+		rec := do(http.MethodGet, "/api/v1/calendar/business-days?days=3&from="+url.QueryEscape(from.Format(time.RFC3339)), "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response models.BusinessDaysResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Days)
+		assert.Equal(t, "Europe/London", response.Timezone)
+		assert.True(t, time.Date(2026, 12, 31, 17, 0, 0, 0, london).Equal(response.Due), response.Due)
+	})
+
+	t.Run("AlwaysOpen", func(t *testing.T) {
+		calendar, err := services.NewBusinessCalendarService(&config.Config{Widget: config.WidgetConfig{Holidays: "2026-12-25"}})
+		require.NoError(t, err)
+		response, err := calendar.AddBusinessDays(time.Date(2026, 12, 24, 10, 0, 0, 0, time.UTC), 1)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 12, 26, 23, 59, 59, 0, time.UTC), response.Due)
+	})
+
+	t.Run("RejectsInvalidRequests", func(t *testing.T) {
+		for _, query := range []string{"", "days=0", "days=366", "days=three", "days=1&from=tomorrow"} {
+			assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/v1/calendar/business-days?"+query, "").Code, query)
+		}
+		_, err := services.NewBusinessCalendarService(&config.Config{Widget: config.WidgetConfig{Holidays: "25/12/2026"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("DueDatesMustBeInTheFuture", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		rec := do(http.MethodPost, "/api/v1/tickets", `{"title":"Late","description":"x","priority":"LOW","due_date":"`+past+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "due_date must be in the future")
+
+		rec = do(http.MethodPost, "/api/v1/tickets", `{"title":"On time","description":"x","priority":"LOW"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var ticket models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ticket))
+
+		yesterday := time.Now().AddDate(0, 0, -1).Format(time.DateOnly)
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/v1/tickets/"+ticket.ID.String(), `{"due_date":"`+yesterday+`"}`).Code)
+	})
+}
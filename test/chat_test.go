@@ -0,0 +1,644 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnowledgeSearch(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	knowledge := services.NewKnowledgeService(repository.NewKnowledgeRepository(db))
+	authorID := uuid.New()
+
+	vpn, err := knowledge.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "Connecting to the VPN", Body: "Open the client and sign in.", IsPublished: true}, authorID)
+	assert.NoError(t, err)
+	_, err = knowledge.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "Working from home", Body: "Use the VPN for internal sites.", IsPublished: true}, authorID)
+	assert.NoError(t, err)
+	reset, err := knowledge.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "Resetting your password", Body: "Use the self-service portal.", Keywords: "locked out, login", IsPublished: true}, authorID)
+	assert.NoError(t, err)
+	draft, err := knowledge.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "VPN outage runbook", Body: "Internal only"}, authorID)
+	assert.NoError(t, err)
+
+	t.Run("TitleMatchesRankFirst", func(t *testing.T) {
+		articles, err := knowledge.Search(ctx, "My VPN won't connect", 0)
+		assert.NoError(t, err)
+		if assert.Len(t, articles, 2) {
+			assert.Equal(t, vpn.ID, articles[0].ID)
+		}
+	})
+
+	t.Run("KeywordsMatch", func(t *testing.T) {
+		articles, err := knowledge.Search(ctx, "I'm locked out", 0)
+		assert.NoError(t, err)
+		if assert.Len(t, articles, 1) {
+			assert.Equal(t, reset.ID, articles[0].ID)
+		}
+	})
+
+	t.Run("DraftsAreHidden", func(t *testing.T) {
+		articles, err := knowledge.Search(ctx, "runbook", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, articles)
+
+		_, err = knowledge.GetArticle(ctx, draft.ID, false)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+		_, err = knowledge.GetArticle(ctx, draft.ID, true)
+		assert.NoError(t, err)
+	})
+}
+
+func TestChatbot(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress:       "support@helpchat.com",
+			MaxAttachmentSize: 1024,
+		},
+		Widget: config.WidgetConfig{
+			Greeting: "Hi there!",
+		},
+		Chat: config.ChatConfig{
+			SecretKey:          "chat-secret",
+			VisitorTokenTTL:    "1h",
+			MaxConcurrentChats: 3,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, &recordingSender{}, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, Notifier: emailChannel, Redaction: redaction})
+	intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+	knowledge := services.NewKnowledgeService(repository.NewKnowledgeRepository(db))
+	presence := services.NewPresenceService(cache.NewMemory(), userRepo, cfg)
+	widget, err := services.NewWidgetService(userRepo, repository.NewChatTriggerRepository(db), presence, cfg)
+	assert.NoError(t, err)
+	sender := &recordingSender{}
+	chats := services.NewChatService(repository.NewChatRepository(db), userRepo, teamRepo, knowledge, widget, intake, presence, realtime.NewHub(), sender, cfg)
+
+	_, err = knowledge.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "Printer is offline", Body: "Turn the printer off and on again.", IsPublished: true}, uuid.New())
+	assert.NoError(t, err)
+
+	lastMessage := func(session *models.ChatSession) models.ChatMessage {
+		return session.Messages[len(session.Messages)-1]
+	}
+	say := func(session *models.ChatSession, token, content string) *models.ChatSession {
+		updated, err := chats.SendVisitorMessage(ctx, session.ID, token, &models.SendChatMessageRequest{Content: content})
+		assert.NoError(t, err)
+		return updated
+	}
+
+	t.Run("CollectsDetailsAndSuggestsArticles", func(t *testing.T) {
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{}, "203.0.113.7")
+		assert.NoError(t, err)
+		session := started.Session
+		assert.Equal(t, models.ChatBot, session.Status)
+		assert.Equal(t, models.BotStepName, session.BotStep)
+		if assert.Len(t, session.Messages, 2) {
+			assert.Equal(t, "Hi there!", session.Messages[0].Content)
+		}
+
+		session = say(session, started.Token, "Jane Doe")
+		assert.Equal(t, models.BotStepEmail, session.BotStep)
+		session = say(session, started.Token, "not an email")
+		assert.Equal(t, models.BotStepEmail, session.BotStep)
+		session = say(session, started.Token, "jane@example.com")
+		assert.Equal(t, models.BotStepIssue, session.BotStep)
+		assert.Equal(t, "jane@example.com", session.VisitorEmail)
+
+		session = say(session, started.Token, "The printer says offline")
+		assert.Equal(t, models.BotStepSuggest, session.BotStep)
+		assert.Contains(t, lastMessage(session).Content, "Printer is offline")
+
+		session = say(session, started.Token, "Yes, thanks!")
+		assert.Equal(t, models.ChatEnded, session.Status)
+		assert.Nil(t, session.TicketID)
+
+		_, err = chats.SendVisitorMessage(ctx, session.ID, started.Token, &models.SendChatMessageRequest{Content: "hello?"})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("TokenIsBoundToItsChat", func(t *testing.T) {
+		first, err := chats.StartChat(ctx, &models.StartChatRequest{}, "")
+		assert.NoError(t, err)
+		second, err := chats.StartChat(ctx, &models.StartChatRequest{}, "")
+		assert.NoError(t, err)
+
+		_, err = chats.GetVisitorChat(ctx, first.Session.ID, second.Token)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		_, err = chats.GetVisitorChat(ctx, first.Session.ID, "")
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("OfflineHandOffCreatesTicketWithTranscript", func(t *testing.T) {
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Sam Guest", Email: "sam@example.com"}, "")
+		assert.NoError(t, err)
+		assert.Equal(t, models.BotStepIssue, started.Session.BotStep, "known details are not asked for")
+
+		session := say(started.Session, started.Token, "Payroll export fails")
+		assert.Equal(t, models.ChatEnded, session.Status)
+		if assert.NotNil(t, session.TicketID) {
+			ticket, err := ticketRepo.GetByID(ctx, *session.TicketID)
+			assert.NoError(t, err)
+			assert.Equal(t, "Payroll export fails", ticket.Title)
+			assert.Contains(t, ticket.Description, "Sam Guest: Payroll export fails")
+			assert.Contains(t, ticket.Description, "Bot: What can we help you with today?")
+		}
+	})
+
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Alex", LastName: "Agent", Role: models.RoleSupportAgent, IsActive: true}
+	otherAgent := &models.User{Email: "other@example.com", PasswordHash: "x", FirstName: "Olly", LastName: "Agent", Role: models.RoleSupportAgent, IsActive: true}
+	assert.NoError(t, userRepo.Create(agent))
+	assert.NoError(t, userRepo.Create(otherAgent))
+	presence.Connect(agent.ID)
+	presence.Connect(otherAgent.ID)
+
+	t.Run("HandOffRoutesToAnAgent", func(t *testing.T) {
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Pat", Email: "pat@example.com"}, "")
+		assert.NoError(t, err)
+
+		session := say(started.Session, started.Token, "Printer offline on floor 3")
+		assert.Equal(t, models.BotStepSuggest, session.BotStep)
+		session = say(session, started.Token, "No, I need a person")
+		assert.Equal(t, models.ChatActive, session.Status, "an agent with free capacity gets the chat straight away")
+		if !assert.NotNil(t, session.AgentID) {
+			return
+		}
+		assert.Equal(t, agent.ID, *session.AgentID, "ties go to the longest-serving agent")
+		assert.NotNil(t, session.AcceptedAt)
+
+		_, err = chats.AcceptChat(ctx, session.ID, otherAgent)
+		assert.ErrorIs(t, err, services.ErrConflict)
+
+		_, err = chats.SendAgentMessage(ctx, session.ID, otherAgent, &models.SendChatMessageRequest{Content: "Hi"})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		session, err = chats.SendAgentMessage(ctx, session.ID, agent, &models.SendChatMessageRequest{Content: "Hi Pat, let's take a look."})
+		assert.NoError(t, err)
+
+		session = say(session, started.Token, "Thanks")
+		assert.Equal(t, models.ChatActive, session.Status, "the bot stays quiet once an agent has the chat")
+		assert.Equal(t, models.SenderVisitor, lastMessage(session).SenderType)
+
+		session, err = chats.EndChat(ctx, session.ID, agent)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChatEnded, session.Status)
+		assert.NotNil(t, session.EndedAt)
+
+		// The whole conversation, bot included, is preserved
+		var senders []models.ChatSenderType
+		for _, message := range session.Messages {
+			senders = append(senders, message.SenderType)
+		}
+		assert.Contains(t, senders, models.SenderBot)
+		assert.Contains(t, senders, models.SenderAgent)
+		assert.Equal(t, models.SenderSystem, lastMessage(session).SenderType)
+	})
+
+	setCapacity := func(user *models.User, max *int) {
+		_, err := chats.SetAgentCapacity(ctx, user.ID, &models.SetChatCapacityRequest{MaxConcurrentChats: max})
+		assert.NoError(t, err)
+		user.MaxConcurrentChats = max
+	}
+	none, one := 0, 1
+
+	t.Run("QueuePositionAndLeavingForTicket", func(t *testing.T) {
+		// With every agent full, chats wait in the queue
+		setCapacity(agent, &none)
+		setCapacity(otherAgent, &none)
+
+		queue := func(name string) (*models.ChatSession, string) {
+			started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: name, Email: "queue@example.com"}, "")
+			assert.NoError(t, err)
+			session := say(started.Session, started.Token, "I want to talk to a human about VPN access")
+			assert.Equal(t, models.ChatQueued, session.Status)
+			assert.NotNil(t, session.QueuedAt)
+			return session, started.Token
+		}
+		first, firstToken := queue("Quinn")
+		second, secondToken := queue("Robin")
+
+		status, err := chats.QueueStatus(ctx, second.ID, secondToken)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), status.Position)
+		// The chat accepted earlier gives a wait history
+		if assert.NotNil(t, status.AverageWaitSeconds) && assert.NotNil(t, status.EstimatedWaitSeconds) {
+			assert.Equal(t, *status.AverageWaitSeconds*2, *status.EstimatedWaitSeconds)
+		}
+		_, err = chats.QueueStatus(ctx, second.ID, firstToken)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+
+		left, err := chats.LeaveQueue(ctx, first.ID, firstToken)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChatEnded, left.Status)
+		if assert.NotNil(t, left.TicketID) {
+			ticket, err := ticketRepo.GetByID(ctx, *left.TicketID)
+			assert.NoError(t, err)
+			assert.Contains(t, ticket.Description, "Quinn: I want to talk to a human about VPN access")
+		}
+		_, err = chats.LeaveQueue(ctx, first.ID, firstToken)
+		assert.ErrorIs(t, err, services.ErrConflict)
+
+		status, err = chats.QueueStatus(ctx, second.ID, secondToken)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), status.Position, "the queue moves up when a visitor leaves")
+
+		_, err = chats.EndVisitorChat(ctx, second.ID, secondToken)
+		assert.NoError(t, err)
+		status, err = chats.QueueStatus(ctx, second.ID, secondToken)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChatEnded, status.Status)
+		assert.Zero(t, status.Position)
+
+		counts := map[models.ChatQueueEventType]int64{}
+		for _, eventType := range []models.ChatQueueEventType{models.QueueEventJoined, models.QueueEventAccepted, models.QueueEventAbandoned, models.QueueEventConverted} {
+			var count int64
+			assert.NoError(t, db.DB.Model(&models.ChatQueueEvent{}).Where("type = ?", eventType).Count(&count).Error)
+			counts[eventType] = count
+		}
+		assert.Equal(t, map[models.ChatQueueEventType]int64{
+			models.QueueEventJoined:    3,
+			models.QueueEventAccepted:  1,
+			models.QueueEventAbandoned: 1,
+			models.QueueEventConverted: 1,
+		}, counts)
+	})
+
+	t.Run("CapacityRouting", func(t *testing.T) {
+		setCapacity(agent, &one)
+		setCapacity(otherAgent, &none)
+
+		start := func(name string) *models.ChatSession {
+			started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: name, Email: "capacity@example.com"}, "")
+			assert.NoError(t, err)
+			return say(started.Session, started.Token, "Please put me through to a person")
+		}
+		first := start("Casey")
+		assert.Equal(t, models.ChatActive, first.Status)
+		second := start("Drew")
+		assert.Equal(t, models.ChatQueued, second.Status, "overflow waits in the queue when every agent is full")
+
+		_, err := chats.AcceptChat(ctx, second.ID, agent)
+		assert.ErrorIs(t, err, services.ErrConflict, "agents cannot accept chats beyond their limit")
+
+		report, err := chats.CapacityReport(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), report.ActiveChats)
+		assert.Equal(t, int64(1), report.Capacity)
+		assert.Equal(t, 1.0, report.Utilization)
+		assert.Equal(t, int64(1), report.QueuedChats)
+
+		// Ending a chat frees the agent for the next one waiting
+		_, err = chats.EndChat(ctx, first.ID, agent)
+		assert.NoError(t, err)
+		second, err = chats.GetChat(ctx, second.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChatActive, second.Status)
+		assert.Equal(t, agent.ID, *second.AgentID)
+
+		// Raising a limit overflows new chats to the agent with the most room
+		capacity, err := chats.SetAgentCapacity(ctx, otherAgent.ID, &models.SetChatCapacityRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, capacity.MaxChats, "clearing the limit restores the default")
+		third := start("Emery")
+		if assert.Equal(t, models.ChatActive, third.Status) {
+			assert.Equal(t, otherAgent.ID, *third.AgentID)
+		}
+
+		_, err = chats.SetAgentCapacity(ctx, uuid.New(), &models.SetChatCapacityRequest{MaxConcurrentChats: &one})
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+
+	t.Run("TransferAndSupervision", func(t *testing.T) {
+		setCapacity(agent, nil)
+		setCapacity(otherAgent, nil)
+		manager := &models.User{Email: "manager@example.com", PasswordHash: "x", FirstName: "Morgan", LastName: "Manager", Role: models.RoleManager, IsActive: true, MaxConcurrentChats: &none}
+		assert.NoError(t, userRepo.Create(manager))
+
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Frankie", Email: "frankie@example.com"}, "")
+		assert.NoError(t, err)
+		session := say(started.Session, started.Token, "Please put me through to a person")
+		if !assert.Equal(t, models.ChatActive, session.Status) {
+			return
+		}
+		owner, other := agent, otherAgent
+		if *session.AgentID == otherAgent.ID {
+			owner, other = otherAgent, agent
+		}
+
+		_, err = chats.TransferChat(ctx, session.ID, other, &models.TransferChatRequest{AgentID: &other.ID})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		_, err = chats.TransferChat(ctx, session.ID, owner, &models.TransferChatRequest{})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+
+		session, err = chats.TransferChat(ctx, session.ID, owner, &models.TransferChatRequest{AgentID: &other.ID, Note: "Needs a VPN reset"})
+		assert.NoError(t, err)
+		assert.Equal(t, other.ID, *session.AgentID)
+		assert.Equal(t, owner.ID, *session.TransferredFromID)
+		var notes []string
+		for _, message := range session.Messages {
+			if message.Internal {
+				notes = append(notes, message.Content)
+			}
+		}
+		assert.Equal(t, []string{owner.FullName() + " transferred the chat to " + other.FullName() + ". Note: Needs a VPN reset"}, notes)
+
+		visitor, err := chats.GetVisitorChat(ctx, session.ID, started.Token)
+		assert.NoError(t, err)
+		for _, message := range visitor.Messages {
+			assert.False(t, message.Internal, "staff notes are hidden from the visitor")
+		}
+		assert.Equal(t, other.FirstName+" joined the chat.", lastMessage(visitor).Content)
+
+		// A team transfer re-queues the chat for the team's members only
+		team := &models.Team{Name: "Network"}
+		assert.NoError(t, teamRepo.Create(ctx, team))
+		assert.NoError(t, teamRepo.AddMember(ctx, team.ID, owner.ID))
+		session, err = chats.TransferChat(ctx, session.ID, other, &models.TransferChatRequest{TeamID: &team.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChatActive, session.Status)
+		assert.Equal(t, owner.ID, *session.AgentID)
+		assert.Equal(t, team.ID, *session.TeamID)
+
+		// Supervisors can watch silently, then barge in
+		session, err = chats.MonitorChat(ctx, session.ID, manager)
+		assert.NoError(t, err)
+		if assert.Len(t, session.Participants, 1) {
+			assert.Equal(t, models.ParticipantMonitoring, session.Participants[0].Mode)
+		}
+		assert.True(t, lastMessage(session).Internal)
+		_, err = chats.SendAgentMessage(ctx, session.ID, manager, &models.SendChatMessageRequest{Content: "Hello"})
+		assert.ErrorIs(t, err, services.ErrForbidden, "monitoring supervisors cannot message the visitor")
+
+		visitor, err = chats.GetVisitorChat(ctx, session.ID, started.Token)
+		assert.NoError(t, err)
+		assert.Empty(t, visitor.Participants)
+		assert.Equal(t, owner.FirstName+" joined the chat.", lastMessage(visitor).Content)
+
+		session, err = chats.JoinChat(ctx, session.ID, manager)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ParticipantJoined, session.Participants[0].Mode)
+		assert.Equal(t, "Morgan joined the chat.", lastMessage(session).Content)
+		_, err = chats.SendAgentMessage(ctx, session.ID, manager, &models.SendChatMessageRequest{Content: "I'm the team lead, happy to help too."})
+		assert.NoError(t, err)
+		_, err = chats.MonitorChat(ctx, session.ID, manager)
+		assert.ErrorIs(t, err, services.ErrConflict)
+		_, err = chats.JoinChat(ctx, session.ID, owner)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("TypingAndReceiptsOverWebSocket", func(t *testing.T) {
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Gale", Email: "gale@example.com"}, "")
+		assert.NoError(t, err)
+		session := say(started.Session, started.Token, "Please put me through to a person")
+		if !assert.Equal(t, models.ChatActive, session.Status) {
+			return
+		}
+		owner := agent
+		if *session.AgentID == otherAgent.ID {
+			owner = otherAgent
+		}
+
+		e := echo.New()
+		e.GET("/chats/:id/ws", handlers.NewChatHandler(chats, nil, nil, cfg).VisitorSocket)
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/chats/" + session.ID.String() + "/ws?token=" + started.Token
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		_, _, err = websocket.DefaultDialer.Dial(strings.Replace(url, started.Token, "forged", 1), nil)
+		assert.Error(t, err, "a visitor socket needs the chat token")
+
+		staff, err := chats.SubscribeStaff(ctx, session.ID)
+		assert.NoError(t, err)
+		defer staff.Close()
+
+		// nextStaff and nextVisitor skip to the next event of a type
+		nextStaff := func(eventType models.ChatEventType) *models.ChatEvent {
+			for {
+				select {
+				case event := <-staff.Events():
+					if chatEvent := event.(*models.ChatEvent); chatEvent.Type == eventType {
+						return chatEvent
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("no %s event for staff", eventType)
+				}
+			}
+		}
+		nextVisitor := func(eventType models.ChatEventType) *models.ChatEvent {
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			for {
+				var event models.ChatEvent
+				if err := conn.ReadJSON(&event); err != nil {
+					t.Fatalf("no %s event for the visitor: %v", eventType, err)
+				}
+				if event.Type == eventType {
+					return &event
+				}
+			}
+		}
+
+		assert.NoError(t, conn.WriteJSON(models.ChatClientFrame{Type: models.ChatEventTypingStart}))
+		typing := nextStaff(models.ChatEventTypingStart)
+		assert.Equal(t, models.SenderVisitor, typing.Sender)
+
+		_, err = chats.SendAgentMessage(ctx, session.ID, owner, &models.SendChatMessageRequest{Content: "Looking into it now"})
+		assert.NoError(t, err)
+		pushed := nextVisitor(models.ChatEventMessage)
+		if !assert.NotNil(t, pushed.Message) {
+			return
+		}
+		assert.Equal(t, "Looking into it now", pushed.Message.Content)
+
+		assert.NoError(t, conn.WriteJSON(models.ChatClientFrame{Type: models.ChatEventRead, MessageID: &pushed.Message.ID}))
+		read := nextStaff(models.ChatEventRead)
+		assert.Contains(t, read.MessageIDs, pushed.Message.ID)
+
+		session, err = chats.GetChat(ctx, session.ID)
+		assert.NoError(t, err)
+		for _, message := range session.Messages {
+			switch {
+			case message.ID == pushed.Message.ID:
+				assert.NotNil(t, message.ReadAt, "receipts are persisted per message")
+				assert.NotNil(t, message.DeliveredAt, "reading implies delivery")
+			case message.SenderType == models.SenderVisitor:
+				assert.Nil(t, message.ReadAt, "visitors do not acknowledge their own messages")
+			}
+		}
+
+		// The agent's receipts reach the visitor
+		var lastVisitorMessage uuid.UUID
+		for _, message := range session.Messages {
+			if message.SenderType == models.SenderVisitor {
+				lastVisitorMessage = message.ID
+			}
+		}
+		assert.NoError(t, chats.HandleStaffFrame(ctx, session.ID, staff, owner, &models.ChatClientFrame{Type: models.ChatEventDelivered, MessageID: &lastVisitorMessage}))
+		delivered := nextVisitor(models.ChatEventDelivered)
+		assert.Equal(t, models.SenderAgent, delivered.Sender)
+		assert.Contains(t, delivered.MessageIDs, lastVisitorMessage)
+
+		assert.NoError(t, conn.WriteJSON(map[string]string{"type": "message.read"}))
+		failed := nextVisitor(models.ChatEventError)
+		assert.Contains(t, failed.Error, "message_id is required")
+	})
+
+	t.Run("RatingsAndTranscripts", func(t *testing.T) {
+		plenty := 10
+		setCapacity(agent, &plenty)
+		setCapacity(otherAgent, &plenty)
+
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Harper", Email: "harper@example.com"}, "")
+		assert.NoError(t, err)
+		session, err := chats.RequestTranscript(ctx, started.Session.ID, started.Token)
+		assert.NoError(t, err)
+		assert.True(t, session.TranscriptRequested)
+		_, err = chats.RateChat(ctx, session.ID, started.Token, &models.RateChatRequest{Score: 5})
+		assert.ErrorIs(t, err, services.ErrConflict, "only ended chats can be rated")
+
+		session = say(session, started.Token, "Please put me through to a person")
+		if !assert.Equal(t, models.ChatActive, session.Status) {
+			return
+		}
+		owner := agent
+		if *session.AgentID == otherAgent.ID {
+			owner = otherAgent
+		}
+		_, err = chats.SendAgentMessage(ctx, session.ID, owner, &models.SendChatMessageRequest{Content: "All sorted, Harper"})
+		assert.NoError(t, err)
+
+		sent := len(sender.messages)
+		_, err = chats.EndChat(ctx, session.ID, owner)
+		assert.NoError(t, err)
+		if assert.Len(t, sender.messages, sent+1, "the requested transcript is sent when the chat ends") {
+			msg := sender.messages[sent]
+			assert.Equal(t, []string{"harper@example.com"}, msg.To)
+			assert.Contains(t, msg.Text, owner.FirstName+": All sorted, Harper")
+		}
+		session, err = chats.RequestTranscript(ctx, session.ID, started.Token)
+		assert.NoError(t, err)
+		assert.NotNil(t, session.TranscriptSentAt)
+		assert.Len(t, sender.messages, sent+1, "the transcript is only sent once")
+
+		_, err = chats.RateChat(ctx, session.ID, "forged", &models.RateChatRequest{Score: 1})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		session, err = chats.RateChat(ctx, session.ID, started.Token, &models.RateChatRequest{Score: 5, Comment: " Quick and friendly "})
+		assert.NoError(t, err)
+		if assert.NotNil(t, session.Rating) {
+			assert.Equal(t, 5, *session.Rating)
+		}
+		assert.Equal(t, "Quick and friendly", session.RatingComment)
+		_, err = chats.RateChat(ctx, session.ID, started.Token, &models.RateChatRequest{Score: 1})
+		assert.ErrorIs(t, err, services.ErrConflict, "a chat is rated once")
+
+		// A visitor who never gave an email can still rate the chat
+		anonymous, err := chats.StartChat(ctx, &models.StartChatRequest{}, "")
+		assert.NoError(t, err)
+		_, err = chats.EndVisitorChat(ctx, anonymous.Session.ID, anonymous.Token)
+		assert.NoError(t, err)
+		_, err = chats.RequestTranscript(ctx, anonymous.Session.ID, anonymous.Token)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = chats.RateChat(ctx, anonymous.Session.ID, anonymous.Token, &models.RateChatRequest{Score: 2})
+		assert.NoError(t, err)
+
+		report, err := chats.CSATReport(ctx, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), report.RatedChats)
+		assert.GreaterOrEqual(t, report.EndedChats, int64(2))
+		assert.Equal(t, map[int]int64{2: 1, 5: 1}, report.Distribution)
+		if assert.NotNil(t, report.AverageRating) && assert.NotNil(t, report.CSAT) {
+			assert.Equal(t, 3.5, *report.AverageRating)
+			assert.Equal(t, 0.5, *report.CSAT)
+		}
+		if assert.Len(t, report.Agents, 1, "chats no agent handled are left out of the agent breakdown") {
+			assert.Equal(t, owner.ID, report.Agents[0].AgentID)
+			assert.Equal(t, 1.0, report.Agents[0].CSAT)
+		}
+	})
+
+	t.Run("OnlyOnlineAgentsAreRouted", func(t *testing.T) {
+		setCapacity(agent, &none)
+		presence.Disconnect(otherAgent.ID)
+		assert.False(t, presence.IsOnline(otherAgent.ID))
+
+		started, err := chats.StartChat(ctx, &models.StartChatRequest{Name: "Indy", Email: "indy@example.com"}, "")
+		assert.NoError(t, err)
+		session := say(started.Session, started.Token, "Please put me through to a person")
+		assert.Equal(t, models.ChatQueued, session.Status, "offline agents are not routed chats")
+
+		agents, err := presence.ListAgents(ctx)
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, agents) {
+			assert.Equal(t, agent.ID, agents[0].AgentID, "online agents are listed first")
+			assert.True(t, agents[0].Online)
+			assert.NotNil(t, agents[0].LastSeenAt)
+		}
+		for _, entry := range agents {
+			if entry.AgentID == otherAgent.ID {
+				assert.False(t, entry.Online)
+				assert.Nil(t, entry.LastSeenAt)
+			}
+		}
+
+		// Coming online picks up the chat waiting
+		presence.Connect(otherAgent.ID)
+		session, err = chats.GetChat(ctx, session.ID)
+		assert.NoError(t, err)
+		if assert.Equal(t, models.ChatActive, session.Status) {
+			assert.Equal(t, otherAgent.ID, *session.AgentID)
+		}
+
+		// A second connection, such as another tab, keeps the agent online when one closes
+		presence.Connect(otherAgent.ID)
+		presence.Disconnect(otherAgent.ID)
+		assert.True(t, presence.IsOnline(otherAgent.ID))
+		setCapacity(agent, nil)
+	})
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/markdown"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownRendering(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "Emphasis",
+			source:   "**Restart** the *router* and the ~~modem~~, not my_config_file",
+			expected: "<p><strong>Restart</strong> the <em>router</em> and the <del>modem</del>, not my_config_file</p>\n",
+		},
+		{
+			name:     "HeadingsAndCode",
+			source:   "## Steps\n\nRun `ipconfig /all`:\n\n```\nC:\\> ipconfig <all>\n```",
+			expected: "<h2>Steps</h2>\n<p>Run <code>ipconfig /all</code>:</p>\n<pre><code>C:\\&gt; ipconfig &lt;all&gt;\n</code></pre>\n",
+		},
+		{
+			name:     "Lists",
+			source:   "1. Open settings\n2. Choose:\n   - Wi-Fi\n   - VPN\n\n> quoted *reply*",
+			expected: "<ol>\n<li>Open settings</li>\n<li>Choose:<ul>\n<li>Wi-Fi</li>\n<li>VPN</li>\n</ul>\n</li>\n</ol>\n<blockquote>\n<p>quoted <em>reply</em></p>\n</blockquote>\n",
+		},
+		{
+			name:     "Links",
+			source:   "See [the guide](https://help.example.com/vpn?a=1&b=2), [ticket](/tickets/42) or https://status.example.com.",
+			expected: "<p>See <a href=\"https://help.example.com/vpn?a=1&amp;b=2\" rel=\"nofollow noopener noreferrer\">the guide</a>, <a href=\"/tickets/42\" rel=\"nofollow noopener noreferrer\">ticket</a> or <a href=\"https://status.example.com\" rel=\"nofollow noopener noreferrer\">https://status.example.com</a>.</p>\n",
+		},
+		{
+			name:     "RawHTMLIsText",
+			source:   "<script>alert(1)</script><img src=x onerror=alert(1)>",
+			expected: "<p>&lt;script&gt;alert(1)&lt;/script&gt;&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name:     "UnsafeLinksLoseTheirTarget",
+			source:   "[a](javascript:alert(1)) [b](JaVaScRiPt:alert(1)) [c](data:text/html,x) [d](//evil.example) [e](/\\evil.example) [f](vbscript:x)",
+			expected: "<p>a b c d e f</p>\n",
+		},
+		{
+			name:     "AttributesCannotBeBrokenOutOf",
+			source:   "[x](https://example.com/\"onmouseover=\"alert(1)) https://example.com/\"><script>",
+			expected: "<p><a href=\"https://example.com/%22onmouseover=%22alert%281%29\" rel=\"nofollow noopener noreferrer\">x</a> <a href=\"https://example.com/\" rel=\"nofollow noopener noreferrer\">https://example.com/</a>&#34;&gt;&lt;script&gt;</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, markdown.Render(tt.source))
+		})
+	}
+
+	t.Run("Plain", func(t *testing.T) {
+		assert.Equal(t, "<p>**not bold** &lt;b&gt;<br>\nline two</p>\n<p>see <a href=\"https://example.com\" rel=\"nofollow noopener noreferrer\">https://example.com</a></p>\n",
+			markdown.RenderPlain("**not bold** <b>\nline two\n\nsee https://example.com"))
+	})
+
+	t.Run("HostileInputStaysFast", func(t *testing.T) {
+		for _, source := range []string{
+			strings.Repeat("*a ", 100000),
+			strings.Repeat("[a](", 50000),
+			strings.Repeat("> ", 50000) + "deep",
+			strings.Repeat("- ", 50000) + "deep",
+		} {
+			assert.NotEmpty(t, markdown.Render(source))
+		}
+	})
+}
+
+func TestCommentFormats(t *testing.T) {
+	cfg := &config.Config{
+		Database:  config.DatabaseConfig{FilePath: ":memory:"},
+		Redaction: config.RedactionConfig{Enabled: true, Rules: []string{"credit_card"}},
+	}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Test", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	require.NoError(t, userRepo.Create(requester))
+	ticket := &models.Ticket{Title: "Printer offline", Description: "It says offline", Status: models.StatusOpen, Priority: models.PriorityMedium, CreatedByID: requester.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	markdownComment, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "**Still** offline <img src=x onerror=alert(1)>", Format: models.CommentFormatMarkdown}, requester)
+	require.NoError(t, err)
+	assert.Equal(t, "<p><strong>Still</strong> offline &lt;img src=x onerror=alert(1)&gt;</p>\n", markdownComment.HTML)
+
+	plainComment, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "**as typed**"}, requester)
+	require.NoError(t, err)
+	assert.Equal(t, models.CommentFormatPlain, plainComment.Format, "comments are plain unless said otherwise")
+
+	comments, err := ticketService.GetComments(ctx, ticket.ID, requester)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, markdownComment.HTML, comments[0].HTML, "comments are rendered when read")
+	assert.Equal(t, "<p>**as typed**</p>\n", comments[1].HTML)
+
+	preview := ticketService.PreviewComment(&models.CommentPreviewRequest{Content: "My card is 4111 1111 1111 1111, *please* help", Format: models.CommentFormatMarkdown})
+	assert.NotContains(t, preview.HTML, "4111 1111 1111 1111", "the preview is masked as the comment would be")
+	assert.Contains(t, preview.HTML, "<em>please</em>")
+}
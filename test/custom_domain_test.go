@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomDomain(t *testing.T) {
+	t.Run("NotConfigured", func(t *testing.T) {
+		cfg := config.Load()
+		assert.Equal(t, "support@helpchat.com", cfg.Email.FromAddress)
+		assert.Equal(t, "http://localhost:8080", cfg.Share.BaseURL)
+		assert.False(t, cfg.JWT.CookieSecure)
+
+		service, err := services.NewCustomDomainService(cfg)
+		require.NoError(t, err)
+		status := service.Status(context.Background())
+		assert.False(t, status.Configured)
+		assert.False(t, status.Ready)
+		assert.Empty(t, status.Checks)
+	})
+
+	t.Run("DomainSetsDefaults", func(t *testing.T) {
+		t.Setenv("CUSTOM_DOMAIN", "Support.Acme.invalid")
+		t.Setenv("CUSTOM_EMAIL_DOMAIN", "acme.invalid")
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.acme.invalid")
+
+		cfg := config.Load()
+		assert.Equal(t, "support.acme.invalid", cfg.CustomDomain.Domain)
+		assert.Equal(t, "support.acme.invalid", cfg.JWT.CookieDomain)
+		assert.True(t, cfg.JWT.CookieSecure)
+		assert.Equal(t, []string{"https://app.acme.invalid", "https://support.acme.invalid"}, cfg.CORS.AllowedOrigins)
+		assert.Equal(t, "https://support.acme.invalid", cfg.Share.BaseURL)
+		assert.Equal(t, "support@acme.invalid", cfg.Email.FromAddress)
+
+		service, err := services.NewCustomDomainService(cfg)
+		require.NoError(t, err)
+		status := service.Status(context.Background())
+		assert.True(t, status.Configured)
+		assert.False(t, status.Ready, "the .invalid domain never resolves")
+		assert.Equal(t, map[string]bool{
+			"dns":            false,
+			"cookie_domain":  true,
+			"secure_cookies": true,
+			"cors":           true,
+			"share_links":    true,
+			"email_from":     true,
+			"email_spf":      false,
+			"email_replies":  true,
+		}, customDomainChecks(status))
+	})
+
+	t.Run("ExplicitSettingsAreChecked", func(t *testing.T) {
+		t.Setenv("CUSTOM_DOMAIN", "support.acme.invalid")
+		t.Setenv("JWT_COOKIE_DOMAIN", ".acme.invalid")
+		t.Setenv("SHARE_BASE_URL", "https://helpchat.example.com")
+		t.Setenv("EMAIL_FROM_ADDRESS", "HelpChat <noreply@helpchat.example.com>")
+		t.Setenv("EMAIL_REPLY_DOMAIN", "reply.helpchat.example.com")
+
+		cfg := config.Load()
+		assert.Equal(t, "support.acme.invalid", cfg.CustomDomain.EmailDomain, "the email domain defaults to the domain")
+
+		service, err := services.NewCustomDomainService(cfg)
+		require.NoError(t, err)
+		checks := customDomainChecks(service.Status(context.Background()))
+		assert.True(t, checks["cookie_domain"], "a parent cookie domain covers the domain")
+		assert.False(t, checks["share_links"])
+		assert.False(t, checks["email_from"])
+		assert.False(t, checks["email_replies"])
+	})
+
+	t.Run("InvalidDomains", func(t *testing.T) {
+		for _, domain := range []string{"https://support.acme.com", "support.acme.com:8443", "support.acme.com/portal", "localhost", "10.0.0.1", "under_score.acme.com", "-support.acme.com"} {
+			t.Setenv("CUSTOM_DOMAIN", domain)
+			_, err := services.NewCustomDomainService(config.Load())
+			assert.Error(t, err, domain)
+		}
+	})
+}
+
+func customDomainChecks(status *models.CustomDomainStatus) map[string]bool {
+	checks := make(map[string]bool)
+	for _, check := range status.Checks {
+		checks[check.Name] = check.OK
+	}
+	return checks
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/requestid"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDatabasePool(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pool.db")
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath:     path,
+			MaxOpenConns: 3,
+			MaxIdleConns: 10,
+			BusyTimeout:  "20ms",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.DB.Exec("CREATE TABLE counters (n INTEGER)").Error)
+
+	t.Run("PoolConfigured", func(t *testing.T) {
+		stats := db.PoolStats()
+		assert.Len(t, stats, 1)
+		assert.Equal(t, "primary", stats[0].Name)
+		assert.Equal(t, 3, stats[0].MaxOpenConnections)
+
+		memory, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:", MaxOpenConns: 8}})
+		assert.NoError(t, err)
+		defer memory.Close()
+		assert.Equal(t, 1, memory.PoolStats()[0].MaxOpenConnections, "in-memory databases cannot be shared between connections")
+	})
+
+	// A second handle on the same file stands in for another process holding the write lock
+	other, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer other.Close()
+	insert := func(tx *gorm.DB) error { return tx.Exec("INSERT INTO counters (n) VALUES (1)").Error }
+
+	t.Run("RetriesWhileLocked", func(t *testing.T) {
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		go other.Transaction(ctx, func(tx *gorm.DB) error {
+			if err := insert(tx); err != nil {
+				return err
+			}
+			close(locked)
+			<-release
+			return nil
+		})
+		<-locked
+
+		err := db.Transaction(ctx, insert)
+		assert.True(t, database.IsBusy(err), "got %v", err)
+		assert.Equal(t, int64(2), db.BusyRetries())
+
+		// Released during the backoff, the retry goes through
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			close(release)
+		}()
+		assert.NoError(t, db.Transaction(ctx, insert))
+
+		var count int64
+		assert.NoError(t, db.DB.Raw("SELECT COUNT(*) FROM counters").Scan(&count).Error)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		e := echo.New()
+		handlers.NewMetricsHandler(db, nil, &config.Config{Metrics: config.MetricsConfig{Token: "scrape"}}).RegisterRoutes(e)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer scrape")
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/plain")
+		assert.Contains(t, rec.Body.String(), "# TYPE helpchat_db_connections_open gauge")
+		assert.Contains(t, rec.Body.String(), `helpchat_db_connections_max{db="primary"} 3`)
+		assert.Contains(t, rec.Body.String(), fmt.Sprintf("helpchat_db_busy_retries_total %d", db.BusyRetries()))
+		assert.NotContains(t, rec.Body.String(), "helpchat_db_replica_available", "no replica is configured")
+	})
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	db, err := database.NewDatabase(&config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+			// Every statement counts as slow
+			SlowQueryThreshold: "1ns",
+		},
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.DB.Exec("CREATE TABLE notes (id INTEGER, body TEXT)").Error)
+
+	ctx := requestid.NewContext(context.Background(), "req-42")
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, db.DB.WithContext(ctx).Exec("INSERT INTO notes (id, body) VALUES (?, ?)", i, "secret@example.com").Error)
+	}
+	assert.NoError(t, db.DB.Exec("SELECT * FROM notes WHERE id IN (?)", []int{1, 2, 3}).Error)
+
+	t.Run("GroupsByShape", func(t *testing.T) {
+		report := db.SlowQueries("count", 0)
+		assert.GreaterOrEqual(t, report.Total, int64(5))
+		assert.Equal(t, "INSERT INTO notes (id, body) VALUES (?, ...)", report.Queries[0].SQL)
+		assert.Equal(t, int64(3), report.Queries[0].Count)
+		assert.Equal(t, "req-42", report.Queries[0].LastRequestID)
+		for _, query := range report.Queries {
+			assert.NotContains(t, query.SQL, "secret@example.com", "literal values are not kept")
+		}
+
+		var shapes []string
+		for _, query := range report.Queries {
+			shapes = append(shapes, query.SQL)
+		}
+		assert.Contains(t, shapes, "SELECT * FROM notes WHERE id IN (?, ...)")
+	})
+
+	t.Run("AdminEndpoint", func(t *testing.T) {
+		e := echo.New()
+		handler := handlers.NewSlowQueryHandler(db)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/slow-queries?sort=count&limit=1", nil)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, handler.ListSlowQueries(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var report database.SlowQueryReport
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Len(t, report.Queries, 1)
+		assert.Equal(t, int64(3), report.Queries[0].Count)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/slow-queries?sort=name", nil)
+		rec = httptest.NewRecorder()
+		assert.NoError(t, handler.ListSlowQueries(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
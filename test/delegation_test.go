@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutOfOfficeDelegation(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress: "support@helpchat.com",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	delegation := services.NewDelegationService(repository.NewOutOfOfficeRepository(db), ticketRepo, userRepo)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Delegation: delegation, Notifier: emailChannel, Redaction: redaction})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		assert.NoError(t, userRepo.Create(user))
+		return user
+	}
+	away := newUser("away@example.com", models.RoleSupportAgent)
+	delegate := newUser("delegate@example.com", models.RoleSupportAgent)
+	colleague := newUser("colleague@example.com", models.RoleSupportAgent)
+	requester := newUser("requester@example.com", models.RoleEndUser)
+
+	newTicket := func(status models.TicketStatus) *models.Ticket {
+		ticket := &models.Ticket{Title: "Printer", Description: "Jammed", Status: status, Priority: models.PriorityLow, CreatedByID: requester.ID, AssignedAgentID: &away.ID}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	open := newTicket(models.StatusOpen)
+	inProgress := newTicket(models.StatusInProgress)
+	resolved := newTicket(models.StatusResolved)
+
+	now := time.Now()
+
+	t.Run("InvalidWindowsRejected", func(t *testing.T) {
+		_, err := delegation.SetOutOfOffice(ctx, away.ID, &models.SetOutOfOfficeRequest{DelegateID: away.ID, StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = delegation.SetOutOfOffice(ctx, away.ID, &models.SetOutOfOfficeRequest{DelegateID: delegate.ID, StartsAt: now, EndsAt: now.Add(-time.Hour)})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = delegation.SetOutOfOffice(ctx, away.ID, &models.SetOutOfOfficeRequest{DelegateID: requester.ID, StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("FutureWindowDoesNotRoute", func(t *testing.T) {
+		_, err := delegation.SetOutOfOffice(ctx, away.ID, &models.SetOutOfOfficeRequest{DelegateID: delegate.ID, StartsAt: now.Add(24 * time.Hour), EndsAt: now.Add(48 * time.Hour)})
+		assert.NoError(t, err)
+
+		assignee, err := delegation.ResolveAssignee(ctx, away.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, away.ID, assignee)
+	})
+
+	_, err = delegation.SetOutOfOffice(ctx, away.ID, &models.SetOutOfOfficeRequest{DelegateID: delegate.ID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour), Note: "On leave"})
+	assert.NoError(t, err)
+
+	t.Run("NewAssignmentsRouteToDelegate", func(t *testing.T) {
+		ticket := &models.Ticket{Title: "VPN", Description: "Down", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: requester.ID}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+
+		assert.NoError(t, ticketService.AssignTicket(ctx, ticket.ID, away.ID, colleague.ID))
+		ticket, err := ticketRepo.GetByID(ctx, ticket.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, delegate.ID, *ticket.AssignedAgentID)
+	})
+
+	t.Run("DelegationCycleStops", func(t *testing.T) {
+		_, err := delegation.SetOutOfOffice(ctx, delegate.ID, &models.SetOutOfOfficeRequest{DelegateID: away.ID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)})
+		assert.NoError(t, err)
+		defer delegation.ClearOutOfOffice(ctx, delegate.ID)
+
+		assignee, err := delegation.ResolveAssignee(ctx, away.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, delegate.ID, assignee)
+
+		_, err = delegation.HandoverOpenTickets(ctx, away.ID)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("MentionNotifiesAgentAndDelegate", func(t *testing.T) {
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, open.ID, &models.CreateCommentRequest{Content: "Can @away@example.com take a look?", IsInternal: true}, colleague)
+		assert.NoError(t, err)
+
+		if assert.Len(t, sender.messages, 1) {
+			assert.ElementsMatch(t, []string{away.Email, delegate.Email}, sender.messages[0].To)
+		}
+	})
+
+	t.Run("MentionOfRequesterIgnored", func(t *testing.T) {
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, open.ID, &models.CreateCommentRequest{Content: "ping @requester@example.com", IsInternal: true}, colleague)
+		assert.NoError(t, err)
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("HandoverReassignsOpenTickets", func(t *testing.T) {
+		result, err := delegation.HandoverOpenTickets(ctx, away.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, delegate.ID, result.DelegateID)
+		assert.EqualValues(t, 2, result.Reassigned)
+
+		for _, ticket := range []*models.Ticket{open, inProgress} {
+			current, err := ticketRepo.GetByID(ctx, ticket.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, delegate.ID, *current.AssignedAgentID)
+		}
+		current, err := ticketRepo.GetByID(ctx, resolved.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, away.ID, *current.AssignedAgentID)
+	})
+
+	t.Run("ClearedWindowStopsRouting", func(t *testing.T) {
+		assert.NoError(t, delegation.ClearOutOfOffice(ctx, away.ID))
+		_, err := delegation.GetOutOfOffice(ctx, away.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+
+		assignee, err := delegation.ResolveAssignee(ctx, away.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, away.ID, assignee)
+	})
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoReset(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Demo:     config.DemoConfig{Enabled: true},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(repository.NewTicketRepository(db), repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db)})
+	files := storage.NewLocalStorage(t.TempDir())
+	demoService := services.NewDemoService(repository.NewDemoRepository(db), files, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewDemoHandler(demoService, cfg).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "sales@example.com", Password: "password123", FirstName: "Sales", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+	_, managerTokens, err := authService.Register(&models.RegisterRequest{Email: "manager@example.com", Password: "password123", FirstName: "Team", LastName: "Manager", Role: models.RoleManager})
+	require.NoError(t, err)
+	customer, _, err := authService.Register(&models.RegisterRequest{Email: "customer@example.com", Password: "password123", FirstName: "Real", LastName: "Customer", Role: models.RoleEndUser})
+	require.NoError(t, err)
+	leftover, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Leftover from the last demo", Description: "x", Priority: models.PriorityMedium}, customer.User.ID)
+	require.NoError(t, err)
+
+	_, err = files.Save(ctx, "attachments/leftover.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+	require.NoError(t, db.DB.Create(&models.Attachment{TicketID: leftover.ID, Filename: "leftover.txt", FilePath: "attachments/leftover.txt", FileSize: 5, MimeType: "text/plain", UploadedByID: customer.User.ID}).Error)
+
+	reset := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/demo/reset", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("RequiresConfirmation", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, reset(adminTokens.AccessToken, `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, reset(adminTokens.AccessToken, `{"confirm":"yes"}`).Code)
+		assert.Equal(t, http.StatusForbidden, reset(managerTokens.AccessToken, `{"confirm":"RESET"}`).Code)
+
+		_, err := ticketService.GetTicket(ctx, leftover.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		rec := reset(adminTokens.AccessToken, `{"confirm":"RESET"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result models.DemoResetResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Positive(t, result.Tickets)
+		assert.Positive(t, result.Comments)
+
+		_, err := ticketService.GetTicket(ctx, leftover.ID)
+		assert.Error(t, err, "tickets from before the reset are deleted")
+		_, err = userRepo.GetByEmail("customer@example.com")
+		assert.Error(t, err, "users from before the reset are deleted")
+		_, err = files.Open(ctx, "attachments/leftover.txt")
+		assert.Error(t, err, "attachment files are deleted")
+
+		page, err := ticketService.ListTickets(ctx, &models.TicketQuery{Page: 1, PageSize: 100})
+		require.NoError(t, err)
+		assert.Len(t, page.Tickets, result.Tickets)
+		statuses := make(map[models.TicketStatus]bool)
+		for _, ticket := range page.Tickets {
+			statuses[ticket.Status] = true
+		}
+		assert.Len(t, statuses, 4, "the history has tickets in every status")
+
+		users, err := userRepo.List(100, 0)
+		require.NoError(t, err)
+		assert.Len(t, users, result.Users)
+
+		var indexed int64
+		require.NoError(t, db.DB.Raw("SELECT COUNT(*) FROM tickets_fts WHERE tickets_fts MATCH 'invoice'").Scan(&indexed).Error)
+		assert.Equal(t, int64(1), indexed, "the search index follows the reset")
+
+		// The old session's user is gone; the demo administrator signs in instead
+		assert.Equal(t, http.StatusUnauthorized, reset(adminTokens.AccessToken, `{"confirm":"RESET"}`).Code)
+		_, tokens, err := authService.Login(&models.LoginRequest{Email: "admin@helpchat.com", Password: "password"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, reset(tokens.AccessToken, `{"confirm":"RESET"}`).Code, "resetting again starts over")
+	})
+
+	t.Run("OffOutsideDemoMode", func(t *testing.T) {
+		off := &config.Config{}
+		_, err := services.NewDemoService(repository.NewDemoRepository(db), files, off).Reset(ctx)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+
+		e := echo.New()
+		handlers.NewDemoHandler(demoService, off).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+		assert.Empty(t, e.Routes())
+	})
+}
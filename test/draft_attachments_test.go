@@ -0,0 +1,206 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestDraftAttachments(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Storage: config.StorageConfig{
+			MaxUploadSize:      1024,
+			AllowedTypes:       []string{"image/*", "text/plain"},
+			DraftAttachmentTTL: "1h",
+		},
+	}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	draftRepo := repository.NewDraftAttachmentRepository(db)
+	fileStorage := storage.NewLocalStorage(t.TempDir())
+	authService := services.NewAuthService(userRepo, cfg)
+	attachmentService := services.NewAttachmentService(attachmentRepo, draftRepo, ticketRepo, repository.NewCommentRepository(db), fileStorage, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: draftRepo})
+
+	e := echo.New()
+	handlers.NewAttachmentHandler(attachmentService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	register := func(email string) (*models.User, string) {
+		response, tokens, err := authService.Register(&models.RegisterRequest{Email: email, Password: "password123", FirstName: "Test", LastName: "User", Role: models.RoleEndUser})
+		require.NoError(t, err)
+		return response.User, tokens.AccessToken
+	}
+	requester, requesterToken := register("requester@example.com")
+	other, otherToken := register("other@example.com")
+
+	upload := func(path, token, filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		part.Write(content)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, path, &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	uploadDraft := func(path, token, filename string) models.DraftAttachment {
+		rec := upload(path, token, filename, []byte("\x89PNG fake image"))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var draft models.DraftAttachment
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &draft))
+		return draft
+	}
+	uploadImage := func(token, filename string) models.DraftAttachment {
+		return uploadDraft("/api/v1/tickets/inline-images", token, filename)
+	}
+
+	t.Run("Restrictions", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, upload("/api/v1/tickets/inline-images", requesterToken, "notes.txt", []byte("not an image")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload("/api/v1/tickets/inline-images", requesterToken, "photo.webp", []byte("RIFF")).Code, "only images that get thumbnails can be embedded")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, upload("/api/v1/tickets/inline-images", requesterToken, "big.png", bytes.Repeat([]byte("x"), 1025)).Code)
+		assert.Equal(t, http.StatusBadRequest, upload("/api/v1/tickets/draft-attachments", requesterToken, "setup.exe", []byte("MZ")).Code)
+		assert.Equal(t, http.StatusBadRequest, upload("/api/v1/tickets/draft-attachments", requesterToken, "empty.txt", nil).Code)
+	})
+
+	t.Run("CreateAttachesDrafts", func(t *testing.T) {
+		notes := uploadDraft("/api/v1/tickets/draft-attachments", requesterToken, "notes.txt")
+		assert.False(t, notes.Inline)
+		assert.Equal(t, "text/plain", notes.MimeType)
+		screenshot := uploadDraft("/api/v1/tickets/draft-attachments", requesterToken, "screenshot.png")
+
+		_, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Wrong list", Description: "Embedded", Priority: models.PriorityLow, InlineImageIDs: []uuid.UUID{screenshot.ID}}, requester.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput, "attachments are not inline images")
+
+		created, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Printer jams", Description: "Notes and a screenshot attached", Priority: models.PriorityLow, AttachmentIDs: []uuid.UUID{notes.ID, screenshot.ID}}, requester.ID)
+		require.NoError(t, err)
+		require.Len(t, created.Attachments, 2)
+		for _, attachment := range created.Attachments {
+			assert.Contains(t, []uuid.UUID{notes.ID, screenshot.ID}, attachment.ID)
+			assert.False(t, attachment.Inline)
+		}
+		drafts, err := draftRepo.GetByIDs(ctx, []uuid.UUID{notes.ID, screenshot.ID})
+		require.NoError(t, err)
+		assert.Empty(t, drafts)
+	})
+
+	t.Run("CreationIsAllOrNothing", func(t *testing.T) {
+		draft := uploadDraft("/api/v1/tickets/draft-attachments", requesterToken, "log.txt")
+		taken := uploadDraft("/api/v1/tickets/draft-attachments", requesterToken, "taken.txt")
+		// Another request takes one of the drafts once they have been checked
+		require.NoError(t, draftRepo.AttachToTicket(ctx, uuid.New(), []models.DraftAttachment{taken}))
+
+		var before, after int64
+		require.NoError(t, db.DB.Model(&models.Ticket{}).Count(&before).Error)
+		err := ticketRepo.CreateWithAttachments(ctx, &models.Ticket{Title: "Half", Description: "Half attached", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}, []models.DraftAttachment{draft, taken})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		require.NoError(t, db.DB.Model(&models.Ticket{}).Count(&after).Error)
+		assert.Equal(t, before, after, "the ticket is not created")
+		drafts, err := draftRepo.GetByIDs(ctx, []uuid.UUID{draft.ID})
+		require.NoError(t, err)
+		assert.Len(t, drafts, 1, "the other draft is kept")
+	})
+
+	var ticket *models.Ticket
+	first := uploadImage(requesterToken, "error.png")
+	t.Run("CreateAttachesImages", func(t *testing.T) {
+		assert.Equal(t, "image/png", first.MimeType)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), first.ExpiresAt, time.Minute)
+
+		ticket, err = ticketService.CreateTicket(ctx, &models.CreateTicketRequest{
+			Title:          "VPN error",
+			Description:    "I get this error: ![error](" + first.ID.String() + ")",
+			Priority:       models.PriorityMedium,
+			InlineImageIDs: []uuid.UUID{first.ID},
+		}, requester.ID)
+		require.NoError(t, err)
+		require.Len(t, ticket.Attachments, 1)
+		attachment := ticket.Attachments[0]
+		assert.Equal(t, first.ID, attachment.ID, "the image keeps its ID")
+		assert.True(t, attachment.Inline)
+		assert.Equal(t, models.ThumbnailPending, attachment.ThumbnailStatus)
+
+		_, content, err := attachmentService.Open(ctx, ticket.ID, first.ID, requester)
+		require.NoError(t, err)
+		defer content.Close()
+
+		images, err := draftRepo.GetByIDs(ctx, []uuid.UUID{first.ID})
+		require.NoError(t, err)
+		assert.Empty(t, images, "the upload is attached, not kept waiting")
+	})
+
+	t.Run("ImagesAreAttachedOnce", func(t *testing.T) {
+		_, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Again", Description: "Same picture", Priority: models.PriorityLow, InlineImageIDs: []uuid.UUID{first.ID}}, requester.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("OnlyTheUploaderCanUseAnImage", func(t *testing.T) {
+		mine := uploadImage(requesterToken, "settings.png")
+		theirs := uploadImage(otherToken, "theirs.png")
+
+		description := "Also these settings"
+		_, err := ticketService.UpdateTicket(ctx, ticket.ID, &models.UpdateTicketRequest{Description: &description, InlineImageIDs: []uuid.UUID{mine.ID, theirs.ID}}, requester.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		images, err := draftRepo.GetByIDs(ctx, []uuid.UUID{mine.ID, theirs.ID})
+		require.NoError(t, err)
+		assert.Len(t, images, 2, "nothing is attached when an image is refused")
+
+		_, err = ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Mine", Description: "Their picture", Priority: models.PriorityLow, InlineImageIDs: []uuid.UUID{theirs.ID}}, requester.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Theirs", Description: "Their picture", Priority: models.PriorityLow, InlineImageIDs: []uuid.UUID{theirs.ID}}, other.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("UnusedImagesExpire", func(t *testing.T) {
+		fresh := uploadImage(requesterToken, "draft.png")
+		_, err := fileStorage.Save(ctx, "drafts/stale.png", bytes.NewReader([]byte("stale")))
+		require.NoError(t, err)
+		stale := &models.DraftAttachment{Filename: "stale.png", FilePath: "drafts/stale.png", FileSize: 5, MimeType: "image/png", UploadedByID: requester.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+		require.NoError(t, draftRepo.Create(ctx, stale))
+
+		_, err = ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Late", Description: "Too late", Priority: models.PriorityLow, InlineImageIDs: []uuid.UUID{stale.ID}}, requester.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput, "expired images cannot be attached")
+
+		require.NoError(t, attachmentService.RemoveExpiredDrafts(ctx))
+		images, err := draftRepo.GetByIDs(ctx, []uuid.UUID{fresh.ID, stale.ID})
+		require.NoError(t, err)
+		require.Len(t, images, 1)
+		assert.Equal(t, fresh.ID, images[0].ID)
+		_, err = fileStorage.Open(ctx, "drafts/stale.png")
+		assert.Error(t, err, "the file is removed with the record")
+	})
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySender records messages and fails while err is set
+type flakySender struct {
+	recordingSender
+	err error
+}
+
+func (s *flakySender) Send(ctx context.Context, msg *email.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.recordingSender.Send(ctx, msg)
+}
+
+func TestEmailDeliveryTracking(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com", InboundSecret: "inbound-secret", FailureThreshold: 2},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	sender := &flakySender{}
+	deliveries := services.NewEmailDeliveryService(sender, repository.NewEmailDeliveryRepository(db), userRepo, nil, cfg)
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewEmailDeliveryHandler(deliveries, cfg).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+	jane, _, err := authService.Register(&models.RegisterRequest{Email: "jane@example.com", Password: "password123", FirstName: "Jane", LastName: "Doe", Role: models.RoleEndUser})
+	require.NoError(t, err)
+
+	call := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	report := func(events ...models.EmailDeliveryEvent) models.EmailDeliveryEventsResponse {
+		rec := call(http.MethodPost, "/api/v1/inbound/email/events?secret=inbound-secret", "", models.EmailDeliveryEventsRequest{Events: events})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result models.EmailDeliveryEventsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return result
+	}
+	listDeliveries := func(query string) models.EmailDeliveryListResponse {
+		rec := call(http.MethodGet, "/api/v1/admin/email/deliveries"+query, adminTokens.AccessToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list models.EmailDeliveryListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		return list
+	}
+	failingUsers := func() []models.User {
+		rec := call(http.MethodGet, "/api/v1/admin/email/failing-users", adminTokens.AccessToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var users []models.User
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &users))
+		return users
+	}
+
+	t.Run("RecordsSentEmails", func(t *testing.T) {
+		require.NoError(t, deliveries.Send(ctx, &email.Message{To: []string{"Jane@Example.com", "bob@example.com"}, Subject: "Welcome", Text: "Hi"}))
+		require.Len(t, sender.messages, 1)
+		messageID := sender.messages[0].Headers["Message-ID"]
+		assert.Regexp(t, `^<.+@helpchat\.com>$`, messageID)
+
+		list := listDeliveries("?status=SENT")
+		require.Len(t, list.Deliveries, 2)
+		for _, delivery := range list.Deliveries {
+			assert.Equal(t, messageID, delivery.MessageID)
+		}
+		assert.Len(t, listDeliveries("?recipient=jane@example.com").Deliveries, 1)
+	})
+
+	t.Run("WebhookRequiresSecret", func(t *testing.T) {
+		rec := call(http.MethodPost, "/api/v1/inbound/email/events?secret=wrong", "", models.EmailDeliveryEventsRequest{Events: []models.EmailDeliveryEvent{{Recipient: "jane@example.com", Event: "delivered"}}})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("HardBounceSuppressesAndFlags", func(t *testing.T) {
+		messageID := sender.messages[0].Headers["Message-ID"]
+		result := report(
+			models.EmailDeliveryEvent{MessageID: messageID[1 : len(messageID)-1], Recipient: "jane@example.com", Event: "bounce", BounceType: "hard", Reason: "550 no such user"},
+			models.EmailDeliveryEvent{Recipient: "nobody@example.com", Event: "bounce"},
+		)
+		assert.Equal(t, models.EmailDeliveryEventsResponse{Applied: 1, Unmatched: 1}, result)
+
+		users := failingUsers()
+		require.Len(t, users, 1)
+		assert.Equal(t, jane.User.ID, users[0].ID)
+
+		// Later email skips the suppressed address
+		sender.messages = nil
+		require.NoError(t, deliveries.Send(ctx, &email.Message{To: []string{"jane@example.com", "bob@example.com"}, Subject: "Update", Text: "Hi"}))
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{"bob@example.com"}, sender.messages[0].To)
+		assert.Len(t, listDeliveries("?status=SUPPRESSED").Deliveries, 1)
+
+		// A late delivery report does not hide the bounce
+		report(models.EmailDeliveryEvent{MessageID: messageID, Recipient: "jane@example.com", Event: "delivered"})
+		assert.Len(t, listDeliveries("?status=BOUNCED").Deliveries, 1)
+	})
+
+	t.Run("UnsuppressClearsFlag", func(t *testing.T) {
+		rec := call(http.MethodGet, "/api/v1/admin/email/suppressions", adminTokens.AccessToken, nil)
+		assert.Contains(t, rec.Body.String(), `"reason":"HARD_BOUNCE"`)
+
+		assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, "/api/v1/admin/email/suppressions/jane@example.com", adminTokens.AccessToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/api/v1/admin/email/suppressions/jane@example.com", adminTokens.AccessToken, nil).Code)
+		assert.Empty(t, failingUsers())
+	})
+
+	t.Run("RepeatedFailuresFlag", func(t *testing.T) {
+		_, _, err := authService.Register(&models.RegisterRequest{Email: "sam@example.com", Password: "password123", FirstName: "Sam", LastName: "Lee", Role: models.RoleEndUser})
+		require.NoError(t, err)
+
+		sender.err = errors.New("connection refused")
+		assert.Error(t, deliveries.Send(ctx, &email.Message{To: []string{"sam@example.com"}, Subject: "One", Text: "Hi"}))
+		assert.Empty(t, failingUsers())
+		assert.Error(t, deliveries.Send(ctx, &email.Message{To: []string{"sam@example.com"}, Subject: "Two", Text: "Hi"}))
+		assert.Len(t, failingUsers(), 1)
+
+		// Once email gets through again the flag is cleared
+		sender.err = nil
+		require.NoError(t, deliveries.Send(ctx, &email.Message{To: []string{"sam@example.com"}, Subject: "Three", Text: "Hi"}))
+		report(models.EmailDeliveryEvent{Recipient: "sam@example.com", Event: "delivered"})
+		assert.Empty(t, failingUsers())
+	})
+
+	t.Run("AdminsOnly", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/api/v1/admin/email/deliveries", "", nil).Code)
+	})
+}
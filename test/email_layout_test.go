@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailLayout(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Email: config.EmailConfig{
+		BrandName:    "Acme Support",
+		LogoURL:      "https://cdn.acme.test/logo.png",
+		PrimaryColor: "#ff6600",
+		Footer:       "Acme Inc, 1 Main Street",
+	}}
+	layout, err := email.NewLayout(cfg.Email)
+	require.NoError(t, err)
+	sender := &recordingSender{}
+	branded := email.NewLayoutSender(sender, layout)
+
+	t.Run("TextGetsBrandedHTML", func(t *testing.T) {
+		sender.messages = nil
+		text := email.ReplyMarker + "\n\nSam wrote:\n\n<script>alert(1)</script> see https://status.acme.test.\n"
+		require.NoError(t, branded.Send(ctx, &email.Message{To: []string{"a@example.com"}, Subject: "Re: VPN", Text: text}))
+		require.Len(t, sender.messages, 1)
+		msg := sender.messages[0]
+
+		assert.Contains(t, msg.HTML, `src="https://cdn.acme.test/logo.png"`)
+		assert.Contains(t, msg.HTML, "background:#ff6600")
+		assert.Contains(t, msg.HTML, "Acme Inc, 1 Main Street")
+		assert.Contains(t, msg.HTML, "&lt;script&gt;alert(1)&lt;/script&gt;")
+		assert.NotContains(t, msg.HTML, "<script>")
+		assert.Contains(t, msg.HTML, `<a href="https://status.acme.test" style="color:#ff6600;">https://status.acme.test</a>.`)
+		assert.Contains(t, msg.HTML, "Sam wrote:")
+
+		// The text keeps the reply marker and ends with a signature, which replies are cut at
+		assert.Equal(t, text+"\n-- \nAcme Support\nAcme Inc, 1 Main Street\n", msg.Text)
+		assert.Equal(t, "Sam wrote:\n\n<script>alert(1)</script> see https://status.acme.test.", email.ExtractReply(msg.Text[len(email.ReplyMarker):]))
+	})
+
+	t.Run("HTMLGetsPlainTextAlternate", func(t *testing.T) {
+		sender.messages = nil
+		require.NoError(t, branded.Send(ctx, &email.Message{To: []string{"a@example.com"}, Subject: "Digest", HTML: `<p>Hello &amp; welcome</p><p>Read <a href="https://kb.acme.test/start">the guide</a></p>`}))
+		require.Len(t, sender.messages, 1)
+		msg := sender.messages[0]
+
+		assert.Contains(t, msg.HTML, "<p>Hello &amp; welcome</p>")
+		assert.Equal(t, "Hello & welcome\nRead the guide (https://kb.acme.test/start)\n\n-- \nAcme Support\nAcme Inc, 1 Main Street\n", msg.Text)
+	})
+
+	t.Run("RejectsInvalidBranding", func(t *testing.T) {
+		_, err := email.NewLayout(config.EmailConfig{PrimaryColor: "red;background:url(x)"})
+		assert.Error(t, err)
+		_, err = email.NewLayout(config.EmailConfig{PrimaryColor: "#fff", LogoURL: "javascript:alert(1)"})
+		assert.Error(t, err)
+
+		// A bad reload keeps the current branding
+		assert.Error(t, branded.Reload(&config.Config{Email: config.EmailConfig{PrimaryColor: "blue"}}))
+		sender.messages = nil
+		require.NoError(t, branded.Send(ctx, &email.Message{Subject: "x", Text: "y"}))
+		assert.Contains(t, sender.messages[0].HTML, "#ff6600")
+	})
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailProviders(t *testing.T) {
+	ctx := context.Background()
+	msg := &email.Message{
+		To:      []string{"jane@example.com"},
+		Subject: "Ticket received",
+		Text:    "We got your request",
+		HTML:    "<p>We got your request</p>",
+		ReplyTo: "reply+abc@reply.helpchat.com",
+		Headers: map[string]string{"Message-ID": "<1@helpchat.com>"},
+	}
+
+	var requests []*http.Request
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &body))
+		requests, bodies = append(requests, r), append(bodies, body)
+		if strings.Contains(string(data), "rejected@example.com") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"Email address is not verified."}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.EmailConfig{
+		FromAddress:          "support@helpchat.com",
+		FromName:             "HelpChat Support",
+		SESRegion:            "eu-west-1",
+		SESEndpoint:          server.URL,
+		SESAccessKey:         "AKIDEXAMPLE",
+		SESSecretKeySecret:   "TEST_SES_SECRET_KEY",
+		SESConfigurationSet:  "delivery-events",
+		SendGridEndpoint:     server.URL,
+		SendGridAPIKeySecret: "TEST_SENDGRID_API_KEY",
+	}
+	t.Setenv("TEST_SES_SECRET_KEY", "secret")
+	t.Setenv("TEST_SENDGRID_API_KEY", "SG.key")
+	provider, err := secrets.NewProvider("env", "")
+	require.NoError(t, err)
+
+	t.Run("SES", func(t *testing.T) {
+		requests, bodies = nil, nil
+		cfg := cfg
+		cfg.Provider = "ses"
+		sender, err := email.NewSender(ctx, cfg, provider)
+		require.NoError(t, err)
+		require.NoError(t, sender.Send(ctx, msg))
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/v2/email/outbound-emails", requests[0].URL.Path)
+		assert.Contains(t, requests[0].Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/")
+		assert.Contains(t, requests[0].Header.Get("Authorization"), "/eu-west-1/ses/aws4_request")
+		assert.Equal(t, "delivery-events", bodies[0]["ConfigurationSetName"])
+		assert.Equal(t, `"HelpChat Support" <support@helpchat.com>`, bodies[0]["FromEmailAddress"])
+
+		// The raw message keeps our threading headers
+		data := bodies[0]["Content"].(map[string]any)["Raw"].(map[string]any)["Data"].(string)
+		var raw []byte
+		require.NoError(t, json.Unmarshal([]byte(`"`+data+`"`), &raw))
+		assert.Contains(t, string(raw), "Message-Id: <1@helpchat.com>")
+		assert.Contains(t, string(raw), "Reply-To: reply+abc@reply.helpchat.com")
+	})
+
+	t.Run("SendGrid", func(t *testing.T) {
+		requests, bodies = nil, nil
+		cfg := cfg
+		cfg.Provider = "sendgrid"
+		sender, err := email.NewSender(ctx, cfg, provider)
+		require.NoError(t, err)
+		require.NoError(t, sender.Send(ctx, msg))
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/v3/mail/send", requests[0].URL.Path)
+		assert.Equal(t, "Bearer SG.key", requests[0].Header.Get("Authorization"))
+		assert.Equal(t, "reply+abc@reply.helpchat.com", bodies[0]["reply_to"].(map[string]any)["email"])
+		assert.Equal(t, "<1@helpchat.com>", bodies[0]["headers"].(map[string]any)["Message-ID"])
+		content := bodies[0]["content"].([]any)
+		require.Len(t, content, 2)
+		assert.Equal(t, "text/plain", content[0].(map[string]any)["type"])
+
+		err = sender.Send(ctx, &email.Message{To: []string{"rejected@example.com"}, Subject: "x", Text: "y"})
+		assert.ErrorContains(t, err, "Email address is not verified.")
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		requests, bodies = nil, nil
+		cfg := cfg
+		cfg.Provider = "sendgrid"
+		cfg.Sandbox = true
+		cfg.SandboxRecipients = []string{"@qa.helpchat.com", "Jane@example.com"}
+		sender, err := email.NewSender(ctx, cfg, provider)
+		require.NoError(t, err)
+
+		require.NoError(t, sender.Send(ctx, &email.Message{To: []string{"bob@example.com"}, Subject: "x", Text: "y"}))
+		assert.Empty(t, requests)
+
+		require.NoError(t, sender.Send(ctx, &email.Message{To: []string{"jane@example.com", "bob@example.com", "tester@qa.helpchat.com"}, Subject: "x", Text: "y"}))
+		require.Len(t, requests, 1)
+		assert.Equal(t, []any{
+			map[string]any{"email": "jane@example.com"},
+			map[string]any{"email": "tester@qa.helpchat.com"},
+		}, bodies[0]["personalizations"].([]any)[0].(map[string]any)["to"])
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		sender := email.NewRateLimitedSender(&recordingSender{}, 1)
+		require.NoError(t, sender.Send(ctx, &email.Message{To: []string{"a@example.com"}}))
+
+		// The next recipient must wait about a second
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		assert.Error(t, sender.Send(timeout, &email.Message{To: []string{"b@example.com"}}))
+	})
+
+	t.Run("InvalidConfiguration", func(t *testing.T) {
+		_, err := email.NewSender(ctx, config.EmailConfig{FromAddress: "support@helpchat.com", Provider: "carrier-pigeon"}, provider)
+		assert.Error(t, err)
+		_, err = email.NewSender(ctx, config.EmailConfig{FromAddress: "support@helpchat.com", Provider: "smtp"}, provider)
+		assert.Error(t, err)
+		_, err = email.NewSender(ctx, config.EmailConfig{FromAddress: "not an address"}, provider)
+		assert.Error(t, err)
+	})
+}
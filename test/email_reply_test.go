@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSender captures sent messages instead of delivering them
+type recordingSender struct {
+	messages []*email.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg *email.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestExtractReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "GmailQuote",
+			body:     "Thanks, that fixed it.\n\nOn Mon, 1 Jan 2024 at 10:00, Support <support@example.com> wrote:\n> Please try restarting.\n",
+			expected: "Thanks, that fixed it.",
+		},
+		{
+			name:     "WrappedAttribution",
+			body:     "Still broken.\r\n\r\nOn Mon, 1 Jan 2024 at 10:00, HelpChat Support\r\n<support@example.com> wrote:\r\n> Please try restarting.",
+			expected: "Still broken.",
+		},
+		{
+			name:     "ReplyMarker",
+			body:     "Works now\n\n" + email.ReplyMarker + "\n\nAgent wrote: ...",
+			expected: "Works now",
+		},
+		{
+			name:     "OutlookHeaders",
+			body:     "See attached.\n\nFrom: HelpChat Support <support@example.com>\nSent: Monday, January 1, 2024 10:00 AM\nTo: Jane\nSubject: Re: ticket",
+			expected: "See attached.",
+		},
+		{
+			name:     "Signature",
+			body:     "Done.\n\n-- \nJane Doe\nACME Corp",
+			expected: "Done.",
+		},
+		{
+			name:     "MobileSignature",
+			body:     "Ok\n\nSent from my iPhone",
+			expected: "Ok",
+		},
+		{
+			name:     "LiteralFromKept",
+			body:     "From: the logs it looks like a timeout.\nCan you check?",
+			expected: "From: the logs it looks like a timeout.\nCan you check?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, email.ExtractReply(tt.body))
+		})
+	}
+}
+
+func TestInboundEmailReply(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress:       "support@helpchat.com",
+			ReplyDomain:       "reply.helpchat.com",
+			ReplySecretKey:    "test-reply-secret",
+			ReplyTokenTTL:     "24h",
+			MaxAttachmentSize: 1024,
+		},
+		Storage: config.StorageConfig{
+			MaxUploadSize: 4096,
+			AllowedTypes:  []string{"image/*", "text/plain"},
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	fileStorage := storage.NewLocalStorage(t.TempDir())
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, fileStorage, redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+	intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "User", Role: models.RoleSupportAgent, IsActive: true}
+	stranger := &models.User{Email: "stranger@example.com", PasswordHash: "x", FirstName: "Str", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, userRepo.Create(requester))
+	assert.NoError(t, userRepo.Create(agent))
+	assert.NoError(t, userRepo.Create(stranger))
+
+	ticket := &models.Ticket{Title: "VPN down", Description: "Help", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: requester.ID, AssignedAgentID: &agent.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	t.Run("AgentCommentEmailsRequesterWithReplyAddress", func(t *testing.T) {
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "Please restart"}, agent)
+		assert.NoError(t, err)
+
+		if assert.Len(t, sender.messages, 1) {
+			msg := sender.messages[0]
+			assert.Equal(t, []string{requester.Email}, msg.To)
+			assert.True(t, strings.HasPrefix(msg.ReplyTo, "reply+"))
+			assert.True(t, strings.HasSuffix(msg.ReplyTo, "@reply.helpchat.com"))
+			assert.Contains(t, msg.Headers["In-Reply-To"], ticket.ID.String())
+		}
+	})
+
+	t.Run("InternalNotesAreNotEmailed", func(t *testing.T) {
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "note", IsInternal: true}, agent)
+		assert.NoError(t, err)
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("ReplyCreatesCommentWithAttachment", func(t *testing.T) {
+		sender.messages = nil
+		comment, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "Req User <requester@example.com>",
+			To:   []string{"HelpChat <" + strings.ToUpper(emailChannel.ReplyAddress(ticket.ID)) + ">"},
+			Text: "Restarted, still broken.\n\nOn Tue, Support wrote:\n> Please restart",
+			Attachments: []models.InboundAttachment{
+				{Filename: "../../screenshot.png", ContentType: "image/png", Content: []byte("png")},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Restarted, still broken.", comment.Content)
+		assert.Equal(t, requester.ID, comment.UserID)
+
+		attachments, err := attachmentRepo.GetByTicket(ctx, ticket.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, attachments, 1) {
+			assert.Equal(t, "screenshot.png", attachments[0].Filename)
+			assert.Equal(t, comment.ID, *attachments[0].CommentID)
+		}
+
+		// The assigned agent is notified of the requester's reply
+		if assert.Len(t, sender.messages, 1) {
+			assert.Equal(t, []string{agent.Email}, sender.messages[0].To)
+		}
+	})
+
+	t.Run("StrangerCannotReply", func(t *testing.T) {
+		_, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "stranger@example.com",
+			To:   []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text: "hijack",
+		})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("TamperedTokenRejected", func(t *testing.T) {
+		_, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "requester@example.com",
+			To:   []string{"reply+aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa@reply.helpchat.com"},
+			Text: "hello",
+		})
+		assert.ErrorIs(t, err, services.ErrReplyTokenInvalid)
+	})
+
+	t.Run("OversizedAttachmentRejected", func(t *testing.T) {
+		_, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From:        "requester@example.com",
+			To:          []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text:        "big file",
+			Attachments: []models.InboundAttachment{{Filename: "big.bin", Content: make([]byte, 2048)}},
+		})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("DisallowedAttachmentRejected", func(t *testing.T) {
+		_, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From:        "requester@example.com",
+			To:          []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text:        "run this",
+			Attachments: []models.InboundAttachment{{Filename: "fix.exe", ContentType: "application/x-msdownload", Content: []byte("MZ")}},
+		})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+}
@@ -0,0 +1,236 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/docs"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailTemplates(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email: config.EmailConfig{
+			FromAddress:    "support@helpchat.com",
+			ReplyDomain:    "reply.helpchat.com",
+			ReplySecretKey: "test-reply-secret",
+			ReplyTokenTTL:  "24h",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	templateService := services.NewEmailTemplateService(repository.NewEmailTemplateRepository(db), cfg)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, templateService, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewEmailTemplateHandler(templateService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	call := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		if body != nil {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	path := "/api/v1/admin/email-templates/" + email.TemplateCommentAdded
+
+	t.Run("AdminsOnly", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call(http.MethodGet, "/api/v1/admin/email-templates", agentTokens.AccessToken, nil).Code)
+	})
+
+	t.Run("ListsDefaults", func(t *testing.T) {
+		rec := call(http.MethodGet, "/api/v1/admin/email-templates", adminTokens.AccessToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var templates []models.EmailTemplateResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &templates))
+		assert.Len(t, templates, len(email.DefaultTemplates))
+		for _, template := range templates {
+			assert.False(t, template.Customized, template.Name)
+			assert.NotEmpty(t, template.Variables, template.Name)
+		}
+
+		assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/api/v1/admin/email-templates/unknown", adminTokens.AccessToken, nil).Code)
+	})
+
+	t.Run("DocumentsEveryTemplate", func(t *testing.T) {
+		var names []string
+		for _, template := range email.DefaultTemplates {
+			names = append(names, template.Name)
+		}
+
+		var doc struct {
+			Paths map[string]map[string]struct {
+				Parameters []struct {
+					Name string   `json:"name"`
+					In   string   `json:"in"`
+					Enum []string `json:"enum"`
+				} `json:"parameters"`
+			} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &doc))
+		documented := 0
+		for path, operations := range doc.Paths {
+			if !strings.HasPrefix(path, "/api/v1/admin/email-templates/{name}") {
+				continue
+			}
+			for method, operation := range operations {
+				for _, parameter := range operation.Parameters {
+					if parameter.In == "path" && parameter.Name == "name" {
+						assert.ElementsMatch(t, names, parameter.Enum, "%s %s", method, path)
+						documented++
+					}
+				}
+			}
+		}
+		assert.Positive(t, documented)
+	})
+
+	t.Run("RejectsInvalidSyntax", func(t *testing.T) {
+		for name, req := range map[string]models.EmailTemplateRequest{
+			"unknown placeholder": {Subject: "{{ticket_title}}", Text: "{{reply_marker}} {{password}}"},
+			"unbalanced braces":   {Subject: "{{ticket_title}", Text: "{{reply_marker}}"},
+			"missing marker":      {Subject: "{{ticket_title}}", Text: "{{comment}}"},
+			"multi-line subject":  {Subject: "a\nb", Text: "{{reply_marker}}"},
+		} {
+			rec := call(http.MethodPut, path, adminTokens.AccessToken, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+		}
+	})
+
+	t.Run("PreviewsDraft", func(t *testing.T) {
+		rec := call(http.MethodPost, path+"/preview", adminTokens.AccessToken, models.EmailTemplatePreviewRequest{
+			Subject: "Update on {{ticket_title}}",
+			Text:    "{{reply_marker}}\n{{author_name}}: {{comment}}",
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var preview models.EmailTemplatePreview
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+		assert.Equal(t, "Update on Cannot sign in to the customer portal", preview.Subject)
+		assert.NotContains(t, preview.Text, "{{")
+
+		// Previewing does not save the draft
+		rec = call(http.MethodGet, path, adminTokens.AccessToken, nil)
+		assert.Contains(t, rec.Body.String(), `"customized":false`)
+	})
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Alex", LastName: "Morgan", Role: models.RoleEndUser, IsActive: true}
+	require.NoError(t, userRepo.Create(requester))
+	ticket := &models.Ticket{Title: "VPN down", Description: "Help", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: requester.ID, AssignedAgentID: &agent.User.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+	reference := strings.ToUpper(ticket.ID.String()[:8])
+	comment := func() *email.Message {
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "Please restart"}, agent.User)
+		require.NoError(t, err)
+		require.Len(t, sender.messages, 1)
+		return sender.messages[0]
+	}
+
+	t.Run("CustomizedTemplateIsSent", func(t *testing.T) {
+		rec := call(http.MethodPut, path, adminTokens.AccessToken, models.EmailTemplateRequest{
+			Subject: "Update on {{ticket_title}} [#{{ticket_reference}}]",
+			Text:    "{{reply_marker}}\n\nHello {{recipient_first_name}}, {{author_name}} replied:\n\n{{comment}}\n",
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var saved models.EmailTemplateResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+		assert.True(t, saved.Customized)
+
+		msg := comment()
+		assert.Equal(t, "Update on VPN down [#"+reference+"]", msg.Subject)
+		assert.Contains(t, msg.Text, "Hello Alex, Test Agent replied:\n\nPlease restart")
+	})
+
+	t.Run("RevertRestoresDefault", func(t *testing.T) {
+		rec := call(http.MethodDelete, path, adminTokens.AccessToken, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"customized":false`)
+
+		assert.Equal(t, "Re: [#"+reference+"] VPN down", comment().Subject)
+	})
+
+	t.Run("TranslationForRecipientLanguage", func(t *testing.T) {
+		translations := path + "/translations/"
+		assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, translations+"not_a_language!", adminTokens.AccessToken, models.EmailTemplateRequest{Subject: "x", Text: "{{reply_marker}}"}).Code)
+		assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, translations+"fr", adminTokens.AccessToken, models.EmailTemplateRequest{Subject: "{{titre}}", Text: "{{reply_marker}}"}).Code)
+
+		rec := call(http.MethodPut, translations+"FR", adminTokens.AccessToken, models.EmailTemplateRequest{
+			Subject: "Re : [#{{ticket_reference}}] {{ticket_title}}",
+			Text:    "{{reply_marker}}\n\nBonjour {{recipient_first_name}}, {{author_name}} a répondu :\n\n{{comment}}\n",
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var translation models.EmailTemplateTranslation
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &translation))
+		assert.Equal(t, "fr", translation.Language)
+
+		rec = call(http.MethodGet, path, adminTokens.AccessToken, nil)
+		assert.Contains(t, rec.Body.String(), `"translations":["fr"]`)
+
+		// Recipients without a preference get the default language
+		assert.Equal(t, "Re: [#"+reference+"] VPN down", comment().Subject)
+
+		// Regional variants fall back to their base language
+		_, err := services.NewUserService(userRepo).SetLanguage(ctx, requester.ID, "fr-ca")
+		require.NoError(t, err)
+		msg := comment()
+		assert.Equal(t, "Re : [#"+reference+"] VPN down", msg.Subject)
+		assert.Contains(t, msg.Text, "Bonjour Alex, Test Agent a répondu")
+
+		rec = call(http.MethodPost, path+"/preview", adminTokens.AccessToken, models.EmailTemplatePreviewRequest{Language: "fr-CA"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		var preview models.EmailTemplatePreview
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+		assert.Equal(t, "fr", preview.Language)
+		assert.Contains(t, preview.Text, "Bonjour Alex")
+
+		assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, translations+"fr", adminTokens.AccessToken, nil).Code)
+		assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, translations+"fr", adminTokens.AccessToken, nil).Code)
+		assert.Equal(t, "Re: [#"+reference+"] VPN down", comment().Subject)
+	})
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/encryption"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey returns a deterministic 32-byte base64 key
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func TestKeyring(t *testing.T) {
+	keyring, err := encryption.ParseKeyring("k2:"+testKey('b')+", k1:"+testKey('a'), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", keyring.PrimaryKeyID())
+
+	ciphertext, err := keyring.Encrypt("uploads/ticket/file.pdf")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "enc:k2:"))
+	assert.NotContains(t, ciphertext, "file.pdf")
+
+	plaintext, err := keyring.Decrypt(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "uploads/ticket/file.pdf", plaintext)
+
+	t.Run("TamperingDetected", func(t *testing.T) {
+		// Relabelling the value with another key must fail authentication
+		_, err := keyring.Decrypt(strings.Replace(ciphertext, "enc:k2:", "enc:k1:", 1))
+		assert.ErrorIs(t, err, encryption.ErrMalformed)
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		_, err := keyring.Decrypt("enc:k9:AAAA")
+		assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+	})
+
+	t.Run("NeedsRotation", func(t *testing.T) {
+		assert.False(t, keyring.NeedsRotation(ciphertext))
+		assert.True(t, keyring.NeedsRotation("plain@example.com"))
+		assert.False(t, keyring.NeedsRotation(""))
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		_, err := encryption.ParseKeyring("k1:"+base64.StdEncoding.EncodeToString([]byte("short")), "")
+		assert.Error(t, err)
+		_, err = encryption.ParseKeyring("k1:"+testKey('a'), "k2")
+		assert.Error(t, err)
+	})
+}
+
+func TestFileSecretsProvider(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ENCRYPTION_KEYS"), []byte("k1:"+testKey('a')+"\n"), 0600))
+
+	provider, err := secrets.NewProvider("file", dir)
+	assert.NoError(t, err)
+
+	keyring, err := encryption.LoadKeyring(context.Background(), provider, "ENCRYPTION_KEYS", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", keyring.PrimaryKeyID())
+
+	// A missing secret disables encryption rather than failing
+	keyring, err = encryption.LoadKeyring(context.Background(), provider, "MISSING", "")
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+
+	_, err = provider.Get(context.Background(), "../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestEncryptedColumnsKeyRotation(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	oldKeyring, err := encryption.ParseKeyring("k1:"+testKey('a'), "")
+	assert.NoError(t, err)
+	encryption.SetKeyring(oldKeyring)
+	t.Cleanup(func() { encryption.SetKeyring(nil) })
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	quarantineRepo := repository.NewQuarantineRepository(db)
+	auditService := services.NewAuditService(repository.NewAuditLogRepository(db), nil)
+
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "x", FirstName: "Ad", LastName: "Min", Role: models.RoleAdministrator}
+	assert.NoError(t, userRepo.Create(admin))
+	ticket := &models.Ticket{Title: "Scan", Description: "Upload", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: admin.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	attachment := &models.Attachment{TicketID: ticket.ID, Filename: "scan.pdf", FilePath: "tickets/scan.pdf", FileSize: 3, MimeType: "application/pdf", UploadedByID: admin.ID}
+	assert.NoError(t, attachmentRepo.Create(ctx, attachment))
+	submission := &models.QuarantinedSubmission{Source: models.SourceGuestForm, Email: "guest@example.com", Title: "Hi", Description: "Hello"}
+	assert.NoError(t, quarantineRepo.Create(ctx, submission))
+
+	rawValue := func(table, column, id string) string {
+		var value string
+		assert.NoError(t, db.DB.Table(table).Select(column).Where("id = ?", id).Scan(&value).Error)
+		return value
+	}
+
+	t.Run("StoredEncrypted", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(rawValue("attachments", "file_path", attachment.ID.String()), "enc:k1:"))
+		assert.True(t, strings.HasPrefix(rawValue("quarantined_submissions", "email", submission.ID.String()), "enc:k1:"))
+
+		loaded, err := attachmentRepo.GetByID(ctx, attachment.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "tickets/scan.pdf", loaded.FilePath)
+	})
+
+	t.Run("RotationReencryptsOldAndPlaintextValues", func(t *testing.T) {
+		// A row written before encryption was enabled
+		legacy := &models.QuarantinedSubmission{Source: models.SourceEmail, Email: "x", Title: "Old", Description: "Old"}
+		assert.NoError(t, quarantineRepo.Create(ctx, legacy))
+		assert.NoError(t, db.DB.Table("quarantined_submissions").Where("id = ?", legacy.ID).UpdateColumn("email", "legacy@example.com").Error)
+
+		newKeyring, err := encryption.ParseKeyring("k2:"+testKey('b')+",k1:"+testKey('a'), "k2")
+		assert.NoError(t, err)
+		encryption.SetKeyring(newKeyring)
+
+		encryptionService := services.NewEncryptionService(repository.NewEncryptedColumnRepository(db), auditService, newKeyring)
+		result, err := encryptionService.RotateKeys(ctx, admin.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "k2", result.PrimaryKeyID)
+		assert.Equal(t, 1, result.Rotated["attachments.file_path"])
+		assert.Equal(t, 2, result.Rotated["quarantined_submissions.email"])
+
+		assert.True(t, strings.HasPrefix(rawValue("attachments", "file_path", attachment.ID.String()), "enc:k2:"))
+		assert.True(t, strings.HasPrefix(rawValue("quarantined_submissions", "email", legacy.ID.String()), "enc:k2:"))
+
+		loaded, err := quarantineRepo.GetByID(ctx, legacy.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "legacy@example.com", loaded.Email)
+
+		// A second run has nothing left to do
+		result, err = encryptionService.RotateKeys(ctx, admin.ID)
+		assert.NoError(t, err)
+		assert.Zero(t, result.Rotated["attachments.file_path"])
+		assert.Zero(t, result.Rotated["quarantined_submissions.email"])
+	})
+
+	t.Run("DisabledWithoutKeys", func(t *testing.T) {
+		_, err := services.NewEncryptionService(repository.NewEncryptedColumnRepository(db), auditService, nil).RotateKeys(ctx, admin.ID)
+		assert.ErrorIs(t, err, services.ErrEncryptionDisabled)
+	})
+}
@@ -2,9 +2,13 @@ package test
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
 	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +50,78 @@ func TestErrorResponseFromError(t *testing.T) {
 	assert.Len(t, errorResp.Messages, 1)
 	assert.Equal(t, testError.Error(), errorResp.Messages[0])
 }
+
+func TestProblemJSONNegotiation(t *testing.T) {
+	errorFormats := testMiddleware.NewProblemJSON()
+	e := echo.New()
+	e.JSONSerializer = errorFormats
+	e.Use(testMiddleware.ErrorHandlerMiddleware())
+	e.POST("/register", func(c echo.Context) error {
+		return c.JSON(http.StatusBadRequest, models.NewErrorResponseWithMessages([]string{"Invalid email format", "Password too short"}))
+	})
+	e.GET("/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "ticket not found")
+	})
+	e.GET("/ok", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, models.SuccessResponse{Status: "success", Message: "done"})
+	})
+
+	request := func(method, path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if accept != "" {
+			req.Header.Set(echo.HeaderAccept, accept)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("LegacyByDefault", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "application/problem+json;q=0, application/json"} {
+			rec := request(http.MethodPost, "/register", accept)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType), accept)
+			var legacy models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &legacy))
+			assert.Equal(t, "error", legacy.Status)
+			assert.Len(t, legacy.Messages, 2)
+		}
+	})
+
+	t.Run("ProblemDetailsWhenAccepted", func(t *testing.T) {
+		rec := request(http.MethodPost, "/register", "application/json;q=0.5, application/problem+json")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, testMiddleware.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderAccept)
+		var problem models.ProblemDetails
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, models.ProblemDetails{
+			Type:     "about:blank",
+			Title:    "Bad Request",
+			Status:   http.StatusBadRequest,
+			Detail:   "Invalid email format; Password too short",
+			Instance: "/register",
+			Errors:   []string{"Invalid email format", "Password too short"},
+		}, problem)
+
+		// Errors raised as echo.HTTPError are negotiated too
+		rec = request(http.MethodGet, "/missing", testMiddleware.MIMEApplicationProblemJSON)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		var notFound models.ProblemDetails
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notFound))
+		assert.Equal(t, "Not Found", notFound.Title)
+		assert.Equal(t, "ticket not found", notFound.Detail)
+		assert.Empty(t, notFound.Errors)
+	})
+
+	t.Run("SuccessUnchanged", func(t *testing.T) {
+		rec := request(http.MethodGet, "/ok", testMiddleware.MIMEApplicationProblemJSON)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), `"message":"done"`)
+	})
+
+	problem, legacy := errorFormats.Counts()
+	assert.Equal(t, int64(2), problem)
+	assert.Equal(t, int64(3), legacy)
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketEscalation(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	agent, tokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	manager, managerTokens, err := authService.Register(&models.RegisterRequest{Email: "manager@example.com", Password: "password123", FirstName: "Maria", LastName: "Lopez", Role: models.RoleManager})
+	require.NoError(t, err)
+
+	ticket := &models.Ticket{Title: "Payroll export broken", Description: "x", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: agent.User.ID, AssignedAgentID: &agent.User.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	doAs := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		return doAs(tokens.AccessToken, method, path, body)
+	}
+	details := func() *models.Ticket {
+		rec := do(http.MethodGet, "/api/v1/tickets/"+ticket.ID.String(), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var details models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &details))
+		return &details
+	}
+	escalate := func(reason string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.EscalateTicketRequest{EscalatedTo: manager.User.ID, Reason: reason})
+		return do(http.MethodPost, "/api/v1/tickets/"+ticket.ID.String()+"/escalate", string(body))
+	}
+
+	t.Run("RequiresReason", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, escalate("   ").Code)
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("StoresAndSendsReason", func(t *testing.T) {
+		rec := escalate("  Affects every customer on the March payroll run ")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		current := details()
+		assert.Equal(t, "Affects every customer on the March payroll run", current.EscalationReason)
+		assert.Equal(t, &manager.User.ID, current.EscalatedTo)
+		require.Len(t, current.EscalationEvents, 1)
+		assert.Equal(t, models.EscalationEscalated, current.EscalationEvents[0].Action)
+		assert.Equal(t, "Affects every customer on the March payroll run", current.EscalationEvents[0].Note)
+
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{"manager@example.com"}, sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Subject, "Escalated")
+		assert.Contains(t, sender.messages[0].Text, "Test Agent escalated ticket \"Payroll export broken\"")
+		assert.Contains(t, sender.messages[0].Text, "Affects every customer on the March payroll run")
+	})
+
+	t.Run("DeEscalate", func(t *testing.T) {
+		sender.messages = nil
+		deEscalate := func(token, note string) *httptest.ResponseRecorder {
+			return doAs(token, http.MethodPost, "/api/v1/tickets/"+ticket.ID.String()+"/de-escalate", `{"note":"`+note+`"}`)
+		}
+
+		// Only the escalation target or an administrator may hand the ticket back
+		assert.Equal(t, http.StatusForbidden, deEscalate(tokens.AccessToken, "Never mind").Code)
+		assert.Equal(t, http.StatusConflict, escalate("Again").Code)
+
+		rec := deEscalate(managerTokens.AccessToken, "Approved the refund; please tell the customer")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		current := details()
+		assert.Nil(t, current.EscalatedAt)
+		assert.Nil(t, current.EscalatedTo)
+		assert.Empty(t, current.EscalationReason)
+		require.Len(t, current.EscalationEvents, 2)
+		assert.Equal(t, models.EscalationDeEscalated, current.EscalationEvents[1].Action)
+		assert.Equal(t, manager.User.ID, current.EscalationEvents[1].ActorID)
+		assert.Equal(t, &agent.User.ID, current.EscalationEvents[1].TargetID)
+
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{"agent@example.com"}, sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Text, "Approved the refund; please tell the customer")
+
+		assert.Equal(t, http.StatusConflict, deEscalate(managerTokens.AccessToken, "Again").Code)
+		assert.Equal(t, http.StatusBadRequest, deEscalate(managerTokens.AccessToken, "  ").Code)
+
+		// A handed back ticket can be escalated again
+		require.Equal(t, http.StatusOK, escalate("Customer disputes the refund amount").Code)
+		assert.Len(t, details().EscalationEvents, 3)
+	})
+
+	t.Run("KeptOnNewVersions", func(t *testing.T) {
+		current, err := ticketRepo.GetByID(ctx, ticket.ID)
+		require.NoError(t, err)
+		current.Priority = models.PriorityCritical
+		require.NoError(t, ticketRepo.Update(ctx, current))
+
+		var latest models.Ticket
+		require.NoError(t, db.DB.Where("expiration_time IS NULL AND title = ?", ticket.Title).First(&latest).Error)
+		assert.Equal(t, "Customer disputes the refund amount", latest.EscalationReason)
+	})
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticsExport(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Widget: config.WidgetConfig{
+			Timezone: "UTC",
+		},
+		Export: config.ExportConfig{
+			Prefix: "warehouse",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	exportRepo := repository.NewExportRepository(db)
+	files := storage.NewLocalStorage(t.TempDir())
+	exportService := services.NewExportService(exportRepo, files, cfg)
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, repository.NewUserRepository(db).Create(requester))
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	ticketRepo := repository.NewTicketRepository(db)
+	ticket := &models.Ticket{CreationTime: yesterday.Add(10 * time.Hour), Title: "Printer on fire", Description: "Secret details", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: requester.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+	assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: "Today", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}))
+	assert.NoError(t, repository.NewCommentRepository(db).Create(ctx, &models.Comment{TicketID: ticket.ID, UserID: requester.ID, Content: "Still burning", CreatedAt: yesterday.Add(11 * time.Hour)}))
+
+	readCSV := func(key string) [][]string {
+		f, err := files.Open(ctx, key)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		assert.NoError(t, err)
+		return records
+	}
+
+	t.Run("CatchesUpMissedDays", func(t *testing.T) {
+		assert.NoError(t, exportRepo.Save(ctx, &models.DataExport{Day: today.AddDate(0, 0, -3).Format(time.DateOnly)}))
+
+		assert.NoError(t, exportService.RunNightly(ctx))
+		exports, err := exportService.ListExports(ctx)
+		assert.NoError(t, err)
+		var days []string
+		for _, export := range exports {
+			days = append(days, export.Day)
+		}
+		assert.Equal(t, []string{
+			yesterday.Format(time.DateOnly),
+			today.AddDate(0, 0, -2).Format(time.DateOnly),
+			today.AddDate(0, 0, -3).Format(time.DateOnly),
+		}, days)
+
+		// Nothing new until tomorrow
+		assert.NoError(t, exportService.RunNightly(ctx))
+		exports, err = exportService.ListExports(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, exports, 3)
+	})
+
+	t.Run("WritesOneFilePerDataset", func(t *testing.T) {
+		day := yesterday.Format(time.DateOnly)
+		export, err := exportService.GetExport(ctx, day)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), export.Rows)
+		assert.Contains(t, export.Files, "warehouse/ticket_versions/date="+day+"/ticket_versions.csv")
+
+		tickets := readCSV("warehouse/ticket_versions/date=" + day + "/ticket_versions.csv")
+		if assert.Len(t, tickets, 2, "only the ticket created that day") {
+			assert.Equal(t, "id", tickets[0][0])
+			assert.NotContains(t, tickets[0], "title")
+			assert.NotContains(t, tickets[0], "description")
+			assert.Equal(t, ticket.ID.String(), tickets[1][0])
+			assert.Equal(t, yesterday.Add(10*time.Hour).Format(time.RFC3339Nano), tickets[1][1])
+			assert.Equal(t, "HIGH", tickets[1][4])
+		}
+		comments := readCSV("warehouse/comments/date=" + day + "/comments.csv")
+		assert.Len(t, comments, 2)
+		assert.Len(t, readCSV("warehouse/audit_events/date="+day+"/audit_events.csv"), 1, "header only")
+	})
+
+	t.Run("RerunReplacesDay", func(t *testing.T) {
+		day := yesterday.Format(time.DateOnly)
+		export, err := exportService.ExportDay(ctx, day, &requester.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &requester.ID, export.TriggeredByID)
+		exports, err := exportService.ListExports(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, exports, 3)
+
+		_, err = exportService.ExportDay(ctx, today.Format(time.DateOnly), nil)
+		assert.ErrorIs(t, err, services.ErrInvalidInput, "today is not finished")
+		_, err = exportService.ExportDay(ctx, "yesterday", nil)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = exportService.GetExport(ctx, "2001-01-01")
+		assert.ErrorIs(t, err, services.ErrNotFound)
+	})
+
+	t.Run("S3Storage", func(t *testing.T) {
+		var method, path, authorization, contentHash string
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.EscapedPath()
+			authorization, contentHash = r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256")
+			body, _ = io.ReadAll(r.Body)
+			if strings.Contains(path, "denied") {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}))
+		defer server.Close()
+
+		bucket := storage.NewS3Storage(server.URL, "eu-west-1", "analytics", "AKIDEXAMPLE", "secret")
+		n, err := bucket.Save(ctx, "warehouse/comments/date=2026-01-02/comments.csv", strings.NewReader("id\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/analytics/warehouse/comments/date%3D2026-01-02/comments.csv", path)
+		assert.Equal(t, "id\n", string(body))
+		sum := sha256.Sum256([]byte("id\n"))
+		assert.Equal(t, hex.EncodeToString(sum[:]), contentHash)
+		assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), authorization)
+		assert.Contains(t, authorization, "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+
+		_, err = bucket.Save(ctx, "denied.csv", strings.NewReader("x"))
+		assert.Error(t, err)
+		_, err = bucket.Save(ctx, "../escape.csv", strings.NewReader("x"))
+		assert.ErrorIs(t, err, storage.ErrInvalidKey)
+	})
+
+	t.Run("SchedulerRunsJobs", func(t *testing.T) {
+		jobs := scheduler.New()
+		runs := make(chan struct{}, 10)
+		jobs.Every("tick", 10*time.Millisecond, func(ctx context.Context) error {
+			runs <- struct{}{}
+			return nil
+		})
+		assert.NoError(t, jobs.Daily("nightly", "02:00", time.UTC, func(ctx context.Context) error { return nil }))
+		assert.Error(t, jobs.Daily("bad", "25:99", time.UTC, func(ctx context.Context) error { return nil }))
+
+		jobs.Start()
+		for i := 0; i < 2; i++ {
+			select {
+			case <-runs:
+			case <-time.After(time.Second):
+				t.Fatal("job did not run")
+			}
+		}
+		jobs.Stop()
+	})
+}
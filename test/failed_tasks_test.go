@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/auditsink"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedTasks(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+	ctx := context.Background()
+
+	// The fake collector refuses batches with a report.exported event while
+	// down is set, and accepts the events of the other tests
+	var down atomic.Bool
+	var received atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if down.Load() && strings.Contains(string(body), "report.exported") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	sink, err := auditsink.NewHTTPSink(collector.URL, "json", "")
+	require.NoError(t, err)
+	forwarder := auditsink.NewForwarder([]auditsink.Sink{sink}, 10, 10*time.Millisecond)
+	defer forwarder.Close(ctx)
+
+	userRepo := repository.NewUserRepository(db)
+	taskRepo := repository.NewFailedTaskRepository(db)
+	auditService := services.NewAuditService(repository.NewAuditLogRepository(db), forwarder)
+	failedTasks := services.NewFailedTaskService(taskRepo, auditService)
+	sender := &flakySender{}
+	deliveries := services.NewEmailDeliveryService(sender, repository.NewEmailDeliveryRepository(db), userRepo, failedTasks, cfg)
+	failedTasks.Register(models.FailedTaskEmail, deliveries)
+
+	authService := services.NewAuthService(userRepo, cfg)
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewFailedTaskHandler(failedTasks).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+	admin, tokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Ada", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	list := func(kind string) []models.FailedTask {
+		t.Helper()
+		response, err := failedTasks.List(ctx, &models.FailedTaskQuery{Kind: kind})
+		require.NoError(t, err)
+		return response.Tasks
+	}
+
+	t.Run("FailedEmailIsRetried", func(t *testing.T) {
+		sender.err = errors.New("provider unavailable")
+		err := deliveries.Send(ctx, &email.Message{To: []string{"user@example.com"}, Subject: "Ticket updated", Text: "A new comment"})
+		require.Error(t, err)
+
+		tasks := list(models.FailedTaskEmail)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "Ticket updated", tasks[0].Name)
+		assert.Equal(t, "provider unavailable", tasks[0].Error)
+
+		rec := call(http.MethodGet, "/api/v1/admin/failed-tasks/"+tasks[0].ID.String(), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"subject":"Ticket updated"`, "the payload is shown")
+
+		rec = call(http.MethodPost, "/api/v1/admin/failed-tasks/"+tasks[0].ID.String()+"/retry", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result models.FailedTaskBulkResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.NotEmpty(t, result.Failed, "the provider is still down")
+		assert.Equal(t, 2, list(models.FailedTaskEmail)[0].Failures)
+
+		sender.err = nil
+		rec = call(http.MethodPost, "/api/v1/admin/failed-tasks/"+tasks[0].ID.String()+"/retry", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{"user@example.com"}, sender.messages[0].To)
+		assert.Empty(t, list(models.FailedTaskEmail), "a task that succeeds is removed")
+
+		rec = call(http.MethodPost, "/api/v1/admin/failed-tasks/"+tasks[0].ID.String()+"/retry", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("FailedJobIsKeptOnce", func(t *testing.T) {
+		var runs atomic.Int32
+		jobs := scheduler.New()
+		failedTasks.WatchJobs(jobs)
+		jobs.Every("sync_directory", 10*time.Millisecond, func(ctx context.Context) error {
+			if runs.Add(1) <= 3 {
+				return errors.New("directory unreachable")
+			}
+			return nil
+		})
+		jobs.Start()
+		require.Eventually(t, func() bool { return runs.Load() > 3 }, 5*time.Second, 5*time.Millisecond)
+		jobs.Stop()
+
+		tasks := list(models.FailedTaskJob)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "sync_directory", tasks[0].Name)
+		assert.Equal(t, 3, tasks[0].Failures)
+
+		result, err := failedTasks.Retry(ctx, []uuid.UUID{tasks[0].ID}, admin.User.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{tasks[0].ID}, result.Succeeded)
+		assert.Empty(t, list(models.FailedTaskJob))
+	})
+
+	t.Run("FailedAuditBatchIsResent", func(t *testing.T) {
+		down.Store(true)
+		forwarder.Forward(models.AuditLog{ID: uuid.New(), Action: "report.exported", CreatedAt: time.Now()})
+		require.Eventually(t, func() bool { return len(list(models.FailedTaskAuditSink)) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		task := list(models.FailedTaskAuditSink)[0]
+		assert.Equal(t, "http", task.Name)
+		down.Store(false)
+		before := received.Load()
+
+		rec := call(http.MethodPost, "/api/v1/admin/failed-tasks/retry", `{"ids":["`+task.ID.String()+`"]}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Greater(t, received.Load(), before)
+		assert.Empty(t, list(models.FailedTaskAuditSink))
+	})
+
+	t.Run("Discard", func(t *testing.T) {
+		sender.err = errors.New("provider unavailable")
+		require.Error(t, deliveries.Send(ctx, &email.Message{To: []string{"a@example.com"}, Subject: "One", Text: "1"}))
+		require.Error(t, deliveries.Send(ctx, &email.Message{To: []string{"b@example.com"}, Subject: "Two", Text: "2"}))
+		sender.err = nil
+		tasks := list(models.FailedTaskEmail)
+		require.Len(t, tasks, 2)
+
+		rec := call(http.MethodDelete, "/api/v1/admin/failed-tasks/"+tasks[0].ID.String(), "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		unknown := uuid.New()
+		rec = call(http.MethodPost, "/api/v1/admin/failed-tasks/discard", `{"ids":["`+tasks[1].ID.String()+`","`+unknown.String()+`"]}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var result models.FailedTaskBulkResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, []uuid.UUID{tasks[1].ID}, result.Succeeded)
+		assert.Contains(t, result.Failed, unknown.String())
+		assert.Empty(t, list(""))
+		assert.Len(t, sender.messages, 1, "discarded emails are not sent")
+
+		rec = call(http.MethodPost, "/api/v1/admin/failed-tasks/discard", `{"ids":[]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureUsage(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	authService := services.NewAuthService(repository.NewUserRepository(db), cfg)
+	usageService := services.NewUsageService(cfg)
+	featureUsageService := services.NewFeatureUsageService(repository.NewFeatureUsageRepository(db), cfg)
+	ami := testMiddleware.NewAuthMiddleware(authService, usageService)
+
+	e := echo.New()
+	e.Use(testMiddleware.FeatureUsage(featureUsageService))
+	handlers.NewUserHandler(nil, nil, nil, usageService).RegisterRoutes(e, ami)
+	handlers.NewFeatureUsageHandler(featureUsageService).RegisterRoutes(e, ami)
+
+	request := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	admin, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	report := func(query string) *models.FeatureUsageReport {
+		return decodeFeatureUsage(t, request("/api/v1/admin/feature-usage"+query, adminTokens.AccessToken))
+	}
+
+	// The agent checks their usage three times, the admin once and an anonymous caller is refused
+	for range 3 {
+		assert.Equal(t, http.StatusOK, request("/api/v1/users/me/usage", agentTokens.AccessToken).Code)
+	}
+	assert.Equal(t, http.StatusOK, request("/api/v1/users/me/usage", adminTokens.AccessToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, request("/api/v1/users/me/usage", "").Code)
+	// Paths matching no route are not features
+	assert.Equal(t, http.StatusNotFound, request("/api/v1/unknown", agentTokens.AccessToken).Code)
+
+	t.Run("CountedOnFlush", func(t *testing.T) {
+		empty := report("")
+		assert.Zero(t, empty.Requests, "requests are only written when flushed")
+
+		require.NoError(t, featureUsageService.Flush(context.Background()))
+		usage := report("")
+		// The first report request was counted before the flush too
+		assert.Equal(t, int64(6), usage.Requests)
+		assert.Equal(t, int64(2), usage.Users)
+
+		var me *models.FeatureUsageSummary
+		for i := range usage.Features {
+			assert.NotEqual(t, "/api/v1/unknown", usage.Features[i].Route)
+			if usage.Features[i].Route == "/api/v1/users/me/usage" {
+				me = &usage.Features[i]
+			}
+		}
+		require.NotNil(t, me, "the usage route is reported")
+		assert.Equal(t, "GET", me.Method)
+		assert.Equal(t, int64(5), me.Requests)
+		assert.Equal(t, int64(2), me.Users)
+		assert.Equal(t, int64(1), me.Errors)
+		assert.InDelta(t, 0.2, me.ErrorRate, 0.0001)
+		assert.Equal(t, "/api/v1/users/me/usage", usage.Features[0].Route, "busiest route first")
+
+		roles := make(map[models.UserRole]int64)
+		for _, role := range usage.Roles {
+			roles[role.Role] = role.Requests
+		}
+		assert.Equal(t, map[models.UserRole]int64{models.RoleSupportAgent: 3, models.RoleAdministrator: 2, "": 1}, roles)
+
+		require.Len(t, usage.TopUsers, 2, "anonymous requests are not a user")
+		assert.Equal(t, agent.User.ID, usage.TopUsers[0].UserID)
+		assert.Equal(t, "agent@example.com", usage.TopUsers[0].Email)
+		assert.Equal(t, int64(3), usage.TopUsers[0].Requests)
+		assert.Equal(t, admin.User.ID, usage.TopUsers[1].UserID)
+		assert.Equal(t, int64(2), usage.TopUsers[1].Features)
+	})
+
+	t.Run("FlushesAddUp", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/api/v1/users/me/usage", agentTokens.AccessToken).Code)
+		require.NoError(t, featureUsageService.Flush(context.Background()))
+
+		usage := report("?limit=1")
+		require.Len(t, usage.Features, 1)
+		assert.Equal(t, int64(6), usage.Features[0].Requests)
+		require.Len(t, usage.TopUsers, 1)
+		assert.Equal(t, int64(4), usage.TopUsers[0].Requests)
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		today := time.Now().UTC().Format(time.DateOnly)
+		assert.NotZero(t, report("?from="+today+"&to="+today).Requests)
+		assert.Zero(t, report("?from=2020-01-01&to=2020-01-31").Requests)
+
+		for _, query := range []string{"?from=2024-02-01&to=2024-01-01", "?from=yesterday", "?from=2020-01-01&to=2024-01-01", "?limit=0", "?limit=101"} {
+			assert.Equal(t, http.StatusBadRequest, request("/api/v1/admin/feature-usage"+query, adminTokens.AccessToken).Code, query)
+		}
+	})
+
+	t.Run("AdminsOnly", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("/api/v1/admin/feature-usage", agentTokens.AccessToken).Code)
+	})
+}
+
+func decodeFeatureUsage(t *testing.T, rec *httptest.ResponseRecorder) *models.FeatureUsageReport {
+	t.Helper()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var report models.FeatureUsageReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return &report
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+		HTTPCache: config.HTTPCacheConfig{
+			TTL:    "5m",
+			MaxAge: 60,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	knowledgeRepo := repository.NewKnowledgeRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	knowledgeService := services.NewKnowledgeService(knowledgeRepo)
+	intakeService := services.NewIntakeService(repository.NewTicketRepository(db), categoryRepo, userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), nil, nil)
+	botProtection, err := testMiddleware.NewBotProtection(cfg)
+	assert.NoError(t, err)
+	responseCache := testMiddleware.NewResponseCache(cache.NewMemory(), cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewKnowledgeHandler(knowledgeService, responseCache).RegisterRoutes(e, ami)
+	handlers.NewIntakeHandler(intakeService, botProtection, responseCache).RegisterRoutes(e, ami)
+
+	_, tokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	assert.NoError(t, err)
+
+	article, err := knowledgeService.CreateArticle(ctx, &models.CreateKnowledgeArticleRequest{Title: "Reset your password", Body: "Use the link on the sign-in page", IsPublished: true}, uuid.Nil)
+	assert.NoError(t, err)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/api/v1/public/kb/articles", "")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Contains(t, first.Body.String(), "Reset your password")
+	assert.Equal(t, "public, max-age=60", first.Header().Get("Cache-Control"))
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	t.Run("ServedFromCache", func(t *testing.T) {
+		// Removed behind the handler's back, so only the cache still has it
+		assert.NoError(t, knowledgeRepo.Delete(ctx, article.ID))
+
+		rec := get("/api/v1/public/kb/articles", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, first.Body.String(), rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+
+		assert.NoError(t, knowledgeRepo.Create(ctx, article))
+	})
+
+	t.Run("NotModified", func(t *testing.T) {
+		rec := get("/api/v1/public/kb/articles", etag)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+
+		rec = get("/api/v1/public/kb/articles", `"stale"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("InvalidatedByEdits", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/kb/articles/"+article.ID.String(), strings.NewReader(`{"title":"Resetting a forgotten password"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = get("/api/v1/public/kb/articles", etag)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Resetting a forgotten password")
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("SearchesNotStored", func(t *testing.T) {
+		rec := get("/api/v1/public/kb/articles?q=password", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+		assert.NoError(t, knowledgeRepo.Delete(ctx, article.ID))
+		rec = get("/api/v1/public/kb/articles?q=password", "")
+		assert.Equal(t, "[]\n", rec.Body.String())
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		assert.NoError(t, categoryRepo.Create(ctx, &models.Category{Name: "Hardware", IsActive: true}))
+		rec := get("/api/v1/public/categories", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Hardware")
+
+		assert.Equal(t, http.StatusNotModified, get("/api/v1/public/categories", rec.Header().Get("ETag")).Code)
+	})
+}
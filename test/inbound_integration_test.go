@@ -0,0 +1,270 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboundIntegrations(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, commentRepo, userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewInboundIntegrationHandler(services.NewInboundService(repository.NewInboundIntegrationRepository(db), userRepo, categoryRepo, teamRepo, ticketService)).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, managerTokens, err := authService.Register(&models.RegisterRequest{Email: "manager@example.com", Password: "password123", FirstName: "Maria", LastName: "Lopez", Role: models.RoleManager})
+	require.NoError(t, err)
+	monitoring, _, err := authService.Register(&models.RegisterRequest{Email: "monitoring@example.com", Password: "password123", FirstName: "Monitoring", LastName: "Bot", Role: models.RoleEndUser})
+	require.NoError(t, err)
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: managerTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	post := func(key string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/inbound/"+key, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	result := func(rec *httptest.ResponseRecorder) *models.InboundResult {
+		var result models.InboundResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		return &result
+	}
+
+	request := `{
+		"key": "grafana",
+		"name": "Grafana alerts",
+		"requester_id": "` + monitoring.User.ID.String() + `",
+		"mapping": {
+			"title": "{{alert.name}} on {{alert.labels.host}}",
+			"external_id": "{{alert.id}}",
+			"priority": "{{alert.labels.severity}}",
+			"priorities": {"critical": "CRITICAL", "warning": "HIGH"},
+			"status": "{{alert.state}}",
+			"statuses": {"ok": "RESOLVED", "alerting": "IN_PROGRESS"},
+			"comment": "Alert is {{alert.state}}: {{alert.value}}"
+		}
+	}`
+	rec := admin(http.MethodPost, "/api/v1/admin/inbound-integrations", request)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created models.InboundIntegrationSecretResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Secret)
+	assert.True(t, created.Integration.Enabled)
+	assert.Equal(t, models.PriorityMedium, created.Integration.Mapping.DefaultPriority)
+	secret := created.Secret
+	integrationPath := "/api/v1/admin/inbound-integrations/" + created.Integration.ID.String()
+
+	alert := func(state string, value int) string {
+		return `{"alert":{"id":"cpu-high-web1","name":"CPU high","state":"` + state + `","value":` + strconv.Itoa(value) + `,"labels":{"host":"web1","severity":"critical"}}}`
+	}
+
+	t.Run("Management", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, admin(http.MethodPost, "/api/v1/admin/inbound-integrations", request).Code, "keys are unique")
+		assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, "/api/v1/admin/inbound-integrations", strings.Replace(request, `"grafana"`, `"Grafana Alerts"`, 1)).Code)
+		assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, "/api/v1/admin/inbound-integrations", strings.Replace(request, `"HIGH"`, `"URGENT"`, 1)).Code)
+
+		rec := admin(http.MethodGet, "/api/v1/admin/inbound-integrations", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), secret, "secrets are only shown when generated")
+		assert.NotContains(t, rec.Body.String(), `"secret"`)
+	})
+
+	var ticketID string
+	t.Run("OpensTicket", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, post("grafana", nil, alert("alerting", 93)).Code)
+		assert.Equal(t, http.StatusUnauthorized, post("grafana", map[string]string{"X-Inbound-Secret": "wrong"}, alert("alerting", 93)).Code)
+		assert.Equal(t, http.StatusUnauthorized, post("unknown", map[string]string{"X-Inbound-Secret": secret}, alert("alerting", 93)).Code, "unknown keys look like a wrong secret")
+		assert.Equal(t, http.StatusBadRequest, post("grafana", map[string]string{"X-Inbound-Secret": secret}, `{"alert":`).Code)
+
+		rec := post("grafana", map[string]string{"X-Inbound-Secret": secret}, alert("alerting", 93))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		opened := result(rec)
+		assert.True(t, opened.Created)
+		ticketID = opened.TicketID.String()
+
+		ticket, err := ticketService.GetTicket(ctx, opened.TicketID)
+		require.NoError(t, err)
+		assert.Equal(t, "CPU high on web1", ticket.Title)
+		assert.Equal(t, models.PriorityCritical, ticket.Priority)
+		assert.Equal(t, monitoring.User.ID, ticket.CreatedByID)
+		assert.Contains(t, ticket.Description, `"host": "web1"`, "the description defaults to the payload")
+
+		integration := admin(http.MethodGet, integrationPath, "")
+		require.Equal(t, http.StatusOK, integration.Code)
+		assert.NotContains(t, integration.Body.String(), `"last_received_at":null`)
+	})
+
+	t.Run("UpdatesTicketOfExternalID", func(t *testing.T) {
+		body := alert("ok", 12)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		rec := post("grafana", map[string]string{"X-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil))}, body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		updated := result(rec)
+		assert.False(t, updated.Created)
+		assert.Equal(t, ticketID, updated.TicketID.String())
+		assert.Equal(t, models.StatusResolved, updated.Status)
+		require.NotNil(t, updated.CommentID)
+		comment, err := commentRepo.GetByID(ctx, *updated.CommentID)
+		require.NoError(t, err)
+		assert.Equal(t, "Alert is ok: 12", comment.Content)
+
+		// Firing again reopens the same ticket rather than raising another
+		rec = post("grafana", map[string]string{"X-Inbound-Secret": secret}, alert("alerting", 97))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, models.StatusInProgress, result(rec).Status)
+		assert.Equal(t, ticketID, result(rec).TicketID.String())
+
+		// A payload about something else opens its own ticket
+		rec = post("grafana", map[string]string{"X-Inbound-Secret": secret}, strings.Replace(alert("alerting", 91), "cpu-high-web1", "cpu-high-web2", 1))
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.NotEqual(t, ticketID, result(rec).TicketID.String())
+	})
+
+	t.Run("Alertmanager", func(t *testing.T) {
+		rec := admin(http.MethodPost, "/api/v1/admin/inbound-integrations", `{"key":"prometheus","name":"Prometheus","requester_id":"`+monitoring.User.ID.String()+`","mapping":{"priorities":{"page":"CRITICAL"}}}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var prometheus models.InboundIntegrationSecretResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &prometheus))
+		bearer := map[string]string{"Authorization": "Bearer " + prometheus.Secret}
+
+		notify := func(status, statusWeb1, statusWeb2 string) *httptest.ResponseRecorder {
+			return post("prometheus/alertmanager", bearer, `{
+				"version": "4", "groupKey": "{}:{alertname=\"HighCPU\", job=\"node\"}", "status": "`+status+`", "receiver": "helpchat",
+				"groupLabels": {"alertname": "HighCPU", "job": "node"},
+				"commonLabels": {"alertname": "HighCPU", "job": "node", "severity": "warning"},
+				"commonAnnotations": {"summary": "CPU above 90%"},
+				"externalURL": "http://alertmanager:9093",
+				"alerts": [
+					{"status": "`+statusWeb1+`", "labels": {"alertname": "HighCPU", "instance": "web1", "job": "node", "severity": "warning"}, "annotations": {"summary": "CPU above 90%"}, "startsAt": "2026-10-17T08:00:00Z", "endsAt": "0001-01-01T00:00:00Z", "fingerprint": "1a2b3c"},
+					{"status": "`+statusWeb2+`", "labels": {"alertname": "HighCPU", "instance": "web2", "job": "node", "severity": "warning"}, "annotations": {"summary": "CPU above 90%"}, "startsAt": "2026-10-17T08:01:00Z", "endsAt": "0001-01-01T00:00:00Z", "fingerprint": "4d5e6f"}
+				]
+			}`)
+		}
+		alertResult := func(rec *httptest.ResponseRecorder) *models.AlertmanagerResult {
+			var result models.AlertmanagerResult
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+			return &result
+		}
+		comments := func(ticketID uuid.UUID) []models.Comment {
+			comments, err := commentRepo.GetByTicket(ctx, ticketID, true)
+			require.NoError(t, err)
+			return comments
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, post("prometheus/alertmanager", map[string]string{"Authorization": "Bearer " + secret}, `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, post("prometheus/alertmanager", bearer, `{"alerts":[]}`).Code)
+
+		rec = notify("firing", "firing", "firing")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		opened := alertResult(rec)
+		require.NotNil(t, opened.TicketID)
+		assert.Equal(t, int64(2), opened.Firing)
+		ticket, err := ticketService.GetTicket(ctx, *opened.TicketID)
+		require.NoError(t, err)
+		assert.Equal(t, "HighCPU (job=node)", ticket.Title)
+		assert.Equal(t, models.PriorityMedium, ticket.Priority)
+		assert.Contains(t, ticket.Description, "CPU above 90%")
+		assert.Contains(t, ticket.Description, "HighCPU instance=web2, job=node, severity=warning")
+
+		// Repeated notifications are deduplicated on fingerprint
+		rec = notify("firing", "firing", "firing")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, opened.TicketID, alertResult(rec).TicketID)
+		assert.Empty(t, comments(*opened.TicketID))
+
+		rec = notify("firing", "firing", "resolved")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, int64(1), alertResult(rec).Firing)
+		assert.Equal(t, models.StatusOpen, alertResult(rec).Status)
+		require.Len(t, comments(*opened.TicketID), 1)
+		assert.Equal(t, "Resolved:\n- HighCPU instance=web2, job=node, severity=warning: CPU above 90%", comments(*opened.TicketID)[0].Content)
+
+		// The ticket resolves when the alerts clear and reopens when one fires again
+		rec = notify("resolved", "resolved", "resolved")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, models.StatusResolved, alertResult(rec).Status)
+		assert.Zero(t, alertResult(rec).Firing)
+
+		rec = notify("firing", "firing", "resolved")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, opened.TicketID, alertResult(rec).TicketID)
+		assert.Equal(t, models.StatusInProgress, alertResult(rec).Status)
+		assert.Len(t, comments(*opened.TicketID), 3)
+
+		// Alerts that clear before they ever opened a ticket are ignored
+		rec = post("prometheus/alertmanager", bearer, `{"groupKey":"{}:{alertname=\"DiskFull\"}","status":"resolved","alerts":[{"status":"resolved","labels":{"alertname":"DiskFull"},"fingerprint":"7a8b9c"}]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Nil(t, alertResult(rec).TicketID)
+	})
+
+	t.Run("RotateAndDisable", func(t *testing.T) {
+		rec := admin(http.MethodPost, integrationPath+"/secret", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var rotated models.InboundIntegrationSecretResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rotated))
+		assert.NotEqual(t, secret, rotated.Secret)
+		assert.Equal(t, http.StatusUnauthorized, post("grafana", map[string]string{"X-Inbound-Secret": secret}, alert("ok", 10)).Code, "the old secret is refused")
+		assert.Equal(t, http.StatusOK, post("grafana?secret="+rotated.Secret, nil, alert("ok", 10)).Code)
+
+		disabled := strings.Replace(request, `"name"`, `"enabled": false, "name"`, 1)
+		rec = admin(http.MethodPut, integrationPath, disabled)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, http.StatusUnauthorized, post("grafana", map[string]string{"X-Inbound-Secret": rotated.Secret}, alert("alerting", 99)).Code)
+
+		assert.Equal(t, http.StatusNoContent, admin(http.MethodDelete, integrationPath, "").Code)
+		assert.Equal(t, http.StatusNotFound, admin(http.MethodGet, integrationPath, "").Code)
+	})
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpamFilter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("KeywordRules", func(t *testing.T) {
+		filter := services.NewSpamFilter(&config.Config{Spam: config.SpamConfig{Enabled: true, Threshold: 5, Keywords: []string{"casino"}}})
+
+		verdict, isSpam := filter.Evaluate(ctx, &services.SpamCandidate{Subject: "BEST CASINO BONUS", Body: "casino casino http://a http://b http://c http://d"})
+		assert.True(t, isSpam)
+		assert.Contains(t, verdict.Reasons, "keyword: casino")
+
+		_, isSpam = filter.Evaluate(ctx, &services.SpamCandidate{Subject: "Printer offline", Body: "The 3rd floor printer is offline"})
+		assert.False(t, isSpam)
+	})
+
+	t.Run("ExternalServiceAddsScore", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+			json.NewEncoder(w).Encode(services.SpamVerdict{Score: 9, Reasons: []string{"external"}})
+		}))
+		defer server.Close()
+
+		filter := services.NewSpamFilter(&config.Config{Spam: config.SpamConfig{Enabled: true, Threshold: 5, ExternalURL: server.URL, ExternalAPIKey: "key"}})
+		verdict, isSpam := filter.Evaluate(ctx, &services.SpamCandidate{Subject: "hello"})
+		assert.True(t, isSpam)
+		assert.Equal(t, []string{"external"}, verdict.Reasons)
+	})
+
+	t.Run("ExternalServiceFailureFailsOpen", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		filter := services.NewSpamFilter(&config.Config{Spam: config.SpamConfig{Enabled: true, Threshold: 5, ExternalURL: server.URL}})
+		_, isSpam := filter.Evaluate(ctx, &services.SpamCandidate{Subject: "hello"})
+		assert.False(t, isSpam)
+	})
+}
+
+func TestGuestIntakeQuarantine(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress:       "support@helpchat.com",
+			MaxAttachmentSize: 1024,
+		},
+		Storage: config.StorageConfig{
+			MaxUploadSize: 4096,
+			AllowedTypes:  []string{"image/*", "text/plain"},
+		},
+		Spam: config.SpamConfig{
+			Enabled:   true,
+			Threshold: 5,
+			Keywords:  []string{"casino"},
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, &recordingSender{}, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, Notifier: emailChannel, Redaction: redaction})
+	intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "x", FirstName: "Ad", LastName: "Min", Role: models.RoleAdministrator}
+	assert.NoError(t, userRepo.Create(admin))
+
+	t.Run("CleanSubmissionCreatesTicketForGuest", func(t *testing.T) {
+		ticket, err := intake.SubmitGuestTicket(ctx, &models.GuestTicketRequest{
+			Name: "Jane Guest", Email: "jane@example.com", Title: "Cannot log in", Description: "Locked out",
+		}, "203.0.113.7")
+		assert.NoError(t, err)
+		if assert.NotNil(t, ticket) {
+			guest, err := userRepo.GetByEmail("jane@example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, guest.ID, ticket.CreatedByID)
+			assert.False(t, guest.IsVerified)
+			assert.Equal(t, models.PriorityMedium, ticket.Priority)
+		}
+	})
+
+	t.Run("SpamIsQuarantinedThenApproved", func(t *testing.T) {
+		ticket, err := intake.IngestEmail(ctx, &models.InboundEmail{
+			From:    "Spammer <spam@example.com>",
+			Subject: "Casino bonus",
+			Text:    "Play casino now",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, ticket)
+
+		pending, err := intake.ListQuarantine(ctx, models.QuarantinePending, 1, 20)
+		assert.NoError(t, err)
+		if !assert.Len(t, pending.Submissions, 1) {
+			return
+		}
+		submission := pending.Submissions[0]
+		assert.Equal(t, models.SourceEmail, submission.Source)
+		assert.GreaterOrEqual(t, submission.SpamScore, 5.0)
+
+		ticket, err = intake.ApproveSubmission(ctx, submission.ID, admin.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Casino bonus", ticket.Title)
+
+		// A reviewed submission cannot be reviewed again
+		assert.ErrorIs(t, intake.RejectSubmission(ctx, submission.ID, admin.ID), services.ErrConflict)
+	})
+
+	t.Run("EmailKeepsOnlyAttachmentsThatCouldBeUploaded", func(t *testing.T) {
+		ticket, err := intake.IngestEmail(ctx, &models.InboundEmail{
+			From:    "Pat Sender <pat@example.com>",
+			Subject: "Screen is blank",
+			Text:    "See the photo",
+			Attachments: []models.InboundAttachment{
+				{Filename: "photo.png", ContentType: "image/png", Content: []byte("png")},
+				{Filename: "fix.exe", ContentType: "application/x-msdownload", Content: []byte("MZ")},
+				{Filename: "huge.png", ContentType: "image/png", Content: make([]byte, 2048)},
+				{Filename: "empty.txt", ContentType: "text/plain"},
+			},
+		})
+		assert.NoError(t, err)
+		if !assert.NotNil(t, ticket) {
+			return
+		}
+
+		attachments, err := attachmentRepo.GetByTicket(ctx, ticket.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, attachments, 1) {
+			assert.Equal(t, "photo.png", attachments[0].Filename)
+			assert.Equal(t, "image/png", attachments[0].MimeType)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/auditsink"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/secrets"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationHealth(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com", PrimaryColor: "#2563eb"},
+	}
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+	ctx := context.Background()
+
+	// The fake provider accepts one API key and any message
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer provider.Close()
+	// The fake collector only takes POST, and the fake hub rejects every signature
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer collector.Close()
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer hub.Close()
+
+	userRepo := repository.NewUserRepository(db)
+	newEmail := func(apiKey string) *services.EmailDeliveryService {
+		sender := email.NewSendGridSender(config.EmailConfig{SendGridEndpoint: provider.URL, FromAddress: "support@helpchat.com"}, apiKey)
+		layout, err := email.NewLayout(cfg.Email)
+		require.NoError(t, err)
+		return services.NewEmailDeliveryService(email.NewLayoutSender(sender, layout), repository.NewEmailDeliveryRepository(db), userRepo, nil, cfg)
+	}
+	exportRepo := repository.NewExportRepository(db)
+
+	t.Setenv("AUDIT_HTTP_TOKEN", "collector-token")
+	t.Setenv("AUDIT_EVENTHUB_KEY", "hub-key")
+	forwarder, err := auditsink.New(ctx, config.AuditConfig{
+		Sinks:             []string{"http", "eventhub"},
+		BufferSize:        10,
+		FlushInterval:     "10ms",
+		HTTPURL:           collector.URL + "/events",
+		HTTPFormat:        "json",
+		HTTPTokenSecret:   "AUDIT_HTTP_TOKEN",
+		EventHubEndpoint:  hub.URL,
+		EventHubName:      "audit",
+		EventHubKeyName:   "send",
+		EventHubKeySecret: "AUDIT_EVENTHUB_KEY",
+	}, secrets.EnvProvider{})
+	require.NoError(t, err)
+	defer forwarder.Close(ctx)
+
+	find := func(report *models.IntegrationHealthReport, name string) models.IntegrationHealth {
+		t.Helper()
+		for _, health := range report.Integrations {
+			if health.Name == name {
+				return health
+			}
+		}
+		require.Failf(t, "integration not reported", "%s", name)
+		return models.IntegrationHealth{}
+	}
+
+	t.Run("HealthyIntegrations", func(t *testing.T) {
+		deliveries := newEmail("good-key")
+		exportDir := t.TempDir()
+		health := services.NewIntegrationHealthService()
+		health.Register("email", deliveries)
+		health.Register("export_storage", services.NewExportService(exportRepo, storage.NewLocalStorage(exportDir), cfg))
+
+		report := health.Check(ctx)
+		assert.True(t, report.Healthy)
+		assert.Equal(t, []string{"email", "export_storage"}, []string{report.Integrations[0].Name, report.Integrations[1].Name}, "integrations are reported in registration order")
+		assert.Nil(t, find(report, "email").LastDelivery, "nothing has been sent yet")
+		files, err := os.ReadDir(exportDir)
+		require.NoError(t, err)
+		assert.Empty(t, files, "the probe file is removed")
+
+		require.NoError(t, deliveries.Send(ctx, &email.Message{To: []string{"user@example.com"}, Subject: "Hello", Text: "Hi"}))
+		delivered := find(health.Check(ctx), "email").LastDelivery
+		require.NotNil(t, delivered)
+		assert.WithinDuration(t, time.Now(), *delivered, time.Minute)
+	})
+
+	t.Run("FailingIntegrations", func(t *testing.T) {
+		// A file where the export directory should be cannot be written under
+		blocked := filepath.Join(t.TempDir(), "exports")
+		require.NoError(t, os.WriteFile(blocked, []byte("x"), 0o600))
+
+		health := services.NewIntegrationHealthService()
+		health.Register("email", newEmail("revoked-key"))
+		health.Register("export_storage", services.NewExportService(exportRepo, storage.NewLocalStorage(blocked), cfg))
+		report := health.Check(ctx)
+
+		assert.False(t, report.Healthy)
+		for _, name := range []string{"email", "export_storage"} {
+			result := find(report, name)
+			assert.False(t, result.Healthy, name)
+			assert.NotEmpty(t, result.Error, name)
+		}
+		assert.Contains(t, find(report, "email").Error, "401")
+	})
+
+	t.Run("AuditSinks", func(t *testing.T) {
+		health := services.NewIntegrationHealthService()
+		sinks := forwarder.Sinks()
+		require.Len(t, sinks, 2)
+		for _, sink := range sinks {
+			health.Register("audit_"+sink.Name, sink)
+		}
+
+		report := health.Check(ctx)
+		assert.False(t, report.Healthy)
+		assert.True(t, find(report, "audit_http").Healthy, "a collector answering 405 to the probe is up")
+		assert.Contains(t, find(report, "audit_eventhub").Error, "rejected the credentials")
+		assert.Nil(t, find(report, "audit_http").LastDelivery)
+
+		forwarder.Forward(models.AuditLog{Action: "user.login", CreatedAt: time.Now()})
+		require.Eventually(t, func() bool {
+			return find(health.Check(ctx), "audit_http").LastDelivery != nil
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Nil(t, find(health.Check(ctx), "audit_eventhub").LastDelivery, "the hub never accepted the event")
+	})
+}
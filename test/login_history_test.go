@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	firefoxWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	firefoxNewer   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0"
+	safariIPhone   = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+)
+
+func TestDeviceLabel(t *testing.T) {
+	assert.Equal(t, "Firefox on Windows", services.DeviceLabel(firefoxWindows))
+	assert.Equal(t, "Safari on iOS", services.DeviceLabel(safariIPhone))
+	assert.Equal(t, "Edge on Windows", services.DeviceLabel("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0"))
+	assert.Equal(t, "Unknown browser on Unknown OS", services.DeviceLabel(""))
+}
+
+func TestLoginHistory(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Security: config.SecurityConfig{
+			LoginAlertsEnabled: true,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	sender := &recordingSender{}
+	loginHistory := services.NewLoginHistoryService(repository.NewLoginEventRepository(db), sender, cfg)
+
+	user := &models.User{Email: "user@example.com", PasswordHash: "x", FirstName: "Us", LastName: "Er", Role: models.RoleEndUser}
+	assert.NoError(t, userRepo.Create(user))
+
+	login := func(userAgent, location string) *models.LoginEvent {
+		event, err := loginHistory.RecordLogin(ctx, user, &models.LoginMetadata{IPAddress: "203.0.113.5", UserAgent: userAgent, Location: location})
+		assert.NoError(t, err)
+		return event
+	}
+
+	// The first login establishes the baseline
+	assert.False(t, login(firefoxWindows, "US").NewDevice)
+	// A browser update is still the same device
+	assert.False(t, login(firefoxNewer, "US").NewDevice)
+	assert.Empty(t, sender.messages)
+
+	// A new device alerts
+	assert.True(t, login(safariIPhone, "US").NewDevice)
+	// A known device from a new location alerts
+	assert.True(t, login(firefoxWindows, "DE").NewDevice)
+	if assert.Len(t, sender.messages, 2) {
+		assert.Equal(t, []string{user.Email}, sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Text, "Safari on iOS")
+		assert.Contains(t, sender.messages[1].Text, "Location: DE")
+	}
+
+	history, err := loginHistory.ListLogins(ctx, user.ID, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), history.Total)
+	assert.Equal(t, 2, history.TotalPages)
+	assert.Len(t, history.Logins, 2)
+
+	t.Run("AlertsDisabled", func(t *testing.T) {
+		sender.messages = nil
+		cfg.Security.LoginAlertsEnabled = false
+		assert.True(t, login("curl/8.0", "").NewDevice)
+		assert.Empty(t, sender.messages)
+	})
+}
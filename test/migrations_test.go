@@ -0,0 +1,129 @@
+package test
+
+import (
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedMigrations(t *testing.T) {
+	newDatabase := func(t *testing.T) *database.Database {
+		db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:"}})
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	schema := func(t *testing.T, db *database.Database) []string {
+		var statements []string
+		require.NoError(t, db.DB.Raw("SELECT sql FROM sqlite_master WHERE sql IS NOT NULL ORDER BY type, name").Scan(&statements).Error)
+		return statements
+	}
+	tables := func(t *testing.T, db *database.Database) []string {
+		var names []string
+		require.NoError(t, db.DB.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&names).Error)
+		return names
+	}
+
+	t.Run("MatchModels", func(t *testing.T) {
+		db := newDatabase(t)
+		require.NoError(t, database.RunMigrations(db))
+		migrated := schema(t, db)
+
+		require.NoError(t, db.DB.AutoMigrate(database.Models()...))
+		assert.Equal(t, migrated, schema(t, db), "the migrations build the schema AutoMigrate would; add a migration for the model change")
+	})
+
+	t.Run("TicketIDIsNotAForeignKey", func(t *testing.T) {
+		db := newDatabase(t)
+		require.NoError(t, database.RunMigrations(db))
+
+		// Every version shares the ticket id, so a foreign key to it would be
+		// a mismatch once SQLite enforces foreign keys
+		assert.NoError(t, db.DB.Exec("PRAGMA foreign_key_check").Error)
+		var references int64
+		require.NoError(t, db.DB.Raw("SELECT COUNT(*) FROM sqlite_master AS m, pragma_foreign_key_list(m.name) AS f WHERE m.type = 'table' AND f.\"table\" = 'tickets'").Scan(&references).Error)
+		assert.Zero(t, references)
+	})
+
+	t.Run("RollBackAndForward", func(t *testing.T) {
+		db := newDatabase(t)
+		require.NoError(t, database.RunMigrations(db))
+		latest, err := database.MigrationVersion(db)
+		require.NoError(t, err)
+		assert.Positive(t, latest)
+		migrated := schema(t, db)
+
+		require.NoError(t, database.MigrateTo(db, 0))
+		version, err := database.MigrationVersion(db)
+		require.NoError(t, err)
+		assert.Zero(t, version)
+		assert.Equal(t, []string{database.MigrationsTable}, tables(t, db))
+
+		require.NoError(t, database.MigrateTo(db, latest))
+		assert.Equal(t, migrated, schema(t, db))
+		require.NoError(t, database.RunMigrations(db), "migrations can run again")
+
+		assert.Error(t, database.MigrateTo(db, latest+1))
+		assert.Error(t, database.MigrateTo(db, -1))
+	})
+
+	t.Run("RefuseNewerSchema", func(t *testing.T) {
+		db := newDatabase(t)
+		require.NoError(t, database.RunMigrations(db))
+		latest, err := database.MigrationVersion(db)
+		require.NoError(t, err)
+		require.NoError(t, db.DB.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from a newer build', CURRENT_TIMESTAMP)", latest+1).Error)
+
+		assert.ErrorContains(t, database.RunMigrations(db), "newer")
+	})
+
+	t.Run("UpgradeLegacyDatabase", func(t *testing.T) {
+		db := newDatabase(t)
+		fresh := newDatabase(t)
+		require.NoError(t, database.RunMigrations(fresh))
+
+		// A users table as an older release's AutoMigrate left it, without the
+		// columns added since
+		userID := uuid.New()
+		require.NoError(t, db.DB.Exec("CREATE TABLE `users` (`id` char(36),`email` text NOT NULL,`password_hash` text NOT NULL,`first_name` text NOT NULL,`last_name` text NOT NULL,`role` text NOT NULL DEFAULT \"END_USER\",`is_verified` numeric DEFAULT false,`is_active` numeric DEFAULT true,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`))").Error)
+		require.NoError(t, db.DB.Exec("INSERT INTO users (id, email, password_hash, first_name, last_name, role, is_active) VALUES (?, 'legacy@example.com', 'x', 'Legacy', 'User', 'SUPPORT_AGENT', true)", userID).Error)
+		// Tickets and their comments from before versions had their own ID,
+		// when comments could reference the ticket id
+		ticketID, commentID := uuid.New(), uuid.New()
+		require.NoError(t, db.DB.Exec("CREATE TABLE `tickets` (`id` char(36),`creation_time` datetime NOT NULL,`expiration_time` datetime,`title` text NOT NULL,`description` text NOT NULL,`status` text NOT NULL DEFAULT \"OPEN\",`priority` text NOT NULL DEFAULT \"MEDIUM\",`created_by_id` char(36) NOT NULL,PRIMARY KEY (`id`))").Error)
+		require.NoError(t, db.DB.Exec("CREATE TABLE `comments` (`id` char(36),`ticket_id` char(36) NOT NULL,`user_id` char(36) NOT NULL,`content` text NOT NULL,`is_internal` numeric DEFAULT false,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`),CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_tickets_comments` FOREIGN KEY (`ticket_id`) REFERENCES `tickets`(`id`))").Error)
+		require.NoError(t, db.DB.Exec("INSERT INTO tickets (id, creation_time, title, description, created_by_id) VALUES (?, CURRENT_TIMESTAMP, 'Legacy ticket', 'x', ?)", ticketID, userID).Error)
+		require.NoError(t, db.DB.Exec("INSERT INTO comments (id, ticket_id, user_id, content) VALUES (?, ?, ?, 'Legacy comment')", commentID, ticketID, userID).Error)
+
+		require.NoError(t, database.RunMigrations(db))
+		var versions []int
+		require.NoError(t, db.DB.Raw("SELECT version FROM schema_migrations ORDER BY version").Scan(&versions).Error)
+		assert.Equal(t, 1, versions[0], "a legacy database is taken to the baseline")
+		latest, err := database.MigrationVersion(fresh)
+		require.NoError(t, err)
+		version, err := database.MigrationVersion(db)
+		require.NoError(t, err)
+		assert.Equal(t, latest, version, "and migrated on from there")
+		assert.ElementsMatch(t, tables(t, fresh), tables(t, db))
+
+		var user models.User
+		require.NoError(t, db.DB.First(&user, "id = ?", userID).Error)
+		assert.Equal(t, "legacy@example.com", user.Email)
+		assert.False(t, user.Demo)
+
+		var comment models.Comment
+		require.NoError(t, db.DB.First(&comment, "id = ?", commentID).Error)
+		assert.Equal(t, ticketID, comment.TicketID)
+		assert.Equal(t, models.CommentFormatPlain, comment.Format)
+		var references int64
+		require.NoError(t, db.DB.Raw("SELECT COUNT(*) FROM pragma_foreign_key_list('comments') WHERE \"table\" = 'tickets'").Scan(&references).Error)
+		assert.Zero(t, references, "comments no longer reference the ticket id")
+		assert.NoError(t, db.DB.Exec("PRAGMA foreign_key_check").Error)
+	})
+}
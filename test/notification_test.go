@@ -0,0 +1,181 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	notificationService := services.NewNotificationService(repository.NewNotificationRepository(db))
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, notificationService, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: notificationService.Notifier(emailChannel), Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewNotificationHandler(notificationService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	manager, _, err := authService.Register(&models.RegisterRequest{Email: "manager@example.com", Password: "password123", FirstName: "Maria", LastName: "Lopez", Role: models.RoleManager})
+	require.NoError(t, err)
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	requester, requesterTokens, err := authService.Register(&models.RegisterRequest{Email: "customer@example.com", Password: "password123", FirstName: "Casey", LastName: "Customer", Role: models.RoleEndUser})
+	require.NoError(t, err)
+
+	ticket := &models.Ticket{Title: "Cannot print invoices", Description: "x", Status: models.StatusOpen, Priority: models.PriorityMedium, CreatedByID: requester.User.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	doAs := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	list := func(token, query string) *models.NotificationListResponse {
+		rec := doAs(token, http.MethodGet, "/api/v1/notifications"+query, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var page models.NotificationListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		return &page
+	}
+	sentTo := func(address string) []string {
+		var subjects []string
+		for _, msg := range sender.messages {
+			for _, to := range msg.To {
+				if to == address {
+					subjects = append(subjects, msg.Subject)
+				}
+			}
+		}
+		return subjects
+	}
+
+	t.Run("RecordsTicketEvents", func(t *testing.T) {
+		require.NoError(t, ticketService.AssignTicket(ctx, ticket.ID, agent.User.ID, manager.User.ID))
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "It fails on every printer"}, requester.User)
+		require.NoError(t, err)
+		ticket, err = ticketService.GetTicket(ctx, ticket.ID)
+		require.NoError(t, err)
+		require.NoError(t, ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: models.StatusInProgress}, agent.User.ID))
+
+		page := list(agentTokens.AccessToken, "")
+		require.Len(t, page.Notifications, 2)
+		assert.Equal(t, int64(2), page.Unread)
+		types := []models.NotificationType{page.Notifications[0].Type, page.Notifications[1].Type}
+		assert.ElementsMatch(t, []models.NotificationType{models.NotificationTicketAssigned, models.NotificationTicketCommented}, types)
+		for _, notification := range page.Notifications {
+			assert.Equal(t, ticket.ID, notification.TicketID)
+			if notification.Type == models.NotificationTicketCommented {
+				assert.Equal(t, "Casey Customer commented on \"Cannot print invoices\"", notification.Title)
+				assert.Equal(t, "It fails on every printer", notification.Body)
+			}
+		}
+
+		// The agent changed the status, so only the requester hears about it
+		page = list(requesterTokens.AccessToken, "")
+		require.Len(t, page.Notifications, 1)
+		assert.Equal(t, models.NotificationTicketStatusChanged, page.Notifications[0].Type)
+		assert.Equal(t, &agent.User.ID, page.Notifications[0].ActorID)
+		assert.Contains(t, page.Notifications[0].Title, "from OPEN to IN_PROGRESS")
+		assert.Len(t, sentTo("customer@example.com"), 1)
+		assert.Contains(t, sentTo("customer@example.com")[0], "IN_PROGRESS")
+	})
+
+	t.Run("ReadState", func(t *testing.T) {
+		page := list(agentTokens.AccessToken, "")
+		id := page.Notifications[0].ID.String()
+
+		rec := doAs(agentTokens.AccessToken, http.MethodPut, "/api/v1/notifications/"+id+"/read", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var read models.Notification
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &read))
+		assert.NotNil(t, read.ReadAt)
+		unread := list(agentTokens.AccessToken, "?unread=true")
+		assert.Len(t, unread.Notifications, 1)
+		assert.Equal(t, int64(1), unread.Unread)
+
+		assert.Equal(t, http.StatusOK, doAs(agentTokens.AccessToken, http.MethodDelete, "/api/v1/notifications/"+id+"/read", "").Code)
+		assert.Equal(t, int64(2), list(agentTokens.AccessToken, "").Unread)
+
+		// Other users' notifications are not found
+		assert.Equal(t, http.StatusNotFound, doAs(requesterTokens.AccessToken, http.MethodPut, "/api/v1/notifications/"+id+"/read", "").Code)
+		assert.Equal(t, http.StatusNotFound, doAs(agentTokens.AccessToken, http.MethodPut, "/api/v1/notifications/"+uuid.NewString()+"/read", "").Code)
+
+		rec = doAs(agentTokens.AccessToken, http.MethodPost, "/api/v1/notifications/read-all", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"marked":2}`, rec.Body.String())
+		assert.Zero(t, list(agentTokens.AccessToken, "").Unread)
+	})
+
+	t.Run("Preferences", func(t *testing.T) {
+		rec := doAs(requesterTokens.AccessToken, http.MethodGet, "/api/v1/notifications/preferences", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var preferences models.NotificationPreferencesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preferences))
+		require.Len(t, preferences.Preferences, len(models.NotificationTypes))
+		for _, channels := range preferences.Preferences {
+			assert.True(t, channels.Email && channels.InApp, "every channel is on by default")
+		}
+
+		assert.Equal(t, http.StatusBadRequest, doAs(requesterTokens.AccessToken, http.MethodPut, "/api/v1/notifications/preferences", `{"preferences":[{"type":"ticket.deleted","email":false,"in_app":false}]}`).Code)
+		rec = doAs(requesterTokens.AccessToken, http.MethodPut, "/api/v1/notifications/preferences",
+			`{"preferences":[{"type":"ticket.status_changed","email":false,"in_app":true},{"type":"ticket.commented","email":true,"in_app":false}]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preferences))
+		assert.Contains(t, preferences.Preferences, models.NotificationChannels{Type: models.NotificationTicketStatusChanged, Email: false, InApp: true})
+		assert.Contains(t, preferences.Preferences, models.NotificationChannels{Type: models.NotificationTicketAssigned, Email: true, InApp: true})
+
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "Which printer driver do you use?"}, agent.User)
+		require.NoError(t, err)
+		require.NoError(t, ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: models.StatusResolved}, agent.User.ID))
+
+		page := list(requesterTokens.AccessToken, "")
+		require.Len(t, page.Notifications, 2, "the comment is only emailed")
+		assert.Equal(t, models.NotificationTicketStatusChanged, page.Notifications[0].Type)
+		assert.Contains(t, page.Notifications[0].Title, "to RESOLVED")
+		subjects := sentTo("customer@example.com")
+		require.Len(t, subjects, 1, "the status change is only in the app")
+		assert.Contains(t, subjects[0], "Re:")
+	})
+}
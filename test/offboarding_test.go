@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaffOffboarding(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	outOfOfficeRepo := repository.NewOutOfOfficeRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	teamService := services.NewTeamService(teamRepo, userRepo)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db)})
+	offboardingService := services.NewOffboardingService(repository.NewOffboardingRepository(db), userRepo, teamRepo, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewOffboardingHandler(offboardingService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	admin, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: adminTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Sam", LastName: "Leaver", Role: role, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	departing := newUser("departing@example.com", models.RoleSupportAgent)
+	colleague := newUser("colleague@example.com", models.RoleSupportAgent)
+	requester := newUser("requester@example.com", models.RoleEndUser)
+
+	network, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Network"})
+	require.NoError(t, err)
+	triage, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Triage"})
+	require.NoError(t, err)
+	_, err = teamService.AddMember(ctx, network.ID, departing.ID)
+	require.NoError(t, err)
+
+	newTicket := func(title string) *models.Ticket {
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: title, Description: "x", Priority: models.PriorityMedium, TeamID: &network.ID}, requester.ID)
+		require.NoError(t, err)
+		require.NoError(t, ticketService.AssignTicket(ctx, ticket.ID, departing.ID, admin.User.ID))
+		return ticket
+	}
+	open := newTicket("VPN drops")
+	solved := newTicket("Printer jammed")
+	require.NoError(t, ticketService.UpdateTicketStatus(ctx, solved.ID, &models.UpdateTicketStatusRequest{Status: models.StatusResolved}, departing.ID))
+	require.NoError(t, commentRepo.Create(ctx, &models.Comment{TicketID: open.ID, UserID: departing.ID, Content: "Looking into it"}))
+	require.NoError(t, outOfOfficeRepo.Upsert(ctx, &models.OutOfOffice{UserID: colleague.ID, DelegateID: departing.ID, StartsAt: time.Now(), EndsAt: time.Now().Add(24 * time.Hour)}))
+
+	t.Run("Validation", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/v1/admin/offboarding", `{"user_id":"`+admin.User.ID.String()+`"}`).Code, "admins cannot offboard themselves")
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/api/v1/admin/offboarding", `{"user_id":"`+requester.ID.String()+`"}`).Code, "requesters are left to retention rules")
+		assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/api/v1/admin/offboarding", `{"user_id":"`+departing.ID.String()+`","team_id":"`+requester.ID.String()+`"}`).Code)
+	})
+
+	t.Run("ReleasesTicketsAndAnonymizes", func(t *testing.T) {
+		rec := send(http.MethodPost, "/api/v1/admin/offboarding", `{"user_id":"`+departing.ID.String()+`","team_id":"`+triage.ID.String()+`"}`)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		var job models.OffboardingJob
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, models.OffboardingPending, job.Status)
+
+		assert.Equal(t, http.StatusConflict, send(http.MethodPost, "/api/v1/admin/offboarding", `{"user_id":"`+departing.ID.String()+`"}`).Code, "one job at a time")
+
+		require.NoError(t, offboardingService.RunPending(ctx))
+
+		rec = send(http.MethodGet, "/api/v1/admin/offboarding/"+job.ID.String(), "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, models.OffboardingCompleted, job.Status, job.Error)
+		assert.EqualValues(t, 1, job.TicketsReleased)
+		assert.EqualValues(t, 1, job.CommentsAttributed)
+		assert.NotNil(t, job.CompletedAt)
+
+		released, err := ticketService.GetTicket(ctx, open.ID)
+		require.NoError(t, err)
+		assert.Nil(t, released.AssignedAgentID, "open tickets go back to the queue")
+		if assert.NotNil(t, released.TeamID) {
+			assert.Equal(t, triage.ID, *released.TeamID)
+		}
+		kept, err := ticketService.GetTicket(ctx, solved.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, kept.AssignedAgentID, "resolved tickets keep who solved them")
+
+		comments, err := commentRepo.GetByTicket(ctx, open.ID, true)
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		if assert.NotNil(t, comments[0].User) {
+			assert.Equal(t, "Former employee", comments[0].User.FullName())
+		}
+
+		user, err := userRepo.GetByID(departing.ID.String())
+		require.NoError(t, err)
+		assert.False(t, user.IsActive)
+		assert.NotContains(t, user.Email, "departing")
+		teams, err := teamRepo.GetTeamIDsForUser(ctx, departing.ID)
+		require.NoError(t, err)
+		assert.Empty(t, teams)
+		_, err = outOfOfficeRepo.GetByUser(ctx, colleague.ID)
+		assert.Error(t, err, "nobody delegates to a former employee")
+
+		rec = send(http.MethodGet, "/api/v1/admin/offboarding", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list models.OffboardingJobListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		assert.EqualValues(t, 1, list.Total)
+	})
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUIDParam(t *testing.T) {
+	e := echo.New()
+	echoIDs := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]uuid.UUID{
+			"id":       testMiddleware.ParamUUID(c, "id"),
+			"asset_id": testMiddleware.ParamUUID(c, "asset_id"),
+		})
+	}
+	tickets := e.Group("/tickets")
+	tickets.Use(testMiddleware.UUIDParam("id", "ticket"), testMiddleware.UUIDParam("asset_id", "asset"))
+	tickets.GET("", echoIDs)
+	tickets.GET("/:id", echoIDs)
+	tickets.DELETE("/:id/assets/:asset_id", echoIDs)
+	// Without the middleware the helper parses the parameter itself
+	e.GET("/unchecked/:id", echoIDs)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+	decodeIDs := func(rec *httptest.ResponseRecorder) map[string]uuid.UUID {
+		var ids map[string]uuid.UUID
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ids))
+		return ids
+	}
+	ticketID, assetID := uuid.New(), uuid.New()
+
+	t.Run("ValidParamsAreInjected", func(t *testing.T) {
+		rec := request(http.MethodDelete, "/tickets/"+ticketID.String()+"/assets/"+assetID.String())
+		require.Equal(t, http.StatusOK, rec.Code)
+		ids := decodeIDs(rec)
+		assert.Equal(t, ticketID, ids["id"])
+		assert.Equal(t, assetID, ids["asset_id"])
+	})
+
+	t.Run("RoutesWithoutParamPassThrough", func(t *testing.T) {
+		rec := request(http.MethodGet, "/tickets")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("InvalidParamsRejected", func(t *testing.T) {
+		cases := []struct {
+			method, path, message string
+		}{
+			{http.MethodGet, "/tickets/not-a-uuid", "Invalid ticket ID"},
+			{http.MethodDelete, "/tickets/" + ticketID.String() + "/assets/42", "Invalid asset ID"},
+			{http.MethodDelete, "/tickets/42/assets/" + assetID.String(), "Invalid ticket ID"},
+		}
+		for _, tc := range cases {
+			rec := request(tc.method, tc.path)
+			require.Equal(t, http.StatusBadRequest, rec.Code, tc.path)
+			var resp models.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, []string{tc.message}, resp.Messages, tc.path)
+		}
+	})
+
+	t.Run("FallsBackWithoutMiddleware", func(t *testing.T) {
+		rec := request(http.MethodGet, "/unchecked/"+ticketID.String())
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ticketID, decodeIDs(rec)["id"])
+
+		rec = request(http.MethodGet, "/unchecked/not-a-uuid")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, uuid.Nil, decodeIDs(rec)["id"])
+	})
+}
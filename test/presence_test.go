@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Presence: config.PresenceConfig{
+			TTL: "200ms",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	userRepo := repository.NewUserRepository(db)
+	presence := services.NewPresenceService(cache.NewMemory(), userRepo, cfg)
+
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Ash", LastName: "Agent", Role: models.RoleSupportAgent, IsActive: true}
+	assert.NoError(t, userRepo.Create(agent))
+
+	var cameOnline []uuid.UUID
+	presence.OnOnline(func(userID uuid.UUID) {
+		cameOnline = append(cameOnline, userID)
+	})
+
+	t.Run("HeartbeatsExpire", func(t *testing.T) {
+		presence.Heartbeat(agent.ID)
+		presence.Heartbeat(agent.ID)
+		assert.True(t, presence.IsOnline(agent.ID))
+		assert.Equal(t, []uuid.UUID{agent.ID}, cameOnline, "listeners hear about an agent coming online once")
+
+		time.Sleep(250 * time.Millisecond)
+		assert.False(t, presence.IsOnline(agent.ID), "an agent goes offline when heartbeats stop")
+		agents, err := presence.ListAgents(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, agents, 1) {
+			assert.False(t, agents[0].Online)
+		}
+	})
+
+	t.Run("HeartbeatOverWebSocket", func(t *testing.T) {
+		e := echo.New()
+		e.GET("/presence/ws", handlers.NewPresenceHandler(presence, cfg).Socket, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Set("user", agent)
+				return next(c)
+			}
+		})
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/presence/ws", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Eventually(t, func() bool { return presence.IsOnline(agent.ID) }, time.Second, 10*time.Millisecond)
+
+		// Heartbeats keep the agent online past the TTL
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			assert.NoError(t, conn.WriteJSON(models.PresenceFrame{Type: models.PresenceFrameHeartbeat}))
+		}
+		assert.True(t, presence.IsOnline(agent.ID))
+
+		conn.Close()
+		assert.Eventually(t, func() bool { return !presence.IsOnline(agent.ID) }, time.Second, 10*time.Millisecond, "closing the socket takes the agent offline")
+	})
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemGroupsIncidents(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+	problemService := services.NewProblemService(repository.NewProblemRepository(db), ticketRepo, ticketService)
+
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "User", Role: models.RoleSupportAgent, IsActive: true}
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, userRepo.Create(agent))
+	assert.NoError(t, userRepo.Create(requester))
+
+	newTicket := func(status models.TicketStatus) *models.Ticket {
+		ticket := &models.Ticket{Title: "No email", Description: "Outlook offline", Status: status, Priority: models.PriorityHigh, CreatedByID: requester.ID}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	open := newTicket(models.StatusOpen)
+	inProgress := newTicket(models.StatusInProgress)
+	closed := newTicket(models.StatusClosed)
+	other := newTicket(models.StatusOpen)
+
+	outage, err := problemService.CreateProblem(ctx, &models.CreateProblemRequest{Title: "Mail server outage"}, agent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ProblemOpen, outage.Status)
+	printers, err := problemService.CreateProblem(ctx, &models.CreateProblemRequest{Title: "Printer driver"}, agent.ID)
+	assert.NoError(t, err)
+
+	for _, ticket := range []*models.Ticket{open, inProgress, closed} {
+		_, err := problemService.LinkIncident(ctx, outage.ID, ticket.ID)
+		assert.NoError(t, err)
+	}
+	_, err = problemService.LinkIncident(ctx, printers.ID, other.ID)
+	assert.NoError(t, err)
+
+	t.Run("TicketMovesBetweenProblems", func(t *testing.T) {
+		problem, err := problemService.LinkIncident(ctx, printers.ID, open.ID)
+		assert.NoError(t, err)
+		assert.Len(t, problem.Incidents, 2)
+
+		problem, err = problemService.LinkIncident(ctx, outage.ID, open.ID)
+		assert.NoError(t, err)
+		assert.Len(t, problem.Incidents, 3)
+	})
+
+	t.Run("TopProblemsByIncidentCount", func(t *testing.T) {
+		top, err := problemService.TopProblems(ctx, 0)
+		assert.NoError(t, err)
+		if assert.Len(t, top, 2) {
+			assert.Equal(t, outage.ID, top[0].ProblemID)
+			assert.EqualValues(t, 3, top[0].IncidentCount)
+			assert.EqualValues(t, 1, top[1].IncidentCount)
+		}
+	})
+
+	t.Run("CloseResolvesOpenIncidents", func(t *testing.T) {
+		result, err := problemService.CloseProblem(ctx, outage.ID, &models.CloseProblemRequest{Resolution: "Mail server patched", ResolveIncidents: true}, agent)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ProblemClosed, result.Problem.Status)
+		assert.NotNil(t, result.Problem.ClosedAt)
+		assert.Equal(t, 2, result.ResolvedIncidents)
+
+		for _, ticket := range []*models.Ticket{open, inProgress} {
+			current, err := ticketRepo.GetByID(ctx, ticket.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, models.StatusResolved, current.Status)
+
+			comments, err := commentRepo.GetByTicket(ctx, ticket.ID, false)
+			assert.NoError(t, err)
+			if assert.Len(t, comments, 1) {
+				assert.Contains(t, comments[0].Content, "Mail server patched")
+			}
+		}
+		current, err := ticketRepo.GetByID(ctx, closed.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.StatusClosed, current.Status)
+	})
+
+	t.Run("ClosedProblemIsFrozen", func(t *testing.T) {
+		_, err := problemService.CloseProblem(ctx, outage.ID, &models.CloseProblemRequest{Resolution: "again"}, agent)
+		assert.ErrorIs(t, err, services.ErrConflict)
+		_, err = problemService.LinkIncident(ctx, outage.ID, other.ID)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("CloseWithoutResolvingIncidents", func(t *testing.T) {
+		result, err := problemService.CloseProblem(ctx, printers.ID, &models.CloseProblemRequest{Resolution: "Driver rolled back"}, agent)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.ResolvedIncidents)
+
+		current, err := ticketRepo.GetByID(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.StatusOpen, current.Status)
+	})
+}
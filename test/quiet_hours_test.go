@@ -0,0 +1,152 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHours(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email: config.EmailConfig{
+			FromAddress:            "support@helpchat.com",
+			BreakthroughPriorities: []string{"critical"},
+			BreakthroughOverdue:    true,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	quietHours := services.NewQuietHoursService(repository.NewNotificationQueueRepository(db), sender, cfg)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, quietHours, nil, cfg)
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewUserHandler(services.NewUserService(userRepo), nil, nil, nil).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	requester, requesterTokens, err := authService.Register(&models.RegisterRequest{Email: "requester@example.com", Password: "password123", FirstName: "Alex", LastName: "Morgan", Role: models.RoleEndUser})
+	require.NoError(t, err)
+	agent, _, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	setQuietHours := func(req models.QuietHoursRequest) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPut, "/api/v1/users/me/quiet-hours", bytes.NewReader(data))
+		httpReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		httpReq.AddCookie(&http.Cookie{Name: "token", Value: requesterTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httpReq)
+		return rec
+	}
+	notify := func(ticket *models.Ticket) {
+		sender.messages = nil
+		require.NoError(t, emailChannel.NotifyCommentAdded(ctx, ticket, &models.Comment{ID: uuid.New(), Content: "Please restart"}, agent.User))
+	}
+	newTicket := func(priority models.TicketPriority, due *time.Time) *models.Ticket {
+		ticket := &models.Ticket{Title: "VPN down", Description: "Help", Status: models.StatusOpen, Priority: priority, DueDate: due, CreatedByID: requester.User.ID, AssignedAgentID: &agent.User.ID}
+		require.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+
+	// Quiet hours from an hour ago to an hour from now in the user's time zone
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	now := time.Now().In(kolkata)
+	quiet := models.QuietHoursRequest{Timezone: "Asia/Kolkata", Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")}
+
+	t.Run("RejectsInvalidQuietHours", func(t *testing.T) {
+		for name, req := range map[string]models.QuietHoursRequest{
+			"unknown time zone": {Timezone: "Mars/Olympus", Start: "22:00", End: "07:00"},
+			"missing end":       {Start: "22:00"},
+			"invalid time":      {Start: "25:00", End: "07:00"},
+			"empty period":      {Start: "22:00", End: "22:00"},
+		} {
+			assert.Equal(t, http.StatusBadRequest, setQuietHours(req).Code, name)
+		}
+	})
+
+	t.Run("HoldsNotificationsUntilQuietHoursEnd", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setQuietHours(quiet).Code)
+		ticket := newTicket(models.PriorityMedium, nil)
+
+		notify(ticket)
+		assert.Empty(t, sender.messages)
+
+		sent, err := quietHours.DeliverDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		assert.Empty(t, sender.messages)
+
+		sent, err = quietHours.DeliverDue(ctx, time.Now().Add(61*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{"requester@example.com"}, sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Text, "Please restart")
+		assert.NotEmpty(t, sender.messages[0].Headers["In-Reply-To"])
+
+		// Delivered notifications leave the queue
+		sent, err = quietHours.DeliverDue(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+	})
+
+	t.Run("UrgentTicketsBreakThrough", func(t *testing.T) {
+		notify(newTicket(models.PriorityCritical, nil))
+		assert.Len(t, sender.messages, 1)
+
+		overdue := time.Now().Add(-time.Hour)
+		notify(newTicket(models.PriorityLow, &overdue))
+		assert.Len(t, sender.messages, 1)
+	})
+
+	t.Run("SpansMidnight", func(t *testing.T) {
+		user := &models.User{Timezone: "UTC", QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+		until, isQuiet := services.QuietUntil(user, time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC))
+		assert.True(t, isQuiet)
+		assert.Equal(t, time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC), until)
+		until, isQuiet = services.QuietUntil(user, time.Date(2026, 3, 2, 6, 59, 0, 0, time.UTC))
+		assert.True(t, isQuiet)
+		assert.Equal(t, time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC), until)
+		_, isQuiet = services.QuietUntil(user, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+		assert.False(t, isQuiet)
+	})
+
+	t.Run("TurnedOff", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setQuietHours(models.QuietHoursRequest{}).Code)
+		notify(newTicket(models.PriorityMedium, nil))
+		assert.Len(t, sender.messages, 1)
+	})
+}
@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePermissions(t *testing.T) {
+	assert.True(t, models.RoleViewer.HasPermission(models.PermTicketRead))
+	assert.True(t, models.RoleViewer.HasPermission(models.PermReportRead))
+	assert.False(t, models.RoleViewer.HasPermission(models.PermTicketCreate))
+	assert.False(t, models.RoleViewer.HasPermission(models.PermTicketUpdate))
+	assert.False(t, models.RoleViewer.HasPermission(models.PermCommentCreate))
+
+	assert.True(t, models.RoleEndUser.HasPermission(models.PermTicketReadOwn))
+	assert.False(t, models.RoleEndUser.HasPermission(models.PermTicketRead))
+	assert.True(t, models.RoleAdministrator.HasPermission(models.PermSystemAdmin))
+	assert.False(t, models.UserRole("ROOT").IsValid())
+}
+
+func TestViewerRoutes(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	signIn := func(email string, role models.UserRole) (*models.User, string) {
+		auth, tokens, err := authService.Register(&models.RegisterRequest{Email: email, Password: "password123", FirstName: "Test", LastName: "User", Role: role})
+		assert.NoError(t, err)
+		return auth.User, tokens.AccessToken
+	}
+	_, viewerToken := signIn("viewer@example.com", models.RoleViewer)
+	requester, requesterToken := signIn("requester@example.com", models.RoleEndUser)
+	_, otherToken := signIn("other@example.com", models.RoleEndUser)
+
+	ticket := &models.Ticket{Title: "Printer", Description: "Jammed", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+	ticketPath := "/api/v1/tickets/" + ticket.ID.String()
+
+	request := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("ViewerCanBrowse", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/tickets", viewerToken, ""))
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, ticketPath, viewerToken, ""))
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, ticketPath+"/comments", viewerToken, ""))
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v1/tickets/stats", viewerToken, ""))
+	})
+
+	t.Run("ViewerCannotModify", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/api/v1/tickets", viewerToken, `{"title":"New","description":"x","priority":"LOW"}`))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPut, ticketPath, viewerToken, `{"title":"Changed"}`))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, ticketPath+"/comments", viewerToken, `{"content":"hi"}`))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, ticketPath+"/status", viewerToken, `{"status":"CLOSED"}`))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, ticketPath, viewerToken, ""))
+	})
+
+	t.Run("InternalNotesStayWithStaff", func(t *testing.T) {
+		agent, agentToken := signIn("agent@example.com", models.RoleSupportAgent)
+		note := &models.Comment{TicketID: ticket.ID, UserID: agent.ID, Content: "SECRET", IsInternal: true}
+		assert.NoError(t, repository.NewCommentRepository(db).Create(ctx, note))
+		assert.NoError(t, repository.NewCommentRepository(db).Create(ctx, &models.Comment{TicketID: ticket.ID, UserID: agent.ID, Content: "On its way"}))
+		assert.NoError(t, repository.NewAttachmentRepository(db).Create(ctx, &models.Attachment{TicketID: ticket.ID, CommentID: &note.ID, Filename: "secret.log", FilePath: "x", FileSize: 1, MimeType: "text/plain", UploadedByID: agent.ID}))
+
+		body := func(token string) string {
+			req := httptest.NewRequest(http.MethodGet, ticketPath, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			return rec.Body.String()
+		}
+		for _, token := range []string{requesterToken, viewerToken} {
+			ticketJSON := body(token)
+			assert.Contains(t, ticketJSON, "On its way")
+			assert.NotContains(t, ticketJSON, "SECRET")
+			assert.NotContains(t, ticketJSON, "secret.log")
+		}
+		assert.Contains(t, body(agentToken), "SECRET")
+		assert.Contains(t, body(agentToken), "secret.log")
+	})
+
+	t.Run("EndUsersSeeOnlyTheirOwnTickets", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(http.MethodGet, ticketPath, requesterToken, ""))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, ticketPath, otherToken, ""))
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/v1/tickets", requesterToken, ""))
+	})
+}
+
+func TestAuthorizationRequirements(t *testing.T) {
+	ami := testMiddleware.NewAuthMiddleware(nil, nil)
+	owner := &models.User{ID: uuid.New(), Role: models.RoleEndUser}
+	ownerLookups := 0
+	ownerOf := func(c echo.Context) (string, error) {
+		ownerLookups++
+		if c.QueryParam("owner") == "unknown" {
+			return "", errors.New("ticket not found")
+		}
+		return owner.ID.String(), nil
+	}
+
+	e := echo.New()
+	signIn := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user, ok := map[string]*models.User{
+				"owner":   owner,
+				"other":   {ID: uuid.New(), Role: models.RoleEndUser},
+				"viewer":  {ID: uuid.New(), Role: models.RoleViewer},
+				"manager": {ID: uuid.New(), Role: models.RoleManager},
+			}[c.QueryParam("as")]; ok {
+				c.Set("user", user)
+			}
+			return next(c)
+		}
+	}
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/unauthenticated", ok, ami.RequireAgent())
+	e.GET("/own", ok, signIn, ami.RequirePermissionOrOwner(models.PermTicketRead, ownerOf))
+	e.GET("/owner-or-admin", ok, signIn, ami.RequireOwnerOrAdmin(ownerOf))
+	e.GET("/composed", ok, signIn, ami.Require(testMiddleware.Roles(models.RoleViewer, models.RoleManager), testMiddleware.Permissions(models.PermUserManage)))
+
+	request := func(path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	t.Run("MissingUserIsUnauthorized", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("/unauthenticated"))
+		assert.Equal(t, http.StatusUnauthorized, request("/own"))
+	})
+
+	t.Run("PermissionOrOwner", func(t *testing.T) {
+		ownerLookups = 0
+		assert.Equal(t, http.StatusOK, request("/own?as=viewer"))
+		assert.Equal(t, 0, ownerLookups, "the permission alone is enough")
+		assert.Equal(t, http.StatusOK, request("/own?as=owner"))
+		assert.Equal(t, http.StatusForbidden, request("/own?as=other"))
+		assert.Equal(t, http.StatusBadRequest, request("/own?as=owner&owner=unknown"))
+	})
+
+	t.Run("OwnerOrAdmin", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/owner-or-admin?as=manager&owner=unknown"))
+		assert.Equal(t, http.StatusOK, request("/owner-or-admin?as=owner"))
+		assert.Equal(t, http.StatusForbidden, request("/owner-or-admin?as=viewer"))
+	})
+
+	t.Run("EveryRequirementMustHold", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("/composed?as=manager"))
+		assert.Equal(t, http.StatusForbidden, request("/composed?as=viewer"), "viewers lack user:manage")
+		assert.Equal(t, http.StatusForbidden, request("/composed?as=owner"))
+	})
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/redact"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	dictionary, ok := redact.DictionaryRule([]string{"darn"})
+	assert.True(t, ok)
+	redactor := redact.New(redact.CreditCardRule(), redact.SSNRule(), redact.PhoneRule(), dictionary)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		rules    []string
+	}{
+		{"SSN", "My SSN is 123-45-6789.", "My SSN is [REDACTED SSN].", []string{redact.RuleSSN}},
+		{"InvalidSSNKept", "Order 900-12-3456 shipped", "Order 900-12-3456 shipped", nil},
+		{"CreditCard", "Card 4111 1111 1111 1111 please", "Card [REDACTED CARD] please", []string{redact.RuleCreditCard}},
+		{"NonLuhnNumberKept", "Invoice 1234567890123", "Invoice 1234567890123", nil},
+		{"Phone", "Call (555) 123-4567 or +1 555.987.6543", "Call [REDACTED PHONE] or [REDACTED PHONE]", []string{redact.RulePhone}},
+		{"Dictionary", "This darn printer, DARN it", "This **** printer, **** it", []string{redact.RuleDictionary}},
+		{"DictionaryWholeWordsOnly", "Darnell called", "Darnell called", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, matches := redactor.Redact(tt.input)
+			assert.Equal(t, tt.expected, output)
+
+			var rules []string
+			for _, match := range matches {
+				rules = append(rules, match.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestCommentRedactionIsAudited(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Redaction: config.RedactionConfig{
+			Enabled: true,
+			Rules:   []string{"credit_card", "ssn", "phone"},
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	auditService := services.NewAuditService(repository.NewAuditLogRepository(db), nil)
+	redaction, err := services.NewRedactionService(cfg, auditService)
+	assert.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+
+	requester := &models.User{Email: "patient@example.com", PasswordHash: "x", FirstName: "Pat", LastName: "Ient", Role: models.RoleEndUser}
+	assert.NoError(t, userRepo.Create(requester))
+	ticket := &models.Ticket{Title: "Billing", Description: "Help", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	comment, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "SSN 123-45-6789, card 4111-1111-1111-1111"}, requester)
+	assert.NoError(t, err)
+	assert.Equal(t, "SSN [REDACTED SSN], card [REDACTED CARD]", comment.Content)
+
+	entries, err := auditService.List(ctx, &models.AuditLogQuery{Action: models.AuditActionRedaction, EntityID: &comment.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, entries.Entries, 1) {
+		assert.Equal(t, requester.ID, *entries.Entries[0].ActorID)
+		assert.Contains(t, entries.Entries[0].Details, `"rule":"ssn"`)
+		assert.NotContains(t, entries.Entries[0].Details, "6789", "audit must not contain the redacted value")
+	}
+
+	t.Run("InvalidRuleRejected", func(t *testing.T) {
+		_, err := services.NewRedactionService(&config.Config{Redaction: config.RedactionConfig{Enabled: true, Rules: []string{"dob"}}}, auditService)
+		assert.Error(t, err)
+	})
+}
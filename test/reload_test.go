@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "helpchat.env")
+	policyFile := filepath.Join(dir, "rbac.json")
+	require.NoError(t, os.WriteFile(configFile, []byte("RATE_LIMIT_USER_DAILY_QUOTA=1000\n"), 0o600))
+	t.Cleanup(func() {
+		config.LoadFile("")
+		models.SetRolePermissions(nil)
+	})
+
+	cfg, err := config.LoadFile(configFile)
+	require.NoError(t, err)
+	cfg.Database = config.DatabaseConfig{FilePath: ":memory:"}
+	cfg.JWT = config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"}
+	assert.Equal(t, int64(1000), cfg.RateLimit.UserDailyQuota)
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	auditRepo := repository.NewAuditLogRepository(db)
+	authService := services.NewAuthService(repository.NewUserRepository(db), cfg)
+	usageService := services.NewUsageService(cfg)
+	reloadService := services.NewReloadService(services.NewAuditService(auditRepo, nil), cfg)
+	reloadService.Register("daily_quota", usageService)
+	reloadService.Register("spam_filter", services.NewSpamFilter(cfg))
+
+	e := echo.New()
+	handlers.NewReloadHandler(reloadService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	admin, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+	_, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	reload := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/reload", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("AdminsOnly", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, reload(agentTokens.AccessToken).Code)
+	})
+
+	t.Run("AppliesPolicyAndSettings", func(t *testing.T) {
+		require.True(t, models.RoleViewer.HasPermission(models.PermReportRead))
+		require.NoError(t, os.WriteFile(policyFile, []byte(`{"VIEWER": ["ticket:read", "ticket:read:all"]}`), 0o600))
+		require.NoError(t, os.WriteFile(configFile, []byte("# tightened for the quarter\nRATE_LIMIT_USER_DAILY_QUOTA=2\nRBAC_POLICY_FILE="+policyFile+"\n"), 0o600))
+
+		rec := reload(adminTokens.AccessToken)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result models.ReloadResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, []string{"permissions", "daily_quota", "spam_filter"}, result.Reloaded)
+		assert.Empty(t, result.Failed)
+
+		assert.False(t, models.RoleViewer.HasPermission(models.PermReportRead))
+		assert.True(t, models.RoleViewer.HasPermission(models.PermTicketReadAll))
+		assert.True(t, models.RoleSupportAgent.HasPermission(models.PermTicketAssign), "roles left out keep their defaults")
+		if limit := usageService.Usage(admin.User.ID).Limit; assert.NotNil(t, limit) {
+			assert.Equal(t, int64(2), *limit)
+		}
+
+		entries, err := auditRepo.List(context.Background(), &models.AuditLogQuery{Action: models.AuditActionConfigReload, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), entries.Total)
+	})
+
+	t.Run("InvalidPolicyKeepsCurrent", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(policyFile, []byte(`{"ADMINISTRATOR": ["ticket:read"]}`), 0o600))
+
+		rec := reload(adminTokens.AccessToken)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var result models.ReloadResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Contains(t, result.Failed["permissions"], "must keep system:admin")
+		assert.Equal(t, []string{"daily_quota", "spam_filter"}, result.Reloaded)
+		assert.True(t, models.RoleAdministrator.HasPermission(models.PermSystemAdmin))
+		assert.False(t, models.RoleViewer.HasPermission(models.PermReportRead), "the last good policy stays in force")
+	})
+
+	t.Run("UnreadableConfigFile", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configFile, []byte("not a setting\n"), 0o600))
+		rec := reload(adminTokens.AccessToken)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "line 1")
+	})
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplica(t *testing.T) {
+	ctx := context.Background()
+	replicaPath := filepath.Join(t.TempDir(), "replica.db")
+
+	// Build the replica from a database holding one open ticket
+	source, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:"}})
+	assert.NoError(t, err)
+	assert.NoError(t, database.RunMigrations(source))
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, repository.NewUserRepository(source).Create(requester))
+	assert.NoError(t, repository.NewTicketRepository(source).Create(ctx, &models.Ticket{Title: "Replicated", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}))
+	assert.NoError(t, source.DB.Exec("VACUUM INTO ?", replicaPath).Error)
+	source.Close()
+
+	t.Run("ReportsReadTheReplica", func(t *testing.T) {
+		db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:", ReplicaFilePath: replicaPath}})
+		assert.NoError(t, err)
+		defer db.Close()
+		assert.NoError(t, database.RunMigrations(db))
+
+		assert.NotSame(t, db.DB, db.Reader())
+		now := time.Now()
+		snapshot, err := repository.NewReportRepository(db).QueueSnapshot(ctx, now, now, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), snapshot.QueueDepth, "the primary is empty")
+
+		stats, err := repository.NewTicketRepository(db).GetStats(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), stats.OpenTickets)
+
+		err = db.Reader().Exec("DELETE FROM tickets").Error
+		assert.Error(t, err, "the replica is read-only")
+	})
+
+	t.Run("FallsBackToPrimary", func(t *testing.T) {
+		db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:", ReplicaFilePath: filepath.Join(t.TempDir(), "missing.db")}})
+		assert.NoError(t, err)
+		defer db.Close()
+		assert.NoError(t, database.RunMigrations(db))
+
+		assert.Same(t, db.DB, db.Reader(), "an empty replica is not used")
+		now := time.Now()
+		snapshot, err := repository.NewReportRepository(db).QueueSnapshot(ctx, now, now, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), snapshot.QueueDepth)
+	})
+}
@@ -0,0 +1,415 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReports(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Widget: config.WidgetConfig{
+			Timezone: "UTC",
+		},
+		Reports: config.ReportsConfig{
+			StreamInterval: "50ms",
+			SLARiskWindow:  "1h",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	categoryRepo := repository.NewCategoryRepository(db)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, repository.NewCommentRepository(db), userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+	chatRepo := repository.NewChatRepository(db)
+	appCache := cache.NewMemory()
+	presence := services.NewPresenceService(appCache, userRepo, cfg)
+	reportService := services.NewReportService(repository.NewReportRepository(db), categoryRepo, chatRepo, presence, appCache, cfg)
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "One", Role: models.RoleSupportAgent, IsActive: true}
+	viewer := &models.User{Email: "viewer@example.com", PasswordHash: "x", FirstName: "Wall", LastName: "Board", Role: models.RoleViewer, IsActive: true}
+	manager := &models.User{Email: "manager@example.com", PasswordHash: "x", FirstName: "Team", LastName: "Lead", Role: models.RoleManager, IsActive: true}
+	for _, user := range []*models.User{requester, agent, viewer, manager} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+	team := &models.Team{Name: "Network"}
+	assert.NoError(t, teamRepo.Create(ctx, team))
+	assert.NoError(t, teamRepo.AddMember(ctx, team.ID, manager.ID))
+
+	now := time.Now()
+	soon, overdue, later := now.Add(30*time.Minute), now.Add(-time.Hour), now.Add(48*time.Hour)
+	yesterday := now.AddDate(0, 0, -1)
+	create := func(status models.TicketStatus, assignee *models.User, due, resolvedAt *time.Time, teamID *models.Team) {
+		ticket := &models.Ticket{Title: "Ticket", Description: "x", Status: status, Priority: models.PriorityMedium, CreatedByID: requester.ID, DueDate: due, ResolvedAt: resolvedAt}
+		if assignee != nil {
+			ticket.AssignedAgentID = &assignee.ID
+		}
+		if teamID != nil {
+			ticket.TeamID = &teamID.ID
+		}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+	}
+	create(models.StatusOpen, nil, &soon, nil, team)
+	create(models.StatusOpen, agent, &overdue, nil, nil)
+	create(models.StatusInProgress, agent, &later, nil, nil)
+	create(models.StatusResolved, agent, &overdue, &now, team)
+	create(models.StatusClosed, agent, nil, &yesterday, nil)
+
+	t.Run("QueueSnapshot", func(t *testing.T) {
+		snapshot, err := reportService.QueueSnapshot(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), snapshot.QueueDepth)
+		assert.Equal(t, int64(1), snapshot.Unassigned)
+		assert.Equal(t, int64(1), snapshot.SLAAtRisk)
+		assert.Equal(t, int64(1), snapshot.SLABreached, "resolved tickets are not breaches")
+		assert.Equal(t, int64(1), snapshot.ResolvedToday)
+
+		scoped, err := ticketService.ScopeTickets(ctx, manager)
+		assert.NoError(t, err)
+		snapshot, err = reportService.QueueSnapshot(scoped)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), snapshot.QueueDepth, "managers see their teams' queue")
+		assert.Equal(t, int64(1), snapshot.ResolvedToday)
+	})
+
+	t.Run("Dashboard", func(t *testing.T) {
+		presence.Connect(agent.ID)
+		defer presence.Disconnect(agent.ID)
+		for _, status := range []models.ChatStatus{models.ChatActive, models.ChatActive, models.ChatQueued, models.ChatEnded} {
+			assert.NoError(t, chatRepo.Create(ctx, &models.ChatSession{Status: status}))
+		}
+		assert.NoError(t, chatRepo.AddQueueEvent(ctx, &models.ChatQueueEvent{SessionID: agent.ID, Type: models.QueueEventAccepted, WaitSeconds: 30}))
+		assert.NoError(t, chatRepo.AddQueueEvent(ctx, &models.ChatQueueEvent{SessionID: agent.ID, Type: models.QueueEventAccepted, WaitSeconds: 90}))
+
+		dashboard, err := reportService.Dashboard(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), dashboard.OpenByPriority[models.PriorityMedium])
+		assert.Equal(t, int64(0), dashboard.OpenByPriority[models.PriorityCritical])
+		assert.Equal(t, int64(1), dashboard.Unassigned)
+		assert.Equal(t, int64(5), dashboard.CreatedToday)
+		assert.Equal(t, int64(1), dashboard.ResolvedToday)
+		if assert.NotNil(t, dashboard.AverageWaitSeconds) {
+			assert.Equal(t, 60.0, *dashboard.AverageWaitSeconds)
+		}
+		assert.Equal(t, int64(1), dashboard.OnlineAgents)
+		assert.Equal(t, int64(2), dashboard.ActiveChats)
+		assert.Equal(t, int64(1), dashboard.QueuedChats)
+
+		scoped, err := ticketService.ScopeTickets(ctx, manager)
+		assert.NoError(t, err)
+		dashboard, err = reportService.Dashboard(scoped)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), dashboard.OpenByPriority[models.PriorityMedium], "managers see their teams' tickets")
+		assert.Equal(t, int64(2), dashboard.CreatedToday)
+
+		// Editing an old ticket writes a new version; it was still not created today
+		old := &models.Ticket{Title: "Old", Description: "x", Status: models.StatusClosed, Priority: models.PriorityLow, CreatedByID: requester.ID, CreationTime: now.AddDate(0, 0, -3)}
+		assert.NoError(t, ticketRepo.Create(ctx, old))
+		old.Title = "Old, edited"
+		assert.NoError(t, ticketRepo.Update(ctx, old))
+		uncached := services.NewReportService(repository.NewReportRepository(db), categoryRepo, chatRepo, presence, cache.NewMemory(), cfg)
+		dashboard, err = uncached.Dashboard(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), dashboard.CreatedToday)
+	})
+
+	t.Run("QueueStream", func(t *testing.T) {
+		e := echo.New()
+		e.GET("/reports/queue/stream", handlers.NewReportHandler(reportService, ticketService).StreamQueue, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Set("user", viewer)
+				return next(c)
+			}
+		})
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/reports/queue/stream")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer res.Body.Close()
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		events := make(chan models.QueueSnapshot)
+		go func() {
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					var snapshot models.QueueSnapshot
+					if json.Unmarshal([]byte(data), &snapshot) == nil {
+						events <- snapshot
+					}
+				}
+			}
+			close(events)
+		}()
+		next := func() models.QueueSnapshot {
+			select {
+			case snapshot := <-events:
+				return snapshot
+			case <-time.After(2 * time.Second):
+				t.Fatal("no queue event")
+				return models.QueueSnapshot{}
+			}
+		}
+
+		assert.Equal(t, int64(3), next().QueueDepth)
+
+		// A new ticket is pushed once the shared snapshot refreshes
+		create(models.StatusOpen, nil, nil, nil, nil)
+		snapshot := next()
+		assert.Equal(t, int64(4), snapshot.QueueDepth)
+		assert.Equal(t, int64(2), snapshot.Unassigned)
+	})
+
+	t.Run("Trends", func(t *testing.T) {
+		at := func(day, hour int) time.Time { return time.Date(2026, time.March, day, hour, 0, 0, 0, time.UTC) }
+		resolved := at(3, 12)
+		tokyo := time.FixedZone("JST", 9*60*60)
+		tickets := []*models.Ticket{
+			{CreationTime: at(1, 10), Status: models.StatusOpen},
+			{CreationTime: at(2, 9), Status: models.StatusResolved, ResolvedAt: &resolved},
+			{CreationTime: at(2, 23), Status: models.StatusInProgress},
+			{CreationTime: at(4, 8), Status: models.StatusOpen},
+			// Stored with its own offset; it is still the 4th in UTC
+			{CreationTime: time.Date(2026, time.March, 5, 1, 0, 0, 0, tokyo), Status: models.StatusOpen},
+		}
+		for _, ticket := range tickets {
+			ticket.Title, ticket.Description, ticket.Priority, ticket.CreatedByID = "Old ticket", "x", models.PriorityLow, requester.ID
+			assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		}
+		// Editing a ticket writes a new version; it still counts as created on the 2nd
+		tickets[1].Title = "Old ticket, edited"
+		assert.NoError(t, ticketRepo.Update(ctx, tickets[1]))
+		values := func(series *models.TrendSeries) []int64 {
+			values := make([]int64, len(series.Points))
+			for i, point := range series.Points {
+				values[i] = point.Value
+			}
+			return values
+		}
+
+		for metric, want := range map[models.TrendMetric][]int64{
+			models.TrendCreated:  {2, 0, 2},
+			models.TrendResolved: {0, 1, 0},
+			models.TrendBacklog:  {3, 2, 4},
+		} {
+			series, err := reportService.Trends(ctx, models.TrendQuery{Metric: metric, From: "2026-03-02", To: "2026-03-04"})
+			assert.NoError(t, err)
+			assert.Equal(t, want, values(series), metric)
+			assert.True(t, series.Points[0].Start.Equal(at(2, 0)))
+		}
+
+		series, err := reportService.Trends(ctx, models.TrendQuery{Metric: models.TrendCreated, Interval: models.TrendWeek, From: "2026-02-25", To: "2026-03-04"})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 4}, values(series), "weeks start on Monday")
+		assert.True(t, series.Points[1].Start.Equal(at(2, 0)))
+
+		_, err = reportService.Trends(ctx, models.TrendQuery{Metric: "reopened"})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		_, err = reportService.Trends(ctx, models.TrendQuery{Metric: models.TrendCreated, From: "2026-03-04", To: "2026-03-02"})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		hardware := &models.Category{Name: "Hardware", IsActive: true}
+		assert.NoError(t, categoryRepo.Create(ctx, hardware))
+		laptops := &models.Category{Name: "Laptops", ParentID: &hardware.ID, IsActive: true}
+		assert.NoError(t, categoryRepo.Create(ctx, laptops))
+		printers := &models.Category{Name: "Printers", ParentID: &hardware.ID, IsActive: true}
+		assert.NoError(t, categoryRepo.Create(ctx, printers))
+
+		at := func(day, hour int) time.Time { return time.Date(2026, time.April, day, hour, 0, 0, 0, time.UTC) }
+		fast, slow := at(1, 11), at(2, 15)
+		tickets := []*models.Ticket{
+			{CreationTime: at(1, 9), CategoryID: &laptops.ID, Status: models.StatusResolved, ResolvedAt: &fast},
+			{CreationTime: at(1, 9), CategoryID: &laptops.ID, Status: models.StatusClosed, ResolvedAt: &slow},
+			{CreationTime: at(2, 9), CategoryID: &printers.ID, Status: models.StatusOpen},
+			{CreationTime: at(2, 9), CategoryID: &hardware.ID, Status: models.StatusOpen},
+			{CreationTime: at(3, 9), Status: models.StatusOpen},
+			{CreationTime: at(9, 9), CategoryID: &printers.ID, Status: models.StatusOpen},
+		}
+		for _, ticket := range tickets {
+			ticket.Title, ticket.Description, ticket.Priority, ticket.CreatedByID = "Category ticket", "x", models.PriorityLow, requester.ID
+			assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		}
+		// Editing a ticket writes a new version, which changes neither when it
+		// was created nor how long it took to resolve
+		tickets[1].Title = "Category ticket, edited"
+		assert.NoError(t, ticketRepo.Update(ctx, tickets[1]))
+
+		report, err := reportService.CategoryReport(ctx, "2026-04-01", "2026-04-07")
+		assert.NoError(t, err)
+		rows := make(map[string]models.CategoryBreakdown)
+		var names []string
+		for _, row := range report.Categories {
+			rows[row.Name] = row
+			names = append(names, row.Name)
+		}
+		assert.Equal(t, []string{"Hardware", "Laptops", "Printers", "Uncategorized"}, names, "subcategories follow their parent")
+
+		assert.Equal(t, 1, rows["Laptops"].Depth)
+		assert.Equal(t, int64(2), rows["Laptops"].Own.Resolved)
+		if assert.NotNil(t, rows["Laptops"].Own.AverageResolutionHours) {
+			assert.InDelta(t, 16.0, *rows["Laptops"].Own.AverageResolutionHours, 0.01)
+		}
+		assert.Equal(t, int64(1), rows["Printers"].Own.Created, "tickets outside the range are not counted")
+		assert.Nil(t, rows["Printers"].Own.AverageResolutionHours)
+
+		hardwareRow := rows["Hardware"]
+		assert.Equal(t, int64(1), hardwareRow.Own.Created)
+		assert.Equal(t, int64(4), hardwareRow.Total.Created, "parents roll up their subcategories")
+		assert.Equal(t, int64(2), hardwareRow.Total.Resolved)
+		if assert.NotNil(t, hardwareRow.Total.AverageResolutionHours) {
+			assert.InDelta(t, 16.0, *hardwareRow.Total.AverageResolutionHours, 0.01)
+		}
+		assert.Nil(t, rows["Uncategorized"].CategoryID)
+		assert.Equal(t, int64(1), rows["Uncategorized"].Total.Created)
+	})
+
+	t.Run("Aging", func(t *testing.T) {
+		aged := func(priority models.TicketPriority, age time.Duration) *models.Ticket {
+			ticket := &models.Ticket{Title: "Aged", Description: "x", Status: models.StatusOpen, Priority: priority, CreatedByID: requester.ID, TeamID: &team.ID, CreationTime: time.Now().Add(-age)}
+			assert.NoError(t, ticketRepo.Create(ctx, ticket))
+			return ticket
+		}
+		aged(models.PriorityHigh, 2*24*time.Hour)
+		aged(models.PriorityHigh, 4*24*time.Hour)
+		oldest := aged(models.PriorityCritical, 10*24*time.Hour)
+		// Editing a ticket writes a new version, which does not make it younger
+		oldest.Title = "Aged and edited"
+		assert.NoError(t, ticketRepo.Update(ctx, oldest))
+
+		scoped, err := ticketService.ScopeTickets(ctx, manager)
+		assert.NoError(t, err)
+		report, err := reportService.AgingReport(scoped)
+		assert.NoError(t, err)
+		assert.Equal(t, models.AgingCounts{UnderOneDay: 1, OneToThreeDays: 1, ThreeToSevenDays: 1, OverSevenDays: 1, Total: 4}, report.Total)
+
+		var priorities []models.TicketPriority
+		for _, row := range report.ByPriority {
+			priorities = append(priorities, row.Priority)
+		}
+		assert.Equal(t, []models.TicketPriority{models.PriorityCritical, models.PriorityHigh, models.PriorityMedium, models.PriorityLow}, priorities)
+		assert.Equal(t, models.AgingCounts{OverSevenDays: 1, Total: 1}, report.ByPriority[0].AgingCounts)
+		assert.Equal(t, models.AgingCounts{OneToThreeDays: 1, ThreeToSevenDays: 1, Total: 2}, report.ByPriority[1].AgingCounts)
+		assert.Equal(t, models.AgingCounts{UnderOneDay: 1, Total: 1}, report.ByPriority[2].AgingCounts)
+		assert.Zero(t, report.ByPriority[3].Total)
+
+		if assert.Len(t, report.ByTeam, 1) {
+			assert.Equal(t, "Network", report.ByTeam[0].TeamName)
+			assert.Equal(t, report.Total, report.ByTeam[0].AgingCounts)
+		}
+		if assert.Len(t, report.Rows, 3) {
+			assert.Equal(t, models.PriorityCritical, report.Rows[0].Priority)
+			assert.Equal(t, models.PriorityMedium, report.Rows[2].Priority)
+		}
+
+		report, err = reportService.AgingReport(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, report.ByTeam, 2) {
+			assert.Equal(t, "No team", report.ByTeam[1].TeamName)
+			assert.Nil(t, report.ByTeam[1].TeamID)
+		}
+	})
+
+	t.Run("Burndown", func(t *testing.T) {
+		scoped, err := ticketService.ScopeTickets(ctx, manager)
+		assert.NoError(t, err)
+
+		// Four of the team's tickets were created in the last week and one resolved
+		report, err := reportService.Burndown(scoped, "", "", 7)
+		assert.NoError(t, err)
+		assert.Len(t, report.Points, 30)
+		assert.Equal(t, int64(4), report.Forecast.Backlog)
+		assert.InDelta(t, 4.0/7, report.Forecast.CreatedPerDay, 0.001)
+		assert.InDelta(t, 1.0/7, report.Forecast.ResolvedPerDay, 0.001)
+		assert.Nil(t, report.Forecast.DaysToClear, "a growing backlog never clears")
+		assert.Nil(t, report.Forecast.ClearsOn)
+
+		// Clearing six older tickets makes the backlog shrink by three a week
+		yesterday := time.Now().AddDate(0, 0, -1)
+		for range 6 {
+			ticket := &models.Ticket{Title: "Old", Description: "x", Status: models.StatusResolved, Priority: models.PriorityLow, CreatedByID: requester.ID, TeamID: &team.ID, CreationTime: time.Now().AddDate(0, 0, -20), ResolvedAt: &yesterday}
+			assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		}
+		report, err = reportService.Burndown(scoped, "", "", 7)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), report.Forecast.Backlog)
+		if assert.NotNil(t, report.Forecast.DaysToClear) {
+			assert.Equal(t, 10, *report.Forecast.DaysToClear)
+			today := time.Now().UTC()
+			assert.Equal(t, time.Date(today.Year(), today.Month(), today.Day()+10, 0, 0, 0, 0, time.UTC), *report.Forecast.ClearsOn)
+		}
+		assert.Equal(t, report.Forecast.Backlog, report.Points[len(report.Points)-1].Value)
+
+		_, err = reportService.Burndown(scoped, "", "", 500)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+}
+
+func TestQueueSnapshotOutsideUTC(t *testing.T) {
+	db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:"}})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, repository.NewUserRepository(db).Create(requester))
+	ticketRepo := repository.NewTicketRepository(db)
+	create := func(status models.TicketStatus, due, resolvedAt *time.Time) {
+		ticket := &models.Ticket{Title: "Ticket", Description: "x", Status: status, Priority: models.PriorityMedium, CreatedByID: requester.ID, DueDate: due, ResolvedAt: resolvedAt}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+	}
+
+	// Ticket times are stored in UTC while the office day starts at midnight in New York
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	at := time.Date(2026, time.October, 17, 10, 0, 0, 0, newYork)
+	dayStart := time.Date(2026, time.October, 17, 0, 0, 0, 0, newYork)
+	breached := time.Date(2026, time.October, 17, 13, 0, 0, 0, time.UTC)
+	atRisk := time.Date(2026, time.October, 17, 14, 30, 0, 0, time.UTC)
+	lastNight := time.Date(2026, time.October, 17, 2, 0, 0, 0, time.UTC)
+	thisMorning := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	create(models.StatusOpen, &breached, nil)
+	create(models.StatusOpen, &atRisk, nil)
+	create(models.StatusResolved, nil, &lastNight)
+	create(models.StatusResolved, nil, &thisMorning)
+
+	snapshot, err := repository.NewReportRepository(db).QueueSnapshot(ctx, at, at.Add(time.Hour), dayStart)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), snapshot.SLABreached)
+	assert.Equal(t, int64(1), snapshot.SLAAtRisk)
+	assert.Equal(t, int64(1), snapshot.ResolvedToday, "resolved before midnight in New York is yesterday")
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeRequester(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		assert.NoError(t, userRepo.Create(user))
+		return user
+	}
+	creator := newUser("creator@example.com", models.RoleEndUser)
+	colleague := newUser("colleague@example.com", models.RoleEndUser)
+	other := newUser("other@example.com", models.RoleEndUser)
+	viewer := newUser("viewer@example.com", models.RoleViewer)
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+
+	created, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Laptop", Description: "For my colleague", Priority: models.PriorityLow}, creator.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, created.OpenedBy) {
+		assert.Equal(t, creator.ID, *created.OpenedBy)
+	}
+
+	t.Run("StrangerForbidden", func(t *testing.T) {
+		_, err := ticketService.ChangeRequester(ctx, created.ID, &models.ChangeRequesterRequest{RequesterID: other.ID}, other)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("ViewerCannotBecomeRequester", func(t *testing.T) {
+		_, err := ticketService.ChangeRequester(ctx, created.ID, &models.ChangeRequesterRequest{RequesterID: viewer.ID}, creator)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("CreatorTransfersAndIsKeptAsOpener", func(t *testing.T) {
+		ticket, err := ticketService.ChangeRequester(ctx, created.ID, &models.ChangeRequesterRequest{RequesterID: colleague.ID}, creator)
+		assert.NoError(t, err)
+		assert.Equal(t, colleague.ID, ticket.CreatedByID)
+		assert.Equal(t, creator.ID, *ticket.OpenedBy)
+
+		// The original creator no longer owns the ticket
+		_, err = ticketService.ChangeRequester(ctx, created.ID, &models.ChangeRequesterRequest{RequesterID: creator.ID}, creator)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("AgentTransferKeepsOriginalOpener", func(t *testing.T) {
+		ticket, err := ticketService.ChangeRequester(ctx, created.ID, &models.ChangeRequesterRequest{RequesterID: other.ID}, agent)
+		assert.NoError(t, err)
+		assert.Equal(t, other.ID, ticket.CreatedByID)
+		assert.Equal(t, creator.ID, *ticket.OpenedBy)
+	})
+}
+
+func TestOnBehalfOfTicketCreation(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress: "support@helpchat.com",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		assert.NoError(t, userRepo.Create(user))
+		return user
+	}
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+	caller := newUser("caller@example.com", models.RoleEndUser)
+	endUser := newUser("enduser@example.com", models.RoleEndUser)
+
+	t.Run("AgentLogsTicketForCaller", func(t *testing.T) {
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Phone call", Description: "Cannot print", Priority: models.PriorityMedium, RequesterID: &caller.ID}, agent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, caller.ID, ticket.CreatedByID)
+		assert.Equal(t, agent.ID, *ticket.OpenedBy)
+
+		// The caller sees the ticket in their portal and is told about it
+		mine, err := ticketService.GetTicketsByUser(ctx, caller.ID, &models.TicketQuery{Page: 1, PageSize: 20})
+		assert.NoError(t, err)
+		assert.Len(t, mine.Tickets, 1)
+		if assert.Len(t, sender.messages, 1) {
+			assert.Equal(t, []string{caller.Email}, sender.messages[0].To)
+		}
+	})
+
+	t.Run("EndUserCannotActOnBehalf", func(t *testing.T) {
+		_, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Spoof", Description: "x", Priority: models.PriorityLow, RequesterID: &caller.ID}, endUser.ID)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("OwnIDIsNotOnBehalf", func(t *testing.T) {
+		sender.messages = nil
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Mine", Description: "x", Priority: models.PriorityLow, RequesterID: &endUser.ID}, endUser.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, endUser.ID, ticket.CreatedByID)
+		// They are sent the same summary as any requester, once
+		if assert.Len(t, sender.messages, 1) {
+			assert.Equal(t, []string{endUser.Email}, sender.messages[0].To)
+		}
+	})
+}
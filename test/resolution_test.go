@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolutionRequirements(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, ami)
+	handlers.NewCategoryHandler(services.NewCategoryService(categoryRepo)).RegisterRoutes(e, ami)
+
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Ada", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	hardware := &models.Category{Name: "Hardware", IsActive: true}
+	require.NoError(t, categoryRepo.Create(ctx, hardware))
+	software := &models.Category{Name: "Software", IsActive: true}
+	require.NoError(t, categoryRepo.Create(ctx, software))
+
+	doAs := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	newTicket := func(category *models.Category) *models.Ticket {
+		ticket := &models.Ticket{Title: "Monitor flickers", Description: "x", Status: models.StatusInProgress, Priority: models.PriorityLow, CategoryID: &category.ID, CreatedByID: agent.User.ID}
+		require.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	resolve := func(ticket *models.Ticket, body string) *httptest.ResponseRecorder {
+		return doAs(agentTokens.AccessToken, http.MethodPost, "/api/v1/tickets/"+ticket.ID.String()+"/status", body)
+	}
+	requirementsPath := "/api/v1/admin/categories/" + hardware.ID.String() + "/resolution-requirements"
+
+	t.Run("AdminsSetRequirements", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doAs(agentTokens.AccessToken, http.MethodPut, requirementsPath, `{"required_fields":["root_cause"]}`).Code)
+		assert.Equal(t, http.StatusBadRequest, doAs(adminTokens.AccessToken, http.MethodPut, requirementsPath, `{"required_fields":["mood"]}`).Code)
+		assert.Equal(t, http.StatusNotFound, doAs(adminTokens.AccessToken, http.MethodPut, "/api/v1/admin/categories/"+uuid.NewString()+"/resolution-requirements", `{"required_fields":[]}`).Code)
+
+		rec := doAs(adminTokens.AccessToken, http.MethodPut, requirementsPath, `{"required_fields":["resolution_code","root_cause","root_cause"]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var category models.Category
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &category))
+		assert.Equal(t, []models.ResolutionField{models.ResolutionFieldCode, models.ResolutionFieldRootCause}, category.RequiredResolutionFields)
+	})
+
+	t.Run("BlocksResolutionWithoutRequiredFields", func(t *testing.T) {
+		ticket := newTicket(hardware)
+
+		rec := resolve(ticket, `{"status":"RESOLVED","resolution_code":"REPLACED"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "root_cause")
+		assert.NotContains(t, rec.Body.String(), "resolution_code")
+		assert.Equal(t, http.StatusBadRequest, resolve(ticket, `{"status":"CLOSED"}`).Code)
+
+		// Moving between open states needs nothing
+		require.Equal(t, http.StatusOK, resolve(ticket, `{"status":"OPEN"}`).Code)
+
+		rec = resolve(ticket, `{"status":"RESOLVED","resolution_code":"REPLACED","root_cause":"Loose cable"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		resolved, err := ticketRepo.GetByID(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusResolved, resolved.Status)
+		assert.Equal(t, "REPLACED", resolved.ResolutionCode)
+		assert.Equal(t, "Loose cable", resolved.RootCause)
+
+		// Details given earlier still count when the ticket is closed
+		require.Equal(t, http.StatusOK, resolve(ticket, `{"status":"CLOSED"}`).Code)
+	})
+
+	t.Run("OtherCategoriesUnaffected", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, resolve(newTicket(software), `{"status":"RESOLVED"}`).Code)
+	})
+
+	t.Run("LiftingRequirements", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, doAs(adminTokens.AccessToken, http.MethodPut, requirementsPath, `{"required_fields":[]}`).Code)
+		assert.Equal(t, http.StatusOK, resolve(newTicket(hardware), `{"status":"RESOLVED"}`).Code)
+	})
+}
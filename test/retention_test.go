@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionRules(t *testing.T) {
+	cfg := &config.Config{
+		Database:  config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:       config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Retention: config.RetentionConfig{Rules: []string{"anonymize_requester=730", "purge_attachments=365", "shred_everything=1"}, Time: "03:00"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	files := storage.NewLocalStorage(t.TempDir())
+	authService := services.NewAuthService(userRepo, cfg)
+	auditService := services.NewAuditService(repository.NewAuditLogRepository(db), nil)
+	retentionService := services.NewRetentionService(repository.NewRetentionRepository(db), attachmentRepo, files, auditService, cfg)
+
+	e := echo.New()
+	handlers.NewRetentionHandler(retentionService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	newRequester := func(email string) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Jo", LastName: "Requester", Role: models.RoleEndUser, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	// newTicket creates a ticket, closed the given number of days ago unless negative
+	newTicket := func(requester *models.User, closedDaysAgo int) *models.Ticket {
+		ticket := &models.Ticket{Title: "Laptop", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}
+		require.NoError(t, ticketRepo.Create(ctx, ticket))
+		if closedDaysAgo >= 0 {
+			require.NoError(t, db.DB.Model(&models.Ticket{}).Where("id = ?", ticket.ID).
+				Updates(map[string]any{"status": models.StatusClosed, "resolved_at": time.Now().AddDate(0, 0, -closedDaysAgo)}).Error)
+		}
+		return ticket
+	}
+	attach := func(ticket *models.Ticket, name string) *models.Attachment {
+		_, err := files.Save(ctx, "attachments/"+name, strings.NewReader("data"))
+		require.NoError(t, err)
+		attachment := &models.Attachment{TicketID: ticket.ID, Filename: name, FilePath: "attachments/" + name, FileSize: 4, MimeType: "text/plain", UploadedByID: ticket.CreatedByID}
+		require.NoError(t, attachmentRepo.Create(ctx, attachment))
+		return attachment
+	}
+
+	// Long gone: every ticket closed three years ago
+	departed := newRequester("departed@example.com")
+	oldTicket := newTicket(departed, 3*365)
+	oldAttachment := attach(oldTicket, "old.txt")
+	require.NoError(t, db.DB.Create(&models.LoginEvent{UserID: departed.ID, IPAddress: "10.0.0.1"}).Error)
+	require.NoError(t, db.DB.Create(&models.TicketSubscriber{TicketID: oldTicket.ID, Email: "cc@example.com", AddedByID: departed.ID}).Error)
+
+	// Still around: an old closed ticket, but another still open
+	regular := newRequester("regular@example.com")
+	newTicket(regular, 3*365)
+	newTicket(regular, -1)
+
+	// Recently closed: attachments are kept for now
+	recent := newRequester("recent@example.com")
+	recentAttachment := attach(newTicket(recent, 30), "recent.txt")
+
+	rule := func(t *testing.T, report *models.RetentionReport, action models.RetentionAction) models.RetentionRuleResult {
+		for _, result := range report.Rules {
+			if result.Action == action {
+				return result
+			}
+		}
+		t.Fatalf("no %s rule in the report", action)
+		return models.RetentionRuleResult{}
+	}
+
+	t.Run("PreviewChangesNothing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/retention/preview", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: adminTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var report models.RetentionReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		require.Len(t, report.Rules, 2, "invalid rules are ignored")
+
+		anonymize := rule(t, &report, models.RetentionAnonymizeRequester)
+		assert.Equal(t, 730, anonymize.AfterDays)
+		assert.EqualValues(t, 1, anonymize.Count)
+		assert.EqualValues(t, 1, anonymize.Tickets)
+		assert.Equal(t, departed.ID, anonymize.SampleIDs[0])
+
+		purge := rule(t, &report, models.RetentionPurgeAttachments)
+		assert.EqualValues(t, 1, purge.Count)
+		assert.EqualValues(t, 4, purge.Bytes)
+		assert.Equal(t, oldAttachment.ID, purge.SampleIDs[0])
+
+		user, err := userRepo.GetByID(departed.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "departed@example.com", user.Email)
+		_, err = attachmentRepo.GetByID(ctx, oldAttachment.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ApplyClearsClosedTicketData", func(t *testing.T) {
+		report, err := retentionService.Apply(ctx)
+		require.NoError(t, err)
+		assert.False(t, report.DryRun)
+		assert.EqualValues(t, 1, rule(t, report, models.RetentionAnonymizeRequester).Count)
+		assert.EqualValues(t, 1, rule(t, report, models.RetentionPurgeAttachments).Count)
+
+		user, err := userRepo.GetByID(departed.ID.String())
+		require.NoError(t, err)
+		assert.NotContains(t, user.Email, "departed")
+		assert.Equal(t, "Anonymized", user.FirstName)
+		assert.False(t, user.IsActive)
+		assert.NotNil(t, user.AnonymizedAt)
+		var count int64
+		require.NoError(t, db.DB.Model(&models.LoginEvent{}).Where("user_id = ?", departed.ID).Count(&count).Error)
+		assert.Zero(t, count, "sign-in history is deleted")
+		require.NoError(t, db.DB.Model(&models.TicketSubscriber{}).Where("ticket_id = ?", oldTicket.ID).Count(&count).Error)
+		assert.Zero(t, count, "copied addresses are removed")
+		_, err = ticketRepo.GetByID(ctx, oldTicket.ID)
+		assert.NoError(t, err, "the ticket itself is kept")
+
+		user, err = userRepo.GetByID(regular.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "regular@example.com", user.Email, "requesters with an open ticket are kept")
+
+		_, err = attachmentRepo.GetByID(ctx, oldAttachment.ID)
+		assert.Error(t, err)
+		_, err = files.Open(ctx, oldAttachment.FilePath)
+		assert.Error(t, err, "the file is deleted")
+		_, err = attachmentRepo.GetByID(ctx, recentAttachment.ID)
+		assert.NoError(t, err, "attachments of recently closed tickets are kept")
+
+		require.NoError(t, db.DB.Model(&models.AuditLog{}).Where("action = ?", models.AuditActionRetention).Count(&count).Error)
+		assert.EqualValues(t, 2, count)
+
+		// A second run has nothing left to do
+		report, err = retentionService.Apply(ctx)
+		require.NoError(t, err)
+		for _, result := range report.Rules {
+			assert.Zero(t, result.Count)
+		}
+	})
+}
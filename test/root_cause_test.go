@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootCauses(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Widget:   config.WidgetConfig{Timezone: "UTC"},
+		Reports:  config.ReportsConfig{StreamInterval: "1m", SLARiskWindow: "1h"},
+		Tickets:  config.TicketsConfig{RootCauses: []string{"hardware_failure", "USER_ERROR", " NETWORK "}},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+	appCache := cache.NewMemory()
+	reportService := services.NewReportService(repository.NewReportRepository(db), categoryRepo, repository.NewChatRepository(db), services.NewPresenceService(appCache, userRepo, cfg), appCache, cfg)
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, ami)
+	handlers.NewReportHandler(reportService, ticketService).RegisterRoutes(e, ami)
+
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Ada", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	hardware := &models.Category{Name: "Hardware", IsActive: true}
+	require.NoError(t, categoryRepo.Create(ctx, hardware))
+	network := &models.Category{Name: "Network", IsActive: true}
+	require.NoError(t, categoryRepo.Create(ctx, network))
+
+	doAs := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("ListsCodes", func(t *testing.T) {
+		rec := doAs(agentTokens.AccessToken, http.MethodGet, "/api/v1/tickets/root-causes", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var codes []string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &codes))
+		assert.Equal(t, []string{"HARDWARE_FAILURE", "USER_ERROR", "NETWORK"}, codes)
+	})
+
+	t.Run("CapturedAtResolution", func(t *testing.T) {
+		ticket := &models.Ticket{Title: "Switch down", Description: "x", Status: models.StatusInProgress, Priority: models.PriorityHigh, CategoryID: &network.ID, CreatedByID: agent.User.ID}
+		require.NoError(t, ticketRepo.Create(ctx, ticket))
+		path := "/api/v1/tickets/" + ticket.ID.String() + "/status"
+
+		rec := doAs(agentTokens.AccessToken, http.MethodPost, path, `{"status":"RESOLVED","root_cause_code":"GREMLINS"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "NETWORK")
+
+		rec = doAs(agentTokens.AccessToken, http.MethodPost, path, `{"status":"RESOLVED","root_cause_code":"network"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		resolved, err := ticketRepo.GetByID(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "NETWORK", resolved.RootCauseCode)
+	})
+
+	t.Run("ReportsTopCausesPerMonthAndCategory", func(t *testing.T) {
+		resolved := func(day time.Time, category *models.Category, code string) {
+			ticket := &models.Ticket{Title: "Resolved", Description: "x", Status: models.StatusResolved, Priority: models.PriorityLow, CreatedByID: agent.User.ID, ResolvedAt: &day, RootCauseCode: code}
+			if category != nil {
+				ticket.CategoryID = &category.ID
+			}
+			require.NoError(t, ticketRepo.Create(ctx, ticket))
+		}
+		february := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+		march := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+		resolved(february, hardware, "HARDWARE_FAILURE")
+		resolved(february, hardware, "HARDWARE_FAILURE")
+		resolved(february, hardware, "USER_ERROR")
+		resolved(february, network, "NETWORK")
+		resolved(february, nil, "USER_ERROR")
+		resolved(march, hardware, "USER_ERROR")
+		// Outside the range, and resolved without a code
+		resolved(time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC), hardware, "USER_ERROR")
+		resolved(march, hardware, "")
+
+		rec := doAs(adminTokens.AccessToken, http.MethodGet, "/api/v1/reports/root-causes?from=2026-02-01&to=2026-03-31&limit=1", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var report models.RootCauseReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		require.Len(t, report.Months, 2)
+
+		feb := report.Months[0]
+		assert.Equal(t, "2026-02", feb.Month)
+		// USER_ERROR ties with HARDWARE_FAILURE overall and loses on code order
+		assert.Equal(t, []models.RootCauseCount{{Code: "HARDWARE_FAILURE", Tickets: 2}}, feb.Top)
+		require.Len(t, feb.Categories, 3)
+		assert.Equal(t, "Hardware", feb.Categories[0].Name)
+		assert.Equal(t, int64(3), feb.Categories[0].Tickets)
+		assert.Equal(t, []models.RootCauseCount{{Code: "HARDWARE_FAILURE", Tickets: 2}}, feb.Categories[0].Causes)
+		assert.Equal(t, "Network", feb.Categories[1].Name)
+		assert.Equal(t, "Uncategorized", feb.Categories[2].Name)
+		assert.Nil(t, feb.Categories[2].CategoryID)
+
+		mar := report.Months[1]
+		assert.Equal(t, "2026-03", mar.Month)
+		assert.Equal(t, []models.RootCauseCount{{Code: "USER_ERROR", Tickets: 1}}, mar.Top)
+
+		assert.Equal(t, http.StatusBadRequest, doAs(adminTokens.AccessToken, http.MethodGet, "/api/v1/reports/root-causes?limit=500", "").Code)
+		assert.Equal(t, http.StatusForbidden, doAs(agentTokens.AccessToken, http.MethodGet, "/api/v1/reports/root-causes", "").Code)
+	})
+}
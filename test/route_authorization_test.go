@@ -0,0 +1,547 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/docs"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services/email"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouteTestServer wires every handler the way the server does and registers
+// their routes through handlers.Handlers
+func newRouteTestServer(t *testing.T, cfg *config.Config) (*echo.Echo, *services.AuthService, handlers.RouteAuthorizations) {
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, database.RunMigrations(db))
+
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	draftRepo := repository.NewDraftAttachmentRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	problemRepo := repository.NewProblemRepository(db)
+	chatRepo := repository.NewChatRepository(db)
+
+	authService := services.NewAuthService(userRepo, cfg)
+	auditService := services.NewAuditService(repository.NewAuditLogRepository(db), nil)
+	redactionService, err := services.NewRedactionService(cfg, auditService)
+	require.NoError(t, err)
+	emailDeliveryService := services.NewEmailDeliveryService(email.NewNoopSender(), repository.NewEmailDeliveryRepository(db), userRepo, nil, cfg)
+	loginHistoryService := services.NewLoginHistoryService(repository.NewLoginEventRepository(db), emailDeliveryService, cfg)
+	emailTemplateService := services.NewEmailTemplateService(repository.NewEmailTemplateRepository(db), cfg)
+	notificationService := services.NewNotificationService(repository.NewNotificationRepository(db))
+	emailChannelService := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, emailDeliveryService, storage.NewLocalStorage(t.TempDir()), redactionService, emailTemplateService, nil, notificationService, cfg)
+	delegationService := services.NewDelegationService(repository.NewOutOfOfficeRepository(db), ticketRepo, userRepo)
+	hub := realtime.NewHub()
+	ticketEventService := services.NewTicketEventService(hub, teamRepo)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, commentRepo, userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: draftRepo, Delegation: delegationService, Notifier: notificationService.Notifier(emailChannelService), Events: ticketEventService, Redaction: redactionService})
+	appCache := cache.NewMemory()
+	presenceService := services.NewPresenceService(appCache, userRepo, cfg)
+	widgetService, err := services.NewWidgetService(userRepo, repository.NewChatTriggerRepository(db), presenceService, cfg)
+	require.NoError(t, err)
+	calendarService, err := services.NewBusinessCalendarService(cfg)
+	require.NoError(t, err)
+	intakeService := services.NewIntakeService(ticketRepo, categoryRepo, userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannelService, ticketService)
+	knowledgeService := services.NewKnowledgeService(repository.NewKnowledgeRepository(db))
+	usageService := services.NewUsageService(cfg)
+	customDomainService, err := services.NewCustomDomainService(cfg)
+	require.NoError(t, err)
+
+	ami := testMiddleware.NewAuthMiddleware(authService, usageService)
+	responseCache := testMiddleware.NewResponseCache(appCache, cfg)
+	botProtection, err := testMiddleware.NewBotProtection(cfg)
+	require.NoError(t, err)
+	tokenLockout := testMiddleware.NewTokenLockout(auditService, cfg)
+
+	routes := &handlers.Handlers{
+		Ping:          handlers.NewPingHandler(db),
+		Metrics:       handlers.NewMetricsHandler(db, testMiddleware.NewProblemJSON(), cfg),
+		Auth:          handlers.NewAuthHandler(authService, services.NewAccountService(userRepo, repository.NewAccountTokenRepository(db), emailDeliveryService, emailTemplateService, cfg), loginHistoryService, botProtection, tokenLockout),
+		Ticket:        handlers.NewTicketHandler(ticketService),
+		TicketTrash:   handlers.NewTicketTrashHandler(services.NewTicketTrashService(ticketRepo, storage.NewLocalStorage(t.TempDir()), cfg)),
+		Share:         handlers.NewShareHandler(services.NewShareService(ticketRepo, commentRepo, cfg), tokenLockout),
+		Subscriber:    handlers.NewTicketSubscriberHandler(ticketService, emailChannelService),
+		Attachment:    handlers.NewAttachmentHandler(services.NewAttachmentService(attachmentRepo, draftRepo, ticketRepo, commentRepo, storage.NewLocalStorage(t.TempDir()), cfg)),
+		TicketLock:    handlers.NewTicketLockHandler(services.NewTicketLockService(appCache, ticketRepo, cfg)),
+		InboundEmail:  handlers.NewInboundEmailHandler(intakeService, cfg),
+		Inbound:       handlers.NewInboundIntegrationHandler(services.NewInboundService(repository.NewInboundIntegrationRepository(db), userRepo, categoryRepo, teamRepo, ticketService)),
+		Webhook:       handlers.NewWebhookHandler(services.NewWebhookService(repository.NewWebhookRepository(db))),
+		Intake:        handlers.NewIntakeHandler(intakeService, botProtection, responseCache),
+		Audit:         handlers.NewAuditHandler(auditService),
+		Encryption:    handlers.NewEncryptionHandler(services.NewEncryptionService(repository.NewEncryptedColumnRepository(db), auditService, nil)),
+		Retention:     handlers.NewRetentionHandler(services.NewRetentionService(repository.NewRetentionRepository(db), attachmentRepo, storage.NewLocalStorage(t.TempDir()), auditService, cfg)),
+		Offboarding:   handlers.NewOffboardingHandler(services.NewOffboardingService(repository.NewOffboardingRepository(db), userRepo, teamRepo, auditService)),
+		Sandbox:       handlers.NewSandboxHandler(services.NewSandboxService(repository.NewSandboxRepository(db), storage.NewLocalStorage(t.TempDir()), cfg)),
+		Demo:          handlers.NewDemoHandler(services.NewDemoService(repository.NewDemoRepository(db), storage.NewLocalStorage(t.TempDir()), cfg), cfg),
+		User:          handlers.NewUserHandler(services.NewUserService(userRepo), loginHistoryService, delegationService, usageService),
+		Notification:  handlers.NewNotificationHandler(notificationService),
+		Team:          handlers.NewTeamHandler(services.NewTeamService(teamRepo, userRepo)),
+		Category:      handlers.NewCategoryHandler(services.NewCategoryService(categoryRepo)),
+		Asset:         handlers.NewAssetHandler(services.NewAssetService(repository.NewAssetRepository(db), ticketRepo, userRepo), ticketService),
+		Problem:       handlers.NewProblemHandler(services.NewProblemService(problemRepo, ticketRepo, ticketService)),
+		StatusBanner:  handlers.NewStatusBannerHandler(services.NewStatusBannerService(repository.NewStatusBannerRepository(db), categoryRepo, problemRepo, emailDeliveryService), botProtection),
+		Widget:        handlers.NewWidgetHandler(widgetService),
+		Knowledge:     handlers.NewKnowledgeHandler(knowledgeService, responseCache),
+		Chat:          handlers.NewChatHandler(services.NewChatService(chatRepo, userRepo, teamRepo, knowledgeService, widgetService, intakeService, presenceService, hub, emailDeliveryService, cfg), botProtection, tokenLockout, cfg),
+		Presence:      handlers.NewPresenceHandler(presenceService, cfg),
+		TicketEvents:  handlers.NewTicketEventHandler(ticketEventService, cfg),
+		Report:        handlers.NewReportHandler(services.NewReportService(repository.NewReportRepository(db), categoryRepo, chatRepo, presenceService, appCache, cfg), ticketService),
+		Export:        handlers.NewExportHandler(services.NewExportService(repository.NewExportRepository(db), storage.NewLocalStorage(t.TempDir()), cfg)),
+		Search:        handlers.NewSearchHandler(services.NewSearchService(repository.NewSearchRepository(db), appCache), ticketService),
+		Calendar:      handlers.NewCalendarHandler(calendarService),
+		Reload:        handlers.NewReloadHandler(services.NewReloadService(auditService, cfg)),
+		EmailTemplate: handlers.NewEmailTemplateHandler(emailTemplateService),
+		EmailDelivery: handlers.NewEmailDeliveryHandler(emailDeliveryService, cfg),
+		SlowQuery:     handlers.NewSlowQueryHandler(db),
+		Profiling:     handlers.NewProfilingHandler(cfg),
+		FeatureUsage:  handlers.NewFeatureUsageHandler(services.NewFeatureUsageService(repository.NewFeatureUsageRepository(db), cfg)),
+		CustomDomain:  handlers.NewCustomDomainHandler(customDomainService),
+		Integrations:  handlers.NewIntegrationHealthHandler(services.NewIntegrationHealthService()),
+		FailedTasks:   handlers.NewFailedTaskHandler(services.NewFailedTaskService(repository.NewFailedTaskRepository(db), auditService)),
+	}
+	value := reflect.ValueOf(routes).Elem()
+	for i := range value.NumField() {
+		require.False(t, value.Field(i).IsNil(), "handler %s is not wired into the route test server", value.Type().Field(i).Name)
+	}
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	authorizations := routes.RegisterRoutes(e, ami)
+	return e, authService, authorizations
+}
+
+// routeRule is the access a route grants
+type routeRule struct {
+	// public routes need no session; some check a token of their own
+	public bool
+	// roles are the roles let through; every other role is refused
+	roles []models.UserRole
+}
+
+var (
+	allRoles = []models.UserRole{models.RoleEndUser, models.RoleViewer, models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator}
+
+	publicRoute = routeRule{public: true}
+	// secretRoute is authorized by a shared secret, never by a session
+	secretRoute   = routeRule{}
+	signedIn      = routeRule{roles: allRoles}
+	agents        = routeRule{roles: []models.UserRole{models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator}}
+	managers      = routeRule{roles: []models.UserRole{models.RoleManager, models.RoleAdministrator}}
+	admins        = managers // RequireAdmin lets managers through too
+	systemAdmins  = routeRule{roles: []models.UserRole{models.RoleAdministrator}}
+	ticketRead    = routeRule{roles: []models.UserRole{models.RoleViewer, models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator}}
+	ticketCreate  = routeRule{roles: []models.UserRole{models.RoleEndUser, models.RoleSupportAgent, models.RoleManager, models.RoleAdministrator}}
+	ticketUpdate  = ticketCreate // ticket:update or ticket:update:own
+	commentCreate = ticketCreate
+	reportRead    = routeRule{roles: []models.UserRole{models.RoleViewer, models.RoleManager, models.RoleAdministrator}}
+	statsRead     = ticketRead
+)
+
+// routeAccess is the expected access of every registered route. A new route
+// fails TestRouteAuthorization until it is added here.
+var routeAccess = map[string]routeRule{
+	"GET /ping":                        publicRoute,
+	"GET /ping-through":                publicRoute,
+	"GET /metrics":                     publicRoute,
+	"GET /widget.js":                   publicRoute,
+	"GET /api/v1/public/widget/config": publicRoute,
+
+	"POST /api/v1/auth/register":        publicRoute,
+	"POST /api/v1/auth/login":           publicRoute,
+	"POST /api/v1/auth/refresh":         publicRoute,
+	"POST /api/v1/auth/logout":          signedIn,
+	"POST /api/v1/auth/forgot-password": publicRoute,
+	"POST /api/v1/auth/reset-password":  publicRoute,
+	"POST /api/v1/auth/verify-email":    publicRoute,
+
+	"GET /api/v1/tickets":                          ticketRead,
+	"POST /api/v1/tickets":                         ticketCreate,
+	"POST /api/v1/tickets/similar":                 ticketCreate,
+	"GET /api/v1/tickets/:id":                      signedIn, // end users reach the ownership check
+	"PUT /api/v1/tickets/:id":                      ticketUpdate,
+	"DELETE /api/v1/tickets/:id":                   admins,
+	"GET /api/v1/tickets/:id/versions":             admins,
+	"POST /api/v1/tickets/:id/restore":             systemAdmins,
+	"GET /api/v1/admin/tickets/trash":              admins,
+	"POST /api/v1/admin/tickets/trash/:id/restore": admins,
+	"POST /api/v1/tickets/:id/assign":              agents,
+	"POST /api/v1/tickets/:id/unassign":            agents,
+	"POST /api/v1/tickets/:id/take":                agents,
+	"POST /api/v1/tickets/:id/status":              agents,
+	"POST /api/v1/tickets/:id/escalate":            agents,
+	"POST /api/v1/tickets/:id/de-escalate":         agents,
+	"GET /api/v1/tickets/:id/activity":             agents,
+	"POST /api/v1/tickets/:id/requester":           signedIn,
+	"GET /api/v1/tickets/:id/comments":             signedIn,
+	"POST /api/v1/tickets/:id/comments":            signedIn,
+	"POST /api/v1/tickets/comments/preview":        commentCreate,
+	"GET /api/v1/tickets/my":                       signedIn,
+	"GET /api/v1/tickets/assigned":                 signedIn,
+	"GET /api/v1/tickets/recent":                   signedIn,
+	"PUT /api/v1/tickets/:id/pin":                  signedIn,
+	"DELETE /api/v1/tickets/:id/pin":               signedIn,
+	"GET /api/v1/tickets/root-causes":              agents,
+	"GET /api/v1/tickets/stats":                    statsRead,
+
+	"POST /api/v1/tickets/:id/share":           signedIn,
+	"GET /api/v1/public/shared-tickets/:token": publicRoute,
+
+	"GET /api/v1/tickets/:id/subscribers":                                signedIn,
+	"POST /api/v1/tickets/:id/subscribers":                               signedIn,
+	"DELETE /api/v1/tickets/:id/subscribers/:subscriberId":               signedIn,
+	"POST /api/v1/tickets/:id/attachments":                               signedIn,
+	"POST /api/v1/tickets/draft-attachments":                             ticketCreate,
+	"POST /api/v1/tickets/inline-images":                                 ticketCreate,
+	"GET /api/v1/tickets/:id/attachments/:attachmentId":                  signedIn,
+	"DELETE /api/v1/tickets/:id/attachments/:attachmentId":               signedIn,
+	"GET /api/v1/tickets/:id/attachments/:attachmentId/thumbnails/:size": signedIn,
+	"GET /api/v1/public/unsubscribe/:token":                              publicRoute,
+	"POST /api/v1/public/unsubscribe/:token":                             publicRoute,
+
+	"GET /api/v1/tickets/:id/lock":            agents,
+	"POST /api/v1/tickets/:id/lock":           agents,
+	"POST /api/v1/tickets/:id/lock/heartbeat": agents,
+	"DELETE /api/v1/tickets/:id/lock":         agents,
+
+	"POST /api/v1/inbound/email":                         secretRoute,
+	"POST /api/v1/inbound/email/events":                  secretRoute,
+	"POST /api/v1/inbound/:integration_key":              secretRoute,
+	"POST /api/v1/inbound/:integration_key/alertmanager": secretRoute,
+
+	"GET /api/v1/admin/inbound-integrations":             admins,
+	"POST /api/v1/admin/inbound-integrations":            admins,
+	"GET /api/v1/admin/inbound-integrations/:id":         admins,
+	"PUT /api/v1/admin/inbound-integrations/:id":         admins,
+	"DELETE /api/v1/admin/inbound-integrations/:id":      admins,
+	"POST /api/v1/admin/inbound-integrations/:id/secret": admins,
+	"GET /api/v1/admin/webhooks":                         admins,
+	"POST /api/v1/admin/webhooks":                        admins,
+	"GET /api/v1/admin/webhooks/:id":                     admins,
+	"PUT /api/v1/admin/webhooks/:id":                     admins,
+	"DELETE /api/v1/admin/webhooks/:id":                  admins,
+	"POST /api/v1/admin/webhooks/:id/secret":             admins,
+	"GET /api/v1/admin/webhooks/:id/deliveries":          admins,
+
+	"POST /api/v1/public/tickets":               publicRoute,
+	"GET /api/v1/public/categories":             publicRoute,
+	"GET /api/v1/admin/quarantine":              admins,
+	"POST /api/v1/admin/quarantine/:id/approve": admins,
+	"POST /api/v1/admin/quarantine/:id/reject":  admins,
+
+	"GET /api/v1/admin/audit-logs":         admins,
+	"POST /api/v1/admin/encryption/rotate": admins,
+	"GET /api/v1/admin/retention/preview":  admins,
+	"POST /api/v1/admin/offboarding":       admins,
+	"GET /api/v1/admin/offboarding":        admins,
+	"GET /api/v1/admin/offboarding/:id":    admins,
+	"POST /api/v1/sandbox":                 agents,
+	"GET /api/v1/sandbox":                  agents,
+	"DELETE /api/v1/sandbox":               agents,
+	"POST /api/v1/admin/demo/reset":        systemAdmins,
+
+	"GET /api/v1/users/me/logins":                  signedIn,
+	"GET /api/v1/users/me/usage":                   signedIn,
+	"PUT /api/v1/users/me/language":                signedIn,
+	"PUT /api/v1/users/me/timezone":                signedIn,
+	"PUT /api/v1/users/me/quiet-hours":             signedIn,
+	"GET /api/v1/users/me/out-of-office":           agents,
+	"PUT /api/v1/users/me/out-of-office":           agents,
+	"DELETE /api/v1/users/me/out-of-office":        agents,
+	"POST /api/v1/users/me/out-of-office/handover": agents,
+	"GET /api/v1/notifications":                    signedIn,
+	"POST /api/v1/notifications/read-all":          signedIn,
+	"PUT /api/v1/notifications/:id/read":           signedIn,
+	"DELETE /api/v1/notifications/:id/read":        signedIn,
+	"GET /api/v1/notifications/preferences":        signedIn,
+	"PUT /api/v1/notifications/preferences":        signedIn,
+
+	"GET /api/v1/admin/teams":                         systemAdmins,
+	"POST /api/v1/admin/teams":                        systemAdmins,
+	"GET /api/v1/admin/teams/:id":                     systemAdmins,
+	"DELETE /api/v1/admin/teams/:id":                  systemAdmins,
+	"POST /api/v1/admin/teams/:id/members":            systemAdmins,
+	"DELETE /api/v1/admin/teams/:id/members/:user_id": systemAdmins,
+
+	"GET /api/v1/admin/categories":                             systemAdmins,
+	"PUT /api/v1/admin/categories/:id/resolution-requirements": systemAdmins,
+
+	"GET /api/v1/assets":                          ticketRead,
+	"POST /api/v1/assets":                         agents,
+	"GET /api/v1/assets/report":                   reportRead,
+	"GET /api/v1/assets/:id":                      ticketRead,
+	"PUT /api/v1/assets/:id":                      agents,
+	"DELETE /api/v1/assets/:id":                   admins,
+	"GET /api/v1/assets/:id/tickets":              ticketRead,
+	"GET /api/v1/tickets/:id/assets":              ticketRead,
+	"POST /api/v1/tickets/:id/assets":             agents,
+	"DELETE /api/v1/tickets/:id/assets/:asset_id": agents,
+
+	"GET /api/v1/problems":                             ticketRead,
+	"POST /api/v1/problems":                            agents,
+	"GET /api/v1/problems/top":                         reportRead,
+	"GET /api/v1/problems/:id":                         ticketRead,
+	"POST /api/v1/problems/:id/incidents":              agents,
+	"DELETE /api/v1/problems/:id/incidents/:ticket_id": agents,
+	"POST /api/v1/problems/:id/close":                  agents,
+
+	"GET /api/v1/public/status-banners":                publicRoute,
+	"POST /api/v1/public/status-banners/:id/subscribe": publicRoute,
+	"POST /api/v1/status-banners/:id/subscribe":        signedIn,
+	"GET /api/v1/admin/status-banners":                 admins,
+	"POST /api/v1/admin/status-banners":                admins,
+	"GET /api/v1/admin/status-banners/:id":             admins,
+	"DELETE /api/v1/admin/status-banners/:id":          admins,
+	"POST /api/v1/admin/status-banners/:id/resolve":    admins,
+
+	"GET /api/v1/admin/chat-triggers":        admins,
+	"POST /api/v1/admin/chat-triggers":       admins,
+	"GET /api/v1/admin/chat-triggers/:id":    admins,
+	"PUT /api/v1/admin/chat-triggers/:id":    admins,
+	"DELETE /api/v1/admin/chat-triggers/:id": admins,
+
+	"GET /api/v1/public/kb/articles":     publicRoute,
+	"GET /api/v1/public/kb/articles/:id": publicRoute,
+	"GET /api/v1/kb/articles":            agents,
+	"POST /api/v1/kb/articles":           agents,
+	"GET /api/v1/kb/articles/:id":        agents,
+	"PUT /api/v1/kb/articles/:id":        agents,
+	"DELETE /api/v1/kb/articles/:id":     agents,
+
+	// Visitor chat routes check the chat token rather than a session
+	"POST /api/v1/public/chats":                publicRoute,
+	"GET /api/v1/public/chats/:id":             publicRoute,
+	"POST /api/v1/public/chats/:id/messages":   publicRoute,
+	"POST /api/v1/public/chats/:id/end":        publicRoute,
+	"GET /api/v1/public/chats/:id/queue":       publicRoute,
+	"POST /api/v1/public/chats/:id/ticket":     publicRoute,
+	"POST /api/v1/public/chats/:id/transcript": publicRoute,
+	"POST /api/v1/public/chats/:id/rating":     publicRoute,
+	"GET /api/v1/public/chats/:id/ws":          publicRoute,
+	"GET /api/v1/chats":                        agents,
+	"GET /api/v1/chats/:id":                    agents,
+	"POST /api/v1/chats/:id/accept":            agents,
+	"POST /api/v1/chats/:id/messages":          agents,
+	"POST /api/v1/chats/:id/end":               agents,
+	"POST /api/v1/chats/:id/transfer":          agents,
+	"GET /api/v1/chats/:id/ws":                 agents,
+	"POST /api/v1/chats/:id/monitor":           managers,
+	"POST /api/v1/chats/:id/join":              managers,
+	"GET /api/v1/chats/capacity":               managers,
+	"PUT /api/v1/chats/agents/:id/capacity":    managers,
+	// Both RequireAgent and report:read apply, so viewers are still refused
+	"GET /api/v1/chats/csat": managers,
+
+	"GET /api/v1/presence/ws": agents,
+	"GET /api/v1/ws":          signedIn,
+	"GET /api/v1/agents":      agents,
+
+	"GET /api/v1/reports/queue":        reportRead,
+	"GET /api/v1/reports/queue/stream": reportRead,
+	"GET /api/v1/reports/dashboard":    reportRead,
+	"GET /api/v1/reports/trends":       reportRead,
+	"GET /api/v1/reports/categories":   reportRead,
+	"GET /api/v1/reports/root-causes":  reportRead,
+	"GET /api/v1/reports/aging":        reportRead,
+	"GET /api/v1/reports/burndown":     reportRead,
+
+	"GET /api/v1/admin/exports":      admins,
+	"POST /api/v1/admin/exports":     admins,
+	"GET /api/v1/admin/exports/:day": admins,
+
+	"GET /api/v1/search/suggest":         ticketRead,
+	"GET /api/v1/lookup":                 ticketRead,
+	"GET /api/v1/calendar/business-days": signedIn,
+	"POST /api/v1/admin/reload":          systemAdmins,
+
+	"GET /api/v1/admin/email-templates":                                 admins,
+	"GET /api/v1/admin/email-templates/:name":                           admins,
+	"PUT /api/v1/admin/email-templates/:name":                           admins,
+	"DELETE /api/v1/admin/email-templates/:name":                        admins,
+	"POST /api/v1/admin/email-templates/:name/preview":                  admins,
+	"GET /api/v1/admin/email-templates/:name/translations":              admins,
+	"PUT /api/v1/admin/email-templates/:name/translations/:language":    admins,
+	"DELETE /api/v1/admin/email-templates/:name/translations/:language": admins,
+
+	"GET /api/v1/admin/email/deliveries":               admins,
+	"GET /api/v1/admin/email/suppressions":             admins,
+	"DELETE /api/v1/admin/email/suppressions/:address": admins,
+	"GET /api/v1/admin/email/failing-users":            admins,
+
+	"GET /api/v1/admin/slow-queries":        admins,
+	"GET /api/v1/admin/feature-usage":       admins,
+	"GET /api/v1/admin/custom-domain":       admins,
+	"GET /api/v1/admin/integrations/health": admins,
+
+	"GET /api/v1/admin/failed-tasks":            admins,
+	"GET /api/v1/admin/failed-tasks/:id":        admins,
+	"POST /api/v1/admin/failed-tasks/:id/retry": admins,
+	"DELETE /api/v1/admin/failed-tasks/:id":     admins,
+	"POST /api/v1/admin/failed-tasks/retry":     admins,
+	"POST /api/v1/admin/failed-tasks/discard":   admins,
+
+	"GET /debug/pprof/*":       systemAdmins,
+	"GET /debug/pprof/cmdline": systemAdmins,
+	"GET /debug/pprof/profile": systemAdmins,
+	"GET /debug/pprof/symbol":  systemAdmins,
+	"POST /debug/pprof/symbol": systemAdmins,
+	"GET /debug/pprof/trace":   systemAdmins,
+}
+
+// TestRouteAuthorization calls every registered route anonymously and as every
+// role, and checks each is let through or refused as routeAccess says. Routes
+// are called with unknown IDs and empty bodies, so "let through" only means
+// the route did not answer 401 or 403.
+func TestRouteAuthorization(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{InboundSecret: "inbound-secret"},
+		Metrics:  config.MetricsConfig{Profiling: true},
+		Demo:     config.DemoConfig{Enabled: true},
+		Widget:   config.WidgetConfig{Timezone: "UTC"},
+		Reports:  config.ReportsConfig{StreamInterval: "1m", SLARiskWindow: "1h"},
+	}
+	e, authService, authorizations := newRouteTestServer(t, cfg)
+
+	tokens := make(map[models.UserRole]string)
+	for _, role := range allRoles {
+		_, issued, err := authService.Register(&models.RegisterRequest{Email: string(role) + "@example.com", Password: "password123", FirstName: "Test", LastName: "User", Role: role})
+		require.NoError(t, err)
+		tokens[role] = issued.AccessToken
+	}
+
+	call := func(method, path, token string) int {
+		// The queue stream only returns once its request is cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(method, path, strings.NewReader("{}")).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	denied := func(code int) bool {
+		return code == http.StatusUnauthorized || code == http.StatusForbidden
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range e.Routes() {
+		// Echo adds catch-all entries for groups; they are not routes
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+
+		rule, ok := routeAccess[key]
+		if !assert.True(t, ok, "route %s has no entry in routeAccess", key) {
+			continue
+		}
+		path := routePath(route.Path)
+
+		code := call(route.Method, path, "")
+		if rule.public {
+			assert.NotEqual(t, http.StatusUnauthorized, code, "%s asked an anonymous caller to sign in", key)
+			continue
+		}
+		assert.Equal(t, http.StatusUnauthorized, code, "%s let an anonymous caller through", key)
+
+		for _, role := range allRoles {
+			code := call(route.Method, path, tokens[role])
+			if slices.Contains(rule.roles, role) {
+				assert.False(t, denied(code), "%s refused %s with %d", key, role, code)
+			} else {
+				assert.True(t, denied(code), "%s let %s through with %d", key, role, code)
+			}
+		}
+	}
+
+	for key := range routeAccess {
+		assert.True(t, registered[key], "routeAccess entry %s matches no registered route", key)
+	}
+
+	t.Run("Documented", func(t *testing.T) {
+		for key, rule := range routeAccess {
+			authorization := authorizations[key]
+			if rule.public || len(rule.roles) == 0 {
+				assert.Nil(t, authorization, "%s is described as authenticated", key)
+				continue
+			}
+			if assert.NotNil(t, authorization, "%s is not described as authenticated", key) {
+				assert.ElementsMatch(t, rule.roles, authorization.Roles, "%s: %v", key, authorization.Rules)
+			}
+		}
+
+		doc, err := handlers.DocumentAuthorization(docs.SwaggerInfo.ReadDoc(), authorizations)
+		require.NoError(t, err)
+		var spec struct {
+			Paths map[string]map[string]struct {
+				Security      []map[string][]string              `json:"security"`
+				Authorization *testMiddleware.RouteAuthorization `json:"x-authorization"`
+			} `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(doc), &spec))
+		documented := 0
+		for path, operations := range spec.Paths {
+			for method, operation := range operations {
+				key := strings.ToUpper(method) + " " + strings.NewReplacer("{", ":", "}", "").Replace(path)
+				rule, ok := routeAccess[key]
+				if !ok {
+					continue
+				}
+				documented++
+				if rule.public || len(rule.roles) == 0 {
+					assert.Nil(t, operation.Authorization, key)
+					assert.Empty(t, operation.Security, key)
+					continue
+				}
+				assert.NotEmpty(t, operation.Security, key)
+				if assert.NotNil(t, operation.Authorization, key) {
+					assert.ElementsMatch(t, rule.roles, operation.Authorization.Roles, key)
+				}
+			}
+		}
+		assert.Greater(t, documented, 100)
+	})
+}
+
+// routePath fills each parameter of a route path with a random UUID
+func routePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = uuid.NewString()
+		}
+	}
+	return strings.Join(segments, "/")
+}
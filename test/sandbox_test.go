@@ -0,0 +1,219 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTrainingSandbox(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Tickets:  config.TicketsConfig{SandboxTTL: "1h"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db)})
+	sandboxService := services.NewSandboxService(repository.NewSandboxRepository(db), storage.NewLocalStorage(t.TempDir()), cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewSandboxHandler(sandboxService).RegisterRoutes(e, ami)
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, ami)
+
+	trainee, traineeTokens, err := authService.Register(&models.RegisterRequest{Email: "trainee@example.com", Password: "password123", FirstName: "New", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	_, colleagueTokens, err := authService.Register(&models.RegisterRequest{Email: "colleague@example.com", Password: "password123", FirstName: "Old", LastName: "Hand", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	requester, requesterTokens, err := authService.Register(&models.RegisterRequest{Email: "requester@example.com", Password: "password123", FirstName: "Real", LastName: "Customer", Role: models.RoleEndUser})
+	require.NoError(t, err)
+
+	real, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Real customer issue", Description: "x", Priority: models.PriorityMedium}, requester.User.ID)
+	require.NoError(t, err)
+
+	send := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(""))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	titles := func(token string) []string {
+		rec := send(token, http.MethodGet, "/api/v1/tickets")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var page models.TicketListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		var titles []string
+		for _, ticket := range page.Tickets {
+			titles = append(titles, ticket.Title)
+		}
+		return titles
+	}
+
+	t.Run("OnlyAgents", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, send(requesterTokens.AccessToken, http.MethodPost, "/api/v1/sandbox").Code)
+		assert.Equal(t, http.StatusNotFound, send(traineeTokens.AccessToken, http.MethodGet, "/api/v1/sandbox").Code)
+	})
+
+	var sandbox models.Sandbox
+	t.Run("Start", func(t *testing.T) {
+		rec := send(traineeTokens.AccessToken, http.MethodPost, "/api/v1/sandbox")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sandbox))
+		assert.Equal(t, trainee.User.ID, sandbox.AgentID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), sandbox.ExpiresAt, time.Minute)
+
+		assert.Equal(t, http.StatusConflict, send(traineeTokens.AccessToken, http.MethodPost, "/api/v1/sandbox").Code)
+		assert.Equal(t, http.StatusOK, send(traineeTokens.AccessToken, http.MethodGet, "/api/v1/sandbox").Code)
+	})
+
+	t.Run("Isolated", func(t *testing.T) {
+		practice := titles(traineeTokens.AccessToken)
+		assert.Len(t, practice, sandbox.Tickets)
+		assert.NotContains(t, practice, real.Title)
+		assert.Equal(t, []string{real.Title}, titles(colleagueTokens.AccessToken))
+
+		scoped := repository.WithSandbox(ctx, sandbox.ID)
+		_, err := ticketService.GetTicket(scoped, real.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		demo, err := ticketService.ListTickets(scoped, &models.TicketQuery{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		require.NotEmpty(t, demo.Tickets)
+		_, err = ticketService.GetTicket(ctx, demo.Tickets[0].ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Equal(t, http.StatusOK, send(traineeTokens.AccessToken, http.MethodGet, "/api/v1/tickets/"+demo.Tickets[0].ID.String()).Code)
+
+		users, err := userRepo.List(20, 0)
+		require.NoError(t, err)
+		assert.Len(t, users, 3, "demo requesters are hidden from user lists")
+	})
+
+	t.Run("NoSharedCaches", func(t *testing.T) {
+		appCache := cache.NewMemory()
+		search := services.NewSearchService(repository.NewSearchRepository(db), appCache)
+		newReports := func(appCache cache.Cache) *services.ReportService {
+			return services.NewReportService(repository.NewReportRepository(db), repository.NewCategoryRepository(db), repository.NewChatRepository(db), services.NewPresenceService(appCache, userRepo, cfg), appCache, cfg)
+		}
+		reports := newReports(appCache)
+		scoped := repository.WithSandbox(ctx, sandbox.ID)
+
+		suggested := func(ctx context.Context) []string {
+			suggestions, err := search.Suggest(ctx, "Real", 5, false)
+			require.NoError(t, err)
+			var labels []string
+			for _, ticket := range suggestions.Tickets {
+				labels = append(labels, ticket.Label)
+			}
+			return labels
+		}
+		// Whichever caller fills the caches first, the other gets its own results
+		assert.Contains(t, suggested(ctx), real.Title)
+		assert.NotContains(t, suggested(scoped), real.Title, "real tickets are not suggested from the cache in a sandbox")
+
+		for _, caller := range []context.Context{ctx, scoped, ctx} {
+			uncached := newReports(cache.NewMemory())
+			queue, err := reports.QueueSnapshot(caller)
+			require.NoError(t, err)
+			want, err := uncached.QueueSnapshot(caller)
+			require.NoError(t, err)
+			assert.Equal(t, want.QueueDepth, queue.QueueDepth)
+
+			dashboard, err := reports.Dashboard(caller)
+			require.NoError(t, err)
+			wantDashboard, err := uncached.Dashboard(caller)
+			require.NoError(t, err)
+			assert.Equal(t, wantDashboard.OpenByPriority, dashboard.OpenByPriority)
+			assert.Equal(t, wantDashboard.CreatedToday, dashboard.CreatedToday)
+		}
+
+		realStats, err := ticketRepo.GetStats(ctx)
+		require.NoError(t, err)
+		sandboxStats, err := ticketRepo.GetStats(scoped)
+		require.NoError(t, err)
+		require.NotEqual(t, realStats.TotalTickets, sandboxStats.TotalTickets)
+
+		// Concurrent statistics calls are only coalesced within a scope
+		var wg sync.WaitGroup
+		for i := range 20 {
+			caller, want := ctx, realStats.TotalTickets
+			if i%2 == 0 {
+				caller, want = scoped, sandboxStats.TotalTickets
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stats, err := ticketService.GetTicketStats(caller)
+				if assert.NoError(t, err) {
+					assert.Equal(t, want, stats.TotalTickets)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("CreatedTicketsStayInSandbox", func(t *testing.T) {
+		scoped := repository.WithSandbox(ctx, sandbox.ID)
+		practice, err := ticketService.CreateTicket(scoped, &models.CreateTicketRequest{Title: "Practice ticket", Description: "x", Priority: models.PriorityLow}, trainee.User.ID)
+		require.NoError(t, err)
+		require.NotNil(t, practice.SandboxID)
+		assert.Contains(t, titles(traineeTokens.AccessToken), "Practice ticket")
+		assert.NotContains(t, titles(colleagueTokens.AccessToken), "Practice ticket")
+	})
+
+	t.Run("End", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, send(traineeTokens.AccessToken, http.MethodDelete, "/api/v1/sandbox").Code)
+		assert.Equal(t, http.StatusNotFound, send(traineeTokens.AccessToken, http.MethodGet, "/api/v1/sandbox").Code)
+		assert.Equal(t, []string{real.Title}, titles(traineeTokens.AccessToken))
+
+		var tickets, demoUsers int64
+		require.NoError(t, db.DB.Model(&models.Ticket{}).Where("sandbox_id IS NOT NULL").Count(&tickets).Error)
+		require.NoError(t, db.DB.Model(&models.User{}).Where("demo = ?", true).Count(&demoUsers).Error)
+		assert.Zero(t, tickets)
+		assert.Zero(t, demoUsers)
+	})
+
+	t.Run("ExpiredSandboxesAreRemoved", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, send(traineeTokens.AccessToken, http.MethodPost, "/api/v1/sandbox").Code)
+		require.NoError(t, db.DB.Model(&models.Sandbox{}).Where("agent_id = ?", trainee.User.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		require.NoError(t, sandboxService.RemoveExpired(ctx))
+		assert.Equal(t, http.StatusNotFound, send(traineeTokens.AccessToken, http.MethodGet, "/api/v1/sandbox").Code)
+		assert.Equal(t, []string{real.Title}, titles(traineeTokens.AccessToken))
+
+		agent, err := userRepo.GetByID(trainee.User.ID.String())
+		require.NoError(t, err)
+		assert.Nil(t, agent.SandboxID)
+	})
+}
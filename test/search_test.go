@@ -0,0 +1,246 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchSuggestions(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+	assert.NoError(t, database.CreateIndexes(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	knowledgeRepo := repository.NewKnowledgeRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, categoryRepo, repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+	searchService := services.NewSearchService(repository.NewSearchRepository(db), cache.NewMemory())
+
+	e := echo.New()
+	handlers.NewSearchHandler(searchService, ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	agent, agentTokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	assert.NoError(t, err)
+	_, requesterTokens, err := authService.Register(&models.RegisterRequest{Email: "priya@example.com", Password: "password123", FirstName: "Priya", LastName: "Patel"})
+	assert.NoError(t, err)
+
+	for _, title := range []string{"Printer jammed", "Laptop printer driver", "VPN drops"} {
+		assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: title, Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: agent.User.ID}))
+	}
+	assert.NoError(t, categoryRepo.Create(ctx, &models.Category{Name: "Printing", IsActive: true}))
+	assert.NoError(t, knowledgeRepo.Create(ctx, &models.KnowledgeArticle{Title: "Printer setup", Body: "x", IsPublished: true, AuthorID: agent.User.ID}))
+	assert.NoError(t, knowledgeRepo.Create(ctx, &models.KnowledgeArticle{Title: "Printer fleet rollout", Body: "x", AuthorID: agent.User.ID}))
+
+	suggest := func(query, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/search/suggest?"+query, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	labels := func(suggestions []models.SearchSuggestion) []string {
+		result := make([]string, len(suggestions))
+		for i, suggestion := range suggestions {
+			result[i] = suggestion.Label
+		}
+		return result
+	}
+
+	t.Run("MatchesEachKind", func(t *testing.T) {
+		rec := suggest("q=PRI", agentTokens.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var suggestions models.SearchSuggestions
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &suggestions))
+
+		// Titles starting with the prefix come before ones with a later word matching
+		assert.Equal(t, []string{"Printer jammed", "Laptop printer driver"}, labels(suggestions.Tickets))
+		assert.Equal(t, []string{"Priya Patel"}, labels(suggestions.Requesters))
+		assert.Equal(t, "priya@example.com", suggestions.Requesters[0].Detail)
+		assert.Equal(t, []string{"Printing"}, labels(suggestions.Categories))
+		assert.ElementsMatch(t, []string{"Printer setup", "Printer fleet rollout"}, labels(suggestions.Articles), "agents see drafts")
+	})
+
+	t.Run("ShortAndWildcardPrefixes", func(t *testing.T) {
+		for _, query := range []string{"q=p", "q=%25%25", "q=__"} {
+			rec := suggest(query, agentTokens.AccessToken)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var suggestions models.SearchSuggestions
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &suggestions))
+			assert.Empty(t, suggestions.Tickets, query)
+			assert.Empty(t, suggestions.Requesters, query)
+			assert.Empty(t, suggestions.Articles, query)
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: "Printer toner", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: agent.User.ID}))
+
+		var suggestions models.SearchSuggestions
+		assert.NoError(t, json.Unmarshal(suggest("q=pri", agentTokens.AccessToken).Body.Bytes(), &suggestions))
+		assert.Len(t, suggestions.Tickets, 2, "a repeated prefix is served from the cache")
+		assert.Equal(t, "pri", suggestions.Query)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, suggest("q=pri&limit=0", agentTokens.AccessToken).Code)
+		assert.Equal(t, http.StatusBadRequest, suggest("q=pri&limit=50", agentTokens.AccessToken).Code)
+		assert.Equal(t, http.StatusForbidden, suggest("q=pri", requesterTokens.AccessToken).Code)
+	})
+}
+
+func TestQuickLookup(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db)})
+	searchService := services.NewSearchService(repository.NewSearchRepository(db), cache.NewMemory())
+
+	e := echo.New()
+	handlers.NewSearchHandler(searchService, ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	register := func(email string, role models.UserRole) (*models.User, string) {
+		auth, tokens, err := authService.Register(&models.RegisterRequest{Email: email, Password: "password123", FirstName: "Test", LastName: "User", Role: role})
+		assert.NoError(t, err)
+		return auth.User, tokens.AccessToken
+	}
+	_, agentToken := register("agent@example.com", models.RoleSupportAgent)
+	manager, managerToken := register("manager@example.com", models.RoleManager)
+	requester, requesterToken := register("priya@example.com", models.RoleEndUser)
+
+	teamService := services.NewTeamService(teamRepo, userRepo)
+	network, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Network"})
+	assert.NoError(t, err)
+	_, err = teamService.AddMember(ctx, network.ID, manager.ID)
+	assert.NoError(t, err)
+
+	create := func(title string, teamID *uuid.UUID) *models.Ticket {
+		ticket := &models.Ticket{Title: title, Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID, TeamID: teamID}
+		assert.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	vpn := create("VPN drops", &network.ID)
+	printer := create("Printer jammed", nil)
+
+	lookup := func(q, token string) (*httptest.ResponseRecorder, models.LookupResult) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/lookup?q="+url.QueryEscape(q), nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var result models.LookupResult
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		}
+		return rec, result
+	}
+	ids := func(suggestions []models.SearchSuggestion) []uuid.UUID {
+		result := make([]uuid.UUID, len(suggestions))
+		for i, suggestion := range suggestions {
+			result[i] = suggestion.ID
+		}
+		return result
+	}
+
+	t.Run("TicketReferences", func(t *testing.T) {
+		reference := strings.ToUpper(printer.ID.String()[:8])
+		for _, q := range []string{"#" + reference, strings.ToLower(reference), printer.ID.String()} {
+			rec, result := lookup(q, agentToken)
+			assert.Equal(t, http.StatusOK, rec.Code, q)
+			assert.Equal(t, []uuid.UUID{printer.ID}, ids(result.Tickets), q)
+			assert.Nil(t, result.User, q)
+		}
+
+		_, result := lookup("#00000000", agentToken)
+		assert.Empty(t, result.Tickets)
+	})
+
+	t.Run("EmailAddresses", func(t *testing.T) {
+		rec, result := lookup("Priya@Example.com", agentToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		if assert.NotNil(t, result.User) {
+			assert.Equal(t, requester.ID, result.User.ID)
+			assert.Equal(t, "priya@example.com", result.User.Detail)
+		}
+		assert.Equal(t, []uuid.UUID{printer.ID, vpn.ID}, ids(result.Tickets), "latest tickets first")
+
+		_, result = lookup("nobody@example.com", agentToken)
+		assert.Nil(t, result.User)
+		assert.Empty(t, result.Tickets)
+	})
+
+	t.Run("ScopedToTheCaller", func(t *testing.T) {
+		// Managers only find their teams' tickets
+		_, result := lookup("priya@example.com", managerToken)
+		assert.Equal(t, []uuid.UUID{vpn.ID}, ids(result.Tickets))
+		_, result = lookup(printer.ID.String(), managerToken)
+		assert.Empty(t, result.Tickets)
+
+		// Staff accounts are only found by those who manage users
+		_, result = lookup("manager@example.com", agentToken)
+		assert.Nil(t, result.User)
+		_, result = lookup("agent@example.com", managerToken)
+		assert.NotNil(t, result.User)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rec, _ := lookup("printer", agentToken)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec, _ = lookup("Priya <priya@example.com>", agentToken)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec, _ = lookup("priya@example.com", requesterToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/signedtoken"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedToken(t *testing.T) {
+	signer := signedtoken.NewSigner("secret", "purpose")
+	now := time.Now()
+
+	token := signer.Sign([]byte("payload"), now.Add(time.Hour))
+
+	// Valid token round-trips its payload
+	payload, _, err := signer.Verify(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+
+	// Tokens are case-insensitive so they survive email address normalisation
+	_, _, err = signer.Verify(strings.ToUpper(token), now)
+	assert.NoError(t, err)
+
+	// Expired tokens are rejected
+	_, _, err = signer.Verify(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, signedtoken.ErrExpiredToken)
+
+	// Tokens signed for another purpose are rejected
+	_, _, err = signedtoken.NewSigner("secret", "other").Verify(token, now)
+	assert.ErrorIs(t, err, signedtoken.ErrInvalidToken)
+
+	// Tampered tokens are rejected
+	tampered := []byte(token)
+	if tampered[0] == 'a' {
+		tampered[0] = 'b'
+	} else {
+		tampered[0] = 'a'
+	}
+	_, _, err = signer.Verify(string(tampered), now)
+	assert.ErrorIs(t, err, signedtoken.ErrInvalidToken)
+}
+
+func TestTicketShareLinks(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Share: config.ShareConfig{
+			BaseURL:    "https://help.example.com/",
+			SecretKey:  "test-share-secret",
+			DefaultTTL: "1h",
+			MaxTTL:     "24h",
+		},
+		Security: config.SecurityConfig{
+			TokenMaxFailures: 3,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	shareService := services.NewShareService(ticketRepo, commentRepo, cfg)
+
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser}
+	stranger := &models.User{Email: "stranger@example.com", PasswordHash: "x", FirstName: "Str", LastName: "User", Role: models.RoleEndUser}
+	assert.NoError(t, userRepo.Create(requester))
+	assert.NoError(t, userRepo.Create(stranger))
+
+	ticket := &models.Ticket{Title: "Printer on fire", Description: "Help", Status: models.StatusOpen, Priority: models.PriorityHigh, CreatedByID: requester.ID}
+	assert.NoError(t, ticketRepo.Create(ctx, ticket))
+	assert.NoError(t, commentRepo.Create(ctx, &models.Comment{TicketID: ticket.ID, UserID: requester.ID, Content: "public update"}))
+	assert.NoError(t, commentRepo.Create(ctx, &models.Comment{TicketID: ticket.ID, UserID: requester.ID, Content: "internal note", IsInternal: true}))
+
+	t.Run("RequesterCanShare", func(t *testing.T) {
+		share, err := shareService.CreateShareLink(ctx, ticket.ID, requester, &models.ShareTicketRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://help.example.com"+services.SharedTicketPath+share.Token, share.URL)
+
+		view, err := shareService.GetSharedTicket(ctx, share.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, "Printer on fire", view.Title)
+		assert.Len(t, view.Comments, 1, "internal comments must not be shared")
+		assert.Equal(t, "public update", view.Comments[0].Content)
+	})
+
+	t.Run("StrangerCannotShare", func(t *testing.T) {
+		_, err := shareService.CreateShareLink(ctx, ticket.ID, stranger, &models.ShareTicketRequest{})
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("TTLAboveMaximumRejected", func(t *testing.T) {
+		_, err := shareService.CreateShareLink(ctx, ticket.ID, requester, &models.ShareTicketRequest{ExpiresIn: "48h"})
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("InvalidTokenRejected", func(t *testing.T) {
+		_, err := shareService.GetSharedTicket(ctx, "not-a-token")
+		assert.ErrorIs(t, err, services.ErrShareLinkInvalid)
+	})
+
+	t.Run("BadTokensLockedOut", func(t *testing.T) {
+		auditRepo := repository.NewAuditLogRepository(db)
+		e := echo.New()
+		e.IPExtractor = echo.ExtractIPDirect()
+		handlers.NewShareHandler(shareService, testMiddleware.NewTokenLockout(services.NewAuditService(auditRepo, nil), cfg)).RegisterRoutes(e, nil)
+
+		share, err := shareService.CreateShareLink(ctx, ticket.ID, requester, &models.ShareTicketRequest{})
+		assert.NoError(t, err)
+		view := func(token, remoteAddr string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, services.SharedTicketPath+token, nil)
+			req.RemoteAddr = remoteAddr
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Guessing many tokens from one address blocks the address
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusNotFound, view("guess-"+strings.Repeat("x", i), "198.51.100.1:1000").Code)
+		}
+		rec := view(share.Token, "198.51.100.1:1000")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code, "even a valid token is refused while blocked")
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+		assert.Equal(t, http.StatusOK, view(share.Token, "198.51.100.2:1000").Code)
+
+		// Trying one token from many addresses blocks the token
+		for i := 3; i < 6; i++ {
+			assert.Equal(t, http.StatusNotFound, view("tampered", fmt.Sprintf("203.0.113.%d:1000", i)).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, view("tampered", "203.0.113.9:1000").Code)
+
+		entries, err := auditRepo.List(ctx, &models.AuditLogQuery{Action: models.AuditActionTokenLockout, Page: 1, PageSize: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), entries.Total)
+		for _, entry := range entries.Entries {
+			assert.Equal(t, models.AuditEntityShareLink, entry.EntityType)
+			assert.NotContains(t, entry.Details, "tampered", "tokens are only stored as fingerprints")
+		}
+	})
+}
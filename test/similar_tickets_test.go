@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarTickets(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	requester := newUser("requester@example.com", models.RoleEndUser)
+	other := newUser("other@example.com", models.RoleEndUser)
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+
+	newTicket := func(createdBy *models.User, title, description string, status models.TicketStatus) *models.Ticket {
+		ticket := &models.Ticket{Title: title, Description: description, Status: status, Priority: models.PriorityMedium, CreatedByID: createdBy.ID}
+		require.NoError(t, ticketRepo.Create(ctx, ticket))
+		return ticket
+	}
+	vpn := newTicket(requester, "VPN keeps disconnecting", "The VPN client drops every few minutes", models.StatusOpen)
+	printer := newTicket(requester, "Printer jammed", "Paper stuck in the second floor printer, the VPN is fine", models.StatusInProgress)
+	newTicket(requester, "VPN certificate expired", "Could not connect to the VPN", models.StatusResolved)
+	othersVPN := newTicket(other, "VPN disconnecting at home", "My VPN disconnects", models.StatusOpen)
+
+	find := func(req *models.SimilarTicketsRequest, user *models.User) []models.SimilarTicket {
+		t.Helper()
+		response, err := ticketService.FindSimilarTickets(ctx, req, user)
+		require.NoError(t, err)
+		return response.Tickets
+	}
+	ids := func(tickets []models.SimilarTicket) []uuid.UUID {
+		list := []uuid.UUID{}
+		for _, ticket := range tickets {
+			list = append(list, ticket.ID)
+		}
+		return list
+	}
+
+	t.Run("RequesterOpenTicketsClosestFirst", func(t *testing.T) {
+		tickets := find(&models.SimilarTicketsRequest{Title: "VPN disconnecting", Description: "my vpn drops"}, requester)
+		assert.Equal(t, []uuid.UUID{vpn.ID, printer.ID}, ids(tickets), "resolved and other users' tickets are left out")
+		assert.True(t, tickets[0].Own)
+		assert.Greater(t, tickets[0].Score, tickets[1].Score)
+	})
+
+	t.Run("IncludeOthers", func(t *testing.T) {
+		tickets := find(&models.SimilarTicketsRequest{Title: "VPN disconnecting", IncludeOthers: true}, agent)
+		assert.ElementsMatch(t, []uuid.UUID{vpn.ID, printer.ID, othersVPN.ID}, ids(tickets))
+		for _, ticket := range tickets {
+			assert.False(t, ticket.Own, "the agent owns none of them")
+		}
+
+		_, err := ticketService.FindSimilarTickets(ctx, &models.SimilarTicketsRequest{Title: "VPN", IncludeOthers: true}, requester)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("AgentChecksRequesterTickets", func(t *testing.T) {
+		tickets := find(&models.SimilarTicketsRequest{Title: "VPN disconnecting", RequesterID: &other.ID}, agent)
+		assert.Equal(t, []uuid.UUID{othersVPN.ID}, ids(tickets))
+		assert.True(t, tickets[0].Own)
+
+		_, err := ticketService.FindSimilarTickets(ctx, &models.SimilarTicketsRequest{Title: "VPN", RequesterID: &other.ID}, requester)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("LimitAndNoiseWords", func(t *testing.T) {
+		assert.Len(t, find(&models.SimilarTicketsRequest{Title: "VPN", Limit: 1}, requester), 1)
+		assert.Empty(t, find(&models.SimilarTicketsRequest{Title: "Please help, it is still a problem"}, requester))
+		// Query syntax in a draft is searched for as plain words
+		assert.Equal(t, []uuid.UUID{printer.ID}, ids(find(&models.SimilarTicketsRequest{Title: `printer* AND "jammed" NEAR(paper) OR -`}, requester)))
+	})
+
+	t.Run("IndexFollowsUpdates", func(t *testing.T) {
+		updated := *printer
+		updated.Title = "Scanner jammed"
+		updated.Description = "Paper stuck in the scanner"
+		require.NoError(t, ticketRepo.Update(ctx, &updated))
+
+		assert.Empty(t, find(&models.SimilarTicketsRequest{Title: "printer"}, requester), "the expired version is not listed")
+		tickets := find(&models.SimilarTicketsRequest{Title: "scanner"}, requester)
+		require.Len(t, tickets, 1)
+		assert.Equal(t, "Scanner jammed", tickets[0].Title)
+	})
+
+	t.Run("RebuiltWhenTriggersAreMissing", func(t *testing.T) {
+		require.NoError(t, db.DB.Exec("DROP TRIGGER tickets_fts_insert").Error)
+		keyboard := newTicket(requester, "Keyboard missing keys", "Several keys fell off", models.StatusOpen)
+		assert.Empty(t, find(&models.SimilarTicketsRequest{Title: "keyboard"}, requester))
+
+		require.NoError(t, database.RunMigrations(db))
+		assert.Equal(t, []uuid.UUID{keyboard.ID}, ids(find(&models.SimilarTicketsRequest{Title: "keyboard"}, requester)))
+	})
+}
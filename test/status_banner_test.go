@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusBanners(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	categoryRepo := repository.NewCategoryRepository(db)
+	sender := &recordingSender{}
+	bannerService := services.NewStatusBannerService(repository.NewStatusBannerRepository(db), categoryRepo, repository.NewProblemRepository(db), sender)
+
+	emailCategory := &models.Category{Name: "Email", IsActive: true}
+	printingCategory := &models.Category{Name: "Printing", IsActive: true}
+	assert.NoError(t, categoryRepo.Create(ctx, emailCategory))
+	assert.NoError(t, categoryRepo.Create(ctx, printingCategory))
+	adminID := uuid.New()
+
+	outage, err := bannerService.CreateBanner(ctx, &models.CreateStatusBannerRequest{
+		Message:     "Email outage - known issue",
+		Severity:    models.BannerCritical,
+		CategoryIDs: []uuid.UUID{emailCategory.ID},
+	}, adminID)
+	assert.NoError(t, err)
+	assert.Len(t, outage.Categories, 1)
+
+	future := time.Now().Add(24 * time.Hour)
+	scheduled, err := bannerService.CreateBanner(ctx, &models.CreateStatusBannerRequest{
+		Message:  "Planned maintenance",
+		Severity: models.BannerInfo,
+		StartsAt: &future,
+	}, adminID)
+	assert.NoError(t, err)
+
+	t.Run("InvalidWindowRejected", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		_, err := bannerService.CreateBanner(ctx, &models.CreateStatusBannerRequest{Message: "x", Severity: models.BannerInfo, EndsAt: &past}, adminID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+	})
+
+	t.Run("ActiveBannersFilteredByCategory", func(t *testing.T) {
+		banners, err := bannerService.ActiveBanners(ctx, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, banners, 1) {
+			assert.Equal(t, outage.ID, banners[0].ID)
+		}
+
+		banners, err = bannerService.ActiveBanners(ctx, &emailCategory.ID)
+		assert.NoError(t, err)
+		assert.Len(t, banners, 1)
+
+		banners, err = bannerService.ActiveBanners(ctx, &printingCategory.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, banners)
+	})
+
+	t.Run("SubscribeOnlyToActiveIssues", func(t *testing.T) {
+		assert.NoError(t, bannerService.Subscribe(ctx, outage.ID, &models.SubscribeBannerRequest{Email: "User@Example.com"}))
+		assert.NoError(t, bannerService.Subscribe(ctx, outage.ID, &models.SubscribeBannerRequest{Email: "user@example.com"}), "subscribing twice is a no-op")
+		assert.NoError(t, bannerService.Subscribe(ctx, outage.ID, &models.SubscribeBannerRequest{Email: "other@example.com"}))
+
+		err := bannerService.Subscribe(ctx, scheduled.ID, &models.SubscribeBannerRequest{Email: "user@example.com"})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("SignedInUserSubscribesWithAccountAddress", func(t *testing.T) {
+		user := &models.User{Email: "user@example.com", Role: models.RoleEndUser, IsActive: true}
+		assert.NoError(t, bannerService.SubscribeUser(ctx, outage.ID, user), "already subscribed by address")
+
+		err := bannerService.SubscribeUser(ctx, scheduled.ID, user)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+
+	t.Run("SubscriberCountsGaugeImpact", func(t *testing.T) {
+		banner, err := bannerService.GetBanner(ctx, outage.ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, banner.SubscriberCount)
+
+		banners, err := bannerService.ListBanners(ctx)
+		assert.NoError(t, err)
+		counts := map[uuid.UUID]int64{}
+		for _, b := range banners {
+			counts[b.ID] = b.SubscriberCount
+		}
+		assert.EqualValues(t, 2, counts[outage.ID])
+		assert.EqualValues(t, 0, counts[scheduled.ID])
+	})
+
+	t.Run("ResolveNotifiesEachSubscriber", func(t *testing.T) {
+		banner, err := bannerService.ResolveBanner(ctx, outage.ID, &models.ResolveStatusBannerRequest{Resolution: "Mail flow restored"})
+		assert.NoError(t, err)
+		assert.NotNil(t, banner.ResolvedAt)
+
+		if assert.Len(t, sender.messages, 2) {
+			var recipients []string
+			for _, msg := range sender.messages {
+				assert.Len(t, msg.To, 1)
+				assert.Contains(t, msg.Text, "Mail flow restored")
+				recipients = append(recipients, msg.To...)
+			}
+			assert.ElementsMatch(t, []string{"user@example.com", "other@example.com"}, recipients)
+		}
+
+		banners, err := bannerService.ActiveBanners(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, banners)
+
+		_, err = bannerService.ResolveBanner(ctx, outage.ID, &models.ResolveStatusBannerRequest{})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+}
@@ -0,0 +1,192 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamScopedTicketVisibility(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	teamService := services.NewTeamService(teamRepo, userRepo)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	assert.NoError(t, err)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role}
+		assert.NoError(t, userRepo.Create(user))
+		return user
+	}
+	manager := newUser("manager@example.com", models.RoleManager)
+	loneManager := newUser("lone@example.com", models.RoleManager)
+	admin := newUser("admin@example.com", models.RoleAdministrator)
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+	requester := newUser("requester@example.com", models.RoleEndUser)
+
+	network, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Network"})
+	assert.NoError(t, err)
+	billing, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Billing"})
+	assert.NoError(t, err)
+	_, err = teamService.AddMember(ctx, network.ID, manager.ID)
+	assert.NoError(t, err)
+	team, err := teamService.AddMember(ctx, network.ID, agent.ID)
+	assert.NoError(t, err)
+	assert.Len(t, team.Members, 2)
+
+	_, err = teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Network"})
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	create := func(title string, teamID *models.Team) *models.Ticket {
+		req := &models.CreateTicketRequest{Title: title, Description: "x", Priority: models.PriorityLow}
+		if teamID != nil {
+			req.TeamID = &teamID.ID
+		}
+		ticket, err := ticketService.CreateTicket(ctx, req, requester.ID)
+		assert.NoError(t, err)
+		return ticket
+	}
+	create("Router down", network)
+	assigned := create("VPN slow", nil)
+	create("Invoice wrong", billing)
+	create("Unrouted", nil)
+	assert.NoError(t, ticketRepo.AssignToAgent(ctx, &models.TicketAssignment{TicketID: assigned.ID, AgentID: &agent.ID, AssignedByID: agent.ID}))
+
+	list := func(user *models.User) []string {
+		scoped, err := ticketService.ScopeTickets(ctx, user)
+		assert.NoError(t, err)
+		result, err := ticketService.ListTickets(scoped, &models.TicketQuery{Page: 1, PageSize: 20})
+		assert.NoError(t, err)
+		var titles []string
+		for _, ticket := range result.Tickets {
+			titles = append(titles, ticket.Title)
+		}
+		return titles
+	}
+
+	t.Run("ManagerSeesTeamTickets", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Router down", "VPN slow"}, list(manager))
+
+		scoped, err := ticketService.ScopeTickets(ctx, manager)
+		assert.NoError(t, err)
+		stats, err := ticketService.GetTicketStats(scoped)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), stats.TotalTickets)
+	})
+
+	t.Run("ManagerWithoutTeamsSeesNothing", func(t *testing.T) {
+		assert.Empty(t, list(loneManager))
+	})
+
+	t.Run("GlobalPermissionSeesEverything", func(t *testing.T) {
+		assert.Len(t, list(admin), 4)
+		assert.Len(t, list(agent), 4)
+	})
+
+	t.Run("UnknownTeamRejected", func(t *testing.T) {
+		_, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "x", Description: "x", Priority: models.PriorityLow, TeamID: &requester.ID}, requester.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("RemovingMembershipNarrowsScope", func(t *testing.T) {
+		assert.NoError(t, teamService.RemoveMember(ctx, network.ID, agent.ID))
+		assert.ElementsMatch(t, []string{"Router down"}, list(manager))
+	})
+}
+
+// slowStatsRepository holds GetStats calls until released and counts them
+type slowStatsRepository struct {
+	repository.TicketRepository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *slowStatsRepository) GetStats(ctx context.Context) (*models.TicketStats, error) {
+	r.calls.Add(1)
+	<-r.release
+	return r.TicketRepository.GetStats(ctx)
+}
+
+func TestTicketStatsCoalescing(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	assert.NoError(t, repository.NewUserRepository(db).Create(requester))
+	ticketRepo := repository.NewTicketRepository(db)
+	assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: "Printer", Description: "Jammed", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: requester.ID}))
+
+	repo := &slowStatsRepository{TicketRepository: ticketRepo, release: make(chan struct{})}
+	ticketService := services.NewTicketService(repo, nil, nil, nil, nil, cfg, services.TicketServiceDeps{})
+	teamCtx := repository.WithTicketScope(ctx, &repository.TicketScope{TeamIDs: []uuid.UUID{uuid.New()}})
+
+	var wg sync.WaitGroup
+	results := make([]*models.TicketStats, 6)
+	for i := range results {
+		scoped := ctx
+		if i == len(results)-1 {
+			scoped = teamCtx
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats, err := ticketService.GetTicketStats(scoped)
+			assert.NoError(t, err)
+			results[i] = stats
+		}()
+	}
+
+	// Give every caller time to join its scope's in-flight call
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), repo.calls.Load(), "one query per ticket scope")
+	for _, stats := range results[:len(results)-1] {
+		assert.Equal(t, int64(1), stats.OpenTickets)
+	}
+	assert.Equal(t, int64(0), results[len(results)-1].OpenTickets)
+
+	t.Run("CallerGivesUp", func(t *testing.T) {
+		repo.release = make(chan struct{})
+		defer close(repo.release)
+		cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := ticketService.GetTicketStats(cancelled)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketConfirmation(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com", ReplyDomain: "reply.helpchat.com", ReplySecretKey: "reply-secret"},
+		Share:    config.ShareConfig{BaseURL: "https://support.example.com"},
+		Tickets:  config.TicketsConfig{ResponseTargets: []string{"CRITICAL=1h", "high=4h", "MEDIUM=soon"}},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, Notifier: emailChannel, Redaction: redaction})
+
+	requester := &models.User{Email: "jordan@example.com", PasswordHash: "x", FirstName: "Jordan", LastName: "Reyes", Role: models.RoleEndUser, IsActive: true}
+	require.NoError(t, userRepo.Create(requester))
+
+	create := func(priority models.TicketPriority, description string) string {
+		sender.messages = nil
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Laptop will not boot", Description: description, Priority: priority}, requester.ID)
+		require.NoError(t, err)
+		require.Len(t, sender.messages, 1)
+		msg := sender.messages[0]
+		assert.Equal(t, []string{"jordan@example.com"}, msg.To)
+		assert.Equal(t, "[#"+strings.ToUpper(ticket.ID.String()[:8])+"] Laptop will not boot", msg.Subject)
+		assert.True(t, strings.HasPrefix(msg.ReplyTo, "reply+"), msg.ReplyTo)
+		assert.Contains(t, msg.Text, "https://support.example.com"+services.TicketPath+ticket.ID.String())
+		return msg.Text
+	}
+
+	t.Run("QuotesTheResponseTarget", func(t *testing.T) {
+		text := create(models.PriorityHigh, "The screen stays black after the logo.")
+		assert.Contains(t, text, "Hi Jordan,")
+		assert.Contains(t, text, "Priority: HIGH")
+		assert.Contains(t, text, "Summary:\nThe screen stays black after the logo.")
+		assert.Contains(t, text, "Expected response: within 4 hours")
+
+		assert.Contains(t, create(models.PriorityCritical, "x"), "Expected response: within 1 hour")
+	})
+
+	t.Run("PrioritiesWithoutATargetPromiseNoTime", func(t *testing.T) {
+		// MEDIUM has an invalid target and LOW none
+		assert.Contains(t, create(models.PriorityMedium, "x"), "Expected response: as soon as possible")
+		assert.Contains(t, create(models.PriorityLow, "x"), "Expected response: as soon as possible")
+	})
+
+	t.Run("ShortensLongDescriptions", func(t *testing.T) {
+		text := create(models.PriorityLow, strings.Repeat("ä", 1200))
+		assert.Contains(t, text, strings.Repeat("ä", 1000)+"…")
+		assert.NotContains(t, text, strings.Repeat("ä", 1001))
+	})
+}
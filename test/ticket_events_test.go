@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/realtime"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketEvents(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	teamService := services.NewTeamService(teamRepo, userRepo)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	events := services.NewTicketEventService(realtime.NewHub(), teamRepo)
+	ticketService := services.NewTicketService(repository.NewTicketRepository(db), repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, teamRepo, cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), Events: events, Redaction: redaction})
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+	manager := newUser("manager@example.com", models.RoleManager)
+	loneManager := newUser("lone@example.com", models.RoleManager)
+	requester := newUser("requester@example.com", models.RoleEndUser)
+	bystander := newUser("bystander@example.com", models.RoleEndUser)
+
+	network, err := teamService.CreateTeam(ctx, &models.CreateTeamRequest{Name: "Network"})
+	require.NoError(t, err)
+	_, err = teamService.AddMember(ctx, network.ID, manager.ID)
+	require.NoError(t, err)
+
+	subscribe := func(user *models.User) *realtime.Subscription {
+		sub, err := events.Subscribe(ctx, user)
+		require.NoError(t, err)
+		t.Cleanup(sub.Close)
+		return sub
+	}
+	next := func(t *testing.T, sub *realtime.Subscription) *models.TicketEvent {
+		select {
+		case event := <-sub.Events():
+			return event.(*models.TicketEvent)
+		case <-time.After(time.Second):
+			t.Fatal("no ticket event received")
+			return nil
+		}
+	}
+	assertQuiet := func(t *testing.T, sub *realtime.Subscription) {
+		select {
+		case event := <-sub.Events():
+			t.Fatalf("unexpected ticket event %+v", event)
+		default:
+		}
+	}
+
+	t.Run("AgentsAndOwnersFollowATicket", func(t *testing.T) {
+		agentSub, managerSub, loneSub := subscribe(agent), subscribe(manager), subscribe(loneManager)
+		requesterSub, bystanderSub := subscribe(requester), subscribe(bystander)
+
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "VPN drops", Description: "x", Priority: models.PriorityHigh, TeamID: &network.ID}, requester.ID)
+		require.NoError(t, err)
+		for _, sub := range []*realtime.Subscription{agentSub, managerSub, requesterSub} {
+			event := next(t, sub)
+			assert.Equal(t, models.TicketEventCreated, event.Type)
+			assert.Equal(t, ticket.ID, event.TicketID)
+			assert.Equal(t, "VPN drops", event.Title)
+			assert.Equal(t, requester.ID, event.ActorID)
+		}
+
+		require.NoError(t, ticketService.AssignTicket(ctx, ticket.ID, agent.ID, manager.ID))
+		for _, sub := range []*realtime.Subscription{agentSub, managerSub, requesterSub} {
+			event := next(t, sub)
+			assert.Equal(t, models.TicketEventAssigned, event.Type)
+			if assert.NotNil(t, event.AssignedAgentID) {
+				assert.Equal(t, agent.ID, *event.AssignedAgentID)
+			}
+		}
+
+		require.NoError(t, ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: models.StatusInProgress}, agent.ID))
+		for _, sub := range []*realtime.Subscription{agentSub, managerSub, requesterSub} {
+			event := next(t, sub)
+			assert.Equal(t, models.TicketEventStatusChanged, event.Type)
+			assert.Equal(t, models.StatusInProgress, event.Status)
+		}
+
+		// Neither another requester nor a manager of no team hear about the ticket
+		assertQuiet(t, loneSub)
+		assertQuiet(t, bystanderSub)
+	})
+
+	t.Run("StreamsOverWebSocket", func(t *testing.T) {
+		e := echo.New()
+		e.GET("/ws", handlers.NewTicketEventHandler(events, cfg).Socket, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Set("user", requester)
+				return next(c)
+			}
+		})
+		server := httptest.NewServer(e)
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// The subscription is in place once the upgrade has completed
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Printer jammed", Description: "x", Priority: models.PriorityLow}, requester.ID)
+		require.NoError(t, err)
+
+		var event models.TicketEvent
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		require.NoError(t, conn.ReadJSON(&event))
+		assert.Equal(t, models.TicketEventCreated, event.Type)
+		assert.Equal(t, ticket.ID, event.TicketID)
+		assert.Equal(t, models.PriorityLow, event.Priority)
+	})
+}
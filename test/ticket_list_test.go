@@ -0,0 +1,314 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketListing(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	auth, tokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	assert.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: fmt.Sprintf("Ticket %d", i), Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: auth.User.ID}))
+	}
+
+	list := func(path string) (*models.TicketListResponse, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response models.TicketListResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return &response, rec
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		first, rec := list("/api/v1/tickets?page_size=2")
+		assert.Len(t, first.Tickets, 2)
+		assert.True(t, first.HasMore)
+		if assert.NotNil(t, first.Total) {
+			assert.Equal(t, int64(5), *first.Total)
+			assert.Equal(t, 3, *first.TotalPages)
+		}
+		assert.Equal(t, `</api/v1/tickets?page=2&page_size=2>; rel="next"`, rec.Header().Get("Link"))
+
+		middle, rec := list("/api/v1/tickets?page=2&page_size=2&status=OPEN")
+		assert.True(t, middle.HasMore)
+		assert.Equal(t, `</api/v1/tickets?page=3&page_size=2&status=OPEN>; rel="next", </api/v1/tickets?page=1&page_size=2&status=OPEN>; rel="prev"`, rec.Header().Get("Link"))
+
+		last, rec := list("/api/v1/tickets?page=3&page_size=2")
+		assert.Len(t, last.Tickets, 1)
+		assert.False(t, last.HasMore)
+		assert.Equal(t, `</api/v1/tickets?page=2&page_size=2>; rel="prev"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("CountOptOut", func(t *testing.T) {
+		page, rec := list("/api/v1/tickets?page_size=2&count=false")
+		assert.Len(t, page.Tickets, 2)
+		assert.True(t, page.HasMore)
+		assert.Nil(t, page.Total)
+		assert.Nil(t, page.TotalPages)
+		assert.NotContains(t, rec.Body.String(), `"total"`)
+
+		mine, _ := list("/api/v1/tickets/my?page_size=10&count=false")
+		assert.Len(t, mine.Tickets, 5)
+		assert.False(t, mine.HasMore)
+	})
+
+	t.Run("Sorting", func(t *testing.T) {
+		byTitle, _ := list("/api/v1/tickets?sort_field=title&sort_direction=desc")
+		assert.Equal(t, "Ticket 5", byTitle.Tickets[0].Title)
+
+		for _, priority := range []models.TicketPriority{models.PriorityCritical, models.PriorityHigh} {
+			assert.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: string(priority), Description: "x", Status: models.StatusOpen, Priority: priority, CreatedByID: auth.User.ID}))
+		}
+		bySeverity, _ := list("/api/v1/tickets?sort_field=priority&sort_direction=DESC")
+		assert.Equal(t, models.PriorityCritical, bySeverity.Tickets[0].Priority, "priorities order by severity, not alphabetically")
+		assert.Equal(t, models.PriorityHigh, bySeverity.Tickets[1].Priority)
+		assert.Equal(t, models.PriorityLow, bySeverity.Tickets[2].Priority)
+
+		// Ties on priority fall back to newest first
+		assert.Equal(t, "Ticket 5", bySeverity.Tickets[2].Title)
+
+		for _, query := range []string{"sort_field=title%3BDROP%20TABLE%20tickets", "sort_field=description", "sort_field=title&sort_direction=sideways"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tickets?"+query, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("SearchCommentsAndAttachments", func(t *testing.T) {
+		commentRepo := repository.NewCommentRepository(db)
+		attachmentRepo := repository.NewAttachmentRepository(db)
+		newTicket := func(title, description string) *models.Ticket {
+			ticket := &models.Ticket{Title: title, Description: description, Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: auth.User.ID}
+			assert.NoError(t, ticketRepo.Create(ctx, ticket))
+			return ticket
+		}
+		newTicket("Printer", "Needs a new Cartridge")
+		byComment := newTicket("Copier", "Streaks on every page")
+		byNote := newTicket("Scanner", "Lines across scans")
+		byFile := newTicket("Plotter", "Faded output")
+		comment := &models.Comment{TicketID: byComment.ID, UserID: auth.User.ID, Content: "Swapped the <black> cartridge on floor 2 and the streaks stopped"}
+		assert.NoError(t, commentRepo.Create(ctx, comment))
+		assert.NoError(t, commentRepo.Create(ctx, &models.Comment{TicketID: byNote.ID, UserID: auth.User.ID, Content: "Vendor cartridge recall", IsInternal: true}))
+		assert.NoError(t, attachmentRepo.Create(ctx, &models.Attachment{TicketID: byFile.ID, Filename: "cartridge-receipt.pdf", FilePath: "tickets/receipt.pdf", FileSize: 3, MimeType: "application/pdf", UploadedByID: auth.User.ID}))
+
+		titles := func(list *models.TicketListResponse) []string {
+			var found []string
+			for _, ticket := range list.Tickets {
+				found = append(found, ticket.Title)
+			}
+			return found
+		}
+
+		plain, _ := list("/api/v1/tickets?search=cartridge")
+		assert.ElementsMatch(t, []string{"Printer"}, titles(plain), "title and description by default")
+		if assert.Len(t, plain.Tickets[0].Matches, 1) {
+			assert.Equal(t, models.SearchInDescription, plain.Tickets[0].Matches[0].Field)
+			assert.Equal(t, "Needs a new <mark>Cartridge</mark>", plain.Tickets[0].Matches[0].Snippet)
+		}
+
+		everywhere, _ := list("/api/v1/tickets?search=cartridge&search_in=description,comments,attachments")
+		assert.ElementsMatch(t, []string{"Printer", "Copier", "Scanner", "Plotter"}, titles(everywhere), "agents search internal notes")
+		for _, ticket := range everywhere.Tickets {
+			if ticket.ID == byComment.ID && assert.Len(t, ticket.Matches, 1) {
+				assert.Equal(t, models.SearchInComments, ticket.Matches[0].Field)
+				assert.Equal(t, &comment.ID, ticket.Matches[0].SourceID)
+				assert.Equal(t, "Swapped the &lt;black&gt; <mark>cartridge</mark> on floor 2 and the streaks stopped", ticket.Matches[0].Snippet)
+			}
+			if ticket.ID == byFile.ID && assert.Len(t, ticket.Matches, 1) {
+				assert.Equal(t, "<mark>cartridge</mark>-receipt.pdf", ticket.Matches[0].Snippet)
+			}
+		}
+
+		// Viewers cannot read internal notes, so they are not searched for them
+		_, viewerTokens, err := authService.Register(&models.RegisterRequest{Email: "viewer@example.com", Password: "password123", FirstName: "Test", LastName: "Viewer", Role: models.RoleViewer})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tickets?search=cartridge&search_in=comments", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: viewerTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var viewed models.TicketListResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &viewed))
+		assert.ElementsMatch(t, []string{"Copier"}, titles(&viewed))
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/tickets?search=cartridge&search_in=title,notes", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("RecentlyViewed", func(t *testing.T) {
+		get := func(path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+		recent := func(path, token string) []models.Ticket {
+			rec := get(path, token)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var tickets []models.Ticket
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tickets))
+			return tickets
+		}
+
+		assert.Empty(t, recent("/api/v1/tickets/recent", tokens.AccessToken))
+
+		page, _ := list("/api/v1/tickets?page_size=3&sort_field=title")
+		first, second, third := page.Tickets[0], page.Tickets[1], page.Tickets[2]
+		for _, ticket := range []models.Ticket{first, second, third, first} {
+			assert.Equal(t, http.StatusOK, get("/api/v1/tickets/"+ticket.ID.String(), tokens.AccessToken).Code)
+		}
+
+		tickets := recent("/api/v1/tickets/recent", tokens.AccessToken)
+		if assert.Len(t, tickets, 3) {
+			assert.Equal(t, []string{first.Title, third.Title, second.Title}, []string{tickets[0].Title, tickets[1].Title, tickets[2].Title}, "newest view first, without repeats")
+		}
+		assert.Len(t, recent("/api/v1/tickets/recent?limit=1", tokens.AccessToken), 1)
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/tickets/recent?limit=50", tokens.AccessToken).Code)
+
+		// Only the most recent views are kept
+		assert.NoError(t, ticketRepo.RecordView(ctx, &models.TicketView{UserID: auth.User.ID, TicketID: second.ID, ViewedAt: time.Now().UTC()}, 2))
+		tickets = recent("/api/v1/tickets/recent", tokens.AccessToken)
+		if assert.Len(t, tickets, 2) {
+			assert.Equal(t, second.ID, tickets[0].ID)
+			assert.Equal(t, first.ID, tickets[1].ID)
+		}
+
+		// Views are per user
+		_, otherTokens, err := authService.Register(&models.RegisterRequest{Email: "other-agent@example.com", Password: "password123", FirstName: "Other", LastName: "Agent", Role: models.RoleSupportAgent})
+		assert.NoError(t, err)
+		assert.Empty(t, recent("/api/v1/tickets/recent", otherTokens.AccessToken))
+	})
+
+	t.Run("Favorites", func(t *testing.T) {
+		send := func(method, path string) int {
+			req := httptest.NewRequest(method, path, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		none, _ := list("/api/v1/tickets?favorites=true")
+		assert.Empty(t, none.Tickets)
+
+		page, _ := list("/api/v1/tickets?page_size=2&sort_field=title")
+		for _, ticket := range page.Tickets {
+			assert.Equal(t, http.StatusNoContent, send(http.MethodPut, "/api/v1/tickets/"+ticket.ID.String()+"/pin"))
+		}
+		assert.Equal(t, http.StatusNoContent, send(http.MethodPut, "/api/v1/tickets/"+page.Tickets[0].ID.String()+"/pin"), "pinning twice is harmless")
+		assert.Equal(t, http.StatusNotFound, send(http.MethodPut, "/api/v1/tickets/00000000-0000-0000-0000-000000000001/pin"))
+
+		favorites, _ := list("/api/v1/tickets?favorites=true&sort_field=title")
+		if assert.Len(t, favorites.Tickets, 2) {
+			assert.Equal(t, page.Tickets[0].ID, favorites.Tickets[0].ID)
+			assert.Equal(t, page.Tickets[1].ID, favorites.Tickets[1].ID)
+		}
+
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/api/v1/tickets/"+page.Tickets[0].ID.String()+"/pin"))
+		favorites, _ = list("/api/v1/tickets?favorites=true")
+		if assert.Len(t, favorites.Tickets, 1) {
+			assert.Equal(t, page.Tickets[1].ID, favorites.Tickets[0].ID)
+		}
+		combined, _ := list("/api/v1/tickets?favorites=true&priority=CRITICAL")
+		assert.Empty(t, combined.Tickets, "favorites combine with other filters")
+
+		// Pins are per user
+		_, otherTokens, err := authService.Register(&models.RegisterRequest{Email: "pin-agent@example.com", Password: "password123", FirstName: "Pin", LastName: "Agent", Role: models.RoleSupportAgent})
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tickets?favorites=true", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: otherTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var other models.TicketListResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &other))
+		assert.Empty(t, other.Tickets)
+	})
+	t.Run("EnumValues", func(t *testing.T) {
+		send := func(method, path, body string) (int, []string) {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			var response models.ErrorResponse
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			return rec.Code, response.Messages
+		}
+
+		open, _ := list("/api/v1/tickets?status=OPEN&priority=LOW")
+		assert.NotEmpty(t, open.Tickets)
+		for _, ticket := range open.Tickets {
+			assert.Equal(t, models.StatusOpen, ticket.Status)
+		}
+
+		code, messages := send(http.MethodGet, "/api/v1/tickets?status=open", "")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{`status must be one of: OPEN, IN_PROGRESS, RESOLVED, CLOSED (got "open")`}, messages)
+
+		code, messages = send(http.MethodGet, "/api/v1/tickets?priority=URGENT", "")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{`priority must be one of: LOW, MEDIUM, HIGH, CRITICAL (got "URGENT")`}, messages)
+
+		code, messages = send(http.MethodPost, "/api/v1/tickets", `{"title":"Enum","description":"Bad priority","priority":"URGENT"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{`priority must be one of: LOW, MEDIUM, HIGH, CRITICAL (got "URGENT")`}, messages)
+
+		code, messages = send(http.MethodPost, "/api/v1/tickets/"+open.Tickets[0].ID.String()+"/status", `{"status":"DONE"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{`status must be one of: OPEN, IN_PROGRESS, RESOLVED, CLOSED (got "DONE")`}, messages)
+	})
+}
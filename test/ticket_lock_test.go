@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketLocks(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Tickets:  config.TicketsConfig{LockTTL: "300ms"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	handlers.NewTicketLockHandler(services.NewTicketLockService(cache.NewMemory(), ticketRepo, cfg)).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	alice, aliceTokens, err := authService.Register(&models.RegisterRequest{Email: "alice@example.com", Password: "password123", FirstName: "Alice", LastName: "Jones", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	_, bobTokens, err := authService.Register(&models.RegisterRequest{Email: "bob@example.com", Password: "password123", FirstName: "Bob", LastName: "Brown", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+	_, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Ada", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	ticket := &models.Ticket{Title: "Laptop won't boot", Description: "x", Status: models.StatusOpen, Priority: models.PriorityMedium, CreatedByID: alice.User.ID}
+	require.NoError(t, ticketRepo.Create(context.Background(), ticket))
+	path := "/api/v1/tickets/" + ticket.ID.String() + "/lock"
+
+	do := func(token, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	aliceToken := aliceTokens.AccessToken
+	bob := bobTokens.AccessToken
+
+	t.Run("WarnsOtherAgents", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodGet, path).Code)
+
+		rec := do(aliceToken, http.MethodPost, path)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var lock models.TicketLock
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lock))
+		assert.Equal(t, alice.User.ID, lock.HolderID)
+		assert.True(t, lock.ExpiresAt.After(time.Now()))
+
+		rec = do(bob, http.MethodGet, path)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Alice Jones")
+
+		rec = do(bob, http.MethodPost, path)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Alice Jones")
+		assert.Equal(t, http.StatusConflict, do(bob, http.MethodPost, path+"/heartbeat").Code)
+		assert.Equal(t, http.StatusForbidden, do(bob, http.MethodDelete, path).Code)
+	})
+
+	t.Run("HeartbeatKeepsLock", func(t *testing.T) {
+		for range 3 {
+			time.Sleep(150 * time.Millisecond)
+			require.Equal(t, http.StatusOK, do(aliceToken, http.MethodPost, path+"/heartbeat").Code)
+		}
+		assert.Equal(t, http.StatusConflict, do(bob, http.MethodPost, path).Code)
+	})
+
+	t.Run("ExpiresWithoutHeartbeat", func(t *testing.T) {
+		time.Sleep(350 * time.Millisecond)
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodGet, path).Code)
+		assert.Equal(t, http.StatusConflict, do(aliceToken, http.MethodPost, path+"/heartbeat").Code)
+		require.Equal(t, http.StatusOK, do(bob, http.MethodPost, path).Code)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(adminTokens.AccessToken, http.MethodDelete, path).Code)
+		require.Equal(t, http.StatusOK, do(aliceToken, http.MethodPost, path).Code)
+		assert.Equal(t, http.StatusNoContent, do(aliceToken, http.MethodDelete, path).Code)
+		assert.Equal(t, http.StatusNoContent, do(aliceToken, http.MethodDelete, path).Code)
+		assert.Equal(t, http.StatusNoContent, do(bob, http.MethodGet, path).Code)
+	})
+
+	t.Run("UnknownTicket", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(bob, http.MethodPost, "/api/v1/tickets/"+uuid.NewString()+"/lock").Code)
+	})
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenTicketQuota(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress: "support@helpchat.com",
+		},
+		Tickets: config.TicketsConfig{
+			MaxOpenPerRequester: 2,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, &recordingSender{}, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Redaction: redaction})
+	intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	endUser := newUser("requester@example.com", models.RoleEndUser)
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+	admin := newUser("admin@example.com", models.RoleAdministrator)
+
+	create := func(createdBy *models.User, onBehalfOf ...*models.User) (*models.Ticket, error) {
+		req := &models.CreateTicketRequest{Title: "Printer offline", Description: "The printer is offline", Priority: models.PriorityLow}
+		if len(onBehalfOf) > 0 {
+			req.RequesterID = &onBehalfOf[0].ID
+		}
+		return ticketService.CreateTicket(ctx, req, createdBy.ID)
+	}
+
+	var open []*models.Ticket
+	for range 2 {
+		ticket, err := create(endUser)
+		require.NoError(t, err)
+		open = append(open, ticket)
+	}
+
+	t.Run("EndUserRefusedAtQuota", func(t *testing.T) {
+		_, err := create(endUser)
+		assert.ErrorIs(t, err, services.ErrConflict)
+		assert.ErrorContains(t, err, "add any new details to one of them")
+	})
+
+	t.Run("GuestFormRefusedAtQuota", func(t *testing.T) {
+		_, err := intake.SubmitGuestTicket(ctx, &models.GuestTicketRequest{Name: "Test User", Email: endUser.Email, Title: "Printer", Description: "Still offline"}, "127.0.0.1")
+		assert.ErrorIs(t, err, services.ErrConflict)
+
+		// New guests have no tickets yet
+		ticket, err := intake.SubmitGuestTicket(ctx, &models.GuestTicketRequest{Name: "New Guest", Email: "guest@example.com", Title: "Printer", Description: "Offline"}, "127.0.0.1")
+		assert.NoError(t, err)
+		assert.NotNil(t, ticket)
+	})
+
+	t.Run("EmailIsNotRefused", func(t *testing.T) {
+		ticket, err := intake.IngestEmail(ctx, &models.InboundEmail{From: endUser.Email, Subject: "Printer", Text: "Still offline"})
+		assert.NoError(t, err)
+		if assert.NotNil(t, ticket) {
+			open = append(open, ticket)
+		}
+	})
+
+	t.Run("AgentsAreExempt", func(t *testing.T) {
+		for _, staff := range []*models.User{agent, admin} {
+			for i := range 3 {
+				_, err := create(staff)
+				assert.NoError(t, err, "%s ticket %d", staff.Role, i+1)
+			}
+		}
+		// Logging a ticket on an end user's behalf is the agent's call
+		_, err := create(agent, endUser)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ResolvingFreesASlot", func(t *testing.T) {
+		for _, ticket := range open {
+			require.NoError(t, ticketRepo.UpdateStatus(ctx, ticket.ID, models.StatusResolved, nil))
+		}
+		// The ticket the agent logged for them still counts
+		count, err := ticketRepo.CountOpenByRequester(ctx, endUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		_, err = create(endUser)
+		assert.NoError(t, err)
+		_, err = create(endUser)
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketSubscribers(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Email: config.EmailConfig{
+			FromAddress:       "support@helpchat.com",
+			ReplyDomain:       "reply.helpchat.com",
+			ReplySecretKey:    "test-reply-secret",
+			ReplyTokenTTL:     "24h",
+			MaxAttachmentSize: 1024,
+		},
+		Share: config.ShareConfig{
+			BaseURL: "https://help.example.com/",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	sender := &recordingSender{}
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	emailChannel := services.NewEmailChannelService(ticketRepo, commentRepo, attachmentRepo, userRepo, sender, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo, DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: emailChannel, Redaction: redaction})
+	intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+
+	e := echo.New()
+	ami := testMiddleware.NewAuthMiddleware(services.NewAuthService(userRepo, cfg), services.NewUsageService(cfg))
+	handlers.NewTicketSubscriberHandler(ticketService, emailChannel).RegisterRoutes(e, ami)
+
+	newUser := func(email string, role models.UserRole) *models.User {
+		user := &models.User{Email: email, PasswordHash: "x", FirstName: "Test", LastName: "User", Role: role, IsActive: true}
+		require.NoError(t, userRepo.Create(user))
+		return user
+	}
+	requester := newUser("requester@example.com", models.RoleEndUser)
+	agent := newUser("agent@example.com", models.RoleSupportAgent)
+	stranger := newUser("stranger@example.com", models.RoleEndUser)
+
+	ticket := &models.Ticket{Title: "Invoice portal down", Description: "Vendor cannot log in", Status: models.StatusOpen, Priority: models.PriorityMedium, CreatedByID: requester.ID, AssignedAgentID: &agent.ID}
+	require.NoError(t, ticketRepo.Create(ctx, ticket))
+
+	var vendor *models.TicketSubscriber
+	t.Run("RequesterCopiesAnAddress", func(t *testing.T) {
+		vendor, err = ticketService.AddSubscriber(ctx, ticket.ID, &models.AddTicketSubscriberRequest{Email: " Pat@Vendor.example ", Name: "Pat Jones"}, requester)
+		require.NoError(t, err)
+		assert.Equal(t, "pat@vendor.example", vendor.Email)
+
+		_, err = ticketService.AddSubscriber(ctx, ticket.ID, &models.AddTicketSubscriberRequest{Email: "pat@vendor.example"}, agent)
+		assert.ErrorIs(t, err, services.ErrConflict, "each address is copied once")
+		_, err = ticketService.AddSubscriber(ctx, ticket.ID, &models.AddTicketSubscriberRequest{Email: "REQUESTER@example.com"}, agent)
+		assert.ErrorIs(t, err, services.ErrConflict, "the requester is emailed already")
+		_, err = ticketService.AddSubscriber(ctx, ticket.ID, &models.AddTicketSubscriberRequest{Email: "spy@example.com"}, stranger)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+
+		subscribers, err := ticketService.ListSubscribers(ctx, ticket.ID, agent)
+		require.NoError(t, err)
+		require.Len(t, subscribers, 1)
+		assert.Equal(t, "pat@vendor.example", subscribers[0].Email, "the address is decrypted when read")
+		_, err = ticketService.ListSubscribers(ctx, ticket.ID, stranger)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+	})
+
+	t.Run("PublicCommentsAreEmailed", func(t *testing.T) {
+		sender.messages = nil
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "A fix is rolling out"}, agent)
+		require.NoError(t, err)
+
+		require.Len(t, sender.messages, 2)
+		assert.Equal(t, []string{requester.Email}, sender.messages[0].To)
+		assert.Empty(t, sender.messages[0].Headers["List-Unsubscribe"], "the requester has notification settings instead")
+
+		msg := sender.messages[1]
+		assert.Equal(t, []string{"pat@vendor.example"}, msg.To)
+		assert.True(t, strings.HasPrefix(msg.ReplyTo, "reply+"))
+		assert.Contains(t, msg.Text, "A fix is rolling out")
+		assert.Contains(t, msg.Text, "https://help.example.com/api/v1/public/unsubscribe/")
+		assert.True(t, strings.HasPrefix(msg.Headers["List-Unsubscribe"], "<https://help.example.com/api/v1/public/unsubscribe/"))
+		assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+
+		sender.messages = nil
+		_, err = ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "note", IsInternal: true}, agent)
+		require.NoError(t, err)
+		assert.Empty(t, sender.messages, "internal notes stay internal")
+	})
+
+	t.Run("SubscriberRepliesByEmail", func(t *testing.T) {
+		sender.messages = nil
+		comment, err := intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "Pat Jones <PAT@vendor.example>",
+			To:   []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text: "Works for us now.\n\nOn Tue, Support wrote:\n> A fix is rolling out",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Works for us now.", comment.Content)
+
+		author, err := userRepo.GetByEmail("pat@vendor.example")
+		require.NoError(t, err)
+		assert.Equal(t, author.ID, comment.UserID)
+		assert.Equal(t, models.RoleEndUser, author.Role)
+		assert.False(t, author.IsVerified, "the author is a guest account that cannot sign in")
+
+		// The requester hears about the reply; the subscriber is not sent their own reply
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, []string{requester.Email}, sender.messages[0].To)
+
+		_, err = intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: stranger.Email,
+			To:   []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text: "hijack",
+		})
+		assert.ErrorIs(t, err, services.ErrForbidden, "addresses not copied still cannot reply")
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		link := emailChannel.UnsubscribeURL(vendor.ID)
+		path := strings.TrimPrefix(link, "https://help.example.com")
+
+		for _, method := range []string{http.MethodPost, http.MethodGet} {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader("List-Unsubscribe=One-Click")))
+			assert.Equal(t, http.StatusOK, rec.Code, "%s: unsubscribing twice has no effect", method)
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"x", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		subscribers, err := ticketService.ListSubscribers(ctx, ticket.ID, requester)
+		require.NoError(t, err)
+		assert.Empty(t, subscribers)
+
+		sender.messages = nil
+		_, err = ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: "Closing this out"}, agent)
+		require.NoError(t, err)
+		assert.Len(t, sender.messages, 1, "only the requester is emailed")
+
+		_, err = intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "pat@vendor.example",
+			To:   []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text: "One more thing",
+		})
+		assert.ErrorIs(t, err, services.ErrForbidden, "an unsubscribed address can no longer reply")
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		subscriber, err := ticketService.AddSubscriber(ctx, ticket.ID, &models.AddTicketSubscriberRequest{Email: "lee@vendor.example"}, agent)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, ticketService.RemoveSubscriber(ctx, ticket.ID, subscriber.ID, stranger), services.ErrForbidden)
+		require.NoError(t, ticketService.RemoveSubscriber(ctx, ticket.ID, subscriber.ID, requester))
+		assert.ErrorIs(t, ticketService.RemoveSubscriber(ctx, ticket.ID, subscriber.ID, requester), services.ErrNotFound)
+	})
+}
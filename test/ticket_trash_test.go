@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketTrash(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Tickets:  config.TicketsConfig{TrashRetentionDays: 30},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	files := storage.NewLocalStorage(t.TempDir())
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), commentRepo, userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: attachmentRepo})
+	trashService := services.NewTicketTrashService(ticketRepo, files, cfg)
+
+	e := echo.New()
+	handlers.NewTicketTrashHandler(trashService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	admin, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: adminTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	listed := func() []uuid.UUID {
+		page, err := ticketService.ListTickets(ctx, &models.TicketQuery{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, ticket := range page.Tickets {
+			ids = append(ids, ticket.ID)
+		}
+		return ids
+	}
+	create := func(title string) *models.Ticket {
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: title, Description: "x", Priority: models.PriorityLow}, admin.User.ID)
+		require.NoError(t, err)
+		return ticket
+	}
+
+	t.Run("DeletedTicketsCanBeRestored", func(t *testing.T) {
+		ticket := create("Monitor flickers")
+		require.NoError(t, ticketService.DeleteTicket(ctx, ticket.ID, admin.User.ID))
+		assert.NotContains(t, listed(), ticket.ID)
+		_, err := ticketService.GetTicket(ctx, ticket.ID)
+		assert.Error(t, err)
+
+		rec := send(http.MethodGet, "/api/v1/admin/tickets/trash")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var trash models.TrashedTicketListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &trash))
+		require.Len(t, trash.Tickets, 1)
+		trashed := trash.Tickets[0]
+		assert.Equal(t, ticket.ID, trashed.ID)
+		if assert.NotNil(t, trashed.DeletedByID) && assert.NotNil(t, trashed.DeletedAt) && assert.NotNil(t, trashed.PurgeAt) {
+			assert.Equal(t, admin.User.ID, *trashed.DeletedByID)
+			assert.WithinDuration(t, trashed.DeletedAt.Add(30*24*time.Hour), *trashed.PurgeAt, time.Second)
+		}
+
+		rec = send(http.MethodPost, "/api/v1/admin/tickets/trash/"+ticket.ID.String()+"/restore")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, listed(), ticket.ID)
+		restored, err := ticketService.GetTicket(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Nil(t, restored.DeletedAt)
+
+		// Only tickets in the trash can be restored
+		assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/api/v1/admin/tickets/trash/"+ticket.ID.String()+"/restore").Code)
+	})
+
+	t.Run("PurgesAfterRetention", func(t *testing.T) {
+		stale, fresh := create("Old printer"), create("New printer")
+		require.NoError(t, commentRepo.Create(ctx, &models.Comment{TicketID: stale.ID, UserID: admin.User.ID, Content: "Toner replaced"}))
+		_, err := files.Save(ctx, "attachments/log.txt", strings.NewReader("log"))
+		require.NoError(t, err)
+		require.NoError(t, attachmentRepo.Create(ctx, &models.Attachment{TicketID: stale.ID, Filename: "log.txt", FilePath: "attachments/log.txt", FileSize: 3, MimeType: "text/plain", UploadedByID: admin.User.ID}))
+
+		require.NoError(t, ticketService.DeleteTicket(ctx, stale.ID, admin.User.ID))
+		require.NoError(t, ticketService.DeleteTicket(ctx, fresh.ID, admin.User.ID))
+		require.NoError(t, db.DB.Model(&models.Ticket{}).Where("id = ?", stale.ID).Update("deleted_at", time.Now().Add(-31*24*time.Hour)).Error)
+
+		require.NoError(t, trashService.PurgeExpired(ctx))
+
+		var count int64
+		require.NoError(t, db.DB.Model(&models.Ticket{}).Where("id = ?", stale.ID).Count(&count).Error)
+		assert.Zero(t, count, "the ticket is gone for good")
+		require.NoError(t, db.DB.Model(&models.Comment{}).Where("ticket_id = ?", stale.ID).Count(&count).Error)
+		assert.Zero(t, count, "with its comments")
+		require.NoError(t, db.DB.Model(&models.Attachment{}).Where("ticket_id = ?", stale.ID).Count(&count).Error)
+		assert.Zero(t, count, "and attachments")
+		_, err = files.Open(ctx, "attachments/log.txt")
+		assert.Error(t, err, "whose files are deleted")
+
+		trash, err := trashService.List(ctx, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, trash.Tickets, 1)
+		assert.Equal(t, fresh.ID, trash.Tickets[0].ID, "recently deleted tickets stay restorable")
+	})
+}
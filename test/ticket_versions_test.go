@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketVersionRestore(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(repository.NewTicketRepository(db), repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db)})
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	admin, adminTokens, err := authService.Register(&models.RegisterRequest{Email: "admin@example.com", Password: "password123", FirstName: "Test", LastName: "Admin", Role: models.RoleAdministrator})
+	require.NoError(t, err)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: adminTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	restore := func(ticketID uuid.UUID, body string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/api/v1/tickets/"+ticketID.String()+"/restore", body)
+	}
+
+	ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Keyboard", Description: "x", Priority: models.PriorityLow}, admin.User.ID)
+	require.NoError(t, err)
+	other, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Mouse", Description: "x", Priority: models.PriorityLow}, admin.User.ID)
+	require.NoError(t, err)
+	require.NoError(t, ticketService.DeleteTicket(ctx, other.ID, admin.User.ID))
+
+	t.Run("OnlyEarlierVersionsOfTheTicket", func(t *testing.T) {
+		_, err := ticketService.RestoreVersion(ctx, ticket.ID, ticket.VersionID, admin.User.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound, "the current version is not an earlier one")
+		_, err = ticketService.RestoreVersion(ctx, ticket.ID, other.VersionID, admin.User.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound, "versions of other tickets are refused")
+		_, err = ticketService.RestoreVersion(ctx, ticket.ID, uuid.New(), admin.User.ID)
+		assert.ErrorIs(t, err, services.ErrNotFound)
+
+		current, err := ticketService.GetTicket(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Keyboard", current.Title)
+	})
+
+	t.Run("Endpoint", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, restore(ticket.ID, `{}`).Code)
+		assert.Equal(t, http.StatusNotFound, restore(ticket.ID, `{"version_id":"`+other.VersionID.String()+`"}`).Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/api/v1/tickets/"+uuid.NewString()+"/versions", "").Code)
+	})
+
+	t.Run("UpdatesKeepTheTicketID", func(t *testing.T) {
+		title := "Keyboard missing keys"
+		updated, err := ticketService.UpdateTicket(ctx, ticket.ID, &models.UpdateTicketRequest{Title: &title}, admin.User.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ticket.ID, updated.ID)
+		assert.NotEqual(t, ticket.VersionID, updated.VersionID)
+
+		current, err := ticketService.GetTicket(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Equal(t, title, current.Title)
+
+		rec := send(http.MethodGet, "/api/v1/tickets/"+ticket.ID.String()+"/versions", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var versions []models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &versions))
+		require.Len(t, versions, 2)
+		assert.Equal(t, ticket.VersionID, versions[0].VersionID)
+		assert.NotNil(t, versions[0].ExpirationTime)
+		assert.Equal(t, updated.VersionID, versions[1].VersionID)
+		assert.Nil(t, versions[1].ExpirationTime)
+	})
+
+	t.Run("RestoresAnEarlierVersion", func(t *testing.T) {
+		rec := restore(ticket.ID, `{"version_id":"`+ticket.VersionID.String()+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var restored models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restored))
+		assert.Equal(t, ticket.ID, restored.ID)
+		assert.Equal(t, "Keyboard", restored.Title)
+
+		versions, err := ticketService.ListVersions(ctx, ticket.ID)
+		require.NoError(t, err)
+		assert.Len(t, versions, 3, "the replaced version stays in the history")
+
+		rec = send(http.MethodGet, "/api/v1/tickets/"+ticket.ID.String(), "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var current models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &current))
+		assert.Equal(t, restored.VersionID, current.VersionID, "the ticket is still found by its id")
+		assert.Equal(t, "Keyboard", current.Title)
+
+		page, err := ticketService.ListTickets(ctx, &models.TicketQuery{Page: 1, PageSize: 20})
+		require.NoError(t, err)
+		require.Len(t, page.Tickets, 1, "only the current version is listed")
+		assert.Equal(t, restored.VersionID, page.Tickets[0].VersionID)
+	})
+}
+
+func TestTicketVersionMigration(t *testing.T) {
+	db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{FilePath: ":memory:"}})
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A tickets table from before versions had their own ID
+	ticketID, userID := uuid.New(), uuid.New()
+	require.NoError(t, db.DB.Exec("CREATE TABLE tickets (`id` char(36), `creation_time` datetime NOT NULL, `expiration_time` datetime, `title` text NOT NULL, `description` text NOT NULL, `status` text NOT NULL, `priority` text NOT NULL, `created_by_id` char(36) NOT NULL, PRIMARY KEY (`id`))").Error)
+	require.NoError(t, db.DB.Exec("CREATE INDEX idx_tickets_expiration_time ON tickets(expiration_time)").Error)
+	require.NoError(t, db.DB.Exec("INSERT INTO tickets (id, creation_time, title, description, status, priority, created_by_id) VALUES (?, ?, 'Scanner offline', 'x', 'OPEN', 'LOW', ?)", ticketID, time.Now(), userID).Error)
+
+	require.NoError(t, database.RunMigrations(db))
+	require.NoError(t, database.RunMigrations(db), "migrations can run again")
+
+	ticket, err := repository.NewTicketRepository(db).GetByID(context.Background(), ticketID)
+	require.NoError(t, err)
+	assert.Equal(t, ticketID, ticket.ID)
+	assert.Equal(t, ticketID, ticket.VersionID)
+	assert.Equal(t, "Scanner offline", ticket.Title)
+
+	var matches int64
+	require.NoError(t, db.DB.Raw("SELECT COUNT(*) FROM tickets_fts WHERE tickets_fts MATCH 'scanner'").Scan(&matches).Error)
+	assert.Equal(t, int64(1), matches, "the search index is rebuilt")
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserTimezone(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	ticketRepo := repository.NewTicketRepository(db)
+	authService := services.NewAuthService(userRepo, cfg)
+	ticketService := services.NewTicketService(ticketRepo, repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db)})
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	e.JSONSerializer = testMiddleware.NewLocalTimeJSON(testMiddleware.NewProblemJSON())
+	ami := testMiddleware.NewAuthMiddleware(authService, nil)
+	handlers.NewTicketHandler(ticketService).RegisterRoutes(e, ami)
+	handlers.NewUserHandler(services.NewUserService(userRepo), nil, nil, nil).RegisterRoutes(e, ami)
+
+	agent, tokens, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Test", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		var data bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&data).Encode(body))
+		}
+		req := httptest.NewRequest(method, path, &data)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	t.Run("RejectsUnknownTimezone", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/api/v1/users/me/timezone", models.TimezoneRequest{Timezone: "Mars/Olympus"}).Code)
+	})
+
+	t.Run("SetsTimezone", func(t *testing.T) {
+		rec := do(http.MethodPut, "/api/v1/users/me/timezone", models.TimezoneRequest{Timezone: "Asia/Tokyo"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		user, err := userRepo.GetByID(agent.User.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Tokyo", user.Timezone)
+	})
+
+	t.Run("DateOnlyDueDateEndsTheLocalDay", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/tickets", map[string]any{"title": "Renew licence", "description": "x", "priority": "LOW", "due_date": "2030-03-31"})
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var created models.Ticket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		require.NotNil(t, created.DueDate)
+		assert.True(t, time.Date(2030, 3, 31, 23, 59, 59, 0, tokyo).Equal(*created.DueDate))
+
+		// Timestamps come back in the user's time zone, with its offset
+		assert.Contains(t, rec.Body.String(), `"due_date":"2030-03-31T23:59:59+09:00"`)
+	})
+
+	t.Run("TimestampsKeepTheirOffset", func(t *testing.T) {
+		rec := do(http.MethodPost, "/api/v1/tickets", map[string]any{"title": "Call back", "description": "x", "priority": "LOW", "due_date": "2030-04-01T09:00:00-04:00"})
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `"due_date":"2030-04-01T22:00:00+09:00"`)
+
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/v1/tickets", map[string]any{"title": "x", "description": "x", "priority": "LOW", "due_date": "31/03/2026"}).Code)
+	})
+
+	t.Run("FiltersByLocalDates", func(t *testing.T) {
+		// Just after midnight in Tokyo is still the previous day in UTC
+		early := time.Date(2026, 2, 10, 0, 30, 0, 0, tokyo)
+		require.NoError(t, ticketRepo.Create(ctx, &models.Ticket{Title: "Early", Description: "x", Status: models.StatusOpen, Priority: models.PriorityLow, CreatedByID: agent.User.ID, CreationTime: early.UTC()}))
+
+		list := func(query string) []models.Ticket {
+			rec := do(http.MethodGet, "/api/v1/tickets?"+query, nil)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var response models.TicketListResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			return response.Tickets
+		}
+		tickets := list("date_from=2026-02-10&date_to=2026-02-10")
+		require.Len(t, tickets, 1)
+		assert.Equal(t, "Early", tickets[0].Title)
+		assert.Empty(t, list("date_from=2026-02-09&date_to=2026-02-09"))
+		assert.Len(t, list("date_from=2026-02-09T15:00:00Z&date_to=2026-02-09T16:00:00Z"), 1)
+
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/v1/tickets?date_from=yesterday", nil).Code)
+	})
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDailyQuota(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		JWT: config.JWTConfig{
+			SecretKey:       "test-secret-key",
+			AccessTokenTTL:  "15m",
+			RefreshTokenTTL: "7d",
+			Issuer:          "test",
+		},
+		RateLimit: config.RateLimitConfig{
+			UserDailyQuota: 3,
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	authService := services.NewAuthService(repository.NewUserRepository(db), cfg)
+	newServer := func(usageService *services.UsageService) *echo.Echo {
+		e := echo.New()
+		handlers.NewUserHandler(nil, nil, nil, usageService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, usageService))
+		return e
+	}
+	usage := func(e *echo.Echo, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/usage", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	_, tokens, err := authService.Register(&models.RegisterRequest{Email: "integrator@example.com", Password: "password123", FirstName: "Test", LastName: "Integrator"})
+	assert.NoError(t, err)
+	_, otherTokens, err := authService.Register(&models.RegisterRequest{Email: "other@example.com", Password: "password123", FirstName: "Other", LastName: "User"})
+	assert.NoError(t, err)
+
+	t.Run("Quota", func(t *testing.T) {
+		e := newServer(services.NewUsageService(cfg))
+		tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+		for i := 1; i <= 3; i++ {
+			rec := usage(e, tokens.AccessToken)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "3", rec.Header().Get("X-RateLimit-Limit"))
+			assert.Equal(t, strconv.Itoa(3-i), rec.Header().Get("X-RateLimit-Remaining"))
+			assert.Equal(t, strconv.FormatInt(tomorrow.Unix(), 10), rec.Header().Get("X-RateLimit-Reset"))
+
+			var report models.APIUsage
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, int64(i), report.Used)
+			if assert.NotNil(t, report.Remaining) {
+				assert.Equal(t, int64(3-i), *report.Remaining)
+			}
+			assert.True(t, tomorrow.Equal(report.ResetsAt))
+		}
+
+		rec := usage(e, tokens.AccessToken)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+		// Each user has their own quota
+		rec = usage(e, otherTokens.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Remaining"))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		unlimited := *cfg
+		unlimited.RateLimit.UserDailyQuota = 0
+		e := newServer(services.NewUsageService(&unlimited))
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, usage(e, tokens.AccessToken).Code)
+		}
+		rec := usage(e, tokens.AccessToken)
+		assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+		var report models.APIUsage
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, int64(6), report.Used)
+		assert.Nil(t, report.Limit)
+		assert.Nil(t, report.Remaining)
+	})
+}
@@ -0,0 +1,314 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/handlers"
+	testMiddleware "dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/middleware"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records the requests posted to it, failing while failing is set
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failing  bool
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	if r.failing {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}
+}
+
+func (r *webhookReceiver) setFailing(failing bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failing = failing
+}
+
+// take returns and forgets the requests received so far
+func (r *webhookReceiver) take() ([]*http.Request, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests, bodies := r.requests, r.bodies
+	r.requests, r.bodies = nil, nil
+	return requests, bodies
+}
+
+func TestWebhooks(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{FilePath: ":memory:"},
+		JWT:      config.JWTConfig{SecretKey: "test-secret-key", AccessTokenTTL: "15m", RefreshTokenTTL: "7d", Issuer: "test"},
+		Email:    config.EmailConfig{FromAddress: "support@helpchat.com", ReplyDomain: "reply.helpchat.com", ReplySecretKey: "test-reply-secret", ReplyTokenTTL: "24h", MaxAttachmentSize: 1024},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	redaction, err := services.NewRedactionService(cfg, services.NewAuditService(repository.NewAuditLogRepository(db), nil))
+	require.NoError(t, err)
+	webhookService := services.NewWebhookService(repository.NewWebhookRepository(db))
+	ticketService := services.NewTicketService(repository.NewTicketRepository(db), repository.NewCategoryRepository(db), repository.NewCommentRepository(db), userRepo, repository.NewTeamRepository(db), cfg, services.TicketServiceDeps{AttachmentRepo: repository.NewAttachmentRepository(db), DraftRepo: repository.NewDraftAttachmentRepository(db), Notifier: webhookService.Notifier(nil), Redaction: redaction})
+	authService := services.NewAuthService(userRepo, cfg)
+
+	e := echo.New()
+	e.Validator = testMiddleware.NewCustomValidator()
+	e.Binder = testMiddleware.NewBinder()
+	handlers.NewWebhookHandler(webhookService).RegisterRoutes(e, testMiddleware.NewAuthMiddleware(authService, nil))
+
+	_, managerTokens, err := authService.Register(&models.RegisterRequest{Email: "manager@example.com", Password: "password123", FirstName: "Maria", LastName: "Lopez", Role: models.RoleManager})
+	require.NoError(t, err)
+	agent, _, err := authService.Register(&models.RegisterRequest{Email: "agent@example.com", Password: "password123", FirstName: "Alex", LastName: "Agent", Role: models.RoleSupportAgent})
+	require.NoError(t, err)
+
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "token", Value: managerTokens.AccessToken})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	subscribe := func(events string) *models.WebhookSecretResponse {
+		rec := admin(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"`+server.URL+`/hooks","events":[`+events+`]}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created models.WebhookSecretResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		return &created
+	}
+	deliveries := func(subscription *models.WebhookSubscription, query string) *models.WebhookDeliveryListResponse {
+		rec := admin(http.MethodGet, "/api/v1/admin/webhooks/"+subscription.ID.String()+"/deliveries"+query, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var page models.WebhookDeliveryListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		return &page
+	}
+	comment := func(ticket *models.Ticket, content string) {
+		_, err := ticketService.AddComment(ctx, ticket.ID, &models.CreateCommentRequest{Content: content}, agent.User)
+		require.NoError(t, err)
+	}
+
+	all := subscribe(`"ticket.created","ticket.status_changed","comment.added","comment.added"`)
+	require.NotEmpty(t, all.Secret)
+	assert.True(t, all.Subscription.Enabled)
+	assert.Equal(t, []string{"comment.added", "ticket.created", "ticket.status_changed"}, all.Subscription.Events)
+
+	t.Run("Management", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"ftp://example.com/hooks","events":["ticket.created"]}`).Code)
+		assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"https://example.com/hooks","events":["ticket.deleted"]}`).Code)
+		assert.Equal(t, http.StatusBadRequest, admin(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"https://example.com/hooks","events":[]}`).Code)
+
+		rec := admin(http.MethodGet, "/api/v1/admin/webhooks", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), all.Secret, "secrets are only shown when generated")
+		assert.NotContains(t, rec.Body.String(), `"secret"`)
+
+		assert.Equal(t, http.StatusNotFound, admin(http.MethodGet, "/api/v1/admin/webhooks/00000000-0000-0000-0000-000000000000/deliveries", "").Code)
+	})
+
+	t.Run("DeliversSignedEvents", func(t *testing.T) {
+		comments := subscribe(`"comment.added"`)
+
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "VPN drops", Description: "Every hour", Priority: models.PriorityHigh}, agent.User.ID)
+		require.NoError(t, err)
+		comment(ticket, "Looking into it")
+		// The agent changing the status of their own ticket leaves no one to email
+		require.NoError(t, ticketService.UpdateTicketStatus(ctx, ticket.ID, &models.UpdateTicketStatusRequest{Status: models.StatusInProgress}, agent.User.ID))
+
+		delivered, err := webhookService.DeliverDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 4, delivered)
+
+		requests, bodies := receiver.take()
+		require.Len(t, requests, 4)
+		secrets := map[string]string{all.Subscription.ID.String(): all.Secret, comments.Subscription.ID.String(): comments.Secret}
+		events := map[string][]string{}
+		for i, req := range requests {
+			assert.Equal(t, "/hooks", req.URL.Path)
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+			var payload models.WebhookPayload
+			require.NoError(t, json.Unmarshal([]byte(bodies[i]), &payload))
+			assert.Equal(t, req.Header.Get("X-Webhook-Event"), payload.Event)
+			assert.Equal(t, req.Header.Get("X-Webhook-Delivery"), payload.ID.String())
+			assert.Equal(t, ticket.ID, payload.Data.Ticket.ID)
+			assert.Equal(t, agent.User.ID, payload.Data.ActorID)
+
+			// Each subscription signs with its own secret
+			var signedBy string
+			for id, secret := range secrets {
+				if services.WebhookSignature(secret, []byte(bodies[i])) == req.Header.Get("X-Signature-256") {
+					signedBy = id
+				}
+			}
+			require.NotEmpty(t, signedBy, "the payload is signed")
+			events[signedBy] = append(events[signedBy], payload.Event)
+
+			switch payload.Event {
+			case models.WebhookEventCommentAdded:
+				require.NotNil(t, payload.Data.Comment)
+				assert.Equal(t, "Looking into it", payload.Data.Comment.Content)
+			case models.WebhookEventTicketStatusChanged:
+				assert.Equal(t, models.StatusOpen, payload.Data.PreviousStatus)
+				assert.Equal(t, models.StatusInProgress, payload.Data.Ticket.Status)
+			}
+		}
+		assert.ElementsMatch(t, []string{"ticket.created", "comment.added", "ticket.status_changed"}, events[all.Subscription.ID.String()])
+		assert.Equal(t, []string{"comment.added"}, events[comments.Subscription.ID.String()])
+
+		page := deliveries(all.Subscription, "?status=DELIVERED")
+		assert.Equal(t, int64(3), page.Total)
+		assert.Equal(t, 1, page.Deliveries[0].Attempts)
+		assert.Equal(t, http.StatusOK, page.Deliveries[0].ResponseStatus)
+		assert.NotNil(t, page.Deliveries[0].DeliveredAt)
+
+		require.Equal(t, http.StatusNoContent, admin(http.MethodDelete, "/api/v1/admin/webhooks/"+comments.Subscription.ID.String(), "").Code)
+	})
+
+	t.Run("RetriesWithBackoff", func(t *testing.T) {
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Printer offline", Description: "Third floor", Priority: models.PriorityLow}, agent.User.ID)
+		require.NoError(t, err)
+		receiver.setFailing(true)
+		now := time.Now()
+
+		delivered, err := webhookService.DeliverDue(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		pending := deliveries(all.Subscription, "?status=PENDING")
+		require.Equal(t, int64(1), pending.Total)
+		retry := pending.Deliveries[0]
+		assert.Equal(t, 1, retry.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, retry.ResponseStatus)
+		assert.Contains(t, retry.LastError, "unavailable")
+		require.NotNil(t, retry.NextAttemptAt)
+		assert.WithinDuration(t, now.Add(30*time.Second), *retry.NextAttemptAt, time.Second)
+
+		// Retries wait twice as long each time
+		for attempt := 1; attempt < 3; attempt++ {
+			now = now.Add(time.Duration(30<<(attempt-1)) * time.Second)
+			_, err := webhookService.DeliverDue(ctx, now.Add(-time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, attempt, deliveries(all.Subscription, "?status=PENDING").Deliveries[0].Attempts, "not due yet")
+			_, err = webhookService.DeliverDue(ctx, now)
+			require.NoError(t, err)
+		}
+		_, bodies := receiver.take()
+		assert.Len(t, bodies, 3)
+
+		receiver.setFailing(false)
+		delivered, err = webhookService.DeliverDue(ctx, now.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		_, bodies = receiver.take()
+		require.Len(t, bodies, 1)
+		assert.Contains(t, bodies[0], retry.ID.String(), "retries post the same delivery")
+		assert.Contains(t, bodies[0], ticket.ID.String())
+
+		// Deliveries fail once they run out of attempts
+		receiver.setFailing(true)
+		comment(ticket, "Still offline")
+		for range 10 {
+			now = now.Add(2 * time.Hour)
+			_, err := webhookService.DeliverDue(ctx, now)
+			require.NoError(t, err)
+		}
+		failed := deliveries(all.Subscription, "?status=FAILED")
+		require.Equal(t, int64(1), failed.Total)
+		assert.Equal(t, 8, failed.Deliveries[0].Attempts)
+		assert.Nil(t, failed.Deliveries[0].NextAttemptAt)
+		receiver.setFailing(false)
+		receiver.take()
+	})
+
+	t.Run("DisabledAndRotated", func(t *testing.T) {
+		ticket, err := ticketService.CreateTicket(ctx, &models.CreateTicketRequest{Title: "Laptop slow", Description: "Since the update", Priority: models.PriorityLow}, agent.User.ID)
+		require.NoError(t, err)
+
+		path := "/api/v1/admin/webhooks/" + all.Subscription.ID.String()
+		require.Equal(t, http.StatusOK, admin(http.MethodPut, path, `{"url":"`+server.URL+`/hooks","events":["comment.added"],"enabled":false}`).Code)
+		delivered, err := webhookService.DeliverDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		requests, _ := receiver.take()
+		assert.Empty(t, requests, "pending deliveries of disabled subscriptions are not sent")
+		assert.Contains(t, deliveries(all.Subscription, "?status=FAILED").Deliveries[0].LastError, "disabled")
+
+		comment(ticket, "Ignored while disabled")
+		assert.Zero(t, deliveries(all.Subscription, "?status=PENDING").Total)
+
+		rec := admin(http.MethodPost, path+"/secret", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var rotated models.WebhookSecretResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rotated))
+		assert.NotEqual(t, all.Secret, rotated.Secret)
+
+		require.Equal(t, http.StatusOK, admin(http.MethodPut, path, `{"url":"`+server.URL+`/hooks","events":["comment.added"],"enabled":true}`).Code)
+		comment(ticket, "Back on")
+		_, err = webhookService.DeliverDue(ctx, time.Now())
+		require.NoError(t, err)
+		requests, bodies := receiver.take()
+		require.Len(t, requests, 1)
+		assert.Equal(t, services.WebhookSignature(rotated.Secret, []byte(bodies[0])), requests[0].Header.Get("X-Signature-256"))
+	})
+
+	t.Run("IntakeTickets", func(t *testing.T) {
+		ticketRepo := repository.NewTicketRepository(db)
+		emailChannel := services.NewEmailChannelService(ticketRepo, repository.NewCommentRepository(db), repository.NewAttachmentRepository(db), userRepo, &recordingSender{}, storage.NewLocalStorage(t.TempDir()), redaction, nil, nil, nil, cfg)
+		intake := services.NewIntakeService(ticketRepo, repository.NewCategoryRepository(db), userRepo, repository.NewQuarantineRepository(db), services.NewSpamFilter(cfg), emailChannel, ticketService)
+		intakeHooks := subscribe(`"ticket.created","comment.added"`)
+
+		ticket, err := intake.IngestEmail(ctx, &models.InboundEmail{From: "Gus Guest <gus@example.com>", Subject: "Monitor flickers", Text: "Since this morning"})
+		require.NoError(t, err)
+		require.NotNil(t, ticket)
+		_, err = intake.ReceiveReply(ctx, &models.InboundEmail{
+			From: "gus@example.com",
+			To:   []string{emailChannel.ReplyAddress(ticket.ID)},
+			Text: "Still flickering",
+		})
+		require.NoError(t, err)
+
+		page := deliveries(intakeHooks.Subscription, "")
+		require.Equal(t, int64(2), page.Total)
+		events := []string{}
+		for _, delivery := range page.Deliveries {
+			var payload models.WebhookPayload
+			require.NoError(t, json.Unmarshal([]byte(delivery.Payload), &payload))
+			assert.Equal(t, ticket.ID, payload.Data.Ticket.ID)
+			assert.Equal(t, ticket.CreatedByID, payload.Data.ActorID, "the requester is the actor")
+			events = append(events, delivery.Event)
+		}
+		assert.ElementsMatch(t, []string{"ticket.created", "comment.added"}, events)
+	})
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/config"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/models"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/repository"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/internal/services"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/cache"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/database"
+	"dev.azure.com/clearpointhealth/ClearQuoteV3/_git/helpchat/pkg/officehours"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfficeHours(t *testing.T) {
+	schedule, err := officehours.Parse("mon-fri 09:00-17:00; sat 10:00-14:00", "UTC")
+	assert.NoError(t, err)
+	assert.Len(t, schedule.Windows, 6)
+
+	// 2025-01-06 is a Monday
+	monday := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	assert.False(t, schedule.IsOpen(monday.Add(8*time.Hour+59*time.Minute)))
+	assert.True(t, schedule.IsOpen(monday.Add(9*time.Hour)))
+	assert.False(t, schedule.IsOpen(monday.Add(17*time.Hour)), "closing time is exclusive")
+	assert.True(t, schedule.IsOpen(monday.AddDate(0, 0, 5).Add(11*time.Hour)), "saturday")
+	assert.False(t, schedule.IsOpen(monday.AddDate(0, 0, 6).Add(11*time.Hour)), "sunday")
+
+	assert.Equal(t, monday.Add(9*time.Hour), schedule.NextOpen(monday.Add(2*time.Hour)))
+	assert.Equal(t, monday.AddDate(0, 0, 5).Add(10*time.Hour), schedule.NextOpen(monday.AddDate(0, 0, 4).Add(18*time.Hour)), "friday evening opens saturday")
+	assert.Equal(t, monday.AddDate(0, 0, 7).Add(9*time.Hour), schedule.NextOpen(monday.AddDate(0, 0, 5).Add(15*time.Hour)), "saturday afternoon opens monday")
+
+	t.Run("TimeZone", func(t *testing.T) {
+		schedule, err := officehours.Parse("mon-fri 09:00-17:00", "America/New_York")
+		assert.NoError(t, err)
+		assert.False(t, schedule.IsOpen(monday.Add(10*time.Hour)), "10:00 UTC is 05:00 in New York")
+		assert.True(t, schedule.IsOpen(monday.Add(15*time.Hour)))
+	})
+
+	t.Run("WrappingDayRange", func(t *testing.T) {
+		schedule, err := officehours.Parse("sat-sun 00:00-24:00", "UTC")
+		assert.NoError(t, err)
+		assert.Len(t, schedule.Windows, 2)
+		assert.True(t, schedule.IsOpen(monday.AddDate(0, 0, 6).Add(23*time.Hour+59*time.Minute)))
+	})
+
+	t.Run("EmptyIsAlwaysOpen", func(t *testing.T) {
+		schedule, err := officehours.Parse("", "")
+		assert.NoError(t, err)
+		assert.True(t, schedule.AlwaysOpen())
+		assert.True(t, schedule.IsOpen(monday.Add(3*time.Hour)))
+	})
+
+	t.Run("InvalidSpecs", func(t *testing.T) {
+		for _, spec := range []string{"mon 09:00", "funday 09:00-17:00", "mon 17:00-09:00", "mon 9am-5pm"} {
+			_, err := officehours.Parse(spec, "UTC")
+			assert.Error(t, err, spec)
+		}
+		_, err := officehours.Parse("mon 09:00-17:00", "Mars/Olympus")
+		assert.Error(t, err)
+	})
+}
+
+func TestWidgetConfig(t *testing.T) {
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			FilePath: ":memory:",
+		},
+		Widget: config.WidgetConfig{
+			Title:        "Acme Help",
+			Greeting:     "Hello",
+			PrimaryColor: "#ff0000",
+			OfficeHours:  "mon-fri 09:00-17:00",
+			Timezone:     "UTC",
+		},
+	}
+
+	db, err := database.NewDatabase(cfg)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, database.RunMigrations(db))
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	outOfOfficeRepo := repository.NewOutOfOfficeRepository(db)
+	presence := services.NewPresenceService(cache.NewMemory(), userRepo, cfg)
+	widgetService, err := services.NewWidgetService(userRepo, repository.NewChatTriggerRepository(db), presence, cfg)
+	assert.NoError(t, err)
+
+	agent := &models.User{Email: "agent@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "One", Role: models.RoleSupportAgent, IsActive: true}
+	awayAgent := &models.User{Email: "away@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "Two", Role: models.RoleSupportAgent, IsActive: true}
+	requester := &models.User{Email: "requester@example.com", PasswordHash: "x", FirstName: "Req", LastName: "User", Role: models.RoleEndUser, IsActive: true}
+	offlineAgent := &models.User{Email: "offline@example.com", PasswordHash: "x", FirstName: "Agent", LastName: "Three", Role: models.RoleSupportAgent, IsActive: true}
+	for _, user := range []*models.User{agent, awayAgent, requester, offlineAgent} {
+		assert.NoError(t, userRepo.Create(user))
+	}
+	presence.Connect(agent.ID)
+	presence.Connect(awayAgent.ID)
+
+	// 2025-01-06 is a Monday
+	open := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, outOfOfficeRepo.Upsert(ctx, &models.OutOfOffice{UserID: awayAgent.ID, DelegateID: agent.ID, StartsAt: open.Add(-time.Hour), EndsAt: open.Add(time.Hour)}))
+
+	t.Run("OpenWithAgents", func(t *testing.T) {
+		widget, err := widgetService.GetConfig(ctx, open, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "Acme Help", widget.Branding.Title)
+		assert.Equal(t, "#ff0000", widget.Branding.PrimaryColor)
+		assert.True(t, widget.OfficeHours.OpenNow)
+		assert.Nil(t, widget.OfficeHours.NextOpenAt)
+		if assert.Len(t, widget.OfficeHours.Windows, 5) {
+			assert.Equal(t, models.OfficeHoursWindow{Day: "monday", Open: "09:00", Close: "17:00"}, widget.OfficeHours.Windows[0])
+		}
+		assert.True(t, widget.Queue.Available)
+		assert.EqualValues(t, 1, widget.Queue.AgentsAvailable, "out-of-office and offline agents are not available")
+	})
+
+	t.Run("ClosedOutsideOfficeHours", func(t *testing.T) {
+		widget, err := widgetService.GetConfig(ctx, open.Add(10*time.Hour), nil)
+		assert.NoError(t, err)
+		assert.False(t, widget.OfficeHours.OpenNow)
+		if assert.NotNil(t, widget.OfficeHours.NextOpenAt) {
+			assert.True(t, widget.OfficeHours.NextOpenAt.Equal(open.AddDate(0, 0, 1).Add(-time.Hour)))
+		}
+		assert.False(t, widget.Queue.Available)
+		assert.EqualValues(t, 2, widget.Queue.AgentsAvailable)
+	})
+
+	t.Run("ProactiveTriggers", func(t *testing.T) {
+		disabled := false
+		_, err := widgetService.CreateTrigger(ctx, &models.ChatTriggerRequest{Name: "Everywhere", URLPattern: "*", Greeting: "Hi there", Enabled: &disabled}, agent.ID)
+		assert.NoError(t, err)
+		pricing, err := widgetService.CreateTrigger(ctx, &models.ChatTriggerRequest{Name: "Pricing", URLPattern: "/pricing*", MinSecondsOnPage: 30, Greeting: "Questions about {{ page_title }}? {{site_title}} can help.", Priority: 10}, agent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.TriggerAnyVisitor, pricing.VisitorType)
+		welcomeBack, err := widgetService.CreateTrigger(ctx, &models.ChatTriggerRequest{Name: "Welcome back", URLPattern: "https://shop.example.com/*", VisitorType: models.TriggerReturningVisitor, Greeting: "Welcome back!"}, agent.ID)
+		assert.NoError(t, err)
+
+		_, err = widgetService.CreateTrigger(ctx, &models.ChatTriggerRequest{Name: "Bad", URLPattern: "*", Greeting: "Hi {{visitor_name}}"}, agent.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidInput, "unknown placeholders are rejected")
+
+		widget, err := widgetService.GetConfig(ctx, open, &models.WidgetVisit{PageURL: "https://www.example.com/pricing/teams?ref=ad", PageTitle: "Team plans"})
+		assert.NoError(t, err)
+		if assert.NotNil(t, widget.Proactive) {
+			assert.Equal(t, pricing.ID, widget.Proactive.TriggerID)
+			assert.Equal(t, 30, widget.Proactive.DelaySeconds)
+			assert.Equal(t, "Questions about Team plans? Acme Help can help.", widget.Proactive.Greeting)
+		}
+
+		visit := &models.WidgetVisit{PageURL: "https://shop.example.com/cart"}
+		widget, err = widgetService.GetConfig(ctx, open, visit)
+		assert.NoError(t, err)
+		assert.Nil(t, widget.Proactive, "disabled triggers and other visitor types do not fire")
+		visit.Returning = true
+		widget, err = widgetService.GetConfig(ctx, open, visit)
+		assert.NoError(t, err)
+		if assert.NotNil(t, widget.Proactive) {
+			assert.Equal(t, welcomeBack.ID, widget.Proactive.TriggerID)
+		}
+
+		// Lowering the priority lets the full URL trigger win on a shop pricing page
+		_, err = widgetService.UpdateTrigger(ctx, pricing.ID, &models.ChatTriggerRequest{Name: "Pricing", URLPattern: "/pricing*", Greeting: "Pricing help", Priority: -1})
+		assert.NoError(t, err)
+		widget, err = widgetService.GetConfig(ctx, open, &models.WidgetVisit{PageURL: "https://shop.example.com/pricing", Returning: true})
+		assert.NoError(t, err)
+		if assert.NotNil(t, widget.Proactive) {
+			assert.Equal(t, welcomeBack.ID, widget.Proactive.TriggerID)
+		}
+
+		assert.NoError(t, widgetService.DeleteTrigger(ctx, welcomeBack.ID))
+		assert.ErrorIs(t, widgetService.DeleteTrigger(ctx, welcomeBack.ID), services.ErrNotFound)
+		triggers, err := widgetService.ListTriggers(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, triggers, 2)
+	})
+
+	t.Run("InvalidOfficeHoursRejected", func(t *testing.T) {
+		_, err := services.NewWidgetService(userRepo, repository.NewChatTriggerRepository(db), presence, &config.Config{Widget: config.WidgetConfig{OfficeHours: "weekdays"}})
+		assert.Error(t, err)
+	})
+}